@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/tempfiles"
+)
+
+// cleanCmd removes temp files left behind by crashed or killed runs
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove temp files left behind by crashed or killed runs",
+	Long: `Remove the temp files of runs that are no longer running, e.g. after a
+crash or kill. Each run keeps its converted audio and chunks in its own
+directory under <temp-dir>/gollmscribe-jobs, which is removed when the run
+ends; transcribe and watch also sweep the directories of dead runs when
+they start. Directories of running jobs are never removed, and ones kept
+with --preserve-audio only with --kept.
+
+Resumable checkpoints (see gollmscribe jobs) are not touched.
+
+Examples:
+  # See what would be removed and how much space the temp files use
+  gollmscribe clean --dry-run
+
+  # Also remove audio kept with --preserve-audio
+  gollmscribe clean --kept`,
+	Args: cobra.NoArgs,
+	RunE: runClean,
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().Bool("dry-run", false, "only report what would be removed")
+	cleanCmd.Flags().Bool("kept", false, "also remove temp files kept with --preserve-audio")
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	manager := tempfiles.New(cfg.Audio.TempDir, int64(cfg.Audio.MaxTempMB)<<20)
+
+	var options tempfiles.SweepOptions
+	options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+	options.Kept, _ = cmd.Flags().GetBool("kept")
+
+	swept, sweepErr := manager.Sweep(options)
+	verb := "Removed"
+	if options.DryRun {
+		verb = "Would remove"
+	}
+	var freed int64
+	for _, job := range swept {
+		freed += job.Size
+		fmt.Printf("✓ %s %s (%s)\n", verb, job.Path, humanize.Size(job.Size))
+	}
+
+	jobs, err := manager.List()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Alive && !job.Legacy {
+			fmt.Printf("• Running job %s (pid %d, %s)\n", job.ID, job.PID, humanize.Size(job.Size))
+		} else if job.Kept && !options.Kept {
+			fmt.Printf("• Kept job %s (%s), remove with --kept\n", job.ID, humanize.Size(job.Size))
+		}
+	}
+
+	fmt.Printf("\n%s %d temp director(ies), %s", verb, len(swept), humanize.Size(freed))
+	if used, err := manager.Usage(); err == nil {
+		fmt.Printf("; %s in use", humanize.Size(used))
+		if quota := manager.Quota(); quota > 0 {
+			fmt.Printf(" of the %s quota", humanize.Size(quota))
+		}
+	}
+	fmt.Println()
+	return sweepErr
+}
+
+// sweepTempFiles removes the temp directories of runs that died, logging
+// what was freed. It never fails a run.
+func sweepTempFiles(manager *tempfiles.Manager) {
+	log := logger.WithComponent("tempfiles")
+	swept, err := manager.Sweep(tempfiles.SweepOptions{})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to remove temp files of earlier runs")
+	}
+	var freed int64
+	for _, job := range swept {
+		freed += job.Size
+	}
+	if len(swept) > 0 {
+		log.Info().Int("directories", len(swept)).Str("freed", humanize.Size(freed)).
+			Msg("Removed temp files left behind by earlier runs")
+	}
+}
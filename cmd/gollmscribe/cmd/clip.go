@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/clips"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// clipCmd represents the clip command
+var clipCmd = &cobra.Command{
+	Use:   "clip [recording]",
+	Short: "Cut moments selected in a transcript out of a recording",
+	Long: `Cut clips out of a recording, e.g. for a highlight reel, and name
+each after what is said in it: 01-we-ship-the-beta-in-march.mp4.
+
+--from-segments reads a JSON array of segment indices of the transcript,
+counted from 0, where consecutive indices make one clip ([4, 5, 12]).
+It also takes clips with start and end, such as the output of
+"quotes --json". --range cuts a time range instead and can be repeated.
+
+The transcript is the JSON result next to the recording (meeting.json
+for meeting.mp4) unless --transcript is given. Clips keep the format of
+the recording and need ffmpeg.
+
+Examples:
+  # Clips of the selected segments
+  gollmscribe clip meeting.mp4 --from-segments ids.json
+
+  # Every quote about the budget
+  gollmscribe quotes meeting.json -q budget --json > budget.json
+  gollmscribe clip meeting.mp4 --from-segments budget.json
+
+  # Time ranges
+  gollmscribe clip meeting.mp4 --range 1:30-2:10 --range 00:14:05-00:14:40`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClip,
+}
+
+func init() {
+	rootCmd.AddCommand(clipCmd)
+
+	clipCmd.Flags().String("from-segments", "", "JSON file selecting segment indices or clips to cut")
+	clipCmd.Flags().StringArray("range", nil, "time range to cut, e.g. 1:30-2:10 (repeatable)")
+	clipCmd.Flags().String("transcript", "", "JSON result of the recording (default: next to the recording)")
+	clipCmd.Flags().Duration("padding", 500*time.Millisecond, "time added before and after every clip")
+	clipCmd.Flags().StringP("output", "o", "", "directory for the clips (default: <recording>_clips)")
+}
+
+func runClip(cmd *cobra.Command, args []string) error {
+	recording := args[0]
+	if _, err := os.Stat(recording); err != nil {
+		return fmt.Errorf("recording not found: %w", err)
+	}
+	selection, _ := cmd.Flags().GetString("from-segments")
+	ranges, _ := cmd.Flags().GetStringArray("range")
+	if selection == "" && len(ranges) == 0 {
+		return fmt.Errorf("select clips with --from-segments or --range")
+	}
+
+	base := strings.TrimSuffix(recording, filepath.Ext(recording))
+	transcriptPath, _ := cmd.Flags().GetString("transcript")
+	explicit := transcriptPath != ""
+	if !explicit {
+		transcriptPath = base + ".json"
+	}
+
+	// Ranges only use the transcript to name their clips
+	var result *transcriber.TranscribeResult
+	if _, err := os.Stat(transcriptPath); err == nil || explicit || selection != "" {
+		cfg := loadConfig()
+		cipher, err := loadCipher(cfg)
+		if err != nil {
+			return err
+		}
+		if result, err = transcriber.LoadEncryptedResult(transcriptPath, cipher); err != nil {
+			return err
+		}
+	}
+
+	var selected []clips.Clip
+	if selection != "" {
+		data, err := os.ReadFile(selection)
+		if err != nil {
+			return fmt.Errorf("failed to read selection: %w", err)
+		}
+		if selected, err = clips.ParseSelection(data, result); err != nil {
+			return fmt.Errorf("%s: %w", selection, err)
+		}
+	}
+	for _, r := range ranges {
+		clip, err := clips.ParseRange(r)
+		if err != nil {
+			return err
+		}
+		clip.Text = clips.TextBetween(result, clip.Start, clip.End)
+		selected = append(selected, clip)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("nothing selected to cut")
+	}
+
+	outputDir, _ := cmd.Flags().GetString("output")
+	if outputDir == "" {
+		outputDir = base + "_clips"
+	}
+	padding, _ := cmd.Flags().GetDuration("padding")
+
+	paths, err := clips.Cut(cmd.Context(), recording, outputDir, selected, padding)
+	for i, path := range paths {
+		fmt.Printf("Wrote %s (%s - %s)\n", path, formatClock(selected[i].Start), formatClock(selected[i].End))
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+)
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [file]",
+	Short: "Decrypt a transcript encrypted at rest",
+	Long: `Decrypt a transcript written with an encryption key configured.
+
+The key is read from GOLLMSCRIBE_ENCRYPTION_KEY, encryption.key in the
+config file, or --encryption-key-file.
+
+Examples:
+  # Create a key for the team and keep it out of the shared disk
+  gollmscribe decrypt --generate-key > ~/.gollmscribe.key
+
+  # Print a transcript
+  gollmscribe decrypt meeting.txt --encryption-key-file ~/.gollmscribe.key
+
+  # Write the plaintext to a file
+  gollmscribe decrypt meeting.txt -o meeting.plain.txt`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if generate, _ := cmd.Flags().GetBool("generate-key"); generate {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runDecrypt,
+}
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	decryptCmd.Flags().StringP("output", "o", "", "write the plaintext to this file instead of stdout")
+	decryptCmd.Flags().Bool("generate-key", false, "print a new random base64 encryption key and exit")
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	if generate, _ := cmd.Flags().GetBool("generate-key"); generate {
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+
+	cipher, err := loadCipher(loadConfig())
+	if err != nil {
+		return err
+	}
+	if cipher == nil {
+		return fmt.Errorf("no encryption key configured. Set GOLLMSCRIBE_ENCRYPTION_KEY or use --encryption-key-file")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	plaintext, err := cipher.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	if outputPath, _ := cmd.Flags().GetString("output"); outputPath != "" {
+		if err := os.WriteFile(outputPath, plaintext, 0o600); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	}
+
+	_, err = os.Stdout.Write(plaintext)
+	return err
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment gollmscribe needs",
+	Long: `Check for the config file and the ffmpeg/ffprobe tools used for
+audio processing.
+
+Without ffmpeg only WAV and MP3 files can be transcribed. Static ffmpeg
+and ffprobe binaries in audio.ffmpeg_dir are used ahead of PATH.
+
+Examples:
+  # Check the environment
+  gollmscribe doctor`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(_ *cobra.Command, _ []string) error {
+	encrypted := false
+	if file := viper.ConfigFileUsed(); file != "" {
+		data, err := os.ReadFile(file)
+		if encrypted = err == nil && config.IsEncrypted(data); encrypted {
+			fmt.Printf("Config:  %s (encrypted with sops)\n", file)
+		} else {
+			fmt.Printf("Config:  %s\n", file)
+		}
+	} else {
+		fmt.Println("Config:  none found, using defaults")
+	}
+
+	// An encrypted config is decrypted by sops at every start
+	if encrypted {
+		if path, err := exec.LookPath(config.SopsBinary); err != nil {
+			fmt.Printf("%-8s not found, needed to decrypt the config\n", "sops:")
+		} else {
+			fmt.Printf("%-8s %s\n", "sops:", path)
+		}
+	}
+
+	missing := false
+	for _, tool := range []string{"ffmpeg", "ffprobe"} {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			fmt.Printf("%-8s not found\n", tool+":")
+			missing = true
+			continue
+		}
+		fmt.Printf("%-8s %s (%s)\n", tool+":", path, toolVersion(path))
+	}
+
+	if missing {
+		fmt.Println()
+		fmt.Println("Without ffmpeg only WAV and MP3 files can be transcribed, and")
+		fmt.Println("silence trimming and voice profiles are unavailable.")
+		fmt.Println("Install it with your package manager or put static builds in audio.ffmpeg_dir.")
+	}
+	return nil
+}
+
+// toolVersion returns the first line of a tool's -version output
+func toolVersion(path string) string {
+	out, err := exec.Command(path, "-version").Output()
+	if err != nil {
+		return "version unknown"
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// experimentCmd represents the experiment command
+var experimentCmd = &cobra.Command{
+	Use:   "experiment [file]",
+	Short: "Compare two prompts on the chunks of a recording",
+	Long: `Transcribe the chunks of a recording with two prompts, A and B, and
+compare the responses: words per chunk, how often the response is
+well-structured, latency, cost and, with --reference, the word error
+rate against a trusted transcript.
+
+In alternate mode (the default) even chunks use prompt A and odd chunks
+prompt B, so the experiment costs what transcribing the file does. In
+duplicate mode --sample chunks, spread over the file, are transcribed
+with both prompts so they are compared on the same audio.
+
+A response is well-structured when it has text, its timestamps are in
+order within the chunk and, with --line-pattern, every line matches the
+pattern. The reference is a JSON result with timestamped segments, such
+as a transcript corrected by hand.
+
+Examples:
+  # Which of two library prompts keeps speaker lines in shape?
+  gollmscribe experiment standup.mp3 --prompt-a-name meeting --prompt-b-name meeting-v2 \
+    --line-pattern '^\[\d{2}:\d{2}\] [^:]+: '
+
+  # Score both prompts on the same three chunks against a corrected transcript
+  gollmscribe experiment lecture.mp4 --prompt-a "Transcribe verbatim." \
+    --prompt-b "Transcribe verbatim, keeping technical terms in English." \
+    --mode duplicate --sample 3 --reference lecture.corrected.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExperiment,
+}
+
+func init() {
+	rootCmd.AddCommand(experimentCmd)
+
+	experimentCmd.Flags().String("prompt-a", "", "prompt A")
+	experimentCmd.Flags().String("prompt-b", "", "prompt B")
+	experimentCmd.Flags().String("prompt-a-name", "", "prompt A from the prompt library")
+	experimentCmd.Flags().String("prompt-b-name", "", "prompt B from the prompt library")
+	experimentCmd.Flags().String("mode", string(transcriber.ExperimentAlternate), "alternate prompts across chunks, or duplicate sampled chunks with both")
+	experimentCmd.Flags().Int("sample", 3, "chunks transcribed with both prompts in duplicate mode (0 = all)")
+	experimentCmd.Flags().String("reference", "", "JSON result to score word error rate against")
+	experimentCmd.Flags().String("line-pattern", "", "regular expression every line of a well-structured response matches")
+	experimentCmd.Flags().Int("chunk-minutes", 0, "chunk duration in minutes (default: audio.chunk_minutes)")
+	experimentCmd.Flags().Int("overlap-seconds", 0, "overlap duration in seconds (default: audio.overlap_seconds)")
+	experimentCmd.Flags().Float32("temperature", 0, "LLM temperature (default: provider.temperature)")
+	experimentCmd.Flags().Bool("json", false, "print the report as JSON")
+	experimentCmd.Flags().StringP("output", "o", "", "also write the JSON report, with every response, to this file")
+}
+
+func runExperiment(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+
+	promptA, err := experimentPrompt(cmd, cfg, "prompt-a")
+	if err != nil {
+		return err
+	}
+	promptB, err := experimentPrompt(cmd, cfg, "prompt-b")
+	if err != nil {
+		return err
+	}
+	mode, _ := cmd.Flags().GetString("mode")
+	opts := transcriber.ExperimentOptions{PromptA: promptA, PromptB: promptB}
+	if opts.Mode, err = transcriber.ParseExperimentMode(mode); err != nil {
+		return err
+	}
+	opts.Sample, _ = cmd.Flags().GetInt("sample")
+	if pattern, _ := cmd.Flags().GetString("line-pattern"); pattern != "" {
+		if opts.LinePattern, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid --line-pattern: %w", err)
+		}
+	}
+
+	if err := requireCredentials(cfg); err != nil {
+		return err
+	}
+	provider, err := initializeProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize provider: %w", err)
+	}
+	run, err := loadRunResources(cfg)
+	if err != nil {
+		return err
+	}
+	if reference, _ := cmd.Flags().GetString("reference"); reference != "" {
+		if opts.Reference, err = transcriber.LoadEncryptedResult(reference, run.cipher); err != nil {
+			return err
+		}
+	}
+	tr := run.newTranscriber(provider, cfg)
+
+	options := transcriber.TranscribeOptions{
+		ChunkMinutes:   cfg.Audio.ChunkMinutes,
+		OverlapSeconds: cfg.Audio.OverlapSeconds,
+		Temperature:    cfg.Provider.Temperature,
+		TrimSilence:    cfg.Audio.TrimSilence,
+		LeadingContext: cfg.Audio.LeadingContext,
+	}
+	if cmd.Flags().Changed("chunk-minutes") {
+		options.ChunkMinutes, _ = cmd.Flags().GetInt("chunk-minutes")
+	}
+	if cmd.Flags().Changed("overlap-seconds") {
+		options.OverlapSeconds, _ = cmd.Flags().GetInt("overlap-seconds")
+	}
+	if cmd.Flags().Changed("temperature") {
+		options.Temperature, _ = cmd.Flags().GetFloat32("temperature")
+	}
+
+	report, err := tr.Experiment(cmd.Context(), &transcriber.TranscribeRequest{FilePath: args[0], Options: options}, opts)
+	if err != nil {
+		return err
+	}
+
+	if outputPath, _ := cmd.Flags().GetString("output"); outputPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printExperiment(report)
+	return nil
+}
+
+// experimentPrompt returns the prompt given by --<flag> or --<flag>-name
+func experimentPrompt(cmd *cobra.Command, cfg *config.Config, flag string) (string, error) {
+	prompt, _ := cmd.Flags().GetString(flag)
+	name, _ := cmd.Flags().GetString(flag + "-name")
+	switch {
+	case prompt != "" && name != "":
+		return "", fmt.Errorf("use either --%s or --%s-name", flag, flag)
+	case name != "":
+		return namedPrompt(cfg, name)
+	case prompt == "":
+		return "", fmt.Errorf("--%s or --%s-name is required", flag, flag)
+	}
+	return prompt, nil
+}
+
+// printExperiment prints every run and the comparison of the prompts
+func printExperiment(report *transcriber.ExperimentReport) {
+	fmt.Printf("Experiment on %s (%s mode)\n\n", report.FilePath, report.Mode)
+	fmt.Printf("%-6s %-8s %-20s %7s %6s %7s %9s\n", "Chunk", "Prompt", "Span", "Words", "Valid", "WER", "Latency")
+	for _, run := range report.Runs {
+		span := formatClock(run.Start)[:8] + "-" + formatClock(run.End)[:8]
+		if run.Error != "" {
+			fmt.Printf("%-6d %-8s %-20s failed: %s\n", run.Chunk, run.Variant, span, run.Error)
+			continue
+		}
+		fmt.Printf("%-6d %-8s %-20s %7d %6t %7s %9v\n", run.Chunk, run.Variant, span, run.Words, run.Valid, formatWER(run.WER), run.Latency.Round(10*time.Millisecond))
+	}
+
+	fmt.Println()
+	for _, v := range report.Variants {
+		fmt.Printf("Prompt %s: %q\n", v.Variant, truncateString(v.Prompt, 60))
+		fmt.Printf("  runs %d (%d failed), %.0f words per chunk, %.0f%% well-structured, WER %s, %v per chunk, $%.4f\n",
+			v.Runs, v.Failures, v.MeanWords, v.ValidShare*100, formatWER(v.MeanWER), v.MeanLatency.Round(10*time.Millisecond), v.Cost)
+	}
+}
+
+// formatWER formats a word error rate as a percentage, or "-" without one
+func formatWER(wer float64) string {
+	if wer < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", wer*100)
+}
@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/store"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+	"github.com/eternnoir/gollmscribe/pkg/watcher"
+)
+
+// historyCmd groups commands working on the watch history database
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Manage the watch history database",
+	Long: `Manage the history database watch mode keeps of processed and failed
+files. Files are identified by a hash of their content, so a recorded file
+is skipped even after it is renamed.`,
+}
+
+// historyImportCmd records transcripts made by other tools
+var historyImportCmd = &cobra.Command{
+	Use:   "import [media-dir]",
+	Short: "Record media already transcribed by other tools as processed",
+	Long: `Record media that was already transcribed, e.g. by other tools before
+switching to watch mode, as processed, so watch mode does not transcribe it
+again. Each media file in media-dir (default: the current directory) that
+has a transcript of the same name in --dir is hashed and recorded:
+meeting.mp3 matches meeting.txt or meeting.mp3.txt, and .md, .srt, .vtt and
+.json transcripts. The record is dated when the transcript was last
+written, which the retention policy ages it from. Media without a
+transcript is left for watch mode.
+
+Stop watch mode first; the history database is locked while it runs.
+
+Examples:
+  # See what would be recorded
+  gollmscribe history import ./inbox --dir ./transcripts --dry-run
+
+  # Record it
+  gollmscribe history import ./inbox --dir ./transcripts --history-db .gollmscribe-watch.db`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistoryImport,
+}
+
+// historyVersionsCmd lists the kept versions of a transcript
+var historyVersionsCmd = &cobra.Command{
+	Use:   "versions <file>",
+	Short: "List the versions of a file's transcript and how they differ",
+	Long: `List the versions of a transcript kept by --keep-versions (or
+output.keep_versions): meeting.txt, meeting_v2.txt, ... The file is the
+media, found in the watch history or next to its transcript, or any
+version of the transcript itself. For each version the words that
+changed from the one before are counted, and the provider and model are
+shown when a JSON version was written alongside it (--formats json).
+
+Examples:
+  gollmscribe history versions ./inbox/meeting.mp3
+  gollmscribe history versions ./transcripts/meeting_v2.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryVersions,
+}
+
+// historySearchCmd finds processed files by their notes
+var historySearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Find processed files by their operator notes or path",
+	Long: `Find the files in the watch history whose operator notes (--note of
+watch) or path contain the query, ignoring case, newest first. Without a
+query every file with notes is listed.
+
+Examples:
+  gollmscribe history search "board meeting"
+  gollmscribe history search confidential --history-db ./inbox/.gollmscribe-watch.db`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistorySearch,
+}
+
+// historyExportCmd mirrors the history for reporting
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the history and usage stats to JSON/CSV or an HTTP endpoint",
+	Long: `Export the processed and failed files of the watch history, with usage
+stats per day, for teams building reports without access to the database.
+The report is written to --output as JSON, or as CSV with a row per file
+and the daily stats in name.stats.csv, and POSTed as JSON to --url. Without
+either, the configured watch.export is used, or JSON is printed to stdout.
+
+The database is read from a snapshot, so it can be exported while watch
+mode runs. Watch mode also exports it on its own when watch.export is
+configured; --every exports on a schedule without it, until interrupted.
+
+Examples:
+  # Print the report
+  gollmscribe history export
+
+  # Refresh a CSV mirror every 15 minutes
+  gollmscribe history export -o reports/history.csv --every 15m
+
+  # Push the report to a reporting service
+  gollmscribe history export --url https://reports.example.com/gollmscribe --header "Authorization: Bearer $TOKEN"`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryExport,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyImportCmd)
+	historyCmd.AddCommand(historyVersionsCmd)
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyExportCmd)
+
+	historyImportCmd.Flags().String("dir", "", "directory of the existing transcripts")
+	historyImportCmd.Flags().String("history-db", ".gollmscribe-watch.db", "watch history database")
+	historyImportCmd.Flags().StringSlice("pattern", []string{"*.mp3", "*.wav", "*.mp4", "*.m4a"}, "media file patterns (comma-separated)")
+	historyImportCmd.Flags().BoolP("recursive", "r", false, "include subdirectories; transcripts must mirror the media's layout")
+	historyImportCmd.Flags().Bool("dry-run", false, "only report what would be recorded")
+	_ = historyImportCmd.MarkFlagRequired("dir")
+
+	historySearchCmd.Flags().String("history-db", ".gollmscribe-watch.db", "watch history database")
+	historyVersionsCmd.Flags().String("history-db", ".gollmscribe-watch.db", "watch history database to find the transcript of media in")
+
+	historyExportCmd.Flags().String("history-db", ".gollmscribe-watch.db", "watch history database")
+	historyExportCmd.Flags().StringP("output", "o", "", "file to write the report to; .csv writes CSV (overrides watch.export.path)")
+	historyExportCmd.Flags().String("format", "", "report file format: json or csv (default: from the file extension)")
+	historyExportCmd.Flags().String("url", "", "endpoint to POST the JSON report to (overrides watch.export.url)")
+	historyExportCmd.Flags().StringArray("header", nil, "header sent with the report, e.g. \"Authorization: Bearer ...\" (repeatable)")
+	historyExportCmd.Flags().Duration("every", 0, "export again at this interval until interrupted, e.g. 1h")
+}
+
+func runHistoryExport(cmd *cobra.Command, args []string) error {
+	appCfg := loadConfig()
+	export := historyExport(appCfg.Watch.Export)
+	if cmd.Flags().Changed("output") || cmd.Flags().Changed("url") {
+		export.Path, _ = cmd.Flags().GetString("output")
+		export.URL, _ = cmd.Flags().GetString("url")
+	}
+	if cmd.Flags().Changed("format") {
+		export.Format, _ = cmd.Flags().GetString("format")
+	}
+	headers, _ := cmd.Flags().GetStringArray("header")
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid header %q, want \"Name: value\"", header)
+		}
+		if export.Headers == nil {
+			export.Headers = make(map[string]string)
+		}
+		export.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if err := export.Validate(); err != nil {
+		return err
+	}
+
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	if _, err := os.Stat(historyDB); err != nil {
+		return fmt.Errorf("history database not found: %w", err)
+	}
+	cipher, err := loadCipher(appCfg)
+	if err != nil {
+		return err
+	}
+
+	every, _ := cmd.Flags().GetDuration("every")
+	if every <= 0 {
+		return exportHistoryOnce(cmd.Context(), historyDB, cipher, export)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		// A failed export is retried at the next interval
+		if err := exportHistoryOnce(ctx, historyDB, cipher, export); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportHistoryOnce exports a snapshot of the history database, printing
+// it to stdout when the export has no destination
+func exportHistoryOnce(ctx context.Context, historyDB string, cipher *encryption.Cipher, export watcher.HistoryExport) error {
+	history, err := watcher.NewReadOnlyProcessingHistory(historyDB, cipher)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = history.Close() }()
+
+	if !export.Enabled() {
+		report, err := watcher.BuildHistoryReport(history, time.Now())
+		if err != nil {
+			return err
+		}
+		return report.WriteJSON(os.Stdout)
+	}
+
+	report, err := watcher.ExportHistory(ctx, history, export, time.Now())
+	if err != nil {
+		return err
+	}
+	var destinations []string
+	if export.Path != "" {
+		destinations = append(destinations, export.Path)
+	}
+	if export.URL != "" {
+		destinations = append(destinations, export.URL)
+	}
+	fmt.Printf("✓ Exported %d processed and %d failed file(s) to %s at %s\n", len(report.Processed), len(report.Failed),
+		strings.Join(destinations, " and "), report.GeneratedAt.Format("15:04:05"))
+	return nil
+}
+
+// historyExport converts the export config into a watcher export
+func historyExport(ec config.HistoryExportConfig) watcher.HistoryExport {
+	return watcher.HistoryExport{
+		Path:     ec.Path,
+		Format:   ec.Format,
+		URL:      ec.URL,
+		Headers:  ec.Headers,
+		Interval: ec.Interval,
+	}
+}
+
+func runHistorySearch(cmd *cobra.Command, args []string) error {
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+	cipher, err := loadCipher(loadConfig())
+	if err != nil {
+		return err
+	}
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	if _, err := os.Stat(historyDB); err != nil {
+		return fmt.Errorf("history database not found: %w", err)
+	}
+	history, err := watcher.NewReadOnlyProcessingHistory(historyDB, cipher)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = history.Close() }()
+
+	matches, err := watcher.SearchProcessed(history, query)
+	if err != nil {
+		return err
+	}
+	for _, info := range matches {
+		fmt.Printf("%s  %s\n", info.ProcessedAt.Format("2006-01-02 15:04"), info.FilePath)
+		fmt.Printf("    Output: %s\n", info.OutputPath)
+		if len(info.Notes) > 0 {
+			fmt.Printf("    Notes: %s\n", strings.Join(info.Notes, "; "))
+		}
+		if info.Quality != nil {
+			fmt.Printf("    Quality: %s\n", info.Quality)
+		}
+	}
+	fmt.Printf("\n%d file(s) found\n", len(matches))
+	return nil
+}
+
+func runHistoryImport(cmd *cobra.Command, args []string) error {
+	options := watcher.ImportOptions{MediaDir: "."}
+	if len(args) > 0 {
+		options.MediaDir = args[0]
+	}
+	options.TranscriptDir, _ = cmd.Flags().GetString("dir")
+	options.Patterns, _ = cmd.Flags().GetStringSlice("pattern")
+	options.Recursive, _ = cmd.Flags().GetBool("recursive")
+	options.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	cipher, err := loadCipher(loadConfig())
+	if err != nil {
+		return err
+	}
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	history, err := watcher.NewEncryptedProcessingHistory(historyDB, cipher)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = history.Close() }()
+
+	entries, err := watcher.ImportTranscripts(history, options)
+	if err != nil {
+		return err
+	}
+
+	verb := "Recorded"
+	if options.DryRun {
+		verb = "Would record"
+	}
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Status]++
+		switch entry.Status {
+		case watcher.ImportRecorded:
+			fmt.Printf("✓ %s %s (%s)\n", verb, entry.MediaPath, entry.Transcript)
+		case watcher.ImportExisting:
+			fmt.Printf("• Already recorded %s\n", entry.MediaPath)
+		case watcher.ImportFailed:
+			fmt.Printf("✗ %s: %s\n", entry.MediaPath, entry.Error)
+		}
+	}
+
+	fmt.Printf("\n%s %d file(s)", verb, counts[watcher.ImportRecorded])
+	if n := counts[watcher.ImportExisting]; n > 0 {
+		fmt.Printf(", %d already recorded", n)
+	}
+	if n := counts[watcher.ImportNoTranscript]; n > 0 {
+		fmt.Printf(", %d without a transcript left for watch mode", n)
+	}
+	fmt.Println()
+	if n := counts[watcher.ImportFailed]; n > 0 {
+		return fmt.Errorf("%d file(s) could not be recorded, see above", n)
+	}
+	return nil
+}
+
+func runHistoryVersions(cmd *cobra.Command, args []string) error {
+	cipher, err := loadCipher(loadConfig())
+	if err != nil {
+		return err
+	}
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	outputPath, err := transcriptOf(args[0], historyDB, cipher)
+	if err != nil {
+		return err
+	}
+
+	versions, err := transcriber.Versions(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to list versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no transcript of %s found at %s", args[0], outputPath)
+	}
+
+	fmt.Printf("%-8s %-17s %-7s %-8s %-28s %s\n", "VERSION", "SAVED", "WORDS", "CHANGED", "PROVIDER", "FILE")
+	var previous string
+	for i, version := range versions {
+		text, err := readTranscriptText(version.Path, cipher)
+		if err != nil {
+			return err
+		}
+		changed := "-"
+		if i > 0 {
+			changed = fmt.Sprintf("%.0f%%", transcriber.WordErrorRate(previous, text)*100)
+		}
+		provider := "-"
+		for _, format := range []string{"json", "json" + transcriber.CompressionSuffix} {
+			if result, err := transcriber.LoadEncryptedResult(transcriber.FormatPath(version.Path, format), cipher); err == nil {
+				provider = strings.TrimSuffix(result.Provider+"/"+result.Model, "/")
+				break
+			}
+		}
+		fmt.Printf("%-8d %-17s %-7d %-8s %-28s %s\n", version.Version, version.Modified.Format("2006-01-02 15:04"),
+			len(strings.Fields(text)), changed, provider, version.Path)
+		previous = text
+	}
+	return nil
+}
+
+// transcriptOf returns the transcript path of a media file, from the
+// watch history if it is recorded there, or of a transcript itself
+func transcriptOf(path, historyDB string, cipher *encryption.Cipher) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt", ".md", ".srt", ".vtt", ".json":
+		return path, nil
+	}
+	if isJSONResultPath(path) {
+		return path, nil
+	}
+
+	if _, err := os.Stat(historyDB); err == nil {
+		history, err := watcher.NewReadOnlyProcessingHistory(historyDB, cipher)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = history.Close() }()
+
+		hash, err := store.HashFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		if info, err := history.GetProcessedInfo(hash); err == nil && info != nil && info.OutputPath != "" {
+			return info.OutputPath, nil
+		}
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".txt", nil
+}
+
+// readTranscriptText returns the text of a transcript, decrypting it and
+// taking the text out of JSON results
+func readTranscriptText(path string, cipher *encryption.Cipher) (string, error) {
+	if isJSONResultPath(path) {
+		result, err := transcriber.LoadEncryptedResult(path, cipher)
+		if err != nil {
+			return "", err
+		}
+		return result.Text, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if data, err = cipher.Decrypt(data); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// isJSONResultPath reports whether path is a JSON result, gzipped or not
+func isJSONResultPath(path string) bool {
+	path = strings.ToLower(path)
+	return strings.HasSuffix(path, ".json") || strings.HasSuffix(path, ".json"+transcriber.CompressionSuffix)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// jobsCmd represents the jobs command
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "List interrupted transcriptions that can be resumed",
+	Long: `List the transcription jobs that stopped before finishing, or finished
+with gaps, and left a checkpoint in the temp directory. Every run gets a
+short job ID, shown in its progress line and logs; resume a job with
+
+  gollmscribe transcribe --resume-job <id>
+
+using the same prompt and provider settings as the interrupted run.`,
+	Args: cobra.NoArgs,
+	RunE: runJobs,
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+
+	jobsCmd.Flags().Bool("json", false, "print the jobs as JSON")
+}
+
+func runJobs(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return err
+	}
+	tr := transcriber.NewTranscriber(nil, cfg)
+	tr.SetCipher(cipher)
+
+	jobs, err := tr.Jobs()
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(jobs)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No resumable jobs")
+		return nil
+	}
+	fmt.Printf("%-10s %-8s %-17s %s\n", "JOB", "CHUNKS", "UPDATED", "SOURCE")
+	for _, job := range jobs {
+		fmt.Printf("%-10s %-8d %-17s %s\n", job.ID, job.Chunks, job.Updated.Local().Format("2006-01-02 15:04"), job.Source)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// liveCmd represents the live command
+var liveCmd = &cobra.Command{
+	Use:   "live",
+	Short: "Transcribe the microphone as you speak",
+	Long: `Record the default input device with ffmpeg and transcribe it in
+rolling chunks: every --segment-seconds the recorded audio is sent to
+the provider and its segments are printed, timestamped from the start
+of the recording. Shorter chunks print sooner; longer chunks give the
+model more context.
+
+Press Ctrl+C, or set --max-duration, to stop recording. The chunk being
+recorded is finished and transcribed, and the whole transcript is saved
+to --output (default: live-<date>-<time>.txt). Press Ctrl+C again to
+abort without waiting.
+
+The input device defaults to PulseAudio "default" on Linux and
+AVFoundation ":0" on macOS; use --input-format and --device for another
+device or platform, e.g. --input-format dshow --device "audio=Microphone".
+
+Examples:
+  gollmscribe live
+  gollmscribe live --segment-seconds 15 --max-duration 1h -o standup.txt
+  gollmscribe live --input-format alsa --device hw:1 --prompt-name meeting`,
+	Args: cobra.NoArgs,
+	RunE: runLive,
+}
+
+func init() {
+	rootCmd.AddCommand(liveCmd)
+
+	liveCmd.Flags().String("device", "", "input device (default: the platform's default microphone)")
+	liveCmd.Flags().String("input-format", "", "ffmpeg input format of the device, e.g. pulse, alsa, avfoundation or dshow")
+	addCaptureFlags(liveCmd, 30)
+}
+
+// addCaptureFlags adds the flags shared by commands transcribing a capture
+func addCaptureFlags(c *cobra.Command, segmentSeconds int) {
+	c.Flags().Int("segment-seconds", segmentSeconds, "seconds of audio per transcribed chunk")
+	c.Flags().Duration("max-duration", 0, "stop recording after this long (0 = until Ctrl+C)")
+	c.Flags().StringP("output", "o", "", "output file path (default: "+c.Name()+"-<date>-<time>.txt)")
+	c.Flags().StringP("prompt", "p", "", "custom transcription prompt")
+	c.Flags().String("prompt-file", "", "file containing custom prompt")
+	c.Flags().String("prompt-name", "", "use a named prompt from the prompt library (see gollmscribe prompts)")
+	c.Flags().Float32("temperature", 0, "LLM temperature (default: provider.temperature)")
+	c.Flags().Bool("preserve-audio", false, "keep the recorded chunks in the temporary directory")
+}
+
+func runLive(cmd *cobra.Command, args []string) error {
+	input, err := liveInput(cmd)
+	if err != nil {
+		return err
+	}
+	return transcribeCapture(cmd, input)
+}
+
+// transcribeCapture records input in chunks of --segment-seconds and
+// transcribes every chunk as it closes, printing its segments, until
+// Ctrl+C, --max-duration or the end of the input. The merged transcript
+// is then saved to --output.
+func transcribeCapture(cmd *cobra.Command, input audio.CaptureInput) error {
+	cfg := loadConfig()
+
+	segmentSeconds, _ := cmd.Flags().GetInt("segment-seconds")
+	if segmentSeconds <= 0 {
+		return fmt.Errorf("--segment-seconds must be positive")
+	}
+	customPrompt, err := getCustomPrompt(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("%s-%s.txt", cmd.Name(), time.Now().Format("20060102-150405"))
+	}
+
+	if err := requireCredentials(cfg); err != nil {
+		return err
+	}
+	provider, err := initializeProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize provider: %w", err)
+	}
+	run, err := loadRunResources(cfg)
+	if err != nil {
+		return err
+	}
+	tr := run.newTranscriber(provider, cfg)
+
+	options := transcriber.TranscribeOptions{
+		ChunkMinutes:   cfg.Audio.ChunkMinutes,
+		OverlapSeconds: cfg.Audio.OverlapSeconds,
+		Temperature:    cfg.Provider.Temperature,
+		ChunkRetries:   cfg.Transcribe.ChunkRetries,
+		TrimSilence:    cfg.Audio.TrimSilence,
+	}
+	if cmd.Flags().Changed("temperature") {
+		options.Temperature, _ = cmd.Flags().GetFloat32("temperature")
+	}
+	options.PreserveAudio, _ = cmd.Flags().GetBool("preserve-audio")
+
+	dir, err := os.MkdirTemp(cfg.Audio.TempDir, cmd.Name()+"_*")
+	if err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	if !options.PreserveAudio {
+		defer os.RemoveAll(dir)
+	}
+
+	// The first Ctrl+C only stops recording; once it has, signals are
+	// handled as usual again so a second one aborts
+	captureCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if maxDuration, _ := cmd.Flags().GetDuration("max-duration"); maxDuration > 0 {
+		var cancel context.CancelFunc
+		captureCtx, cancel = context.WithTimeout(captureCtx, maxDuration)
+		defer cancel()
+	}
+	go func() {
+		<-captureCtx.Done()
+		stop()
+	}()
+
+	files, captureErrs := audio.Capture(captureCtx, input, time.Duration(segmentSeconds)*time.Second, dir)
+	fmt.Printf("🎙  Recording from %s, press Ctrl+C to stop\n", input.Source)
+
+	req := &transcriber.TranscribeRequest{
+		FilePath:     input.Source,
+		OutputPath:   outputPath,
+		CustomPrompt: customPrompt,
+		Options:      options,
+	}
+	result, err := tr.TranscribeLive(cmd.Context(), req, files, printSegment)
+	if err != nil {
+		// Unblock the capture so it can report why it stopped
+		go func() {
+			for range files {
+			}
+		}()
+	}
+	if captureErr := <-captureErrs; captureErr != nil {
+		if err != nil {
+			return captureErr
+		}
+		fmt.Printf("⚠️  Recording stopped early: %v\n", captureErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Recorded %s from %s\n", humanize.Duration(result.Duration), input.Source)
+	fmt.Printf("  Job: %s\n", result.JobID)
+	fmt.Printf("  Output: %s\n", outputPath)
+	fmt.Printf("  Segments: %d\n", len(result.Segments))
+	printUsage("  ", result.Usage, result.Cost)
+	for _, gap := range result.Gaps() {
+		fmt.Printf("  ⚠️  Missing %s-%s: %s\n", humanize.Duration(gap.Start), humanize.Duration(gap.End), gap.Error)
+	}
+	return nil
+}
+
+// liveInput returns the input device selected by the flags, defaulting to
+// the platform's microphone
+func liveInput(cmd *cobra.Command) (audio.CaptureInput, error) {
+	device, _ := cmd.Flags().GetString("device")
+	format, _ := cmd.Flags().GetString("input-format")
+	if device != "" && format != "" {
+		return audio.CaptureInput{Format: format, Source: device}, nil
+	}
+
+	input, err := audio.DefaultMicrophone()
+	if err != nil {
+		return input, fmt.Errorf("%w (use --input-format and --device)", err)
+	}
+	if device != "" {
+		input.Source = device
+	}
+	if format != "" {
+		input.Format = format
+	}
+	return input, nil
+}
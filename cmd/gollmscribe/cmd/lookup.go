@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/store"
+	"github.com/eternnoir/gollmscribe/pkg/watcher"
+)
+
+// lookupCmd represents the lookup command
+var lookupCmd = &cobra.Command{
+	Use:   "lookup [file]",
+	Short: "Find an existing transcript for a media file",
+	Long: `Find an existing transcript for a media file by its content hash.
+
+The transcript store is checked first, then the watch history database.
+Exits with an error if no transcript is found.
+
+Examples:
+  # Look up a file in the transcript store
+  gollmscribe lookup meeting.mp3 --store ./transcripts-store
+
+  # Also check a watch history database
+  gollmscribe lookup meeting.mp3 --store ./store --history-db .gollmscribe-watch.db
+
+  # Print only the transcript text
+  gollmscribe lookup meeting.mp3 --store ./store --text`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLookup,
+}
+
+func init() {
+	rootCmd.AddCommand(lookupCmd)
+
+	lookupCmd.Flags().String("store", "", "transcript store directory")
+	lookupCmd.Flags().String("history-db", "", "watch history database to search")
+	lookupCmd.Flags().Bool("text", false, "print the transcript text instead of its location")
+}
+
+func runLookup(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+	storeDir, _ := cmd.Flags().GetString("store")
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	printText, _ := cmd.Flags().GetBool("text")
+
+	if storeDir == "" && historyDB == "" {
+		return fmt.Errorf("at least one of --store or --history-db is required")
+	}
+
+	cipher, err := loadCipher(loadConfig())
+	if err != nil {
+		return err
+	}
+
+	hash, err := store.HashFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	if storeDir != "" {
+		s, err := store.New(storeDir, store.WithCipher(cipher))
+		if err != nil {
+			return err
+		}
+		result, found, err := s.LookupHash(hash)
+		if err != nil {
+			return err
+		}
+		if found {
+			if printText {
+				fmt.Println(result.Text)
+			} else {
+				fmt.Printf("Found in store: %s (hash %s)\n", storeDir, hash)
+				if notes := result.Notes(); len(notes) > 0 {
+					fmt.Printf("Notes: %s\n", strings.Join(notes, "; "))
+				}
+			}
+			return nil
+		}
+	}
+
+	if historyDB != "" {
+		if _, err := os.Stat(historyDB); err != nil {
+			return fmt.Errorf("history database not found: %w", err)
+		}
+		history, err := watcher.NewReadOnlyProcessingHistory(historyDB, cipher)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = history.Close() }()
+
+		info, err := history.GetProcessedInfo(hash)
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			if printText {
+				data, err := os.ReadFile(info.OutputPath)
+				if err != nil {
+					return fmt.Errorf("failed to read transcript: %w", err)
+				}
+				if data, err = cipher.Decrypt(data); err != nil {
+					return fmt.Errorf("failed to read transcript: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				fmt.Printf("Found in history: %s (processed %s)\n", info.OutputPath, info.ProcessedAt.Format("2006-01-02 15:04"))
+				if len(info.Notes) > 0 {
+					fmt.Printf("Notes: %s\n", strings.Join(info.Notes, "; "))
+				}
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no transcript found for %s", filePath)
+}
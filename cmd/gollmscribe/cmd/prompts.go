@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/prompts"
+)
+
+// promptsCmd lists the named prompt library
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "List the named prompts available to --prompt-name",
+	Long: `List the named prompts available to --prompt-name.
+
+Prompts are the *.md and *.txt files in the prompt directory
+(~/.config/gollmscribe/prompts by default, or transcribe.prompts_dir),
+named after the file, plus transcribe.prompt_templates from the config
+file. A file overrides a template of the same name.
+
+Examples:
+  # List the prompt library, then use a prompt by name
+  gollmscribe prompts
+  gollmscribe transcribe call.mp3 --prompt-name sales-call`,
+	Args: cobra.NoArgs,
+	RunE: runPrompts,
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+}
+
+func runPrompts(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	dir, _ := promptsDir(cfg)
+	lib, err := loadPromptLibrary(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Prompt directory: %s\n\n", dir)
+	list := lib.Prompts()
+	if len(list) == 0 {
+		fmt.Println("No prompts found")
+		return nil
+	}
+
+	for _, prompt := range list {
+		fmt.Printf("%s (%s)\n", prompt.Name, prompt.Source)
+		fmt.Printf("  %s\n", truncateString(strings.Join(strings.Fields(prompt.Text), " "), 100))
+	}
+	return nil
+}
+
+// promptsDir returns the directory of the named prompt library
+func promptsDir(cfg *config.Config) (string, error) {
+	if cfg.Transcribe.PromptsDir != "" {
+		return cfg.Transcribe.PromptsDir, nil
+	}
+	return prompts.DefaultDir()
+}
+
+// loadPromptLibrary loads the named prompts from the prompt directory and
+// the config file's prompt templates
+func loadPromptLibrary(cfg *config.Config) (*prompts.Library, error) {
+	dir, err := promptsDir(cfg)
+	if err != nil {
+		dir = ""
+	}
+	return prompts.Load(dir, cfg.Transcribe.PromptTemplates)
+}
+
+// namedPrompt returns the text of a prompt from the prompt library
+func namedPrompt(cfg *config.Config, name string) (string, error) {
+	lib, err := loadPromptLibrary(cfg)
+	if err != nil {
+		return "", err
+	}
+	return lib.Get(name)
+}
@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/providers/gemini"
+	"github.com/eternnoir/gollmscribe/pkg/providers/groq"
+	"github.com/eternnoir/gollmscribe/pkg/providers/whispercpp"
+)
+
+// initializeProvider creates the configured provider, combined into an
+// ensemble when provider.ensemble is set and wrapped in a fallback chain
+// when provider.fallbacks is set
+func initializeProvider(cfg *config.Config) (providers.LLMProvider, error) {
+	provider, err := buildProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrivacy(cfg.Privacy, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// checkPrivacy rejects providers that would send audio to a cloud service
+// when privacy.local_only is set. Providers make no requests until they
+// transcribe, so checking after construction is safe.
+func checkPrivacy(privacy config.PrivacyConfig, provider providers.LLMProvider) error {
+	if !privacy.LocalOnly || providers.IsLocal(provider) {
+		return nil
+	}
+	return fmt.Errorf("privacy.local_only is set but provider %s is not local; use a local provider such as whispercpp on a loopback or private address", provider.Name())
+}
+
+// buildProvider assembles the primary provider with its ensemble and fallbacks
+func buildProvider(cfg *config.Config) (providers.LLMProvider, error) {
+	log := logger.WithComponent("provider")
+
+	primary, err := newKeyedProvider(cfg.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Provider.Ensemble.Enabled() {
+		primary, err = newEnsembleProvider(cfg.Provider, primary)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("ensemble", primary.Name()).Msg("Ensemble transcription enabled")
+	}
+
+	if len(cfg.Provider.Fallbacks) == 0 {
+		return primary, nil
+	}
+
+	fallbacks := make([]providers.LLMProvider, 0, len(cfg.Provider.Fallbacks))
+	for _, fb := range cfg.Provider.Fallbacks {
+		provider, err := newRefProvider(cfg.Provider, fb)
+		if err != nil {
+			return nil, fmt.Errorf("fallback provider %s: %w", fb.Name, err)
+		}
+		fallbacks = append(fallbacks, provider)
+	}
+
+	chain := providers.NewFallbackProvider(primary, fallbacks...)
+	log.Info().Str("chain", chain.Name()).Msg("Provider fallback chain initialized")
+	return chain, nil
+}
+
+// newEnsembleProvider combines the primary provider with the configured
+// ensemble members. The primary provider adjudicates disagreements.
+func newEnsembleProvider(cfg config.ProviderConfig, primary providers.LLMProvider) (providers.LLMProvider, error) {
+	members := []providers.EnsembleMember{{Provider: primary, Weight: cfg.Ensemble.Weight}}
+	for _, ref := range cfg.Ensemble.Members {
+		provider, err := newRefProvider(cfg, ref)
+		if err != nil {
+			return nil, fmt.Errorf("ensemble provider %s: %w", ref.Name, err)
+		}
+		members = append(members, providers.EnsembleMember{Provider: provider, Weight: ref.Weight})
+	}
+
+	return providers.NewEnsembleProvider(providers.EnsembleMethod(cfg.Ensemble.Method), primary, members...)
+}
+
+// newRefProvider creates an additional provider that inherits the primary
+// provider's settings, overridden by the reference
+func newRefProvider(cfg config.ProviderConfig, ref config.ProviderRef) (providers.LLMProvider, error) {
+	refCfg := cfg
+	refCfg.Name = ref.Name
+	refCfg.APIKey = ref.APIKey
+	refCfg.Model = ref.Model
+	refCfg.BaseURL = ref.BaseURL
+	if ref.Name != "gemini" {
+		refCfg.Vertex = config.VertexConfig{}
+	}
+	return newLimitedProvider(refCfg)
+}
+
+// newRouteProvider creates the provider for a watch route. Routes that name
+// no provider, or the primary one, inherit its credentials and settings.
+func newRouteProvider(cfg config.ProviderConfig, ref config.ProviderRef) (providers.LLMProvider, error) {
+	if ref.Name == "" || ref.Name == cfg.Name {
+		routeCfg := cfg
+		if ref.APIKey != "" {
+			routeCfg.APIKey = ref.APIKey
+			routeCfg.APIKeys = nil
+		}
+		if ref.Model != "" {
+			routeCfg.Model = ref.Model
+		}
+		if ref.BaseURL != "" {
+			routeCfg.BaseURL = ref.BaseURL
+		}
+		return newKeyedProvider(routeCfg)
+	}
+	return newRefProvider(cfg, ref)
+}
+
+// newKeyedProvider creates a provider, rotating between API keys when
+// more than one is configured. The rotation moves on from a rate limited
+// key instead of letting its provider retry (see RotatingProvider).
+func newKeyedProvider(cfg config.ProviderConfig) (providers.LLMProvider, error) {
+	keys := cfg.APIKeys
+	if cfg.APIKey != "" {
+		keys = append([]string{cfg.APIKey}, keys...)
+	}
+	if len(keys) <= 1 {
+		if len(keys) == 1 {
+			cfg.APIKey = keys[0]
+		}
+		return newLimitedProvider(cfg)
+	}
+
+	var buildErr error
+	rotating, err := providers.NewRotatingProvider(keys, providers.KeyStrategy(cfg.KeyStrategy), func(apiKey string) providers.LLMProvider {
+		keyCfg := cfg
+		keyCfg.APIKey = apiKey
+		provider, err := newLimitedProvider(keyCfg)
+		if err != nil && buildErr == nil {
+			buildErr = err
+		}
+		return provider
+	})
+	if err != nil {
+		return nil, err
+	}
+	if buildErr != nil {
+		return nil, buildErr
+	}
+
+	logger.WithComponent("provider").Info().
+		Int("keys", len(keys)).
+		Str("strategy", cfg.KeyStrategy).
+		Msg("API key rotation enabled")
+	return rotating, nil
+}
+
+// keySlots are the per-key concurrency slots of the process, shared by
+// every provider using a key, by lock directory and limit
+var (
+	keySlotsMu sync.Mutex
+	keySlots   = make(map[string]*providers.KeySlots)
+)
+
+// newLimitedProvider creates a single provider whose requests take a slot
+// of its API key when provider.rate_limit.max_concurrent_per_key is set
+func newLimitedProvider(cfg config.ProviderConfig) (providers.LLMProvider, error) {
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return providers.LimitKeyConcurrency(provider, sharedKeySlots(cfg.RateLimit), cfg.APIKey), nil
+}
+
+// sharedKeySlots returns the slots for a rate limit configuration, the
+// same for every provider of the process, or nil without a cap
+func sharedKeySlots(cfg config.RateLimitConfig) *providers.KeySlots {
+	if cfg.MaxConcurrentPerKey <= 0 {
+		return nil
+	}
+	id := fmt.Sprintf("%d|%s|%s", cfg.MaxConcurrentPerKey, cfg.LockDir, cfg.LeaseTTL)
+	keySlotsMu.Lock()
+	defer keySlotsMu.Unlock()
+	if slots, ok := keySlots[id]; ok {
+		return slots
+	}
+	var backend providers.SlotBackend
+	if cfg.LockDir != "" {
+		backend = providers.NewFileSlots(cfg.LockDir, cfg.LeaseTTL)
+	}
+	slots := providers.NewKeySlots(cfg.MaxConcurrentPerKey, backend)
+	keySlots[id] = slots
+	logger.WithComponent("provider").Info().
+		Int("max_concurrent_per_key", cfg.MaxConcurrentPerKey).
+		Str("lock_dir", cfg.LockDir).
+		Msg("Per-key concurrency cap enabled")
+	return slots
+}
+
+// providerLanguage returns the ISO 639-1 code of transcribe.language for
+// providers taking a language hint, e.g. zh for zh-TW, or "" for auto
+func providerLanguage() string {
+	language := expectedLanguage(viper.GetString("transcribe.language"))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return strings.ToLower(language)
+}
+
+// newProvider creates and validates a single provider
+func newProvider(cfg config.ProviderConfig) (providers.LLMProvider, error) {
+	log := logger.WithComponent("provider")
+
+	switch cfg.Name {
+	case "gemini":
+		// Use longer timeout for audio transcription
+		timeout := cfg.Timeout
+		if timeout < 5*time.Minute {
+			timeout = 5 * time.Minute // Minimum 5 minutes for audio processing
+			log.Debug().
+				Dur("original_timeout", cfg.Timeout).
+				Dur("adjusted_timeout", timeout).
+				Msg("Adjusted timeout for audio processing")
+		}
+
+		log.Debug().
+			Dur("timeout", timeout).
+			Int("retries", cfg.Retries).
+			Msg("Creating Gemini provider")
+
+		options := []gemini.ProviderOption{
+			gemini.WithTimeout(timeout),
+			gemini.WithRetries(cfg.Retries),
+			gemini.WithModel(cfg.Model),
+		}
+		if cfg.BaseURL != "" {
+			options = append(options, gemini.WithBaseURL(cfg.BaseURL))
+		}
+		if cfg.EmbeddingModel != "" {
+			options = append(options, gemini.WithEmbeddingModel(cfg.EmbeddingModel))
+		}
+		if cfg.Vertex.Enabled() {
+			log.Debug().
+				Str("project", cfg.Vertex.Project).
+				Str("location", cfg.Vertex.Location).
+				Msg("Using Vertex AI authentication")
+			options = append(options,
+				gemini.WithVertexAI(cfg.Vertex.Project, cfg.Vertex.Location),
+				gemini.WithCredentialsFile(cfg.Vertex.CredentialsFile),
+			)
+		}
+
+		provider := gemini.NewProvider(cfg.APIKey, options...)
+
+		log.Debug().Msg("Validating provider configuration")
+		if err := provider.ValidateConfig(); err != nil {
+			log.Error().Err(err).Msg("Provider validation failed")
+			return nil, fmt.Errorf("provider validation failed: %w", err)
+		}
+
+		log.Info().Msg("Gemini provider initialized successfully")
+		return provider, nil
+	case "groq":
+		log.Debug().
+			Dur("timeout", cfg.Timeout).
+			Int("retries", cfg.Retries).
+			Msg("Creating Groq provider")
+
+		provider := groq.NewProvider(
+			cfg.APIKey,
+			groq.WithBaseURL(cfg.BaseURL),
+			groq.WithTimeout(cfg.Timeout),
+			groq.WithRetries(cfg.Retries),
+			groq.WithModel(cfg.Model),
+			groq.WithLanguage(providerLanguage()),
+		)
+
+		log.Debug().Msg("Validating provider configuration")
+		if err := provider.ValidateConfig(); err != nil {
+			log.Error().Err(err).Msg("Provider validation failed")
+			return nil, fmt.Errorf("provider validation failed: %w", err)
+		}
+
+		log.Info().Msg("Groq provider initialized successfully")
+		return provider, nil
+	case "whispercpp":
+		log.Debug().
+			Str("base_url", cfg.BaseURL).
+			Int("retries", cfg.Retries).
+			Msg("Creating whisper.cpp provider")
+
+		provider := whispercpp.NewProvider(
+			whispercpp.WithBaseURL(cfg.BaseURL),
+			whispercpp.WithTimeout(cfg.Timeout),
+			whispercpp.WithRetries(cfg.Retries),
+			whispercpp.WithModel(cfg.Model),
+			whispercpp.WithLanguage(providerLanguage()),
+		)
+
+		log.Debug().Msg("Validating provider configuration")
+		if err := provider.ValidateConfig(); err != nil {
+			log.Error().Err(err).Msg("Provider validation failed")
+			return nil, fmt.Errorf("provider validation failed: %w", err)
+		}
+
+		log.Info().Msg("whisper.cpp provider initialized successfully")
+		return provider, nil
+	default:
+		log.Error().Str("provider", cfg.Name).Msg("Unsupported provider")
+		return nil, fmt.Errorf("unsupported provider: %s", cfg.Name)
+	}
+}
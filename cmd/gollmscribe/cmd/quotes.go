@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/quotes"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// quotesCmd represents the quotes command
+var quotesCmd = &cobra.Command{
+	Use:   "quotes [result.json]",
+	Short: "Find time-coded quotes in a saved JSON result",
+	Long: `Print the segments of a JSON result that match a query, word for word
+with their speaker and timestamp, ready to cite in a report.
+
+Segments match when they contain every word of the query. When
+provider.embedding_model is configured they are ranked by meaning
+instead, using embeddings from the provider; --keyword keeps keyword
+matching.
+
+Examples:
+  # Quotes mentioning the budget
+  gollmscribe quotes meeting.json --query "budget"
+
+  # The five quotes closest in meaning, with provider.embedding_model set
+  gollmscribe quotes meeting.json --query "concerns about hiring" --limit 5
+
+  # Machine-readable output
+  gollmscribe quotes meeting.json --query "budget" --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runQuotes,
+}
+
+func init() {
+	rootCmd.AddCommand(quotesCmd)
+
+	quotesCmd.Flags().StringP("query", "q", "", "keyword or question to find quotes for")
+	quotesCmd.Flags().Int("limit", 10, "maximum quotes to print (0 = all)")
+	quotesCmd.Flags().Bool("keyword", false, "match keywords even when an embedding model is configured")
+	quotesCmd.Flags().Float64("min-score", quotes.DefaultMinScore, "lowest similarity of semantic matches (0-1)")
+	quotesCmd.Flags().Bool("json", false, "print the quotes as JSON")
+	_ = quotesCmd.MarkFlagRequired("query")
+}
+
+func runQuotes(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return err
+	}
+	result, err := transcriber.LoadEncryptedResult(args[0], cipher)
+	if err != nil {
+		return err
+	}
+
+	query, _ := cmd.Flags().GetString("query")
+	limit, _ := cmd.Flags().GetInt("limit")
+	keyword, _ := cmd.Flags().GetBool("keyword")
+
+	var found []quotes.Quote
+	if cfg.Provider.EmbeddingModel != "" && !keyword {
+		provider, err := initializeProvider(cfg)
+		if err != nil {
+			return err
+		}
+		minScore, _ := cmd.Flags().GetFloat64("min-score")
+		found, err = quotes.Semantic(cmd.Context(), provider, result, query, limit, minScore)
+		if err != nil {
+			return err
+		}
+	} else {
+		found, err = quotes.Keyword(result, query, limit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(found)
+	}
+
+	if len(found) == 0 {
+		fmt.Printf("No quotes match %q\n", query)
+		return nil
+	}
+	source := filepath.Base(args[0])
+	if result.FilePath != "" {
+		source = filepath.Base(result.FilePath)
+	}
+	for _, quote := range found {
+		fmt.Println(quote.Cite(source))
+	}
+	return nil
+}
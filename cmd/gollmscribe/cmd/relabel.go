@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// relabelCmd represents the relabel command
+var relabelCmd = &cobra.Command{
+	Use:   "relabel [result.json]",
+	Short: "Rename speakers in a saved JSON result",
+	Long: `Rename speakers in a JSON transcription result without transcribing
+the audio again. Speaker IDs of segments and "Label:" prefixes of
+transcript lines are renamed; labels match ignoring case.
+
+The result is rewritten in place unless --output is given. The output
+format follows the output extension: .txt, .srt, .vtt, otherwise JSON.
+
+Examples:
+  # Name the speakers the provider could only number
+  gollmscribe relabel meeting.json --speaker "Speaker 1=Alice" --speaker "Speaker 2=Bob"
+
+  # Write renamed subtitles next to the result
+  gollmscribe relabel meeting.json --speaker "Speaker 1=Alice" -o meeting.srt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRelabel,
+}
+
+func init() {
+	rootCmd.AddCommand(relabelCmd)
+
+	relabelCmd.Flags().StringArray("speaker", nil, "rename a speaker label, as LABEL=NAME (repeatable)")
+	relabelCmd.Flags().StringP("output", "o", "", "write the result to this file instead of rewriting the input")
+}
+
+func runRelabel(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	values, _ := cmd.Flags().GetStringArray("speaker")
+	speakers, err := parseSpeakerMap(values, cfg.Transcribe.SpeakerMap)
+	if err != nil {
+		return err
+	}
+	if len(speakers) == 0 {
+		return fmt.Errorf("no speaker renames given. Use --speaker LABEL=NAME or transcribe.speaker_map")
+	}
+
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return err
+	}
+	result, err := transcriber.LoadEncryptedResult(args[0], cipher)
+	if err != nil {
+		return err
+	}
+
+	renamed := transcriber.RelabelSpeakers(result, speakers)
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		outputPath = args[0]
+	}
+	if err := transcriber.SaveEncryptedResult(result, outputPath, resultFormat(outputPath), cipher); err != nil {
+		return err
+	}
+
+	fmt.Printf("Renamed %d speaker labels in %s\n", renamed, outputPath)
+	return nil
+}
+
+// resultFormat picks the result format for an output file extension,
+// keeping gzipped outputs such as meeting.json.gz compressed
+func resultFormat(path string) string {
+	if base, ok := strings.CutSuffix(path, transcriber.CompressionSuffix); ok {
+		return resultFormat(base) + transcriber.CompressionSuffix
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt":
+		return "text"
+	case ".srt":
+		return "srt"
+	case ".vtt":
+		return "vtt"
+	default:
+		return "json"
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+	"github.com/eternnoir/gollmscribe/pkg/watcher"
+)
+
+// retentionCmd represents the retention command
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Report or apply the watch retention policy",
+	Long: `Report or apply the retention policy for files processed in watch mode.
+
+Processed source media and transcripts older than watch.retention.media_days
+and watch.retention.transcript_days are deleted, or moved to
+watch.retention.archive_dir with action "archive". Every removal is appended
+to the audit log. Watch mode applies the policy automatically every hour.
+
+Examples:
+  # Show what would be removed
+  gollmscribe retention --dry-run
+
+  # Remove media older than 30 days and transcripts older than a year
+  gollmscribe retention --media-days 30 --transcript-days 365`,
+	Args: cobra.NoArgs,
+	RunE: runRetention,
+}
+
+func init() {
+	rootCmd.AddCommand(retentionCmd)
+
+	retentionCmd.Flags().String("history-db", ".gollmscribe-watch.db", "watch history database")
+	retentionCmd.Flags().Bool("dry-run", false, "only report what would be removed")
+	retentionCmd.Flags().Int("media-days", 0, "days to keep processed source media (overrides config)")
+	retentionCmd.Flags().Int("transcript-days", 0, "days to keep transcripts (overrides config)")
+}
+
+func runRetention(cmd *cobra.Command, args []string) error {
+	appCfg := loadConfig()
+	rc := appCfg.Watch.Retention
+	if cmd.Flags().Changed("media-days") {
+		rc.MediaDays, _ = cmd.Flags().GetInt("media-days")
+	}
+	if cmd.Flags().Changed("transcript-days") {
+		rc.TranscriptDays, _ = cmd.Flags().GetInt("transcript-days")
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		rc.DryRun = true
+	}
+
+	policy := retentionPolicy(rc)
+	if !policy.Enabled() {
+		return fmt.Errorf("no retention period configured. Set watch.retention.media_days or transcript_days, or use --media-days/--transcript-days")
+	}
+
+	historyDB, _ := cmd.Flags().GetString("history-db")
+	if _, err := os.Stat(historyDB); err != nil {
+		return fmt.Errorf("history database not found: %w", err)
+	}
+	cipher, err := loadCipher(appCfg)
+	if err != nil {
+		return err
+	}
+	history, err := watcher.NewEncryptedProcessingHistory(historyDB, cipher)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = history.Close() }()
+
+	entries, err := watcher.ApplyRetention(history, policy, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No expired files.")
+		return nil
+	}
+
+	verb := "Removed"
+	if policy.DryRun {
+		verb = "Would remove"
+	}
+	failed := 0
+	for _, entry := range entries {
+		age := humanize.Duration(time.Since(entry.ProcessedAt).Round(time.Hour))
+		switch {
+		case entry.Error != "":
+			failed++
+			fmt.Printf("✗ %s %s (%s): %s\n", entry.Action, entry.Path, entry.Kind, entry.Error)
+		case entry.ArchivedTo != "":
+			fmt.Printf("✓ Archived %s (%s, %s old) to %s\n", entry.Path, entry.Kind, age, entry.ArchivedTo)
+		default:
+			fmt.Printf("✓ %s %s (%s, %s old)\n", verb, entry.Path, entry.Kind, age)
+		}
+	}
+	fmt.Printf("\n%s %d file(s)", verb, len(entries)-failed)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	if !policy.DryRun && policy.AuditLog != "" {
+		fmt.Printf("Audit log: %s\n", policy.AuditLog)
+	}
+	return nil
+}
+
+// retentionPolicy converts the retention config into a watcher policy
+func retentionPolicy(rc config.RetentionConfig) watcher.RetentionPolicy {
+	const day = 24 * time.Hour
+	return watcher.RetentionPolicy{
+		MediaAfter:       time.Duration(rc.MediaDays) * day,
+		TranscriptsAfter: time.Duration(rc.TranscriptDays) * day,
+		Action:           rc.Action,
+		ArchiveDir:       rc.ArchiveDir,
+		DryRun:           rc.DryRun,
+		AuditLog:         rc.AuditLog,
+	}
+}
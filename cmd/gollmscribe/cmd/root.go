@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/eternnoir/gollmscribe/pkg/audio"
 	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
 	"github.com/eternnoir/gollmscribe/pkg/logger"
 )
 
@@ -21,7 +25,7 @@ var rootCmd = &cobra.Command{
 transcripts using advanced Large Language Models and multimodal AI processing capabilities.
 
 Features:
-- Support for multiple audio/video formats (WAV, MP3, M4A, FLAC, MP4)
+- Support for multiple audio/video formats (WAV, MP3, M4A, FLAC, OGG, Opus, AAC, WMA, AMR, MP4, WebM, 3GP)
 - Automatic video to audio conversion
 - Intelligent chunking with overlap for long audio files
 - Custom transcription prompts for different use cases
@@ -42,10 +46,27 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gollmscribe.yaml)")
+	rootCmd.PersistentFlags().String("config-key-file", "", "age identity file to decrypt a sops-encrypted config with")
+	rootCmd.PersistentFlags().String("config-kms", "", "KMS key (AWS ARN or GCP resource ID) a sops-encrypted config must be encrypted with")
 	rootCmd.PersistentFlags().String("api-key", "", "LLM provider API key")
-	rootCmd.PersistentFlags().String("provider", "gemini", "LLM provider (gemini, openai)")
+	rootCmd.PersistentFlags().StringSlice("api-keys", nil, "additional API keys to rotate between (comma-separated)")
+	rootCmd.PersistentFlags().String("key-strategy", "round_robin", "API key rotation strategy (round_robin, lru)")
+	rootCmd.PersistentFlags().Int("max-concurrent-per-key", 0, "requests in flight per API key across all workers (0 = unlimited)")
+	rootCmd.PersistentFlags().String("key-lock-dir", "", "share the --max-concurrent-per-key cap with other processes through lease files in this directory")
+	rootCmd.PersistentFlags().String("provider", "gemini", "LLM provider (gemini, groq, whispercpp)")
 	rootCmd.PersistentFlags().String("model", "", "model name to use (e.g., gemini-1.5-pro, gemini-2.5-flash)")
+	rootCmd.PersistentFlags().String("base-url", "", "provider API base URL (e.g., http://127.0.0.1:8080 for a whisper.cpp server)")
+	rootCmd.PersistentFlags().Bool("local-only", false, "refuse cloud providers and redact file paths in logs")
+	rootCmd.PersistentFlags().Float64("max-cost", 0, "maximum estimated cost in USD for a batch or watch session (0 = unlimited)")
+	rootCmd.PersistentFlags().String("encryption-key-file", "", "file with a base64 AES-256 key to encrypt transcripts and history at rest")
+	rootCmd.PersistentFlags().String("cache-dir", "", "cache provider responses per chunk in this directory so re-runs skip finished chunks")
+	rootCmd.PersistentFlags().String("manifest", "", "write a manifest of outputs and their SHA256 hashes to this file")
+	rootCmd.PersistentFlags().String("signing-key-file", "", "file with a base64 ed25519 key to sign the manifest")
 	rootCmd.PersistentFlags().String("temp-dir", "", "temporary directory for processing")
+	rootCmd.PersistentFlags().Int("max-temp-mb", 0, "fail jobs whose temp files would take the temp directory over this many MB (0 = unlimited)")
+	rootCmd.PersistentFlags().String("vertex-project", "", "GCP project for Gemini via Vertex AI (enables ADC auth instead of API key)")
+	rootCmd.PersistentFlags().String("vertex-location", "us-central1", "GCP region for Vertex AI")
+	rootCmd.PersistentFlags().String("credentials-file", "", "service account key file for Vertex AI (default: application default credentials)")
 	rootCmd.PersistentFlags().Bool("verbose", false, "verbose output (deprecated, use --log-level debug)")
 
 	// Logging flags
@@ -56,10 +77,20 @@ func init() {
 	rootCmd.PersistentFlags().Bool("log-caller", false, "include caller information in logs")
 
 	// Bind flags to viper
+	_ = viper.BindPFlag("config_key_file", rootCmd.PersistentFlags().Lookup("config-key-file"))
+	_ = viper.BindPFlag("config_kms", rootCmd.PersistentFlags().Lookup("config-kms"))
 	_ = viper.BindPFlag("api_key", rootCmd.PersistentFlags().Lookup("api-key"))
-	_ = viper.BindPFlag("provider", rootCmd.PersistentFlags().Lookup("provider"))
-	_ = viper.BindPFlag("model", rootCmd.PersistentFlags().Lookup("model"))
+	_ = viper.BindPFlag("provider.rate_limit.max_concurrent_per_key", rootCmd.PersistentFlags().Lookup("max-concurrent-per-key"))
+	_ = viper.BindPFlag("provider.rate_limit.lock_dir", rootCmd.PersistentFlags().Lookup("key-lock-dir"))
+	_ = viper.BindPFlag("provider.base_url", rootCmd.PersistentFlags().Lookup("base-url"))
+	_ = viper.BindPFlag("privacy.local_only", rootCmd.PersistentFlags().Lookup("local-only"))
+	_ = viper.BindPFlag("budget.max_cost_per_run", rootCmd.PersistentFlags().Lookup("max-cost"))
+	_ = viper.BindPFlag("encryption.key_file", rootCmd.PersistentFlags().Lookup("encryption-key-file"))
+	_ = viper.BindPFlag("cache.dir", rootCmd.PersistentFlags().Lookup("cache-dir"))
+	_ = viper.BindPFlag("manifest.path", rootCmd.PersistentFlags().Lookup("manifest"))
+	_ = viper.BindPFlag("manifest.signing_key_file", rootCmd.PersistentFlags().Lookup("signing-key-file"))
 	_ = viper.BindPFlag("temp_dir", rootCmd.PersistentFlags().Lookup("temp-dir"))
+	_ = viper.BindPFlag("audio.max_temp_mb", rootCmd.PersistentFlags().Lookup("max-temp-mb"))
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 
 	// Bind logging flags to viper
@@ -72,6 +103,42 @@ func init() {
 	// Environment variable bindings
 	viper.SetEnvPrefix("GOLLMSCRIBE")
 	viper.AutomaticEnv()
+	bindProviderKeys(viper.GetViper(), rootCmd)
+	_ = viper.BindEnv("provider.base_url", "GOLLMSCRIBE_BASE_URL")
+	_ = viper.BindEnv("privacy.local_only", "GOLLMSCRIBE_LOCAL_ONLY")
+	_ = viper.BindEnv("encryption.key", "GOLLMSCRIBE_ENCRYPTION_KEY")
+	_ = viper.BindEnv("manifest.signing_key", "GOLLMSCRIBE_SIGNING_KEY")
+}
+
+// bindProviderKeys binds the provider flags to the nested provider keys
+// of the config file. Before these were nested, the flags set flat keys
+// (provider, model, api_keys, ...), which hid the config file's provider
+// section; their environment variables keep working.
+func bindProviderKeys(v *viper.Viper, cmd *cobra.Command) {
+	// With AutomaticEnv, GOLLMSCRIBE_PROVIDER would hide every provider.*
+	// key, the config file's included, so it moves to provider.name's own
+	// variable
+	if name, ok := os.LookupEnv("GOLLMSCRIBE_PROVIDER"); ok {
+		_ = os.Unsetenv("GOLLMSCRIBE_PROVIDER")
+		_ = os.Setenv("GOLLMSCRIBE_PROVIDER_NAME", name)
+	}
+
+	flags := cmd.PersistentFlags()
+	_ = v.BindPFlag("provider.name", flags.Lookup("provider"))
+	_ = v.BindPFlag("provider.model", flags.Lookup("model"))
+	_ = v.BindPFlag("provider.api_keys", flags.Lookup("api-keys"))
+	_ = v.BindPFlag("provider.key_strategy", flags.Lookup("key-strategy"))
+	_ = v.BindPFlag("provider.vertex.project", flags.Lookup("vertex-project"))
+	_ = v.BindPFlag("provider.vertex.location", flags.Lookup("vertex-location"))
+	_ = v.BindPFlag("provider.vertex.credentials_file", flags.Lookup("credentials-file"))
+
+	_ = v.BindEnv("provider.name", "GOLLMSCRIBE_PROVIDER_NAME")
+	_ = v.BindEnv("provider.model", "GOLLMSCRIBE_MODEL")
+	_ = v.BindEnv("provider.api_keys", "GOLLMSCRIBE_API_KEYS")
+	_ = v.BindEnv("provider.key_strategy", "GOLLMSCRIBE_KEY_STRATEGY")
+	_ = v.BindEnv("provider.vertex.project", "GOLLMSCRIBE_VERTEX_PROJECT")
+	_ = v.BindEnv("provider.vertex.location", "GOLLMSCRIBE_VERTEX_LOCATION")
+	_ = v.BindEnv("provider.vertex.credentials_file", "GOLLMSCRIBE_CREDENTIALS_FILE", "GOOGLE_APPLICATION_CREDENTIALS")
 }
 
 // initConfig reads in config file and ENV variables.
@@ -91,19 +158,45 @@ func initConfig() {
 		viper.SetConfigName(".gollmscribe")
 	}
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in, decrypting it if it is
+	// encrypted with sops
 	configFileUsed := ""
-	if err := viper.ReadInConfig(); err == nil {
+	err := config.ReadInConfig(viper.GetViper(), config.DecryptOptions{
+		KeyFile: viper.GetString("config_key_file"),
+		KMS:     viper.GetString("config_kms"),
+	})
+	if err == nil {
 		configFileUsed = viper.ConfigFileUsed()
 	}
 
 	// Initialize logger
 	initLogger()
 
+	// Running with the ciphertext in place of credentials would only fail later
+	if errors.Is(err, config.ErrConfigDecryption) {
+		cobra.CheckErr(err)
+	}
+
 	// Log config file usage after logger is initialized
 	if configFileUsed != "" {
 		logger.Info().Str("config_file", configFileUsed).Msg("Loaded configuration file")
 	}
+
+	// Format numbers in summaries for the user's locale
+	humanize.SetLocale(viper.GetString("output.locale"))
+
+	// Prefer a static ffmpeg in audio.ffmpeg_dir
+	if dir, err := ffmpegDir(); err == nil && audio.UseFFmpegDir(dir) {
+		logger.Debug().Str("ffmpeg_dir", dir).Msg("Using installed ffmpeg")
+	}
+}
+
+// ffmpegDir returns the directory for a static ffmpeg install
+func ffmpegDir() (string, error) {
+	if dir := viper.GetString("audio.ffmpeg_dir"); dir != "" {
+		return dir, nil
+	}
+	return audio.DefaultFFmpegDir()
 }
 
 // initLogger initializes the logger based on configuration
@@ -114,7 +207,15 @@ func initLogger() {
 	cfg.Logging.Level = viper.GetString("logging.level")
 	cfg.Logging.Format = viper.GetString("logging.format")
 	cfg.Logging.Output = viper.GetString("logging.output")
+	if stdoutOutput(transcribeCmd) && (cfg.Logging.Output == "" || strings.EqualFold(cfg.Logging.Output, "stdout")) {
+		// Logs would end up in the transcript piped on
+		cfg.Logging.Output = "stderr"
+	}
 	cfg.Logging.Caller = viper.GetBool("logging.caller")
+	cfg.Logging.RedactPaths = config.PrivacyConfig{
+		LocalOnly:   viper.GetBool("privacy.local_only"),
+		RedactPaths: viper.GetBool("privacy.redact_paths"),
+	}.ShouldRedactPaths()
 
 	// Handle legacy verbose flag
 	if viper.GetBool("verbose") && cfg.Logging.Level == "info" {
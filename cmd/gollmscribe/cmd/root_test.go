@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const nestedProviderConfig = `provider:
+  name: openai
+  model: gpt-4o-audio-preview
+  api_keys: [key-a, key-b]
+  key_strategy: lru
+  timeout: 45s
+  vertex:
+    project: from-config
+    location: europe-west4
+`
+
+func newProviderViper(t *testing.T) *viper.Viper {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".gollmscribe.yaml")
+	if err := os.WriteFile(path, []byte(nestedProviderConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v := viper.New()
+	v.SetEnvPrefix("GOLLMSCRIBE")
+	v.AutomaticEnv()
+	bindProviderKeys(v, rootCmd)
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestBindProviderKeysNested(t *testing.T) {
+	v := newProviderViper(t)
+
+	want := map[string]string{
+		"provider.name":            "openai",
+		"provider.model":           "gpt-4o-audio-preview",
+		"provider.key_strategy":    "lru",
+		"provider.vertex.project":  "from-config",
+		"provider.vertex.location": "europe-west4",
+	}
+	for key, value := range want {
+		if got := v.GetString(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if keys := v.GetStringSlice("provider.api_keys"); len(keys) != 2 || keys[1] != "key-b" {
+		t.Errorf("provider.api_keys = %v, want [key-a key-b]", keys)
+	}
+}
+
+func TestBindProviderKeysLegacyEnv(t *testing.T) {
+	env := map[string]string{
+		"GOLLMSCRIBE_PROVIDER":         "gemini",
+		"GOLLMSCRIBE_MODEL":            "gemini-2.5-flash",
+		"GOLLMSCRIBE_API_KEYS":         "env-a,env-b",
+		"GOLLMSCRIBE_KEY_STRATEGY":     "round_robin",
+		"GOLLMSCRIBE_VERTEX_PROJECT":   "from-env",
+		"GOLLMSCRIBE_VERTEX_LOCATION":  "us-east1",
+		"GOLLMSCRIBE_CREDENTIALS_FILE": "/etc/gollmscribe/sa.json",
+	}
+	// Registered first, so it is unset again after the test
+	t.Setenv("GOLLMSCRIBE_PROVIDER_NAME", "")
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+	v := newProviderViper(t)
+
+	want := map[string]string{
+		"provider.name":                    "gemini",
+		"provider.model":                   "gemini-2.5-flash",
+		"provider.api_keys":                "env-a,env-b",
+		"provider.key_strategy":            "round_robin",
+		"provider.vertex.project":          "from-env",
+		"provider.vertex.location":         "us-east1",
+		"provider.vertex.credentials_file": "/etc/gollmscribe/sa.json",
+	}
+	for key, value := range want {
+		if got := v.GetString(key); got != value {
+			t.Errorf("%s = %q, want %q from the environment", key, got, value)
+		}
+	}
+	if got := v.GetString("provider.timeout"); got != "45s" {
+		t.Errorf("provider.timeout = %q, want the config file's 45s next to GOLLMSCRIBE_PROVIDER", got)
+	}
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// siblingFormats are the outputs next to a JSON result that assign rewrites
+var siblingFormats = []string{".txt", ".srt", ".vtt"}
+
+// speakersCmd groups commands working on the speakers of a saved result
+var speakersCmd = &cobra.Command{
+	Use:   "speakers",
+	Short: "Work with the speakers of a saved JSON result",
+}
+
+// speakersAssignCmd names anonymous speakers interactively
+var speakersAssignCmd = &cobra.Command{
+	Use:   "assign [result.json]",
+	Short: "Name speakers interactively from samples of what they said",
+	Long: `Show representative segments of every speaker in a JSON result and
+ask for their real names, then rename them as relabel does. Press Enter
+to keep a label.
+
+The result is rewritten in place unless --output is given, together with
+the .txt, .srt and .vtt outputs next to it (meeting.txt for meeting.json),
+which are regenerated from the renamed result. With --play each sample is
+played with ffplay from the source recording, or --audio.
+
+Examples:
+  # Read samples and type names
+  gollmscribe speakers assign meeting.json
+
+  # Listen to the samples as well
+  gollmscribe speakers assign meeting.json --play`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSpeakersAssign,
+}
+
+// speakersSplitCmd writes one file per speaker
+var speakersSplitCmd = &cobra.Command{
+	Use:   "split [result.json]",
+	Short: "Write everything each speaker said to a file of its own",
+	Long: `Write the segments of every speaker in a JSON result, with their
+timestamps, to one text file per speaker: meeting.alice.txt,
+meeting.speaker-2.txt and so on, next to the result or to --output.
+Segments without a speaker go to meeting.unlabeled.txt.
+
+Examples:
+  # One file per speaker for deposition or interview coding
+  gollmscribe speakers split deposition.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSpeakersSplit,
+}
+
+func init() {
+	rootCmd.AddCommand(speakersCmd)
+	speakersCmd.AddCommand(speakersAssignCmd)
+	speakersCmd.AddCommand(speakersSplitCmd)
+
+	speakersSplitCmd.Flags().StringP("output", "o", "", "name the speaker files after this path instead of the result")
+
+	speakersAssignCmd.Flags().Int("samples", 3, "segments shown per speaker")
+	speakersAssignCmd.Flags().Bool("play", false, "play each sample with ffplay")
+	speakersAssignCmd.Flags().String("audio", "", "recording to play samples from (default: the result's source file)")
+	speakersAssignCmd.Flags().StringP("output", "o", "", "write the result to this file instead of rewriting the input")
+}
+
+func runSpeakersAssign(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return err
+	}
+	result, err := transcriber.LoadEncryptedResult(args[0], cipher)
+	if err != nil {
+		return err
+	}
+
+	perSpeaker, _ := cmd.Flags().GetInt("samples")
+	samples := transcriber.SpeakerSamples(result, perSpeaker)
+	if len(samples) == 0 {
+		return fmt.Errorf("%s has no segments with speaker labels", args[0])
+	}
+
+	var player, audioPath string
+	if play, _ := cmd.Flags().GetBool("play"); play {
+		if player, err = exec.LookPath("ffplay"); err != nil {
+			return fmt.Errorf("--play needs ffplay on PATH: %w", err)
+		}
+		audioPath, _ = cmd.Flags().GetString("audio")
+		if audioPath == "" {
+			audioPath = result.FilePath
+		}
+		if _, err := os.Stat(audioPath); err != nil {
+			return fmt.Errorf("cannot play samples, use --audio: %w", err)
+		}
+	}
+
+	speakers, err := askSpeakerNames(cmd.InOrStdin(), samples, func(segment providers.TranscriptionSegment) {
+		if player == "" {
+			return
+		}
+		if err := playSegment(player, audioPath, segment); err != nil {
+			fmt.Printf("  (playback failed: %v)\n", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(speakers) == 0 {
+		fmt.Println("No speakers renamed")
+		return nil
+	}
+
+	renamed := transcriber.RelabelSpeakers(result, speakers)
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		outputPath = args[0]
+	}
+	if err := transcriber.SaveEncryptedResult(result, outputPath, resultFormat(outputPath), cipher); err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %d speaker labels in %s\n", renamed, outputPath)
+
+	// Regenerate the other outputs of this result
+	base := strings.TrimSuffix(args[0], filepath.Ext(args[0]))
+	for _, ext := range siblingFormats {
+		sibling := base + ext
+		if sibling == args[0] || sibling == outputPath {
+			continue
+		}
+		if _, err := os.Stat(sibling); err != nil {
+			continue
+		}
+		if err := transcriber.SaveEncryptedResult(result, sibling, resultFormat(sibling), cipher); err != nil {
+			return err
+		}
+		fmt.Printf("Rewrote %s\n", sibling)
+	}
+	return nil
+}
+
+func runSpeakersSplit(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return err
+	}
+	result, err := transcriber.LoadEncryptedResult(args[0], cipher)
+	if err != nil {
+		return err
+	}
+
+	outputPath, _ := cmd.Flags().GetString("output")
+	if outputPath == "" {
+		outputPath = args[0]
+	}
+	paths, err := transcriber.SaveSpeakerTranscripts(result, outputPath, cipher)
+	for _, path := range paths {
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return err
+}
+
+// askSpeakerNames prints the samples of each speaker and reads a name for
+// it from in, returning the renames. play is called for every sample shown.
+func askSpeakerNames(in io.Reader, samples []transcriber.SpeakerSample, play func(providers.TranscriptionSegment)) (map[string]string, error) {
+	reader := bufio.NewReader(in)
+	speakers := make(map[string]string)
+
+	for _, sample := range samples {
+		fmt.Printf("\n%s: %d segments, %s spoken\n", sample.Speaker, sample.Count, humanize.Duration(sample.Duration))
+		for _, segment := range sample.Segments {
+			fmt.Printf("  [%s] %s\n", formatClock(segment.Start), strings.TrimSpace(segment.Text))
+			play(segment)
+		}
+
+		fmt.Printf("Name for %s (Enter to keep): ", sample.Speaker)
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read name: %w", err)
+		}
+		if name := strings.TrimSpace(answer); name != "" && name != sample.Speaker {
+			speakers[sample.Speaker] = name
+		}
+		if err == io.EOF {
+			fmt.Println()
+			break
+		}
+	}
+	return speakers, nil
+}
+
+// playSegment plays the audio of a segment with ffplay
+func playSegment(player, audioPath string, segment providers.TranscriptionSegment) error {
+	play := exec.Command(player, "-nodisp", "-autoexit", "-loglevel", "error",
+		"-ss", fmt.Sprintf("%.3f", segment.Start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", (segment.End-segment.Start).Seconds()),
+		audioPath)
+	play.Stderr = os.Stderr
+	return play.Run()
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+)
+
+// streamCmd represents the stream command
+var streamCmd = &cobra.Command{
+	Use:   "stream [url]",
+	Short: "Transcribe a live RTSP, HLS or Icecast stream",
+	Long: `Tail a continuous stream with ffmpeg and transcribe it in fixed-length
+chunks: every --segment-seconds the received audio is sent to the
+provider and its segments are printed, timestamped from the start of
+the capture.
+
+Press Ctrl+C, or set --max-duration, to stop. The chunk being recorded
+is finished and transcribed, and the merged transcript is saved to
+--output (default: stream-<date>-<time>.txt). A stream that ends on its
+own, such as a finished broadcast, stops the capture the same way.
+
+Supported URLs are rtsp://, rtmp://, srt://, udp:// and http(s)://,
+which covers HLS playlists (.m3u8) and Icecast/Shoutcast radio. RTSP is
+read over TCP and HTTP streams reconnect after a dropped connection.
+
+Examples:
+  # A meeting room camera for an hour
+  gollmscribe stream rtsp://camera.local:554/live --max-duration 1h -o standup.txt
+
+  # Internet radio in two-minute chunks, with a named prompt
+  gollmscribe stream https://radio.example.com/live.mp3 --segment-seconds 120 --prompt-name news`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStream,
+}
+
+func init() {
+	rootCmd.AddCommand(streamCmd)
+
+	addCaptureFlags(streamCmd, 60)
+}
+
+func runStream(cmd *cobra.Command, args []string) error {
+	input, err := audio.StreamInput(args[0])
+	if err != nil {
+		return err
+	}
+	return transcribeCapture(cmd, input)
+}
@@ -1,19 +1,38 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/budget"
+	"github.com/eternnoir/gollmscribe/pkg/cache"
 	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
 	"github.com/eternnoir/gollmscribe/pkg/logger"
-	"github.com/eternnoir/gollmscribe/pkg/providers/gemini"
+	"github.com/eternnoir/gollmscribe/pkg/manifest"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+	"github.com/eternnoir/gollmscribe/pkg/postprocess"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/rules"
+	"github.com/eternnoir/gollmscribe/pkg/store"
+	"github.com/eternnoir/gollmscribe/pkg/tempfiles"
 	"github.com/eternnoir/gollmscribe/pkg/transcriber"
 )
 
@@ -24,8 +43,8 @@ var transcribeCmd = &cobra.Command{
 	Long: `Transcribe audio or video files to text using AI models.
 
 Supported formats:
-- Audio: WAV, MP3, M4A, FLAC
-- Video: MP4 (automatically converted to audio)
+- Audio: WAV, MP3, M4A, FLAC, OGG, Opus, AAC, WMA, AMR
+- Video: MP4, AVI, MOV, MKV, WebM, 3GP (automatically converted to audio)
 
 Examples:
   # Transcribe a single file
@@ -40,9 +59,51 @@ Examples:
   # Batch transcribe with custom settings
   gollmscribe transcribe *.wav --chunk-minutes 20 --overlap-seconds 45
 
+  # Transcribe as readable prose without filler words
+  gollmscribe transcribe interview.mp3 --style clean
+
+  # Tag each line of a recording mixing Mandarin and English with its language
+  gollmscribe transcribe standup.mp3 --segment-languages -o standup.json
+
   # Transcribe with prompt file
-  gollmscribe transcribe interview.mp3 --prompt-file my-prompt.txt`,
-	Args: cobra.MinimumNArgs(1),
+  gollmscribe transcribe interview.mp3 --prompt-file my-prompt.txt
+
+  # Transcribe with a named prompt from ~/.config/gollmscribe/prompts/sales-call.md
+  gollmscribe transcribe call.mp3 --prompt-name sales-call
+
+  # Transcribe and write a summary with decisions and action items to meeting.summary.md
+  gollmscribe transcribe meeting.mp3 --summary-file
+
+  # Write YouTube-style chapters to lecture.chapters.txt
+  gollmscribe transcribe lecture.mp4 --chapters-file
+
+  # Write subtitles with each line in the original language and in Spanish
+  gollmscribe transcribe talk.mp4 --translate Spanish --bilingual
+
+  # Stereo call recording with the agent on the left channel and the customer on the right
+  gollmscribe transcribe call.wav --call-center -o call.json
+
+  # Also write one timestamped file per speaker (deposition.counsel.txt, ...)
+  gollmscribe transcribe deposition.mp3 --per-speaker
+
+  # Print segments while a long recording is still being transcribed
+  gollmscribe transcribe lecture.mp4 --stream
+
+  # Retry failed chunks twice, then keep a transcript with gaps
+  gollmscribe transcribe long-call.mp3 --chunk-retries 2 --allow-partial
+
+  # Name outputs after the run's job ID (interview.3f9a2c1d.txt)
+  gollmscribe transcribe interview.mp3 --output-template "{name}.{job}.txt"
+
+  # Resume an interrupted run by its job ID (see gollmscribe jobs)
+  gollmscribe transcribe --resume-job 3f9a2c1d`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// A resumed job already names its file
+		if job, _ := cmd.Flags().GetString("resume-job"); job != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runTranscribe,
 }
 
@@ -50,21 +111,69 @@ func init() {
 	rootCmd.AddCommand(transcribeCmd)
 
 	// Output options
-	transcribeCmd.Flags().StringP("output", "o", "", "output file path (default: input_file.txt)")
+	transcribeCmd.Flags().StringP("output", "o", "", "output file path, or - for stdout (default: input_file.txt)")
+	transcribeCmd.Flags().Bool("stdout", false, "write the transcript to stdout for shell pipelines, with logs and summaries on stderr; same as -o -")
+	transcribeCmd.Flags().String("stdout-format", "text", "format of the transcript written to stdout: text, json, srt, vtt, ...")
+	transcribeCmd.Flags().StringSlice("formats", nil, "also write these formats next to the output, rendered concurrently: json, srt, vtt, bilingual-srt, bilingual-vtt, chapters, html, html-audio, audacity, textgrid; add .gz to compress, e.g. json.gz")
+	transcribeCmd.Flags().String("format-template", "", "also render this Go text/template file with the result, e.g. anki.csv.tmpl writes input_file.anki.csv")
+	transcribeCmd.Flags().Bool("keep-versions", false, "keep an existing transcript and write the new one as name_v2.txt, name_v3.txt, ...")
+	transcribeCmd.Flags().String("output-template", "", "output filename template with {name}, {job} and {date}, e.g. {name}.{job}.txt")
 
 	// Transcription options
 	transcribeCmd.Flags().StringP("prompt", "p", "", "custom transcription prompt")
 	transcribeCmd.Flags().String("prompt-file", "", "file containing custom prompt")
+	transcribeCmd.Flags().String("style", "", "transcript style: verbatim (every filler word), clean (readable prose) or notes (condensed bullet points)")
+	transcribeCmd.Flags().String("prompt-name", "", "use a named prompt from the prompt library (see gollmscribe prompts)")
+	transcribeCmd.Flags().Bool("segment-languages", false, "tag the language of every segment, for recordings that switch languages")
+	transcribeCmd.Flags().String("language", "auto", "language the audio is spoken in, e.g. ja or zh-TW")
+	transcribeCmd.Flags().String("language-check", "", "catch chunks transcribed in another language than --language: flag them, or rerun them with a corrective prompt")
 
 	// Processing options
 	transcribeCmd.Flags().Int("chunk-minutes", 15, "chunk duration in minutes")
 	transcribeCmd.Flags().Int("overlap-seconds", 30, "overlap duration in seconds")
 	transcribeCmd.Flags().Int("workers", 3, "number of concurrent workers")
+	transcribeCmd.Flags().Bool("adaptive-workers", false, "scale workers up and down with provider latency and errors, within audio.min_workers and audio.max_workers")
 	transcribeCmd.Flags().Float32("temperature", 0.1, "LLM temperature (0.0-1.0)")
 
 	// Advanced options
 	transcribeCmd.Flags().Bool("preserve-audio", false, "keep temporary audio files")
+	transcribeCmd.Flags().Bool("trim-silence", false, "cut long leading/trailing silence from chunks before upload")
+	transcribeCmd.Flags().Int("sample-rate", 0, "sample rate of the audio uploaded, e.g. 16000 for much smaller uploads (default: audio.sample_rate)")
+	transcribeCmd.Flags().Int("channels", 0, "channels of the audio uploaded, 1 for mono (default: audio.channels)")
+	transcribeCmd.Flags().Bool("loudnorm", false, "normalize the loudness of each chunk, for quiet or uneven speakers")
+	transcribeCmd.Flags().Float64("denoise", 0, "reduce background noise by this many dB before upload, e.g. 12")
+	transcribeCmd.Flags().Bool("skip-silence", false, "remove silences longer than audio.skip_silence_seconds (default 10) before chunking; times still refer to the original audio")
+	transcribeCmd.Flags().String("merge-strategy", "", "how chunk transcripts are joined: text-align (default), timestamp, naive or llm-assisted")
+	transcribeCmd.Flags().Bool("leading-context", false, "send the overlap as context-only audio at the start of each chunk instead of transcribing it twice")
+	transcribeCmd.Flags().Bool("in-memory-chunks", false, "keep chunk audio in memory instead of writing chunk files to the temp directory")
+	transcribeCmd.Flags().String("voice-profiles", "", "directory of speaker recordings (e.g. Alice.mp3) used to label speakers by name")
+	transcribeCmd.Flags().StringArray("speaker", nil, "rename a speaker label, as LABEL=NAME (repeatable)")
+	transcribeCmd.Flags().Bool("native-diarization", false, "use the speaker labels of providers that diarize natively (whisper.cpp) instead of prompt-based identification")
 	transcribeCmd.Flags().Bool("progress", true, "show progress during transcription")
+	transcribeCmd.Flags().Bool("show-chunks", false, "print the chunk boundaries each file would be split into and exit without transcribing")
+	transcribeCmd.Flags().Bool("stream", false, "print segments as soon as their chunk is transcribed")
+	transcribeCmd.Flags().Bool("timing", false, "print where each chunk's time went: ffmpeg encoding, upload, provider latency and response parsing")
+	transcribeCmd.Flags().Int("chunk-retries", 0, "times to retry a failed chunk before giving up on it")
+	transcribeCmd.Flags().Bool("allow-partial", false, "write a transcript with gaps instead of failing when chunks still fail")
+	transcribeCmd.Flags().Bool("resume", false, "resume an interrupted transcription, skipping chunks already completed")
+	transcribeCmd.Flags().String("resume-job", "", "resume the interrupted job with this ID (see gollmscribe jobs)")
+	transcribeCmd.Flags().StringArray("note", nil, "operator note stored in the transcript's metadata, e.g. \"board meeting, confidential\" (repeatable)")
+	transcribeCmd.Flags().BoolP("yes", "y", false, "continue without asking when the estimated cost exceeds --max-cost or the daily quota")
+	transcribeCmd.Flags().String("quota-check", "", "before batches of budget.quota_check_files files or more, warn or abort if they would use up the daily quota (off, warn, abort)")
+	transcribeCmd.Flags().Bool("summarize", false, "summarize the transcript (summary, decisions, action items) with a second request to the provider")
+	transcribeCmd.Flags().String("summary-prompt", "", "prompt used by --summarize instead of the default")
+	transcribeCmd.Flags().Bool("summary-file", false, "write the summary to <output>.summary.md (implies --summarize)")
+	transcribeCmd.Flags().Bool("chapters", false, "split the transcript into chapters where the topic changes, with a second request to the provider")
+	transcribeCmd.Flags().Bool("chapters-file", false, "write YouTube-style chapters to <output>.chapters.txt (implies --chapters)")
+	transcribeCmd.Flags().String("translate", "", "translate each segment into this language, e.g. Spanish, with further requests to the provider")
+	transcribeCmd.Flags().Bool("bilingual", false, "write subtitles with the original and translated line per cue to <output>.bilingual.srt and .bilingual.vtt (needs --translate)")
+	transcribeCmd.Flags().String("embedded-subtitles", "", "use subtitle tracks videos already have: extract writes them to <output>.embedded.srt to compare with the transcript, prefer uses them instead of transcribing")
+	transcribeCmd.Flags().String("subtitles-language", "", "language of the embedded subtitle track to use, e.g. en (default: transcribe.language, auto for any)")
+	transcribeCmd.Flags().Bool("mux-subtitles", false, "also write a copy of each MP4/MOV/MKV/WebM video with the transcript as a soft subtitle track, e.g. meeting.subtitled.mp4")
+	transcribeCmd.Flags().Bool("per-speaker", false, "also write one file per speaker with timestamps, e.g. meeting.alice.txt")
+	transcribeCmd.Flags().String("store", "", "transcript store directory; reuse stored transcripts for already-transcribed media")
+	transcribeCmd.Flags().Bool("call-center", false, "stereo call recording: transcribe each channel separately with fixed speaker labels and compute hold/silence metrics")
+	transcribeCmd.Flags().StringSlice("call-speakers", []string{"Agent", "Customer"}, "speaker of each channel for --call-center, left first")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("transcribe.chunk_minutes", transcribeCmd.Flags().Lookup("chunk-minutes"))
@@ -72,6 +181,42 @@ func init() {
 	_ = viper.BindPFlag("transcribe.workers", transcribeCmd.Flags().Lookup("workers"))
 	_ = viper.BindPFlag("transcribe.temperature", transcribeCmd.Flags().Lookup("temperature"))
 	_ = viper.BindPFlag("transcribe.preserve_audio", transcribeCmd.Flags().Lookup("preserve-audio"))
+	_ = viper.BindPFlag("transcribe.chunk_retries", transcribeCmd.Flags().Lookup("chunk-retries"))
+	_ = viper.BindPFlag("transcribe.allow_partial", transcribeCmd.Flags().Lookup("allow-partial"))
+	_ = viper.BindPFlag("audio.trim_silence", transcribeCmd.Flags().Lookup("trim-silence"))
+	_ = viper.BindPFlag("audio.skip_silence", transcribeCmd.Flags().Lookup("skip-silence"))
+	_ = viper.BindPFlag("audio.sample_rate", transcribeCmd.Flags().Lookup("sample-rate"))
+	_ = viper.BindPFlag("audio.channels", transcribeCmd.Flags().Lookup("channels"))
+	_ = viper.BindPFlag("audio.filters.loudnorm", transcribeCmd.Flags().Lookup("loudnorm"))
+	_ = viper.BindPFlag("audio.filters.denoise_db", transcribeCmd.Flags().Lookup("denoise"))
+	_ = viper.BindPFlag("transcribe.style", transcribeCmd.Flags().Lookup("style"))
+	_ = viper.BindPFlag("transcribe.segment_languages", transcribeCmd.Flags().Lookup("segment-languages"))
+	_ = viper.BindPFlag("transcribe.language", transcribeCmd.Flags().Lookup("language"))
+	_ = viper.BindPFlag("transcribe.language_check", transcribeCmd.Flags().Lookup("language-check"))
+	_ = viper.BindPFlag("transcribe.merge_strategy", transcribeCmd.Flags().Lookup("merge-strategy"))
+	_ = viper.BindPFlag("audio.leading_context", transcribeCmd.Flags().Lookup("leading-context"))
+	_ = viper.BindPFlag("audio.in_memory_chunks", transcribeCmd.Flags().Lookup("in-memory-chunks"))
+	_ = viper.BindPFlag("audio.adaptive_workers", transcribeCmd.Flags().Lookup("adaptive-workers"))
+	_ = viper.BindPFlag("transcribe.voice_profiles_dir", transcribeCmd.Flags().Lookup("voice-profiles"))
+	_ = viper.BindPFlag("transcribe.native_diarization", transcribeCmd.Flags().Lookup("native-diarization"))
+	_ = viper.BindPFlag("summary.enabled", transcribeCmd.Flags().Lookup("summarize"))
+	_ = viper.BindPFlag("summary.prompt", transcribeCmd.Flags().Lookup("summary-prompt"))
+	_ = viper.BindPFlag("summary.file", transcribeCmd.Flags().Lookup("summary-file"))
+	_ = viper.BindPFlag("chapters.enabled", transcribeCmd.Flags().Lookup("chapters"))
+	_ = viper.BindPFlag("chapters.file", transcribeCmd.Flags().Lookup("chapters-file"))
+	_ = viper.BindPFlag("translation.language", transcribeCmd.Flags().Lookup("translate"))
+	_ = viper.BindPFlag("translation.bilingual", transcribeCmd.Flags().Lookup("bilingual"))
+	_ = viper.BindPFlag("output.per_speaker", transcribeCmd.Flags().Lookup("per-speaker"))
+	_ = viper.BindPFlag("output.filename", transcribeCmd.Flags().Lookup("output-template"))
+	_ = viper.BindPFlag("output.formats", transcribeCmd.Flags().Lookup("formats"))
+	_ = viper.BindPFlag("output.template", transcribeCmd.Flags().Lookup("format-template"))
+	_ = viper.BindPFlag("output.keep_versions", transcribeCmd.Flags().Lookup("keep-versions"))
+	_ = viper.BindPFlag("transcribe.embedded_subtitles", transcribeCmd.Flags().Lookup("embedded-subtitles"))
+	_ = viper.BindPFlag("transcribe.subtitles_language", transcribeCmd.Flags().Lookup("subtitles-language"))
+	_ = viper.BindPFlag("output.mux_subtitles", transcribeCmd.Flags().Lookup("mux-subtitles"))
+	_ = viper.BindPFlag("budget.quota_check", transcribeCmd.Flags().Lookup("quota-check"))
+	_ = viper.BindPFlag("call.enabled", transcribeCmd.Flags().Lookup("call-center"))
+	_ = viper.BindPFlag("call.speakers", transcribeCmd.Flags().Lookup("call-speakers"))
 }
 
 func runTranscribe(cmd *cobra.Command, args []string) error {
@@ -79,17 +224,26 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 
 	log.Info().Int("file_count", len(args)).Strs("files", args).Msg("Starting transcription")
 
-	// Validate API key
-	apiKey := viper.GetString("api_key")
-	if apiKey == "" {
-		log.Error().Msg("API key is required")
-		return fmt.Errorf("API key is required. Set GOLLMSCRIBE_API_KEY environment variable or use --api-key flag")
-	}
+	// Ctrl+C cancels the run, stopping ffmpeg and requests in flight
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cmd.SetContext(ctx)
 
 	// Get configuration
 	cfg := loadConfig()
 	log.Debug().Interface("config", cfg).Msg("Loaded configuration")
 
+	// Previewing chunks needs no provider
+	if showChunks, _ := cmd.Flags().GetBool("show-chunks"); showChunks {
+		return showChunkPlans(cmd, cfg, args)
+	}
+
+	// Validate credentials
+	if err := requireCredentials(cfg); err != nil {
+		log.Error().Msg("API key is required")
+		return err
+	}
+
 	// Initialize provider
 	provider, err := initializeProvider(cfg)
 	if err != nil {
@@ -98,16 +252,100 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 	}
 	log.Info().Str("provider", cfg.Provider.Name).Msg("Initialized LLM provider")
 
+	// Load the encryption key and response cache, if configured
+	run, err := loadRunResources(cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize run resources")
+		return err
+	}
+
 	// Initialize transcriber
 	log.Debug().Str("temp_dir", cfg.Audio.TempDir).Msg("Using temporary directory")
-	tr := transcriber.NewTranscriber(provider, cfg)
+	tr := run.newTranscriber(provider, cfg)
+	if run.rules, err = loadRules(cfg, run); err != nil {
+		log.Error().Err(err).Msg("Failed to initialize rules")
+		return err
+	}
 
 	// Get transcription options
 	options := getTranscribeOptions(cmd, cfg)
+
+	// A resumed job transcribes the file it was started on
+	resumeJob, _ := cmd.Flags().GetString("resume-job")
+	if resumeJob != "" {
+		job, err := tr.FindJob(resumeJob)
+		if err != nil {
+			return err
+		}
+		args = []string{job.Source}
+		options.Resume = true
+		log.Info().Str("job", job.ID).Str("file", job.Source).Int("completed_chunks", job.Chunks).Msg("Resuming job")
+	}
+	speakers, _ := cmd.Flags().GetStringArray("speaker")
+	if options.SpeakerMap, err = parseSpeakerMap(speakers, cfg.Transcribe.SpeakerMap); err != nil {
+		return err
+	}
+	if options.MergeStrategy, err = transcriber.ParseMergeStrategy(cfg.Transcribe.MergeStrategy); err != nil {
+		return err
+	}
+	if options.Style, err = transcriber.ParseStyle(cfg.Transcribe.Style); err != nil {
+		return err
+	}
+	if _, err := transcriber.ParseEmbeddedMode(cfg.Transcribe.EmbeddedSubtitles); err != nil {
+		return err
+	}
+	if options.LanguageCheck, err = transcriber.ParseLanguageCheck(cfg.Transcribe.LanguageCheck); err != nil {
+		return err
+	}
+	if options.LanguageCheck != "" && options.ExpectedLanguage == "" {
+		return fmt.Errorf("--language-check needs the language of the audio, e.g. --language ja")
+	}
+	if err := transcriber.CheckFormats(options.OutputFormats); err != nil {
+		return err
+	}
+	stdoutFormat := ""
+	if stdoutOutput(cmd) {
+		if len(options.OutputFormats) > 0 {
+			return fmt.Errorf("--formats and --format-template write files next to the transcript and cannot be used with --stdout")
+		}
+		stdoutFormat, _ = cmd.Flags().GetString("stdout-format")
+		if err := transcriber.CheckFormats([]string{stdoutFormat}); err != nil {
+			return err
+		}
+	}
+	if cfg.Call.Enabled {
+		if len(cfg.Call.Speakers) < 2 {
+			return fmt.Errorf("--call-center needs a speaker for each channel, e.g. --call-speakers Agent,Customer")
+		}
+		options.ChannelSpeakers = cfg.Call.Speakers
+		options.HoldThreshold = cfg.Call.HoldThreshold
+	}
 	log.Debug().Interface("options", options).Msg("Transcription options configured")
 
+	// Summarize and split each merged transcript with further requests
+	postOptions := providers.TranscriptionOptions{
+		Temperature:    options.Temperature,
+		MaxTokens:      cfg.Provider.MaxTokens,
+		TimeoutSeconds: int(cfg.Provider.Timeout.Seconds()),
+	}
+	if cfg.Summary.Enabled || cfg.Summary.File {
+		tr.AddPostProcessor(postprocess.NewSummarizer(cfg.Summary.Prompt, postOptions))
+		log.Info().Bool("summary_file", cfg.Summary.File).Msg("Summarizing transcripts")
+	}
+	if cfg.Chapters.Enabled || cfg.Chapters.File {
+		tr.AddPostProcessor(postprocess.NewChapterizer(cfg.Chapters.Prompt, postOptions))
+		log.Info().Bool("chapters_file", cfg.Chapters.File).Msg("Detecting chapters")
+	}
+	if cfg.Translation.Bilingual && cfg.Translation.Language == "" {
+		return fmt.Errorf("--bilingual needs a language to translate to, e.g. --translate Spanish")
+	}
+	if cfg.Translation.Language != "" {
+		tr.AddPostProcessor(postprocess.NewTranslator(cfg.Translation.Language, cfg.Translation.Prompt, postOptions))
+		log.Info().Str("language", cfg.Translation.Language).Bool("bilingual", cfg.Translation.Bilingual).Msg("Translating segments")
+	}
+
 	// Get custom prompt
-	customPrompt, err := getCustomPrompt(cmd)
+	customPrompt, err := getCustomPrompt(cmd, cfg)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get custom prompt")
 		return fmt.Errorf("failed to get custom prompt: %w", err)
@@ -116,83 +354,636 @@ func runTranscribe(cmd *cobra.Command, args []string) error {
 		log.Info().Str("prompt", customPrompt).Msg("Using custom transcription prompt")
 	}
 
+	// Check the whole batch against the run budget before uploading anything
+	if cfg.Budget.MaxCostPerRun > 0 {
+		proceed, err := checkBatchCost(cmd, tr, args, options, customPrompt, cfg.Budget.MaxCostPerRun)
+		if err != nil {
+			log.Error().Err(err).Msg("Batch cost check failed")
+			return err
+		}
+		if proceed {
+			// The user accepted the estimate, so only the other limits apply
+			runBudget := cfg.Budget
+			runBudget.MaxCostPerRun = 0
+			tr.SetBudget(budget.New(runBudget))
+		}
+	}
+
+	// Check large batches against the daily quota left
+	if err := checkBatchQuota(cmd, tr, run.budget, args, options, customPrompt, cfg.Budget); err != nil {
+		log.Error().Err(err).Msg("Batch quota check failed")
+		return err
+	}
+
+	// Keep stdout for transcripts and print everything else to stderr
+	if stdoutFormat != "" {
+		stdout := os.Stdout
+		run.stdout, run.stdoutFormat = stdout, stdoutFormat
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+		warnStdoutSkipped(log)
+	}
+
 	// Process files
 	successCount := 0
 	failureCount := 0
+	var totalUsage providers.Usage
+	var totalCost float64
+	var suspects []string
+
+	if showProgress, _ := cmd.Flags().GetBool("progress"); showProgress && len(args) > 1 {
+		run.batch = tr.NewBatchProgress(ctx, args)
+	}
 
-	for _, filePath := range args {
-		fileLog := log.WithField("file", filepath.Base(filePath))
+	for i, filePath := range args {
+		jobID := jobIDFor(tr, filePath, options, resumeJob)
+		fileLog := log.WithField("file", filepath.Base(filePath)).WithField("job", jobID)
 		fileLog.Info().Msg("Processing file")
 
-		if err := processFile(tr, filePath, options, customPrompt, cmd); err != nil {
+		if run.batch != nil {
+			run.batch.Start(i)
+		}
+		result, err := processFile(tr, run, filePath, jobID, options, customPrompt, cmd)
+		if run.batch != nil {
+			run.batch.Finish(err)
+			fmt.Printf("%s\n", formatBatchProgress(run.batch.Snapshot()))
+		}
+		if err != nil {
 			fileLog.Error().Err(err).Msg("Failed to process file")
+			if job, findErr := tr.FindJob(jobID); findErr == nil {
+				fmt.Printf("\nJob %s stopped after %d chunks; resume it with: gollmscribe transcribe --resume-job %s\n", job.ID, job.Chunks, job.ID)
+			}
 			failureCount++
+			if ctx.Err() != nil {
+				log.Warn().Int("skipped", len(args)-i-1).Msg("Interrupted, skipping the remaining files")
+				break
+			}
 			continue
 		}
 		fileLog.Info().Msg("Successfully processed file")
 		successCount++
+
+		if result != nil {
+			if result.Usage != nil {
+				totalUsage = totalUsage.Add(*result.Usage)
+			}
+			totalCost += result.Cost
+			if quality := result.Quality(); quality != nil && quality.Suspect() {
+				suspects = append(suspects, fmt.Sprintf("%s: %s", filePath, quality))
+			}
+		}
 	}
 
 	log.Info().
 		Int("successful", successCount).
 		Int("failed", failureCount).
 		Int("total", len(args)).
+		Int("total_tokens", totalUsage.TotalTokens).
+		Float64("cost_usd", totalCost).
 		Msg("Transcription batch completed")
 
+	if len(args) > 1 && (!totalUsage.IsZero() || totalCost > 0) {
+		fmt.Printf("\nBatch total (%d files):\n", successCount)
+		var usage *providers.Usage
+		if !totalUsage.IsZero() {
+			usage = &totalUsage
+		}
+		printUsage("  ", usage, totalCost)
+	}
+	if len(args) > 1 && len(suspects) > 0 {
+		fmt.Printf("\nSuspect transcripts (quality below %d), worth reading before use:\n", transcriber.SuspectQuality)
+		for _, suspect := range suspects {
+			fmt.Printf("  %s\n", suspect)
+		}
+	}
+
+	return nil
+}
+
+// showChunkPlans prints the chunks each file would be split into
+func showChunkPlans(cmd *cobra.Command, cfg *config.Config, files []string) error {
+	// Planning only probes the files, so no provider is set
+	tr := transcriber.NewTranscriber(nil, cfg)
+	options := getTranscribeOptions(cmd, cfg)
+
+	failed := 0
+	for i, filePath := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		chunks, info, err := tr.PlanChunks(cmd.Context(), &transcriber.TranscribeRequest{FilePath: filePath, Options: options})
+		if err != nil {
+			fmt.Printf("%s: %v\n", filePath, err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("%s (%s, %d chunks)\n", filePath, formatClock(info.Duration), len(chunks))
+		fmt.Printf("  %-5s %-12s %-12s %-10s %-9s %s\n", "CHUNK", "START", "END", "DURATION", "OVERLAP", "KEY")
+		for j, chunk := range chunks {
+			overlap := "-"
+			if j > 0 {
+				overlap = (chunks[j-1].End - chunk.Start).Round(time.Millisecond).String()
+				if chunk.Context > 0 {
+					overlap += " ctx"
+				}
+			}
+			fmt.Printf("  %-5d %-12s %-12s %-10s %-9s %s\n", chunk.Index, formatClock(chunk.Start), formatClock(chunk.End),
+				chunk.Duration.Round(time.Millisecond), overlap, chunk.Key)
+		}
+	}
+	if options.TrimSilence {
+		fmt.Println("\nSilence trimming is applied per chunk during transcription and is not shown.")
+	}
+	if options.SkipSilence {
+		fmt.Println("\nSkipped silence is removed during transcription, which splits the shorter audio into fewer chunks than shown.")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("could not plan %d of %d files", failed, len(files))
+	}
 	return nil
 }
 
+// formatClock formats a duration as HH:MM:SS.mmm
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Milliseconds()%1000)
+}
+
+// checkBatchCost estimates the cost of transcribing files and compares it
+// with maxCost. When the estimate is over the limit it asks for confirmation
+// on a terminal (or accepts --yes) and fails otherwise. It reports whether
+// the user chose to exceed the limit.
+func checkBatchCost(cmd *cobra.Command, tr *transcriber.TranscriberImpl, files []string, options transcriber.TranscribeOptions, customPrompt string, maxCost float64) (bool, error) {
+	log := logger.WithComponent("transcribe")
+
+	total := 0.0
+	for _, filePath := range files {
+		cost, ok, err := tr.EstimateCost(cmd.Context(), &transcriber.TranscribeRequest{
+			FilePath:     filePath,
+			CustomPrompt: customPrompt,
+			Options:      options,
+		})
+		if err != nil {
+			// processFile reports unreadable files; they cost nothing
+			log.Warn().Err(err).Str("file", filePath).Msg("Could not estimate cost")
+			continue
+		}
+		if !ok {
+			log.Warn().Msg("Provider model has no known price, cost is checked per file during transcription")
+			return false, nil
+		}
+		total += cost
+	}
+
+	log.Info().Float64("estimated_cost", total).Float64("max_cost", maxCost).Msg("Estimated batch cost")
+	if total <= maxCost {
+		return false, nil
+	}
+
+	fmt.Printf("Estimated cost $%.4f for %d file(s) exceeds the budget of $%.4f\n", total, len(files), maxCost)
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return false, fmt.Errorf("estimated cost $%.4f exceeds --max-cost $%.4f (use --yes to continue): %w", total, maxCost, budget.ErrBudgetExceeded)
+	}
+
+	fmt.Print("Continue anyway? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, fmt.Errorf("transcription cancelled: %w", budget.ErrBudgetExceeded)
+}
+
+// checkBatchQuota estimates the usage of a batch of at least
+// quota_check_files files and compares it with what is left today of the
+// provider's quota, where it reports one, and of the budget's daily limits.
+// It warns, or with quota_check "abort" fails unless --yes is given.
+func checkBatchQuota(cmd *cobra.Command, tr *transcriber.TranscriberImpl, guard *budget.Guard, files []string, options transcriber.TranscribeOptions, customPrompt string, cfg config.BudgetConfig) error {
+	switch cfg.QuotaCheck {
+	case "", "off":
+		return nil
+	case "warn", "abort":
+	default:
+		return fmt.Errorf("invalid quota check %q (use off, warn or abort)", cfg.QuotaCheck)
+	}
+	if len(files) < cfg.QuotaCheckFiles {
+		return nil
+	}
+	log := logger.WithComponent("transcribe")
+
+	allowances, err := guard.Allowances()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not read the usage ledger")
+	}
+	quota, err := providers.ProviderQuota(cmd.Context(), tr.Provider())
+	switch {
+	case err == nil:
+		allowances = append(allowances, budget.Allowance{
+			Name:      quota.Provider + " requests per day",
+			Unit:      budget.UnitRequests,
+			Remaining: float64(quota.RequestsRemaining),
+			Max:       float64(quota.RequestsLimit),
+		})
+	case errors.Is(err, providers.ErrQuotaUnknown):
+		log.Debug().Msg("Provider does not report its quota")
+	default:
+		log.Warn().Err(err).Msg("Could not query the provider's quota")
+	}
+	if len(allowances) == 0 {
+		log.Info().Msg("No daily quota or limit known, skipping the quota check")
+		return nil
+	}
+
+	var total budget.Estimate
+	for _, filePath := range files {
+		estimate, _, err := tr.EstimateUsage(cmd.Context(), &transcriber.TranscribeRequest{
+			FilePath:     filePath,
+			CustomPrompt: customPrompt,
+			Options:      options,
+		})
+		if err != nil {
+			// processFile reports unreadable files; they use nothing
+			log.Warn().Err(err).Str("file", filePath).Msg("Could not estimate usage")
+			continue
+		}
+		total.Chunks += estimate.Chunks
+		total.Tokens += estimate.Tokens
+		total.Cost += estimate.Cost
+	}
+
+	err = budget.CheckAllowances(allowances, total, cfg.MinFreeQuota)
+	if err == nil {
+		log.Info().Int("requests", total.Chunks).Int("tokens", total.Tokens).Float64("cost", total.Cost).
+			Msg("Batch fits in the daily quota")
+		return nil
+	}
+	fmt.Printf("Quota check for %d file(s): %v\n", len(files), err)
+	if cfg.QuotaCheck == "warn" {
+		return nil
+	}
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return nil
+	}
+	return fmt.Errorf("%w (use --yes to start anyway)", err)
+}
+
+// runResources are shared by every transcriber in one run, so spending
+// limits, encryption and the response cache apply across watch routes
+type runResources struct {
+	budget *budget.Guard
+	cipher *encryption.Cipher
+	cache  *cache.Cache
+	temp   *tempfiles.Manager
+
+	// Overall progress of transcribing several files, nil for one file or
+	// without --progress
+	batch *transcriber.BatchProgress
+
+	// Rules routing each file by its tags
+	rules []rules.Rule
+
+	// Where transcripts are written with --stdout, in stdoutFormat; nil
+	// when they are written to files
+	stdout       io.Writer
+	stdoutFormat string
+
+	manifest     *manifest.Manifest
+	manifestPath string
+	signingKey   ed25519.PrivateKey
+	manifestMu   sync.Mutex
+}
+
+// loadRunResources creates the budget guard, cipher, response cache, temp
+// file manager and run manifest
+func loadRunResources(cfg *config.Config) (*runResources, error) {
+	cipher, err := loadCipher(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &runResources{
+		budget: budget.New(cfg.Budget),
+		cipher: cipher,
+		temp:   tempfiles.New(cfg.Audio.TempDir, int64(cfg.Audio.MaxTempMB)<<20),
+	}
+	sweepTempFiles(run.temp)
+	if cfg.Manifest.Path != "" {
+		run.signingKey, err = manifest.LoadSigningKey(cfg.Manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load manifest signing key: %w", err)
+		}
+		run.manifest = manifest.New("gollmscribe "+version, time.Now())
+		run.manifestPath = cfg.Manifest.Path
+		logger.WithComponent("manifest").Info().
+			Str("path", cfg.Manifest.Path).
+			Bool("signed", run.signingKey != nil).
+			Msg("Writing run manifest")
+	}
+	if cfg.Cache.Dir != "" {
+		run.cache, err = cache.New(cfg.Cache.Dir, cache.WithCipher(cipher))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open response cache: %w", err)
+		}
+		logger.WithComponent("cache").Info().Str("dir", cfg.Cache.Dir).Msg("Caching provider responses")
+	}
+	return run, nil
+}
+
+// recordOutput adds an output to the run manifest and rewrites it, so the
+// manifest is current even if a long run is interrupted
+func (r *runResources) recordOutput(source, output string, result *transcriber.TranscribeResult) {
+	if r.manifest == nil {
+		return
+	}
+	log := logger.WithComponent("manifest")
+
+	var provider, model string
+	if result != nil {
+		provider, model = result.Provider, result.Model
+	}
+
+	r.manifestMu.Lock()
+	defer r.manifestMu.Unlock()
+
+	if err := r.manifest.Add(source, output, provider, model); err != nil {
+		log.Warn().Err(err).Msg("Failed to add output to manifest")
+		return
+	}
+	if r.signingKey != nil {
+		if err := r.manifest.Sign(r.signingKey); err != nil {
+			log.Warn().Err(err).Msg("Failed to sign manifest")
+			return
+		}
+	}
+	if err := r.manifest.Write(r.manifestPath); err != nil {
+		log.Warn().Err(err).Msg("Failed to write manifest")
+	}
+}
+
+// newTranscriber creates a transcriber using the shared resources
+func (r *runResources) newTranscriber(provider providers.LLMProvider, cfg *config.Config) *transcriber.TranscriberImpl {
+	tr := transcriber.NewTranscriber(provider, cfg)
+	tr.SetBudget(r.budget)
+	tr.SetCipher(r.cipher)
+	tr.SetCache(r.cache)
+	tr.SetTempFiles(r.temp)
+	return tr
+}
+
+// loadRules creates the tag rules of the config, with a transcriber for
+// each rule naming a provider. A rule that is local_only must name a local
+// provider, if it names one.
+func loadRules(appCfg *config.Config, run *runResources) ([]rules.Rule, error) {
+	log := logger.WithComponent("rules")
+
+	loaded := make([]rules.Rule, 0, len(appCfg.Rules))
+	for i, rc := range appCfg.Rules {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		rule := rules.Rule{Name: name, Tags: rc.Tags, OutputDir: rc.OutputDir, LocalOnly: rc.LocalOnly, Stop: rc.Stop}
+		if rc.Provider != (config.ProviderRef{}) {
+			provider, err := newRouteProvider(appCfg.Provider, rc.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			privacy := appCfg.Privacy
+			privacy.LocalOnly = privacy.LocalOnly || rc.LocalOnly
+			if err := checkPrivacy(privacy, provider); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			rule.Transcriber = run.newTranscriber(provider, appCfg)
+		}
+
+		log.Info().
+			Str("rule", name).
+			Strs("tags", rc.Tags).
+			Str("output_dir", rc.OutputDir).
+			Str("provider", rc.Provider.Name).
+			Bool("local_only", rc.LocalOnly).
+			Msg("Rule configured")
+		loaded = append(loaded, rule)
+	}
+	return loaded, nil
+}
+
+// loadCipher creates the cipher for encrypting transcripts and history at
+// rest, or nil when no key is configured
+func loadCipher(cfg *config.Config) (*encryption.Cipher, error) {
+	cipher, err := encryption.FromConfig(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	if cipher != nil {
+		logger.WithComponent("encryption").Info().Msg("Encrypting transcripts at rest")
+	}
+	return cipher, nil
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func loadConfig() *config.Config {
 	cfg := config.DefaultConfig()
 
 	// Override with viper values
 	cfg.Provider.APIKey = viper.GetString("api_key")
-	cfg.Provider.Name = viper.GetString("provider")
-	cfg.Provider.Model = viper.GetString("model")
+	if cfg.Provider.APIKey == "" {
+		cfg.Provider.APIKey = viper.GetString("provider.api_key")
+	}
+	cfg.Provider.Name = viper.GetString("provider.name")
+	cfg.Provider.Model = viper.GetString("provider.model")
+	cfg.Provider.BaseURL = viper.GetString("provider.base_url")
+	cfg.Provider.EmbeddingModel = viper.GetString("provider.embedding_model")
 	cfg.Audio.TempDir = viper.GetString("temp_dir")
+	cfg.Provider.Vertex.Project = viper.GetString("provider.vertex.project")
+	cfg.Provider.Vertex.Location = viper.GetString("provider.vertex.location")
+	cfg.Provider.Vertex.CredentialsFile = viper.GetString("provider.vertex.credentials_file")
+	_ = viper.UnmarshalKey("provider.fallbacks", &cfg.Provider.Fallbacks)
+	_ = viper.UnmarshalKey("provider.ensemble", &cfg.Provider.Ensemble)
+	cfg.Provider.APIKeys = splitList(viper.GetStringSlice("provider.api_keys"))
+	cfg.Provider.KeyStrategy = viper.GetString("provider.key_strategy")
+	cfg.Provider.RateLimit.RequestsPerMinute = viper.GetInt("provider.rate_limit.requests_per_minute")
+	cfg.Provider.RateLimit.TokensPerMinute = viper.GetInt("provider.rate_limit.tokens_per_minute")
+	cfg.Provider.RateLimit.MaxConcurrentPerKey = viper.GetInt("provider.rate_limit.max_concurrent_per_key")
+	cfg.Provider.RateLimit.LockDir = viper.GetString("provider.rate_limit.lock_dir")
+	cfg.Provider.RateLimit.LeaseTTL = viper.GetDuration("provider.rate_limit.lease_ttl")
+	_ = viper.UnmarshalKey("budget", &cfg.Budget)
+	cfg.Budget.MaxCostPerRun = viper.GetFloat64("budget.max_cost_per_run")
+	cfg.Budget.QuotaCheck = viper.GetString("budget.quota_check")
+	_ = viper.UnmarshalKey("pricing", &cfg.Pricing)
+	_ = viper.UnmarshalKey("watch.routes", &cfg.Watch.Routes)
+	_ = viper.UnmarshalKey("rules", &cfg.Rules)
+	_ = viper.UnmarshalKey("watch.retention", &cfg.Watch.Retention)
+	_ = viper.UnmarshalKey("watch.export", &cfg.Watch.Export)
+	_ = viper.UnmarshalKey("watch.languages", &cfg.Watch.Languages)
+	cfg.Watch.QuarantineDir = viper.GetString("watch.quarantine_dir")
+	cfg.Watch.HistoryLocked = viper.GetString("watch.history_locked")
+	if viper.IsSet("watch.max_attempts") {
+		cfg.Watch.MaxAttempts = viper.GetInt("watch.max_attempts")
+	}
+	cfg.Privacy.LocalOnly = viper.GetBool("privacy.local_only")
+	cfg.Privacy.RedactPaths = viper.GetBool("privacy.redact_paths")
+	cfg.Encryption.Key = viper.GetString("encryption.key")
+	cfg.Encryption.KeyFile = viper.GetString("encryption.key_file")
+	cfg.Cache.Dir = viper.GetString("cache.dir")
+	cfg.Transcribe.ChunkRetries = viper.GetInt("transcribe.chunk_retries")
+	cfg.Transcribe.AllowPartial = viper.GetBool("transcribe.allow_partial")
+	cfg.Transcribe.VoiceProfilesDir = viper.GetString("transcribe.voice_profiles_dir")
+	cfg.Transcribe.SpeakerMap = viper.GetStringMapString("transcribe.speaker_map")
+	cfg.Transcribe.NativeDiarization = viper.GetBool("transcribe.native_diarization")
+	cfg.Transcribe.MergeStrategy = viper.GetString("transcribe.merge_strategy")
+	cfg.Transcribe.Style = viper.GetString("transcribe.style")
+	cfg.Transcribe.SegmentLanguages = viper.GetBool("transcribe.segment_languages")
+	cfg.Transcribe.Language = viper.GetString("transcribe.language")
+	cfg.Transcribe.LanguageCheck = viper.GetString("transcribe.language_check")
+	cfg.Transcribe.EmbeddedSubtitles = viper.GetString("transcribe.embedded_subtitles")
+	cfg.Transcribe.SubtitlesLanguage = viper.GetString("transcribe.subtitles_language")
+	cfg.Transcribe.PromptsDir = viper.GetString("transcribe.prompts_dir")
+	if templates := viper.GetStringMapString("transcribe.prompt_templates"); len(templates) > 0 {
+		cfg.Transcribe.PromptTemplates = templates
+	}
+	cfg.Audio.TrimSilence = viper.GetBool("audio.trim_silence")
+	cfg.Audio.SkipSilence = viper.GetBool("audio.skip_silence")
+	cfg.Audio.SkipSilenceSeconds = viper.GetInt("audio.skip_silence_seconds")
+	if format := viper.GetString("audio.output_format"); format != "" {
+		cfg.Audio.OutputFormat = format
+	}
+	if rate := viper.GetInt("audio.sample_rate"); rate > 0 {
+		cfg.Audio.SampleRate = rate
+	}
+	if channels := viper.GetInt("audio.channels"); channels > 0 {
+		cfg.Audio.Channels = channels
+	}
+	if viper.IsSet("audio.quality") {
+		cfg.Audio.Quality = viper.GetInt("audio.quality")
+	}
+	cfg.Audio.Filters.Loudnorm = viper.GetBool("audio.filters.loudnorm")
+	cfg.Audio.Filters.HighpassHz = viper.GetInt("audio.filters.highpass_hz")
+	cfg.Audio.Filters.LowpassHz = viper.GetInt("audio.filters.lowpass_hz")
+	cfg.Audio.Filters.DenoiseDB = viper.GetFloat64("audio.filters.denoise_db")
+	cfg.Audio.LeadingContext = viper.GetBool("audio.leading_context")
+	cfg.Audio.InMemoryChunks = viper.GetBool("audio.in_memory_chunks")
+	cfg.Audio.MaxTempMB = viper.GetInt("audio.max_temp_mb")
+	cfg.Audio.AdaptiveWorkers = viper.GetBool("audio.adaptive_workers")
+	cfg.Audio.MinWorkers = viper.GetInt("audio.min_workers")
+	cfg.Audio.MaxWorkers = viper.GetInt("audio.max_workers")
+	cfg.Audio.SilenceThreshold = viper.GetInt("audio.silence_threshold")
+	cfg.Audio.MinSilenceSeconds = viper.GetInt("audio.min_silence_seconds")
+	cfg.Manifest.Path = viper.GetString("manifest.path")
+	cfg.Summary.Enabled = viper.GetBool("summary.enabled")
+	cfg.Summary.Prompt = viper.GetString("summary.prompt")
+	cfg.Summary.File = viper.GetBool("summary.file")
+	cfg.Chapters.Enabled = viper.GetBool("chapters.enabled")
+	cfg.Chapters.Prompt = viper.GetString("chapters.prompt")
+	cfg.Chapters.File = viper.GetBool("chapters.file")
+	cfg.Translation.Language = strings.TrimSpace(viper.GetString("translation.language"))
+	cfg.Translation.Prompt = viper.GetString("translation.prompt")
+	cfg.Translation.Bilingual = viper.GetBool("translation.bilingual")
+	cfg.Call.Enabled = viper.GetBool("call.enabled")
+	cfg.Call.Speakers = viper.GetStringSlice("call.speakers")
+	cfg.Call.HoldThreshold = viper.GetDuration("call.hold_threshold")
+	cfg.Output.PerSpeaker = viper.GetBool("output.per_speaker")
+	cfg.Output.Filename = viper.GetString("output.filename")
+	cfg.Output.Formats = splitList(viper.GetStringSlice("output.formats"))
+	cfg.Output.RenderWorkers = viper.GetInt("output.render_workers")
+	cfg.Output.KeepVersions = viper.GetBool("output.keep_versions")
+	cfg.Output.MuxSubtitles = viper.GetBool("output.mux_subtitles")
+	cfg.Output.Locale = viper.GetString("output.locale")
+	if cfg.Translation.Bilingual {
+		cfg.Output.Formats = append(cfg.Output.Formats, "bilingual-srt", "bilingual-vtt")
+	}
+	if cfg.Output.Template = viper.GetString("output.template"); cfg.Output.Template != "" {
+		cfg.Output.Formats = append(cfg.Output.Formats, transcriber.TemplateFormat(cfg.Output.Template))
+	}
+	cfg.Manifest.SigningKey = viper.GetString("manifest.signing_key")
+	cfg.Manifest.SigningKeyFile = viper.GetString("manifest.signing_key_file")
 
 	return cfg
 }
 
-func initializeProvider(cfg *config.Config) (*gemini.Provider, error) {
-	log := logger.WithComponent("provider")
+// expectedLanguage returns the language the audio is configured to be
+// in, or "" for auto
+func expectedLanguage(language string) string {
+	language = strings.TrimSpace(language)
+	if strings.EqualFold(language, "auto") {
+		return ""
+	}
+	return language
+}
 
-	switch cfg.Provider.Name {
-	case "gemini":
-		// Use longer timeout for audio transcription
-		timeout := cfg.Provider.Timeout
-		if timeout < 5*time.Minute {
-			timeout = 5 * time.Minute // Minimum 5 minutes for audio processing
-			log.Debug().
-				Dur("original_timeout", cfg.Provider.Timeout).
-				Dur("adjusted_timeout", timeout).
-				Msg("Adjusted timeout for audio processing")
-		}
+// stdoutOutput reports whether transcripts are written to stdout, with
+// --stdout or -o -
+func stdoutOutput(cmd *cobra.Command) bool {
+	if stdout, _ := cmd.Flags().GetBool("stdout"); stdout {
+		return true
+	}
+	output, _ := cmd.Flags().GetString("output")
+	return output == "-"
+}
 
-		log.Debug().
-			Dur("timeout", timeout).
-			Int("retries", cfg.Provider.Retries).
-			Msg("Creating Gemini provider")
+// warnStdoutSkipped warns about the configured outputs written next to
+// the transcript file, which --stdout has none of
+func warnStdoutSkipped(log *logger.Logger) {
+	for _, option := range []struct{ key, flag string }{
+		{"summary.file", "--summary-file"},
+		{"chapters.file", "--chapters-file"},
+		{"output.per_speaker", "--per-speaker"},
+		{"output.mux_subtitles", "--mux-subtitles"},
+	} {
+		if viper.GetBool(option.key) {
+			log.Warn().Str("option", option.flag).Msg("Output is written next to the transcript file; skipped with --stdout")
+		}
+	}
+}
 
-		provider := gemini.NewProvider(
-			cfg.Provider.APIKey,
-			gemini.WithTimeout(timeout),
-			gemini.WithRetries(cfg.Provider.Retries),
-			gemini.WithModel(cfg.Provider.Model),
-		)
+// splitList flattens comma-separated entries into a list
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
 
-		log.Debug().Msg("Validating provider configuration")
-		if err := provider.ValidateConfig(); err != nil {
-			log.Error().Err(err).Msg("Provider validation failed")
-			return nil, fmt.Errorf("provider validation failed: %w", err)
+// parseSpeakerMap reads LABEL=NAME speaker renames over the configured map
+func parseSpeakerMap(values []string, configured map[string]string) (map[string]string, error) {
+	speakers := make(map[string]string, len(configured)+len(values))
+	for label, name := range configured {
+		speakers[label] = name
+	}
+	for _, value := range values {
+		label, name, ok := strings.Cut(value, "=")
+		label, name = strings.TrimSpace(label), strings.TrimSpace(name)
+		if !ok || label == "" || name == "" {
+			return nil, fmt.Errorf("invalid speaker rename %q, expected LABEL=NAME", value)
 		}
+		speakers[label] = name
+	}
+	return speakers, nil
+}
 
-		log.Info().Msg("Gemini provider initialized successfully")
-		return provider, nil
-	default:
-		log.Error().Str("provider", cfg.Provider.Name).Msg("Unsupported provider")
-		return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider.Name)
+// requireCredentials checks that the provider has some way to authenticate
+func requireCredentials(cfg *config.Config) error {
+	if cfg.Provider.APIKey != "" || len(cfg.Provider.APIKeys) > 0 {
+		return nil
+	}
+	if !cfg.Provider.RequiresAPIKey() {
+		return nil
 	}
+	return fmt.Errorf("API key is required. Set GOLLMSCRIBE_API_KEY environment variable or use --api-key flag")
 }
 
 func getTranscribeOptions(cmd *cobra.Command, cfg *config.Config) transcriber.TranscribeOptions {
@@ -218,6 +1009,8 @@ func getTranscribeOptions(cmd *cobra.Command, cfg *config.Config) transcriber.Tr
 	}
 
 	preserveAudio, _ := cmd.Flags().GetBool("preserve-audio")
+	resume, _ := cmd.Flags().GetBool("resume")
+	timing, _ := cmd.Flags().GetBool("timing")
 
 	return transcriber.TranscribeOptions{
 		ChunkMinutes:   chunkMinutes,
@@ -225,10 +1018,30 @@ func getTranscribeOptions(cmd *cobra.Command, cfg *config.Config) transcriber.Tr
 		Workers:        workers,
 		Temperature:    temperature,
 		PreserveAudio:  preserveAudio,
+		Resume:         resume,
+		ChunkRetries:   cfg.Transcribe.ChunkRetries,
+		AllowPartial:   cfg.Transcribe.AllowPartial,
+		TrimSilence:    cfg.Audio.TrimSilence,
+		SkipSilence:    cfg.Audio.SkipSilence,
+		LeadingContext: cfg.Audio.LeadingContext,
+		Timing:         timing,
+
+		AdaptiveWorkers: cfg.Audio.AdaptiveWorkers,
+		MinWorkers:      cfg.Audio.MinWorkers,
+		MaxWorkers:      cfg.Audio.MaxWorkers,
+
+		VoiceProfilesDir:  cfg.Transcribe.VoiceProfilesDir,
+		NativeDiarization: cfg.Transcribe.NativeDiarization,
+		SegmentLanguages:  cfg.Transcribe.SegmentLanguages,
+		ExpectedLanguage:  expectedLanguage(cfg.Transcribe.Language),
+
+		OutputFormats: cfg.Output.Formats,
+		RenderWorkers: cfg.Output.RenderWorkers,
+		KeepVersions:  cfg.Output.KeepVersions,
 	}
 }
 
-func getCustomPrompt(cmd *cobra.Command) (string, error) {
+func getCustomPrompt(cmd *cobra.Command, cfg *config.Config) (string, error) {
 	// Check direct prompt flag
 	if prompt, _ := cmd.Flags().GetString("prompt"); prompt != "" {
 		return prompt, nil
@@ -243,26 +1056,142 @@ func getCustomPrompt(cmd *cobra.Command) (string, error) {
 		return strings.TrimSpace(string(data)), nil
 	}
 
+	// Check named prompt flag
+	if name, _ := cmd.Flags().GetString("prompt-name"); name != "" {
+		return namedPrompt(cfg, name)
+	}
+
 	return "", nil
 }
 
-func processFile(tr transcriber.Transcriber, filePath string, options transcriber.TranscribeOptions, customPrompt string, cmd *cobra.Command) error {
-	log := logger.WithComponent("processor").WithField("file", filepath.Base(filePath))
+// jobIDFor returns the job ID of a file's run: the resumed job's, that of
+// the file's latest interrupted job when resuming, or a new one
+func jobIDFor(tr *transcriber.TranscriberImpl, filePath string, options transcriber.TranscribeOptions, resumeJob string) string {
+	if resumeJob != "" {
+		return resumeJob
+	}
+	if options.Resume {
+		if job := tr.LatestJob(filePath); job != nil {
+			return job.ID
+		}
+	}
+	return transcriber.NewJobID()
+}
+
+func processFile(tr transcriber.Transcriber, run *runResources, filePath, jobID string, options transcriber.TranscribeOptions, customPrompt string, cmd *cobra.Command) (*transcriber.TranscribeResult, error) {
+	log := logger.WithComponent("processor").WithField("file", filepath.Base(filePath)).WithField("job", jobID)
+	ctx := cmd.Context()
 
 	log.Debug().Str("full_path", filePath).Msg("Starting file processing")
 
 	// Validate file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		log.Error().Str("path", filePath).Msg("File does not exist")
-		return fmt.Errorf("file does not exist: %s", filePath)
+		return nil, fmt.Errorf("file does not exist: %s", filePath)
 	}
 
 	// Get output path
 	outputPath, _ := cmd.Flags().GetString("output")
 	if outputPath == "" {
-		outputPath = strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".txt"
+		if template := viper.GetString("output.filename"); template != "" {
+			outputPath = transcriber.ExpandOutputTemplate(template, filePath, jobID, time.Now())
+		} else {
+			outputPath = strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".txt"
+		}
 	}
 	log.Debug().Str("output_path", outputPath).Msg("Output configuration")
+	notes, _ := cmd.Flags().GetStringArray("note")
+
+	// Apply the rules matching the file's tags
+	decision := rules.Evaluate(run.rules, rules.Tags(filePath, "", notes))
+	if len(decision.Matched) > 0 {
+		log.Info().Strs("rules", decision.Matched).Str("output_dir", decision.OutputDir).Msg("Rules matched")
+	}
+	if err := decision.CheckLocal(tr); err != nil {
+		return nil, err
+	}
+	if decision.Transcriber != nil {
+		tr = decision.Transcriber
+	}
+	if decision.OutputDir != "" {
+		if err := os.MkdirAll(decision.OutputDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		outputPath = filepath.Join(decision.OutputDir, filepath.Base(outputPath))
+	}
+
+	// Reuse a stored transcript if this media was transcribed before
+	var resultStore *store.Store
+	if storeDir, _ := cmd.Flags().GetString("store"); storeDir != "" {
+		var err error
+		resultStore, err = store.New(storeDir, store.WithCipher(run.cipher))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open transcript store: %w", err)
+		}
+
+		cached, found, err := resultStore.Lookup(filePath)
+		if err != nil {
+			log.Warn().Err(err).Msg("Transcript store lookup failed")
+		} else if found {
+			log.Info().Str("store_dir", storeDir).Msg("Reusing stored transcript")
+			if run.stdout != nil {
+				if err := transcriber.WriteResult(run.stdout, cached, run.stdoutFormat); err != nil {
+					return nil, err
+				}
+				fmt.Printf("✓ Reused stored transcript for %s\n", filepath.Base(filePath))
+				return nil, nil
+			}
+			// Written like a fresh result: every format, as a new version
+			savedPath, timings, err := transcriber.SaveOutputs(ctx, cached, outputPath, options, run.cipher)
+			if err != nil {
+				return nil, fmt.Errorf("failed to save stored result: %w", err)
+			}
+			for _, timing := range timings {
+				run.recordOutput(filePath, timing.Path, cached)
+			}
+			fmt.Printf("✓ Reused stored transcript for %s\n", filepath.Base(filePath))
+			fmt.Printf("  Output: %s\n", savedPath)
+			return nil, nil
+		}
+	}
+
+	// Use the subtitles the video already has
+	mode, _ := transcriber.ParseEmbeddedMode(viper.GetString("transcribe.embedded_subtitles"))
+	embedded := embeddedSubtitles(ctx, filePath, mode)
+	embeddedPath := ""
+	switch {
+	case embedded != nil && mode == transcriber.EmbeddedPrefer && run.stdout != nil:
+		transcriber.AddNotes(embedded, notes...)
+		if err := transcriber.WriteResult(run.stdout, embedded, run.stdoutFormat); err != nil {
+			return nil, err
+		}
+		fmt.Printf("✓ Used the embedded subtitles of %s instead of transcribing\n", filepath.Base(filePath))
+		return embedded, nil
+	case embedded != nil && mode == transcriber.EmbeddedPrefer:
+		transcriber.AddNotes(embedded, notes...)
+		timings, err := transcriber.RenderResult(ctx, embedded,
+			transcriber.RenderTargets(outputPath, options.OutputFormats), options.RenderWorkers, run.cipher)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save embedded subtitles: %w", err)
+		}
+		for _, timing := range timings {
+			run.recordOutput(filePath, timing.Path, embedded)
+		}
+		stream, _ := embedded.Metadata[transcriber.MetadataSubtitleStream].(audio.SubtitleStream)
+		fmt.Printf("✓ Used the embedded subtitles of %s instead of transcribing\n", filepath.Base(filePath))
+		fmt.Printf("  Track: %d (%s)\n", stream.Index, subtitleTrackName(stream))
+		fmt.Printf("  Output: %s\n", outputPath)
+		fmt.Printf("  Segments: %d\n", len(embedded.Segments))
+		return embedded, nil
+	case embedded != nil && run.stdout == nil:
+		embeddedPath = transcriber.EmbeddedPath(outputPath)
+		if err := transcriber.SaveEncryptedResult(embedded, embeddedPath, "srt", run.cipher); err != nil {
+			log.Warn().Err(err).Msg("Failed to save embedded subtitles")
+			embeddedPath = ""
+		} else {
+			run.recordOutput(filePath, embeddedPath, embedded)
+		}
+	}
 
 	// Create transcription request
 	req := &transcriber.TranscribeRequest{
@@ -270,6 +1199,12 @@ func processFile(tr transcriber.Transcriber, filePath string, options transcribe
 		OutputPath:   outputPath,
 		CustomPrompt: customPrompt,
 		Options:      options,
+		JobID:        jobID,
+		Notes:        notes,
+	}
+	if run.stdout != nil {
+		// The transcript is written to stdout below instead
+		req.OutputPath, outputPath = "", "stdout"
 	}
 	log.Debug().Interface("request", req).Msg("Created transcription request")
 
@@ -278,23 +1213,31 @@ func processFile(tr transcriber.Transcriber, filePath string, options transcribe
 	var progressCallback transcriber.ProgressCallback
 	if showProgress {
 		progressCallback = func(completed, total int, currentChunk string) {
-			fmt.Printf("\r[%s] Processing %s: %d/%d chunks completed",
-				filepath.Base(filePath), currentChunk, completed, total)
+			fmt.Printf("\r[%s %s] Processing %s: %d/%d chunks completed",
+				jobID, filepath.Base(filePath), currentChunk, completed, total)
+			if run.batch != nil {
+				fmt.Printf(" | %s", formatBatchProgress(run.batch.Snapshot()))
+			}
 			if completed == total {
 				fmt.Println() // New line when complete
 			}
 		}
+		if run.batch != nil {
+			progressCallback = run.batch.Callback(progressCallback)
+		}
 	}
 
 	// Start transcription
-	ctx := context.Background()
 	startTime := time.Now()
 	log.Info().Msg("Starting transcription")
 
 	var result *transcriber.TranscribeResult
 	var err error
 
-	if progressCallback != nil {
+	if stream, _ := cmd.Flags().GetBool("stream"); stream {
+		log.Debug().Msg("Streaming segments")
+		result, err = tr.TranscribeStream(ctx, req, printSegment)
+	} else if progressCallback != nil {
 		log.Debug().Msg("Using progress callback")
 		result, err = tr.TranscribeWithProgress(ctx, req, progressCallback)
 	} else {
@@ -304,7 +1247,82 @@ func processFile(tr transcriber.Transcriber, filePath string, options transcribe
 
 	if err != nil {
 		log.Error().Err(err).Dur("elapsed", time.Since(startTime)).Msg("Transcription failed")
-		return fmt.Errorf("transcription failed: %w", err)
+		return nil, fmt.Errorf("transcription failed (job %s): %w", jobID, err)
+	}
+	if saved := result.SavedPath(); saved != "" {
+		outputPath = saved
+	}
+
+	for _, timing := range result.RenderTimings() {
+		run.recordOutput(filePath, timing.Path, result)
+	}
+	if run.stdout != nil {
+		if err := transcriber.WriteResult(run.stdout, result, run.stdoutFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	// Write the summary next to the transcript
+	summaryPath := ""
+	if viper.GetBool("summary.file") && run.stdout == nil && postprocess.Summary(result) != "" {
+		summaryPath = postprocess.SummaryPath(outputPath)
+		if err := postprocess.SaveSummary(result, summaryPath, run.cipher); err != nil {
+			log.Warn().Err(err).Msg("Failed to save summary")
+			summaryPath = ""
+		} else {
+			run.recordOutput(filePath, summaryPath, result)
+		}
+	}
+
+	// Write YouTube-style chapters next to the transcript
+	chaptersPath := ""
+	if viper.GetBool("chapters.file") && run.stdout == nil && len(result.Chapters) > 0 {
+		chaptersPath = postprocess.ChaptersPath(outputPath)
+		if err := transcriber.SaveEncryptedResult(result, chaptersPath, "chapters", run.cipher); err != nil {
+			log.Warn().Err(err).Msg("Failed to save chapters")
+			chaptersPath = ""
+		} else {
+			run.recordOutput(filePath, chaptersPath, result)
+		}
+	}
+
+	// Write everything each speaker said to a file of its own
+	var speakerPaths []string
+	if viper.GetBool("output.per_speaker") && run.stdout == nil {
+		var err error
+		speakerPaths, err = transcriber.SaveSpeakerTranscripts(result, outputPath, run.cipher)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to save per-speaker transcripts")
+		}
+		for _, path := range speakerPaths {
+			run.recordOutput(filePath, path, result)
+		}
+	}
+
+	// Add the transcript to a copy of the video as a subtitle track
+	videoPath := ""
+	if viper.GetBool("output.mux_subtitles") && run.stdout == nil {
+		switch {
+		case !transcriber.CanMuxSubtitles(filePath):
+			log.Warn().Str("file", filePath).Msg("Not a video that can carry subtitle tracks; skipping --mux-subtitles")
+		case len(result.Segments) == 0:
+			log.Warn().Msg("Transcript has no timestamped segments; skipping --mux-subtitles")
+		default:
+			videoPath = transcriber.SubtitledPath(outputPath, filePath)
+			if err := transcriber.MuxSubtitles(ctx, result, filePath, videoPath); err != nil {
+				log.Warn().Err(err).Msg("Failed to add subtitles to the video")
+				videoPath = ""
+			} else {
+				run.recordOutput(filePath, videoPath, result)
+			}
+		}
+	}
+
+	// Remember the result for future runs
+	if resultStore != nil {
+		if err := resultStore.Put(filePath, result); err != nil {
+			log.Warn().Err(err).Msg("Failed to store transcript")
+		}
 	}
 
 	// Show results
@@ -320,20 +1338,225 @@ func processFile(tr transcriber.Transcriber, filePath string, options transcribe
 		Dur("processing_time", result.ProcessTime).
 		Msg("Transcription completed successfully")
 
-	fmt.Printf("✓ Transcribed %s in %v\n", filepath.Base(filePath), duration.Round(time.Second))
+	fmt.Printf("✓ Transcribed %s in %s\n", filepath.Base(filePath), humanize.Duration(duration))
+	fmt.Printf("  Job: %s\n", jobID)
 	fmt.Printf("  Output: %s\n", outputPath)
-	fmt.Printf("  Duration: %v\n", result.Duration.Round(time.Second))
+	if notes := result.Notes(); len(notes) > 0 {
+		fmt.Printf("  Notes: %s\n", strings.Join(notes, "; "))
+	}
+	if timings := result.RenderTimings(); len(timings) > 1 {
+		for _, timing := range timings[1:] {
+			fmt.Printf("  Output (%s): %s\n", timing.Format, timing.Path)
+		}
+		fmt.Printf("  Rendered: %s\n", formatRenderTimings(timings))
+	}
+	if summaryPath != "" {
+		fmt.Printf("  Summary: %s\n", summaryPath)
+	}
+	if chaptersPath != "" {
+		fmt.Printf("  Chapters: %d (%s)\n", len(result.Chapters), chaptersPath)
+	}
+	for _, path := range speakerPaths {
+		fmt.Printf("  Speaker: %s\n", path)
+	}
+	if videoPath != "" {
+		fmt.Printf("  Video: %s\n", videoPath)
+	}
+	if embeddedPath != "" {
+		fmt.Printf("  Embedded subtitles: %s (%.0f%% word difference)\n", embeddedPath,
+			transcriber.WordErrorRate(embedded.Text, result.Text)*100)
+	}
+	fmt.Printf("  Duration: %s\n", humanize.Duration(result.Duration))
+	if speed := humanize.Realtime(result.Duration, duration); speed != "" {
+		fmt.Printf("  Speed: %s\n", speed)
+	}
 	fmt.Printf("  Chunks: %d\n", result.ChunkCount)
 	fmt.Printf("  Text length: %d characters\n", len(result.Text))
 
 	if len(result.Segments) > 0 {
 		fmt.Printf("  Segments: %d\n", len(result.Segments))
 	}
+	if quality := result.Quality(); quality != nil {
+		marker := ""
+		if quality.Suspect() {
+			marker = "⚠️  "
+		}
+		fmt.Printf("  %sQuality: %s\n", marker, quality)
+	}
+
+	if metrics, ok := result.Metadata[transcriber.MetadataCallMetrics].(transcriber.CallMetrics); ok {
+		printCallMetrics(metrics)
+	}
+
+	printUsage("  ", result.Usage, result.Cost)
+
+	for _, gap := range result.Gaps() {
+		fmt.Printf("  ⚠️  Missing chunk %d (%s-%s): %s\n", gap.Chunk+1,
+			humanize.Duration(gap.Start), humanize.Duration(gap.End), gap.Error)
+	}
+	for _, mismatch := range result.LanguageMismatches() {
+		fmt.Printf("  ⚠️  Chunk %d (%s-%s) looks like %s, not %s\n", mismatch.Chunk+1,
+			humanize.Duration(mismatch.Start), humanize.Duration(mismatch.End), mismatch.Detected, mismatch.Expected)
+	}
 
 	if viper.GetBool("verbose") {
 		fmt.Printf("  Provider: %s\n", result.Provider)
-		fmt.Printf("  Processing time: %v\n", result.ProcessTime.Round(time.Millisecond))
+		fmt.Printf("  Processing time: %s\n", humanize.Duration(result.ProcessTime))
+		for _, timing := range result.StageTimings() {
+			fmt.Printf("    %-12s %s\n", timing.Stage, humanize.Duration(timing.Duration))
+		}
+	}
+	if options.Timing {
+		printChunkTimings(result.ChunkTimings())
 	}
 
-	return nil
+	return result, nil
+}
+
+// batchBarWidth is the number of cells of the batch progress bar
+const batchBarWidth = 20
+
+// formatBatchProgress describes the progress of a batch, e.g.
+// "Batch [#####---------------] 26% 2/5 files, 34m of 2h 10m audio, ETA 14m"
+func formatBatchProgress(s transcriber.BatchSnapshot) string {
+	fraction := s.Fraction()
+	filled := int(fraction * batchBarWidth)
+	text := fmt.Sprintf("Batch [%s%s] %s%% %d/%d files", strings.Repeat("#", filled), strings.Repeat("-", batchBarWidth-filled),
+		humanize.Decimal(fraction*100, 0), s.FilesDone, s.Files)
+	if s.FilesFailed > 0 {
+		text += fmt.Sprintf(" (%d failed)", s.FilesFailed)
+	}
+	if s.AudioTotal > 0 {
+		text += fmt.Sprintf(", %s of %s audio", humanize.Duration(s.AudioDone), humanize.Duration(s.AudioTotal))
+	}
+	if s.ETA > 0 {
+		text += ", ETA " + humanize.Duration(s.ETA)
+	}
+	return text
+}
+
+// formatRenderTimings lists how long each output took, e.g.
+// "text 2ms, srt 15ms, json 40ms"
+func formatRenderTimings(timings []transcriber.RenderTiming) string {
+	parts := make([]string, len(timings))
+	for i, timing := range timings {
+		parts[i] = timing.Format + " " + humanize.Duration(timing.Duration)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printChunkTimings prints where the time of each chunk sent went and the
+// share of each step in all of them, e.g.
+// "    chunk 3   encode 2s  upload 1s  latency 14s  parse 3ms  (1 request)"
+func printChunkTimings(timings []transcriber.ChunkTiming) {
+	if len(timings) == 0 {
+		fmt.Println("  Timing: no chunks were sent to the provider")
+		return
+	}
+	fmt.Println("  Timing:")
+	var total transcriber.ChunkTiming
+	for _, timing := range timings {
+		fmt.Printf("    %-9s %s\n", fmt.Sprintf("chunk %d", timing.Chunk+1), formatChunkTiming(timing))
+		total.Encode += timing.Encode
+		total.Timing = total.Timing.Add(timing.Timing)
+	}
+	fmt.Printf("    %-9s %s\n", "total", formatChunkTiming(total))
+
+	// The largest share says whether ffmpeg, the network or the model is slow
+	if sum := total.Total(); sum > 0 {
+		share := func(d time.Duration) string { return humanize.Decimal(float64(d)/float64(sum)*100, 0) + "%" }
+		fmt.Printf("    %-9s encode %s, upload %s, latency %s, parse %s\n", "share",
+			share(total.Encode), share(total.Upload), share(total.Latency), share(total.Parse))
+	}
+}
+
+// formatChunkTiming describes one timing breakdown
+func formatChunkTiming(timing transcriber.ChunkTiming) string {
+	requests := fmt.Sprintf("%d requests", timing.Requests)
+	if timing.Requests == 1 {
+		requests = "1 request"
+	}
+	return fmt.Sprintf("encode %-7s upload %-7s latency %-7s parse %-7s (%s)",
+		humanize.Duration(timing.Encode), humanize.Duration(timing.Upload),
+		humanize.Duration(timing.Latency), humanize.Duration(timing.Parse), requests)
+}
+
+// printCallMetrics prints the talk time of each speaker and the silences
+// of a call
+func printCallMetrics(metrics transcriber.CallMetrics) {
+	speakers := make([]string, 0, len(metrics.TalkTime))
+	for speaker := range metrics.TalkTime {
+		speakers = append(speakers, speaker)
+	}
+	sort.Strings(speakers)
+	for _, speaker := range speakers {
+		fmt.Printf("  Talk time (%s): %s\n", speaker, humanize.Duration(metrics.TalkTime[speaker]))
+	}
+	fmt.Printf("  Crosstalk: %s\n", humanize.Duration(metrics.Crosstalk))
+	fmt.Printf("  Silence: %s\n", humanize.Duration(metrics.Silence))
+	if len(metrics.Holds) > 0 {
+		fmt.Printf("  Holds: %d, %s total, longest %s\n", len(metrics.Holds),
+			humanize.Duration(metrics.HoldTime), humanize.Duration(metrics.LongestHold))
+	}
+}
+
+// printSegment prints a streamed segment with its start time
+func printSegment(segment providers.TranscriptionSegment) {
+	start := segment.Start.Round(time.Second)
+	speaker := ""
+	if segment.SpeakerID != "" {
+		speaker = segment.SpeakerID + ": "
+	}
+	fmt.Printf("[%02d:%02d:%02d] %s%s\n", int(start.Hours()), int(start.Minutes())%60, int(start.Seconds())%60,
+		speaker, strings.TrimSpace(segment.Text))
+}
+
+// printUsage prints token counts and the estimated cost, if known
+func printUsage(indent string, usage *providers.Usage, cost float64) {
+	if usage != nil {
+		fmt.Printf("%sTokens: %d in / %d out\n", indent, usage.PromptTokens, usage.OutputTokens)
+	}
+	if cost > 0 {
+		fmt.Printf("%sEstimated cost: $%.4f\n", indent, cost)
+	}
+}
+
+// embeddedSubtitles reads the subtitle track of a video in the language
+// of transcribe.subtitles_language, or transcribe.language, for mode. It
+// returns nil when the mode is off, the file is not a video or it has no
+// such track.
+func embeddedSubtitles(ctx context.Context, filePath string, mode transcriber.EmbeddedMode) *transcriber.TranscribeResult {
+	if mode == "" {
+		return nil
+	}
+	if format, ok := mediatype.FromPath(filePath); !ok || !format.Video {
+		return nil
+	}
+	language := viper.GetString("transcribe.subtitles_language")
+	if language == "" {
+		language = viper.GetString("transcribe.language")
+	}
+
+	log := logger.WithComponent("processor").WithField("file", filepath.Base(filePath))
+	result, err := transcriber.EmbeddedSubtitles(ctx, filePath, language)
+	if errors.Is(err, transcriber.ErrNoEmbeddedSubtitles) {
+		log.Info().Str("language", language).Msg("No embedded subtitles in this language; transcribing")
+		return nil
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read embedded subtitles; transcribing")
+		return nil
+	}
+	return result
+}
+
+// subtitleTrackName describes a subtitle track, e.g. "eng, subrip"
+func subtitleTrackName(stream audio.SubtitleStream) string {
+	parts := []string{}
+	for _, part := range []string{stream.Language, stream.Title, stream.Codec} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/manifest"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [manifest]",
+	Short: "Verify a run manifest and the transcripts it lists",
+	Long: `Verify a manifest written with --manifest: check its signature and
+re-hash every output to detect transcripts modified after generation.
+
+Without --public-key the signature is checked against the key embedded in
+the manifest, which proves the manifest is intact but not who signed it.
+
+Examples:
+  # Create a signing key and print its public key
+  gollmscribe verify --generate-key
+
+  # Write a signed manifest during a run
+  gollmscribe transcribe -i ./meetings --manifest manifest.json --signing-key-file signing.key
+
+  # Verify against the trusted public key
+  gollmscribe verify manifest.json --public-key <base64 key>`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if generate, _ := cmd.Flags().GetBool("generate-key"); generate {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().String("public-key", "", "trusted base64 ed25519 public key of the signer")
+	verifyCmd.Flags().String("base-dir", "", "directory relative outputs are resolved against (default: current directory)")
+	verifyCmd.Flags().Bool("unsigned", false, "accept a manifest without a signature and only check file hashes")
+	verifyCmd.Flags().Bool("generate-key", false, "print a new signing key and its public key and exit")
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	if generate, _ := cmd.Flags().GetBool("generate-key"); generate {
+		signingKey, publicKey, err := manifest.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Signing key (keep secret): %s\n", signingKey)
+		fmt.Printf("Public key:                %s\n", publicKey)
+		return nil
+	}
+
+	m, err := manifest.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	var pub ed25519.PublicKey
+	if encoded, _ := cmd.Flags().GetString("public-key"); encoded != "" {
+		if pub, err = manifest.ParsePublicKey(encoded); err != nil {
+			return err
+		}
+	}
+
+	unsigned, _ := cmd.Flags().GetBool("unsigned")
+	switch {
+	case m.Signature == "" && unsigned:
+		fmt.Println("⚠️  Manifest is not signed")
+	default:
+		if err := m.VerifySignature(pub); err != nil {
+			return err
+		}
+		if pub == nil {
+			fmt.Printf("⚠️  Signature is valid for the embedded key %s; pass --public-key to check the signer\n", m.PublicKey)
+		} else {
+			fmt.Println("✅ Signature is valid")
+		}
+	}
+
+	baseDir, _ := cmd.Flags().GetString("base-dir")
+	errs := m.VerifyFiles(baseDir)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d outputs failed verification", len(errs), len(m.Entries))
+	}
+
+	fmt.Printf("✅ All %d outputs match the manifest\n", len(m.Entries))
+	return nil
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+)
+
+// voiceprofileCmd groups commands working on a voice profile directory
+var voiceprofileCmd = &cobra.Command{
+	Use:   "voiceprofile",
+	Short: "Inspect, check and build voice profile directories",
+	Long: `Work with a directory of voice profiles, the reference recordings
+used by --voice-profiles. Each file is a short recording of one speaker,
+named after them: Jane_Doe.mp3 is "Jane Doe" and alice.mp3 is "Alice".
+An optional profiles.yaml in the directory names them instead:
+
+  alice.mp3: Alice Chen
+  bob.wav:
+    name: Bob Martin
+    role: customer
+
+The directory defaults to transcribe.voice_profiles_dir from the config
+file.`,
+}
+
+// voiceprofileListCmd lists the profiles and how much of each is used
+var voiceprofileListCmd = &cobra.Command{
+	Use:   "list [dir]",
+	Short: "List the voice profiles and their durations",
+	Long: `List the speakers in a voice profile directory with the length of
+each recording and how much of it is used (at most 15 seconds).
+
+Examples:
+  gollmscribe voiceprofile list ./voices`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVoiceprofileList,
+}
+
+// voiceprofileValidateCmd reports problems with the profiles
+var voiceprofileValidateCmd = &cobra.Command{
+	Use:   "validate [dir]",
+	Short: "Check every recording in a voice profile directory",
+	Long: `Read every recording in a voice profile directory and report all
+problems at once: files that cannot be read, recordings too short to
+identify a voice or longer than what is used, and speakers with more
+than one recording. With ffmpeg, the speech in each recording is
+measured as well: profiles with under 3 seconds of speech, or whose
+speech levels suggest a second voice, are skipped by transcribe, and
+recordings that are mostly silence are flagged. Exits with an error
+when there are problems.
+
+Examples:
+  gollmscribe voiceprofile validate ./voices`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVoiceprofileValidate,
+}
+
+// voiceprofileBuildCmd writes the merged reference clip
+var voiceprofileBuildCmd = &cobra.Command{
+	Use:   "build [dir]",
+	Short: "Join the voice profiles into the reference clip sent with each chunk",
+	Long: `Join the voice profiles into one MP3, each followed by a second of
+silence, exactly as transcribe prepends it to every chunk, and print where
+each speaker starts. Listen to it to check what the provider hears.
+Requires ffmpeg.
+
+Examples:
+  gollmscribe voiceprofile build ./voices -o voices.mp3`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVoiceprofileBuild,
+}
+
+func init() {
+	rootCmd.AddCommand(voiceprofileCmd)
+	voiceprofileCmd.AddCommand(voiceprofileListCmd)
+	voiceprofileCmd.AddCommand(voiceprofileValidateCmd)
+	voiceprofileCmd.AddCommand(voiceprofileBuildCmd)
+
+	voiceprofileListCmd.Flags().Bool("json", false, "print the profiles as JSON")
+	voiceprofileBuildCmd.Flags().StringP("output", "o", "voices.mp3", "file to write the reference clip to")
+}
+
+// voiceProfileDir returns the directory argument, or the configured one
+func voiceProfileDir(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if dir := loadConfig().Transcribe.VoiceProfilesDir; dir != "" {
+		return dir, nil
+	}
+	return "", fmt.Errorf("no voice profile directory: pass one or set transcribe.voice_profiles_dir")
+}
+
+func runVoiceprofileList(cmd *cobra.Command, args []string) error {
+	dir, err := voiceProfileDir(args)
+	if err != nil {
+		return err
+	}
+	profiles, err := audio.LoadVoiceProfiles(cmd.Context(), dir)
+	if err != nil {
+		return err
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(profiles)
+	}
+	fmt.Printf("%-24s %-9s %-9s %s\n", "SPEAKER", "RECORDED", "USED", "FILE")
+	var total time.Duration
+	for _, profile := range profiles {
+		speaker := profile.Name
+		if profile.Role != "" {
+			speaker += " (" + profile.Role + ")"
+		}
+		fmt.Printf("%-24s %-9s %-9s %s\n", speaker, formatProfileDuration(profile.Recorded),
+			formatProfileDuration(profile.Duration), filepath.Base(profile.Path))
+		total += profile.Duration + time.Second
+	}
+	fmt.Printf("\n%d profiles, %s of reference audio before each chunk\n", len(profiles), formatProfileDuration(total))
+	return nil
+}
+
+func runVoiceprofileValidate(cmd *cobra.Command, args []string) error {
+	dir, err := voiceProfileDir(args)
+	if err != nil {
+		return err
+	}
+	profiles, problems := audio.CheckVoiceProfiles(cmd.Context(), dir)
+
+	fmt.Printf("Checked %d voice profiles in %s\n", len(profiles), dir)
+	for _, problem := range problems {
+		fmt.Printf("  ✗ %v\n", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("voice profiles in %s are not usable, see above", dir)
+	}
+	fmt.Println("  ✓ All profiles are usable")
+	return nil
+}
+
+func runVoiceprofileBuild(cmd *cobra.Command, args []string) error {
+	dir, err := voiceProfileDir(args)
+	if err != nil {
+		return err
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	profiles, err := audio.LoadVoiceProfiles(cmd.Context(), dir)
+	if err != nil {
+		return err
+	}
+	outputDir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	ref, err := audio.BuildVoiceReference(cmd.Context(), profiles, outputDir)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(ref.Path, outputPath); err != nil {
+		_ = ref.Cleanup()
+		return fmt.Errorf("failed to write reference clip: %w", err)
+	}
+
+	fmt.Printf("Wrote %s (%s)\n", outputPath, formatProfileDuration(ref.Duration))
+	for i, profile := range ref.Profiles {
+		fmt.Printf("  %s  %s\n", formatProfileDuration(ref.Offsets[i]), profile.Name)
+	}
+	return nil
+}
+
+// formatProfileDuration formats a duration to a tenth of a second
+func formatProfileDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
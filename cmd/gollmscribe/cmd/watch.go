@@ -13,7 +13,10 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/rules"
 	"github.com/eternnoir/gollmscribe/pkg/transcriber"
 	"github.com/eternnoir/gollmscribe/pkg/watcher"
 )
@@ -26,7 +29,9 @@ var watchCmd = &cobra.Command{
 
 The watch command monitors a directory for new or modified audio/video files and
 automatically transcribes them using the configured AI model. All files in the
-watch session share the same prompt and configuration.
+watch session share the same prompt and configuration, except files with a
+sidecar prompt file: the prompt in meeting1.mp3.prompt.txt is used for
+meeting1.mp3 instead.
 
 Examples:
   # Watch current directory
@@ -35,17 +40,39 @@ Examples:
   # Watch with custom prompt
   gollmscribe watch ./recordings -p "Transcribe and identify speakers"
 
+  # Watch with a named prompt from the prompt library
+  gollmscribe watch ./calls --prompt-name sales-call
+
   # Watch recursively with file movement
   gollmscribe watch ./inbox -r --move-to ./processed
 
   # Watch with custom output directory
   gollmscribe watch ./meetings --output-dir ./transcripts
 
-  # Process existing files once and exit
+  # Move files that failed twice to ./quarantine with a .error.json of
+  # their failures instead of retrying them on every scan
+  gollmscribe watch ./inbox --quarantine-dir ./quarantine --max-attempts 2
+
+  # Process existing files once and exit; run it again after an
+  # interruption to continue where it stopped
   gollmscribe watch ./batch --once
 
+  # Start a --once backlog over instead of continuing the interrupted one
+  gollmscribe watch ./batch --once --reset-session
+
   # Watch specific file types
-  gollmscribe watch ./audio --pattern "*.mp3,*.m4a"`,
+  gollmscribe watch ./audio --pattern "*.mp3,*.m4a"
+
+  # Call-center drop folder: stereo recordings with the agent on the left
+  # channel and the customer on the right, interleaved by time
+  gollmscribe watch ./calls --call-center --call-speakers Agent,Customer
+
+  # Mixed-language inbox: detect each file's language and use the prompt
+  # for it from watch.languages, writing meeting_zh.txt, call_en.txt, ...
+  gollmscribe watch ./inbox --route-languages
+
+Send SIGHUP to reload provider settings from the config file; files already
+being transcribed finish on the previous provider.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runWatch,
 }
@@ -60,10 +87,13 @@ func init() {
 	watchCmd.Flags().Duration("interval", 5*time.Second, "polling interval for new files")
 	watchCmd.Flags().Bool("once", false, "process existing files and exit")
 	watchCmd.Flags().Bool("no-existing", false, "skip processing existing files on startup")
+	watchCmd.Flags().Bool("reset-session", false, "with --once, start the backlog over instead of continuing an interrupted run")
 
 	// Processing options
 	watchCmd.Flags().StringP("prompt", "p", "", "shared prompt for all transcriptions")
 	watchCmd.Flags().String("prompt-file", "", "file containing shared prompt")
+	watchCmd.Flags().StringArray("note", nil, "operator note stored with every transcript and history record, searchable with history search (repeatable)")
+	watchCmd.Flags().String("prompt-name", "", "named prompt from the prompt library shared by all transcriptions")
 	watchCmd.Flags().Duration("stability-wait", 2*time.Second, "time to wait for file stability")
 	watchCmd.Flags().Duration("processing-timeout", 30*time.Minute, "maximum time to process a single file")
 	watchCmd.Flags().Int("max-workers", 3, "maximum concurrent processing workers")
@@ -71,16 +101,25 @@ func init() {
 	// Output options
 	watchCmd.Flags().String("output-dir", "", "directory for transcription outputs")
 	watchCmd.Flags().String("move-to", "", "move processed files to this directory")
+	watchCmd.Flags().Bool("keep-versions", false, "keep the transcript of a reprocessed file and write the new one as name_v2.txt, ...")
 
 	// History options
 	watchCmd.Flags().String("history-db", ".gollmscribe-watch.db", "path to history database")
+	watchCmd.Flags().String("history-locked", "fail", "when another instance has the history database open: fail, readonly (records kept in memory only) or instance (use a separate database next to it)")
 	watchCmd.Flags().Bool("retry-failed", false, "retry previously failed files")
+	watchCmd.Flags().String("quarantine-dir", "", "move files that keep failing here, with a .error.json of their failures")
+	watchCmd.Flags().Int("max-attempts", 3, "failed attempts before a file is quarantined")
 
 	// Transcription options (inherited from transcribe command)
 	watchCmd.Flags().Int("chunk-minutes", 15, "chunk duration in minutes")
 	watchCmd.Flags().Int("overlap-seconds", 30, "overlap duration in seconds")
 	watchCmd.Flags().Float32("temperature", 0.1, "LLM temperature (0.0-1.0)")
 	watchCmd.Flags().Bool("preserve-audio", false, "keep temporary audio files")
+	watchCmd.Flags().String("style", "", "transcript style: verbatim, clean or notes")
+	watchCmd.Flags().Bool("route-languages", false, "detect each file's language and use its prompt from watch.languages")
+	watchCmd.Flags().String("language-check", "", "catch chunks transcribed in another language than transcribe.language or the detected one: flag or rerun")
+	watchCmd.Flags().Bool("call-center", false, "stereo call recordings: transcribe each channel separately with fixed speaker labels (default: call.enabled)")
+	watchCmd.Flags().StringSlice("call-speakers", []string{"Agent", "Customer"}, "speaker of each channel for --call-center, left first")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("watch.pattern", watchCmd.Flags().Lookup("pattern"))
@@ -92,6 +131,9 @@ func init() {
 	_ = viper.BindPFlag("watch.output_dir", watchCmd.Flags().Lookup("output-dir"))
 	_ = viper.BindPFlag("watch.move_to", watchCmd.Flags().Lookup("move-to"))
 	_ = viper.BindPFlag("watch.history_db", watchCmd.Flags().Lookup("history-db"))
+	_ = viper.BindPFlag("watch.history_locked", watchCmd.Flags().Lookup("history-locked"))
+	_ = viper.BindPFlag("watch.quarantine_dir", watchCmd.Flags().Lookup("quarantine-dir"))
+	_ = viper.BindPFlag("watch.max_attempts", watchCmd.Flags().Lookup("max-attempts"))
 }
 
 func runWatch(cmd *cobra.Command, args []string) error {
@@ -109,15 +151,14 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("watch path must be a directory")
 	}
 
-	// Validate API key
-	apiKey := viper.GetString("api_key")
-	if apiKey == "" {
+	// Validate credentials
+	appCfg := loadConfig()
+	if err := requireCredentials(appCfg); err != nil {
 		log.Error().Msg("API key is required")
-		return fmt.Errorf("API key is required. Set GOLLMSCRIBE_API_KEY environment variable or use --api-key flag")
+		return err
 	}
 
 	// Initialize provider first
-	appCfg := loadConfig()
 	provider, err := initializeProvider(appCfg)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialize provider")
@@ -129,12 +170,42 @@ func runWatch(cmd *cobra.Command, args []string) error {
 
 	// Get transcribe options from CLI and apply to config
 	transcribeOpts := getWatchTranscribeOptions(cmd, appCfg)
+	if transcribeOpts.MergeStrategy, err = transcriber.ParseMergeStrategy(appCfg.Transcribe.MergeStrategy); err != nil {
+		return err
+	}
+	if err := transcriber.CheckFormats(transcribeOpts.OutputFormats); err != nil {
+		return err
+	}
+	style, _ := cmd.Flags().GetString("style")
+	if !cmd.Flags().Changed("style") {
+		style = appCfg.Transcribe.Style
+	}
+	if transcribeOpts.Style, err = transcriber.ParseStyle(style); err != nil {
+		return err
+	}
+	callCenter, _ := cmd.Flags().GetBool("call-center")
+	if !cmd.Flags().Changed("call-center") {
+		callCenter = appCfg.Call.Enabled
+	}
+	if callCenter {
+		speakers, _ := cmd.Flags().GetStringSlice("call-speakers")
+		if !cmd.Flags().Changed("call-speakers") {
+			speakers = appCfg.Call.Speakers
+		}
+		speakers = splitList(speakers)
+		if len(speakers) < 2 {
+			return fmt.Errorf("--call-center needs a speaker for each channel, e.g. --call-speakers Agent,Customer")
+		}
+		transcribeOpts.ChannelSpeakers = speakers
+		transcribeOpts.HoldThreshold = appCfg.Call.HoldThreshold
+		log.Info().Strs("speakers", speakers).Msg("Transcribing each channel of call recordings separately")
+	}
 	cfg.TranscribeOptions = transcribeOpts
 
 	log.Debug().Interface("config", cfg).Msg("Loaded watch configuration")
 
 	// Get custom prompt
-	customPrompt, err := getWatchPrompt(cmd)
+	customPrompt, err := getWatchPrompt(cmd, appCfg)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get custom prompt")
 		return fmt.Errorf("failed to get custom prompt: %w", err)
@@ -144,11 +215,83 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		log.Info().Str("prompt_preview", truncateString(customPrompt, 100)).Msg("Using shared custom prompt")
 	}
 
-	// Create transcriber
-	tr := transcriber.NewTranscriber(provider, appCfg)
+	// Create transcriber; all routes share one budget guard so --max-cost
+	// covers the whole session
+	run, err := loadRunResources(appCfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize run resources")
+		return err
+	}
+	cfg.HistoryCipher = run.cipher
+	cfg.HistoryLocked = appCfg.Watch.HistoryLocked
+	cfg.QuarantineDir = appCfg.Watch.QuarantineDir
+	cfg.MaxAttempts = appCfg.Watch.MaxAttempts
+	if cfg.QuarantineDir != "" {
+		log.Info().Str("dir", cfg.QuarantineDir).Int("max_attempts", cfg.MaxAttempts).Msg("Quarantining files that keep failing")
+	}
+	cfg.Retention = retentionPolicy(appCfg.Watch.Retention)
+	if cfg.Retention.Enabled() {
+		log.Info().
+			Int("media_days", appCfg.Watch.Retention.MediaDays).
+			Int("transcript_days", appCfg.Watch.Retention.TranscriptDays).
+			Str("action", cfg.Retention.Action).
+			Bool("dry_run", cfg.Retention.DryRun).
+			Msg("Retention policy enabled")
+	}
+	cfg.Export = historyExport(appCfg.Watch.Export)
+	if cfg.Export.Enabled() {
+		log.Info().
+			Str("path", cfg.Export.Path).
+			Str("url", cfg.Export.URL).
+			Dur("interval", cfg.Export.Interval).
+			Msg("History export enabled")
+	}
+	tr := run.newTranscriber(provider, appCfg)
+	if appCfg.Budget.MaxCostPerRun > 0 {
+		log.Info().Float64("max_cost", appCfg.Budget.MaxCostPerRun).Msg("Session budget enabled, files over budget will be skipped")
+	}
+
+	// Pick prompts and output names by language
+	routeLanguages, _ := cmd.Flags().GetBool("route-languages")
+	if !cmd.Flags().Changed("route-languages") {
+		routeLanguages = appCfg.Watch.Languages.Enabled
+	}
+	if routeLanguages {
+		if cfg.Languages, err = loadLanguageRouting(appCfg); err != nil {
+			log.Error().Err(err).Msg("Failed to initialize language routing")
+			return err
+		}
+		log.Info().Int("languages", len(cfg.Languages.Prompts)).Msg("Language routing enabled")
+	}
+	languageCheck, _ := cmd.Flags().GetString("language-check")
+	if !cmd.Flags().Changed("language-check") {
+		languageCheck = appCfg.Transcribe.LanguageCheck
+	}
+	if cfg.TranscribeOptions.LanguageCheck, err = transcriber.ParseLanguageCheck(languageCheck); err != nil {
+		return err
+	}
+	if cfg.TranscribeOptions.LanguageCheck != "" && cfg.TranscribeOptions.ExpectedLanguage == "" && cfg.Languages == nil {
+		return fmt.Errorf("--language-check needs transcribe.language or --route-languages to know the language of the audio")
+	}
+
+	// Create per-directory routes
+	cfg.Routes, err = loadWatchRoutes(appCfg, run)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize watch routes")
+		return fmt.Errorf("failed to initialize watch routes: %w", err)
+	}
+	if cfg.Rules, err = loadRules(appCfg, run); err != nil {
+		log.Error().Err(err).Msg("Failed to initialize rules")
+		return err
+	}
 
 	// Create file watcher
 	fileWatcher, err := watcher.NewFileWatcher(cfg, tr)
+	if watcher.IsHistoryLocked(err) {
+		return fmt.Errorf("%w\nIs gollmscribe already watching this directory? Stop it first, or start this instance with "+
+			"--history-locked readonly (its records are kept in memory only), --history-locked instance (a separate history "+
+			"next to it) or its own --history-db", err)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create file watcher")
 		return fmt.Errorf("failed to create file watcher: %w", err)
@@ -163,8 +306,11 @@ func runWatch(cmd *cobra.Command, args []string) error {
 			fmt.Printf("⏳ Processing: %s\n", event.FilePath)
 		case "completed":
 			fmt.Printf("✅ Completed: %s - %s\n", event.FilePath, event.Message)
+			run.recordOutput(event.FilePath, event.OutputPath, nil)
 		case "failed":
 			fmt.Printf("❌ Failed: %s - %v\n", event.FilePath, event.Error)
+		case "quarantined":
+			fmt.Printf("🚫 Quarantined: %s - %s\n", event.FilePath, event.Message)
 		case "skipped":
 			fmt.Printf("⏭️  Skipped: %s - %s\n", event.FilePath, event.Message)
 		}
@@ -178,6 +324,26 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// SIGHUP reloads provider settings without dropping in-flight files
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	defer signal.Stop(reloadChan)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloadChan:
+				if err := reloadProviders(tr, cfg.Routes, cfg.Rules); err != nil {
+					log.Error().Err(err).Msg("Failed to reload providers, keeping current providers")
+					fmt.Printf("❌ Provider reload failed: %v\n", err)
+					continue
+				}
+				fmt.Printf("🔄 Providers reloaded, new files use %s\n", tr.Provider().Name())
+			}
+		}
+	}()
+
 	// Start watcher
 	if err := fileWatcher.Start(ctx); err != nil {
 		log.Error().Err(err).Msg("Failed to start file watcher")
@@ -189,11 +355,19 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	if once {
 		log.Info().Msg("Running in once mode, will exit after processing existing files")
 
-		// Wait for initial processing to complete using WaitGroup
-		wg := fileWatcher.WaitForInitialProcessing()
-		wg.Wait()
-
-		log.Info().Msg("Initial processing completed, exiting")
+		// Wait for initial processing to complete, or for a signal
+		done := make(chan struct{})
+		go func() {
+			fileWatcher.WaitForInitialProcessing().Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			log.Info().Msg("Initial processing completed, exiting")
+		case <-sigChan:
+			fmt.Println("\n\n🛑 Interrupted, the next --once run continues with the remaining files")
+			cancel()
+		}
 	} else {
 		// Show watching message
 		fmt.Printf("\n👀 Watching directory: %s\n", watchDir)
@@ -230,8 +404,92 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	fmt.Printf("   Processed: %d files\n", stats.ProcessedCount)
 	fmt.Printf("   Failed: %d files\n", stats.FailedCount)
 	fmt.Printf("   Skipped: %d files\n", stats.SkippedCount)
-	fmt.Printf("   Duration: %v\n", time.Since(stats.StartTime).Round(time.Second))
+	elapsed := time.Since(stats.StartTime)
+	fmt.Printf("   Duration: %s\n", humanize.Duration(elapsed))
+	if stats.ProcessedCount > 0 {
+		fmt.Printf("   Throughput: %s\n", humanize.PerHour(stats.ProcessedCount, elapsed, "files"))
+	}
+
+	return nil
+}
+
+// reloadProviders re-reads the config file and swaps freshly built providers
+// into the default transcriber and the routes and rules with their own
+// provider.
+// Files already being transcribed finish on the providers they started with.
+func reloadProviders(tr *transcriber.TranscriberImpl, routes []watcher.Route, loaded []rules.Rule) error {
+	log := logger.WithComponent("watch")
+
+	// Decrypted like at startup; on failure the settings stay as they were
+	err := config.ReadInConfig(viper.GetViper(), config.DecryptOptions{
+		KeyFile: viper.GetString("config_key_file"),
+		KMS:     viper.GetString("config_kms"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	appCfg := loadConfig()
+	if err := requireCredentials(appCfg); err != nil {
+		return err
+	}
 
+	// Build every provider before swapping any, so a bad config changes nothing
+	provider, err := initializeProvider(appCfg)
+	if err != nil {
+		return err
+	}
+	routeProviders := make(map[string]providers.LLMProvider)
+	for _, rc := range appCfg.Watch.Routes {
+		if rc.Provider == (config.ProviderRef{}) {
+			continue
+		}
+		p, err := newRouteProvider(appCfg.Provider, rc.Provider)
+		if err != nil {
+			return fmt.Errorf("route %s: %w", rc.Dir, err)
+		}
+		if err := checkPrivacy(appCfg.Privacy, p); err != nil {
+			return fmt.Errorf("route %s: %w", rc.Dir, err)
+		}
+		routeProviders[rc.Dir] = p
+	}
+	ruleProviders := make(map[string]providers.LLMProvider)
+	for i, rc := range appCfg.Rules {
+		if rc.Provider == (config.ProviderRef{}) {
+			continue
+		}
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		p, err := newRouteProvider(appCfg.Provider, rc.Provider)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		privacy := appCfg.Privacy
+		privacy.LocalOnly = privacy.LocalOnly || rc.LocalOnly
+		if err := checkPrivacy(privacy, p); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		ruleProviders[name] = p
+	}
+
+	tr.SwapProvider(provider)
+	for _, route := range routes {
+		p, ok := routeProviders[route.Dir]
+		if !ok || route.Transcriber == nil {
+			continue
+		}
+		route.Transcriber.SetProvider(p)
+		log.Info().Str("dir", route.Dir).Str("provider", p.Name()).Msg("Route provider reloaded")
+	}
+	for _, rule := range loaded {
+		p, ok := ruleProviders[rule.Name]
+		if !ok || rule.Transcriber == nil {
+			continue
+		}
+		rule.Transcriber.SetProvider(p)
+		log.Info().Str("rule", rule.Name).Str("provider", p.Name()).Msg("Rule provider reloaded")
+	}
 	return nil
 }
 
@@ -258,12 +516,81 @@ func loadWatchConfig(cmd *cobra.Command, watchDir string) *watcher.WatchConfig {
 	noExisting, _ := cmd.Flags().GetBool("no-existing")
 	cfg.ProcessExisting = !noExisting
 
+	// A --once run records its backlog, so an interrupted run is continued
+	cfg.ResumeSession, _ = cmd.Flags().GetBool("once")
+	cfg.ResetSession, _ = cmd.Flags().GetBool("reset-session")
+
 	cfg.RetryFailed, _ = cmd.Flags().GetBool("retry-failed")
+	cfg.Notes, _ = cmd.Flags().GetStringArray("note")
 
 	return cfg
 }
 
-func getWatchPrompt(cmd *cobra.Command) (string, error) {
+// loadWatchRoutes creates a transcriber for every configured watch route,
+// all sharing the session's run resources
+func loadWatchRoutes(appCfg *config.Config, run *runResources) ([]watcher.Route, error) {
+	log := logger.WithComponent("watch")
+
+	routes := make([]watcher.Route, 0, len(appCfg.Watch.Routes))
+	for _, rc := range appCfg.Watch.Routes {
+		if rc.Dir == "" {
+			return nil, fmt.Errorf("watch route is missing dir")
+		}
+
+		route := watcher.Route{Dir: rc.Dir, Prompt: rc.Prompt}
+		if route.Prompt == "" && rc.PromptName != "" {
+			prompt, err := namedPrompt(appCfg, rc.PromptName)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: %w", rc.Dir, err)
+			}
+			route.Prompt = prompt
+		}
+		if rc.Provider != (config.ProviderRef{}) {
+			provider, err := newRouteProvider(appCfg.Provider, rc.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: %w", rc.Dir, err)
+			}
+			if err := checkPrivacy(appCfg.Privacy, provider); err != nil {
+				return nil, fmt.Errorf("route %s: %w", rc.Dir, err)
+			}
+			route.Transcriber = run.newTranscriber(provider, appCfg)
+		}
+
+		log.Info().
+			Str("dir", rc.Dir).
+			Str("provider", rc.Provider.Name).
+			Str("model", rc.Provider.Model).
+			Str("prompt_name", rc.PromptName).
+			Msg("Watch route configured")
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// loadLanguageRouting builds the language routing of watch mode, looking
+// up the named prompts of watch.languages.prompt_names
+func loadLanguageRouting(appCfg *config.Config) (*watcher.LanguageRouting, error) {
+	lc := appCfg.Watch.Languages
+	routing := &watcher.LanguageRouting{
+		Sample:  time.Duration(lc.SampleSeconds) * time.Second,
+		Prompts: make(map[string]string, len(lc.Prompts)+len(lc.PromptNames)),
+		Suffix:  lc.Suffix,
+	}
+	for language, name := range lc.PromptNames {
+		prompt, err := namedPrompt(appCfg, name)
+		if err != nil {
+			return nil, fmt.Errorf("language %s: %w", language, err)
+		}
+		routing.Prompts[language] = prompt
+	}
+	for language, prompt := range lc.Prompts {
+		routing.Prompts[language] = prompt
+	}
+	return routing, nil
+}
+
+func getWatchPrompt(cmd *cobra.Command, cfg *config.Config) (string, error) {
 	// Check direct prompt flag
 	if prompt, _ := cmd.Flags().GetString("prompt"); prompt != "" {
 		return prompt, nil
@@ -278,6 +605,11 @@ func getWatchPrompt(cmd *cobra.Command) (string, error) {
 		return strings.TrimSpace(string(data)), nil
 	}
 
+	// Check named prompt flag
+	if name, _ := cmd.Flags().GetString("prompt-name"); name != "" {
+		return namedPrompt(cfg, name)
+	}
+
 	return "", nil
 }
 
@@ -298,6 +630,7 @@ func getWatchTranscribeOptions(cmd *cobra.Command, cfg *config.Config) transcrib
 	}
 
 	preserveAudio, _ := cmd.Flags().GetBool("preserve-audio")
+	keepVersions, _ := cmd.Flags().GetBool("keep-versions")
 
 	// Use max workers from watch config
 	workers, _ := cmd.Flags().GetInt("max-workers")
@@ -308,6 +641,25 @@ func getWatchTranscribeOptions(cmd *cobra.Command, cfg *config.Config) transcrib
 		Workers:        workers,
 		Temperature:    temperature,
 		PreserveAudio:  preserveAudio,
+		ChunkRetries:   cfg.Transcribe.ChunkRetries,
+		AllowPartial:   cfg.Transcribe.AllowPartial,
+		TrimSilence:    cfg.Audio.TrimSilence,
+		SkipSilence:    cfg.Audio.SkipSilence,
+		LeadingContext: cfg.Audio.LeadingContext,
+
+		AdaptiveWorkers: cfg.Audio.AdaptiveWorkers,
+		MinWorkers:      cfg.Audio.MinWorkers,
+		MaxWorkers:      cfg.Audio.MaxWorkers,
+
+		VoiceProfilesDir:  cfg.Transcribe.VoiceProfilesDir,
+		SpeakerMap:        cfg.Transcribe.SpeakerMap,
+		NativeDiarization: cfg.Transcribe.NativeDiarization,
+		SegmentLanguages:  cfg.Transcribe.SegmentLanguages,
+		ExpectedLanguage:  expectedLanguage(cfg.Transcribe.Language),
+
+		OutputFormats: cfg.Output.Formats,
+		RenderWorkers: cfg.Output.RenderWorkers,
+		KeepVersions:  keepVersions || cfg.Output.KeepVersions,
 	}
 }
 
@@ -318,8 +670,8 @@ func displayStats(fw watcher.FileWatcher) {
 	for range ticker.C {
 		stats := fw.GetStats()
 		if stats.ProcessedCount > 0 || stats.FailedCount > 0 {
-			fmt.Printf("\r📊 Stats - Processed: %d | Failed: %d | In Progress: %d",
-				stats.ProcessedCount, stats.FailedCount, stats.InProgress)
+			fmt.Printf("\r📊 Stats - Processed: %d | Failed: %d | In Progress: %d | Up: %s",
+				stats.ProcessedCount, stats.FailedCount, stats.InProgress, humanize.Duration(time.Since(stats.StartTime)))
 		}
 	}
 }
@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// BleepFrequency is the pitch of the tone played over bleeped spans, in Hz
+const BleepFrequency = 1000
+
+// Span is a time range of a recording
+type Span struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Bleep writes a copy of the audio of inputPath to outputPath as MP3, with
+// every span silenced and covered by a tone, e.g. to share a recording
+// without the words redacted from its transcript, killing ffmpeg when ctx
+// is done
+func Bleep(ctx context.Context, inputPath, outputPath string, spans []Span) error {
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot bleep %s: %w", filepath.Base(inputPath), ErrFFmpegRequired)
+	}
+	enable := spanExpression(spans)
+	if enable == "" {
+		return fmt.Errorf("no spans to bleep")
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	muted := ffmpeg.Input(inputPath).Audio().
+		Filter("volume", ffmpeg.Args{"0"}, ffmpeg.KwArgs{"enable": enable})
+	tone := ffmpeg.Input(fmt.Sprintf("sine=frequency=%d:sample_rate=44100", BleepFrequency), ffmpeg.KwArgs{"f": "lavfi"}).
+		Filter("volume", ffmpeg.Args{"0"}, ffmpeg.KwArgs{"enable": "not(" + enable + ")"})
+
+	err := runFFmpeg(ctx, ffmpeg.Filter([]*ffmpeg.Stream{muted, tone}, "amix", nil, ffmpeg.KwArgs{
+		"inputs":    2,
+		"duration":  "first",
+		"normalize": 0,
+	}).Output(outputPath, ffmpeg.KwArgs{
+		"acodec": mediatype.Encoder(string(FormatMP3)),
+		"ab":     "192k",
+		"ar":     "44100",
+	}).OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return fmt.Errorf("ffmpeg bleep failed: %w", err)
+	}
+	return nil
+}
+
+// spanExpression returns an ffmpeg timeline expression that is true inside
+// any of the spans, or "" if none is longer than zero
+func spanExpression(spans []Span) string {
+	var terms []string
+	for _, span := range spans {
+		if span.End <= span.Start {
+			continue
+		}
+		terms = append(terms, fmt.Sprintf("between(t,%.3f,%.3f)", span.Start.Seconds(), span.End.Seconds()))
+	}
+	return strings.Join(terms, "+")
+}
@@ -0,0 +1,21 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpanExpression(t *testing.T) {
+	spans := []Span{
+		{Start: 1500 * time.Millisecond, End: 3 * time.Second},
+		{Start: 5 * time.Second, End: 5 * time.Second}, // Empty, skipped
+		{Start: 62 * time.Second, End: 64250 * time.Millisecond},
+	}
+	want := "between(t,1.500,3.000)+between(t,62.000,64.250)"
+	if got := spanExpression(spans); got != want {
+		t.Errorf("spanExpression() = %q, want %q", got, want)
+	}
+	if got := spanExpression(nil); got != "" {
+		t.Errorf("spanExpression(nil) = %q, want empty", got)
+	}
+}
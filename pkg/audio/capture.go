@@ -0,0 +1,190 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// Capture timing
+var (
+	capturePoll        = 500 * time.Millisecond // How often the segment list is read
+	captureStopTimeout = 10 * time.Second       // How long ffmpeg gets to finish the last segment
+)
+
+// CaptureInput is what a capture records: an input device with its ffmpeg
+// input format, or a file or stream URL read by ffmpeg as it is
+type CaptureInput struct {
+	Format string            // ffmpeg input format, e.g. "pulse" or "avfoundation"; empty for URLs
+	Source string            // Device name or URL
+	Args   map[string]string // Extra ffmpeg input options, e.g. "rtsp_transport": "tcp"
+}
+
+// DefaultMicrophone returns the default audio input device of the platform
+func DefaultMicrophone() (CaptureInput, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return CaptureInput{Format: "pulse", Source: "default"}, nil
+	case "darwin":
+		return CaptureInput{Format: "avfoundation", Source: ":0"}, nil
+	}
+	return CaptureInput{}, fmt.Errorf("no default microphone on %s, name the input device", runtime.GOOS)
+}
+
+// StreamInput returns the capture input for a network stream: RTSP and
+// RTMP cameras or servers, HLS playlists and HTTP streams such as Icecast.
+// RTSP is read over TCP and HTTP streams reconnect after a dropped
+// connection.
+func StreamInput(rawURL string) (CaptureInput, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return CaptureInput{}, fmt.Errorf("invalid stream URL: %w", err)
+	}
+
+	input := CaptureInput{Source: rawURL}
+	switch strings.ToLower(u.Scheme) {
+	case "rtsp", "rtsps":
+		input.Args = map[string]string{"rtsp_transport": "tcp"}
+	case "http", "https":
+		input.Args = map[string]string{
+			"reconnect":           "1",
+			"reconnect_streamed":  "1",
+			"reconnect_delay_max": "30",
+		}
+	case "rtmp", "rtmps", "srt", "udp":
+	default:
+		return CaptureInput{}, fmt.Errorf("unsupported stream URL %q: use rtsp, rtmp, srt, udp, http or https", rawURL)
+	}
+	return input, nil
+}
+
+// Capture records input into MP3 segments of segmentDuration in dir and
+// sends the path of every finished segment on the returned channel, in
+// order. Recording stops when ctx is done, which finishes the segment
+// being recorded, or when the input ends. The channel is closed once the
+// last segment is sent; the error channel then receives why ffmpeg failed,
+// if it did, and is closed.
+func Capture(ctx context.Context, input CaptureInput, segmentDuration time.Duration, dir string) (<-chan string, <-chan error) {
+	segments := make(chan string)
+	errs := make(chan error, 1)
+
+	fail := func(err error) (<-chan string, <-chan error) {
+		close(segments)
+		errs <- err
+		close(errs)
+		return segments, errs
+	}
+	if !FFmpegAvailable() {
+		return fail(fmt.Errorf("cannot capture %s: %w", input.Source, ErrFFmpegRequired))
+	}
+	if segmentDuration <= 0 {
+		return fail(fmt.Errorf("segment duration must be positive"))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fail(fmt.Errorf("failed to create capture directory: %w", err))
+	}
+
+	inputArgs := ffmpeg.KwArgs{}
+	for k, v := range input.Args {
+		inputArgs[k] = v
+	}
+	if input.Format != "" {
+		inputArgs["f"] = input.Format
+	}
+	listPath := filepath.Join(dir, "segments.txt")
+	stdin, stop := io.Pipe()
+	var stderr bytes.Buffer
+
+	// The segment muxer adds each segment to the list once it is closed
+	cmd := ffmpeg.Input(input.Source, inputArgs).Output(filepath.Join(dir, "segment_%05d.mp3"), ffmpeg.KwArgs{
+		"f":                 "segment",
+		"segment_time":      fmt.Sprintf("%.3f", segmentDuration.Seconds()),
+		"segment_list":      listPath,
+		"segment_list_type": "flat",
+		"reset_timestamps":  1,
+		"acodec":            mediatype.Encoder(string(FormatMP3)),
+		"ab":                "128k",
+		"ar":                "44100",
+		"ac":                1,
+		"vn":                "",
+	}).GlobalArgs("-loglevel", "error").OverWriteOutput().WithInput(stdin).WithErrorOutput(&stderr).Compile()
+	if err := cmd.Start(); err != nil {
+		return fail(fmt.Errorf("failed to start ffmpeg: %w", err))
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	go func() {
+		defer close(errs)
+		defer close(segments)
+
+		sent := 0
+		send := func() {
+			listed := readSegmentList(listPath, dir)
+			for ; sent < len(listed); sent++ {
+				segments <- listed[sent]
+			}
+		}
+
+		done := ctx.Done()
+		ticker := time.NewTicker(capturePoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				// "q" makes ffmpeg close the current segment and exit; an
+				// input that stopped responding is killed
+				done = nil
+				go func() { _, _ = stop.Write([]byte("q")) }()
+				kill := time.AfterFunc(captureStopTimeout, func() { _ = cmd.Process.Kill() })
+				defer kill.Stop()
+			case err := <-exited:
+				_ = stop.Close()
+				send()
+				// Ctrl+C also reaches ffmpeg, which may exit before done is seen
+				if err != nil && ctx.Err() == nil {
+					errs <- fmt.Errorf("ffmpeg capture failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+				}
+				return
+			case <-ticker.C:
+				send()
+			}
+		}
+	}()
+	return segments, errs
+}
+
+// readSegmentList returns the segments in a segment muxer list, relative
+// to dir unless absolute. A line still being written is left out.
+func readSegmentList(listPath, dir string) []string {
+	data, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+
+	var segments []string
+	for _, line := range lines[:len(lines)-1] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		segments = append(segments, line)
+	}
+	return segments
+}
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSegmentList(t *testing.T) {
+	dir := t.TempDir()
+	listPath := filepath.Join(dir, "segments.txt")
+
+	if got := readSegmentList(listPath, dir); len(got) != 0 {
+		t.Errorf("readSegmentList() without a list = %v", got)
+	}
+
+	// The last line is still being written
+	list := "segment_00000.mp3\n/abs/segment_00001.mp3\n\nsegment_0000"
+	if err := os.WriteFile(listPath, []byte(list), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := readSegmentList(listPath, dir)
+	want := []string{filepath.Join(dir, "segment_00000.mp3"), "/abs/segment_00001.mp3"}
+	if len(got) != len(want) {
+		t.Fatalf("readSegmentList() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("readSegmentList()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStreamInput(t *testing.T) {
+	tests := []struct {
+		url     string
+		arg     string
+		wantErr bool
+	}{
+		{"rtsp://camera.local:554/live", "rtsp_transport", false},
+		{"https://radio.example.com/live.mp3", "reconnect", false},
+		{"http://cdn.example.com/event/index.m3u8", "reconnect_streamed", false},
+		{"rtmp://live.example.com/app/key", "", false},
+		{"meeting.mp3", "", true},
+		{"ftp://example.com/a.mp3", "", true},
+	}
+	for _, tt := range tests {
+		input, err := StreamInput(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("StreamInput(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if input.Source != tt.url || input.Format != "" {
+			t.Errorf("StreamInput(%q) = %+v", tt.url, input)
+		}
+		if _, ok := input.Args[tt.arg]; tt.arg != "" && !ok {
+			t.Errorf("StreamInput(%q) args = %v, want %s", tt.url, input.Args, tt.arg)
+		}
+	}
+}
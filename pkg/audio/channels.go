@@ -0,0 +1,35 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// ExtractChannel writes one channel of a multichannel recording, counted
+// from 0 (left), to a mono MP3, killing ffmpeg when ctx is done
+func ExtractChannel(ctx context.Context, inputPath string, channel int, outputPath string) error {
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot split channels of %s: %w", filepath.Base(inputPath), ErrFFmpegRequired)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	err := runFFmpeg(ctx, ffmpeg.Input(inputPath).Output(outputPath, ffmpeg.KwArgs{
+		"af":     fmt.Sprintf("pan=mono|c0=c%d", channel),
+		"acodec": mediatype.Encoder(string(FormatMP3)),
+		"ab":     "128k",
+		"ar":     "44100",
+		"vn":     "",
+	}).OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return fmt.Errorf("ffmpeg channel extraction failed: %w", err)
+	}
+	return nil
+}
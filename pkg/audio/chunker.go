@@ -1,14 +1,54 @@
 package audio
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/eternnoir/gollmscribe/pkg/logger"
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
+// HashFile calculates the SHA256 content hash used to identify media files.
+// Only the first 1MB is hashed (plus the file size) for performance on large files.
+func HashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, 1024*1024); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	// Also include file size in hash for better uniqueness
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	_, _ = fmt.Fprintf(hash, ":%d", info.Size())
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// ChunkKey returns a stable identifier for the audio between start and end
+// of a source file. Unlike the chunk index it does not change when a file
+// is split differently, so the same span always maps to the same key.
+func ChunkKey(sourceHash string, start, end time.Duration) string {
+	if len(sourceHash) > 16 {
+		sourceHash = sourceHash[:16]
+	}
+	return fmt.Sprintf("%s-%d-%d", sourceHash, start.Milliseconds(), end.Milliseconds())
+}
+
 // ChunkerImpl implements the Chunker interface
 type ChunkerImpl struct {
 	tempDir string
@@ -25,59 +65,187 @@ func NewChunker(tempDir string) *ChunkerImpl {
 }
 
 // ChunkAudio splits an audio file into overlapping chunks
+//
+// Deprecated: Use ChunkAudioContext, which stops ffmpeg on cancellation.
 func (c *ChunkerImpl) ChunkAudio(inputPath string, options ProcessorOptions) ([]*ChunkInfo, error) {
+	return c.ChunkAudioContext(context.Background(), inputPath, options)
+}
+
+// ChunkAudioContext splits an audio file into overlapping chunks,
+// killing ffmpeg when ctx is done; the chunks written so far are removed
+func (c *ChunkerImpl) ChunkAudioContext(ctx context.Context, inputPath string, options ProcessorOptions) ([]*ChunkInfo, error) {
 	// Get audio duration first
 	processor := NewProcessor(c.tempDir)
-	audioInfo, err := processor.GetAudioInfo(inputPath)
+	audioInfo, err := processor.GetAudioInfoContext(ctx, inputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get audio info: %w", err)
 	}
 
 	// Calculate chunk boundaries
-	chunks := c.CalculateChunks(audioInfo.Duration, options.ChunkDuration, options.OverlapDuration)
+	chunks := c.PlanChunks(*audioInfo, options)
+
+	ready := make(chan *ChunkInfo, len(chunks))
+	if err := c.CreateChunks(ctx, inputPath, chunks, options, ready); err != nil {
+		_ = c.CleanupChunks(chunks)
+		return nil, err
+	}
+	return chunks, nil
+}
 
-	// Create temporary directory for chunks
-	chunkDir := filepath.Join(c.tempDir, fmt.Sprintf("gollmscribe_chunks_%d", time.Now().Unix()))
-	if err := os.MkdirAll(chunkDir, 0o755); err != nil {
-		return nil, fmt.Errorf("failed to create chunk directory: %w", err)
+// CreateChunks writes the files of chunks planned for inputPath, in
+// order, and sends each chunk on ready as soon as it can be transcribed:
+// once its file is written, or it is found silent. This lets chunks be
+// transcribed while later ones are still being cut. It stops at the first
+// error or when ctx is done, killing a running ffmpeg, and closes ready
+// either way; files already written are left to the caller to clean up.
+//
+// With options.InMemory nothing is written to disk: ffmpeg's output is
+// piped into ChunkInfo.Data instead. An unbuffered ready then keeps at
+// most one chunk waiting in memory for its consumer.
+func (c *ChunkerImpl) CreateChunks(ctx context.Context, inputPath string, chunks []*ChunkInfo, options ProcessorOptions, ready chan<- *ChunkInfo) error {
+	defer close(ready)
+
+	// Create temporary directory for chunks, in the job's directory if
+	// the options give one
+	tempDir := c.tempDir
+	if options.TempDir != "" {
+		tempDir = options.TempDir
+	}
+	// Runs started at once, e.g. the channels of a file, get a directory
+	// each so their chunk files do not overwrite one another
+	var chunkDir string
+	if !options.InMemory {
+		if err := os.MkdirAll(tempDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create chunk directory: %w", err)
+		}
+		dir, err := os.MkdirTemp(tempDir, "gollmscribe_chunks_*")
+		if err != nil {
+			return fmt.Errorf("failed to create chunk directory: %w", err)
+		}
+		chunkDir = dir
+	}
+
+	// Without ffmpeg chunks are sliced from the source and keep its format
+	encoding := options.Encoding()
+	if err := encoding.Validate(); err != nil {
+		return err
+	}
+	chunkExt := encoding.Ext()
+	if !FFmpegAvailable() {
+		chunkExt = nativeChunkExt(inputPath)
+		if options.TrimSilence {
+			logger.WithComponent("audio-chunker").Warn().Msg("Silence trimming needs ffmpeg, skipping")
+			options.TrimSilence = false
+		}
+		if !options.Filters.IsZero() {
+			logger.WithComponent("audio-chunker").Warn().Msg("Audio filters need ffmpeg, skipping")
+		}
 	}
+	filters := options.Filters.Chain()
 
 	// Create each chunk
 	for i, chunk := range chunks {
-		chunkPath := filepath.Join(chunkDir, fmt.Sprintf("chunk_%03d.mp3", i))
-		chunk.TempFilePath = chunkPath
-		chunk.FilePath = inputPath
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		started := time.Now()
+		if options.TrimSilence {
+			if err := c.trimChunkSilence(ctx, inputPath, chunk, options); err != nil {
+				return fmt.Errorf("failed to detect silence in chunk %d: %w", i, err)
+			}
+		}
 
-		if err := c.CreateChunk(inputPath, chunk.Start, chunk.Duration, chunkPath); err != nil {
-			// Clean up on error
-			_ = c.CleanupChunks(chunks[:i])
-			return nil, fmt.Errorf("failed to create chunk %d: %w", i, err)
+		switch {
+		case chunk.Silent:
+			// Nothing to cut
+		case options.InMemory:
+			data, err := c.readChunk(ctx, inputPath, chunk.Start+chunk.TrimmedStart, chunk.AudioDuration(), encoding, filters)
+			if err != nil {
+				return fmt.Errorf("failed to create chunk %d: %w", i, err)
+			}
+			chunk.Data, chunk.Format = data, AudioFormat(strings.TrimPrefix(chunkExt, "."))
+		default:
+			chunkPath := filepath.Join(chunkDir, fmt.Sprintf("chunk_%03d%s", i, chunkExt))
+			if err := c.createChunk(ctx, inputPath, chunk.Start+chunk.TrimmedStart, chunk.AudioDuration(), chunkPath, encoding, filters); err != nil {
+				_ = os.Remove(chunkPath)
+				return fmt.Errorf("failed to create chunk %d: %w", i, err)
+			}
+			chunk.TempFilePath = chunkPath
+		}
+		chunk.EncodeTime = time.Since(started)
+
+		select {
+		case ready <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
+	return nil
+}
 
-	return chunks, nil
+// PlanChunks returns the chunks ChunkAudio would create for a file,
+// without creating any files. Silence trimming is not reflected, as it
+// needs the audio of each chunk.
+func (c *ChunkerImpl) PlanChunks(info AudioInfo, options ProcessorOptions) []*ChunkInfo {
+	chunkDuration := options.ChunkDuration
+	if chunkDuration <= 0 {
+		chunkDuration = DefaultChunkDuration
+	}
+	overlapDuration := options.OverlapDuration
+	if overlapDuration == 0 {
+		overlapDuration = DefaultOverlapDuration
+	}
+
+	chunks := c.CalculateChunks(info.Duration, chunkDuration, overlapDuration)
+	for i, chunk := range chunks {
+		chunk.FilePath = info.FilePath
+		if options.LeadingContext && i > 0 {
+			chunk.Context = chunks[i-1].End - chunk.Start
+		}
+	}
+	return chunks
 }
 
 // CreateChunk creates a single chunk from the audio file
+//
+// Deprecated: Use CreateChunkContext, which stops ffmpeg on cancellation.
 func (c *ChunkerImpl) CreateChunk(inputPath string, start, duration time.Duration, outputPath string) error {
+	return c.CreateChunkContext(context.Background(), inputPath, start, duration, outputPath)
+}
+
+// CreateChunkContext creates a single chunk from the audio file, killing
+// ffmpeg when ctx is done
+func (c *ChunkerImpl) CreateChunkContext(ctx context.Context, inputPath string, start, duration time.Duration, outputPath string) error {
+	return c.createChunk(ctx, inputPath, start, duration, outputPath, Encoding{}, "")
+}
+
+// createChunk creates a chunk written with encoding, passing it through
+// the ffmpeg audio filters, if any
+func (c *ChunkerImpl) createChunk(ctx context.Context, inputPath string, start, duration time.Duration, outputPath string, encoding Encoding, filters string) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	if !FFmpegAvailable() {
+		if err := nativeSlice(inputPath, start, duration, outputPath); err != nil {
+			return fmt.Errorf("chunk extraction failed: %w", err)
+		}
+		return nil
+	}
+
 	// Create ffmpeg command to extract the chunk
+	args := encoding.outputArgs()
+	if filters != "" {
+		args["af"] = filters
+	}
 	stream := ffmpeg.Input(inputPath, ffmpeg.KwArgs{
 		"ss": formatDuration(start),
 		"t":  formatDuration(duration),
-	}).Output(outputPath, ffmpeg.KwArgs{
-		"acodec": "libmp3lame",
-		"ab":     "192k",
-		"ar":     "44100",
-		"ac":     "2",
-	})
+	}).Output(outputPath, args)
 
 	// Execute the command
-	err := stream.OverWriteOutput().ErrorToStdOut().Run()
+	err := runFFmpeg(ctx, stream.OverWriteOutput().ErrorToStdOut())
 	if err != nil {
 		return fmt.Errorf("ffmpeg chunk extraction failed: %w", err)
 	}
@@ -85,11 +253,46 @@ func (c *ChunkerImpl) CreateChunk(inputPath string, start, duration time.Duratio
 	return nil
 }
 
-// CleanupChunks removes temporary chunk files
+// readChunk returns the audio createChunk would write, piped from
+// ffmpeg's output instead of a file
+func (c *ChunkerImpl) readChunk(ctx context.Context, inputPath string, start, duration time.Duration, encoding Encoding, filters string) ([]byte, error) {
+	var buf bytes.Buffer
+	if !FFmpegAvailable() {
+		if err := nativeSliceTo(inputPath, start, duration, &buf); err != nil {
+			return nil, fmt.Errorf("chunk extraction failed: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	// A pipe has no extension to pick the container by
+	encoding = encoding.withDefaults()
+	args := encoding.outputArgs()
+	args["f"] = string(encoding.Format)
+	if filters != "" {
+		args["af"] = filters
+	}
+	err := runFFmpeg(ctx, ffmpeg.Input(inputPath, ffmpeg.KwArgs{
+		"ss": formatDuration(start),
+		"t":  formatDuration(duration),
+	}).Output("pipe:1", args).WithOutput(&buf))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg chunk extraction failed: %w", err)
+	}
+
+	data := buf.Bytes()
+	if encoding.Format == FormatWAV {
+		setWAVSizes(data)
+	}
+	return data, nil
+}
+
+// CleanupChunks removes temporary chunk files and releases the audio of
+// in-memory chunks
 func (c *ChunkerImpl) CleanupChunks(chunks []*ChunkInfo) error {
 	var lastErr error
 
 	for _, chunk := range chunks {
+		chunk.Data = nil
 		if chunk.TempFilePath != "" {
 			if err := os.Remove(chunk.TempFilePath); err != nil && !os.IsNotExist(err) {
 				lastErr = err
@@ -97,10 +300,13 @@ func (c *ChunkerImpl) CleanupChunks(chunks []*ChunkInfo) error {
 		}
 	}
 
-	// Try to remove the chunk directory if it's empty
-	if len(chunks) > 0 && chunks[0].TempFilePath != "" {
-		chunkDir := filepath.Dir(chunks[0].TempFilePath)
-		_ = os.Remove(chunkDir) // Ignore error if directory is not empty
+	// Try to remove the chunk directory if it's empty; silent chunks have
+	// no file to find it by
+	for _, chunk := range chunks {
+		if chunk.TempFilePath != "" {
+			_ = os.Remove(filepath.Dir(chunk.TempFilePath)) // Ignore error if directory is not empty
+			break
+		}
 	}
 
 	return lastErr
@@ -165,9 +371,18 @@ func formatDuration(d time.Duration) string {
 }
 
 // GetChunkDuration calculates the actual duration of a chunk file
+//
+// Deprecated: Use GetChunkDurationContext, which stops ffprobe on
+// cancellation.
 func (c *ChunkerImpl) GetChunkDuration(chunkPath string) (time.Duration, error) {
+	return c.GetChunkDurationContext(context.Background(), chunkPath)
+}
+
+// GetChunkDurationContext calculates the actual duration of a chunk file,
+// killing ffprobe when ctx is done
+func (c *ChunkerImpl) GetChunkDurationContext(ctx context.Context, chunkPath string) (time.Duration, error) {
 	processor := NewProcessor(c.tempDir)
-	info, err := processor.GetAudioInfo(chunkPath)
+	info, err := processor.GetAudioInfoContext(ctx, chunkPath)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get chunk duration: %w", err)
 	}
@@ -177,6 +392,9 @@ func (c *ChunkerImpl) GetChunkDuration(chunkPath string) (time.Duration, error)
 // ValidateChunks validates that all chunks were created successfully
 func (c *ChunkerImpl) ValidateChunks(chunks []*ChunkInfo) error {
 	for i, chunk := range chunks {
+		if chunk.Silent || len(chunk.Data) > 0 {
+			continue
+		}
 		if chunk.TempFilePath == "" {
 			return fmt.Errorf("chunk %d has no temp file path", i)
 		}
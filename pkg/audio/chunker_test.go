@@ -118,6 +118,41 @@ func TestCalculateChunks(t *testing.T) {
 	}
 }
 
+func TestPlanChunks(t *testing.T) {
+	chunker := NewChunker("")
+	info := AudioInfo{FilePath: "talk.mp3", Duration: 70 * time.Minute}
+
+	// Unset durations fall back to the defaults
+	chunks := chunker.PlanChunks(info, ProcessorOptions{})
+	if len(chunks) != 3 {
+		t.Fatalf("PlanChunks() = %d chunks, want 3", len(chunks))
+	}
+	if chunks[1].Start != DefaultChunkDuration-DefaultOverlapDuration || chunks[1].Duration != DefaultChunkDuration {
+		t.Errorf("chunk 1 = %v+%v", chunks[1].Start, chunks[1].Duration)
+	}
+	for _, chunk := range chunks {
+		if chunk.FilePath != "talk.mp3" || chunk.TempFilePath != "" {
+			t.Errorf("chunk %d paths = %q, %q", chunk.Index, chunk.FilePath, chunk.TempFilePath)
+		}
+	}
+
+	chunks = chunker.PlanChunks(info, ProcessorOptions{ChunkDuration: 30 * time.Minute, OverlapDuration: 30 * time.Second})
+	if last := chunks[len(chunks)-1]; last.End != info.Duration {
+		t.Errorf("last chunk ends at %v, want %v", last.End, info.Duration)
+	}
+	if chunks[1].Context != 0 {
+		t.Errorf("chunk 1 context = %v without LeadingContext", chunks[1].Context)
+	}
+
+	chunks = chunker.PlanChunks(info, ProcessorOptions{ChunkDuration: 30 * time.Minute, OverlapDuration: 30 * time.Second, LeadingContext: true})
+	if chunks[0].Context != 0 || chunks[1].Context != 30*time.Second {
+		t.Errorf("contexts = %v, %v, want 0, 30s", chunks[0].Context, chunks[1].Context)
+	}
+	if got := chunks[1].ContentStart(); got != chunks[0].End {
+		t.Errorf("chunk 1 content starts at %v, want %v", got, chunks[0].End)
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -319,3 +354,18 @@ func BenchmarkFormatDuration(b *testing.B) {
 		formatDuration(duration)
 	}
 }
+
+func TestChunkKey(t *testing.T) {
+	const source = "0123456789abcdef0123456789abcdef"
+
+	key := ChunkKey(source, 15*time.Minute, 30*time.Minute)
+	if key != "0123456789abcdef-900000-1800000" {
+		t.Errorf("ChunkKey() = %q", key)
+	}
+	if key == ChunkKey(source, 15*time.Minute, 31*time.Minute) {
+		t.Error("ChunkKey() ignores the end")
+	}
+	if key == ChunkKey("fedcba9876543210", 15*time.Minute, 30*time.Minute) {
+		t.Error("ChunkKey() ignores the source")
+	}
+}
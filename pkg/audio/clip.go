@@ -0,0 +1,37 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// ExtractClip writes the span of inputPath to outputPath, keeping the video
+// of video files. The container and codecs follow the extension of
+// outputPath. It kills ffmpeg when ctx is done.
+func ExtractClip(ctx context.Context, inputPath, outputPath string, span Span) error {
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot cut clips from %s: %w", filepath.Base(inputPath), ErrFFmpegRequired)
+	}
+	if span.End <= span.Start {
+		return fmt.Errorf("clip ends at %v before it starts at %v", span.End, span.Start)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Seeking on the input is fast and, since the clip is re-encoded,
+	// still frame-accurate
+	err := runFFmpeg(ctx, ffmpeg.Input(inputPath, ffmpeg.KwArgs{
+		"ss": fmt.Sprintf("%.3f", span.Start.Seconds()),
+	}).Output(outputPath, ffmpeg.KwArgs{
+		"t": fmt.Sprintf("%.3f", (span.End - span.Start).Seconds()),
+	}).OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return fmt.Errorf("ffmpeg clip extraction failed: %w", err)
+	}
+	return nil
+}
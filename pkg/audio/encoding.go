@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"fmt"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// Encoding defaults, used for unset Encoding fields
+const (
+	DefaultSampleRate = 44100
+	DefaultChannels   = 2
+	defaultMP3Bitrate = "192k" // Used when no quality is set
+)
+
+// Encoding is how converted audio and chunks are written. Speech needs
+// far less than the defaults: 16 kHz mono shrinks uploads several times
+// over without hurting transcription.
+type Encoding struct {
+	Format     AudioFormat // mp3, wav or flac (default: mp3)
+	SampleRate int         // Hz (default: 44100)
+	Channels   int         // 1 for mono, 2 for stereo (default: 2)
+	Quality    int         // 1 (best) to 9 (smallest) for mp3, FLAC compression level; 0 keeps 192 kbps mp3
+}
+
+// withDefaults fills in unset fields
+func (e Encoding) withDefaults() Encoding {
+	if e.Format == "" {
+		e.Format = FormatMP3
+	}
+	if e.SampleRate <= 0 {
+		e.SampleRate = DefaultSampleRate
+	}
+	if e.Channels <= 0 {
+		e.Channels = DefaultChannels
+	}
+	return e
+}
+
+// Validate checks that the encoding can be written
+func (e Encoding) Validate() error {
+	e = e.withDefaults()
+	switch e.Format {
+	case FormatMP3, FormatWAV, FormatFLAC:
+	default:
+		return fmt.Errorf("unsupported output format %q: use mp3, wav or flac", e.Format)
+	}
+	if e.SampleRate < 8000 || e.SampleRate > 192000 {
+		return fmt.Errorf("sample rate %d Hz is out of range (8000-192000)", e.SampleRate)
+	}
+	if e.Channels > 2 {
+		return fmt.Errorf("%d channels: use 1 (mono) or 2 (stereo)", e.Channels)
+	}
+	if e.Quality < 0 || e.Quality > 9 {
+		return fmt.Errorf("quality %d is out of range (1-9)", e.Quality)
+	}
+	return nil
+}
+
+// Ext returns the file extension of the encoding, with the dot
+func (e Encoding) Ext() string {
+	return "." + string(e.withDefaults().Format)
+}
+
+// outputArgs returns the ffmpeg output arguments that write the encoding
+func (e Encoding) outputArgs() ffmpeg.KwArgs {
+	e = e.withDefaults()
+	args := ffmpeg.KwArgs{
+		"acodec": mediatype.Encoder(string(e.Format)),
+		"ar":     fmt.Sprint(e.SampleRate),
+		"ac":     fmt.Sprint(e.Channels),
+	}
+	switch e.Format {
+	case FormatMP3:
+		if e.Quality > 0 {
+			args["q:a"] = fmt.Sprint(e.Quality)
+		} else {
+			args["ab"] = defaultMP3Bitrate
+		}
+	case FormatFLAC:
+		if e.Quality > 0 {
+			args["compression_level"] = fmt.Sprint(e.Quality)
+		}
+	}
+	return args
+}
+
+// MaxSize returns an upper bound on the bytes of d of audio written with
+// the encoding, for reserving temp space before writing it
+func (e Encoding) MaxSize(d time.Duration) int64 {
+	e = e.withDefaults()
+	pcm := int64(e.SampleRate) * int64(e.Channels) * 2 // 16-bit samples
+	var bytesPerSecond int64
+	switch e.Format {
+	case FormatMP3:
+		bytesPerSecond = 320_000 / 8 // The highest mp3 bitrate
+	default:
+		// FLAC never grows beyond its PCM input
+		bytesPerSecond = pcm
+	}
+	return int64(d.Seconds()*float64(bytesPerSecond)) + 64<<10 // Headers and frame padding
+}
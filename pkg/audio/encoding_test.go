@@ -0,0 +1,46 @@
+package audio
+
+import "testing"
+
+func TestEncodingOutputArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+		want     map[string]string
+	}{
+		{"defaults", Encoding{}, map[string]string{"ar": "44100", "ac": "2", "ab": "192k"}},
+		{"speech", Encoding{SampleRate: 16000, Channels: 1, Quality: 5}, map[string]string{"ar": "16000", "ac": "1", "q:a": "5"}},
+		{"flac", Encoding{Format: FormatFLAC, Quality: 8}, map[string]string{"ar": "44100", "compression_level": "8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.encoding.outputArgs()
+			for key, want := range tt.want {
+				if got := args[key]; got != want {
+					t.Errorf("%s = %v, want %s", key, got, want)
+				}
+			}
+			if _, ok := args["ab"]; ok && tt.encoding.Quality > 0 {
+				t.Error("constant bitrate set along with a quality")
+			}
+		})
+	}
+}
+
+func TestEncodingValidate(t *testing.T) {
+	valid := []Encoding{{}, {Format: FormatWAV, SampleRate: 16000, Channels: 1}, {Quality: 9}}
+	for _, e := range valid {
+		if err := e.Validate(); err != nil {
+			t.Errorf("%+v.Validate() = %v", e, err)
+		}
+	}
+	invalid := []Encoding{{Format: "ogg"}, {SampleRate: 100}, {Channels: 6}, {Quality: 10}}
+	for _, e := range invalid {
+		if err := e.Validate(); err == nil {
+			t.Errorf("%+v.Validate() succeeded", e)
+		}
+	}
+	if ext := (Encoding{Format: FormatFLAC}).Ext(); ext != ".flac" {
+		t.Errorf("Ext() = %s, want .flac", ext)
+	}
+}
@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// streamContext carries the settings ffmpeg-go keeps as values of a
+// stream's context, such as where its output goes, with the cancellation
+// of another context
+type streamContext struct {
+	context.Context
+	settings context.Context
+}
+
+// Value returns a stream setting, or a value of the cancelling context
+func (c streamContext) Value(key any) any {
+	if value := c.settings.Value(key); value != nil {
+		return value
+	}
+	return c.Context.Value(key)
+}
+
+// runFFmpeg runs the ffmpeg command of an output stream and kills ffmpeg
+// when ctx is done, returning ctx's error in that case
+func runFFmpeg(ctx context.Context, stream *ffmpeg.Stream) error {
+	stream.Context = streamContext{Context: ctx, settings: stream.Context}
+	if err := stream.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// probe returns the format and streams of a file as ffprobe JSON, like
+// ffmpeg.Probe, and kills ffprobe when ctx is done
+func probe(ctx context.Context, path string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-show_format", "-show_streams", "-of", "json", path)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	for _, option := range ffmpeg.GlobalCommandOptions {
+		option(cmd)
+	}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("[%s] %w", stderr.String(), err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+func TestRunFFmpegCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output := filepath.Join(t.TempDir(), "out.wav")
+	stream := ffmpeg.Input("anullsrc", ffmpeg.KwArgs{"f": "lavfi", "t": "1"}).
+		Output(output).OverWriteOutput()
+	if err := runFFmpeg(ctx, stream); !errors.Is(err, context.Canceled) {
+		t.Errorf("runFFmpeg() = %v, want %v", err, context.Canceled)
+	}
+	if _, err := probe(ctx, output); !errors.Is(err, context.Canceled) {
+		t.Errorf("probe() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRunFFmpegStopsOnDeadline(t *testing.T) {
+	if !FFmpegAvailable() {
+		t.Skip("ffmpeg not installed")
+	}
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// An endless input only ends when ffmpeg is killed
+	stream := ffmpeg.Input("anullsrc=r=44100:cl=stereo", ffmpeg.KwArgs{"f": "lavfi"}).
+		Output(filepath.Join(dir, "endless.wav")).OverWriteOutput()
+	started := time.Now()
+	if err := runFFmpeg(ctx, stream); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("runFFmpeg() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("ffmpeg ran %s after the context was done", elapsed)
+	}
+}
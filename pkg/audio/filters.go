@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Loudness targeted by Filters.Loudnorm, EBU R128 as used for speech
+const (
+	loudnormTarget    = -16.0 // Integrated loudness, LUFS
+	loudnormTruePeak  = -1.5  // dBTP
+	loudnormLoudRange = 11.0  // LU
+)
+
+// Filters are preprocessing filters applied to each chunk before upload,
+// to help the model with quiet, noisy or band-limited recordings such as
+// phone calls. The zero value applies none.
+type Filters struct {
+	Highpass int     // Remove frequencies below this many Hz, e.g. 100 for rumble and hum
+	Lowpass  int     // Remove frequencies above this many Hz, e.g. 3400 for phone audio
+	Denoise  float64 // Reduce stationary noise by this many dB with afftdn, e.g. 12
+	Loudnorm bool    // Normalize loudness, so quiet speakers are not lost
+}
+
+// IsZero reports whether no filter is enabled
+func (f Filters) IsZero() bool {
+	return f == Filters{}
+}
+
+// Validate checks that the cutoffs leave a pass band
+func (f Filters) Validate() error {
+	if f.Highpass < 0 || f.Lowpass < 0 || f.Denoise < 0 {
+		return fmt.Errorf("audio filters cannot be negative")
+	}
+	if f.Highpass > 0 && f.Lowpass > 0 && f.Lowpass <= f.Highpass {
+		return fmt.Errorf("lowpass cutoff %d Hz must be above highpass cutoff %d Hz", f.Lowpass, f.Highpass)
+	}
+	return nil
+}
+
+// Chain returns the ffmpeg audio filter graph, or "" if no filter is
+// enabled. Band limits come first so the noise profile and loudness are
+// measured on the speech band, and loudness is normalized last.
+func (f Filters) Chain() string {
+	var filters []string
+	if f.Highpass > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", f.Highpass))
+	}
+	if f.Lowpass > 0 {
+		filters = append(filters, fmt.Sprintf("lowpass=f=%d", f.Lowpass))
+	}
+	if f.Denoise > 0 {
+		filters = append(filters, fmt.Sprintf("afftdn=nr=%g", f.Denoise))
+	}
+	if f.Loudnorm {
+		filters = append(filters, fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", loudnormTarget, loudnormTruePeak, loudnormLoudRange))
+	}
+	return strings.Join(filters, ",")
+}
+
+// Key derives a source hash for filtered audio, so its chunks are cached
+// and checkpointed apart from unfiltered chunks. Without filters the
+// hash is returned unchanged.
+func (f Filters) Key(sourceHash string) string {
+	chain := f.Chain()
+	if chain == "" {
+		return sourceHash
+	}
+	sum := sha256.Sum256([]byte(sourceHash + "|" + chain))
+	return hex.EncodeToString(sum[:])
+}
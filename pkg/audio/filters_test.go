@@ -0,0 +1,43 @@
+package audio
+
+import "testing"
+
+func TestFiltersChain(t *testing.T) {
+	if chain := (Filters{}).Chain(); chain != "" {
+		t.Errorf("zero Filters chain = %q, want none", chain)
+	}
+
+	phone := Filters{Highpass: 200, Lowpass: 3400, Denoise: 12, Loudnorm: true}
+	want := "highpass=f=200,lowpass=f=3400,afftdn=nr=12,loudnorm=I=-16:TP=-1.5:LRA=11"
+	if chain := phone.Chain(); chain != want {
+		t.Errorf("Chain() = %q, want %q", chain, want)
+	}
+}
+
+func TestFiltersValidate(t *testing.T) {
+	tests := []struct {
+		filters Filters
+		valid   bool
+	}{
+		{Filters{}, true},
+		{Filters{Highpass: 200, Lowpass: 3400}, true},
+		{Filters{Lowpass: 3400}, true},
+		{Filters{Highpass: 3400, Lowpass: 200}, false},
+		{Filters{Denoise: -6}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.filters.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid %v", tt.filters, err, tt.valid)
+		}
+	}
+}
+
+func TestFiltersKey(t *testing.T) {
+	if key := (Filters{}).Key("abc"); key != "abc" {
+		t.Errorf("unfiltered Key() = %q, want the source hash", key)
+	}
+	loud := Filters{Loudnorm: true}.Key("abc")
+	if loud == "abc" || loud == (Filters{Denoise: 12}).Key("abc") {
+		t.Errorf("Key() = %q does not depend on the filters", loud)
+	}
+}
@@ -0,0 +1,206 @@
+package audio
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// FFmpegVersion is the static ffmpeg build downloaded by InstallFFmpeg
+const FFmpegVersion = "6.1"
+
+// ffmpegReleaseURL is the ffbinaries release holding the pinned builds.
+// Tests point it at a local server.
+var ffmpegReleaseURL = "https://github.com/ffbinaries/ffbinaries-prebuilt/releases/download/v" + FFmpegVersion
+
+// ffmpegPlatforms maps GOOS/GOARCH to the ffbinaries platform name
+var ffmpegPlatforms = map[string]string{
+	"linux/amd64":   "linux-64",
+	"linux/386":     "linux-32",
+	"linux/arm64":   "linux-arm-64",
+	"darwin/amd64":  "macos-64",
+	"darwin/arm64":  "macos-64", // x86-64 build, needs Rosetta 2
+	"windows/amd64": "win-64",
+}
+
+// ffmpegChecksums pins the SHA-256 of every ffbinaries archive, by archive
+// name. An archive without a checksum here is never installed, so a
+// platform added to ffmpegPlatforms needs its checksums added as well.
+// Empty entries are not pinned yet and refuse to install. Tests replace
+// the table.
+var ffmpegChecksums = map[string]string{
+	"ffmpeg-" + FFmpegVersion + "-linux-64.zip":      "",
+	"ffprobe-" + FFmpegVersion + "-linux-64.zip":     "",
+	"ffmpeg-" + FFmpegVersion + "-linux-32.zip":      "",
+	"ffprobe-" + FFmpegVersion + "-linux-32.zip":     "",
+	"ffmpeg-" + FFmpegVersion + "-linux-arm-64.zip":  "",
+	"ffprobe-" + FFmpegVersion + "-linux-arm-64.zip": "",
+	"ffmpeg-" + FFmpegVersion + "-macos-64.zip":      "",
+	"ffprobe-" + FFmpegVersion + "-macos-64.zip":     "",
+	"ffmpeg-" + FFmpegVersion + "-win-64.zip":        "",
+	"ffprobe-" + FFmpegVersion + "-win-64.zip":       "",
+}
+
+// ffmpegTools are the binaries installed and looked up
+var ffmpegTools = []string{"ffmpeg", "ffprobe"}
+
+// DefaultFFmpegDir returns where InstallFFmpeg puts ffmpeg by default,
+// below the user config directory
+func DefaultFFmpegDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gollmscribe", "ffmpeg"), nil
+}
+
+// InstallFFmpeg downloads the pinned static ffmpeg and ffprobe builds for
+// the current OS and architecture into dir and returns their paths.
+// Archives whose SHA-256 does not match the pinned checksum are rejected.
+// There is no arm64 build for macOS, so Apple silicon gets the x86-64
+// build, which needs Rosetta 2. Call UseFFmpegDir to use them.
+func InstallFFmpeg(ctx context.Context, dir string) ([]string, error) {
+	platform, ok := ffmpegPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		return nil, fmt.Errorf("no static ffmpeg build for %s/%s; install ffmpeg with your package manager", runtime.GOOS, runtime.GOARCH)
+	}
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		logger.WithComponent("ffmpeg-installer").Warn().
+			Msg("Installing the x86-64 macOS build, which needs Rosetta 2 (softwareupdate --install-rosetta)")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create ffmpeg directory: %w", err)
+	}
+
+	paths := make([]string, 0, len(ffmpegTools))
+	for _, tool := range ffmpegTools {
+		name := fmt.Sprintf("%s-%s-%s.zip", tool, FFmpegVersion, platform)
+		path := filepath.Join(dir, executableName(tool))
+		if err := installTool(ctx, ffmpegReleaseURL+"/"+name, ffmpegChecksums[name], tool, path); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", tool, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// installTool downloads a zip archive, checks it against its SHA-256 and
+// extracts the tool binary from it
+func installTool(ctx context.Context, url, checksum, tool, path string) error {
+	log := logger.WithComponent("ffmpeg-installer").WithField("tool", tool)
+	if checksum == "" {
+		return fmt.Errorf("no pinned checksum for %s; install ffmpeg with your package manager", filepath.Base(url))
+	}
+	log.Info().Str("url", url).Msg("Downloading static build")
+
+	// Zip archives need random access, so the download is spooled to disk
+	archive, err := os.CreateTemp(filepath.Dir(path), tool+"-*.zip")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = archive.Close()
+		_ = os.Remove(archive.Name())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(archive, hash), resp.Body)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, checksum) {
+		return fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", filepath.Base(url), sum, checksum)
+	}
+
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	for _, file := range zr.File {
+		if filepath.Base(file.Name) != executableName(tool) {
+			continue
+		}
+		// The zip reader checks the CRC of the entry as it is read
+		if err := extractFile(file, path); err != nil {
+			return err
+		}
+		log.Info().Str("path", path).Msg("Installed static build")
+		return nil
+	}
+	return fmt.Errorf("%s not found in archive", executableName(tool))
+}
+
+// extractFile writes a zip entry to path as an executable, atomically
+func extractFile(file *zip.File, path string) error {
+	in, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// UseFFmpegDir puts ffmpeg and ffprobe installed in dir on PATH, ahead of
+// any system copies, and reports whether both were found. It must be
+// called before audio is first processed, when FFmpegAvailable is fixed.
+func UseFFmpegDir(dir string) bool {
+	for _, tool := range ffmpegTools {
+		if info, err := os.Stat(filepath.Join(dir, executableName(tool))); err != nil || info.IsDir() {
+			return false
+		}
+	}
+
+	path := os.Getenv("PATH")
+	for _, entry := range filepath.SplitList(path) {
+		if entry == dir {
+			return true
+		}
+	}
+	_ = os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return true
+}
+
+// executableName adds the platform's executable suffix to a tool name
+func executableName(tool string) string {
+	if runtime.GOOS == "windows" && !strings.HasSuffix(tool, ".exe") {
+		return tool + ".exe"
+	}
+	return tool
+}
@@ -0,0 +1,134 @@
+package audio
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeArchive returns a zip holding a stand-in for tool
+func fakeArchive(tool string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create(executableName(tool))
+	_, _ = f.Write([]byte("#!/bin/sh\necho " + tool + "\n"))
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// serveFakeArchives points the installer at a server of fake archives,
+// pinning checksums returns for each archive name, and returns the
+// requested paths
+func serveFakeArchives(t *testing.T, checksums func(name string, archive []byte) string) *[]string {
+	t.Helper()
+	platform, ok := ffmpegPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	if !ok {
+		t.Skip("no static build for this platform")
+	}
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		tool := strings.SplitN(filepath.Base(r.URL.Path), "-", 2)[0]
+		_, _ = w.Write(fakeArchive(tool))
+	}))
+	t.Cleanup(server.Close)
+
+	originalURL, originalChecksums := ffmpegReleaseURL, ffmpegChecksums
+	ffmpegReleaseURL = server.URL + "/v" + FFmpegVersion
+	ffmpegChecksums = make(map[string]string)
+	for _, tool := range ffmpegTools {
+		name := tool + "-" + FFmpegVersion + "-" + platform + ".zip"
+		ffmpegChecksums[name] = checksums(name, fakeArchive(tool))
+	}
+	t.Cleanup(func() { ffmpegReleaseURL, ffmpegChecksums = originalURL, originalChecksums })
+	return &requested
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInstallFFmpeg(t *testing.T) {
+	platform := ffmpegPlatforms[runtime.GOOS+"/"+runtime.GOARCH]
+	requested := serveFakeArchives(t, func(_ string, archive []byte) string { return sha256Hex(archive) })
+
+	dir := filepath.Join(t.TempDir(), "ffmpeg")
+	paths, err := InstallFFmpeg(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("InstallFFmpeg() error = %v", err)
+	}
+
+	if len(*requested) != 2 || (*requested)[0] != "/v"+FFmpegVersion+"/ffmpeg-"+FFmpegVersion+"-"+platform+".zip" {
+		t.Errorf("requested %v", *requested)
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("installed binary missing: %v", err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("%s is not executable: %v", path, info.Mode())
+		}
+	}
+
+	t.Setenv("PATH", "/usr/bin")
+	if !UseFFmpegDir(dir) {
+		t.Fatal("UseFFmpegDir() = false after install")
+	}
+	if got := os.Getenv("PATH"); !strings.HasPrefix(got, dir+string(os.PathListSeparator)) {
+		t.Errorf("PATH = %q, want %s first", got, dir)
+	}
+	UseFFmpegDir(dir)
+	if strings.Count(os.Getenv("PATH"), dir) != 1 {
+		t.Errorf("PATH = %q, dir added twice", os.Getenv("PATH"))
+	}
+
+	if UseFFmpegDir(t.TempDir()) {
+		t.Error("UseFFmpegDir() = true for an empty directory")
+	}
+}
+
+func TestInstallFFmpegRejectsUnverifiedArchives(t *testing.T) {
+	tests := []struct {
+		name      string
+		checksums func(name string, archive []byte) string
+		want      string
+	}{
+		{
+			name:      "mismatch",
+			checksums: func(_ string, archive []byte) string { return sha256Hex(append(archive, 0)) },
+			want:      "checksum mismatch",
+		},
+		{
+			name:      "missing",
+			checksums: func(string, []byte) string { return "" },
+			want:      "no pinned checksum",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveFakeArchives(t, tt.checksums)
+
+			dir := t.TempDir()
+			_, err := InstallFFmpeg(context.Background(), dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("InstallFFmpeg() error = %v, want %q", err, tt.want)
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Errorf("left %d files behind, e.g. %s", len(entries), entries[0].Name())
+			}
+		})
+	}
+}
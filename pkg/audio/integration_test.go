@@ -172,6 +172,9 @@ func TestVideoToAudioConversion(t *testing.T) {
 	if _, err := os.Stat(testFile); os.IsNotExist(err) {
 		t.Skip("Skipping integration test: testdata/video.mp4 not found")
 	}
+	if !FFmpegAvailable() {
+		t.Skip("Skipping integration test: video conversion needs ffmpeg")
+	}
 
 	// Create temporary directory for test
 	testDir, err := os.MkdirTemp("", "video_conversion_test")
@@ -1,7 +1,10 @@
 package audio
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"path/filepath"
 	"time"
 )
 
@@ -14,6 +17,13 @@ const (
 	FormatM4A  AudioFormat = "m4a"
 	FormatFLAC AudioFormat = "flac"
 	FormatMP4  AudioFormat = "mp4"
+	FormatOGG  AudioFormat = "ogg"
+	FormatOpus AudioFormat = "opus"
+	FormatWebM AudioFormat = "webm"
+	FormatAAC  AudioFormat = "aac"
+	FormatWMA  AudioFormat = "wma"
+	FormatAMR  AudioFormat = "amr"
+	Format3GP  AudioFormat = "3gp"
 )
 
 // AudioInfo contains metadata about an audio file
@@ -32,45 +42,162 @@ type AudioInfo struct {
 // ChunkInfo represents information about an audio chunk
 type ChunkInfo struct {
 	Index        int
+	Key          string // Stable identifier, see ChunkKey
 	Start        time.Duration
 	End          time.Duration
 	Duration     time.Duration
 	FilePath     string
 	TempFilePath string
+
+	// Silence cut from the ends of the chunk file when trimming is on;
+	// the file covers Start+TrimmedStart to End-TrimmedEnd
+	TrimmedStart time.Duration
+	TrimmedEnd   time.Duration
+	Silent       bool // The whole chunk is silence; no file is created
+
+	// Leading audio repeated from the previous chunk as context only;
+	// it is sent with the chunk but not transcribed
+	Context time.Duration
+
+	// Audio of the chunk, in Format, when chunks are kept in memory (see
+	// ProcessorOptions.InMemory); TempFilePath is empty then
+	Data   []byte
+	Format AudioFormat
+
+	// How long detecting silence in and cutting the chunk took
+	EncodeTime time.Duration
 }
 
+// Name returns the file name of the chunk audio, which in-memory chunks
+// only have nominally
+func (c *ChunkInfo) Name() string {
+	if c.TempFilePath != "" {
+		return filepath.Base(c.TempFilePath)
+	}
+	return fmt.Sprintf("chunk_%03d.%s", c.Index, c.Format)
+}
+
+// AudioDuration returns the length of audio actually in the chunk file
+func (c *ChunkInfo) AudioDuration() time.Duration {
+	if c.Silent {
+		return 0
+	}
+	return c.Duration - c.TrimmedStart - c.TrimmedEnd
+}
+
+// ContentStart returns where the audio transcribed for the chunk starts
+// in the source, after its leading context and any trimmed silence
+func (c *ChunkInfo) ContentStart() time.Duration {
+	if c.Context > c.TrimmedStart {
+		return c.Start + c.Context
+	}
+	return c.Start + c.TrimmedStart
+}
+
+// Chunking defaults
+const (
+	DefaultChunkDuration   = 30 * time.Minute
+	DefaultOverlapDuration = time.Minute
+)
+
 // ProcessorOptions provides configuration for audio processing
 type ProcessorOptions struct {
-	ChunkDuration   time.Duration // Default: 30 minutes
-	OverlapDuration time.Duration // Default: 1 minute
-	OutputFormat    AudioFormat   // Target format for conversion
-	SampleRate      int           // Target sample rate
-	Quality         int           // Compression quality (1-9)
+	ChunkDuration   time.Duration // Default: DefaultChunkDuration
+	OverlapDuration time.Duration // Default: DefaultOverlapDuration
+	OutputFormat    AudioFormat   // Format of the chunks (default: mp3)
+	SampleRate      int           // Sample rate of the chunks (default: 44100)
+	Channels        int           // Channels of the chunks (default: 2)
+	Quality         int           // Compression quality (1-9), see Encoding
 	TempDir         string        // Temporary directory for processing
 	KeepTemp        bool          // Keep temporary files after processing
+
+	// Silence trimming of chunk ends
+	TrimSilence      bool          // Cut long leading/trailing silence from each chunk
+	SilenceThreshold int           // Level in dB counted as silence (default: -50)
+	MinSilence       time.Duration // Shortest silence that is cut (default: 2s)
+
+	// Use the overlap as context-only audio at the start of each chunk
+	// (see ChunkInfo.Context) instead of transcribing it twice
+	LeadingContext bool
+
+	// Preprocessing filters applied to each chunk
+	Filters Filters
+
+	// Keep the audio of each chunk in memory (ChunkInfo.Data) instead of
+	// writing chunk files to TempDir, for small or read-only scratch space
+	InMemory bool
 }
 
-// Processor handles audio file processing and conversion
+// Encoding returns how the chunks are written
+func (o ProcessorOptions) Encoding() Encoding {
+	return Encoding{
+		Format:     o.OutputFormat,
+		SampleRate: o.SampleRate,
+		Channels:   o.Channels,
+		Quality:    o.Quality,
+	}
+}
+
+// Processor handles audio file processing and conversion. Methods taking
+// a context kill ffmpeg and ffprobe when it is done.
 type Processor interface {
+	// GetAudioInfoContext extracts metadata from an audio/video file
+	GetAudioInfoContext(ctx context.Context, filePath string) (*AudioInfo, error)
+
+	// ConvertToAudioContext converts video files (MP4) to audio format
+	ConvertToAudioContext(ctx context.Context, inputPath, outputPath string, format AudioFormat) error
+
+	// ConvertWithEncoding converts a file to audio written with encoding
+	ConvertWithEncoding(ctx context.Context, inputPath, outputPath string, encoding Encoding) error
+
+	// ValidateFileContext validates the audio file
+	ValidateFileContext(ctx context.Context, filePath string) error
+
+	// IsSupported checks if the file format is supported
+	IsSupported(filePath string) bool
+
 	// GetAudioInfo extracts metadata from an audio/video file
+	//
+	// Deprecated: Use GetAudioInfoContext.
 	GetAudioInfo(filePath string) (*AudioInfo, error)
 
 	// ConvertToAudio converts video files (MP4) to audio format
+	//
+	// Deprecated: Use ConvertToAudioContext.
 	ConvertToAudio(inputPath, outputPath string, format AudioFormat) error
 
-	// IsSupported checks if the file format is supported
-	IsSupported(filePath string) bool
-
 	// ValidateFile validates the audio file
+	//
+	// Deprecated: Use ValidateFileContext.
 	ValidateFile(filePath string) error
 }
 
-// Chunker handles splitting audio files into overlapping chunks
+// Chunker handles splitting audio files into overlapping chunks. Methods
+// taking a context kill ffmpeg when it is done.
 type Chunker interface {
+	// ChunkAudioContext splits an audio file into overlapping chunks
+	ChunkAudioContext(ctx context.Context, inputPath string, options ProcessorOptions) ([]*ChunkInfo, error)
+
 	// ChunkAudio splits an audio file into overlapping chunks
+	//
+	// Deprecated: Use ChunkAudioContext.
 	ChunkAudio(inputPath string, options ProcessorOptions) ([]*ChunkInfo, error)
 
+	// CreateChunks writes the files of planned chunks in order, or reads
+	// their audio into memory, sending each on ready once it can be
+	// transcribed, and closes ready
+	CreateChunks(ctx context.Context, inputPath string, chunks []*ChunkInfo, options ProcessorOptions, ready chan<- *ChunkInfo) error
+
+	// PlanChunks returns the chunks ChunkAudio would create for a file,
+	// without creating any files
+	PlanChunks(info AudioInfo, options ProcessorOptions) []*ChunkInfo
+
+	// CreateChunkContext creates a single chunk from the audio file
+	CreateChunkContext(ctx context.Context, inputPath string, start, duration time.Duration, outputPath string) error
+
 	// CreateChunk creates a single chunk from the audio file
+	//
+	// Deprecated: Use CreateChunkContext.
 	CreateChunk(inputPath string, start, duration time.Duration, outputPath string) error
 
 	// CleanupChunks removes temporary chunk files
@@ -85,7 +212,13 @@ type Reader interface {
 	// OpenAudio opens an audio file for reading
 	OpenAudio(filePath string) (io.ReadCloser, error)
 
+	// ReadChunkContext reads a specific chunk of audio data; ffmpeg is
+	// killed when ctx is done
+	ReadChunkContext(ctx context.Context, filePath string, start, duration time.Duration) (io.ReadCloser, error)
+
 	// ReadChunk reads a specific chunk of audio data
+	//
+	// Deprecated: Use ReadChunkContext.
 	ReadChunk(filePath string, start, duration time.Duration) (io.ReadCloser, error)
 
 	// GetMimeType returns the MIME type for the audio format
@@ -0,0 +1,458 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// ErrFFmpegRequired is returned for work the pure-Go fallback cannot do
+var ErrFFmpegRequired = errors.New("ffmpeg not found; without it only WAV and MP3 files can be transcribed")
+
+// ffmpegAvailable reports whether ffmpeg and ffprobe are on PATH. Tests
+// replace it to exercise the pure-Go path.
+var ffmpegAvailable = sync.OnceValue(func() bool {
+	_, ffmpegErr := exec.LookPath("ffmpeg")
+	_, ffprobeErr := exec.LookPath("ffprobe")
+	if ffmpegErr != nil || ffprobeErr != nil {
+		logger.WithComponent("audio").Warn().Msg("ffmpeg/ffprobe not found, using the pure-Go WAV/MP3 fallback")
+		return false
+	}
+	return true
+})
+
+// FFmpegAvailable reports whether ffmpeg is used for probing, conversion
+// and chunking. Without it a pure-Go path handles WAV and MP3: it reads
+// their duration and slices them without re-encoding, but cannot convert
+// video, other formats or detect silence.
+func FFmpegAvailable() bool {
+	return ffmpegAvailable()
+}
+
+// nativeAudioInfo reads the metadata of a WAV or MP3 file without ffprobe
+func nativeAudioInfo(filePath string) (*AudioInfo, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AudioInfo{FilePath: filePath, Size: stat.Size()}
+	switch DetectFormat(filePath) {
+	case FormatWAV:
+		wav, err := readWAV(filePath)
+		if err != nil {
+			return nil, err
+		}
+		info.Format = FormatWAV
+		info.Duration = wav.duration()
+		info.SampleRate = wav.sampleRate
+		info.Channels = wav.channels
+		info.BitRate = wav.byteRate * 8
+	case FormatMP3:
+		mp3, err := scanMP3(filePath)
+		if err != nil {
+			return nil, err
+		}
+		info.Format = FormatMP3
+		info.Duration = mp3.duration()
+		info.SampleRate = mp3.sampleRate
+		info.Channels = mp3.channels
+		if info.Duration > 0 {
+			info.BitRate = int(float64(mp3.audioBytes()*8) / info.Duration.Seconds())
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFFmpegRequired, filepath.Ext(filePath))
+	}
+	info.MimeType = GetMimeType(info.Format)
+	return info, nil
+}
+
+// nativeChunkExt returns the chunk file extension used by the pure-Go
+// path, which keeps the source format
+func nativeChunkExt(inputPath string) string {
+	return strings.ToLower(filepath.Ext(inputPath))
+}
+
+// nativeSlice copies the audio between start and start+duration of a WAV
+// or MP3 file to outputPath without re-encoding
+func nativeSlice(inputPath string, start, duration time.Duration, outputPath string) error {
+	slice, err := nativeSlicer(inputPath, start, duration)
+	if err != nil {
+		return err
+	}
+	return writeFile(outputPath, slice)
+}
+
+// nativeSliceTo writes the audio between start and start+duration of a
+// WAV or MP3 file to out without re-encoding
+func nativeSliceTo(inputPath string, start, duration time.Duration, out io.Writer) error {
+	slice, err := nativeSlicer(inputPath, start, duration)
+	if err != nil {
+		return err
+	}
+	return slice(out)
+}
+
+// nativeSlicer reads the layout of a WAV or MP3 file and returns what
+// writes the audio between start and start+duration
+func nativeSlicer(inputPath string, start, duration time.Duration) (func(io.Writer) error, error) {
+	switch DetectFormat(inputPath) {
+	case FormatWAV:
+		wav, err := readWAV(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		return func(out io.Writer) error { return wav.slice(inputPath, start, duration, out) }, nil
+	case FormatMP3:
+		mp3, err := scanMP3(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		return func(out io.Writer) error { return mp3.slice(inputPath, start, duration, out) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFFmpegRequired, filepath.Ext(inputPath))
+	}
+}
+
+// wavFile describes the layout of a PCM WAV file
+type wavFile struct {
+	format     []byte // Raw "fmt " chunk body
+	channels   int
+	sampleRate int
+	byteRate   int
+	blockAlign int
+	dataOffset int64
+	dataSize   int64
+}
+
+// readWAV parses the RIFF chunks of a WAV file
+func readWAV(filePath string) (*wavFile, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var header [12]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file: %s", filepath.Base(filePath))
+	}
+
+	wav := &wavFile{}
+	offset := int64(12)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(f, chunk[:]); err != nil {
+			return nil, fmt.Errorf("invalid WAV file, no data chunk: %s", filepath.Base(filePath))
+		}
+		id, size := string(chunk[0:4]), int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV format chunk")
+			}
+			wav.format = make([]byte, size)
+			if _, err := io.ReadFull(f, wav.format); err != nil {
+				return nil, fmt.Errorf("invalid WAV format chunk: %w", err)
+			}
+			if _, err := f.Seek(size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			wav.channels = int(binary.LittleEndian.Uint16(wav.format[2:4]))
+			wav.sampleRate = int(binary.LittleEndian.Uint32(wav.format[4:8]))
+			wav.byteRate = int(binary.LittleEndian.Uint32(wav.format[8:12]))
+			wav.blockAlign = int(binary.LittleEndian.Uint16(wav.format[12:14]))
+		case "data":
+			if wav.format == nil || wav.byteRate <= 0 || wav.blockAlign <= 0 {
+				return nil, fmt.Errorf("invalid WAV file, data before format")
+			}
+			// Streamed WAVs leave the size unset; use what is on disk
+			wav.dataOffset = offset
+			wav.dataSize = size
+			if remaining := stat.Size() - offset; size == 0xFFFFFFFF || size > remaining {
+				wav.dataSize = remaining
+			}
+			return wav, nil
+		default:
+			if _, err := f.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		offset += size + size%2
+	}
+}
+
+// duration returns the length of the audio data
+func (w *wavFile) duration() time.Duration {
+	return time.Duration(float64(w.dataSize) / float64(w.byteRate) * float64(time.Second))
+}
+
+// setWAVSizes fills in the RIFF and data chunk sizes of a whole WAV file
+// in memory, which ffmpeg leaves unset when writing to a pipe it cannot
+// seek back in
+func setWAVSizes(data []byte) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" {
+		return
+	}
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+	for offset := 12; offset+8 <= len(data); {
+		id, size := string(data[offset:offset+4]), int(binary.LittleEndian.Uint32(data[offset+4:offset+8]))
+		if id == "data" {
+			binary.LittleEndian.PutUint32(data[offset+4:offset+8], uint32(len(data)-offset-8))
+			return
+		}
+		offset += 8 + size + size%2
+	}
+}
+
+// slice writes the samples between start and start+duration as a new WAV
+func (w *wavFile) slice(inputPath string, start, duration time.Duration, out io.Writer) error {
+	align := func(d time.Duration) int64 {
+		bytes := int64(d.Seconds() * float64(w.byteRate))
+		return bytes - bytes%int64(w.blockAlign)
+	}
+	from := align(start)
+	if from > w.dataSize {
+		from = w.dataSize
+	}
+	length := align(duration)
+	if from+length > w.dataSize {
+		length = w.dataSize - from
+	}
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	header := new(bytes.Buffer)
+	header.WriteString("RIFF")
+	_ = binary.Write(header, binary.LittleEndian, uint32(4+8+len(w.format)+8+int(length)))
+	header.WriteString("WAVEfmt ")
+	_ = binary.Write(header, binary.LittleEndian, uint32(len(w.format)))
+	header.Write(w.format)
+	header.WriteString("data")
+	_ = binary.Write(header, binary.LittleEndian, uint32(length))
+	if _, err := out.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err = io.Copy(out, io.NewSectionReader(in, w.dataOffset+from, length))
+	return err
+}
+
+// mp3Frame is the position of one MPEG audio frame
+type mp3Frame struct {
+	offset int64
+	size   int64
+}
+
+// mp3File lists the audio frames of an MP3 file
+type mp3File struct {
+	frames          []mp3Frame
+	sampleRate      int
+	channels        int
+	samplesPerFrame int
+}
+
+// MPEG audio Layer III tables
+var (
+	mp3BitRatesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitRatesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3SampleRate = [3]int{44100, 48000, 32000}
+)
+
+// mp3Header is a decoded frame header
+type mp3Header struct {
+	size            int64
+	sampleRate      int
+	channels        int
+	samplesPerFrame int
+}
+
+// parseMP3Header decodes a Layer III frame header, reporting false for
+// anything else
+func parseMP3Header(h []byte) (mp3Header, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Header{}, false
+	}
+	version := (h[1] >> 3) & 3 // 0: MPEG 2.5, 2: MPEG 2, 3: MPEG 1
+	layer := (h[1] >> 1) & 3   // 1: Layer III
+	bitRateIndex := h[2] >> 4
+	sampleRateIndex := (h[2] >> 2) & 3
+	padding := int64((h[2] >> 1) & 1)
+	if version == 1 || layer != 1 || sampleRateIndex == 3 {
+		return mp3Header{}, false
+	}
+
+	header := mp3Header{channels: 2, samplesPerFrame: 1152}
+	if h[3]>>6 == 3 {
+		header.channels = 1
+	}
+	bitRate := mp3BitRatesV1[bitRateIndex]
+	header.sampleRate = mp3SampleRate[sampleRateIndex]
+	coefficient := int64(144)
+	if version != 3 {
+		bitRate = mp3BitRatesV2[bitRateIndex]
+		header.sampleRate /= 2
+		if version == 0 {
+			header.sampleRate /= 2
+		}
+		header.samplesPerFrame = 576
+		coefficient = 72
+	}
+	if bitRate == 0 {
+		return mp3Header{}, false
+	}
+
+	header.size = coefficient*int64(bitRate)*1000/int64(header.sampleRate) + padding
+	return header, true
+}
+
+// scanMP3 walks the frame headers of an MP3 file, skipping ID3 tags and
+// the Xing/Info frame, without decoding any audio
+func scanMP3(filePath string) (*mp3File, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	offset := int64(0)
+
+	// Skip an ID3v2 tag
+	if tag, err := r.Peek(10); err == nil && string(tag[0:3]) == "ID3" {
+		size := int64(tag[6])<<21 | int64(tag[7])<<14 | int64(tag[8])<<7 | int64(tag[9])
+		size += 10
+		if tag[5]&0x10 != 0 {
+			size += 10
+		}
+		if _, err := r.Discard(int(size)); err != nil {
+			return nil, fmt.Errorf("truncated ID3 tag: %w", err)
+		}
+		offset += size
+	}
+
+	mp3 := &mp3File{}
+	for {
+		h, err := r.Peek(4)
+		if err != nil || string(h[0:3]) == "TAG" {
+			break
+		}
+		header, ok := parseMP3Header(h)
+		if !ok {
+			// Not a frame boundary; resynchronise byte by byte
+			_, _ = r.Discard(1)
+			offset++
+			continue
+		}
+
+		frame, err := r.Peek(int(header.size))
+		if err != nil {
+			break // Truncated last frame
+		}
+		isInfoFrame := len(mp3.frames) == 0 && mp3.sampleRate == 0 &&
+			(bytes.Contains(frame, []byte("Xing")) || bytes.Contains(frame, []byte("Info")))
+		if !isInfoFrame {
+			mp3.frames = append(mp3.frames, mp3Frame{offset: offset, size: header.size})
+		}
+		mp3.sampleRate = header.sampleRate
+		mp3.channels = header.channels
+		mp3.samplesPerFrame = header.samplesPerFrame
+
+		_, _ = r.Discard(int(header.size))
+		offset += header.size
+	}
+
+	if len(mp3.frames) == 0 {
+		return nil, fmt.Errorf("no MPEG audio frames found in %s", filepath.Base(filePath))
+	}
+	return mp3, nil
+}
+
+// frameDuration returns the length of one frame
+func (m *mp3File) frameDuration() time.Duration {
+	return time.Duration(m.samplesPerFrame) * time.Second / time.Duration(m.sampleRate)
+}
+
+// duration returns the length of all frames
+func (m *mp3File) duration() time.Duration {
+	return time.Duration(len(m.frames)) * m.frameDuration()
+}
+
+// audioBytes returns the size of all frames
+func (m *mp3File) audioBytes() int64 {
+	var total int64
+	for _, frame := range m.frames {
+		total += frame.size
+	}
+	return total
+}
+
+// slice copies the frames between start and start+duration. Cuts fall on
+// frame boundaries, so a slice may start with a few milliseconds of noise
+// from the bit reservoir, which decoders tolerate.
+func (m *mp3File) slice(inputPath string, start, duration time.Duration, out io.Writer) error {
+	frameDuration := m.frameDuration()
+	first := int(start / frameDuration)
+	last := int((start + duration + frameDuration - 1) / frameDuration)
+	if first > len(m.frames) {
+		first = len(m.frames)
+	}
+	if last > len(m.frames) {
+		last = len(m.frames)
+	}
+
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	for _, frame := range m.frames[first:last] {
+		if _, err := io.Copy(out, io.NewSectionReader(in, frame.offset, frame.size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile creates outputPath and fills it with write
+func writeFile(outputPath string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	if err := write(w); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
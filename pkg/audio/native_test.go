@@ -0,0 +1,353 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestWAV writes seconds of 8kHz 16-bit mono PCM with an odd-sized
+// LIST chunk before the data
+func writeTestWAV(t *testing.T, path string, seconds int) {
+	t.Helper()
+	data := make([]byte, 16000*seconds)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	list := []byte("INFOx")
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+24+8+len(list)+1+8+len(data)))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("LIST")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(list)))
+	buf.Write(list)
+	buf.WriteByte(0) // Pad byte
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testMP3Frame is a 128kbps 44.1kHz MPEG-1 Layer III stereo frame
+func testMP3Frame(payload string) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	copy(frame[36:], payload)
+	return frame
+}
+
+// writeTestMP3 writes an ID3v2 tag, a Xing frame, frames audio frames and
+// an ID3v1 tag
+func writeTestMP3(t *testing.T, path string, frames int) {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.Write([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 20})
+	buf.Write(make([]byte, 20))
+	buf.Write(testMP3Frame("Xing"))
+	for i := 0; i < frames; i++ {
+		buf.Write(testMP3Frame(""))
+	}
+	buf.WriteString("TAG" + strings.Repeat(" ", 125))
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNativeWAV(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.wav")
+	writeTestWAV(t, input, 2)
+
+	info, err := nativeAudioInfo(input)
+	if err != nil {
+		t.Fatalf("nativeAudioInfo() error = %v", err)
+	}
+	if info.Duration != 2*time.Second || info.SampleRate != 8000 || info.Channels != 1 || info.MimeType != "audio/wav" {
+		t.Errorf("nativeAudioInfo() = %+v", info)
+	}
+
+	output := filepath.Join(dir, "chunk.wav")
+	if err := nativeSlice(input, 500*time.Millisecond, time.Second, output); err != nil {
+		t.Fatalf("nativeSlice() error = %v", err)
+	}
+	slice, err := readWAV(output)
+	if err != nil {
+		t.Fatalf("readWAV() error = %v", err)
+	}
+	if slice.duration() != time.Second {
+		t.Errorf("slice duration = %v, want 1s", slice.duration())
+	}
+
+	// The slice starts 8000 bytes into the source data
+	data, _ := os.ReadFile(output)
+	if got := data[slice.dataOffset]; got != byte(8000%256) {
+		t.Errorf("first sample byte = %d, want %d", got, byte(8000%256))
+	}
+}
+
+func TestNativeMP3(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.mp3")
+	writeTestMP3(t, input, 100)
+
+	mp3, err := scanMP3(input)
+	if err != nil {
+		t.Fatalf("scanMP3() error = %v", err)
+	}
+	if len(mp3.frames) != 100 {
+		t.Fatalf("frames = %d, want 100 (ID3 tags and Xing frame skipped)", len(mp3.frames))
+	}
+	if mp3.frames[0].offset != 30+417 {
+		t.Errorf("first frame offset = %d, want %d", mp3.frames[0].offset, 30+417)
+	}
+
+	info, err := nativeAudioInfo(input)
+	if err != nil {
+		t.Fatalf("nativeAudioInfo() error = %v", err)
+	}
+	if info.Duration != 100*(1152*time.Second/44100) || info.SampleRate != 44100 || info.Channels != 2 {
+		t.Errorf("nativeAudioInfo() = %+v", info)
+	}
+
+	output := filepath.Join(dir, "chunk.mp3")
+	if err := nativeSlice(input, time.Second, time.Second, output); err != nil {
+		t.Fatalf("nativeSlice() error = %v", err)
+	}
+	slice, err := scanMP3(output)
+	if err != nil {
+		t.Fatalf("scanMP3() error = %v", err)
+	}
+	// Frames 38 to 76 cover 1s-2s
+	if len(slice.frames) != 39 {
+		t.Errorf("slice frames = %d, want 39", len(slice.frames))
+	}
+}
+
+func TestNativeUnsupported(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "talk.flac")
+	if err := os.WriteFile(input, []byte("fLaC"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nativeAudioInfo(input); err == nil || !strings.Contains(err.Error(), "ffmpeg not found") {
+		t.Errorf("nativeAudioInfo() error = %v, want ErrFFmpegRequired", err)
+	}
+}
+
+func TestChunkAudioWithoutFFmpeg(t *testing.T) {
+	original := ffmpegAvailable
+	ffmpegAvailable = func() bool { return false }
+	defer func() { ffmpegAvailable = original }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.wav")
+	writeTestWAV(t, input, 5)
+
+	chunker := NewChunker(dir)
+	chunks, err := chunker.ChunkAudio(input, ProcessorOptions{
+		ChunkDuration:   2 * time.Second,
+		OverlapDuration: 500 * time.Millisecond,
+		TrimSilence:     true, // Skipped without ffmpeg
+	})
+	if err != nil {
+		t.Fatalf("ChunkAudio() error = %v", err)
+	}
+	defer func() { _ = chunker.CleanupChunks(chunks) }()
+
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %d, want 3", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if filepath.Ext(chunk.TempFilePath) != ".wav" {
+			t.Errorf("chunk %d file = %s, want a .wav chunk", chunk.Index, chunk.TempFilePath)
+		}
+		wav, err := readWAV(chunk.TempFilePath)
+		if err != nil {
+			t.Fatalf("readWAV() error = %v", err)
+		}
+		if wav.duration() != chunk.Duration {
+			t.Errorf("chunk %d duration = %v, want %v", chunk.Index, wav.duration(), chunk.Duration)
+		}
+	}
+}
+
+func TestCreateChunksSendsEachChunk(t *testing.T) {
+	original := ffmpegAvailable
+	ffmpegAvailable = func() bool { return false }
+	defer func() { ffmpegAvailable = original }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.wav")
+	writeTestWAV(t, input, 5)
+
+	chunker := NewChunker(dir)
+	options := ProcessorOptions{ChunkDuration: 2 * time.Second, OverlapDuration: 500 * time.Millisecond}
+	info, err := NewProcessor(dir).GetAudioInfo(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := chunker.PlanChunks(*info, options)
+	defer func() { _ = chunker.CleanupChunks(chunks) }()
+
+	ready := make(chan *ChunkInfo, len(chunks))
+	if err := chunker.CreateChunks(context.Background(), input, chunks, options, ready); err != nil {
+		t.Fatalf("CreateChunks() error = %v", err)
+	}
+	var sent []*ChunkInfo
+	for chunk := range ready {
+		if _, err := os.Stat(chunk.TempFilePath); err != nil {
+			t.Errorf("chunk %d sent before its file was written: %v", chunk.Index, err)
+		}
+		sent = append(sent, chunk)
+	}
+	if len(sent) != len(chunks) {
+		t.Fatalf("sent %d chunks, want %d", len(sent), len(chunks))
+	}
+	for i, chunk := range sent {
+		if chunk != chunks[i] {
+			t.Errorf("chunk %d sent out of order", i)
+		}
+	}
+
+	// A cancelled run stops and still closes the channel
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ready = make(chan *ChunkInfo, len(chunks))
+	if err := chunker.CreateChunks(ctx, input, chunker.PlanChunks(*info, options), options, ready); !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateChunks() after cancel error = %v, want context.Canceled", err)
+	}
+	if _, open := <-ready; open {
+		t.Error("CreateChunks() after cancel sent a chunk")
+	}
+}
+
+func TestCreateChunksSeparateRuns(t *testing.T) {
+	original := ffmpegAvailable
+	ffmpegAvailable = func() bool { return false }
+	defer func() { ffmpegAvailable = original }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.wav")
+	writeTestWAV(t, input, 5)
+
+	chunker := NewChunker(dir)
+	options := ProcessorOptions{ChunkDuration: 2 * time.Second, OverlapDuration: 500 * time.Millisecond}
+	info, err := NewProcessor(dir).GetAudioInfo(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs started within the same second must not share chunk files
+	seen := make(map[string]bool)
+	for run := 0; run < 2; run++ {
+		chunks := chunker.PlanChunks(*info, options)
+		ready := make(chan *ChunkInfo, len(chunks))
+		if err := chunker.CreateChunks(context.Background(), input, chunks, options, ready); err != nil {
+			t.Fatalf("CreateChunks() error = %v", err)
+		}
+		for chunk := range ready {
+			if seen[chunk.TempFilePath] {
+				t.Errorf("run %d reused chunk file %s", run, chunk.TempFilePath)
+			}
+			seen[chunk.TempFilePath] = true
+		}
+	}
+}
+
+func TestCreateChunksInMemory(t *testing.T) {
+	original := ffmpegAvailable
+	ffmpegAvailable = func() bool { return false }
+	defer func() { ffmpegAvailable = original }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "talk.wav")
+	writeTestWAV(t, input, 5)
+	scratch := t.TempDir()
+
+	chunker := NewChunker(scratch)
+	options := ProcessorOptions{ChunkDuration: 2 * time.Second, OverlapDuration: 500 * time.Millisecond, InMemory: true}
+	info, err := NewProcessor(dir).GetAudioInfo(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := chunker.PlanChunks(*info, options)
+
+	// Unbuffered, as the transcriber uses it
+	ready := make(chan *ChunkInfo)
+	created := make(chan error, 1)
+	go func() { created <- chunker.CreateChunks(context.Background(), input, chunks, options, ready) }()
+	for chunk := range ready {
+		if chunk.TempFilePath != "" {
+			t.Errorf("chunk %d written to %s", chunk.Index, chunk.TempFilePath)
+		}
+		if chunk.Format != FormatWAV || chunk.Name() != fmt.Sprintf("chunk_%03d.wav", chunk.Index) {
+			t.Errorf("chunk %d format = %q, name = %q", chunk.Index, chunk.Format, chunk.Name())
+		}
+
+		// The same audio a chunk file would hold
+		path := filepath.Join(dir, chunk.Name())
+		if err := nativeSlice(input, chunk.Start, chunk.Duration, path); err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(chunk.Data, want) {
+			t.Errorf("chunk %d has %d bytes, want the %d of a chunk file", chunk.Index, len(chunk.Data), len(want))
+		}
+	}
+	if err := <-created; err != nil {
+		t.Fatalf("CreateChunks() error = %v", err)
+	}
+
+	if err := chunker.ValidateChunks(chunks); err != nil {
+		t.Errorf("ValidateChunks() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(scratch); len(entries) > 0 {
+		t.Errorf("temp directory has %d entries, want none", len(entries))
+	}
+	if err := chunker.CleanupChunks(chunks); err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if chunk.Data != nil {
+			t.Errorf("chunk %d audio kept after cleanup", chunk.Index)
+		}
+	}
+}
+
+func TestSetWAVSizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "talk.wav")
+	writeTestWAV(t, path, 1)
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As ffmpeg writes a WAV to a pipe
+	data := bytes.Clone(want)
+	binary.LittleEndian.PutUint32(data[4:8], 0xFFFFFFFF)
+	dataChunk := bytes.Index(data, []byte("data"))
+	binary.LittleEndian.PutUint32(data[dataChunk+4:dataChunk+8], 0xFFFFFFFF)
+
+	setWAVSizes(data)
+	if !bytes.Equal(data, want) {
+		t.Errorf("setWAVSizes() header = %x, want %x", data[:dataChunk+8], want[:dataChunk+8])
+	}
+}
@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,6 +13,7 @@ import (
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
 )
 
 // ProcessorImpl implements the Processor interface
@@ -30,7 +32,15 @@ func NewProcessor(tempDir string) *ProcessorImpl {
 }
 
 // GetAudioInfo extracts metadata from an audio/video file
+//
+// Deprecated: Use GetAudioInfoContext, which stops ffprobe on cancellation.
 func (p *ProcessorImpl) GetAudioInfo(filePath string) (*AudioInfo, error) {
+	return p.GetAudioInfoContext(context.Background(), filePath)
+}
+
+// GetAudioInfoContext extracts metadata from an audio/video file, killing
+// ffprobe when ctx is done
+func (p *ProcessorImpl) GetAudioInfoContext(ctx context.Context, filePath string) (*AudioInfo, error) {
 	log := logger.WithComponent("audio-processor").WithField("file", filepath.Base(filePath))
 
 	log.Debug().Str("full_path", filePath).Msg("Getting audio information")
@@ -40,9 +50,19 @@ func (p *ProcessorImpl) GetAudioInfo(filePath string) (*AudioInfo, error) {
 		return nil, fmt.Errorf("file does not exist: %s", filePath)
 	}
 
+	if !FFmpegAvailable() {
+		log.Debug().Msg("Reading file natively, ffprobe not found")
+		audioInfo, err := nativeAudioInfo(filePath)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to read file")
+			return nil, fmt.Errorf("failed to probe file: %w", err)
+		}
+		return audioInfo, nil
+	}
+
 	// Use ffprobe to get file information
 	log.Debug().Msg("Probing file with ffprobe")
-	info, err := ffmpeg.Probe(filePath)
+	info, err := probe(ctx, filePath)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to probe file")
 		return nil, fmt.Errorf("failed to probe file: %w", err)
@@ -71,7 +91,22 @@ func (p *ProcessorImpl) GetAudioInfo(filePath string) (*AudioInfo, error) {
 }
 
 // ConvertToAudio converts video files (MP4) to audio format
+//
+// Deprecated: Use ConvertToAudioContext, which stops ffmpeg on cancellation.
 func (p *ProcessorImpl) ConvertToAudio(inputPath, outputPath string, format AudioFormat) error {
+	return p.ConvertToAudioContext(context.Background(), inputPath, outputPath, format)
+}
+
+// ConvertToAudioContext converts video files (MP4) to audio format,
+// killing ffmpeg when ctx is done
+func (p *ProcessorImpl) ConvertToAudioContext(ctx context.Context, inputPath, outputPath string, format AudioFormat) error {
+	return p.ConvertWithEncoding(ctx, inputPath, outputPath, Encoding{Format: format})
+}
+
+// ConvertWithEncoding converts a file to audio written with encoding,
+// killing ffmpeg when ctx is done; the partial output is removed
+func (p *ProcessorImpl) ConvertWithEncoding(ctx context.Context, inputPath, outputPath string, encoding Encoding) error {
+	encoding = encoding.withDefaults()
 	log := logger.WithComponent("audio-converter").
 		WithField("input", filepath.Base(inputPath)).
 		WithField("output", filepath.Base(outputPath))
@@ -79,7 +114,9 @@ func (p *ProcessorImpl) ConvertToAudio(inputPath, outputPath string, format Audi
 	log.Info().
 		Str("input_path", inputPath).
 		Str("output_path", outputPath).
-		Str("format", string(format)).
+		Str("format", string(encoding.Format)).
+		Int("sample_rate", encoding.SampleRate).
+		Int("channels", encoding.Channels).
 		Msg("Starting audio conversion")
 
 	if !p.fileExists(inputPath) {
@@ -87,6 +124,15 @@ func (p *ProcessorImpl) ConvertToAudio(inputPath, outputPath string, format Audi
 		return fmt.Errorf("input file does not exist: %s", inputPath)
 	}
 
+	if err := encoding.Validate(); err != nil {
+		log.Error().Err(err).Msg("Unsupported output encoding")
+		return err
+	}
+
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot convert %s: %w", filepath.Base(inputPath), ErrFFmpegRequired)
+	}
+
 	// Ensure output directory exists
 	outputDir := filepath.Dir(outputPath)
 	log.Debug().Str("output_dir", outputDir).Msg("Creating output directory")
@@ -95,45 +141,18 @@ func (p *ProcessorImpl) ConvertToAudio(inputPath, outputPath string, format Audi
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Build ffmpeg command based on output format
-	log.Debug().Str("format", string(format)).Msg("Building ffmpeg command")
-	stream := ffmpeg.Input(inputPath)
-
-	switch format {
-	case FormatMP3:
-		log.Debug().Msg("Configuring MP3 output parameters")
-		stream = stream.Output(outputPath, ffmpeg.KwArgs{
-			"acodec": "libmp3lame",
-			"ab":     "192k",
-			"ar":     "44100",
-			"ac":     "2",
-		})
-	case FormatWAV:
-		log.Debug().Msg("Configuring WAV output parameters")
-		stream = stream.Output(outputPath, ffmpeg.KwArgs{
-			"acodec": "pcm_s16le",
-			"ar":     "44100",
-			"ac":     "2",
-		})
-	case FormatFLAC:
-		log.Debug().Msg("Configuring FLAC output parameters")
-		stream = stream.Output(outputPath, ffmpeg.KwArgs{
-			"acodec": "flac",
-			"ar":     "44100",
-			"ac":     "2",
-		})
-	default:
-		log.Error().Str("format", string(format)).Msg("Unsupported output format")
-		return fmt.Errorf("unsupported output format: %s", format)
-	}
+	stream := ffmpeg.Input(inputPath).Output(outputPath, encoding.outputArgs())
 
 	// Execute the conversion
 	log.Info().Msg("Executing ffmpeg conversion")
 	startTime := time.Now()
-	err := stream.OverWriteOutput().ErrorToStdOut().Run()
+	err := runFFmpeg(ctx, stream.OverWriteOutput().ErrorToStdOut())
 	duration := time.Since(startTime)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			_ = os.Remove(outputPath)
+		}
 		log.Error().Err(err).Dur("duration", duration).Msg("FFmpeg conversion failed")
 		return fmt.Errorf("ffmpeg conversion failed: %w", err)
 	}
@@ -157,21 +176,23 @@ func (p *ProcessorImpl) ConvertToAudio(inputPath, outputPath string, format Audi
 	return nil
 }
 
-// IsSupported checks if the file format is supported
+// IsSupported checks if the file format is supported. Every format known
+// to mediatype is, since ffmpeg decodes them all when writing chunks.
 func (p *ProcessorImpl) IsSupported(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	supportedExts := []string{".wav", ".mp3", ".m4a", ".flac", ".mp4", ".avi", ".mov", ".mkv"}
-
-	for _, supportedExt := range supportedExts {
-		if ext == supportedExt {
-			return true
-		}
-	}
-	return false
+	_, ok := mediatype.FromPath(filePath)
+	return ok
 }
 
 // ValidateFile validates the audio file
+//
+// Deprecated: Use ValidateFileContext, which stops ffprobe on cancellation.
 func (p *ProcessorImpl) ValidateFile(filePath string) error {
+	return p.ValidateFileContext(context.Background(), filePath)
+}
+
+// ValidateFileContext validates the audio file, killing ffprobe when ctx
+// is done
+func (p *ProcessorImpl) ValidateFileContext(ctx context.Context, filePath string) error {
 	if !p.fileExists(filePath) {
 		return fmt.Errorf("file does not exist: %s", filePath)
 	}
@@ -180,9 +201,19 @@ func (p *ProcessorImpl) ValidateFile(filePath string) error {
 		return fmt.Errorf("unsupported file format: %s", filepath.Ext(filePath))
 	}
 
+	if !FFmpegAvailable() {
+		if _, err := nativeAudioInfo(filePath); err != nil {
+			return fmt.Errorf("invalid or corrupted file: %w", err)
+		}
+		return nil
+	}
+
 	// Try to probe the file to ensure it's valid
-	_, err := ffmpeg.Probe(filePath)
+	_, err := probe(ctx, filePath)
 	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
 		return fmt.Errorf("invalid or corrupted file: %w", err)
 	}
 
@@ -254,55 +285,20 @@ func (p *ProcessorImpl) parseProbeInfo(probeData string, info *AudioInfo) error
 	}
 
 	// Determine if it's a video file
-	ext := strings.ToLower(filepath.Ext(info.FilePath))
-	videoExts := []string{".mp4", ".avi", ".mov", ".mkv", ".webm"}
-	for _, videoExt := range videoExts {
-		if ext == videoExt {
-			info.IsVideo = true
-			break
-		}
+	if format, ok := mediatype.FromPath(info.FilePath); ok {
+		info.IsVideo = format.Video
 	}
 
 	// Set format based on extension
-	switch ext {
-	case ".wav":
-		info.Format = FormatWAV
-		info.MimeType = "audio/wav"
-	case ".mp3":
-		info.Format = FormatMP3
-		info.MimeType = "audio/mpeg"
-	case ".m4a":
-		info.Format = FormatM4A
-		info.MimeType = "audio/m4a"
-	case ".flac":
-		info.Format = FormatFLAC
-		info.MimeType = "audio/flac"
-	case ".mp4":
-		info.Format = FormatMP4
-		info.MimeType = "video/mp4"
-	default:
-		info.MimeType = "application/octet-stream"
-	}
+	info.Format = DetectFormat(info.FilePath)
+	info.MimeType = GetMimeType(info.Format)
 
 	return nil
 }
 
 // GetMimeType returns the MIME type for the audio format
 func GetMimeType(format AudioFormat) string {
-	switch format {
-	case FormatWAV:
-		return "audio/wav"
-	case FormatMP3:
-		return "audio/mpeg"
-	case FormatM4A:
-		return "audio/m4a"
-	case FormatFLAC:
-		return "audio/flac"
-	case FormatMP4:
-		return "video/mp4"
-	default:
-		return "application/octet-stream"
-	}
+	return mediatype.MIME(string(format))
 }
 
 // DetectFormat detects audio format from file extension
@@ -319,6 +315,20 @@ func DetectFormat(filePath string) AudioFormat {
 		return FormatFLAC
 	case ".mp4":
 		return FormatMP4
+	case ".ogg":
+		return FormatOGG
+	case ".opus":
+		return FormatOpus
+	case ".webm":
+		return FormatWebM
+	case ".aac":
+		return FormatAAC
+	case ".wma":
+		return FormatWMA
+	case ".amr":
+		return FormatAMR
+	case ".3gp":
+		return Format3GP
 	default:
 		return ""
 	}
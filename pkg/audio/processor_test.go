@@ -82,6 +82,41 @@ func TestIsSupported(t *testing.T) {
 			filePath: "test.mkv",
 			want:     true,
 		},
+		{
+			name:     "ogg file",
+			filePath: "voice_note.ogg",
+			want:     true,
+		},
+		{
+			name:     "opus file",
+			filePath: "voice_note.opus",
+			want:     true,
+		},
+		{
+			name:     "webm file",
+			filePath: "voice_note.webm",
+			want:     true,
+		},
+		{
+			name:     "aac file",
+			filePath: "voice_note.aac",
+			want:     true,
+		},
+		{
+			name:     "wma file",
+			filePath: "voice_note.wma",
+			want:     true,
+		},
+		{
+			name:     "amr file",
+			filePath: "voice_note.amr",
+			want:     true,
+		},
+		{
+			name:     "3gp file",
+			filePath: "voice_note.3gp",
+			want:     true,
+		},
 		{
 			name:     "uppercase extension",
 			filePath: "test.MP3",
@@ -140,6 +175,41 @@ func TestDetectFormat(t *testing.T) {
 			filePath: "test.mp4",
 			want:     FormatMP4,
 		},
+		{
+			name:     "ogg file",
+			filePath: "voice_note.ogg",
+			want:     FormatOGG,
+		},
+		{
+			name:     "opus file",
+			filePath: "voice_note.opus",
+			want:     FormatOpus,
+		},
+		{
+			name:     "webm file",
+			filePath: "voice_note.webm",
+			want:     FormatWebM,
+		},
+		{
+			name:     "aac file",
+			filePath: "voice_note.aac",
+			want:     FormatAAC,
+		},
+		{
+			name:     "wma file",
+			filePath: "voice_note.wma",
+			want:     FormatWMA,
+		},
+		{
+			name:     "amr file",
+			filePath: "voice_note.amr",
+			want:     FormatAMR,
+		},
+		{
+			name:     "3gp file",
+			filePath: "voice_note.3gp",
+			want:     Format3GP,
+		},
 		{
 			name:     "uppercase extension",
 			filePath: "test.WAV",
@@ -186,7 +256,7 @@ func TestGetMimeType(t *testing.T) {
 		{
 			name:   "m4a format",
 			format: FormatM4A,
-			want:   "audio/m4a",
+			want:   "audio/mp4",
 		},
 		{
 			name:   "flac format",
@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -29,7 +30,15 @@ func (r *ReaderImpl) OpenAudio(filePath string) (io.ReadCloser, error) {
 }
 
 // ReadChunk reads a specific chunk of audio data
+//
+// Deprecated: Use ReadChunkContext, which stops ffmpeg on cancellation.
 func (r *ReaderImpl) ReadChunk(filePath string, start, duration time.Duration) (io.ReadCloser, error) {
+	return r.ReadChunkContext(context.Background(), filePath, start, duration)
+}
+
+// ReadChunkContext reads a specific chunk of audio data, killing ffmpeg
+// when ctx is done
+func (r *ReaderImpl) ReadChunkContext(ctx context.Context, filePath string, start, duration time.Duration) (io.ReadCloser, error) {
 	// Create a temporary chunk file
 	tempFile, err := os.CreateTemp(r.chunker.tempDir, "chunk_*.mp3")
 	if err != nil {
@@ -41,7 +50,7 @@ func (r *ReaderImpl) ReadChunk(filePath string, start, duration time.Duration) (
 	}
 
 	// Extract the chunk
-	if err := r.chunker.CreateChunk(filePath, start, duration, tempPath); err != nil {
+	if err := r.chunker.CreateChunkContext(ctx, filePath, start, duration, tempPath); err != nil {
 		_ = os.Remove(tempPath)
 		return nil, fmt.Errorf("failed to create chunk: %w", err)
 	}
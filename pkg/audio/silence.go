@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Silence trimming defaults
+const (
+	DefaultSilenceThreshold = -50             // dB
+	DefaultMinSilence       = 2 * time.Second // Shorter pauses are kept
+	silencePad              = 250 * time.Millisecond
+)
+
+// silence is a silent interval relative to the start of the analysed audio
+type silence struct {
+	start, end time.Duration
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: ([0-9.]+)`)
+)
+
+// detectSilence runs ffmpeg silencedetect over duration of inputPath from
+// start and returns the silent intervals
+func detectSilence(ctx context.Context, inputPath string, start, duration time.Duration, thresholdDB int, minSilence time.Duration) ([]silence, error) {
+	var stderr bytes.Buffer
+	err := runFFmpeg(ctx, ffmpeg.Input(inputPath, ffmpeg.KwArgs{
+		"ss": formatDuration(start),
+		"t":  formatDuration(duration),
+	}).Output("-", ffmpeg.KwArgs{
+		"af": fmt.Sprintf("silencedetect=noise=%ddB:d=%.3f", thresholdDB, minSilence.Seconds()),
+		"f":  "null",
+	}).WithErrorOutput(&stderr))
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg silence detection failed: %w", err)
+	}
+	return parseSilence(stderr.String(), duration), nil
+}
+
+// parseSilence reads silencedetect log output. An interval still open at
+// the end of the output lasts until duration.
+func parseSilence(output string, duration time.Duration) []silence {
+	var intervals []silence
+	var open *silence
+
+	for _, line := range bytes.Split([]byte(output), []byte("\n")) {
+		if m := silenceStartPattern.FindSubmatch(line); m != nil {
+			open = &silence{start: parseSeconds(string(m[1])), end: duration}
+		} else if m := silenceEndPattern.FindSubmatch(line); m != nil && open != nil {
+			open.end = parseSeconds(string(m[1]))
+			intervals = append(intervals, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		intervals = append(intervals, *open)
+	}
+	return intervals
+}
+
+// parseSeconds converts ffmpeg's fractional seconds, clamping at zero
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// silenceTrim returns how much leading and trailing silence can be cut
+// from audio of the given duration, keeping a short pad next to speech
+// so word onsets are not clipped
+func silenceTrim(intervals []silence, duration time.Duration) (lead, trail time.Duration) {
+	for _, s := range intervals {
+		if s.start <= 0 {
+			lead = s.end - silencePad
+		}
+		if s.end >= duration {
+			trail = duration - s.start - silencePad
+		}
+	}
+	if lead < 0 {
+		lead = 0
+	}
+	if trail < 0 {
+		trail = 0
+	}
+	return lead, trail
+}
+
+// trimChunkSilence measures the leading and trailing silence of a chunk
+// and records it in TrimmedStart/TrimmedEnd, or marks the chunk Silent
+func (c *ChunkerImpl) trimChunkSilence(ctx context.Context, inputPath string, chunk *ChunkInfo, options ProcessorOptions) error {
+	threshold := options.SilenceThreshold
+	if threshold == 0 {
+		threshold = DefaultSilenceThreshold
+	}
+	minSilence := options.MinSilence
+	if minSilence <= 0 {
+		minSilence = DefaultMinSilence
+	}
+
+	intervals, err := detectSilence(ctx, inputPath, chunk.Start, chunk.Duration, threshold, minSilence)
+	if err != nil {
+		return err
+	}
+
+	lead, trail := silenceTrim(intervals, chunk.Duration)
+	if lead+trail >= chunk.Duration-silencePad {
+		chunk.Silent = true
+		return nil
+	}
+	chunk.TrimmedStart, chunk.TrimmedEnd = lead, trail
+	return nil
+}
@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+const silenceLog = `Input #0, mp3, from 'talk.mp3':
+[silencedetect @ 0x5581] silence_start: 0
+[silencedetect @ 0x5581] silence_end: 4.5 | silence_duration: 4.5
+[silencedetect @ 0x5581] silence_start: 30.25
+[silencedetect @ 0x5581] silence_end: 33 | silence_duration: 2.75
+[silencedetect @ 0x5581] silence_start: 52
+size=N/A time=00:01:00.00 bitrate=N/A speed= 400x
+`
+
+func TestParseSilence(t *testing.T) {
+	got := parseSilence(silenceLog, time.Minute)
+	want := []silence{
+		{0, 4500 * time.Millisecond},
+		{30250 * time.Millisecond, 33 * time.Second},
+		{52 * time.Second, time.Minute}, // Still open at the end
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseSilence() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("interval %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSilenceTrim(t *testing.T) {
+	lead, trail := silenceTrim(parseSilence(silenceLog, time.Minute), time.Minute)
+	if lead != 4500*time.Millisecond-silencePad {
+		t.Errorf("lead = %v", lead)
+	}
+	if trail != 8*time.Second-silencePad {
+		t.Errorf("trail = %v", trail)
+	}
+
+	// Pauses in the middle are kept
+	lead, trail = silenceTrim([]silence{{10 * time.Second, 20 * time.Second}}, time.Minute)
+	if lead != 0 || trail != 0 {
+		t.Errorf("silenceTrim(middle) = %v, %v, want 0, 0", lead, trail)
+	}
+}
+
+func TestAudioDuration(t *testing.T) {
+	chunk := &ChunkInfo{Duration: time.Minute, TrimmedStart: 5 * time.Second, TrimmedEnd: 10 * time.Second}
+	if got := chunk.AudioDuration(); got != 45*time.Second {
+		t.Errorf("AudioDuration() = %v, want 45s", got)
+	}
+	chunk.Silent = true
+	if got := chunk.AudioDuration(); got != 0 {
+		t.Errorf("AudioDuration() of silent chunk = %v, want 0", got)
+	}
+}
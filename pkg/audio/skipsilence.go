@@ -0,0 +1,150 @@
+package audio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// DefaultSkipSilence is the shortest silence removed by SkipSilence. It is
+// much longer than DefaultMinSilence so ordinary pauses stay in place.
+const DefaultSkipSilence = 10 * time.Second
+
+// TimeMap maps times in audio with silences removed back to the source
+// recording. It lists the spans of the source that were kept, in order.
+type TimeMap struct {
+	Kept     []Span
+	Duration time.Duration // Length of the source
+}
+
+// Removed returns how much silence was cut from the source
+func (m *TimeMap) Removed() time.Duration {
+	if m == nil {
+		return 0
+	}
+	removed := m.Duration
+	for _, span := range m.Kept {
+		removed -= span.End - span.Start
+	}
+	return removed
+}
+
+// ToSource converts a time in the shortened audio to the source. A time
+// on a cut belongs to the speech after it.
+func (m *TimeMap) ToSource(t time.Duration) time.Duration {
+	return m.toSource(t, false)
+}
+
+// EndToSource converts the end of a range in the shortened audio to the
+// source. A time on a cut belongs to the speech before it, so a range
+// ending there does not stretch over the removed silence.
+func (m *TimeMap) EndToSource(t time.Duration) time.Duration {
+	return m.toSource(t, true)
+}
+
+func (m *TimeMap) toSource(t time.Duration, before bool) time.Duration {
+	if m == nil || len(m.Kept) == 0 {
+		return t
+	}
+	var offset time.Duration
+	for _, span := range m.Kept {
+		length := span.End - span.Start
+		if t < offset+length || (before && t == offset+length) {
+			return span.Start + max(t-offset, 0)
+		}
+		offset += length
+	}
+	last := m.Kept[len(m.Kept)-1]
+	return last.End + (t - offset)
+}
+
+// Key derives a source hash for the shortened audio, so its chunks are
+// cached and checkpointed apart from chunks of the full recording
+func (m *TimeMap) Key(sourceHash string) string {
+	h := sha256.New()
+	h.Write([]byte(sourceHash))
+	for _, span := range m.Kept {
+		fmt.Fprintf(h, ":%d-%d", span.Start.Milliseconds(), span.End.Milliseconds())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SkipSilence writes the audio of inputPath to outputPath as MP3 without
+// its silences of at least minSilence, keeping a short pad next to speech.
+// It returns the mapping back to the source, or nil if there was nothing to
+// remove, in which case no file is written. It kills ffmpeg when ctx is
+// done.
+func SkipSilence(ctx context.Context, inputPath, outputPath string, duration time.Duration, thresholdDB int, minSilence time.Duration) (*TimeMap, error) {
+	if !FFmpegAvailable() {
+		return nil, fmt.Errorf("cannot skip silence in %s: %w", filepath.Base(inputPath), ErrFFmpegRequired)
+	}
+	if thresholdDB == 0 {
+		thresholdDB = DefaultSilenceThreshold
+	}
+	if minSilence <= 0 {
+		minSilence = DefaultSkipSilence
+	}
+
+	intervals, err := detectSilence(ctx, inputPath, 0, duration, thresholdDB, minSilence)
+	if err != nil {
+		return nil, err
+	}
+	timeMap := &TimeMap{Kept: keptSpans(intervals, duration), Duration: duration}
+	if timeMap.Removed() <= 0 {
+		return nil, nil
+	}
+	if len(timeMap.Kept) == 0 {
+		return nil, fmt.Errorf("%s is entirely silent", filepath.Base(inputPath))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	err = runFFmpeg(ctx, ffmpeg.Input(inputPath).Audio().
+		Filter("aselect", ffmpeg.Args{spanExpression(timeMap.Kept)}).
+		Filter("asetpts", ffmpeg.Args{"N/SR/TB"}).
+		Output(outputPath, ffmpeg.KwArgs{
+			"acodec": mediatype.Encoder(string(FormatMP3)),
+			"ab":     "192k",
+			"ar":     "44100",
+		}).OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg silence removal failed: %w", err)
+	}
+	return timeMap, nil
+}
+
+// keptSpans returns the parts of audio of the given duration outside the
+// silent intervals, each silence shrunk by a pad on both sides
+func keptSpans(intervals []silence, duration time.Duration) []Span {
+	var kept []Span
+	var from time.Duration
+	for _, s := range intervals {
+		start, end := s.start+silencePad, s.end-silencePad
+		if s.start <= 0 {
+			start = 0
+		}
+		if s.end >= duration {
+			end = duration
+		}
+		if end <= start || start < from {
+			continue
+		}
+		if start > from {
+			kept = append(kept, Span{Start: from, End: start})
+		}
+		from = end
+	}
+	if from < duration {
+		kept = append(kept, Span{Start: from, End: duration})
+	}
+	return kept
+}
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeptSpans(t *testing.T) {
+	ms := time.Millisecond
+	got := keptSpans(parseSilence(silenceLog, time.Minute), time.Minute)
+	want := []Span{
+		{4500*ms - silencePad, 30250*ms + silencePad},
+		{33*time.Second - silencePad, 52*time.Second + silencePad},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("keptSpans() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("span %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if spans := keptSpans(nil, time.Minute); len(spans) != 1 || spans[0] != (Span{0, time.Minute}) {
+		t.Errorf("without silence keptSpans() = %v, want the whole recording", spans)
+	}
+}
+
+func TestTimeMap(t *testing.T) {
+	m := &TimeMap{
+		Kept:     []Span{{10 * time.Second, 20 * time.Second}, {50 * time.Second, time.Minute}},
+		Duration: 2 * time.Minute,
+	}
+	if removed := m.Removed(); removed != 100*time.Second {
+		t.Errorf("Removed() = %v, want 1m40s", removed)
+	}
+
+	tests := []struct {
+		in, want time.Duration
+	}{
+		{0, 10 * time.Second},
+		{5 * time.Second, 15 * time.Second},
+		{10 * time.Second, 50 * time.Second}, // A cut belongs to the speech after it
+		{15 * time.Second, 55 * time.Second},
+		{25 * time.Second, 65 * time.Second}, // Past the end
+	}
+	for _, tt := range tests {
+		if got := m.ToSource(tt.in); got != tt.want {
+			t.Errorf("ToSource(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if got := m.EndToSource(10 * time.Second); got != 20*time.Second {
+		t.Errorf("EndToSource(10s) = %v, want the end of the speech before the cut", got)
+	}
+
+	var none *TimeMap
+	if got := none.ToSource(time.Second); got != time.Second {
+		t.Errorf("nil map ToSource() = %v, want it unchanged", got)
+	}
+	if m.Key("abc") == (&TimeMap{Kept: m.Kept[:1]}).Key("abc") {
+		t.Error("Key() does not depend on the kept spans")
+	}
+}
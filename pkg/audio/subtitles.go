@@ -0,0 +1,129 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// MuxSubtitles writes a copy of videoPath to outputPath with the SRT file
+// at subtitlePath added as a soft subtitle track, which players can switch
+// on and off. The video, audio and existing tracks are copied without
+// re-encoding. The container follows the extension of outputPath and has
+// to support subtitle tracks (mp4, mov, mkv, webm). language is the track's
+// ISO 639-2 code, e.g. "eng", or "" to leave it unset. It kills ffmpeg
+// when ctx is done.
+func MuxSubtitles(ctx context.Context, videoPath, subtitlePath, outputPath, language string) error {
+	format, ok := mediatype.FromPath(outputPath)
+	if !ok || format.Subtitles == "" {
+		return fmt.Errorf("cannot add subtitle tracks to %s files", filepath.Ext(outputPath))
+	}
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot add subtitles to %s: %w", filepath.Base(videoPath), ErrFFmpegRequired)
+	}
+	existing, err := countSubtitleStreams(ctx, videoPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Only the new track, after any the video has, is encoded
+	track := fmt.Sprintf("s:%d", existing)
+	args := ffmpeg.KwArgs{
+		"c":          "copy",
+		"c:" + track: format.Subtitles,
+	}
+	if language != "" {
+		args["metadata:s:"+track] = "language=" + language
+	}
+	err = runFFmpeg(ctx, ffmpeg.Output([]*ffmpeg.Stream{ffmpeg.Input(videoPath), ffmpeg.Input(subtitlePath)}, outputPath, args).
+		OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return fmt.Errorf("ffmpeg subtitle muxing failed: %w", err)
+	}
+	return nil
+}
+
+// SubtitleStream is a subtitle track of a video
+type SubtitleStream struct {
+	Index    int    // Among the subtitle tracks, from 0
+	Codec    string // e.g. subrip, mov_text, hdmv_pgs_subtitle
+	Language string // ISO 639-2 code from the track's tags, e.g. "eng", or ""
+	Title    string
+	Default  bool
+}
+
+// Text reports whether the track is text rather than images, which
+// cannot be converted to SRT without OCR
+func (s SubtitleStream) Text() bool {
+	switch s.Codec {
+	case "subrip", "srt", "ass", "ssa", "mov_text", "webvtt", "text":
+		return true
+	}
+	return false
+}
+
+// SubtitleStreams returns the subtitle tracks of a file, killing ffprobe
+// when ctx is done
+func SubtitleStreams(ctx context.Context, path string) ([]SubtitleStream, error) {
+	data, err := probe(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe %s: %w", filepath.Base(path), err)
+	}
+	var probe struct {
+		Streams []struct {
+			CodecType   string            `json:"codec_type"`
+			CodecName   string            `json:"codec_name"`
+			Tags        map[string]string `json:"tags"`
+			Disposition map[string]int    `json:"disposition"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(data), &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse probe JSON: %w", err)
+	}
+
+	var streams []SubtitleStream
+	for _, stream := range probe.Streams {
+		if stream.CodecType != "subtitle" {
+			continue
+		}
+		streams = append(streams, SubtitleStream{
+			Index:    len(streams),
+			Codec:    stream.CodecName,
+			Language: stream.Tags["language"],
+			Title:    stream.Tags["title"],
+			Default:  stream.Disposition["default"] == 1,
+		})
+	}
+	return streams, nil
+}
+
+// ExtractSubtitles writes the subtitle track with index stream of videoPath
+// to outputPath as SRT. The track has to be text, see SubtitleStream.Text.
+// It kills ffmpeg when ctx is done.
+func ExtractSubtitles(ctx context.Context, videoPath string, stream int, outputPath string) error {
+	if !FFmpegAvailable() {
+		return fmt.Errorf("cannot extract subtitles from %s: %w", filepath.Base(videoPath), ErrFFmpegRequired)
+	}
+	err := runFFmpeg(ctx, ffmpeg.Input(videoPath).
+		Output(outputPath, ffmpeg.KwArgs{"map": fmt.Sprintf("0:s:%d", stream), "c:s": "srt"}).
+		OverWriteOutput().ErrorToStdOut())
+	if err != nil {
+		return fmt.Errorf("ffmpeg subtitle extraction failed: %w", err)
+	}
+	return nil
+}
+
+// countSubtitleStreams returns how many subtitle tracks a file has
+func countSubtitleStreams(ctx context.Context, path string) (int, error) {
+	streams, err := SubtitleStreams(ctx, path)
+	return len(streams), err
+}
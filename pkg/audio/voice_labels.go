@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileLabelsFile is the optional file in a voice profile directory
+// naming the speaker of each recording, keyed by file name:
+//
+//	alice.mp3: Alice Chen
+//	bob.wav:
+//	  name: Bob Martin
+//	  role: customer
+const ProfileLabelsFile = "profiles.yaml"
+
+// ProfileLabel names the speaker of a profile recording
+type ProfileLabel struct {
+	Name string `yaml:"name"`
+	Role string `yaml:"role"` // e.g. "host" or "customer", given to the model with the name
+}
+
+// UnmarshalYAML accepts a bare name as well as a name and role
+func (l *ProfileLabel) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		l.Name = node.Value
+		return nil
+	}
+	type plain ProfileLabel
+	return node.Decode((*plain)(l))
+}
+
+// loadProfileLabels reads the labels file of dir; without one there are
+// no labels
+func loadProfileLabels(dir string) (map[string]ProfileLabel, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProfileLabelsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ProfileLabelsFile, err)
+	}
+
+	var labels map[string]ProfileLabel
+	if err := yaml.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProfileLabelsFile, err)
+	}
+	for file, label := range labels {
+		if strings.TrimSpace(label.Name) == "" && strings.TrimSpace(label.Role) == "" {
+			return nil, fmt.Errorf("invalid %s: %s has no name", ProfileLabelsFile, file)
+		}
+	}
+	return labels, nil
+}
+
+// unlabeledFiles returns the files named in labels that are not among
+// the profile recordings at paths
+func unlabeledFiles(labels map[string]ProfileLabel, paths []string) []string {
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[filepath.Base(path)] = true
+	}
+	var missing []string
+	for file := range labels {
+		if !present[file] {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// speakerName derives a speaker name from a profile file name.
+// Underscores separate words and all-lowercase names are capitalized:
+// "alice.mp3" is "Alice" and "Jane_Doe.wav" is "Jane Doe", while
+// "McKenzie.mp3" keeps its case.
+func speakerName(file string) string {
+	name := strings.ReplaceAll(strings.TrimSuffix(file, filepath.Ext(file)), "_", " ")
+	if name != strings.ToLower(name) {
+		return name
+	}
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,464 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Voice profile limits
+const (
+	MaxProfileDuration = 15 * time.Second // Longer profile recordings are cut
+	MinProfileDuration = 3 * time.Second  // Shorter recordings rarely identify a voice
+	profileGap         = time.Second      // Silence after each profile
+)
+
+// voiceReferenceVersion is part of the cache key of reference clips; bump
+// it when the way clips are built changes
+const voiceReferenceVersion = 2
+
+// VoiceProfile is a reference recording of a known speaker. The speaker
+// is named in the directory's ProfileLabelsFile, or else after the file
+// name without extension, with underscores as spaces ("Jane_Doe.mp3" is
+// "Jane Doe", "alice.mp3" is "Alice").
+type VoiceProfile struct {
+	Name     string
+	Role     string // From ProfileLabelsFile, if given
+	Path     string
+	Duration time.Duration // Length used, at most MaxProfileDuration
+	Recorded time.Duration // Length of the recording
+}
+
+// LoadVoiceProfiles reads the voice profiles in dir, sorted by name,
+// killing ffprobe when ctx is done
+func LoadVoiceProfiles(ctx context.Context, dir string) ([]*VoiceProfile, error) {
+	paths, err := voiceProfilePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := loadProfileLabels(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*VoiceProfile, 0, len(paths))
+	for _, path := range paths {
+		profile, err := loadVoiceProfile(ctx, path, labels)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no voice profiles found in %s", dir)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// CheckVoiceProfiles reads the voice profiles in dir like
+// LoadVoiceProfiles, but reports every problem instead of stopping at the
+// first: unreadable files, recordings shorter than MinProfileDuration or
+// cut to MaxProfileDuration, speakers with more than one recording and
+// labels of missing recordings. With ffmpeg, the speech in each recording
+// is checked too, see CheckProfileQuality. It returns the profiles that
+// could be read, sorted by name. It kills ffmpeg when ctx is done.
+func CheckVoiceProfiles(ctx context.Context, dir string) ([]*VoiceProfile, []error) {
+	paths, err := voiceProfilePaths(dir)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var problems []error
+	labels, err := loadProfileLabels(dir)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	missing := unlabeledFiles(labels, paths)
+	sort.Strings(missing)
+	for _, file := range missing {
+		problems = append(problems, fmt.Errorf("%s labels %s, which is not a recording in %s", ProfileLabelsFile, file, dir))
+	}
+
+	var profiles []*VoiceProfile
+	byName := make(map[string]string)
+	for _, path := range paths {
+		profile, err := loadVoiceProfile(ctx, path, labels)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		file := filepath.Base(path)
+		if other, ok := byName[strings.ToLower(profile.Name)]; ok {
+			problems = append(problems, fmt.Errorf("%s and %s are both voice profiles of %s", other, file, profile.Name))
+		}
+		byName[strings.ToLower(profile.Name)] = file
+		if profile.Recorded < MinProfileDuration {
+			problems = append(problems, fmt.Errorf("%s is only %s long, record at least %s", file, profile.Recorded.Round(time.Second/10), MinProfileDuration))
+		}
+		if profile.Recorded > MaxProfileDuration {
+			problems = append(problems, fmt.Errorf("%s is cut to its first %s", file, MaxProfileDuration))
+		}
+		if FFmpegAvailable() && profile.Recorded >= MinProfileDuration {
+			quality, err := CheckProfileQuality(ctx, profile)
+			if err != nil {
+				problems = append(problems, err)
+			} else {
+				for _, issue := range quality.Issues {
+					problems = append(problems, fmt.Errorf("%s: %s", file, issue))
+				}
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if len(paths) == 0 {
+		problems = append(problems, fmt.Errorf("no voice profiles found in %s", dir))
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, problems
+}
+
+// voiceProfilePaths returns the supported media files in dir
+func voiceProfilePaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read voice profiles: %w", err)
+	}
+
+	processor := NewProcessor("")
+	var paths []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && processor.IsSupported(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// loadVoiceProfile reads one voice profile recording, named by its label
+// if it has one
+func loadVoiceProfile(ctx context.Context, path string, labels map[string]ProfileLabel) (*VoiceProfile, error) {
+	file := filepath.Base(path)
+	info, err := NewProcessor("").GetAudioInfoContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read voice profile %s: %w", file, err)
+	}
+
+	label := labels[file]
+	name := strings.TrimSpace(label.Name)
+	if name == "" {
+		name = speakerName(file)
+	}
+	return &VoiceProfile{
+		Name:     name,
+		Role:     strings.TrimSpace(label.Role),
+		Path:     path,
+		Duration: min(info.Duration, MaxProfileDuration),
+		Recorded: info.Duration,
+	}, nil
+}
+
+// VoiceReference is a set of voice profiles joined into one clip, which
+// is prepended to chunks so the provider can match speakers by voice
+type VoiceReference struct {
+	Path     string
+	Profiles []*VoiceProfile
+	Offsets  []time.Duration // Start of each profile in the clip
+	Duration time.Duration   // Length of the clip, including the trailing gap
+
+	cached bool // Kept by Cleanup for later runs
+}
+
+// newVoiceReference lays out the profiles of a clip at path
+func newVoiceReference(profiles []*VoiceProfile, path string) *VoiceReference {
+	ref := &VoiceReference{Path: path, Profiles: profiles}
+	for _, profile := range profiles {
+		ref.Offsets = append(ref.Offsets, ref.Duration)
+		ref.Duration += profile.Duration + profileGap
+	}
+	return ref
+}
+
+// BuildVoiceReference joins the profiles into one MP3 in tempDir, each
+// followed by a second of silence, killing ffmpeg when ctx is done
+func BuildVoiceReference(ctx context.Context, profiles []*VoiceProfile, tempDir string) (*VoiceReference, error) {
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	ref := newVoiceReference(profiles, filepath.Join(tempDir, fmt.Sprintf("gollmscribe_voices_%d.mp3", time.Now().UnixNano())))
+	if err := buildVoiceClip(ctx, profiles, ref.Path, os.TempDir()); err != nil {
+		_ = os.Remove(ref.Path)
+		return nil, err
+	}
+	return ref, nil
+}
+
+// buildVoiceClip writes the reference clip of the profiles to path. The
+// profiles are normalized in parallel into workDir, then joined with the
+// gap silence, which is kept in workDir for later clips.
+func buildVoiceClip(ctx context.Context, profiles []*VoiceProfile, path, workDir string) error {
+	if !FFmpegAvailable() {
+		return fmt.Errorf("voice profiles: %w", ErrFFmpegRequired)
+	}
+
+	gap, err := silenceFile(ctx, workDir, profileGap)
+	if err != nil {
+		return err
+	}
+	normalized, err := normalizeProfiles(ctx, profiles, workDir)
+	defer func() {
+		for _, file := range normalized {
+			_ = os.Remove(file)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	streams := make([]*ffmpeg.Stream, 0, 2*len(profiles))
+	for _, file := range normalized {
+		streams = append(streams, ffmpeg.Input(file).Audio(), ffmpeg.Input(gap).Audio())
+	}
+	if err := concatAudio(ctx, streams, path); err != nil {
+		return fmt.Errorf("failed to build voice reference: %w", err)
+	}
+	return nil
+}
+
+// normalizeProfiles writes the used part of each profile to a WAV in dir
+// in the format of the reference clip, several at a time. It returns the
+// files in profile order; on error, those written so far are still
+// returned for removal.
+func normalizeProfiles(ctx context.Context, profiles []*VoiceProfile, dir string) ([]string, error) {
+	files := make([]string, len(profiles))
+	errs := make([]error, len(profiles))
+	stamp := time.Now().UnixNano()
+	slots := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, profile := range profiles {
+		wg.Add(1)
+		go func(i int, profile *VoiceProfile) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			file := filepath.Join(dir, fmt.Sprintf("profile_%d_%d.wav", stamp, i))
+			err := runFFmpeg(ctx, normalizedAudio(ffmpeg.Input(profile.Path, ffmpeg.KwArgs{
+				"t": formatDuration(profile.Duration),
+			})).Output(file, ffmpeg.KwArgs{"acodec": "pcm_s16le"}).
+				OverWriteOutput().ErrorToStdOut())
+			if err != nil {
+				_ = os.Remove(file)
+				errs[i] = fmt.Errorf("failed to normalize voice profile %s: %w", filepath.Base(profile.Path), err)
+				return
+			}
+			files[i] = file
+		}(i, profile)
+	}
+	wg.Wait()
+
+	written := make([]string, 0, len(files))
+	for _, file := range files {
+		if file != "" {
+			written = append(written, file)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// silenceFile returns a WAV of duration silence in the format of the
+// reference clip, from dir if an earlier clip already created it
+func silenceFile(ctx context.Context, dir string, duration time.Duration) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("silence_%dms_44100_stereo.wav", duration.Milliseconds()))
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create silence file: %w", err)
+	}
+
+	// Written under a temporary name, so concurrent builds never read a
+	// partial file
+	building := filepath.Join(dir, fmt.Sprintf("silence_building_%d.wav", time.Now().UnixNano()))
+	err := runFFmpeg(ctx, ffmpeg.Input("anullsrc=r=44100:cl=stereo", ffmpeg.KwArgs{
+		"f": "lavfi",
+		"t": formatDuration(duration),
+	}).Output(building, ffmpeg.KwArgs{"acodec": "pcm_s16le"}).
+		OverWriteOutput().ErrorToStdOut())
+	if err == nil {
+		err = os.Rename(building, path)
+	}
+	if err != nil {
+		_ = os.Remove(building)
+		return "", fmt.Errorf("failed to create silence file: %w", err)
+	}
+	return path, nil
+}
+
+// VoiceReferenceKey hashes the profile recordings together with their
+// names, the durations used and the clip format, so a cached clip is
+// rebuilt when a profile is added, removed, renamed or re-recorded
+func VoiceReferenceKey(profiles []*VoiceProfile) (string, error) {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d\x00%s\x00", voiceReferenceVersion, profileGap)
+	for _, profile := range profiles {
+		_, _ = fmt.Fprintf(hash, "%s\x00%s\x00", profile.Name, profile.Duration)
+		file, err := os.Open(profile.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read voice profile: %w", err)
+		}
+		_, err = io.Copy(hash, file)
+		_ = file.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read voice profile: %w", err)
+		}
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CachedVoiceReference returns the reference clip of the profiles from
+// cacheDir, building it only when no clip of these exact profiles is
+// cached, and reports whether it was cached. Clips built from earlier
+// versions of the same profile directory are removed. Cleanup leaves the
+// returned clip in place for later runs. It kills ffmpeg when ctx is done.
+func CachedVoiceReference(ctx context.Context, profiles []*VoiceProfile, cacheDir string) (*VoiceReference, bool, error) {
+	if len(profiles) == 0 {
+		return nil, false, fmt.Errorf("no voice profiles")
+	}
+	key, err := VoiceReferenceKey(profiles)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return nil, false, fmt.Errorf("failed to create voice reference cache: %w", err)
+	}
+	prefix, err := voiceCachePrefix(profiles)
+	if err != nil {
+		return nil, false, err
+	}
+	ref := newVoiceReference(profiles, filepath.Join(cacheDir, prefix+key[:16]+".mp3"))
+	ref.cached = true
+
+	if info, err := os.Stat(ref.Path); err == nil && info.Size() > 0 {
+		return ref, true, nil
+	}
+
+	// Build under a temporary name, so concurrent runs never read a
+	// partial clip
+	building := filepath.Join(cacheDir, fmt.Sprintf("building_%d.mp3", time.Now().UnixNano()))
+	if err := buildVoiceClip(ctx, profiles, building, cacheDir); err != nil {
+		_ = os.Remove(building)
+		return nil, false, err
+	}
+	if err := os.Rename(building, ref.Path); err != nil {
+		_ = os.Remove(building)
+		return nil, false, fmt.Errorf("failed to cache voice reference: %w", err)
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(cacheDir, prefix+"*.mp3"))
+	for _, path := range stale {
+		if path != ref.Path {
+			_ = os.Remove(path)
+		}
+	}
+	return ref, false, nil
+}
+
+// Prepend writes the reference clip followed by the chunk to outputPath,
+// killing ffmpeg when ctx is done
+func (r *VoiceReference) Prepend(ctx context.Context, chunkPath, outputPath string) error {
+	streams := []*ffmpeg.Stream{
+		normalizedAudio(ffmpeg.Input(r.Path)),
+		normalizedAudio(ffmpeg.Input(chunkPath)),
+	}
+	if err := concatAudio(ctx, streams, outputPath); err != nil {
+		return fmt.Errorf("failed to prepend voice reference: %w", err)
+	}
+	return nil
+}
+
+// PrependData returns the reference clip followed by chunk audio held in
+// memory, as an MP3, without writing either to disk, killing ffmpeg when
+// ctx is done
+func (r *VoiceReference) PrependData(ctx context.Context, chunk []byte, format AudioFormat) ([]byte, error) {
+	streams := []*ffmpeg.Stream{
+		normalizedAudio(ffmpeg.Input(r.Path)),
+		normalizedAudio(ffmpeg.Input("pipe:0", ffmpeg.KwArgs{"f": string(format)})),
+	}
+	var buf bytes.Buffer
+	err := runFFmpeg(ctx, ffmpeg.Concat(streams, ffmpeg.KwArgs{"v": 0, "a": 1}).
+		Output("pipe:1", ffmpeg.KwArgs{
+			"f":      string(FormatMP3),
+			"acodec": mediatype.Encoder(string(FormatMP3)),
+			"ab":     "192k",
+		}).
+		WithInput(bytes.NewReader(chunk)).WithOutput(&buf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepend voice reference: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// voiceCachePrefix names cached clips after the directory of the
+// profiles, so the stale clips of a directory can be found
+func voiceCachePrefix(profiles []*VoiceProfile) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(profiles[0].Path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve voice profile directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return "voices_" + hex.EncodeToString(sum[:])[:12] + "_", nil
+}
+
+// Cleanup removes the reference clip, unless it is cached. It is safe
+// to call on nil.
+func (r *VoiceReference) Cleanup() error {
+	if r == nil || r.cached {
+		return nil
+	}
+	if err := os.Remove(r.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// normalizedAudio converts a stream to the sample format of the chunks,
+// which the concat filter requires of all its inputs
+func normalizedAudio(input *ffmpeg.Stream) *ffmpeg.Stream {
+	return input.Audio().Filter("aformat", nil, ffmpeg.KwArgs{
+		"sample_rates":    "44100",
+		"channel_layouts": "stereo",
+	})
+}
+
+// concatAudio joins audio streams into one MP3
+func concatAudio(ctx context.Context, streams []*ffmpeg.Stream, outputPath string) error {
+	return runFFmpeg(ctx, ffmpeg.Concat(streams, ffmpeg.KwArgs{"v": 0, "a": 1}).
+		Output(outputPath, ffmpeg.KwArgs{
+			"acodec": mediatype.Encoder(string(FormatMP3)),
+			"ab":     "192k",
+		}).
+		OverWriteOutput().ErrorToStdOut())
+}
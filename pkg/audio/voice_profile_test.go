@@ -0,0 +1,194 @@
+package audio
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckVoiceProfiles(t *testing.T) {
+	if FFmpegAvailable() {
+		t.Skip("test WAVs are read natively")
+	}
+	dir := t.TempDir()
+	writeTestWAV(t, filepath.Join(dir, "Jane_Doe.wav"), 5)
+	writeTestWAV(t, filepath.Join(dir, "Bob.wav"), 1)
+	writeTestWAV(t, filepath.Join(dir, "Carol.wav"), 20)
+	writeTestWAV(t, filepath.Join(dir, "jane doe.wav"), 5)
+	if err := os.WriteFile(filepath.Join(dir, "Broken.wav"), []byte("not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, problems := CheckVoiceProfiles(context.Background(), dir)
+	if len(profiles) != 4 {
+		t.Fatalf("CheckVoiceProfiles() read %d profiles, want 4", len(profiles))
+	}
+	if profiles[1].Name != "Carol" || profiles[1].Duration != MaxProfileDuration || profiles[1].Recorded != 20*time.Second {
+		t.Errorf("Carol = %+v", profiles[1])
+	}
+
+	var report []string
+	for _, problem := range problems {
+		report = append(report, problem.Error())
+	}
+	joined := strings.Join(report, "\n")
+	for _, want := range []string{"Broken.wav", "Bob.wav is only 1s long", "Carol.wav is cut", "are both voice profiles of"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("problems missing %q:\n%s", want, joined)
+		}
+	}
+	if len(problems) != 4 {
+		t.Errorf("%d problems, want 4:\n%s", len(problems), joined)
+	}
+
+	if _, err := LoadVoiceProfiles(context.Background(), dir); err == nil || !strings.Contains(err.Error(), "Broken.wav") {
+		t.Errorf("LoadVoiceProfiles() error = %v, want the broken file", err)
+	}
+}
+
+func TestVoiceReferenceKey(t *testing.T) {
+	dir := t.TempDir()
+	alice := filepath.Join(dir, "Alice.wav")
+	if err := os.WriteFile(alice, []byte("first take"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profiles := []*VoiceProfile{{Name: "Alice", Path: alice, Duration: 5 * time.Second}}
+
+	key, err := VoiceReferenceKey(profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := VoiceReferenceKey(profiles); again != key {
+		t.Error("VoiceReferenceKey() is not stable")
+	}
+
+	renamed := []*VoiceProfile{{Name: "Alice Smith", Path: alice, Duration: 5 * time.Second}}
+	if other, _ := VoiceReferenceKey(renamed); other == key {
+		t.Error("renaming a profile kept the key")
+	}
+	if err := os.WriteFile(alice, []byte("second take"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := VoiceReferenceKey(profiles); other == key {
+		t.Error("re-recording a profile kept the key")
+	}
+}
+
+func TestCachedVoiceReferenceHit(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	alice := filepath.Join(dir, "Alice.wav")
+	if err := os.WriteFile(alice, []byte("take"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profiles := []*VoiceProfile{{Name: "Alice", Path: alice, Duration: 5 * time.Second}}
+
+	// A clip of these profiles is served without building it
+	key, _ := VoiceReferenceKey(profiles)
+	prefix, err := voiceCachePrefix(profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(cacheDir, prefix+key[:16]+".mp3")
+	if err := os.WriteFile(path, []byte("clip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, cached, err := CachedVoiceReference(context.Background(), profiles, cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached || ref.Path != path || ref.Duration != 6*time.Second {
+		t.Errorf("CachedVoiceReference() = %+v, cached %v", ref, cached)
+	}
+	if err := ref.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Cleanup() removed the cached clip: %v", err)
+	}
+}
+
+func TestSpeakerName(t *testing.T) {
+	tests := map[string]string{
+		"alice.mp3":    "Alice",
+		"jane_doe.wav": "Jane Doe",
+		"Jane_Doe.mp3": "Jane Doe",
+		"McKenzie.m4a": "McKenzie",
+		"émile.ogg":    "Émile",
+	}
+	for file, want := range tests {
+		if got := speakerName(file); got != want {
+			t.Errorf("speakerName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestProfileLabels(t *testing.T) {
+	if FFmpegAvailable() {
+		t.Skip("test WAVs are read natively")
+	}
+	dir := t.TempDir()
+	writeTestWAV(t, filepath.Join(dir, "alice.wav"), 5)
+	writeTestWAV(t, filepath.Join(dir, "bob.wav"), 5)
+	writeTestWAV(t, filepath.Join(dir, "carol.wav"), 5)
+	labels := "alice.wav: Alice Chen\nbob.wav:\n  name: Bob Martin\n  role: customer\ndave.wav: Dave\n"
+	if err := os.WriteFile(filepath.Join(dir, ProfileLabelsFile), []byte(labels), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := LoadVoiceProfiles(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(profiles))
+	for i, profile := range profiles {
+		got[i] = profile.Name + "/" + profile.Role
+	}
+	if strings.Join(got, ",") != "Alice Chen/,Bob Martin/customer,Carol/" {
+		t.Errorf("profiles = %v", got)
+	}
+
+	_, problems := CheckVoiceProfiles(context.Background(), dir)
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "dave.wav") {
+		t.Errorf("problems = %v, want the label of the missing recording", problems)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ProfileLabelsFile), []byte("alice.wav: [oops"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVoiceProfiles(context.Background(), dir); err == nil || !strings.Contains(err.Error(), ProfileLabelsFile) {
+		t.Errorf("LoadVoiceProfiles() error = %v, want an invalid labels file", err)
+	}
+}
+
+func TestSilenceFileIsReused(t *testing.T) {
+	if !FFmpegAvailable() {
+		t.Skip("silence is generated with ffmpeg")
+	}
+	dir := t.TempDir()
+	path, err := silenceFile(context.Background(), dir, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := silenceFile(context.Background(), dir, time.Second)
+	if err != nil || again != path {
+		t.Fatalf("silenceFile() = %s, %v, want the cached %s", again, err, path)
+	}
+	if reused, _ := os.Stat(again); !reused.ModTime().Equal(info.ModTime()) {
+		t.Error("silenceFile() rewrote the cached file")
+	}
+	if other, _ := silenceFile(context.Background(), dir, 2*time.Second); other == path {
+		t.Error("silenceFile() reused a clip of another duration")
+	}
+}
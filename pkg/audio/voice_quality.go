@@ -0,0 +1,187 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// Voice profile quality limits
+const (
+	MinProfileSpeech      = 3 * time.Second // Less speech rarely identifies a voice
+	MinProfileSpeechRatio = 0.5             // Lower is mostly silence or noise
+	MaxProfileLevelSpread = 10.0            // dB between speech runs that suggests two voices
+
+	profileSilenceThreshold = -40                    // dB; quieter audio is not speech
+	profilePause            = 300 * time.Millisecond // Shortest pause between speech runs
+	minLevelRun             = 500 * time.Millisecond // Shorter runs are not compared
+)
+
+var meanVolumePattern = regexp.MustCompile(`mean_volume: (-?[0-9.]+) dB`)
+
+// ProfileQuality is how much clean speech of one voice a profile holds,
+// measured over the part of the recording that is used
+type ProfileQuality struct {
+	Speech      time.Duration
+	SpeechRatio float64 // Speech as a share of the part used
+	LevelSpread float64 // dB between the quietest and loudest speech run
+	Issues      []ProfileIssue
+}
+
+// ProfileIssue is a quality problem of a voice profile and how to fix it
+type ProfileIssue struct {
+	Problem string
+	Advice  string
+	Skip    bool // The profile would mislead speaker matching
+}
+
+// String returns the problem followed by the advice
+func (i ProfileIssue) String() string {
+	return i.Problem + "; " + i.Advice
+}
+
+// Usable reports whether no issue calls for leaving the profile out
+func (q *ProfileQuality) Usable() bool {
+	for _, issue := range q.Issues {
+		if issue.Skip {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckProfileQuality measures the speech in the used part of a voice
+// profile with ffmpeg. Too little speech, or speech at levels so far apart
+// that it is likely two voices, makes the profile unusable; a low share of
+// speech is only reported. It kills ffmpeg when ctx is done.
+func CheckProfileQuality(ctx context.Context, profile *VoiceProfile) (*ProfileQuality, error) {
+	if !FFmpegAvailable() {
+		return nil, fmt.Errorf("voice profile quality: %w", ErrFFmpegRequired)
+	}
+	intervals, err := detectSilence(ctx, profile.Path, 0, profile.Duration, profileSilenceThreshold, profilePause)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check voice profile %s: %w", filepath.Base(profile.Path), err)
+	}
+
+	runs := speechRuns(intervals, profile.Duration)
+	var levels []float64
+	for _, run := range runs {
+		if run.End-run.Start < minLevelRun {
+			continue
+		}
+		level, err := meanVolume(ctx, profile.Path, run)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check voice profile %s: %w", filepath.Base(profile.Path), err)
+		}
+		levels = append(levels, level)
+	}
+	return assessProfile(profile.Duration, runs, levels), nil
+}
+
+// speechRuns returns the parts of audio of the given duration between
+// the silent intervals
+func speechRuns(intervals []silence, duration time.Duration) []Span {
+	var runs []Span
+	var from time.Duration
+	for _, s := range intervals {
+		if s.start > from {
+			runs = append(runs, Span{Start: from, End: min(s.start, duration)})
+		}
+		from = max(from, s.end)
+	}
+	if from < duration {
+		runs = append(runs, Span{Start: from, End: duration})
+	}
+	return runs
+}
+
+// meanVolume returns the mean level of a span of inputPath in dB
+func meanVolume(ctx context.Context, inputPath string, span Span) (float64, error) {
+	var stderr bytes.Buffer
+	err := runFFmpeg(ctx, ffmpeg.Input(inputPath, ffmpeg.KwArgs{
+		"ss": formatDuration(span.Start),
+		"t":  formatDuration(span.End - span.Start),
+	}).Output("-", ffmpeg.KwArgs{
+		"af": "volumedetect",
+		"f":  "null",
+	}).WithErrorOutput(&stderr))
+	if err != nil {
+		return 0, fmt.Errorf("ffmpeg volume detection failed: %w", err)
+	}
+	m := meanVolumePattern.FindStringSubmatch(stderr.String())
+	if m == nil {
+		return 0, fmt.Errorf("ffmpeg reported no volume")
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
+
+// assessProfile rates the speech runs of a profile of the given used
+// duration, with the mean levels of its longer runs
+func assessProfile(used time.Duration, runs []Span, levels []float64) *ProfileQuality {
+	quality := &ProfileQuality{}
+	for _, run := range runs {
+		quality.Speech += run.End - run.Start
+	}
+	if used > 0 {
+		quality.SpeechRatio = float64(quality.Speech) / float64(used)
+	}
+	if len(levels) > 1 {
+		quietest, loudest := levels[0], levels[0]
+		for _, level := range levels[1:] {
+			quietest, loudest = min(quietest, level), max(loudest, level)
+		}
+		quality.LevelSpread = loudest - quietest
+	}
+
+	if quality.Speech < MinProfileSpeech {
+		quality.Issues = append(quality.Issues, ProfileIssue{
+			Problem: fmt.Sprintf("only %s of speech", quality.Speech.Round(time.Second/10)),
+			Advice:  fmt.Sprintf("record at least %s of the speaker talking", MinProfileSpeech),
+			Skip:    true,
+		})
+	} else if quality.SpeechRatio < MinProfileSpeechRatio {
+		quality.Issues = append(quality.Issues, ProfileIssue{
+			Problem: fmt.Sprintf("only %.0f%% of the recording is speech", quality.SpeechRatio*100),
+			Advice:  "trim the silence around the speaker or record somewhere quieter",
+		})
+	}
+	if quality.LevelSpread > MaxProfileLevelSpread {
+		quality.Issues = append(quality.Issues, ProfileIssue{
+			Problem: fmt.Sprintf("parts of the speech are %.0f dB louder than others, which suggests more than one voice", quality.LevelSpread),
+			Advice:  "record the speaker alone, or cut the other voice out",
+			Skip:    true,
+		})
+	}
+	return quality
+}
+
+// ScreenVoiceProfiles checks the quality of each profile and returns the
+// usable ones, with the quality of every profile that has issues, by path.
+// Without ffmpeg nothing can be measured and all profiles are kept. It
+// kills ffmpeg when ctx is done.
+func ScreenVoiceProfiles(ctx context.Context, profiles []*VoiceProfile) ([]*VoiceProfile, map[string]*ProfileQuality, error) {
+	if !FFmpegAvailable() {
+		return profiles, nil, nil
+	}
+	usable := make([]*VoiceProfile, 0, len(profiles))
+	flagged := make(map[string]*ProfileQuality)
+	for _, profile := range profiles {
+		quality, err := CheckProfileQuality(ctx, profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(quality.Issues) > 0 {
+			flagged[profile.Path] = quality
+		}
+		if quality.Usable() {
+			usable = append(usable, profile)
+		}
+	}
+	return usable, flagged, nil
+}
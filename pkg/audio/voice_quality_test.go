@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpeechRuns(t *testing.T) {
+	intervals := []silence{
+		{start: 0, end: time.Second},
+		{start: 4 * time.Second, end: 5 * time.Second},
+		{start: 9 * time.Second, end: 10 * time.Second},
+	}
+	runs := speechRuns(intervals, 10*time.Second)
+	want := []Span{{Start: time.Second, End: 4 * time.Second}, {Start: 5 * time.Second, End: 9 * time.Second}}
+	if len(runs) != len(want) {
+		t.Fatalf("speechRuns() = %+v, want %+v", runs, want)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Errorf("run %d = %+v, want %+v", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestAssessProfile(t *testing.T) {
+	tests := []struct {
+		name   string
+		runs   []Span
+		levels []float64
+		usable bool
+		issue  string
+	}{
+		{
+			name:   "clean",
+			runs:   []Span{{Start: 0, End: 4 * time.Second}, {Start: 5 * time.Second, End: 9 * time.Second}},
+			levels: []float64{-22, -25},
+			usable: true,
+		},
+		{
+			name:  "too little speech",
+			runs:  []Span{{Start: 0, End: 2 * time.Second}},
+			issue: "only 2s of speech",
+		},
+		{
+			name:   "mostly silence",
+			runs:   []Span{{Start: 0, End: 4 * time.Second}},
+			levels: []float64{-20},
+			usable: true,
+			issue:  "only 40% of the recording is speech",
+		},
+		{
+			name:   "two voices",
+			runs:   []Span{{Start: 0, End: 4 * time.Second}, {Start: 5 * time.Second, End: 9 * time.Second}},
+			levels: []float64{-18, -34},
+			issue:  "16 dB louder",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quality := assessProfile(10*time.Second, tt.runs, tt.levels)
+			if quality.Usable() != tt.usable {
+				t.Errorf("Usable() = %v, want %v (issues %+v)", quality.Usable(), tt.usable, quality.Issues)
+			}
+			if tt.issue == "" {
+				if len(quality.Issues) > 0 {
+					t.Errorf("issues = %+v, want none", quality.Issues)
+				}
+				return
+			}
+			if len(quality.Issues) != 1 || !strings.Contains(quality.Issues[0].Problem, tt.issue) || quality.Issues[0].Advice == "" {
+				t.Errorf("issues = %+v, want %q with advice", quality.Issues, tt.issue)
+			}
+		})
+	}
+}
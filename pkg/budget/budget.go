@@ -0,0 +1,233 @@
+// Package budget enforces hard limits on how much audio is sent to
+// providers per file and per day.
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// ErrBudgetExceeded is matched by every ExceededError
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// ExceededError describes which limit a request would exceed
+type ExceededError struct {
+	Limit string  // Name of the config setting, e.g. "max_tokens_per_day"
+	Value float64 // Value the request would reach
+	Max   float64 // Configured limit
+}
+
+// Error implements the error interface
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s would reach %g (limit %g)", e.Limit, e.Value, e.Max)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) match
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Estimate is the expected usage of transcribing one file
+type Estimate struct {
+	Chunks int
+	Tokens int
+	Cost   float64 // USD
+}
+
+// Guard checks estimated usage against the configured budget and keeps a
+// daily usage ledger. A nil Guard allows everything.
+type Guard struct {
+	cfg     config.BudgetConfig
+	mu      *sync.Mutex
+	usage   dailyUsage
+	runCost float64
+	now     func() time.Time
+}
+
+// ledgerLocks serializes guards sharing a ledger file, e.g. the
+// transcribers of different watch routes
+var (
+	ledgerLocksMu sync.Mutex
+	ledgerLocks   = make(map[string]*sync.Mutex)
+)
+
+// ledgerLock returns the lock shared by all guards using path
+func ledgerLock(path string) *sync.Mutex {
+	if path == "" {
+		return &sync.Mutex{}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	ledgerLocksMu.Lock()
+	defer ledgerLocksMu.Unlock()
+	if ledgerLocks[path] == nil {
+		ledgerLocks[path] = &sync.Mutex{}
+	}
+	return ledgerLocks[path]
+}
+
+// dailyUsage is the persisted ledger for a single day
+type dailyUsage struct {
+	Date   string  `json:"date"`
+	Chunks int     `json:"chunks"`
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// New creates a guard for cfg, or returns nil if no limit is set
+func New(cfg config.BudgetConfig) *Guard {
+	if cfg.MaxChunksPerFile <= 0 && cfg.MaxTokensPerFile <= 0 && cfg.MaxTokensPerDay <= 0 &&
+		cfg.MaxCostPerFile <= 0 && cfg.MaxCostPerDay <= 0 && cfg.MaxCostPerRun <= 0 {
+		return nil
+	}
+	return &Guard{cfg: cfg, mu: ledgerLock(cfg.LedgerPath), now: time.Now}
+}
+
+// EstimateCost converts tokens into an estimated cost using the configured
+// flat price, for models missing from the pricing table
+func (g *Guard) EstimateCost(tokens int) float64 {
+	if g == nil {
+		return 0
+	}
+	return float64(tokens) / 1e6 * g.cfg.PricePerMillionTokens
+}
+
+// Reserve checks a file's estimated usage against the per-file, per-run and
+// daily limits and records it. Per-file and per-run violations always fail;
+// daily violations fail or, with on_exceed "pause", block until the next day.
+func (g *Guard) Reserve(ctx context.Context, est Estimate) error {
+	if g == nil {
+		return nil
+	}
+
+	if err := g.checkFile(est); err != nil {
+		return err
+	}
+
+	for {
+		err := g.reserve(est)
+		var exceeded *ExceededError
+		if err == nil || g.cfg.OnExceed != "pause" || !errors.As(err, &exceeded) || exceeded.Limit == "max_cost_per_run" {
+			return err
+		}
+
+		now := g.now()
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		logger.WithComponent("budget").Warn().
+			Err(err).
+			Time("resume_at", tomorrow).
+			Msg("Daily budget exhausted, pausing until tomorrow")
+
+		timer := time.NewTimer(tomorrow.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// checkFile validates the per-file limits
+func (g *Guard) checkFile(est Estimate) error {
+	switch {
+	case g.cfg.MaxChunksPerFile > 0 && est.Chunks > g.cfg.MaxChunksPerFile:
+		return &ExceededError{Limit: "max_chunks_per_file", Value: float64(est.Chunks), Max: float64(g.cfg.MaxChunksPerFile)}
+	case g.cfg.MaxTokensPerFile > 0 && est.Tokens > g.cfg.MaxTokensPerFile:
+		return &ExceededError{Limit: "max_tokens_per_file", Value: float64(est.Tokens), Max: float64(g.cfg.MaxTokensPerFile)}
+	case g.cfg.MaxCostPerFile > 0 && est.Cost > g.cfg.MaxCostPerFile:
+		return &ExceededError{Limit: "max_cost_per_file", Value: est.Cost, Max: g.cfg.MaxCostPerFile}
+	}
+	return nil
+}
+
+// reserve adds the usage to the run total and today's ledger if it fits
+// the per-run and daily limits
+func (g *Guard) reserve(est Estimate) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if max := g.cfg.MaxCostPerRun; max > 0 && g.runCost+est.Cost > max {
+		return &ExceededError{Limit: "max_cost_per_run", Value: g.runCost + est.Cost, Max: max}
+	}
+
+	if err := g.load(); err != nil {
+		return err
+	}
+
+	today := g.now().Format(time.DateOnly)
+	if g.usage.Date != today {
+		g.usage = dailyUsage{Date: today}
+	}
+
+	if max := g.cfg.MaxTokensPerDay; max > 0 && g.usage.Tokens+est.Tokens > max {
+		return &ExceededError{Limit: "max_tokens_per_day", Value: float64(g.usage.Tokens + est.Tokens), Max: float64(max)}
+	}
+	if max := g.cfg.MaxCostPerDay; max > 0 && g.usage.Cost+est.Cost > max {
+		return &ExceededError{Limit: "max_cost_per_day", Value: g.usage.Cost + est.Cost, Max: max}
+	}
+
+	g.runCost += est.Cost
+	g.usage.Chunks += est.Chunks
+	g.usage.Tokens += est.Tokens
+	g.usage.Cost += est.Cost
+	return g.save()
+}
+
+// load refreshes the usage from the ledger file; a missing file keeps
+// the in-memory ledger
+func (g *Guard) load() error {
+	if g.cfg.LedgerPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(g.cfg.LedgerPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read budget ledger: %w", err)
+	}
+	if err == nil {
+		var usage dailyUsage
+		if err := json.Unmarshal(data, &usage); err != nil {
+			return fmt.Errorf("failed to parse budget ledger %s: %w", g.cfg.LedgerPath, err)
+		}
+		g.usage = usage
+	}
+	return nil
+}
+
+// save writes the ledger file atomically
+func (g *Guard) save() error {
+	if g.cfg.LedgerPath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(g.usage)
+	if err != nil {
+		return fmt.Errorf("failed to encode budget ledger: %w", err)
+	}
+	if dir := filepath.Dir(g.cfg.LedgerPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create ledger directory: %w", err)
+		}
+	}
+
+	tmp := g.cfg.LedgerPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write budget ledger: %w", err)
+	}
+	if err := os.Rename(tmp, g.cfg.LedgerPath); err != nil {
+		return fmt.Errorf("failed to write budget ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+func TestNewWithoutLimits(t *testing.T) {
+	g := New(config.BudgetConfig{PricePerMillionTokens: 1})
+	if g != nil {
+		t.Fatal("New() returned a guard without limits")
+	}
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1000, Tokens: 1e9}); err != nil {
+		t.Errorf("nil guard Reserve() = %v, want nil", err)
+	}
+}
+
+func TestPerFileLimits(t *testing.T) {
+	g := New(config.BudgetConfig{
+		MaxChunksPerFile:      4,
+		MaxCostPerFile:        0.5,
+		PricePerMillionTokens: 1,
+	})
+
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 5, Tokens: 10}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Reserve(5 chunks) = %v, want ErrBudgetExceeded", err)
+	}
+
+	err := g.Reserve(context.Background(), Estimate{Chunks: 1, Tokens: 600_000, Cost: g.EstimateCost(600_000)})
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != "max_cost_per_file" {
+		t.Errorf("Reserve(600k tokens) = %v, want max_cost_per_file", err)
+	}
+
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 4, Tokens: 400_000, Cost: g.EstimateCost(400_000)}); err != nil {
+		t.Errorf("Reserve() within limits = %v", err)
+	}
+}
+
+func TestDailyLimitPersistsAndResets(t *testing.T) {
+	cfg := config.BudgetConfig{
+		MaxTokensPerDay: 1000,
+		LedgerPath:      filepath.Join(t.TempDir(), "usage.json"),
+	}
+	day := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	g := New(cfg)
+	g.now = func() time.Time { return day }
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Tokens: 700}); err != nil {
+		t.Fatalf("Reserve() = %v", err)
+	}
+
+	// A new guard (e.g. the next run) sees the persisted usage
+	g = New(cfg)
+	g.now = func() time.Time { return day }
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Tokens: 400}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Reserve() over daily limit = %v, want ErrBudgetExceeded", err)
+	}
+
+	g.now = func() time.Time { return day.Add(24 * time.Hour) }
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Tokens: 400}); err != nil {
+		t.Errorf("Reserve() on the next day = %v", err)
+	}
+}
+
+func TestPauseHonoursContext(t *testing.T) {
+	g := New(config.BudgetConfig{MaxTokensPerDay: 10, OnExceed: "pause"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := g.Reserve(ctx, Estimate{Chunks: 1, Tokens: 100})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Reserve() = %v, want deadline and budget errors", err)
+	}
+}
+
+func TestRunLimitIsNotPaused(t *testing.T) {
+	g := New(config.BudgetConfig{MaxCostPerRun: 1, OnExceed: "pause", LedgerPath: filepath.Join(t.TempDir(), "usage.json")})
+
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Cost: 0.6}); err != nil {
+		t.Fatalf("Reserve() = %v", err)
+	}
+
+	err := g.Reserve(context.Background(), Estimate{Chunks: 1, Cost: 0.6})
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != "max_cost_per_run" {
+		t.Errorf("Reserve() over run limit = %v, want max_cost_per_run", err)
+	}
+
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Cost: 0.3}); err != nil {
+		t.Errorf("Reserve() within remaining run budget = %v", err)
+	}
+}
+
+func TestAllowances(t *testing.T) {
+	cfg := config.BudgetConfig{
+		MaxTokensPerDay: 1000,
+		MaxCostPerDay:   2,
+		LedgerPath:      filepath.Join(t.TempDir(), "usage.json"),
+	}
+	day := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	g := New(cfg)
+	g.now = func() time.Time { return day }
+	if err := g.Reserve(context.Background(), Estimate{Chunks: 1, Tokens: 600, Cost: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	allowances, err := g.Allowances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allowances) != 2 || allowances[0].Remaining != 400 || allowances[1].Remaining != 1.5 {
+		t.Fatalf("Allowances() = %+v, want 400 tokens and $1.5 left", allowances)
+	}
+
+	if err := CheckAllowances(allowances, Estimate{Tokens: 300, Cost: 1}, 0); err != nil {
+		t.Errorf("CheckAllowances() within the allowance = %v", err)
+	}
+	// Keeping 20% free leaves 200 tokens
+	var quotaErr *QuotaError
+	if err := CheckAllowances(allowances, Estimate{Tokens: 300, Cost: 1}, 0.2); !errors.As(err, &quotaErr) || quotaErr.Allowance.Unit != UnitTokens {
+		t.Errorf("CheckAllowances() keeping 20%% free = %v, want a tokens QuotaError", err)
+	}
+
+	// The next day starts afresh
+	g.now = func() time.Time { return day.AddDate(0, 0, 1) }
+	if allowances, _ := g.Allowances(); allowances[0].Remaining != 1000 {
+		t.Errorf("Allowances() the next day = %+v, want the full allowance", allowances)
+	}
+	var none *Guard
+	if allowances, err := none.Allowances(); allowances != nil || err != nil {
+		t.Errorf("nil guard Allowances() = %v, %v", allowances, err)
+	}
+}
@@ -0,0 +1,108 @@
+package budget
+
+import (
+	"fmt"
+	"time"
+)
+
+// Units an Allowance is counted in
+const (
+	UnitRequests = "requests"
+	UnitTokens   = "tokens"
+	UnitCost     = "USD"
+)
+
+// Allowance is what is left of a daily limit, either one of the budget's
+// or a provider's quota
+type Allowance struct {
+	Name      string // e.g. "max_tokens_per_day" or "groq requests per day"
+	Unit      string // UnitRequests, UnitTokens or UnitCost
+	Remaining float64
+	Max       float64
+}
+
+// Needed returns how much of the allowance est uses
+func (a Allowance) Needed(est Estimate) float64 {
+	switch a.Unit {
+	case UnitRequests:
+		return float64(est.Chunks)
+	case UnitTokens:
+		return float64(est.Tokens)
+	case UnitCost:
+		return est.Cost
+	}
+	return 0
+}
+
+// QuotaError is returned when a batch would use more of an allowance than
+// is left, keeping the configured share free
+type QuotaError struct {
+	Allowance Allowance
+	Needed    float64
+	Keep      float64 // Of the allowance to leave free
+}
+
+// Error implements the error interface
+func (e *QuotaError) Error() string {
+	msg := fmt.Sprintf("batch needs about %s %s of %s, but only %s of %s are left",
+		formatAmount(e.Needed), e.Allowance.Unit, e.Allowance.Name,
+		formatAmount(e.Allowance.Remaining), formatAmount(e.Allowance.Max))
+	if e.Keep > 0 {
+		msg += fmt.Sprintf(" and %s are kept free", formatAmount(e.Keep))
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) match
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// CheckAllowances returns a QuotaError for the first allowance est does
+// not fit in while leaving minFree, a share between 0 and 1 of its
+// maximum, unused
+func CheckAllowances(allowances []Allowance, est Estimate, minFree float64) error {
+	for _, a := range allowances {
+		keep := a.Max * minFree
+		if needed := a.Needed(est); needed > a.Remaining-keep {
+			return &QuotaError{Allowance: a, Needed: needed, Keep: keep}
+		}
+	}
+	return nil
+}
+
+// Allowances returns what is left today of the daily limits, from the
+// ledger. A nil Guard has no limits.
+func (g *Guard) Allowances() ([]Allowance, error) {
+	if g == nil {
+		return nil, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	usage := g.usage
+	if usage.Date != g.now().Format(time.DateOnly) {
+		usage = dailyUsage{}
+	}
+
+	var allowances []Allowance
+	if max := g.cfg.MaxTokensPerDay; max > 0 {
+		allowances = append(allowances, Allowance{Name: "max_tokens_per_day", Unit: UnitTokens,
+			Remaining: float64(max - usage.Tokens), Max: float64(max)})
+	}
+	if max := g.cfg.MaxCostPerDay; max > 0 {
+		allowances = append(allowances, Allowance{Name: "max_cost_per_day", Unit: UnitCost,
+			Remaining: max - usage.Cost, Max: max})
+	}
+	return allowances, nil
+}
+
+// formatAmount formats whole amounts without decimals and costs with four
+func formatAmount(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.4f", v)
+}
@@ -0,0 +1,121 @@
+// Package cache keeps provider responses on disk so re-running an
+// interrupted transcription does not pay again for finished chunks
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataHit marks a response served from the cache
+const MetadataHit = "cache_hit"
+
+// Cache stores provider responses addressed by Key. A nil Cache never
+// hits and discards writes.
+type Cache struct {
+	dir    string
+	cipher *encryption.Cipher
+}
+
+// Option configures a Cache
+type Option func(*Cache)
+
+// WithCipher encrypts cached responses with c
+func WithCipher(c *encryption.Cipher) Option {
+	return func(cache *Cache) {
+		cache.cipher = c
+	}
+}
+
+// New creates a cache rooted at dir
+func New(dir string, opts ...Option) (*Cache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &Cache{dir: dir}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Key returns the SHA256 of the chunk key (see audio.ChunkKey) together
+// with everything that changes the response: the provider, its models and
+// the prompt. Keying by source span rather than the re-encoded chunk audio
+// keeps keys stable across ffmpeg versions.
+func Key(chunkKey, provider string, models []string, prompt string) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s", chunkKey, provider, strings.Join(models, ","), prompt)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the cached response for key, if any
+func (c *Cache) Get(key string) (*providers.TranscriptionResult, bool, error) {
+	if c == nil {
+		return nil, false, nil
+	}
+
+	data, err := os.ReadFile(c.pathFor(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached response: %w", err)
+	}
+
+	data, err = c.cipher.Decrypt(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached response: %w", err)
+	}
+
+	var result providers.TranscriptionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false, fmt.Errorf("failed to parse cached response: %w", err)
+	}
+	return &result, true, nil
+}
+
+// Put stores the response for key
+func (c *Cache) Put(key string, result *providers.TranscriptionResult) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	data, err = c.cipher.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt response: %w", err)
+	}
+
+	// Write atomically so an interrupted run never leaves a partial entry
+	path := c.pathFor(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cached response: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to cache response: %w", err)
+	}
+	return nil
+}
+
+// pathFor returns the file path for a key
+func (c *Cache) pathFor(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestKeyCoversChunkProviderAndPrompt(t *testing.T) {
+	key := func(chunk, provider, prompt string, models ...string) string {
+		return Key(chunk, provider, models, prompt)
+	}
+
+	base := key("abc-0-60000", "gemini", "prompt", "gemini-2.5-flash")
+	if base != key("abc-0-60000", "gemini", "prompt", "gemini-2.5-flash") {
+		t.Error("Key() is not deterministic")
+	}
+	for name, other := range map[string]string{
+		"chunk":    key("abc-60000-120000", "gemini", "prompt", "gemini-2.5-flash"),
+		"provider": key("abc-0-60000", "groq", "prompt", "gemini-2.5-flash"),
+		"model":    key("abc-0-60000", "gemini", "prompt", "gemini-2.5-pro"),
+		"prompt":   key("abc-0-60000", "gemini", "other prompt", "gemini-2.5-flash"),
+	} {
+		if other == base {
+			t.Errorf("Key() ignores the %s", name)
+		}
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := c.Get("missing"); found || err != nil {
+		t.Fatalf("Get(missing) = %v, %v", found, err)
+	}
+
+	want := &providers.TranscriptionResult{
+		Text:     "hello",
+		Segments: []providers.TranscriptionSegment{{Text: "hello", End: 2 * time.Second}},
+		Metadata: map[string]interface{}{"model": "gemini-2.5-flash"},
+	}
+	if err := c.Put("k", want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, found, err := c.Get("k")
+	if err != nil || !found {
+		t.Fatalf("Get(k) = %v, %v", found, err)
+	}
+	if got.Text != want.Text || len(got.Segments) != 1 || got.Segments[0].End != 2*time.Second || got.Metadata["model"] != "gemini-2.5-flash" {
+		t.Errorf("Get(k) = %+v, want %+v", got, want)
+	}
+
+	var none *Cache
+	if err := none.Put("k", want); err != nil {
+		t.Errorf("nil Put() = %v", err)
+	}
+	if _, found, _ := none.Get("k"); found {
+		t.Error("nil Get() hit")
+	}
+}
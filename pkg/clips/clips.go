@@ -0,0 +1,204 @@
+// Package clips selects moments of a transcript, by segment or time range,
+// and cuts them out of the source media, e.g. for highlight reels.
+package clips
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// maxNameWords is how many words of its text a clip file is named after
+const maxNameWords = 6
+
+// Clip is a moment of a recording with what was said in it
+type Clip struct {
+	Text  string        `json:"text,omitempty"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// FromSegments returns a clip for each run of consecutive segment indices
+// of result, counted from 0, in the order given
+func FromSegments(result *transcriber.TranscribeResult, indices []int) ([]Clip, error) {
+	var clips []Clip
+	last := -2
+	for _, i := range indices {
+		if i < 0 || i >= len(result.Segments) {
+			return nil, fmt.Errorf("segment %d out of range, the transcript has %d", i, len(result.Segments))
+		}
+		segment := result.Segments[i]
+		if segment.End <= segment.Start {
+			return nil, fmt.Errorf("segment %d has no timestamps", i)
+		}
+		text := strings.TrimSpace(segment.Text)
+		if i == last+1 && len(clips) > 0 {
+			clip := &clips[len(clips)-1]
+			clip.End = segment.End
+			clip.Text = strings.TrimSpace(clip.Text + " " + text)
+		} else {
+			clips = append(clips, Clip{Text: text, Start: segment.Start, End: segment.End})
+		}
+		last = i
+	}
+	return clips, nil
+}
+
+// ParseSelection reads a JSON array of segment indices of result, or of
+// objects with start and end in nanoseconds such as the output of
+// "quotes --json". Objects without text take it from the transcript.
+func ParseSelection(data []byte, result *transcriber.TranscribeResult) ([]Clip, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("selection must be a JSON array: %w", err)
+	}
+
+	var clips []Clip
+	var indices []int
+	flush := func() error {
+		if len(indices) == 0 {
+			return nil
+		}
+		fromSegments, err := FromSegments(result, indices)
+		if err != nil {
+			return err
+		}
+		clips = append(clips, fromSegments...)
+		indices = nil
+		return nil
+	}
+
+	for n, entry := range entries {
+		var index int
+		if err := json.Unmarshal(entry, &index); err == nil {
+			indices = append(indices, index)
+			continue
+		}
+		var clip Clip
+		if err := json.Unmarshal(entry, &clip); err != nil {
+			return nil, fmt.Errorf("entry %d is neither a segment index nor a clip: %w", n, err)
+		}
+		if clip.End <= clip.Start {
+			return nil, fmt.Errorf("entry %d ends before it starts", n)
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if clip.Text == "" {
+			clip.Text = TextBetween(result, clip.Start, clip.End)
+		}
+		clips = append(clips, clip)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return clips, nil
+}
+
+// ParseRange parses a time range such as "1:30-2:10", "00:01:30.5-00:02:10"
+// or "90-130", in seconds, MM:SS or HH:MM:SS
+func ParseRange(s string) (Clip, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Clip{}, fmt.Errorf("range %q is not START-END", s)
+	}
+	start, err := parseTime(from)
+	if err != nil {
+		return Clip{}, fmt.Errorf("range %q: %w", s, err)
+	}
+	end, err := parseTime(to)
+	if err != nil {
+		return Clip{}, fmt.Errorf("range %q: %w", s, err)
+	}
+	if end <= start {
+		return Clip{}, fmt.Errorf("range %q ends before it starts", s)
+	}
+	return Clip{Start: start, End: end}, nil
+}
+
+// parseTime parses seconds, MM:SS or HH:MM:SS, with optional fractions of
+// a second
+func parseTime(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var total float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i < len(parts)-1 && n != float64(int(n))) {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+// TextBetween returns the text of the segments of result overlapping
+// start to end
+func TextBetween(result *transcriber.TranscribeResult, start, end time.Duration) string {
+	if result == nil {
+		return ""
+	}
+	var texts []string
+	for _, segment := range result.Segments {
+		if segment.Start < end && segment.End > start {
+			texts = append(texts, strings.TrimSpace(segment.Text))
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// FileName names the nth clip, counted from 1, after the first words of
+// its text, e.g. "03-we-ship-the-beta-in.mp4"
+func (c Clip) FileName(n int, ext string) string {
+	var words []string
+	for _, word := range strings.Fields(c.Text) {
+		slug := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, word)
+		if slug == "" {
+			continue
+		}
+		words = append(words, slug)
+		if len(words) == maxNameWords {
+			break
+		}
+	}
+	name := fmt.Sprintf("%02d", n)
+	if len(words) > 0 {
+		name += "-" + strings.Join(words, "-")
+	}
+	return name + ext
+}
+
+// Cut writes every clip of inputPath, widened by padding on both sides, to
+// outputDir with the extension of inputPath, and returns the paths written,
+// killing ffmpeg when ctx is done
+func Cut(ctx context.Context, inputPath, outputDir string, clips []Clip, padding time.Duration) ([]string, error) {
+	ext := filepath.Ext(inputPath)
+	paths := make([]string, 0, len(clips))
+	for i, clip := range clips {
+		span := audio.Span{Start: clip.Start - padding, End: clip.End + padding}
+		if span.Start < 0 {
+			span.Start = 0
+		}
+		path := filepath.Join(outputDir, clip.FileName(i+1, ext))
+		if err := audio.ExtractClip(ctx, inputPath, path, span); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,73 @@
+package clips
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func testResult() *transcriber.TranscribeResult {
+	return &transcriber.TranscribeResult{Segments: []providers.TranscriptionSegment{
+		{Text: "Welcome back.", Start: 0, End: 5 * time.Second},
+		{Text: "We ship the beta", Start: 5 * time.Second, End: 9 * time.Second},
+		{Text: "in March, finally!", Start: 9 * time.Second, End: 12 * time.Second},
+		{Text: "Questions?", Start: 12 * time.Second, End: 14 * time.Second},
+	}}
+}
+
+func TestParseSelection(t *testing.T) {
+	result := testResult()
+	clips, err := ParseSelection([]byte(`[1, 2, {"start": 12000000000, "end": 14000000000}, 0]`), result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Clip{
+		{Text: "We ship the beta in March, finally!", Start: 5 * time.Second, End: 12 * time.Second},
+		{Text: "Questions?", Start: 12 * time.Second, End: 14 * time.Second},
+		{Text: "Welcome back.", Start: 0, End: 5 * time.Second},
+	}
+	if len(clips) != len(want) {
+		t.Fatalf("ParseSelection() = %+v, want %+v", clips, want)
+	}
+	for i := range want {
+		if clips[i] != want[i] {
+			t.Errorf("clip %d = %+v, want %+v", i, clips[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{`{}`, `[7]`, `["1"]`, `[{"start": 5, "end": 1}]`} {
+		if _, err := ParseSelection([]byte(bad), result); err == nil {
+			t.Errorf("ParseSelection(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := map[string]Clip{
+		"90-130":              {Start: 90 * time.Second, End: 130 * time.Second},
+		"1:30-2:10":           {Start: 90 * time.Second, End: 130 * time.Second},
+		"00:01:30.5-00:02:10": {Start: 90500 * time.Millisecond, End: 130 * time.Second},
+	}
+	for s, want := range tests {
+		if got, err := ParseRange(s); err != nil || got != want {
+			t.Errorf("ParseRange(%q) = %+v, %v, want %+v", s, got, err, want)
+		}
+	}
+	for _, bad := range []string{"90", "2:10-1:30", "1:x-2:00", "1.5:00-2:00"} {
+		if _, err := ParseRange(bad); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	clip := Clip{Text: "We ship the beta in March, finally! Right?"}
+	if got := clip.FileName(3, ".mp4"); got != "03-we-ship-the-beta-in-march.mp4" {
+		t.Errorf("FileName() = %q", got)
+	}
+	if got := (Clip{Text: "…"}).FileName(12, ".mp3"); got != "12.mp3" {
+		t.Errorf("FileName() without words = %q", got)
+	}
+}
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/pricing"
 )
 
 // Config represents the application configuration
@@ -25,19 +27,58 @@ type Config struct {
 	// Watch Configuration
 	Watch WatchConfig `yaml:"watch" mapstructure:"watch"`
 
+	// Spending Limits
+	Budget BudgetConfig `yaml:"budget" mapstructure:"budget"`
+
+	// Rules routing files by their tags, from notes and directory names
+	Rules []RuleConfig `yaml:"rules" mapstructure:"rules"`
+
+	// Privacy / Data Residency
+	Privacy PrivacyConfig `yaml:"privacy" mapstructure:"privacy"`
+
+	// Encryption at rest for transcripts and history
+	Encryption EncryptionConfig `yaml:"encryption" mapstructure:"encryption"`
+
+	// On-disk cache of provider responses
+	Cache CacheConfig `yaml:"cache" mapstructure:"cache"`
+
+	// Run manifests listing outputs and their hashes
+	Manifest ManifestConfig `yaml:"manifest" mapstructure:"manifest"`
+
+	// Summary of the merged transcript
+	Summary SummaryConfig `yaml:"summary" mapstructure:"summary"`
+
+	// Chapters detected in the merged transcript
+	Chapters ChaptersConfig `yaml:"chapters" mapstructure:"chapters"`
+
+	// Translation of each segment, for bilingual subtitles
+	Translation TranslationConfig `yaml:"translation" mapstructure:"translation"`
+
+	// Telephony preset for stereo call recordings
+	Call CallConfig `yaml:"call" mapstructure:"call"`
+
+	// Per-model prices used for cost estimates (merged over the built-in table)
+	Pricing pricing.Table `yaml:"pricing" mapstructure:"pricing"`
+
 	// Logging Configuration
 	Logging logger.Config `yaml:"logging" mapstructure:"logging"`
 }
 
 // ProviderConfig contains LLM provider settings
 type ProviderConfig struct {
-	// Provider name (gemini, openai, etc.)
+	// Provider name (gemini, groq, whispercpp)
 	Name string `yaml:"name" mapstructure:"name"`
 
 	// API Configuration
 	APIKey  string `yaml:"api_key" mapstructure:"api_key"`
 	BaseURL string `yaml:"base_url" mapstructure:"base_url"`
 
+	// Additional API keys to rotate between (api_key is used as the first key)
+	APIKeys []string `yaml:"api_keys" mapstructure:"api_keys"`
+
+	// Key selection strategy when multiple keys are set (round_robin, lru)
+	KeyStrategy string `yaml:"key_strategy" mapstructure:"key_strategy"`
+
 	// Request Configuration
 	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
 	Retries int           `yaml:"retries" mapstructure:"retries"`
@@ -46,6 +87,92 @@ type ProviderConfig struct {
 	Model       string  `yaml:"model" mapstructure:"model"`
 	Temperature float32 `yaml:"temperature" mapstructure:"temperature"`
 	MaxTokens   int     `yaml:"max_tokens" mapstructure:"max_tokens"`
+
+	// Model used to embed text for semantic search (gemini only, e.g.
+	// gemini-embedding-001); empty disables embeddings
+	EmbeddingModel string `yaml:"embedding_model" mapstructure:"embedding_model"`
+
+	// Vertex AI Configuration (gemini only)
+	Vertex VertexConfig `yaml:"vertex" mapstructure:"vertex"`
+
+	// Client-side rate limits shared by all chunk workers
+	RateLimit RateLimitConfig `yaml:"rate_limit" mapstructure:"rate_limit"`
+
+	// Providers to fail over to, in order, when this one returns errors
+	Fallbacks []ProviderRef `yaml:"fallbacks" mapstructure:"fallbacks"`
+
+	// Transcribe every chunk with several providers and reconcile the results
+	Ensemble EnsembleConfig `yaml:"ensemble" mapstructure:"ensemble"`
+}
+
+// EnsembleConfig contains ensemble transcription settings.
+// The ensemble is enabled when at least one member is configured.
+type EnsembleConfig struct {
+	Method  string        `yaml:"method" mapstructure:"method"` // vote, adjudicate
+	Weight  float64       `yaml:"weight" mapstructure:"weight"` // Weight of the primary provider
+	Members []ProviderRef `yaml:"members" mapstructure:"members"`
+}
+
+// Enabled reports whether ensemble mode is configured
+func (e EnsembleConfig) Enabled() bool {
+	return len(e.Members) > 0
+}
+
+// RequiresAPIKey reports whether the provider needs an API key:
+// local servers and Gemini via Vertex AI authenticate differently
+func (p ProviderConfig) RequiresAPIKey() bool {
+	switch {
+	case p.Name == "whispercpp":
+		return false
+	case p.Name == "gemini" && p.Vertex.Enabled():
+		return false
+	}
+	return true
+}
+
+// RateLimitConfig contains client-side request throttling settings (0 disables a limit)
+type RateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute" mapstructure:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute" mapstructure:"tokens_per_minute"`
+
+	// Requests in flight per API key, across all providers of the process
+	// and, with LockDir, all processes sharing the directory
+	MaxConcurrentPerKey int `yaml:"max_concurrent_per_key" mapstructure:"max_concurrent_per_key"`
+
+	// Directory of the lease files sharing the per-key cap between
+	// watchers and servers, e.g. on a shared volume ("" caps each process
+	// on its own), and how long a lease of a crashed process lasts
+	LockDir  string        `yaml:"lock_dir" mapstructure:"lock_dir"`
+	LeaseTTL time.Duration `yaml:"lease_ttl" mapstructure:"lease_ttl"`
+}
+
+// ProviderRef describes an additional provider used in a fallback chain or
+// ensemble. Timeout, retries and temperature are inherited from the primary provider.
+type ProviderRef struct {
+	Name    string `yaml:"name" mapstructure:"name"`
+	APIKey  string `yaml:"api_key" mapstructure:"api_key"`
+	Model   string `yaml:"model" mapstructure:"model"`
+	BaseURL string `yaml:"base_url" mapstructure:"base_url"`
+
+	// Voting weight when used as an ensemble member (default 1)
+	Weight float64 `yaml:"weight" mapstructure:"weight"`
+}
+
+// VertexConfig contains Google Vertex AI settings for the Gemini provider
+type VertexConfig struct {
+	// GCP project ID; setting it enables Vertex AI mode
+	Project string `yaml:"project" mapstructure:"project"`
+
+	// GCP region (e.g., us-central1, global)
+	Location string `yaml:"location" mapstructure:"location"`
+
+	// Service account key file (default: Application Default Credentials)
+	CredentialsFile string `yaml:"credentials_file" mapstructure:"credentials_file"`
+}
+
+// Enabled reports whether Vertex AI mode is configured
+func (v VertexConfig) Enabled() bool {
+	return v.Project != ""
 }
 
 // AudioConfig contains audio processing settings
@@ -54,15 +181,56 @@ type AudioConfig struct {
 	ChunkMinutes   int `yaml:"chunk_minutes" mapstructure:"chunk_minutes"`
 	OverlapSeconds int `yaml:"overlap_seconds" mapstructure:"overlap_seconds"`
 
-	// Conversion Configuration
-	OutputFormat string `yaml:"output_format" mapstructure:"output_format"`
+	// Conversion Configuration, for converted video audio and chunks
+	OutputFormat string `yaml:"output_format" mapstructure:"output_format"` // mp3, wav or flac
 	SampleRate   int    `yaml:"sample_rate" mapstructure:"sample_rate"`
-	Quality      int    `yaml:"quality" mapstructure:"quality"`
+	Channels     int    `yaml:"channels" mapstructure:"channels"` // 1 for mono, default 2
+	Quality      int    `yaml:"quality" mapstructure:"quality"`   // 1 (best) to 9 (smallest)
 
 	// Processing Configuration
 	TempDir       string `yaml:"temp_dir" mapstructure:"temp_dir"`
 	KeepTempFiles bool   `yaml:"keep_temp_files" mapstructure:"keep_temp_files"`
 	Workers       int    `yaml:"workers" mapstructure:"workers"`
+	FFmpegDir     string `yaml:"ffmpeg_dir" mapstructure:"ffmpeg_dir"` // Static ffmpeg/ffprobe used ahead of PATH
+
+	// Scale chunk workers between MinWorkers and MaxWorkers by provider
+	// latency and errors, starting at Workers
+	AdaptiveWorkers bool `yaml:"adaptive_workers" mapstructure:"adaptive_workers"`
+	MinWorkers      int  `yaml:"min_workers" mapstructure:"min_workers"`
+	MaxWorkers      int  `yaml:"max_workers" mapstructure:"max_workers"`
+
+	// Silence Trimming Configuration
+	TrimSilence       bool `yaml:"trim_silence" mapstructure:"trim_silence"`
+	SilenceThreshold  int  `yaml:"silence_threshold" mapstructure:"silence_threshold"`     // dB, default -50
+	MinSilenceSeconds int  `yaml:"min_silence_seconds" mapstructure:"min_silence_seconds"` // Default 2
+
+	// Remove silences of at least SkipSilenceSeconds from the whole
+	// recording before chunking
+	SkipSilence        bool `yaml:"skip_silence" mapstructure:"skip_silence"`
+	SkipSilenceSeconds int  `yaml:"skip_silence_seconds" mapstructure:"skip_silence_seconds"` // Default 10
+
+	// Send the overlap as context-only audio at the start of each chunk
+	LeadingContext bool `yaml:"leading_context" mapstructure:"leading_context"`
+
+	// Preprocessing filters applied to each chunk before upload
+	Filters AudioFiltersConfig `yaml:"filters" mapstructure:"filters"`
+
+	// Keep chunk audio in memory instead of writing chunk files to
+	// TempDir, for containers with little or read-only scratch space
+	InMemoryChunks bool `yaml:"in_memory_chunks" mapstructure:"in_memory_chunks"`
+
+	// Fail jobs whose temp files would take TempDir's job directories
+	// over this many MB; 0 for no limit
+	MaxTempMB int `yaml:"max_temp_mb" mapstructure:"max_temp_mb"`
+}
+
+// AudioFiltersConfig enables filters for low-quality recordings, e.g.
+// phone calls. Cutoffs of 0 disable the filter.
+type AudioFiltersConfig struct {
+	Loudnorm   bool    `yaml:"loudnorm" mapstructure:"loudnorm"`       // EBU R128 loudness normalization
+	HighpassHz int     `yaml:"highpass_hz" mapstructure:"highpass_hz"` // Remove rumble and hum below this
+	LowpassHz  int     `yaml:"lowpass_hz" mapstructure:"lowpass_hz"`   // Remove hiss above this
+	DenoiseDB  float64 `yaml:"denoise_db" mapstructure:"denoise_db"`   // afftdn noise reduction, e.g. 12
 }
 
 // TranscribeConfig contains transcription settings
@@ -70,6 +238,41 @@ type TranscribeConfig struct {
 	// Custom Prompts
 	DefaultPrompt   string            `yaml:"default_prompt" mapstructure:"default_prompt"`
 	PromptTemplates map[string]string `yaml:"prompt_templates" mapstructure:"prompt_templates"`
+	PromptsDir      string            `yaml:"prompts_dir" mapstructure:"prompts_dir"` // Named prompt files, e.g. sales-call.md
+
+	// Chunk Failure Policy
+	ChunkRetries int  `yaml:"chunk_retries" mapstructure:"chunk_retries"` // Retries per failed chunk
+	AllowPartial bool `yaml:"allow_partial" mapstructure:"allow_partial"` // Keep the transcript when chunks still fail
+
+	// Speaker Identification
+	VoiceProfilesDir string            `yaml:"voice_profiles_dir" mapstructure:"voice_profiles_dir"` // Recordings named after their speaker
+	SpeakerMap       map[string]string `yaml:"speaker_map" mapstructure:"speaker_map"`               // Speaker label renames, e.g. "Speaker 1": Alice
+
+	// Use the speaker labels of providers that diarize natively instead of
+	// asking for them in the prompt
+	NativeDiarization bool `yaml:"native_diarization" mapstructure:"native_diarization"`
+
+	// How chunk transcripts are joined: text-align, timestamp, naive or llm-assisted
+	MergeStrategy string `yaml:"merge_strategy" mapstructure:"merge_strategy"`
+
+	// Bundled prompt style: verbatim, clean or notes (empty uses the prompt as is)
+	Style string `yaml:"style" mapstructure:"style"`
+
+	// Tag the language of every segment, for code-switching recordings
+	SegmentLanguages bool `yaml:"segment_languages" mapstructure:"segment_languages"`
+
+	// Language the audio is spoken in, e.g. ja or zh-TW (auto assumes
+	// none), and what to do with chunks transcribed in another one: flag
+	// or rerun with a corrective prompt ("" skips the check)
+	Language      string `yaml:"language" mapstructure:"language"`
+	LanguageCheck string `yaml:"language_check" mapstructure:"language_check"`
+
+	// What to do with subtitle tracks videos already have: extract them
+	// next to the transcript, or prefer them over transcribing ("" ignores
+	// them). SubtitlesLanguage picks the track: "" for transcribe.language,
+	// auto for any.
+	EmbeddedSubtitles string `yaml:"embedded_subtitles" mapstructure:"embedded_subtitles"`
+	SubtitlesLanguage string `yaml:"subtitles_language" mapstructure:"subtitles_language"`
 }
 
 // OutputConfig contains output formatting settings
@@ -81,6 +284,30 @@ type OutputConfig struct {
 	// Content Options
 	IncludeMetadata bool `yaml:"include_metadata" mapstructure:"include_metadata"`
 	PrettyPrint     bool `yaml:"pretty_print" mapstructure:"pretty_print"`
+
+	// Also write one file per speaker, e.g. meeting.alice.txt
+	PerSpeaker bool `yaml:"per_speaker" mapstructure:"per_speaker"`
+
+	// Extra formats written next to each transcript (json, srt, vtt,
+	// chapters), rendered up to RenderWorkers at a time (default 4)
+	Formats       []string `yaml:"formats" mapstructure:"formats"`
+	RenderWorkers int      `yaml:"render_workers" mapstructure:"render_workers"`
+
+	// Go text/template file also rendered with each TranscribeResult, for
+	// bespoke formats; anki.csv.tmpl writes meeting.anki.csv
+	Template string `yaml:"template" mapstructure:"template"`
+
+	// Write name_v2.txt, name_v3.txt, ... instead of overwriting the
+	// transcript of a file that is transcribed again
+	KeepVersions bool `yaml:"keep_versions" mapstructure:"keep_versions"`
+
+	// Also write a copy of each video with the transcript as a soft
+	// subtitle track, e.g. meeting.subtitled.mp4
+	MuxSubtitles bool `yaml:"mux_subtitles" mapstructure:"mux_subtitles"`
+
+	// Locale of the numbers in CLI summaries, e.g. de_DE for "1,2 GB";
+	// empty uses LC_ALL, LC_NUMERIC or LANG
+	Locale string `yaml:"locale" mapstructure:"locale"`
 }
 
 // WatchConfig contains watch mode settings
@@ -112,14 +339,258 @@ type WatchConfig struct {
 	// Path to the BoltDB history database
 	HistoryDB string `yaml:"history_db" mapstructure:"history_db"`
 
+	// What to do when another instance has HistoryDB open: fail,
+	// readonly or instance
+	HistoryLocked string `yaml:"history_locked" mapstructure:"history_locked"`
+
 	// Whether to process existing files on startup
 	ProcessExisting bool `yaml:"process_existing" mapstructure:"process_existing"`
 
 	// Whether to retry failed files
 	RetryFailed bool `yaml:"retry_failed" mapstructure:"retry_failed"`
 
+	// Files failing MaxAttempts times are moved to QuarantineDir with a
+	// .error.json of their failures (empty keeps retrying them)
+	QuarantineDir string `yaml:"quarantine_dir" mapstructure:"quarantine_dir"`
+	MaxAttempts   int    `yaml:"max_attempts" mapstructure:"max_attempts"`
+
 	// Maximum number of concurrent processing workers
 	MaxWorkers int `yaml:"max_workers" mapstructure:"max_workers"`
+
+	// Per-directory provider/model overrides
+	Routes []WatchRoute `yaml:"routes" mapstructure:"routes"`
+
+	// Cleanup of processed media and transcripts
+	Retention RetentionConfig `yaml:"retention" mapstructure:"retention"`
+
+	// Periodic export of the history and usage stats for reporting
+	Export HistoryExportConfig `yaml:"export" mapstructure:"export"`
+
+	// Prompt and output name picked from each file's detected language
+	Languages LanguageRoutingConfig `yaml:"languages" mapstructure:"languages"`
+}
+
+// LanguageRoutingConfig detects the language of every watched file from the
+// start of the recording and routes it to a prompt for that language
+type LanguageRoutingConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Seconds of audio sent to detect the language (default: 60)
+	SampleSeconds int `yaml:"sample_seconds" mapstructure:"sample_seconds"`
+
+	// Prompt per language code, e.g. "zh" or "zh-TW"
+	Prompts map[string]string `yaml:"prompts" mapstructure:"prompts"`
+
+	// Prompt from the named prompt library per language, for languages
+	// without an entry in Prompts
+	PromptNames map[string]string `yaml:"prompt_names" mapstructure:"prompt_names"`
+
+	// Add the language to output names: meeting_zh.txt
+	Suffix bool `yaml:"suffix" mapstructure:"suffix"`
+}
+
+// RetentionConfig controls how long watch mode keeps processed source media
+// and transcripts. A period of 0 days keeps files forever.
+type RetentionConfig struct {
+	// Days to keep processed source media
+	MediaDays int `yaml:"media_days" mapstructure:"media_days"`
+
+	// Days to keep transcripts
+	TranscriptDays int `yaml:"transcript_days" mapstructure:"transcript_days"`
+
+	// What to do with expired files: delete or archive
+	Action string `yaml:"action" mapstructure:"action"`
+
+	// Directory expired files are moved to with action "archive"
+	ArchiveDir string `yaml:"archive_dir" mapstructure:"archive_dir"`
+
+	// Only report what would be removed
+	DryRun bool `yaml:"dry_run" mapstructure:"dry_run"`
+
+	// JSON lines file recording every retention action
+	AuditLog string `yaml:"audit_log" mapstructure:"audit_log"`
+}
+
+// HistoryExportConfig mirrors the watch history and its usage stats to a
+// JSON or CSV file, or pushes them to an HTTP endpoint, on a schedule
+type HistoryExportConfig struct {
+	// File the history is written to, e.g. reports/history.json
+	Path string `yaml:"path" mapstructure:"path"`
+
+	// json or csv; empty picks it from the file's extension
+	Format string `yaml:"format" mapstructure:"format"`
+
+	// Endpoint the JSON report is POSTed to
+	URL string `yaml:"url" mapstructure:"url"`
+
+	// Headers sent with the report, e.g. Authorization
+	Headers map[string]string `yaml:"headers" mapstructure:"headers"`
+
+	// How often watch mode exports the history (default: 1h)
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+}
+
+// MarshalJSON encodes the export with its header values redacted, as they
+// hold credentials, so logging the config does not leak them
+func (e HistoryExportConfig) MarshalJSON() ([]byte, error) {
+	type export HistoryExportConfig
+	redacted := export(e)
+	redacted.Headers = RedactHeaders(e.Headers)
+	return json.Marshal(redacted)
+}
+
+// RedactHeaders returns a copy of HTTP headers with every value replaced
+// by "redacted", e.g. to log which headers are sent
+func RedactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name := range headers {
+		redacted[name] = "redacted"
+	}
+	return redacted
+}
+
+// WatchRoute routes files below a directory to a different provider or prompt.
+// An empty provider name keeps the primary provider, optionally with another model.
+type WatchRoute struct {
+	Dir      string      `yaml:"dir" mapstructure:"dir"`
+	Provider ProviderRef `yaml:"provider" mapstructure:"provider"`
+	Prompt   string      `yaml:"prompt" mapstructure:"prompt"`
+
+	// Prompt from the named prompt library, used when Prompt is empty
+	PromptName string `yaml:"prompt_name" mapstructure:"prompt_name"`
+}
+
+// RuleConfig routes files having all of Tags, e.g. tag "legal" to a
+// secure output directory and a local provider. Rules are evaluated in
+// order; later matches override earlier ones.
+type RuleConfig struct {
+	Name string   `yaml:"name" mapstructure:"name"`
+	Tags []string `yaml:"tags" mapstructure:"tags"`
+
+	OutputDir string      `yaml:"output_dir" mapstructure:"output_dir"`
+	Provider  ProviderRef `yaml:"provider" mapstructure:"provider"`
+
+	// Refuse files unless their provider is local
+	LocalOnly bool `yaml:"local_only" mapstructure:"local_only"`
+
+	// Evaluate no further rules after this one
+	Stop bool `yaml:"stop" mapstructure:"stop"`
+}
+
+// BudgetConfig contains hard limits on estimated usage (0 disables a limit)
+type BudgetConfig struct {
+	// Per-file limits; a file exceeding them is rejected before any upload
+	MaxChunksPerFile int     `yaml:"max_chunks_per_file" mapstructure:"max_chunks_per_file"`
+	MaxTokensPerFile int     `yaml:"max_tokens_per_file" mapstructure:"max_tokens_per_file"`
+	MaxCostPerFile   float64 `yaml:"max_cost_per_file" mapstructure:"max_cost_per_file"`
+
+	// Limit for a whole batch or watch session (--max-cost)
+	MaxCostPerRun float64 `yaml:"max_cost_per_run" mapstructure:"max_cost_per_run"`
+
+	// Per-day limits, tracked across runs in the ledger file
+	MaxTokensPerDay int     `yaml:"max_tokens_per_day" mapstructure:"max_tokens_per_day"`
+	MaxCostPerDay   float64 `yaml:"max_cost_per_day" mapstructure:"max_cost_per_day"`
+
+	// Flat price in USD per million tokens for models missing from the pricing table
+	PricePerMillionTokens float64 `yaml:"price_per_million_tokens" mapstructure:"price_per_million_tokens"`
+
+	// What to do when a daily limit is reached (abort, pause)
+	OnExceed string `yaml:"on_exceed" mapstructure:"on_exceed"`
+
+	// File recording today's usage
+	LedgerPath string `yaml:"ledger_path" mapstructure:"ledger_path"`
+
+	// Check batches of at least QuotaCheckFiles files against the provider's
+	// daily quota, where it reports one, and the daily limits above, and
+	// warn or abort if they would leave less than MinFreeQuota (a share
+	// between 0 and 1) free ("" or off, warn, abort)
+	QuotaCheck      string  `yaml:"quota_check" mapstructure:"quota_check"`
+	QuotaCheckFiles int     `yaml:"quota_check_files" mapstructure:"quota_check_files"`
+	MinFreeQuota    float64 `yaml:"min_free_quota" mapstructure:"min_free_quota"`
+}
+
+// PrivacyConfig contains settings for regulated environments
+type PrivacyConfig struct {
+	// Refuse to use any provider that sends audio to a cloud service
+	LocalOnly bool `yaml:"local_only" mapstructure:"local_only"`
+
+	// Replace file paths in logs with hashed tokens (implied by local_only)
+	RedactPaths bool `yaml:"redact_paths" mapstructure:"redact_paths"`
+}
+
+// CacheConfig contains settings for caching provider responses per chunk
+type CacheConfig struct {
+	// Directory for cached responses (empty disables caching)
+	Dir string `yaml:"dir" mapstructure:"dir"`
+}
+
+// ManifestConfig contains settings for run manifests
+type ManifestConfig struct {
+	// Where to write the manifest (empty disables manifests)
+	Path string `yaml:"path" mapstructure:"path"`
+
+	// Base64 ed25519 seed used to sign manifests (better to use GOLLMSCRIBE_SIGNING_KEY),
+	// never logged
+	SigningKey string `yaml:"signing_key" mapstructure:"signing_key" json:"-"`
+
+	// File containing the signing key
+	SigningKeyFile string `yaml:"signing_key_file" mapstructure:"signing_key_file"`
+}
+
+// SummaryConfig contains settings for summarizing the merged transcript
+// with a second request to the provider
+type SummaryConfig struct {
+	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
+	Prompt  string `yaml:"prompt" mapstructure:"prompt"` // Default: summary, decisions and action items
+	File    bool   `yaml:"file" mapstructure:"file"`     // Also write the summary to <output>.summary.md
+}
+
+// ChaptersConfig contains settings for splitting the merged transcript
+// into chapters with a second request to the provider
+type ChaptersConfig struct {
+	Enabled bool   `yaml:"enabled" mapstructure:"enabled"`
+	Prompt  string `yaml:"prompt" mapstructure:"prompt"` // Must ask for "HH:MM:SS Title" lines
+	File    bool   `yaml:"file" mapstructure:"file"`     // Also write YouTube-style chapters to <output>.chapters.txt
+}
+
+// TranslationConfig contains settings for translating each segment of the
+// merged transcript with further requests to the provider
+type TranslationConfig struct {
+	Language  string `yaml:"language" mapstructure:"language"`   // Target language, e.g. "Spanish"; empty disables translation
+	Prompt    string `yaml:"prompt" mapstructure:"prompt"`       // Must ask for "N: translation" lines; %s is the language
+	Bilingual bool   `yaml:"bilingual" mapstructure:"bilingual"` // Also write <output>.bilingual.srt and .bilingual.vtt
+}
+
+// CallConfig contains the telephony preset for stereo call recordings with
+// one party on each channel. Every channel is transcribed separately.
+type CallConfig struct {
+	Enabled       bool          `yaml:"enabled" mapstructure:"enabled"`
+	Speakers      []string      `yaml:"speakers" mapstructure:"speakers"`             // Speaker of each channel, left first
+	HoldThreshold time.Duration `yaml:"hold_threshold" mapstructure:"hold_threshold"` // Shortest silence counted as a hold (default: 15s)
+}
+
+// EncryptionConfig contains the key used to encrypt transcripts, stored
+// results and history records at rest. Encryption is off when no key is set.
+type EncryptionConfig struct {
+	// Base64 or hex encoded 32-byte AES-256 key (better to use GOLLMSCRIBE_ENCRYPTION_KEY),
+	// never logged
+	Key string `yaml:"key" mapstructure:"key" json:"-"`
+
+	// File containing the encoded key
+	KeyFile string `yaml:"key_file" mapstructure:"key_file"`
+}
+
+// Enabled reports whether a key is configured
+func (e EncryptionConfig) Enabled() bool {
+	return e.Key != "" || e.KeyFile != ""
+}
+
+// ShouldRedactPaths reports whether file paths must be redacted from logs
+func (p PrivacyConfig) ShouldRedactPaths() bool {
+	return p.LocalOnly || p.RedactPaths
 }
 
 // DefaultConfig returns the default configuration
@@ -160,10 +631,26 @@ func DefaultConfig() *Config {
 			StabilityWait:     2 * time.Second,
 			ProcessingTimeout: 30 * time.Minute,
 			HistoryDB:         ".gollmscribe-watch.db",
+			HistoryLocked:     "fail",
 			ProcessExisting:   true,
 			RetryFailed:       false,
+			MaxAttempts:       3,
 			MaxWorkers:        3,
+			Retention: RetentionConfig{
+				Action:   "delete",
+				AuditLog: ".gollmscribe-retention.log",
+			},
+			Languages: LanguageRoutingConfig{
+				SampleSeconds: 60,
+				Suffix:        true,
+			},
+		},
+		Budget: BudgetConfig{
+			OnExceed:        "abort",
+			LedgerPath:      ".gollmscribe-usage.json",
+			QuotaCheckFiles: 10,
 		},
+		Pricing: pricing.DefaultTable(),
 		Logging: *logger.DefaultConfig(),
 	}
 }
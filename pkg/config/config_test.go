@@ -0,0 +1,25 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConfigJSONOmitsKeys(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Encryption.Key = "encryption-secret"
+	cfg.Manifest.SigningKey = "signing-secret"
+	cfg.Watch.Export.Headers = map[string]string{"Authorization": "Bearer header-secret"}
+
+	// The config is logged as JSON at debug level
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"encryption-secret", "signing-secret", "header-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("config JSON contains %s", secret)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// SopsBinary is the sops executable encrypted configs are decrypted with
+var SopsBinary = "sops"
+
+// decryptTimeout bounds decrypting a config, which may call a KMS
+const decryptTimeout = time.Minute
+
+// ErrConfigDecryption is returned when an encrypted config cannot be
+// decrypted; running on without its settings would be wrong
+var ErrConfigDecryption = errors.New("failed to decrypt config")
+
+// DecryptOptions picks the key an encrypted config is decrypted with.
+// Without either, sops uses its own defaults, e.g. SOPS_AGE_KEY_FILE or
+// the KMS credentials of the environment.
+type DecryptOptions struct {
+	// age identity file holding the private key the config was encrypted to
+	KeyFile string
+
+	// KMS key the config must be encrypted with: an AWS KMS ARN or a GCP
+	// KMS resource ID. Credentials come from the environment as usual
+	// (AWS_PROFILE, GOOGLE_APPLICATION_CREDENTIALS, ...).
+	KMS string
+}
+
+// sopsMetadata is the part of the sops section of an encrypted file that
+// tells which keys can decrypt it
+type sopsMetadata struct {
+	MAC string `yaml:"mac"`
+	KMS []struct {
+		ARN string `yaml:"arn"`
+	} `yaml:"kms"`
+	GCPKMS []struct {
+		ResourceID string `yaml:"resource_id"`
+	} `yaml:"gcp_kms"`
+}
+
+// parseSopsMetadata returns the sops metadata of a YAML or JSON file, or
+// nil if it is not encrypted with sops
+func parseSopsMetadata(data []byte) *sopsMetadata {
+	var doc struct {
+		Sops *sopsMetadata `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc.Sops == nil || doc.Sops.MAC == "" {
+		return nil
+	}
+	return doc.Sops
+}
+
+// IsEncrypted reports whether config data is a sops-encrypted file
+func IsEncrypted(data []byte) bool {
+	return parseSopsMetadata(data) != nil
+}
+
+// hasKMS reports whether the file can be decrypted with the KMS key ref
+func (m *sopsMetadata) hasKMS(ref string) bool {
+	for _, key := range m.KMS {
+		// sops keeps an assumed role after the ARN, as arn+role
+		if arn, _, _ := strings.Cut(key.ARN, "+"); arn == ref || key.ARN == ref {
+			return true
+		}
+	}
+	for _, key := range m.GCPKMS {
+		if key.ResourceID == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptFile decrypts a sops-encrypted config file with the sops binary
+// and returns it as YAML
+func DecryptFile(ctx context.Context, path string, opts DecryptOptions) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	metadata := parseSopsMetadata(data)
+	if metadata == nil {
+		return nil, fmt.Errorf("%s is not encrypted with sops", filepath.Base(path))
+	}
+	if opts.KMS != "" && !metadata.hasKMS(opts.KMS) {
+		return nil, fmt.Errorf("%s is not encrypted with KMS key %s", filepath.Base(path), opts.KMS)
+	}
+
+	binary, err := exec.LookPath(SopsBinary)
+	if err != nil {
+		return nil, fmt.Errorf("%s is encrypted, but sops was not found: install it from https://github.com/getsops/sops", filepath.Base(path))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, decryptTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary, "--decrypt", "--output-type", "yaml", path)
+	cmd.Env = os.Environ()
+	if opts.KeyFile != "" {
+		cmd.Env = append(cmd.Env, "SOPS_AGE_KEY_FILE="+opts.KeyFile)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	plain, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sops: %s", msg)
+		}
+		return nil, fmt.Errorf("sops: %w", err)
+	}
+	return plain, nil
+}
+
+// ReadInConfig reads v's config file like viper's ReadInConfig, first
+// decrypting it when it is sops-encrypted, e.g. a config with embedded
+// API keys shipped to a fleet of watch daemons. Decryption failures wrap
+// ErrConfigDecryption. A config file read before, e.g. on reload, is only
+// replaced once it is decrypted, so a failure keeps the settings it had.
+func ReadInConfig(v *viper.Viper, opts DecryptOptions) error {
+	path := v.ConfigFileUsed()
+	read := false
+	if path == "" {
+		if err := v.ReadInConfig(); err != nil {
+			return err
+		}
+		path, read = v.ConfigFileUsed(), true
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !IsEncrypted(data) {
+		if read {
+			return nil
+		}
+		return v.ReadInConfig()
+	}
+
+	plain, err := DecryptFile(context.Background(), path, opts)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrConfigDecryption, filepath.Base(path), err)
+	}
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(plain)); err != nil {
+		return fmt.Errorf("%w %s: %w", ErrConfigDecryption, filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const encryptedConfig = `provider:
+    api_key: ENC[AES256_GCM,data:Zm9v,iv:YmFy,tag:YmF6,type:str]
+sops:
+    kms:
+        - arn: arn:aws:kms:us-east-1:123456789012:key/fleet+arn:aws:iam::123456789012:role/watch
+    age:
+        - recipient: age1example
+    mac: ENC[AES256_GCM,data:bWFj,iv:aXY=,tag:dGFn,type:str]
+    version: 3.9.0
+`
+
+func TestReadInConfigEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.enc.yaml")
+	if err := os.WriteFile(path, []byte(encryptedConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted([]byte(encryptedConfig)) || IsEncrypted([]byte("provider:\n  name: gemini\n")) {
+		t.Fatal("IsEncrypted() misdetected a config")
+	}
+
+	script := filepath.Join(t.TempDir(), "sops")
+	body := "#!/bin/sh\nprintf 'provider:\\n  api_key: secret\\n  model: \"%s\"\\n' \"$SOPS_AGE_KEY_FILE\"\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(binary string) { SopsBinary = binary }(SopsBinary)
+	SopsBinary = script
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	err := ReadInConfig(v, DecryptOptions{KeyFile: "/etc/gollmscribe/age.key", KMS: "arn:aws:kms:us-east-1:123456789012:key/fleet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.GetString("provider.api_key") != "secret" || v.GetString("provider.model") != "/etc/gollmscribe/age.key" {
+		t.Errorf("decrypted config = %v", v.AllSettings())
+	}
+	if v.IsSet("sops") {
+		t.Error("sops metadata was kept as settings")
+	}
+
+	// A reload that fails to decrypt keeps the decrypted settings
+	if err := ReadInConfig(v, DecryptOptions{KMS: "arn:aws:kms:us-east-1:123456789012:key/other"}); !errors.Is(err, ErrConfigDecryption) {
+		t.Fatalf("reload with another KMS key = %v", err)
+	}
+	if v.GetString("provider.api_key") != "secret" {
+		t.Errorf("api_key after a failed reload = %q, want the decrypted key", v.GetString("provider.api_key"))
+	}
+
+	err = ReadInConfig(viper.New(), DecryptOptions{})
+	if err == nil || errors.Is(err, ErrConfigDecryption) {
+		t.Errorf("ReadInConfig() without a config file = %v, want viper's not found error", err)
+	}
+
+	// A config not encrypted with the fleet's KMS key is refused
+	v = viper.New()
+	v.SetConfigFile(path)
+	if err := ReadInConfig(v, DecryptOptions{KMS: "arn:aws:kms:us-east-1:123456789012:key/other"}); !errors.Is(err, ErrConfigDecryption) {
+		t.Errorf("ReadInConfig() with another KMS key = %v", err)
+	}
+}
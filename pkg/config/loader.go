@@ -13,6 +13,7 @@ import (
 type Loader struct {
 	configPath string
 	viper      *viper.Viper
+	decrypt    DecryptOptions
 }
 
 // NewLoader creates a new configuration loader
@@ -42,13 +43,19 @@ func NewLoader(configPath string) *Loader {
 	}
 }
 
+// SetDecryptOptions sets the key a sops-encrypted config file is
+// decrypted with
+func (l *Loader) SetDecryptOptions(opts DecryptOptions) {
+	l.decrypt = opts
+}
+
 // Load reads and returns the configuration
 func (l *Loader) Load() (*Config, error) {
 	// Set defaults
 	l.setDefaults()
 
-	// Try to read config file
-	if err := l.viper.ReadInConfig(); err != nil {
+	// Try to read config file, decrypting it if needed
+	if err := ReadInConfig(l.viper, l.decrypt); err != nil {
 		// Config file not found is not an error - we'll use defaults and env vars
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -166,8 +173,8 @@ func (l *Loader) validateConfig(cfg *Config) error {
 		return fmt.Errorf("provider name is required")
 	}
 
-	// Validate API key is set (either in config or environment)
-	if cfg.Provider.APIKey == "" && os.Getenv("GOLLMSCRIBE_API_KEY") == "" {
+	// Validate API key is set (either in config or environment), unless the provider needs none
+	if cfg.Provider.APIKey == "" && os.Getenv("GOLLMSCRIBE_API_KEY") == "" && cfg.Provider.RequiresAPIKey() {
 		return fmt.Errorf("API key is required (set in config file or GOLLMSCRIBE_API_KEY environment variable)")
 	}
 
@@ -0,0 +1,138 @@
+// Package encryption seals transcripts and history records at rest with
+// AES-256-GCM so they can be kept on shared disks
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+// KeySize is the AES-256 key length in bytes
+const KeySize = 32
+
+// header prefixes every sealed payload so encrypted and plaintext data can
+// be told apart, e.g. history records written before encryption was enabled
+var header = []byte("gollmscribe-aes256gcm-v1\n")
+
+// ErrKeyRequired is returned when encrypted data is read without a key
+var ErrKeyRequired = errors.New("data is encrypted; an encryption key is required")
+
+// Cipher encrypts and decrypts payloads. A nil Cipher passes data through
+// unchanged, so callers can use it whether or not encryption is enabled.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New creates a cipher from a 32-byte key
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// FromConfig creates a cipher from the configured key or key file. It
+// returns nil when encryption is not configured.
+func FromConfig(cfg config.EncryptionConfig) (*Cipher, error) {
+	encoded := cfg.Key
+	if encoded == "" && cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := ParseKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return New(key)
+}
+
+// ParseKey decodes a base64 or hex encoded 32-byte key
+func ParseKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("encryption key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// GenerateKey returns a new random key encoded as base64
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted reports whether data was produced by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, header)
+}
+
+// Encrypt seals plaintext as header || nonce || ciphertext
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+c.aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// Decrypt opens data sealed by Encrypt. Plaintext data is returned as is,
+// so files written before encryption was enabled stay readable.
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrKeyRequired
+	}
+
+	payload := data[len(header):]
+	nonceSize := c.aead.NonceSize()
+	if len(payload) < nonceSize {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+
+	plaintext, err := c.aead.Open(nil, payload[:nonceSize], payload[nonceSize:], header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (wrong key or corrupted data): %w", err)
+	}
+	return plaintext, nil
+}
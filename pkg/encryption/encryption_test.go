@@ -0,0 +1,85 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+func testCipher(t *testing.T) *Cipher {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromConfig(config.EncryptionConfig{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := testCipher(t)
+	plaintext := []byte("confidential meeting notes")
+
+	sealed, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || bytes.Contains(sealed, plaintext) {
+		t.Fatalf("Encrypt() did not seal the plaintext: %q", sealed)
+	}
+
+	opened, err := c.Decrypt(sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Errorf("Decrypt() = %q, %v; want %q", opened, err, plaintext)
+	}
+
+	// Plaintext written before encryption was enabled passes through
+	if opened, err := c.Decrypt(plaintext); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Errorf("Decrypt(plaintext) = %q, %v", opened, err)
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	sealed, err := testCipher(t).Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testCipher(t).Decrypt(sealed); err == nil {
+		t.Error("Decrypt() with the wrong key succeeded")
+	}
+
+	var none *Cipher
+	if _, err := none.Decrypt(sealed); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("nil Decrypt() = %v, want ErrKeyRequired", err)
+	}
+	if out, _ := none.Encrypt([]byte("x")); string(out) != "x" {
+		t.Errorf("nil Encrypt() = %q, want passthrough", out)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if c, err := FromConfig(config.EncryptionConfig{}); c != nil || err != nil {
+		t.Errorf("FromConfig(empty) = %v, %v; want nil, nil", c, err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	hexKey := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	if err := os.WriteFile(keyFile, []byte(hexKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromConfig(config.EncryptionConfig{KeyFile: keyFile}); err != nil {
+		t.Errorf("FromConfig(hex key file) = %v", err)
+	}
+
+	if _, err := FromConfig(config.EncryptionConfig{Key: "too-short"}); err == nil {
+		t.Error("FromConfig(invalid key) succeeded")
+	}
+}
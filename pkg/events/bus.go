@@ -0,0 +1,141 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of lifecycle event
+type Type string
+
+// Transcriber lifecycle events
+const (
+	TranscriptionStarted   Type = "transcription.started"
+	ChunksCreated          Type = "transcription.chunks_created"
+	ChunkStarted           Type = "chunk.started"
+	ChunkCompleted         Type = "chunk.completed"
+	ChunkFailed            Type = "chunk.failed"
+	TranscriptionCompleted Type = "transcription.completed"
+	TranscriptionFailed    Type = "transcription.failed"
+	ProviderSwapped        Type = "provider.swapped" // Message is the new provider name
+)
+
+// File watcher lifecycle events
+const (
+	FileFound      Type = "file.found"
+	FileProcessing Type = "file.processing"
+	FileCompleted  Type = "file.completed"
+	FileFailed     Type = "file.failed"
+	FileSkipped    Type = "file.skipped"
+)
+
+// Event is a single lifecycle notification
+type Event struct {
+	Type      Type
+	FilePath  string
+	JobID     string // Job ID of the transcription run
+	ChunkID   int    // Chunk index for chunk events, -1 otherwise
+	ChunkKey  string // Stable chunk key for chunk events
+	Completed int    // Chunks completed so far
+	Total     int    // Total chunks for the file
+	Message   string
+	Error     error
+	Timestamp time.Time
+}
+
+// Bus fans events out to any number of subscribers.
+// Publishing never blocks: events are dropped for subscribers whose buffer is full.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives events published on a Bus
+type Subscription struct {
+	bus    *Bus
+	ch     chan Event
+	filter map[Type]bool
+	once   sync.Once
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber with the given buffer size.
+// If types are given, only those event types are delivered.
+func (b *Bus) Subscribe(bufferSize int, types ...Type) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+
+	sub := &Subscription{
+		bus: b,
+		ch:  make(chan Event, bufferSize),
+	}
+	if len(types) > 0 {
+		sub.filter = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.filter[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers an event to all subscribers
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter[event.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Subscriber is not keeping up, drop the event
+		}
+	}
+}
+
+// Close unsubscribes all subscribers and closes their channels
+func (b *Bus) Close() {
+	b.mu.Lock()
+	subs := b.subscribers
+	b.subscribers = make(map[*Subscription]struct{})
+	b.mu.Unlock()
+
+	for sub := range subs {
+		sub.once.Do(func() { close(sub.ch) })
+	}
+}
+
+// C returns the channel on which events are delivered
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+// Close unsubscribes and closes the event channel
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	delete(s.bus.subscribers, s)
+	s.bus.mu.Unlock()
+
+	s.once.Do(func() { close(s.ch) })
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBusPublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(4)
+	defer sub.Close()
+
+	bus.Publish(Event{Type: ChunkCompleted, ChunkID: 2})
+
+	event := <-sub.C()
+	if event.Type != ChunkCompleted {
+		t.Errorf("Type = %v, want %v", event.Type, ChunkCompleted)
+	}
+	if event.ChunkID != 2 {
+		t.Errorf("ChunkID = %d, want 2", event.ChunkID)
+	}
+	if event.Timestamp.IsZero() {
+		t.Error("Timestamp should be set on publish")
+	}
+}
+
+func TestBusFilter(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(4, FileCompleted)
+	defer sub.Close()
+
+	bus.Publish(Event{Type: FileFound})
+	bus.Publish(Event{Type: FileCompleted})
+
+	event := <-sub.C()
+	if event.Type != FileCompleted {
+		t.Errorf("Type = %v, want %v", event.Type, FileCompleted)
+	}
+	select {
+	case extra := <-sub.C():
+		t.Errorf("unexpected event %v", extra.Type)
+	default:
+	}
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1)
+	defer sub.Close()
+
+	// Publishing must not block even though the buffer only holds one event
+	bus.Publish(Event{Type: ChunkStarted})
+	bus.Publish(Event{Type: ChunkCompleted})
+
+	if got := len(sub.C()); got != 1 {
+		t.Errorf("buffered events = %d, want 1", got)
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1)
+	sub.Close()
+	sub.Close() // closing twice must be safe
+
+	if _, ok := <-sub.C(); ok {
+		t.Error("channel should be closed")
+	}
+
+	bus.Publish(Event{Type: FileFound}) // must not panic on closed subscriber
+	bus.Close()
+}
@@ -0,0 +1,136 @@
+// Package humanize formats durations, sizes and rates for the summaries
+// printed by the CLI, e.g. "1h 23m 45s", "1.2 GB" and "12.5x realtime",
+// with the decimal separator of the user's locale
+package humanize
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commaLanguages write decimals with a comma, e.g. "1,2 GB"
+var commaLanguages = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true,
+	"es": true, "fi": true, "fr": true, "hr": true, "hu": true, "id": true,
+	"it": true, "lt": true, "lv": true, "nb": true, "nl": true, "nn": true,
+	"no": true, "pl": true, "pt": true, "ro": true, "ru": true, "sk": true,
+	"sl": true, "sr": true, "sv": true, "tr": true, "uk": true, "vi": true,
+}
+
+var (
+	mu      sync.RWMutex
+	decimal = "."
+)
+
+// SetLocale sets the locale numbers are formatted for, e.g. "de_DE.UTF-8"
+// or "en-US". An empty locale is taken from the environment: LC_ALL,
+// LC_NUMERIC, then LANG.
+func SetLocale(locale string) {
+	if locale == "" {
+		locale = EnvLocale()
+	}
+	language := strings.ToLower(locale)
+	if i := strings.IndexAny(language, "_-.@"); i >= 0 {
+		language = language[:i]
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	decimal = "."
+	if commaLanguages[language] {
+		decimal = ","
+	}
+}
+
+// EnvLocale returns the locale numbers are formatted for according to the
+// environment, or "" if it is not set
+func EnvLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Duration formats d as its non-zero units, e.g. "1h 23m 45s", "2d 3h" or
+// "45s". Durations under a second are given in milliseconds, e.g. "850ms";
+// longer ones are rounded to the second.
+func Duration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "0s"
+	case d < 0:
+		return "-" + Duration(-d)
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Round(time.Millisecond).Milliseconds())
+	}
+
+	d = d.Round(time.Second)
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"},
+	}
+	var parts []string
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.name))
+			d -= n * unit.size
+		}
+	}
+	// Seconds add nothing to durations of days
+	if len(parts) > 2 && strings.HasSuffix(parts[0], "d") {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, " ")
+}
+
+// Size formats a byte count with binary units, e.g. "512 B" or "1.2 GB"
+func Size(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit || v <= -unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return Decimal(float64(n)/float64(div), 1) + " " + string("KMGTPE"[exp]) + "B"
+}
+
+// Realtime formats how many times faster than realtime audio of length
+// audio was processed in elapsed, e.g. "12.5x realtime"; "" if either is
+// unknown
+func Realtime(audio, elapsed time.Duration) string {
+	if audio <= 0 || elapsed <= 0 {
+		return ""
+	}
+	return Decimal(audio.Seconds()/elapsed.Seconds(), 1) + "x realtime"
+}
+
+// PerHour formats how many of noun were done per hour, e.g. "3.2
+// files/h" for 16 files in 5 hours; "" if elapsed is unknown
+func PerHour(count int, elapsed time.Duration, noun string) string {
+	if elapsed <= 0 {
+		return ""
+	}
+	return Decimal(float64(count)/elapsed.Hours(), 1) + " " + noun + "/h"
+}
+
+// Decimal formats x with digits decimals and the locale's separator
+func Decimal(x float64, digits int) string {
+	s := fmt.Sprintf("%.*f", digits, x)
+	mu.RLock()
+	defer mu.RUnlock()
+	if decimal != "." {
+		s = strings.Replace(s, ".", decimal, 1)
+	}
+	return s
+}
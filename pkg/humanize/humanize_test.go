@@ -0,0 +1,53 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                    "0s",
+		850 * time.Millisecond:               "850ms",
+		45 * time.Second:                     "45s",
+		2*time.Minute + 400*time.Millisecond: "2m",
+		time.Hour + 23*time.Minute + 45*time.Second:   "1h 23m 45s",
+		3*time.Hour + 5*time.Second:                   "3h 5s",
+		50*time.Hour + 10*time.Minute + 5*time.Second: "2d 2h",
+		-90 * time.Second:                             "-1m 30s",
+	} {
+		if got := Duration(d); got != want {
+			t.Errorf("Duration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	defer SetLocale("C")
+
+	SetLocale("en_US.UTF-8")
+	if got := Size(1288490189); got != "1.2 GB" {
+		t.Errorf("Size() = %q, want 1.2 GB", got)
+	}
+	if got := Size(512); got != "512 B" {
+		t.Errorf("Size() = %q, want 512 B", got)
+	}
+	if got := Realtime(time.Hour, 4*time.Minute); got != "15.0x realtime" {
+		t.Errorf("Realtime() = %q", got)
+	}
+	if got := PerHour(16, 5*time.Hour, "files"); got != "3.2 files/h" {
+		t.Errorf("PerHour() = %q", got)
+	}
+
+	SetLocale("de_DE.UTF-8")
+	if got := Size(1288490189); got != "1,2 GB" {
+		t.Errorf("Size() with de_DE = %q, want 1,2 GB", got)
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_NUMERIC", "fr_FR.UTF-8")
+	SetLocale("")
+	if got := Decimal(2.5, 1); got != "2,5" {
+		t.Errorf("Decimal() with LC_NUMERIC=fr_FR = %q, want 2,5", got)
+	}
+}
@@ -25,6 +25,9 @@ type Config struct {
 	Timestamp  bool   `yaml:"timestamp" mapstructure:"timestamp"`     // include timestamp
 	Caller     bool   `yaml:"caller" mapstructure:"caller"`           // include caller info
 	PrettyMode bool   `yaml:"pretty_mode" mapstructure:"pretty_mode"` // enable pretty console output
+
+	// RedactPaths replaces file paths in log records with stable hashed tokens
+	RedactPaths bool `yaml:"redact_paths" mapstructure:"redact_paths"`
 }
 
 // DefaultConfig returns default logger configuration
@@ -75,7 +78,7 @@ func Initialize(config *Config) error {
 	}
 
 	// Create base logger
-	var logger zerolog.Logger
+	var sink io.Writer
 
 	switch {
 	case config.Format == "console" && config.PrettyMode:
@@ -120,18 +123,24 @@ func Initialize(config *Config) error {
 			return ""
 		}
 
-		logger = zerolog.New(consoleWriter)
+		sink = consoleWriter
 	case config.Format == "console":
 		// Simple console output without colors
-		logger = zerolog.New(zerolog.ConsoleWriter{
+		sink = zerolog.ConsoleWriter{
 			Out:        output,
 			TimeFormat: time.RFC3339,
 			NoColor:    true,
-		})
+		}
 	default:
 		// JSON output
-		logger = zerolog.New(output)
+		sink = output
+	}
+
+	// Redaction rewrites the JSON records before they are formatted
+	if config.RedactPaths {
+		sink = &redactWriter{next: sink}
 	}
+	logger := zerolog.New(sink)
 
 	// Add timestamp if enabled
 	if config.Timestamp {
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// pathKeys are log fields that always carry file system paths, or lists
+// of them
+var pathKeys = map[string]bool{
+	"file":       true,
+	"files":      true,
+	"path":       true,
+	"dir":        true,
+	"directory":  true,
+	"input":      true,
+	"output":     true,
+	"history_db": true,
+}
+
+// isPathKey reports whether a log field name refers to a path
+func isPathKey(key string) bool {
+	return pathKeys[key] ||
+		strings.HasSuffix(key, "_path") ||
+		strings.HasSuffix(key, "_file") ||
+		strings.HasSuffix(key, "_dir")
+}
+
+// RedactPath replaces a path with a short stable token so log lines can
+// still be correlated without revealing file or directory names
+func RedactPath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return "[path:" + hex.EncodeToString(sum[:4]) + "]"
+}
+
+// redactWriter rewrites JSON log records, replacing path fields and any
+// occurrence of those paths in other fields (messages, errors)
+type redactWriter struct {
+	next io.Writer
+}
+
+// Write implements io.Writer; records that are not JSON pass through unchanged
+func (w *redactWriter) Write(p []byte) (int, error) {
+	var record map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(string(p)))
+	dec.UseNumber()
+	if err := dec.Decode(&record); err != nil {
+		return w.next.Write(p)
+	}
+
+	replacements := make(map[string]string)
+	for key, value := range record {
+		if !isPathKey(key) {
+			continue
+		}
+		switch value := value.(type) {
+		case string:
+			if value != "" {
+				replacements[value] = RedactPath(value)
+				record[key] = replacements[value]
+			}
+		case []interface{}:
+			for i, item := range value {
+				if s, ok := item.(string); ok && s != "" {
+					replacements[s] = RedactPath(s)
+					value[i] = replacements[s]
+				}
+			}
+		}
+	}
+	if len(replacements) == 0 {
+		return w.next.Write(p)
+	}
+
+	// Replace longer paths first so a base name never splits a full path
+	paths := make([]string, 0, len(replacements))
+	for path := range replacements {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+
+	for key, value := range record {
+		s, ok := value.(string)
+		if !ok || isPathKey(key) {
+			continue
+		}
+		for _, path := range paths {
+			s = strings.ReplaceAll(s, path, replacements[path])
+		}
+		record[key] = s
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return w.next.Write(p)
+	}
+	if _, err := w.next.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
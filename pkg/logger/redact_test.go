@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRedactWriter(t *testing.T) {
+	var buf bytes.Buffer
+	log := zerolog.New(&redactWriter{next: &buf})
+
+	path := "/srv/hr/jane-doe-review.mp3"
+	log.Error().
+		Str("file", path).
+		Str("output_path", "/srv/hr/jane-doe-review.txt").
+		Int("chunks", 3).
+		Msg("failed to probe " + path)
+
+	out := buf.String()
+	if strings.Contains(out, "jane-doe") {
+		t.Errorf("output leaks path: %s", out)
+	}
+	if !strings.Contains(out, RedactPath(path)) {
+		t.Errorf("output missing redacted token %s: %s", RedactPath(path), out)
+	}
+	if !strings.Contains(out, `"chunks":3`) {
+		t.Errorf("output lost non-path fields: %s", out)
+	}
+}
+
+func TestRedactWriterListsOfPaths(t *testing.T) {
+	var buf bytes.Buffer
+	log := zerolog.New(&redactWriter{next: &buf})
+
+	files := []string{"/srv/hr/jane-doe-review.mp3", "/srv/hr/john-roe-exit.mp3"}
+	log.Info().Int("file_count", 2).Strs("files", files).Msg("Starting transcription")
+
+	out := buf.String()
+	if strings.Contains(out, "jane-doe") || strings.Contains(out, "john-roe") {
+		t.Errorf("output leaks paths: %s", out)
+	}
+	for _, file := range files {
+		if !strings.Contains(out, RedactPath(file)) {
+			t.Errorf("output missing redacted token %s: %s", RedactPath(file), out)
+		}
+	}
+}
+
+func TestRedactWriterPassesThroughRecordsWithoutPaths(t *testing.T) {
+	var buf bytes.Buffer
+	log := zerolog.New(&redactWriter{next: &buf})
+
+	log.Info().Str("provider", "gemini").Msg("ready")
+
+	if want := `{"level":"info","provider":"gemini","message":"ready"}` + "\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
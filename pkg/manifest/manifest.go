@@ -0,0 +1,268 @@
+// Package manifest records the transcripts produced by a run with their
+// hashes, optionally signed, so consumers can detect later modification
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+// Version is the version of the manifest layout
+const Version = 1
+
+// ErrInvalidSignature is returned when a manifest signature does not verify
+var ErrInvalidSignature = errors.New("manifest signature is invalid")
+
+// Entry describes one output file
+type Entry struct {
+	Source   string `json:"source"`
+	Output   string `json:"output"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// Manifest lists the outputs of a run. Entries are kept sorted by output
+// path so the same outputs always produce the same manifest body.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Generator string    `json:"generator"`
+	CreatedAt time.Time `json:"created_at"`
+	Entries   []Entry   `json:"entries"`
+
+	// Base64 ed25519 public key and signature over the manifest without
+	// these two fields
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+
+	mu sync.Mutex
+}
+
+// New creates an empty manifest
+func New(generator string, createdAt time.Time) *Manifest {
+	return &Manifest{
+		Version:   Version,
+		Generator: generator,
+		CreatedAt: createdAt.UTC().Truncate(time.Second),
+		Entries:   []Entry{},
+	}
+}
+
+// Add hashes an output file as it is on disk and records it, replacing any
+// earlier entry for the same output
+func (m *Manifest) Add(source, output, provider, model string) error {
+	sum, size, err := HashFile(output)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", output, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := Entry{Source: source, Output: output, SHA256: sum, Size: size, Provider: provider, Model: model}
+	i := sort.Search(len(m.Entries), func(i int) bool { return m.Entries[i].Output >= output })
+	switch {
+	case i < len(m.Entries) && m.Entries[i].Output == output:
+		m.Entries[i] = entry
+	default:
+		m.Entries = append(m.Entries, Entry{})
+		copy(m.Entries[i+1:], m.Entries[i:])
+		m.Entries[i] = entry
+	}
+
+	// Any previous signature no longer covers the entries
+	m.PublicKey, m.Signature = "", ""
+	return nil
+}
+
+// Sign signs the manifest with an ed25519 private key
+func (m *Manifest) Sign(key ed25519.PrivateKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	body, err := m.body()
+	if err != nil {
+		return err
+	}
+	m.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, body))
+	return nil
+}
+
+// VerifySignature checks the signature against pub, or against the
+// embedded public key when pub is nil. Only a trusted pub proves who
+// signed the manifest; the embedded key only proves it is intact.
+func (m *Manifest) VerifySignature(pub ed25519.PublicKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Signature == "" {
+		return fmt.Errorf("manifest is not signed")
+	}
+	embedded, err := base64.StdEncoding.DecodeString(m.PublicKey)
+	if err != nil || len(embedded) != ed25519.PublicKeySize {
+		return fmt.Errorf("manifest public key is malformed")
+	}
+	if pub == nil {
+		pub = embedded
+	} else if !pub.Equal(ed25519.PublicKey(embedded)) {
+		return fmt.Errorf("%w: signed by a different key", ErrInvalidSignature)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("manifest signature is malformed")
+	}
+	body, err := m.body()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, body, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyFiles re-hashes every output and returns an error per file that is
+// missing or modified. Relative outputs are resolved against baseDir.
+func (m *Manifest) VerifyFiles(baseDir string) []error {
+	m.mu.Lock()
+	entries := append([]Entry(nil), m.Entries...)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		path := entry.Output
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		sum, _, err := HashFile(path)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Output, err))
+		case sum != entry.SHA256:
+			errs = append(errs, fmt.Errorf("%s: content has been modified", entry.Output))
+		}
+	}
+	return errs
+}
+
+// body returns the canonical bytes covered by the signature
+func (m *Manifest) body() ([]byte, error) {
+	unsigned := struct {
+		Version   int       `json:"version"`
+		Generator string    `json:"generator"`
+		CreatedAt time.Time `json:"created_at"`
+		Entries   []Entry   `json:"entries"`
+	}{m.Version, m.Generator, m.CreatedAt, m.Entries}
+	return json.Marshal(unsigned)
+}
+
+// Write saves the manifest atomically as indented JSON
+func (m *Manifest) Write(path string) error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Load reads a manifest written by Write
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	return &m, nil
+}
+
+// HashFile returns the hex SHA256 and size of a file
+func HashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// LoadSigningKey reads the configured ed25519 key, or returns nil when
+// signing is not configured
+func LoadSigningKey(cfg config.ManifestConfig) (ed25519.PrivateKey, error) {
+	encoded := cfg.SigningKey
+	if encoded == "" && cfg.SigningKeyFile != "" {
+		data, err := os.ReadFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key file: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("signing key must be a base64 encoded %d-byte ed25519 seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be a base64 encoded %d-byte ed25519 key", ed25519.PublicKeySize)
+	}
+	return key, nil
+}
+
+// GenerateKey returns a new base64 signing key and its public key
+func GenerateKey() (signingKey, publicKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(priv.Seed()), base64.StdEncoding.EncodeToString(pub), nil
+}
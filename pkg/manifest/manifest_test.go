@@ -0,0 +1,116 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEntriesAreSortedAndReplaced(t *testing.T) {
+	dir := t.TempDir()
+	b := writeFile(t, dir, "b.txt", "b")
+	a := writeFile(t, dir, "a.txt", "a")
+
+	m := New("test", time.Now())
+	for _, output := range []string{b, a, b} {
+		if err := m.Add("source", output, "gemini", "gemini-2.5-flash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(m.Entries) != 2 || m.Entries[0].Output != a || m.Entries[1].Output != b {
+		t.Fatalf("Entries = %+v, want a.txt then b.txt", m.Entries)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	output := writeFile(t, dir, "meeting.txt", "transcript")
+
+	signingKey, publicKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadSigningKey(config.ManifestConfig{SigningKey: signingKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New("test", time.Now())
+	if err := m.Add("meeting.mp3", output, "gemini", "gemini-2.5-flash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := m.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.VerifySignature(pub); err != nil {
+		t.Fatalf("VerifySignature() = %v", err)
+	}
+	if errs := loaded.VerifyFiles(""); len(errs) != 0 {
+		t.Fatalf("VerifyFiles() = %v", errs)
+	}
+
+	// A different trusted key is rejected
+	_, otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := ParsePublicKey(otherKey)
+	if err := loaded.VerifySignature(other); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature(other key) = %v, want ErrInvalidSignature", err)
+	}
+
+	// Editing the manifest breaks the signature
+	loaded.Entries[0].SHA256 = "0000"
+	if err := loaded.VerifySignature(pub); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature(tampered) = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestVerifyFilesDetectsModification(t *testing.T) {
+	dir := t.TempDir()
+	output := writeFile(t, dir, "meeting.txt", "transcript")
+
+	m := New("test", time.Now())
+	if err := m.Add("meeting.mp3", output, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "meeting.txt", "edited transcript")
+	if errs := m.VerifyFiles(""); len(errs) != 1 {
+		t.Fatalf("VerifyFiles() = %v, want one error", errs)
+	}
+
+	if err := os.Remove(output); err != nil {
+		t.Fatal(err)
+	}
+	if errs := m.VerifyFiles(""); len(errs) != 1 {
+		t.Fatalf("VerifyFiles(missing) = %v, want one error", errs)
+	}
+}
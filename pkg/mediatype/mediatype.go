@@ -0,0 +1,101 @@
+// Package mediatype maps media formats to their MIME types and ffmpeg
+// encoders. It is the one place these values are defined; providers that
+// expect different MIME types than the standard ones declare Overrides.
+package mediatype
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Fallback is the MIME type of unknown formats
+const Fallback = "application/octet-stream"
+
+// Format describes a media container
+type Format struct {
+	Name    string   // Short name, also the file extension without the dot
+	MIME    string   // Registered MIME type
+	Aliases []string // Non-standard MIME types seen in the wild
+	Encoder string   // ffmpeg audio encoder used when writing this format
+	Video   bool     // May carry video, so the audio is extracted before chunking
+
+	// ffmpeg encoder of soft subtitle tracks in this container, "" if it
+	// has none
+	Subtitles string
+}
+
+// formats lists every known format by name
+var formats = map[string]Format{
+	"wav":  {Name: "wav", MIME: "audio/wav", Aliases: []string{"audio/x-wav", "audio/wave"}, Encoder: "pcm_s16le"},
+	"mp3":  {Name: "mp3", MIME: "audio/mpeg", Aliases: []string{"audio/mp3"}, Encoder: "libmp3lame"},
+	"m4a":  {Name: "m4a", MIME: "audio/mp4", Aliases: []string{"audio/m4a", "audio/x-m4a"}, Encoder: "aac"},
+	"aac":  {Name: "aac", MIME: "audio/aac", Encoder: "aac"},
+	"flac": {Name: "flac", MIME: "audio/flac", Aliases: []string{"audio/x-flac"}, Encoder: "flac"},
+	"ogg":  {Name: "ogg", MIME: "audio/ogg", Encoder: "libvorbis"},
+	"opus": {Name: "opus", MIME: "audio/ogg", Aliases: []string{"audio/opus"}, Encoder: "libopus"},
+	"wma":  {Name: "wma", MIME: "audio/x-ms-wma", Encoder: "wmav2"},
+	"amr":  {Name: "amr", MIME: "audio/amr", Encoder: "libopencore_amrnb"},
+	"webm": {Name: "webm", MIME: "audio/webm", Aliases: []string{"video/webm"}, Encoder: "libopus", Video: true, Subtitles: "webvtt"},
+	"3gp":  {Name: "3gp", MIME: "audio/3gpp", Aliases: []string{"video/3gpp"}, Encoder: "aac", Video: true, Subtitles: "mov_text"},
+	"mp4":  {Name: "mp4", MIME: "video/mp4", Encoder: "aac", Video: true, Subtitles: "mov_text"},
+	"avi":  {Name: "avi", MIME: "video/x-msvideo", Video: true},
+	"mov":  {Name: "mov", MIME: "video/quicktime", Video: true, Subtitles: "mov_text"},
+	"mkv":  {Name: "mkv", MIME: "video/x-matroska", Video: true, Subtitles: "srt"},
+}
+
+// Lookup returns the format with a name, case-insensitively
+func Lookup(name string) (Format, bool) {
+	format, ok := formats[strings.ToLower(strings.TrimPrefix(name, "."))]
+	return format, ok
+}
+
+// FromPath returns the format of a file by its extension
+func FromPath(path string) (Format, bool) {
+	return Lookup(filepath.Ext(path))
+}
+
+// MIME returns the MIME type of a format name, or Fallback if unknown
+func MIME(name string) string {
+	if format, ok := Lookup(name); ok {
+		return format.MIME
+	}
+	return Fallback
+}
+
+// Encoder returns the ffmpeg encoder for a format name, or "" if unknown
+func Encoder(name string) string {
+	format, _ := Lookup(name)
+	return format.Encoder
+}
+
+// MIMETypes returns the MIME types, aliases included, of format names,
+// for listing what a provider accepts
+func MIMETypes(names ...string) []string {
+	var types []string
+	for _, name := range names {
+		if format, ok := Lookup(name); ok {
+			types = append(types, format.MIME)
+			types = append(types, format.Aliases...)
+		}
+	}
+	return types
+}
+
+// Overrides replaces the MIME type of some formats for one provider,
+// keyed by format name
+type Overrides map[string]string
+
+// MIME returns the MIME type a provider expects for a format name. For
+// unknown formats the caller's mimeType is kept, if any.
+func (o Overrides) MIME(name, mimeType string) string {
+	if override, ok := o[strings.ToLower(name)]; ok {
+		return override
+	}
+	if format, ok := Lookup(name); ok {
+		return format.MIME
+	}
+	if mimeType != "" {
+		return mimeType
+	}
+	return Fallback
+}
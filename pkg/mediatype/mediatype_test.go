@@ -0,0 +1,52 @@
+package mediatype
+
+import "testing"
+
+func TestMIME(t *testing.T) {
+	tests := map[string]string{
+		"m4a":  "audio/mp4",
+		".MP3": "audio/mpeg",
+		"wav":  "audio/wav",
+		"mkv":  "video/x-matroska",
+		"opus": "audio/ogg",
+		"amr":  "audio/amr",
+		"3gp":  "audio/3gpp",
+		"xyz":  Fallback,
+	}
+	for name, want := range tests {
+		if got := MIME(name); got != want {
+			t.Errorf("MIME(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if format, ok := FromPath("/tmp/Talk.M4A"); !ok || format.Name != "m4a" || format.Video {
+		t.Errorf("FromPath() = %+v, %v", format, ok)
+	}
+	if format, ok := FromPath("voice.webm"); !ok || !format.Video {
+		t.Errorf("FromPath(webm) = %+v, %v, want a format that may carry video", format, ok)
+	}
+	if Encoder("mp3") != "libmp3lame" {
+		t.Errorf("Encoder(mp3) = %q", Encoder("mp3"))
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	overrides := Overrides{"mp3": "audio/mp3"}
+	tests := []struct {
+		name, mimeType, want string
+	}{
+		{"mp3", "audio/mpeg", "audio/mp3"},
+		{"m4a", "audio/m4a", "audio/mp4"},
+		{"", "audio/ogg", "audio/ogg"},
+		{"", "", Fallback},
+	}
+	for _, tt := range tests {
+		if got := overrides.MIME(tt.name, tt.mimeType); got != tt.want {
+			t.Errorf("MIME(%q, %q) = %q, want %q", tt.name, tt.mimeType, got, tt.want)
+		}
+	}
+
+	if got := MIMETypes("mp3", "nope"); len(got) != 2 || got[0] != "audio/mpeg" || got[1] != "audio/mp3" {
+		t.Errorf("MIMETypes() = %v", got)
+	}
+}
@@ -0,0 +1,146 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// DefaultChapterPrompt asks for one "HH:MM:SS Title" line per chapter
+const DefaultChapterPrompt = "Split the following timestamped transcript into chapters where the topic changes. " +
+	"Reply with one chapter per line as \"HH:MM:SS Title\", using the timestamp of the line where the chapter starts; " +
+	"the first chapter starts at 00:00:00. Use short, descriptive titles in the language of the transcript " +
+	"and write nothing else."
+
+// chapterLine matches a reply line such as "00:12:30 Budget", "- [12:30] - Budget" or "1. 12:30 Budget"
+var chapterLine = regexp.MustCompile(`^(?:[-*•]|\d+\.)?\s*\[?((?:\d+:)?\d{1,2}:\d{2})\]?\s*[-–—:|]?\s*(.+?)\s*$`)
+
+// Chapterizer asks the provider where the topic of the merged transcript
+// changes and stores the chapters in the result
+type Chapterizer struct {
+	prompt  string
+	options providers.TranscriptionOptions
+}
+
+// NewChapterizer creates a chapterizer; an empty prompt uses DefaultChapterPrompt
+func NewChapterizer(prompt string, options providers.TranscriptionOptions) *Chapterizer {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultChapterPrompt
+	}
+	return &Chapterizer{prompt: prompt, options: options}
+}
+
+// Name returns the step name
+func (c *Chapterizer) Name() string {
+	return "chapters"
+}
+
+// Process sends the timestamped segments with a text-only request and
+// parses the chapters from the reply
+func (c *Chapterizer) Process(ctx context.Context, provider providers.LLMProvider, result *transcriber.TranscribeResult) ([]*providers.TranscriptionResult, error) {
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("transcript has no timestamped segments")
+	}
+
+	var transcript strings.Builder
+	for _, segment := range result.Segments {
+		transcript.WriteString("[" + formatTimestamp(segment.Start) + "] ")
+		if segment.SpeakerID != "" {
+			transcript.WriteString(segment.SpeakerID + ": ")
+		}
+		transcript.WriteString(strings.TrimSpace(segment.Text))
+		transcript.WriteString("\n")
+	}
+
+	resp, err := providers.GenerateText(ctx, provider, c.prompt+"\n\nTranscript:\n"+transcript.String(), c.options)
+	if err != nil {
+		return nil, fmt.Errorf("chapter detection failed: %w", err)
+	}
+
+	end := result.Duration
+	if last := result.Segments[len(result.Segments)-1].End; end <= 0 || last > end {
+		end = last
+	}
+	chapters := parseChapters(resp.Text, end)
+	if len(chapters) == 0 {
+		return []*providers.TranscriptionResult{resp}, fmt.Errorf("provider reply contained no chapters")
+	}
+	result.Chapters = chapters
+	return []*providers.TranscriptionResult{resp}, nil
+}
+
+// parseChapters reads "HH:MM:SS Title" lines, skipping anything else. The
+// chapters are sorted, the first starts at zero, and each ends where the
+// next starts or at end.
+func parseChapters(reply string, end time.Duration) []transcriber.Chapter {
+	var chapters []transcriber.Chapter
+	for _, line := range strings.Split(reply, "\n") {
+		match := chapterLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		start, ok := parseTimestamp(match[1])
+		if !ok || (end > 0 && start >= end) {
+			continue
+		}
+		title := strings.Trim(match[2], "*\"")
+		if title == "" {
+			continue
+		}
+		chapters = append(chapters, transcriber.Chapter{Title: title, Start: start})
+	}
+	if len(chapters) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	unique := chapters[:1]
+	for _, chapter := range chapters[1:] {
+		if chapter.Start > unique[len(unique)-1].Start {
+			unique = append(unique, chapter)
+		}
+	}
+
+	unique[0].Start = 0
+	for i := range unique {
+		if i+1 < len(unique) {
+			unique[i].End = unique[i+1].Start
+		} else {
+			unique[i].End = end
+		}
+	}
+	return unique
+}
+
+// parseTimestamp parses MM:SS or HH:MM:SS
+func parseTimestamp(s string) (time.Duration, bool) {
+	var total int
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total) * time.Second, true
+}
+
+// formatTimestamp formats a duration as HH:MM:SS
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// ChaptersPath returns the chapter file next to a transcript, e.g.
+// meeting.chapters.txt for meeting.txt
+func ChaptersPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".chapters.txt"
+}
@@ -0,0 +1,64 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func TestParseChapters(t *testing.T) {
+	reply := "Here are the chapters:\n" +
+		"- [00:00:05] Introduction\n" +
+		"00:12:30 - Budget review\n" +
+		"2. 05:10 **Hiring plan**\n" +
+		"00:12:30 Duplicate start\n" +
+		"02:00:00 Past the end\n"
+
+	got := parseChapters(reply, 20*time.Minute)
+	want := []transcriber.Chapter{
+		{Title: "Introduction", Start: 0, End: 5*time.Minute + 10*time.Second},
+		{Title: "Hiring plan", Start: 5*time.Minute + 10*time.Second, End: 12*time.Minute + 30*time.Second},
+		{Title: "Budget review", Start: 12*time.Minute + 30*time.Second, End: 20 * time.Minute},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseChapters() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chapter %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestChapterizer(t *testing.T) {
+	provider := &textProvider{reply: "00:00:00 Welcome\n00:01:00 Roadmap"}
+	result := &transcriber.TranscribeResult{
+		Duration: 3 * time.Minute,
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello everyone.", Start: 0, End: 5 * time.Second, SpeakerID: "Alice"},
+			{Text: "Now the roadmap.", Start: time.Minute, End: 70 * time.Second},
+		},
+	}
+
+	if _, err := NewChapterizer("", providers.TranscriptionOptions{}).Process(context.Background(), provider, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(provider.prompt, "[00:00:00] Alice: Hello everyone.\n[00:01:00] Now the roadmap.") {
+		t.Errorf("prompt = %q, want timestamped segments", provider.prompt)
+	}
+	if len(result.Chapters) != 2 || result.Chapters[1].End != 3*time.Minute {
+		t.Fatalf("Chapters = %+v", result.Chapters)
+	}
+
+	text, err := result.ToChapters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "00:00 Welcome\n01:00 Roadmap\n" {
+		t.Errorf("ToChapters() = %q", text)
+	}
+}
@@ -0,0 +1,104 @@
+// Package postprocess contains steps run on the merged transcript once all
+// chunks are transcribed, such as summaries and chapters.
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// MetadataSummary is the result metadata key holding the summary
+const MetadataSummary = "summary"
+
+// DefaultSummaryPrompt asks for a summary, decisions and action items
+const DefaultSummaryPrompt = "Summarize the following transcript. Use three Markdown sections: " +
+	"\"Summary\" with a few sentences on what was discussed, \"Decisions\" listing what was agreed, " +
+	"and \"Action Items\" listing each task with its owner and due date when mentioned. " +
+	"Write \"None\" under a section with nothing to list. Reply in the language of the transcript " +
+	"and do not add anything that is not in it."
+
+// Summarizer sends the merged transcript back to the provider with a
+// summary prompt and stores the reply in the result metadata
+type Summarizer struct {
+	prompt  string
+	options providers.TranscriptionOptions
+}
+
+// NewSummarizer creates a summarizer; an empty prompt uses DefaultSummaryPrompt
+func NewSummarizer(prompt string, options providers.TranscriptionOptions) *Summarizer {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultSummaryPrompt
+	}
+	return &Summarizer{prompt: prompt, options: options}
+}
+
+// Name returns the step name
+func (s *Summarizer) Name() string {
+	return "summary"
+}
+
+// Process summarizes the transcript with a text-only request
+func (s *Summarizer) Process(ctx context.Context, provider providers.LLMProvider, result *transcriber.TranscribeResult) ([]*providers.TranscriptionResult, error) {
+	text := strings.TrimSpace(result.Text)
+	if text == "" {
+		return nil, fmt.Errorf("transcript is empty")
+	}
+
+	resp, err := providers.GenerateText(ctx, provider, s.prompt+"\n\nTranscript:\n"+text, s.options)
+	if err != nil {
+		return nil, fmt.Errorf("summarization failed: %w", err)
+	}
+
+	summary := strings.TrimSpace(resp.Text)
+	if summary == "" {
+		return []*providers.TranscriptionResult{resp}, fmt.Errorf("provider returned an empty summary")
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataSummary] = summary
+	return []*providers.TranscriptionResult{resp}, nil
+}
+
+// Summary returns the summary stored in a result, or "" if there is none
+func Summary(result *transcriber.TranscribeResult) string {
+	if result == nil {
+		return ""
+	}
+	summary, _ := result.Metadata[MetadataSummary].(string)
+	return summary
+}
+
+// SummaryPath returns the summary file next to a transcript, e.g.
+// meeting.summary.md for meeting.txt
+func SummaryPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".summary.md"
+}
+
+// SaveSummary writes the summary of result to path, encrypted with c. A
+// nil cipher writes plaintext.
+func SaveSummary(result *transcriber.TranscribeResult, path string, c *encryption.Cipher) error {
+	summary := Summary(result)
+	if summary == "" {
+		return fmt.Errorf("result has no summary")
+	}
+
+	content, err := c.Encrypt([]byte(summary + "\n"))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt summary: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// audioOnlyProvider accepts audio but not text-only prompts
+type audioOnlyProvider struct{}
+
+func (audioOnlyProvider) Name() string { return "audio-only" }
+func (audioOnlyProvider) Transcribe(context.Context, *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	return nil, errors.New("not implemented")
+}
+func (audioOnlyProvider) TranscribeChunk(context.Context, *providers.AudioChunk, string, providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	return nil, errors.New("not implemented")
+}
+func (audioOnlyProvider) ValidateConfig() error      { return nil }
+func (audioOnlyProvider) SupportedFormats() []string { return nil }
+
+// textProvider answers text prompts with a fixed reply
+type textProvider struct {
+	audioOnlyProvider
+	reply  string
+	prompt string
+}
+
+func (p *textProvider) GenerateText(_ context.Context, prompt string, _ providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	p.prompt = prompt
+	result := &providers.TranscriptionResult{Text: p.reply, Metadata: map[string]interface{}{"model": "test"}}
+	providers.Usage{PromptTokens: 100, OutputTokens: 20, TotalTokens: 120}.SetMetadata(result.Metadata)
+	return result, nil
+}
+
+func TestSummarizer(t *testing.T) {
+	provider := &textProvider{reply: "## Summary\nBudget approved.\n"}
+	result := &transcriber.TranscribeResult{Text: "We approve the budget."}
+
+	responses, err := NewSummarizer("", providers.TranscriptionOptions{}).Process(context.Background(), provider, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 {
+		t.Errorf("Process() returned %d responses, want 1", len(responses))
+	}
+	if !strings.HasPrefix(provider.prompt, DefaultSummaryPrompt) || !strings.HasSuffix(provider.prompt, "We approve the budget.") {
+		t.Errorf("prompt = %q, want the default prompt followed by the transcript", provider.prompt)
+	}
+	if got := Summary(result); got != "## Summary\nBudget approved." {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	path := SummaryPath(filepath.Join(t.TempDir(), "meeting.txt"))
+	if filepath.Base(path) != "meeting.summary.md" {
+		t.Errorf("SummaryPath() = %s", path)
+	}
+	if err := SaveSummary(result, path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "## Summary\nBudget approved.\n" {
+		t.Errorf("summary file = %q", data)
+	}
+}
+
+func TestSummarizerUnsupportedProvider(t *testing.T) {
+	result := &transcriber.TranscribeResult{Text: "Hello."}
+	_, err := NewSummarizer("", providers.TranscriptionOptions{}).Process(context.Background(), audioOnlyProvider{}, result)
+	if !errors.Is(err, providers.ErrTextUnsupported) {
+		t.Errorf("Process() error = %v, want ErrTextUnsupported", err)
+	}
+	if Summary(result) != "" {
+		t.Error("summary stored after a failed request")
+	}
+}
@@ -0,0 +1,127 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// MetadataTranslationLanguage is the result metadata key holding the
+// language the segments were translated to
+const MetadataTranslationLanguage = "translation_language"
+
+// DefaultTranslationPrompt asks for one "N: translation" line per segment;
+// %s is replaced by the target language
+const DefaultTranslationPrompt = "Translate each numbered line of the following transcript into %s. " +
+	"Reply with one line per input line as \"N: translation\", keeping the numbers, " +
+	"and translate the meaning naturally for subtitles. Do not merge or split lines and write nothing else."
+
+// translationBatchSize is how many segments are translated per request,
+// so long transcripts stay well within the output token limit
+const translationBatchSize = 100
+
+// translationLine matches a reply line such as "12: Hola", "[12] Hola" or "12. Hola"
+var translationLine = regexp.MustCompile(`^\[?(\d+)\]?\s*[:.)\-]?\s*(.+?)\s*$`)
+
+// Translator translates each segment of the merged transcript with
+// text-only requests and stores the translations in the segments, for
+// bilingual subtitles
+type Translator struct {
+	language string
+	prompt   string
+	options  providers.TranscriptionOptions
+}
+
+// NewTranslator creates a translator to language, e.g. "Spanish" or "ja";
+// an empty prompt uses DefaultTranslationPrompt
+func NewTranslator(language, prompt string, options providers.TranscriptionOptions) *Translator {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultTranslationPrompt
+	}
+	if strings.Contains(prompt, "%s") {
+		prompt = fmt.Sprintf(prompt, language)
+	}
+	return &Translator{language: language, prompt: prompt, options: options}
+}
+
+// Name returns the step name
+func (t *Translator) Name() string {
+	return "translation"
+}
+
+// Process sends the numbered segments in batches and stores the
+// translation of each in its segment. Segments missing from a reply are
+// left untranslated.
+func (t *Translator) Process(ctx context.Context, provider providers.LLMProvider, result *transcriber.TranscribeResult) ([]*providers.TranscriptionResult, error) {
+	if len(result.Segments) == 0 {
+		return nil, fmt.Errorf("transcript has no segments to translate")
+	}
+
+	var responses []*providers.TranscriptionResult
+	translated := 0
+	for start := 0; start < len(result.Segments); start += translationBatchSize {
+		end := min(start+translationBatchSize, len(result.Segments))
+		batch := result.Segments[start:end]
+
+		var transcript strings.Builder
+		for i, segment := range batch {
+			transcript.WriteString(fmt.Sprintf("%d: %s\n", i+1, strings.Join(strings.Fields(segment.Text), " ")))
+		}
+
+		resp, err := providers.GenerateText(ctx, provider, t.prompt+"\n\nTranscript:\n"+transcript.String(), t.options)
+		if err != nil {
+			return responses, fmt.Errorf("translation failed: %w", err)
+		}
+		responses = append(responses, resp)
+
+		for i, translation := range parseTranslations(resp.Text, len(batch)) {
+			batch[i].Translation = translation
+			translated++
+		}
+	}
+	if translated == 0 {
+		return responses, fmt.Errorf("provider reply contained no translations")
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataTranslationLanguage] = t.language
+	return responses, nil
+}
+
+// parseTranslations reads "N: translation" lines numbered 1 to n into a
+// map by zero-based index, skipping anything else
+func parseTranslations(reply string, n int) map[int]string {
+	translations := make(map[int]string)
+	for _, line := range strings.Split(reply, "\n") {
+		match := translationLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 || number > n {
+			continue
+		}
+		if _, ok := translations[number-1]; ok {
+			continue
+		}
+		translations[number-1] = match[2]
+	}
+	return translations
+}
+
+// TranslationLanguage returns the language the result was translated to,
+// or "" if it was not translated
+func TranslationLanguage(result *transcriber.TranscribeResult) string {
+	if result == nil {
+		return ""
+	}
+	language, _ := result.Metadata[MetadataTranslationLanguage].(string)
+	return language
+}
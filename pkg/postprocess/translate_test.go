@@ -0,0 +1,54 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func TestParseTranslations(t *testing.T) {
+	reply := "Here you go:\n1: Hola.\n[2] ¿Qué tal?\n3. Adiós.\n1: Duplicate\n7: Out of range\n"
+
+	got := parseTranslations(reply, 3)
+	want := map[int]string{0: "Hola.", 1: "¿Qué tal?", 2: "Adiós."}
+	if len(got) != len(want) {
+		t.Fatalf("parseTranslations() = %v, want %v", got, want)
+	}
+	for i, translation := range want {
+		if got[i] != translation {
+			t.Errorf("translation %d = %q, want %q", i, got[i], translation)
+		}
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	provider := &textProvider{reply: "1: Hola a todos.\n2: Empecemos."}
+	result := &transcriber.TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello everyone.", Start: 0, End: 2 * time.Second},
+			{Text: "Let's\nbegin.", Start: 2 * time.Second, End: 4 * time.Second},
+		},
+	}
+
+	if _, err := NewTranslator("Spanish", "", providers.TranscriptionOptions{}).Process(context.Background(), provider, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(provider.prompt, "into Spanish") || !strings.HasSuffix(provider.prompt, "1: Hello everyone.\n2: Let's begin.\n") {
+		t.Errorf("prompt = %q, want numbered segments", provider.prompt)
+	}
+	if result.Segments[0].Translation != "Hola a todos." || result.Segments[1].Translation != "Empecemos." {
+		t.Errorf("Segments = %+v", result.Segments)
+	}
+	if got := TranslationLanguage(result); got != "Spanish" {
+		t.Errorf("TranslationLanguage() = %q", got)
+	}
+
+	provider.reply = "I cannot translate this."
+	if _, err := NewTranslator("Spanish", "", providers.TranscriptionOptions{}).Process(context.Background(), provider, result); err == nil {
+		t.Error("Process() succeeded without translations")
+	}
+}
@@ -0,0 +1,119 @@
+// Package pricing estimates transcription cost from token usage and audio
+// duration using a per-model price table.
+package pricing
+
+import (
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// Price is the cost of a model in USD
+type Price struct {
+	InputPerMillion  float64 `yaml:"input_per_million" mapstructure:"input_per_million" json:"input_per_million"`
+	OutputPerMillion float64 `yaml:"output_per_million" mapstructure:"output_per_million" json:"output_per_million"`
+
+	// Models billed by audio length instead of tokens (e.g. Whisper)
+	PerAudioHour float64 `yaml:"per_audio_hour" mapstructure:"per_audio_hour" json:"per_audio_hour"`
+}
+
+// Cost returns the cost of the given usage and audio duration
+func (p Price) Cost(usage providers.Usage, audio time.Duration) float64 {
+	return float64(usage.PromptTokens)/1e6*p.InputPerMillion +
+		float64(usage.OutputTokens)/1e6*p.OutputPerMillion +
+		audio.Hours()*p.PerAudioHour
+}
+
+// Table maps model names to prices
+type Table map[string]Price
+
+// DefaultTable returns list prices (paid tier, audio input) at the time of
+// writing. Override them with the pricing section of the config file.
+func DefaultTable() Table {
+	return Table{
+		"gemini-2.5-pro":         {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+		"gemini-2.5-flash":       {InputPerMillion: 1.00, OutputPerMillion: 2.50},
+		"gemini-2.5-flash-lite":  {InputPerMillion: 0.30, OutputPerMillion: 0.40},
+		"gemini-2.0-flash":       {InputPerMillion: 0.70, OutputPerMillion: 0.40},
+		"gemini-2.0-flash-lite":  {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"gemini-1.5-pro":         {InputPerMillion: 1.25, OutputPerMillion: 5.00},
+		"gemini-1.5-flash":       {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+		"whisper-large-v3":       {PerAudioHour: 0.111},
+		"whisper-large-v3-turbo": {PerAudioHour: 0.04},
+		"whisper.cpp":            {},
+	}
+}
+
+// Merge returns a copy of t with the entries of overrides added or replaced
+func (t Table) Merge(overrides Table) Table {
+	merged := make(Table, len(t)+len(overrides))
+	for model, price := range t {
+		merged[model] = price
+	}
+	for model, price := range overrides {
+		merged[model] = price
+	}
+	return merged
+}
+
+// Lookup returns the price for a model. Versioned names such as
+// "gemini-2.5-flash-preview-05-20" fall back to the longest listed prefix.
+func (t Table) Lookup(model string) (Price, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+
+	best := ""
+	for name := range t {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// Cost estimates the cost for a model, reporting false if the model is unknown
+func (t Table) Cost(model string, usage providers.Usage, audio time.Duration) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(usage, audio), true
+}
+
+// outputTokensPerSecond approximates transcript tokens per second of speech,
+// including timestamps and speaker labels
+const outputTokensPerSecond = 5
+
+// EstimateUsage predicts the usage of transcribing audio before it is sent
+func EstimateUsage(audio time.Duration, prompt string) providers.Usage {
+	usage := providers.Usage{
+		PromptTokens: providers.EstimateTokens(audio, prompt),
+		OutputTokens: int(audio.Seconds() * outputTokensPerSecond),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.OutputTokens
+	return usage
+}
+
+// EstimateCost predicts the cost of sending audio to every model in models,
+// reporting false if there are no models or any of them is unknown
+func (t Table) EstimateCost(models []string, audio time.Duration, prompt string) (float64, bool) {
+	if len(models) == 0 {
+		return 0, false
+	}
+
+	usage := EstimateUsage(audio, prompt)
+	total := 0.0
+	for _, model := range models {
+		cost, ok := t.Cost(model, usage, audio)
+		if !ok {
+			return 0, false
+		}
+		total += cost
+	}
+	return total, true
+}
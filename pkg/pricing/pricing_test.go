@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestTableLookup(t *testing.T) {
+	table := DefaultTable()
+
+	tests := []struct {
+		model string
+		want  string
+		found bool
+	}{
+		{"gemini-2.5-flash", "gemini-2.5-flash", true},
+		{"gemini-2.5-flash-preview-05-20", "gemini-2.5-flash", true},
+		{"gemini-2.5-flash-lite-preview-06-17", "gemini-2.5-flash-lite", true},
+		{"gemini-2.5-flashy", "", false},
+		{"unknown-model", "", false},
+	}
+	for _, tt := range tests {
+		price, ok := table.Lookup(tt.model)
+		if ok != tt.found {
+			t.Errorf("Lookup(%q) found = %v, want %v", tt.model, ok, tt.found)
+			continue
+		}
+		if ok && price != table[tt.want] {
+			t.Errorf("Lookup(%q) = %+v, want price of %s", tt.model, price, tt.want)
+		}
+	}
+}
+
+func TestCost(t *testing.T) {
+	table := DefaultTable().Merge(Table{
+		"custom": {InputPerMillion: 2, OutputPerMillion: 8},
+	})
+
+	cost, ok := table.Cost("custom", providers.Usage{PromptTokens: 500_000, OutputTokens: 250_000}, time.Hour)
+	if !ok || math.Abs(cost-3.0) > 1e-9 {
+		t.Errorf("Cost(custom) = %v, %v; want 3.0", cost, ok)
+	}
+
+	// Whisper models are billed by audio length only
+	cost, ok = table.Cost("whisper-large-v3", providers.Usage{}, 30*time.Minute)
+	if !ok || math.Abs(cost-0.0555) > 1e-9 {
+		t.Errorf("Cost(whisper-large-v3) = %v, %v; want 0.0555", cost, ok)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	table := Table{
+		"a": {InputPerMillion: 1, OutputPerMillion: 2},
+		"b": {PerAudioHour: 0.5},
+	}
+
+	// One minute: 1920 input tokens and 300 output tokens
+	single, ok := table.EstimateCost([]string{"a"}, time.Minute, "")
+	if want := 1920/1e6 + 300*2/1e6; !ok || math.Abs(single-want) > 1e-12 {
+		t.Errorf("EstimateCost(a) = %v, %v; want %v", single, ok, want)
+	}
+
+	// Ensembles are billed once per model
+	both, ok := table.EstimateCost([]string{"a", "b"}, time.Minute, "")
+	if want := single + 0.5/60; !ok || math.Abs(both-want) > 1e-12 {
+		t.Errorf("EstimateCost(a, b) = %v, %v; want %v", both, ok, want)
+	}
+
+	if _, ok := table.EstimateCost([]string{"a", "unknown"}, time.Minute, ""); ok {
+		t.Error("EstimateCost() with an unknown model reported ok")
+	}
+	if _, ok := table.EstimateCost(nil, time.Minute, ""); ok {
+		t.Error("EstimateCost() without models reported ok")
+	}
+}
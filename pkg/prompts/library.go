@@ -0,0 +1,129 @@
+// Package prompts loads the named prompt library: prompt files in a
+// directory, such as ~/.config/gollmscribe/prompts/sales-call.md, plus
+// the prompt templates from the config file.
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileExtensions are the prompt file types loaded from the directory
+var fileExtensions = []string{".md", ".txt"}
+
+// Prompt is a named prompt
+type Prompt struct {
+	Name   string
+	Text   string
+	Source string // File the prompt was read from, or "config"
+}
+
+// Library holds prompts by name. Names are case-insensitive.
+type Library struct {
+	prompts map[string]Prompt
+}
+
+// DefaultDir returns the prompt directory in the user config directory
+func DefaultDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gollmscribe", "prompts"), nil
+}
+
+// Load builds a library from config templates and the prompt files in
+// dir, named after the file without its extension. Files take precedence
+// over templates of the same name. A missing dir is not an error.
+func Load(dir string, templates map[string]string) (*Library, error) {
+	lib := &Library{prompts: make(map[string]Prompt)}
+	for name, text := range templates {
+		if text = strings.TrimSpace(text); text != "" {
+			lib.add(Prompt{Name: name, Text: text, Source: "config"})
+		}
+	}
+
+	if dir == "" {
+		return lib, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return lib, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !isPromptFile(ext) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt %s: %w", entry.Name(), err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			continue
+		}
+		lib.add(Prompt{Name: strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), Text: text, Source: path})
+	}
+	return lib, nil
+}
+
+func isPromptFile(ext string) bool {
+	for _, e := range fileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Library) add(prompt Prompt) {
+	l.prompts[strings.ToLower(prompt.Name)] = prompt
+}
+
+// Get returns the text of a named prompt
+func (l *Library) Get(name string) (string, error) {
+	if l != nil {
+		if prompt, ok := l.prompts[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return prompt.Text, nil
+		}
+	}
+	names := l.Names()
+	if len(names) == 0 {
+		return "", fmt.Errorf("unknown prompt %q: the prompt library is empty", name)
+	}
+	return "", fmt.Errorf("unknown prompt %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Prompts returns every prompt sorted by name
+func (l *Library) Prompts() []Prompt {
+	if l == nil {
+		return nil
+	}
+	list := make([]Prompt, 0, len(l.prompts))
+	for _, prompt := range l.prompts {
+		list = append(list, prompt)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// Names returns the prompt names sorted
+func (l *Library) Names() []string {
+	prompts := l.Prompts()
+	names := make([]string, len(prompts))
+	for i, prompt := range prompts {
+		names[i] = prompt.Name
+	}
+	return names
+}
@@ -0,0 +1,45 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sales-call.md": "\nTranscribe this sales call and list objections.\n",
+		"meeting.md":    "Meeting prompt from a file.",
+		"notes.json":    "{}",
+		"empty.txt":     "  ",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib, err := Load(dir, map[string]string{"meeting": "Meeting prompt from config.", "lecture": "Lecture."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(lib.Names(), ","); got != "lecture,meeting,sales-call" {
+		t.Errorf("Names() = %s", got)
+	}
+	if text, err := lib.Get("Sales-Call"); err != nil || text != "Transcribe this sales call and list objections." {
+		t.Errorf("Get(Sales-Call) = %q, %v", text, err)
+	}
+	if text, _ := lib.Get("meeting"); text != "Meeting prompt from a file." {
+		t.Errorf("Get(meeting) = %q, want the file to override config", text)
+	}
+	if _, err := lib.Get("missing"); err == nil || !strings.Contains(err.Error(), "sales-call") {
+		t.Errorf("Get(missing) error = %v, want the available names", err)
+	}
+
+	if lib, err := Load(filepath.Join(dir, "nope"), nil); err != nil || len(lib.Names()) != 0 {
+		t.Errorf("Load(missing dir) = %v, %v", lib.Names(), err)
+	}
+}
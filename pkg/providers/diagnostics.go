@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+)
+
+// Request size limits of the hosted APIs, including encoding overhead
+const (
+	GeminiMaxRequestSize = 20 << 20 // Inline data, base64-encoded in the JSON body
+	GroqMaxRequestSize   = 25 << 20 // Multipart upload
+)
+
+// maxErrorMessage bounds how much of an unparsed error body is kept
+const maxErrorMessage = 500
+
+// shrinkHint suggests how to make chunk requests smaller
+const shrinkHint = "lower --chunk-minutes, or make chunks smaller with --sample-rate 16000, --channels 1 or a higher audio.quality"
+
+// RequestTooLargeError is returned before sending a request bigger than
+// the provider accepts, instead of uploading it only to be rejected
+type RequestTooLargeError struct {
+	Provider string
+	Size     int64
+	Limit    int64
+}
+
+// Error implements the error interface
+func (e *RequestTooLargeError) Error() string {
+	return fmt.Sprintf("%s request of %s exceeds its %s limit: %s",
+		e.Provider, humanize.Size(e.Size), humanize.Size(e.Limit), shrinkHint)
+}
+
+// CheckRequestSize returns a RequestTooLargeError if a request of size
+// bytes exceeds limit; a limit of 0 or less means none
+func CheckRequestSize(provider string, size, limit int64) error {
+	if limit > 0 && size > limit {
+		return &RequestTooLargeError{Provider: provider, Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// Hint returns what the user can do about a common failure, such as an
+// oversized chunk or a model without audio input, or "" if there is
+// nothing specific to suggest
+func (e *StatusError) Hint() string {
+	message := strings.ToLower(e.Message)
+	has := func(words ...string) bool {
+		for _, word := range words {
+			if strings.Contains(message, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case e.StatusCode == http.StatusRequestEntityTooLarge ||
+		has("payload size", "too large", "file size", "request size"):
+		return "the chunk is too large for the provider; " + shrinkHint
+	case has("token") && has("exceed", "limit", "maximum"):
+		return "the chunk has more tokens than the model accepts; lower --chunk-minutes"
+	case e.StatusCode == http.StatusBadRequest && has("audio", "mime", "modality", "media") &&
+		has("not supported", "unsupported", "does not support", "invalid"):
+		return "check that the model (provider.model) accepts audio input, and try another audio.output_format"
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		has("api key", "api_key", "permission denied"):
+		return "check the API key (provider.api_key) and that it may use this model"
+	case e.StatusCode == http.StatusNotFound:
+		return "check the model name (provider.model); it may not exist or not be available in this region"
+	}
+	return ""
+}
+
+// apiErrorMessage extracts the message from a JSON error body of the
+// shapes used by Gemini ({"error": {"message": ...}}), OpenAI-compatible
+// APIs and whisper.cpp ({"error": "..."}). Other bodies are returned
+// trimmed and shortened, so HTML error pages are not dumped in full.
+func apiErrorMessage(body string) string {
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal([]byte(body), &parsed) == nil && len(parsed.Error) > 0 {
+		var detail struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		}
+		if json.Unmarshal(parsed.Error, &detail) == nil && detail.Message != "" {
+			if detail.Status != "" {
+				return detail.Status + ": " + detail.Message
+			}
+			return detail.Message
+		}
+		var message string
+		if json.Unmarshal(parsed.Error, &message) == nil && message != "" {
+			return message
+		}
+	}
+
+	body = strings.Join(strings.Fields(body), " ")
+	if len(body) > maxErrorMessage {
+		body = body[:maxErrorMessage] + "..."
+	}
+	return body
+}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewHTTPErrorDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string // In the message
+		hint   string // In the hint, "" for none
+	}{
+		{
+			name:   "gemini payload too large",
+			status: http.StatusBadRequest,
+			body:   `{"error": {"code": 400, "message": "Request payload size exceeds the limit: 20971520 bytes.", "status": "INVALID_ARGUMENT"}}`,
+			want:   "INVALID_ARGUMENT: Request payload size exceeds the limit",
+			hint:   "--chunk-minutes",
+		},
+		{
+			name:   "groq file too large",
+			status: http.StatusRequestEntityTooLarge,
+			body:   `{"error": {"message": "Request Entity Too Large", "type": "invalid_request_error"}}`,
+			want:   "Request Entity Too Large",
+			hint:   "--sample-rate",
+		},
+		{
+			name:   "model without audio",
+			status: http.StatusBadRequest,
+			body:   `{"error": {"message": "Audio input modality is not supported for this model", "status": "INVALID_ARGUMENT"}}`,
+			want:   "modality is not supported",
+			hint:   "provider.model",
+		},
+		{
+			name:   "bad key",
+			status: http.StatusBadRequest,
+			body:   `{"error": {"message": "API key not valid. Please pass a valid API key.", "status": "INVALID_ARGUMENT"}}`,
+			want:   "API key not valid",
+			hint:   "provider.api_key",
+		},
+		{
+			name:   "whisper.cpp",
+			status: http.StatusInternalServerError,
+			body:   `{"error": "failed to read WAV file"}`,
+			want:   "failed to read WAV file",
+		},
+		{
+			name:   "html page",
+			status: http.StatusBadGateway,
+			body:   "<html>\n  <body>" + strings.Repeat("x", 2*maxErrorMessage) + "</body>\n</html>",
+			want:   "<html> <body>xxx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHTTPError(&http.Response{StatusCode: tt.status, Header: http.Header{}}, tt.body)
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("NewHTTPError() = %T, want a StatusError", err)
+			}
+			if !strings.Contains(statusErr.Message, tt.want) || len(statusErr.Message) > maxErrorMessage+3 {
+				t.Errorf("Message = %q, want it to contain %q", statusErr.Message, tt.want)
+			}
+			hint := statusErr.Hint()
+			if (tt.hint == "") != (hint == "") || !strings.Contains(hint, tt.hint) {
+				t.Errorf("Hint() = %q, want one mentioning %q", hint, tt.hint)
+			}
+			if hint != "" && !strings.Contains(err.Error(), hint) {
+				t.Errorf("Error() = %q does not include the hint", err)
+			}
+		})
+	}
+}
+
+func TestCheckRequestSize(t *testing.T) {
+	if err := CheckRequestSize("gemini", GeminiMaxRequestSize, GeminiMaxRequestSize); err != nil {
+		t.Errorf("request at the limit: %v", err)
+	}
+	if err := CheckRequestSize("gemini", 1<<40, 0); err != nil {
+		t.Errorf("request without a limit: %v", err)
+	}
+
+	err := CheckRequestSize("gemini", 30<<20, GeminiMaxRequestSize)
+	var tooLarge *RequestTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("CheckRequestSize() = %v, want a RequestTooLargeError", err)
+	}
+	if IsRetryable(err) {
+		t.Error("oversized request is retryable")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "30.0 MB") || !strings.Contains(msg, "20.0 MB") {
+		t.Errorf("Error() = %q, want the size and limit", msg)
+	}
+}
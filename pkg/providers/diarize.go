@@ -0,0 +1,17 @@
+package providers
+
+// Diarizer is implemented by providers that can label speakers from the
+// audio themselves, instead of following speaker instructions in the
+// prompt
+type Diarizer interface {
+	// Diarizes reports whether the provider labels the speakers of its
+	// segments when TranscriptionOptions.Diarize is set
+	Diarizes() bool
+}
+
+// Diarizes reports whether p labels speakers natively. Providers that do
+// not implement Diarizer rely on the prompt.
+func Diarizes(p LLMProvider) bool {
+	diarizer, ok := p.(Diarizer)
+	return ok && diarizer.Diarizes()
+}
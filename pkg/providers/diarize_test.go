@@ -0,0 +1,41 @@
+package providers
+
+import "testing"
+
+func TestDiarizesDecorators(t *testing.T) {
+	diarizing := &diarizingFake{fakeProvider: fakeProvider{name: "whispercpp"}}
+	prompted := &fakeProvider{name: "gemini"}
+
+	if Diarizes(prompted) {
+		t.Error("provider without Diarizer should not diarize")
+	}
+	if !Diarizes(NewFallbackProvider(diarizing, diarizing)) {
+		t.Error("fallback chain of diarizing providers should diarize")
+	}
+	if Diarizes(NewFallbackProvider(diarizing, prompted)) {
+		t.Error("fallback chain with a prompt-labeled provider should not diarize")
+	}
+
+	members := []EnsembleMember{{Provider: diarizing}, {Provider: diarizing}}
+	voting, err := NewEnsembleProvider(EnsembleVote, nil, members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Diarizes(voting) {
+		t.Error("voting ensemble of diarizing providers should diarize")
+	}
+	adjudicated, err := NewEnsembleProvider(EnsembleAdjudicate, prompted, members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Diarizes(adjudicated) {
+		t.Error("adjudicated ensemble should carry the adjudicator's labels")
+	}
+}
+
+// diarizingFake is a fakeProvider that labels speakers natively
+type diarizingFake struct {
+	fakeProvider
+}
+
+func (d *diarizingFake) Diarizes() bool { return true }
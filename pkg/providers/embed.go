@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrEmbeddingsUnsupported is returned when embedding text with a provider
+// that has no embedding model
+var ErrEmbeddingsUnsupported = errors.New("provider does not support embeddings")
+
+// Embedder is implemented by providers that can turn text into vectors,
+// used for semantic search over transcripts
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Embed embeds texts with p, or returns ErrEmbeddingsUnsupported if p does
+// not implement Embedder
+func Embed(ctx context.Context, p LLMProvider, texts []string) ([][]float32, error) {
+	embedder, ok := p.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", p.Name(), ErrEmbeddingsUnsupported)
+	}
+	return embedder.Embed(ctx, texts)
+}
@@ -0,0 +1,461 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// EnsembleMethod selects how an EnsembleProvider reconciles disagreements
+type EnsembleMethod string
+
+const (
+	// EnsembleVote aligns the transcripts word by word and picks the
+	// confidence-weighted majority at every position (ROVER-style)
+	EnsembleVote EnsembleMethod = "vote"
+	// EnsembleAdjudicate asks an adjudicator model to produce the final
+	// transcript from the audio and all candidate transcripts
+	EnsembleAdjudicate EnsembleMethod = "adjudicate"
+)
+
+// EnsembleMember is a provider taking part in an ensemble
+type EnsembleMember struct {
+	Provider LLMProvider
+	Weight   float64 // Relative trust in this provider (default 1)
+}
+
+// EnsembleProvider transcribes every chunk with several providers and
+// reconciles the results into a single, higher-accuracy transcript
+type EnsembleProvider struct {
+	members     []EnsembleMember
+	method      EnsembleMethod
+	adjudicator LLMProvider
+}
+
+// NewEnsembleProvider creates an ensemble of at least two providers.
+// The adjudicator is only used with EnsembleAdjudicate.
+func NewEnsembleProvider(method EnsembleMethod, adjudicator LLMProvider, members ...EnsembleMember) (*EnsembleProvider, error) {
+	if len(members) < 2 {
+		return nil, fmt.Errorf("ensemble requires at least two providers")
+	}
+	switch method {
+	case "":
+		method = EnsembleVote
+	case EnsembleVote:
+	case EnsembleAdjudicate:
+		if adjudicator == nil {
+			return nil, fmt.Errorf("adjudicate method requires an adjudicator provider")
+		}
+	default:
+		return nil, fmt.Errorf("unknown ensemble method: %s", method)
+	}
+
+	for i := range members {
+		if members[i].Weight <= 0 {
+			members[i].Weight = 1
+		}
+	}
+
+	return &EnsembleProvider{
+		members:     members,
+		method:      method,
+		adjudicator: adjudicator,
+	}, nil
+}
+
+// Name returns the ensemble name, e.g. "ensemble(gemini+groq)"
+func (e *EnsembleProvider) Name() string {
+	names := make([]string, len(e.members))
+	for i, m := range e.members {
+		names[i] = m.Provider.Name()
+	}
+	return "ensemble(" + strings.Join(names, "+") + ")"
+}
+
+// Transcribe reads the request audio into memory, which every member
+// and the adjudicator are sent, and transcribes it as a chunk
+func (e *EnsembleProvider) Transcribe(ctx context.Context, req *TranscriptionRequest) (*TranscriptionResult, error) {
+	replay, err := NewReplayableRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := replay.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &AudioChunk{
+		Data:     data,
+		Format:   req.AudioFormat,
+		MimeType: req.MimeType,
+	}
+	return e.TranscribeChunk(ctx, chunk, req.Prompt, req.Options)
+}
+
+// TranscribeChunk transcribes the chunk with every member and reconciles the results
+func (e *EnsembleProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	log := logger.WithComponent("ensemble-provider")
+
+	results := make([]*TranscriptionResult, len(e.members))
+	errs := make([]error, len(e.members))
+
+	var wg sync.WaitGroup
+	for i, member := range e.members {
+		wg.Add(1)
+		go func(index int, p LLMProvider) {
+			defer wg.Done()
+			results[index], errs[index] = p.TranscribeChunk(ctx, chunk, prompt, options)
+		}(i, member.Provider)
+	}
+	wg.Wait()
+
+	// Keep whatever succeeded; a single survivor is returned as-is
+	var candidates []scoredResult
+	for i, result := range results {
+		if errs[i] != nil {
+			log.Warn().Err(errs[i]).Str("provider", e.members[i].Provider.Name()).Msg("Ensemble member failed")
+			continue
+		}
+		candidates = append(candidates, scoredResult{
+			result:   result,
+			provider: e.members[i].Provider.Name(),
+			weight:   e.members[i].Weight * resultConfidence(result),
+		})
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("all ensemble members failed: %w", errors.Join(errs...))
+	case 1:
+		return candidates[0].result, nil
+	}
+
+	// Highest weight first; it acts as the backbone for alignment
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+
+	// Every member request is billed, so usage is the sum over all of them
+	var usage Usage
+	for _, c := range candidates {
+		usage = usage.Add(UsageFromMetadata(c.result.Metadata))
+	}
+
+	var final *TranscriptionResult
+	switch e.method {
+	case EnsembleAdjudicate:
+		adjudicated, err := e.adjudicator.TranscribeChunk(ctx, chunk, adjudicationPrompt(prompt, candidates), options)
+		if err != nil {
+			log.Warn().Err(err).Msg("Adjudication failed, falling back to voting")
+			final = voteResults(candidates)
+		} else {
+			usage = usage.Add(UsageFromMetadata(adjudicated.Metadata))
+			final = adjudicated
+		}
+	default:
+		final = voteResults(candidates)
+	}
+
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.provider
+	}
+	if final.Metadata == nil {
+		final.Metadata = make(map[string]interface{})
+	}
+	if !usage.IsZero() {
+		usage.SetMetadata(final.Metadata)
+	}
+	final.Metadata["ensemble_providers"] = names
+	final.Metadata["ensemble_method"] = string(e.method)
+	final.ChunkID = chunk.ChunkID
+
+	return final, nil
+}
+
+// GenerateText answers a text-only prompt with the adjudicator, or the
+// first member when there is none; the text needs no reconciling
+func (e *EnsembleProvider) GenerateText(ctx context.Context, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	if e.adjudicator != nil {
+		return GenerateText(ctx, e.adjudicator, prompt, options)
+	}
+	return GenerateText(ctx, e.members[0].Provider, prompt, options)
+}
+
+// Embed embeds texts with the adjudicator, or the first member when there
+// is none
+func (e *EnsembleProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.adjudicator != nil {
+		return Embed(ctx, e.adjudicator, texts)
+	}
+	return Embed(ctx, e.members[0].Provider, texts)
+}
+
+// ValidateConfig validates every member and the adjudicator
+func (e *EnsembleProvider) ValidateConfig() error {
+	for _, m := range e.members {
+		if err := m.Provider.ValidateConfig(); err != nil {
+			return fmt.Errorf("%s: %w", m.Provider.Name(), err)
+		}
+	}
+	if e.adjudicator != nil {
+		return e.adjudicator.ValidateConfig()
+	}
+	return nil
+}
+
+// SupportedFormats returns the formats supported by the first member
+func (e *EnsembleProvider) SupportedFormats() []string {
+	return e.members[0].Provider.SupportedFormats()
+}
+
+// Models returns the models of every member, plus the adjudicator's when
+// adjudicating, since each chunk is sent to all of them
+func (e *EnsembleProvider) Models() []string {
+	var models []string
+	for _, m := range e.members {
+		models = append(models, Models(m.Provider)...)
+	}
+	if e.method == EnsembleAdjudicate && e.adjudicator != nil {
+		models = append(models, Models(e.adjudicator)...)
+	}
+	return models
+}
+
+// Diarizes reports whether every member labels speakers natively. An
+// adjudicator rewrites the transcript from text, so adjudicated results
+// carry its labels instead.
+func (e *EnsembleProvider) Diarizes() bool {
+	if e.method == EnsembleAdjudicate {
+		return false
+	}
+	for _, m := range e.members {
+		if !Diarizes(m.Provider) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsLocal reports whether every member and the adjudicator are local
+func (e *EnsembleProvider) IsLocal() bool {
+	for _, m := range e.members {
+		if !IsLocal(m.Provider) {
+			return false
+		}
+	}
+	return e.adjudicator == nil || IsLocal(e.adjudicator)
+}
+
+// scoredResult is a member result with its voting weight
+type scoredResult struct {
+	result   *TranscriptionResult
+	provider string
+	weight   float64
+}
+
+// resultConfidence averages segment confidences, defaulting to 1 when unknown
+func resultConfidence(result *TranscriptionResult) float64 {
+	var sum float64
+	var n int
+	for _, seg := range result.Segments {
+		if seg.Confidence > 0 {
+			sum += float64(seg.Confidence)
+			n++
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return sum / float64(n)
+}
+
+// adjudicationPrompt asks the adjudicator to reconcile candidate transcripts
+func adjudicationPrompt(original string, candidates []scoredResult) string {
+	var b strings.Builder
+	b.WriteString("Several speech recognition systems transcribed the attached audio. ")
+	b.WriteString("Listen to the audio and produce the single most accurate transcript. ")
+	b.WriteString("Where the candidates disagree, choose what is actually spoken. ")
+	b.WriteString("Output only the final transcript.\n")
+	if original != "" {
+		b.WriteString("\nOriginal transcription instructions:\n")
+		b.WriteString(original)
+		b.WriteString("\n")
+	}
+	for i, c := range candidates {
+		fmt.Fprintf(&b, "\nCandidate %d:\n%s\n", i+1, c.result.Text)
+	}
+	return b.String()
+}
+
+// voteResults reconciles candidates ROVER-style. Every candidate is aligned
+// against the backbone (candidates[0]) and at each position the word with the
+// highest summed weight wins.
+func voteResults(candidates []scoredResult) *TranscriptionResult {
+	backbone := strings.Fields(candidates[0].result.Text)
+
+	// slots[2*i+1] holds votes for backbone word i, slots[2*i] for insertions before it
+	slots := make([]map[string]float64, 2*len(backbone)+1)
+	for i := range slots {
+		slots[i] = make(map[string]float64)
+	}
+	for i, word := range backbone {
+		slots[2*i+1][word] += candidates[0].weight
+	}
+	for i := 0; i <= len(backbone); i++ {
+		slots[2*i][""] += candidates[0].weight
+	}
+
+	agreed := 0
+	for _, c := range candidates[1:] {
+		hyp := strings.Fields(c.result.Text)
+		insertions := make(map[int][]string)
+		for _, op := range alignWords(backbone, hyp) {
+			switch {
+			case op.ref >= 0:
+				word := ""
+				if op.hyp >= 0 {
+					word = hyp[op.hyp]
+				}
+				slots[2*op.ref+1][word] += c.weight
+			default:
+				// Insertion before the next backbone word
+				insertions[op.at] = append(insertions[op.at], hyp[op.hyp])
+			}
+		}
+		for i := 0; i <= len(backbone); i++ {
+			slots[2*i][strings.Join(insertions[i], " ")] += c.weight
+		}
+	}
+
+	var words []string
+	for i, votes := range slots {
+		word := pickVote(votes, i%2 == 1, backbone, i)
+		if len(votes) == 1 {
+			agreed++
+		}
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+
+	base := candidates[0].result
+	result := &TranscriptionResult{
+		Text:     strings.Join(words, " "),
+		Language: base.Language,
+		Duration: base.Duration,
+		ChunkID:  base.ChunkID,
+		Metadata: make(map[string]interface{}),
+	}
+	for k, v := range base.Metadata {
+		result.Metadata[k] = v
+	}
+	// Segment timings only stay valid if the backbone text survived unchanged
+	if result.Text == strings.Join(backbone, " ") {
+		result.Segments = base.Segments
+	}
+	result.Metadata["ensemble_agreement"] = float64(agreed) / float64(len(slots))
+
+	return result
+}
+
+// pickVote returns the highest-weighted word, preferring the backbone on ties
+func pickVote(votes map[string]float64, isWordSlot bool, backbone []string, slot int) string {
+	preferred := ""
+	if isWordSlot {
+		preferred = backbone[slot/2]
+	}
+
+	best, bestWeight := preferred, votes[preferred]
+	keys := make([]string, 0, len(votes))
+	for k := range votes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, word := range keys {
+		if votes[word] > bestWeight {
+			best, bestWeight = word, votes[word]
+		}
+	}
+	return best
+}
+
+// alignOp is one step of a word alignment: ref/hyp are indexes or -1,
+// and at is the backbone position an insertion precedes
+type alignOp struct {
+	ref, hyp, at int
+}
+
+// alignWords computes a minimum edit distance alignment between two word sequences
+func alignWords(ref, hyp []string) []alignOp {
+	n, m := len(ref), len(hyp)
+	cost := make([][]int, n+1)
+	for i := range cost {
+		cost[i] = make([]int, m+1)
+		cost[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		cost[0][j] = j
+	}
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			sub := cost[i-1][j-1]
+			if normalizeWord(ref[i-1]) != normalizeWord(hyp[j-1]) {
+				sub++
+			}
+			cost[i][j] = minInt(sub, minInt(cost[i-1][j]+1, cost[i][j-1]+1))
+		}
+	}
+
+	// Trace back from the end
+	var ops []alignOp
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && cost[i][j] == cost[i-1][j-1]+boolToInt(normalizeWord(ref[i-1]) != normalizeWord(hyp[j-1])):
+			ops = append(ops, alignOp{ref: i - 1, hyp: j - 1})
+			i--
+			j--
+		case i > 0 && cost[i][j] == cost[i-1][j]+1:
+			ops = append(ops, alignOp{ref: i - 1, hyp: -1})
+			i--
+		default:
+			ops = append(ops, alignOp{ref: -1, hyp: j - 1, at: i})
+			j--
+		}
+	}
+
+	// Reverse into reading order
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
+
+// normalizeWord lowercases a word and strips punctuation for comparison
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
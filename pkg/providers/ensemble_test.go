@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// textProvider returns a fixed transcript with a fixed segment confidence
+type textProvider struct {
+	fakeProvider
+	text       string
+	confidence float32
+	prompt     string
+}
+
+func (p *textProvider) TranscribeChunk(_ context.Context, _ *AudioChunk, prompt string, _ TranscriptionOptions) (*TranscriptionResult, error) {
+	p.calls++
+	p.prompt = prompt
+	if p.err != nil {
+		return nil, p.err
+	}
+	result := &TranscriptionResult{Text: p.text}
+	if p.confidence > 0 {
+		result.Segments = []TranscriptionSegment{{Text: p.text, Confidence: p.confidence}}
+	}
+	return result, nil
+}
+
+func TestEnsembleVoteMajority(t *testing.T) {
+	a := &textProvider{fakeProvider: fakeProvider{name: "a"}, text: "the quick brown fox"}
+	b := &textProvider{fakeProvider: fakeProvider{name: "b"}, text: "the quick brawn fox jumps"}
+	c := &textProvider{fakeProvider: fakeProvider{name: "c"}, text: "a quick brown fox jumps"}
+
+	ensemble, err := NewEnsembleProvider(EnsembleVote, nil,
+		EnsembleMember{Provider: a}, EnsembleMember{Provider: b}, EnsembleMember{Provider: c})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider() failed: %v", err)
+	}
+
+	result, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	if want := "the quick brown fox jumps"; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	if result.Metadata["ensemble_method"] != "vote" {
+		t.Errorf("ensemble_method = %v, want vote", result.Metadata["ensemble_method"])
+	}
+}
+
+func TestEnsembleVotePrefersConfidence(t *testing.T) {
+	low := &textProvider{fakeProvider: fakeProvider{name: "low"}, text: "recognize speech", confidence: 0.4}
+	high := &textProvider{fakeProvider: fakeProvider{name: "high"}, text: "wreck a nice beach", confidence: 0.9}
+
+	ensemble, err := NewEnsembleProvider(EnsembleVote, nil,
+		EnsembleMember{Provider: low}, EnsembleMember{Provider: high})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider() failed: %v", err)
+	}
+
+	result, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	if want := "wreck a nice beach"; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	// The winning transcript is unchanged, so its segments are kept
+	if len(result.Segments) != 1 {
+		t.Errorf("len(Segments) = %d, want 1", len(result.Segments))
+	}
+}
+
+func TestEnsembleAdjudicate(t *testing.T) {
+	a := &textProvider{fakeProvider: fakeProvider{name: "a"}, text: "hello word"}
+	b := &textProvider{fakeProvider: fakeProvider{name: "b"}, text: "yellow world"}
+	judge := &textProvider{fakeProvider: fakeProvider{name: "judge"}, text: "hello world"}
+
+	ensemble, err := NewEnsembleProvider(EnsembleAdjudicate, judge,
+		EnsembleMember{Provider: a}, EnsembleMember{Provider: b})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider() failed: %v", err)
+	}
+
+	result, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	if result.Text != "hello world" {
+		t.Errorf("Text = %q, want %q", result.Text, "hello world")
+	}
+	if judge.calls != 1 {
+		t.Errorf("adjudicator calls = %d, want 1", judge.calls)
+	}
+}
+
+func TestEnsembleSurvivesMemberFailure(t *testing.T) {
+	ok := &textProvider{fakeProvider: fakeProvider{name: "ok"}, text: "still here"}
+	broken := &textProvider{fakeProvider: fakeProvider{name: "broken", err: errors.New("boom")}}
+
+	ensemble, err := NewEnsembleProvider(EnsembleVote, nil,
+		EnsembleMember{Provider: broken}, EnsembleMember{Provider: ok})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider() failed: %v", err)
+	}
+
+	result, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	if result.Text != "still here" {
+		t.Errorf("Text = %q, want %q", result.Text, "still here")
+	}
+
+	broken2 := &textProvider{fakeProvider: fakeProvider{name: "broken2", err: errors.New("bang")}}
+	ensemble, _ = NewEnsembleProvider(EnsembleVote, nil,
+		EnsembleMember{Provider: broken}, EnsembleMember{Provider: broken2})
+	if _, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{}); err == nil {
+		t.Error("TranscribeChunk() succeeded with all members failing")
+	}
+}
+
+func TestEnsembleSumsUsage(t *testing.T) {
+	a := &usageProvider{textProvider: textProvider{fakeProvider: fakeProvider{name: "a"}, text: "same words"}, usage: Usage{PromptTokens: 100, OutputTokens: 10, TotalTokens: 110}}
+	b := &usageProvider{textProvider: textProvider{fakeProvider: fakeProvider{name: "b"}, text: "same words"}, usage: Usage{PromptTokens: 200, OutputTokens: 20, TotalTokens: 220}}
+
+	ensemble, err := NewEnsembleProvider(EnsembleVote, nil, EnsembleMember{Provider: a}, EnsembleMember{Provider: b})
+	if err != nil {
+		t.Fatalf("NewEnsembleProvider() failed: %v", err)
+	}
+
+	result, err := ensemble.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	want := Usage{PromptTokens: 300, OutputTokens: 30, TotalTokens: 330}
+	if got := UsageFromMetadata(result.Metadata); got != want {
+		t.Errorf("usage = %+v, want %+v", got, want)
+	}
+}
+
+// usageProvider is a textProvider that reports token usage
+type usageProvider struct {
+	textProvider
+	usage Usage
+}
+
+func (p *usageProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	result, err := p.textProvider.TranscribeChunk(ctx, chunk, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	result.Metadata = make(map[string]interface{})
+	p.usage.SetMetadata(result.Metadata)
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RateLimitError is returned by providers when the API rejects a request
+// because a quota or rate limit was exceeded
+type RateLimitError struct {
+	// RetryAfter is the server-suggested wait before retrying, if known
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error implements the error interface
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (retry after %v): %v", e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// IsRateLimited reports whether err is or wraps a RateLimitError
+func IsRateLimited(err error) bool {
+	var rlErr *RateLimitError
+	return errors.As(err, &rlErr)
+}
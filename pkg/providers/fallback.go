@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// FallbackProvider wraps an ordered list of providers and fails over to the
+// next one when a provider returns an error
+type FallbackProvider struct {
+	providers []LLMProvider
+}
+
+// NewFallbackProvider creates a provider that tries each provider in order
+func NewFallbackProvider(primary LLMProvider, fallbacks ...LLMProvider) *FallbackProvider {
+	return &FallbackProvider{
+		providers: append([]LLMProvider{primary}, fallbacks...),
+	}
+}
+
+// Name returns the chain name, e.g. "gemini>groq"
+func (f *FallbackProvider) Name() string {
+	names := make([]string, len(f.providers))
+	for i, p := range f.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ">")
+}
+
+// Transcribe transcribes audio with the first provider that succeeds.
+// The request audio is replayed to later providers.
+func (f *FallbackProvider) Transcribe(ctx context.Context, req *TranscriptionRequest) (*TranscriptionResult, error) {
+	replay, err := NewReplayableRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.try(ctx, func(p LLMProvider) (*TranscriptionResult, error) {
+		return p.Transcribe(ctx, replay.Request())
+	})
+}
+
+// TranscribeChunk transcribes a chunk with the first provider that succeeds
+func (f *FallbackProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return f.try(ctx, func(p LLMProvider) (*TranscriptionResult, error) {
+		return p.TranscribeChunk(ctx, chunk, prompt, options)
+	})
+}
+
+// GenerateText answers a text-only prompt with the first provider that
+// succeeds; providers without text support are skipped
+func (f *FallbackProvider) GenerateText(ctx context.Context, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return f.try(ctx, func(p LLMProvider) (*TranscriptionResult, error) {
+		return GenerateText(ctx, p, prompt, options)
+	})
+}
+
+// Embed embeds texts with the first provider that succeeds; providers
+// without embeddings are skipped
+func (f *FallbackProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	_, err := f.try(ctx, func(p LLMProvider) (*TranscriptionResult, error) {
+		var err error
+		vectors, err = Embed(ctx, p, texts)
+		return &TranscriptionResult{}, err
+	})
+	return vectors, err
+}
+
+// try calls fn for each provider until one succeeds
+func (f *FallbackProvider) try(ctx context.Context, fn func(LLMProvider) (*TranscriptionResult, error)) (*TranscriptionResult, error) {
+	log := logger.WithComponent("fallback-provider")
+
+	var errs []error
+	for i, p := range f.providers {
+		result, err := fn(p)
+		if err == nil {
+			if i > 0 {
+				if result.Metadata == nil {
+					result.Metadata = make(map[string]interface{})
+				}
+				result.Metadata["fallback_from"] = f.providers[0].Name()
+			}
+			return result, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+
+		// Don't fail over when the caller gave up
+		if ctx.Err() != nil {
+			break
+		}
+
+		if i < len(f.providers)-1 {
+			log.Warn().
+				Err(err).
+				Str("provider", p.Name()).
+				Str("next_provider", f.providers[i+1].Name()).
+				Msg("Provider failed, falling back")
+		}
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// ValidateConfig validates every provider in the chain
+func (f *FallbackProvider) ValidateConfig() error {
+	for _, p := range f.providers {
+		if err := p.ValidateConfig(); err != nil {
+			return fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// SupportedFormats returns the formats supported by the primary provider
+func (f *FallbackProvider) SupportedFormats() []string {
+	return f.providers[0].SupportedFormats()
+}
+
+// Models returns the primary provider's models; fallbacks are only
+// billed when the primary fails
+func (f *FallbackProvider) Models() []string {
+	return Models(f.providers[0])
+}
+
+// Quota returns the primary provider's quota; fallbacks are only used
+// when the primary fails
+func (f *FallbackProvider) Quota(ctx context.Context) (*Quota, error) {
+	return ProviderQuota(ctx, f.providers[0])
+}
+
+// Diarizes reports whether every provider in the chain labels speakers
+// natively, so a fallback result is labeled like the primary's
+func (f *FallbackProvider) Diarizes() bool {
+	for _, p := range f.providers {
+		if !Diarizes(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsLocal reports whether every provider in the chain is local
+func (f *FallbackProvider) IsLocal() bool {
+	for _, p := range f.providers {
+		if !IsLocal(p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeProvider is a scripted LLMProvider for tests
+type fakeProvider struct {
+	name  string
+	err   error
+	calls int
+	audio []string
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Transcribe(ctx context.Context, req *TranscriptionRequest) (*TranscriptionResult, error) {
+	data, err := io.ReadAll(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+	f.audio = append(f.audio, string(data))
+	return f.TranscribeChunk(ctx, &AudioChunk{}, req.Prompt, req.Options)
+}
+
+func (f *fakeProvider) TranscribeChunk(_ context.Context, _ *AudioChunk, _ string, _ TranscriptionOptions) (*TranscriptionResult, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &TranscriptionResult{Text: "from " + f.name}, nil
+}
+
+func (f *fakeProvider) ValidateConfig() error { return nil }
+
+func (f *fakeProvider) SupportedFormats() []string { return []string{"audio/mpeg"} }
+
+func TestFallbackProviderFailsOver(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errors.New("rate limited")}
+	secondary := &fakeProvider{name: "secondary"}
+	chain := NewFallbackProvider(primary, secondary)
+
+	result, err := chain.Transcribe(context.Background(), &TranscriptionRequest{
+		Audio: strings.NewReader("audio-bytes"),
+	})
+	if err != nil {
+		t.Fatalf("Transcribe() failed: %v", err)
+	}
+	if result.Text != "from secondary" {
+		t.Errorf("Text = %q, want %q", result.Text, "from secondary")
+	}
+	if result.Metadata["fallback_from"] != "primary" {
+		t.Errorf("fallback_from = %v, want primary", result.Metadata["fallback_from"])
+	}
+	// Both providers must see the full audio even though the first one consumed it
+	for _, p := range []*fakeProvider{primary, secondary} {
+		if len(p.audio) != 1 || p.audio[0] != "audio-bytes" {
+			t.Errorf("%s received audio %q", p.name, p.audio)
+		}
+	}
+}
+
+func TestFallbackProviderAllFail(t *testing.T) {
+	chain := NewFallbackProvider(
+		&fakeProvider{name: "a", err: errors.New("boom")},
+		&fakeProvider{name: "b", err: errors.New("bang")},
+	)
+
+	_, err := chain.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err == nil {
+		t.Fatal("expected error when every provider fails")
+	}
+	if !strings.Contains(err.Error(), "a: boom") || !strings.Contains(err.Error(), "b: bang") {
+		t.Errorf("error should mention every provider, got %v", err)
+	}
+	if chain.Name() != "a>b" {
+		t.Errorf("Name() = %q, want a>b", chain.Name())
+	}
+}
+
+func TestFallbackProviderStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	secondary := &fakeProvider{name: "secondary"}
+	chain := NewFallbackProvider(&fakeProvider{name: "primary", err: context.Canceled}, secondary)
+
+	if _, err := chain.TranscribeChunk(ctx, &AudioChunk{}, "", TranscriptionOptions{}); err == nil {
+		t.Fatal("expected error on cancelled context")
+	}
+	if secondary.calls != 0 {
+		t.Errorf("secondary called %d times after cancellation", secondary.calls)
+	}
+}
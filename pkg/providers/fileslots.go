@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// DefaultLeaseTTL is how long a slot lease lasts without being refreshed
+// when FileSlots is given no TTL
+const DefaultLeaseTTL = 2 * time.Minute
+
+// slotPollInterval is how often a full key is checked for a free slot
+const slotPollInterval = 250 * time.Millisecond
+
+// FileSlots is a SlotBackend sharing slots through lease files in a
+// directory, e.g. on a volume every watcher and server mounts: slot i of a
+// key is the file <dir>/<key>/slot-<i>.lease, created exclusively by its
+// holder and refreshed while held. A lease not refreshed for the TTL, left
+// by a crashed process, is taken over. The cap is cooperative: processes
+// not sharing the directory are not counted.
+type FileSlots struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewFileSlots creates a backend keeping leases in dir that expire after
+// ttl without a refresh (DefaultLeaseTTL if zero)
+func NewFileSlots(dir string, ttl time.Duration) *FileSlots {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	return &FileSlots{dir: dir, ttl: ttl}
+}
+
+// lease is the content of a lease file. Owner is a random token of the
+// holder, checked before the lease is refreshed or removed so a holder
+// whose lease was taken over leaves the new one alone; the rest is for
+// whoever inspects it.
+type lease struct {
+	Owner    string    `json:"owner"`
+	PID      int       `json:"pid"`
+	Host     string    `json:"host"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// leaseOwner returns the owner token of the lease file at path, or "" if
+// it cannot be read
+func leaseOwner(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var l lease
+	if json.Unmarshal(data, &l) != nil {
+		return ""
+	}
+	return l.Owner
+}
+
+// Acquire waits until a slot of key is free and leases it
+func (f *FileSlots) Acquire(ctx context.Context, key string, limit int) (func(), error) {
+	dir := filepath.Join(f.dir, key)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create slot directory: %w", err)
+	}
+
+	ticker := time.NewTicker(slotPollInterval)
+	defer ticker.Stop()
+	for {
+		for i := 0; i < limit; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("slot-%d.lease", i))
+			owner, err := f.take(path)
+			if err != nil {
+				return nil, err
+			}
+			if owner != "" {
+				return f.hold(path, owner), nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tombstones numbers the names expired leases are moved to
+var tombstones atomic.Uint64
+
+// take creates the lease file at path, moving it away first if it
+// expired, and returns its owner token, or "" if the slot is taken
+func (f *FileSlots) take(path string) (string, error) {
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > f.ttl {
+		if !f.takeOver(path, info) {
+			return "", nil
+		}
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to create slot lease: %w", err)
+	}
+	owner := hex.EncodeToString(token)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if os.IsExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create slot lease: %w", err)
+	}
+	host, _ := os.Hostname()
+	err = json.NewEncoder(file).Encode(lease{Owner: owner, PID: os.Getpid(), Host: host, Acquired: time.Now()})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to write slot lease: %w", err)
+	}
+	return owner, nil
+}
+
+// tombstone returns a name no other holder uses to move the lease at path
+// to
+func tombstone(path string) string {
+	return fmt.Sprintf("%s.%d-%d.expired", path, os.Getpid(), tombstones.Add(1))
+}
+
+// takeOver moves the expired lease at path, as stale describes it, out of
+// the way and reports whether it did. Removing it could remove a fresh
+// lease another process created after taking it over first; a rename
+// moves whatever is at path, so a lease that turns out not to be the
+// expired one is linked back instead.
+func (f *FileSlots) takeOver(path string, stale os.FileInfo) bool {
+	moved := tombstone(path)
+	if err := os.Rename(path, moved); err != nil {
+		return false // Another process moved it first
+	}
+
+	info, err := os.Stat(moved)
+	if err != nil || !os.SameFile(stale, info) || time.Since(info.ModTime()) <= f.ttl {
+		restore(path, moved)
+		return false
+	}
+	_ = os.Remove(moved)
+	f.sweep(path)
+	logger.WithComponent("key-slots").Warn().Str("lease_path", path).Msg("Taking over expired API key slot")
+	return true
+}
+
+// restore links a live lease moved away by mistake back to path. If the
+// slot was taken meanwhile the lease is kept where it is, no longer
+// refreshed by its holder, until sweep removes it.
+func restore(path, moved string) {
+	if err := os.Link(moved, path); err != nil {
+		logger.WithComponent("key-slots").Warn().Err(err).Str("lease_path", path).Msg("Failed to restore API key slot lease")
+		return
+	}
+	_ = os.Remove(moved)
+}
+
+// sweep removes leases of path left by restore once they expired
+func (f *FileSlots) sweep(path string) {
+	left, _ := filepath.Glob(path + ".*.expired")
+	for _, moved := range left {
+		if info, err := os.Stat(moved); err == nil && time.Since(info.ModTime()) > f.ttl {
+			_ = os.Remove(moved)
+		}
+	}
+}
+
+// release removes the lease at path if owner still holds it. The lease is
+// moved away before its owner is checked, so a lease taken over meanwhile
+// is never removed, only linked back.
+func release(path, owner string) {
+	if leaseOwner(path) != owner {
+		return
+	}
+	moved := tombstone(path)
+	if err := os.Rename(path, moved); err != nil {
+		return
+	}
+	if leaseOwner(moved) != owner {
+		restore(path, moved)
+		return
+	}
+	_ = os.Remove(moved)
+}
+
+// hold refreshes the lease at path while owner holds it, until the
+// returned release removes it
+func (f *FileSlots) hold(path, owner string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(f.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if leaseOwner(path) != owner {
+					logger.WithComponent("key-slots").Warn().Str("lease_path", path).Msg("API key slot lease was taken over")
+					return
+				}
+				now := time.Now()
+				if err := os.Chtimes(path, now, now); err != nil {
+					logger.WithComponent("key-slots").Warn().Err(err).Str("lease_path", path).Msg("Failed to refresh API key slot")
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			release(path, owner)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// maxEmbedBatch is the most texts embedded in one request
+const maxEmbedBatch = 100
+
+// embedTaskType tunes the vectors for comparing texts with each other
+const embedTaskType = "SEMANTIC_SIMILARITY"
+
+// batchEmbedRequest is the Gemini API batchEmbedContents request
+type batchEmbedRequest struct {
+	Requests []embedContentRequest `json:"requests"`
+}
+
+type embedContentRequest struct {
+	Model    string  `json:"model"`
+	Content  Content `json:"content"`
+	TaskType string  `json:"taskType,omitempty"`
+}
+
+type batchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+// vertexEmbedRequest is the Vertex AI predict request for embedding models
+type vertexEmbedRequest struct {
+	Instances []vertexEmbedInstance `json:"instances"`
+}
+
+type vertexEmbedInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+type vertexEmbedResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
+
+// Embed returns the embedding of every text with the configured embedding
+// model, in batches
+func (p *Provider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if p.embedModel == "" {
+		return nil, fmt.Errorf("no embedding model configured: %w", providers.ErrEmbeddingsUnsupported)
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbedBatch {
+		end := start + maxEmbedBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		var batch [][]float32
+		err := providers.NewRetryPolicy(p.retries).Do(ctx, func(ctx context.Context) error {
+			var err error
+			batch, err = p.embedBatch(ctx, texts[start:end])
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// embedBatch embeds up to maxEmbedBatch texts with one request
+func (p *Provider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if p.vertex {
+		req := vertexEmbedRequest{Instances: make([]vertexEmbedInstance, len(texts))}
+		for i, text := range texts {
+			req.Instances[i] = vertexEmbedInstance{Content: text, TaskType: embedTaskType}
+		}
+
+		var resp vertexEmbedResponse
+		if err := p.postJSON(ctx, p.modelURL(p.embedModel, "predict"), req, &resp); err != nil {
+			return nil, err
+		}
+		vectors := make([][]float32, len(resp.Predictions))
+		for i, prediction := range resp.Predictions {
+			vectors[i] = prediction.Embeddings.Values
+		}
+		return vectors, nil
+	}
+
+	req := batchEmbedRequest{Requests: make([]embedContentRequest, len(texts))}
+	for i, text := range texts {
+		req.Requests[i] = embedContentRequest{
+			Model:    "models/" + p.embedModel,
+			Content:  Content{Parts: []Part{{Text: text}}},
+			TaskType: embedTaskType,
+		}
+	}
+
+	var resp batchEmbedResponse
+	if err := p.postJSON(ctx, p.modelURL(p.embedModel, "batchEmbedContents"), req, &resp); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		vectors[i] = embedding.Values
+	}
+	return vectors, nil
+}
+
+// postJSON sends req to url and decodes the response into resp
+func (p *Provider) postJSON(ctx context.Context, url string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	data, err := p.post(ctx, url, body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -3,15 +3,20 @@ package gemini
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
 	"github.com/eternnoir/gollmscribe/pkg/providers"
 )
 
@@ -19,16 +24,42 @@ const (
 	defaultBaseURL = "https://generativelanguage.googleapis.com"
 	apiVersion     = "v1beta"
 	modelName      = "gemini-2.5-flash"
+
+	vertexAPIVersion      = "v1"
+	defaultVertexLocation = "us-central1"
+	cloudPlatformScope    = "https://www.googleapis.com/auth/cloud-platform"
 )
 
+// audioPlaceholder stands in for the inline audio when a request is
+// marshaled; the audio is base64-encoded into the body in its place
+const audioPlaceholder = "gollmscribe:audio"
+
+// mimeTypes holds the MIME types Gemini documents where they differ from
+// the registered ones
+var mimeTypes = mediatype.Overrides{
+	"mp3": "audio/mp3",
+}
+
 // Provider implements the LLM provider interface for Google Gemini
 type Provider struct {
 	apiKey     string
 	baseURL    string
 	model      string
+	embedModel string
 	timeout    time.Duration
 	retries    int
 	httpClient *http.Client
+
+	// Largest request sent; 0 means GeminiMaxRequestSize, negative no limit
+	maxRequestSize int64
+
+	// Vertex AI mode
+	vertex          bool
+	project         string
+	location        string
+	credentialsFile string
+	tokenSource     oauth2.TokenSource
+	tokenMu         sync.Mutex
 }
 
 // GeminiRequest represents the request structure for Gemini API
@@ -70,8 +101,17 @@ type GenerationConfig struct {
 
 // GeminiResponse represents the response from Gemini API
 type GeminiResponse struct {
-	Candidates []Candidate `json:"candidates"`
-	Error      *APIError   `json:"error,omitempty"`
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	Error         *APIError      `json:"error,omitempty"`
+}
+
+// UsageMetadata reports the tokens billed for a request
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
 }
 
 // Candidate represents a response candidate
@@ -139,6 +179,14 @@ func WithRetries(retries int) ProviderOption {
 	}
 }
 
+// WithMaxRequestSize sets the largest request sent, in bytes; larger
+// chunks fail before they are uploaded. Negative disables the check.
+func WithMaxRequestSize(size int64) ProviderOption {
+	return func(p *Provider) {
+		p.maxRequestSize = size
+	}
+}
+
 // WithModel sets the model name
 func WithModel(model string) ProviderOption {
 	return func(p *Provider) {
@@ -148,30 +196,81 @@ func WithModel(model string) ProviderOption {
 	}
 }
 
+// WithEmbeddingModel sets the model used to embed text, e.g.
+// gemini-embedding-001. Embeddings are unavailable without one.
+func WithEmbeddingModel(model string) ProviderOption {
+	return func(p *Provider) {
+		p.embedModel = model
+	}
+}
+
+// WithVertexAI switches the provider to Vertex AI, authenticating with
+// Application Default Credentials instead of an API key
+func WithVertexAI(project, location string) ProviderOption {
+	return func(p *Provider) {
+		p.vertex = true
+		p.project = project
+		p.location = location
+		if p.location == "" {
+			p.location = defaultVertexLocation
+		}
+	}
+}
+
+// WithCredentialsFile sets a service account key file used for Vertex AI
+// instead of Application Default Credentials
+func WithCredentialsFile(path string) ProviderOption {
+	return func(p *Provider) {
+		p.credentialsFile = path
+	}
+}
+
+// WithTokenSource sets the OAuth2 token source used for Vertex AI
+func WithTokenSource(ts oauth2.TokenSource) ProviderOption {
+	return func(p *Provider) {
+		p.tokenSource = ts
+	}
+}
+
 // Name returns the provider name
 func (p *Provider) Name() string {
 	return "gemini"
 }
 
+// Models returns the model used for each request
+func (p *Provider) Models() []string {
+	return []string{p.model}
+}
+
 // Transcribe transcribes audio using Gemini API
 func (p *Provider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
-	audioData, err := io.ReadAll(req.Audio)
+	// The audio is streamed from the chunk file rather than read into memory
+	audio, err := providers.NewAudioSource(req.Audio)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read audio data: %w", err)
+		return nil, err
 	}
 
 	chunk := &providers.AudioChunk{
-		Data:     audioData,
 		Format:   req.AudioFormat,
 		MimeType: req.MimeType,
 	}
 
-	return p.TranscribeChunk(ctx, chunk, req.Prompt, req.Options)
+	return p.transcribe(ctx, chunk, audio, req.Prompt, req.Options)
 }
 
 // TranscribeChunk transcribes a single audio chunk
 func (p *Provider) TranscribeChunk(ctx context.Context, chunk *providers.AudioChunk, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
-	if len(chunk.Data) == 0 {
+	audio, err := providers.NewAudioSource(bytes.NewReader(chunk.Data))
+	if err != nil {
+		return nil, err
+	}
+	return p.transcribe(ctx, chunk, audio, prompt, options)
+}
+
+// transcribe sends the chunk's audio from audio inline, base64-encoding
+// it while the request body is written
+func (p *Provider) transcribe(ctx context.Context, chunk *providers.AudioChunk, audio *providers.AudioSource, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	if audio.Size() == 0 {
 		return nil, fmt.Errorf("empty audio data")
 	}
 
@@ -190,71 +289,177 @@ func (p *Provider) TranscribeChunk(ctx context.Context, chunk *providers.AudioCh
 					},
 					{
 						InlineData: &InlineData{
-							MimeType: chunk.MimeType,
-							Data:     base64.StdEncoding.EncodeToString(chunk.Data),
+							MimeType: mimeTypes.MIME(chunk.Format, chunk.MimeType),
+							Data:     audioPlaceholder,
 						},
 					},
 				},
 				Role: "user",
 			},
 		},
-		GenerationConfig: &GenerationConfig{
-			Temperature:      options.Temperature,
-			MaxOutputTokens:  options.MaxTokens,
-			ResponseMimeType: "text/plain",
-			ThinkingConfig: &ThinkingConfig{
-				ThinkingBudget: -1,
+		GenerationConfig: generationConfig(options),
+	}
+
+	// Make the API request, retrying transient failures
+	resp, err := p.send(ctx, geminiReq, audio)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the response
+	defer providers.TimeParse(ctx, time.Now())
+	return p.parseResponse(resp, chunk)
+}
+
+// GenerateText answers a text-only prompt, e.g. to summarize a transcript
+func (p *Provider) GenerateText(ctx context.Context, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	if prompt == "" {
+		return nil, fmt.Errorf("empty prompt")
+	}
+
+	resp, err := p.send(ctx, &GeminiRequest{
+		Contents: []Content{
+			{
+				Parts: []Part{{Text: prompt}},
+				Role:  "user",
 			},
 		},
+		GenerationConfig: generationConfig(options),
+	}, nil)
+	if err != nil {
+		return nil, err
 	}
+	return p.parseResponse(resp, &providers.AudioChunk{})
+}
 
-	// Make the API request with retries
-	var resp *GeminiResponse
-	var err error
-	for attempt := 0; attempt <= p.retries; attempt++ {
-		resp, err = p.makeRequest(ctx, geminiReq)
-		if err == nil {
-			break
-		}
-		if attempt < p.retries {
-			time.Sleep(time.Duration(attempt+1) * time.Second)
-		}
+// generationConfig returns the generation settings for a request
+func generationConfig(options providers.TranscriptionOptions) *GenerationConfig {
+	return &GenerationConfig{
+		Temperature:      options.Temperature,
+		MaxOutputTokens:  options.MaxTokens,
+		ResponseMimeType: "text/plain",
+		ThinkingConfig: &ThinkingConfig{
+			ThinkingBudget: -1,
+		},
 	}
+}
 
+// send makes the API request, retrying transient failures. A non-nil
+// audio is streamed into the request in place of audioPlaceholder.
+func (p *Provider) send(ctx context.Context, req *GeminiRequest, audio *providers.AudioSource) (*GeminiResponse, error) {
+	var resp *GeminiResponse
+	err := providers.NewRetryPolicy(p.retries).Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = p.makeRequest(ctx, req, audio)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request after %d attempts: %w", p.retries+1, err)
+		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
-
-	// Parse the response
-	return p.parseResponse(resp, chunk)
+	return resp, nil
 }
 
 // makeRequest makes an HTTP request to the Gemini API
-func (p *Provider) makeRequest(ctx context.Context, req *GeminiRequest) (*GeminiResponse, error) {
+func (p *Provider) makeRequest(ctx context.Context, req *GeminiRequest, audio *providers.AudioSource) (*GeminiResponse, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	body, size := io.Reader(bytes.NewReader(jsonData)), int64(len(jsonData))
+	if audio != nil {
+		// Inline data is the last string of the request
+		at := bytes.LastIndex(jsonData, []byte(`"`+audioPlaceholder+`"`)) + 1
+		if at == 0 {
+			return nil, fmt.Errorf("failed to marshal request: no place for the audio")
+		}
+		prefix, suffix := jsonData[:at], jsonData[at+len(audioPlaceholder):]
+		body = io.MultiReader(bytes.NewReader(prefix), audio.Base64Reader(), bytes.NewReader(suffix))
+		size = int64(len(prefix)) + audio.Base64Size() + int64(len(suffix))
+	}
+	if err := providers.CheckRequestSize("gemini", size, p.requestLimit()); err != nil {
+		return nil, err
+	}
 
 	// Log request details (without API key)
-	url := fmt.Sprintf("%s/%s/models/%s:generateContent", p.baseURL, apiVersion, p.model)
+	url := p.endpointURL()
 	logger.Debug().
 		Str("component", "gemini-provider").
 		Str("url", url).
 		Str("model", p.model).
-		Int("request_size", len(jsonData)).
+		Bool("vertex", p.vertex).
+		Int64("request_size", size).
 		Msg("Sending request to Gemini API")
 
-	url = fmt.Sprintf("%s/%s/models/%s:generateContent?key=%s", p.baseURL, apiVersion, p.model, p.apiKey)
+	respData, err := p.postBody(ctx, url, body, size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log raw response for debugging
+	logger.Debug().
+		Str("component", "gemini-provider").
+		Str("raw_response", string(respData)).
+		Msg("Received raw response from Gemini API")
+
+	defer providers.TimeParse(ctx, time.Now())
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(respData, &geminiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// Log parsed response structure
+	logger.Debug().
+		Str("component", "gemini-provider").
+		Int("candidates_count", len(geminiResp.Candidates)).
+		Msg("Parsed Gemini response")
+
+	if geminiResp.Error != nil {
+		return nil, fmt.Errorf("API error %d: %s", geminiResp.Error.Code, geminiResp.Error.Message)
+	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	return &geminiResp, nil
+}
+
+// requestLimit returns the largest request sent, 0 for no limit
+func (p *Provider) requestLimit() int64 {
+	switch {
+	case p.maxRequestSize > 0:
+		return p.maxRequestSize
+	case p.maxRequestSize < 0:
+		return 0
+	}
+	return providers.GeminiMaxRequestSize
+}
+
+// post sends a JSON body to url with the credentials of the configured
+// mode and returns the response body
+func (p *Provider) post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return p.postBody(ctx, url, bytes.NewReader(body), int64(len(body)))
+}
+
+// postBody is post with a body of size bytes read as it is sent
+func (p *Provider) postBody(ctx context.Context, url string, body io.Reader, size int64) ([]byte, error) {
+	if !p.vertex {
+		url = fmt.Sprintf("%s?key=%s", url, p.apiKey)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
+	httpReq.ContentLength = size
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	httpResp, err := p.httpClient.Do(httpReq)
+	if p.vertex {
+		token, err := p.accessToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain access token: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpResp, err := p.httpClient.Do(providers.TraceRequest(httpReq))
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -268,31 +473,78 @@ func (p *Provider) makeRequest(ctx context.Context, req *GeminiRequest) (*Gemini
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", httpResp.StatusCode, string(respData))
+		return nil, providers.NewHTTPError(httpResp, string(respData))
 	}
+	return respData, nil
+}
 
-	// Log raw response for debugging
-	logger.Debug().
-		Str("component", "gemini-provider").
-		Str("raw_response", string(respData)).
-		Msg("Received raw response from Gemini API")
+// endpointURL returns the generateContent URL for the configured mode
+func (p *Provider) endpointURL() string {
+	return p.modelURL(p.model, "generateContent")
+}
 
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(respData, &geminiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+// modelURL returns the URL of a method of model for the configured mode
+func (p *Provider) modelURL(model, method string) string {
+	if !p.vertex {
+		return fmt.Sprintf("%s/%s/models/%s:%s", p.baseURL, apiVersion, model, method)
 	}
 
-	// Log parsed response structure
-	logger.Debug().
-		Str("component", "gemini-provider").
-		Int("candidates_count", len(geminiResp.Candidates)).
-		Msg("Parsed Gemini response")
+	baseURL := p.baseURL
+	if baseURL == defaultBaseURL {
+		if p.location == "global" {
+			baseURL = "https://aiplatform.googleapis.com"
+		} else {
+			baseURL = fmt.Sprintf("https://%s-aiplatform.googleapis.com", p.location)
+		}
+	}
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s",
+		baseURL, vertexAPIVersion, p.project, p.location, model, method)
+}
 
-	if geminiResp.Error != nil {
-		return nil, fmt.Errorf("API error %d: %s", geminiResp.Error.Code, geminiResp.Error.Message)
+// accessToken returns a valid OAuth2 access token, refreshing it when expired
+func (p *Provider) accessToken(ctx context.Context) (string, error) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	if p.tokenSource == nil {
+		creds, err := p.findCredentials(ctx)
+		if err != nil {
+			return "", err
+		}
+		// ReuseTokenSource caches the token and refreshes it shortly before expiry
+		p.tokenSource = oauth2.ReuseTokenSource(nil, creds.TokenSource)
 	}
 
-	return &geminiResp, nil
+	token, err := p.tokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// findCredentials loads credentials from the configured service account file
+// or falls back to Application Default Credentials
+func (p *Provider) findCredentials(ctx context.Context) (*google.Credentials, error) {
+	// Token acquisition must outlive the request context that triggered it
+	ctx = context.WithoutCancel(ctx)
+
+	if p.credentialsFile != "" {
+		data, err := os.ReadFile(p.credentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		creds, err := google.CredentialsFromJSON(ctx, data, cloudPlatformScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+		}
+		return creds, nil
+	}
+
+	creds, err := google.FindDefaultCredentials(ctx, cloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find application default credentials: %w", err)
+	}
+	return creds, nil
 }
 
 // parseResponse parses the Gemini API response into a TranscriptionResult
@@ -335,6 +587,15 @@ func (p *Provider) parseResponse(resp *GeminiResponse, chunk *providers.AudioChu
 		return nil, fmt.Errorf("empty transcription result")
 	}
 
+	// Thinking tokens are billed as output
+	if usage := resp.UsageMetadata; usage != nil {
+		providers.Usage{
+			PromptTokens: usage.PromptTokenCount,
+			OutputTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+			TotalTokens:  usage.TotalTokenCount,
+		}.SetMetadata(result.Metadata)
+	}
+
 	return result, nil
 }
 
@@ -350,6 +611,12 @@ func (p *Provider) buildDefaultPrompt(_ providers.TranscriptionOptions) string {
 
 // ValidateConfig validates the provider configuration
 func (p *Provider) ValidateConfig() error {
+	if p.vertex {
+		if p.project == "" {
+			return fmt.Errorf("project is required for Vertex AI")
+		}
+		return nil
+	}
 	if p.apiKey == "" {
 		return fmt.Errorf("API key is required")
 	}
@@ -358,12 +625,5 @@ func (p *Provider) ValidateConfig() error {
 
 // SupportedFormats returns the list of supported audio formats
 func (p *Provider) SupportedFormats() []string {
-	return []string{
-		"audio/wav",
-		"audio/mp3",
-		"audio/mpeg",
-		"audio/m4a",
-		"audio/flac",
-		"audio/ogg",
-	}
+	return mediatype.MIMETypes("wav", "mp3", "m4a", "aac", "flac", "ogg")
 }
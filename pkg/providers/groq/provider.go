@@ -0,0 +1,419 @@
+package groq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+const (
+	defaultBaseURL = "https://api.groq.com/openai/v1"
+	modelName      = "whisper-large-v3"
+)
+
+// mimeTypes holds MIME type overrides; Groq accepts the registered types
+var mimeTypes = mediatype.Overrides{}
+
+// Provider implements the LLM provider interface for Groq's Whisper endpoint
+type Provider struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	language   string
+	timeout    time.Duration
+	retries    int
+	httpClient *http.Client
+
+	// Largest request sent; 0 means GroqMaxRequestSize for Groq itself and
+	// no limit for local servers, negative no limit
+	maxRequestSize int64
+
+	// Daily quota from the headers of the last response, nil until one
+	mu    sync.Mutex
+	quota *providers.Quota
+}
+
+// TranscriptionResponse represents the verbose_json response from Groq
+type TranscriptionResponse struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language"`
+	Duration float64   `json:"duration"`
+	Segments []Segment `json:"segments"`
+	Error    *APIError `json:"error,omitempty"`
+}
+
+// Segment represents a transcribed segment in the Groq response
+type Segment struct {
+	ID           int     `json:"id"`
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	AvgLogprob   float64 `json:"avg_logprob"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// APIError represents an API error response
+type APIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// NewProvider creates a new Groq provider instance
+func NewProvider(apiKey string, options ...ProviderOption) *Provider {
+	p := &Provider{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
+		model:   modelName,
+		timeout: 30 * time.Second,
+		retries: 3,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p
+}
+
+// ProviderOption allows customizing the provider
+type ProviderOption func(*Provider)
+
+// WithBaseURL sets a custom base URL
+func WithBaseURL(baseURL string) ProviderOption {
+	return func(p *Provider) {
+		if baseURL != "" {
+			p.baseURL = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+}
+
+// WithTimeout sets the request timeout
+func WithTimeout(timeout time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.timeout = timeout
+		p.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries sets the number of retry attempts
+func WithRetries(retries int) ProviderOption {
+	return func(p *Provider) {
+		p.retries = retries
+	}
+}
+
+// WithMaxRequestSize sets the largest request sent, in bytes; larger
+// chunks fail before they are uploaded. Negative disables the check.
+func WithMaxRequestSize(size int64) ProviderOption {
+	return func(p *Provider) {
+		p.maxRequestSize = size
+	}
+}
+
+// WithModel sets the model name
+func WithModel(model string) ProviderOption {
+	return func(p *Provider) {
+		if model != "" {
+			p.model = model
+		}
+	}
+}
+
+// WithLanguage sets the ISO-639-1 language hint sent with each request
+func WithLanguage(language string) ProviderOption {
+	return func(p *Provider) {
+		if language != "auto" {
+			p.language = language
+		}
+	}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "groq"
+}
+
+// Models returns the model used for each request
+func (p *Provider) Models() []string {
+	return []string{p.model}
+}
+
+// IsLocal reports whether the base URL points at a self-hosted,
+// OpenAI-compatible server on a loopback or private address
+func (p *Provider) IsLocal() bool {
+	return providers.IsLocalURL(p.baseURL)
+}
+
+// Transcribe transcribes audio using the Groq API
+func (p *Provider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	// The audio is streamed from the chunk file rather than read into memory
+	audio, err := providers.NewAudioSource(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &providers.AudioChunk{
+		Format:   req.AudioFormat,
+		MimeType: req.MimeType,
+	}
+
+	return p.transcribe(ctx, chunk, audio, req.Prompt, req.Options)
+}
+
+// TranscribeChunk transcribes a single audio chunk
+func (p *Provider) TranscribeChunk(ctx context.Context, chunk *providers.AudioChunk, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	audio, err := providers.NewAudioSource(bytes.NewReader(chunk.Data))
+	if err != nil {
+		return nil, err
+	}
+	return p.transcribe(ctx, chunk, audio, prompt, options)
+}
+
+// transcribe sends the chunk's audio from audio, retrying transient failures
+func (p *Provider) transcribe(ctx context.Context, chunk *providers.AudioChunk, audio *providers.AudioSource, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	if audio.Size() == 0 {
+		return nil, fmt.Errorf("empty audio data")
+	}
+
+	// Whisper treats the prompt as preceding context and only honours the
+	// last 224 tokens, so long instruction-style prompts are truncated
+	prompt = truncatePrompt(prompt, 896)
+
+	var resp *TranscriptionResponse
+	err := providers.NewRetryPolicy(p.retries).Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = p.makeRequest(ctx, chunk, audio, prompt, options)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+
+	defer providers.TimeParse(ctx, time.Now())
+	return p.parseResponse(resp, chunk)
+}
+
+// makeRequest makes a multipart HTTP request to the Groq transcription
+// endpoint, streaming the audio part
+func (p *Provider) makeRequest(ctx context.Context, chunk *providers.AudioChunk, audio *providers.AudioSource, prompt string, options providers.TranscriptionOptions) (*TranscriptionResponse, error) {
+	fields := map[string]string{
+		"model":           p.model,
+		"response_format": "verbose_json",
+		"temperature":     fmt.Sprintf("%g", options.Temperature),
+	}
+	if prompt != "" {
+		fields["prompt"] = prompt
+	}
+	if p.language != "" {
+		fields["language"] = p.language
+	}
+	form, err := providers.NewAudioForm(fields, "file", chunkFilename(chunk), mimeTypes.MIME(chunk.Format, chunk.MimeType), audio)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := providers.CheckRequestSize("groq", form.Size(), p.requestLimit()); err != nil {
+		return nil, err
+	}
+
+	url := p.baseURL + "/audio/transcriptions"
+	logger.Debug().
+		Str("component", "groq-provider").
+		Str("url", url).
+		Str("model", p.model).
+		Int64("request_size", form.Size()).
+		Msg("Sending request to Groq API")
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, form.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.ContentLength = form.Size()
+
+	httpReq.Header.Set("Content-Type", form.ContentType())
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	httpResp, err := p.httpClient.Do(providers.TraceRequest(httpReq))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+	p.recordQuota(httpResp.Header)
+
+	respData, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, providers.NewHTTPError(httpResp, string(respData))
+	}
+
+	logger.Debug().
+		Str("component", "groq-provider").
+		Str("raw_response", string(respData)).
+		Msg("Received raw response from Groq API")
+
+	defer providers.TimeParse(ctx, time.Now())
+	var groqResp TranscriptionResponse
+	if err := json.Unmarshal(respData, &groqResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if groqResp.Error != nil {
+		return nil, fmt.Errorf("API error %s: %s", groqResp.Error.Code, groqResp.Error.Message)
+	}
+
+	return &groqResp, nil
+}
+
+// Quota returns the daily request quota left, from the rate limit headers
+// of the last response, or of a request listing the models if none was
+// made yet. Local servers report none.
+func (p *Provider) Quota(ctx context.Context) (*providers.Quota, error) {
+	if p.IsLocal() {
+		return nil, providers.ErrQuotaUnknown
+	}
+	p.mu.Lock()
+	quota := p.quota
+	p.mu.Unlock()
+	if quota != nil {
+		copied := *quota
+		return &copied, nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+	respData, _ := io.ReadAll(httpResp.Body)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, providers.NewHTTPError(httpResp, string(respData))
+	}
+
+	quota, ok := providers.ParseRateLimitHeaders(p.Name(), httpResp.Header)
+	if !ok {
+		return nil, providers.ErrQuotaUnknown
+	}
+	return quota, nil
+}
+
+// recordQuota keeps the daily quota reported by a response's headers
+func (p *Provider) recordQuota(header http.Header) {
+	quota, ok := providers.ParseRateLimitHeaders(p.Name(), header)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quota = quota
+}
+
+// requestLimit returns the largest request sent, 0 for no limit
+func (p *Provider) requestLimit() int64 {
+	switch {
+	case p.maxRequestSize > 0:
+		return p.maxRequestSize
+	case p.maxRequestSize < 0 || p.IsLocal():
+		return 0
+	}
+	return providers.GroqMaxRequestSize
+}
+
+// parseResponse converts the Groq response into a TranscriptionResult
+func (p *Provider) parseResponse(resp *TranscriptionResponse, chunk *providers.AudioChunk) (*providers.TranscriptionResult, error) {
+	result := &providers.TranscriptionResult{
+		ChunkID:  chunk.ChunkID,
+		Text:     strings.TrimSpace(resp.Text),
+		Language: resp.Language,
+		Duration: secondsToDuration(resp.Duration),
+		Metadata: map[string]interface{}{
+			"provider": "groq",
+			"model":    p.model,
+		},
+	}
+
+	if result.Text == "" {
+		return nil, fmt.Errorf("empty transcription result")
+	}
+
+	for _, seg := range resp.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		result.Segments = append(result.Segments, providers.TranscriptionSegment{
+			Text:       text,
+			Start:      secondsToDuration(seg.Start),
+			End:        secondsToDuration(seg.End),
+			Confidence: float32(1 - seg.NoSpeechProb),
+			Language:   resp.Language,
+		})
+	}
+
+	return result, nil
+}
+
+// ValidateConfig validates the provider configuration
+func (p *Provider) ValidateConfig() error {
+	if p.apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	return nil
+}
+
+// SupportedFormats returns the list of supported audio formats
+func (p *Provider) SupportedFormats() []string {
+	return mediatype.MIMETypes("wav", "mp3", "m4a", "flac", "ogg", "webm")
+}
+
+// chunkFilename returns a filename whose extension lets Groq detect the container
+func chunkFilename(chunk *providers.AudioChunk) string {
+	ext := chunk.Format
+	if ext == "" {
+		ext = "mp3"
+	}
+	return fmt.Sprintf("chunk_%03d.%s", chunk.ChunkID, ext)
+}
+
+// secondsToDuration converts fractional seconds to a time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// truncatePrompt keeps the trailing maxLen bytes of a prompt on a rune boundary
+func truncatePrompt(prompt string, maxLen int) string {
+	if len(prompt) <= maxLen {
+		return prompt
+	}
+	cut := len(prompt) - maxLen
+	for cut < len(prompt) && !utf8.RuneStart(prompt[cut]) {
+		cut++
+	}
+	return prompt[cut:]
+}
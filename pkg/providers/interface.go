@@ -31,6 +31,11 @@ type TranscriptionOptions struct {
 	Temperature    float32
 	MaxTokens      int
 	TimeoutSeconds int
+
+	// Ask a Diarizer for its own speaker labels. The labels are the
+	// provider's, e.g. "0" or "SPEAKER_01", and are normalized by the
+	// caller.
+	Diarize bool
 }
 
 // TranscriptionSegment represents a segment of transcribed text
@@ -40,6 +45,9 @@ type TranscriptionSegment struct {
 	End        time.Duration `json:"end,omitempty"`
 	SpeakerID  string        `json:"speaker_id,omitempty"`
 	Confidence float32       `json:"confidence,omitempty"`
+	Language   string        `json:"language,omitempty"` // Spoken language, e.g. "en", when known per segment
+
+	Translation string `json:"translation,omitempty"` // Text translated by post-processing
 }
 
 // TranscriptionResult represents the result of a transcription request
@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// SlotBackend shares the concurrency slots of API keys between processes,
+// e.g. several watchers and servers using one key
+type SlotBackend interface {
+	// Acquire blocks until one of the limit slots of key is free and
+	// takes it, until release is called
+	Acquire(ctx context.Context, key string, limit int) (release func(), err error)
+}
+
+// KeySlots caps how many requests use an API key at once, so the requests
+// of every worker stay within the provider's documented per-key limit.
+// Providers wrapped with the same KeySlots share a semaphore per key, and
+// with a SlotBackend the processes sharing it do too. It is safe for
+// concurrent use.
+type KeySlots struct {
+	limit   int
+	backend SlotBackend
+
+	mu    sync.Mutex
+	local map[string]chan struct{}
+}
+
+// NewKeySlots creates slots allowing limit concurrent requests per key,
+// shared through backend if it is not nil; returns nil when limit is zero
+func NewKeySlots(limit int, backend SlotBackend) *KeySlots {
+	if limit <= 0 {
+		return nil
+	}
+	return &KeySlots{limit: limit, backend: backend, local: make(map[string]chan struct{})}
+}
+
+// Limit returns the concurrent requests allowed per key
+func (s *KeySlots) Limit() int {
+	return s.limit
+}
+
+// Acquire blocks until a request may use apiKey and returns the function
+// releasing its slot. A nil KeySlots never blocks.
+func (s *KeySlots) Acquire(ctx context.Context, apiKey string) (release func(), err error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	key := KeyID(apiKey)
+
+	s.mu.Lock()
+	semaphore, ok := s.local[key]
+	if !ok {
+		semaphore = make(chan struct{}, s.limit)
+		s.local[key] = semaphore
+	}
+	s.mu.Unlock()
+
+	select {
+	case semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	releaseLocal := func() { <-semaphore }
+
+	if s.backend == nil {
+		var once sync.Once
+		return func() { once.Do(releaseLocal) }, nil
+	}
+	releaseShared, err := s.backend.Acquire(ctx, key, s.limit)
+	if err != nil {
+		releaseLocal()
+		return nil, fmt.Errorf("failed to acquire API key slot: %w", err)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			releaseShared()
+			releaseLocal()
+		})
+	}, nil
+}
+
+// KeyID identifies an API key without revealing it, e.g. in the names of
+// shared lock files
+func KeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeyLimitedProvider makes the requests of a provider bound to one API key
+// wait for a slot of the key
+type KeyLimitedProvider struct {
+	provider LLMProvider
+	slots    *KeySlots
+	apiKey   string
+}
+
+// LimitKeyConcurrency wraps a provider using apiKey so its requests take a
+// slot of slots. Providers without a key, or nil slots, are returned as is.
+func LimitKeyConcurrency(provider LLMProvider, slots *KeySlots, apiKey string) LLMProvider {
+	if slots == nil || apiKey == "" {
+		return provider
+	}
+	return &KeyLimitedProvider{provider: provider, slots: slots, apiKey: apiKey}
+}
+
+// Name returns the wrapped provider's name
+func (k *KeyLimitedProvider) Name() string {
+	return k.provider.Name()
+}
+
+// Transcribe transcribes audio once a slot of the key is free
+func (k *KeyLimitedProvider) Transcribe(ctx context.Context, req *TranscriptionRequest) (*TranscriptionResult, error) {
+	return k.do(ctx, func() (*TranscriptionResult, error) {
+		return k.provider.Transcribe(ctx, req)
+	})
+}
+
+// TranscribeChunk transcribes a chunk once a slot of the key is free
+func (k *KeyLimitedProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return k.do(ctx, func() (*TranscriptionResult, error) {
+		return k.provider.TranscribeChunk(ctx, chunk, prompt, options)
+	})
+}
+
+// GenerateText answers a text-only prompt once a slot of the key is free
+func (k *KeyLimitedProvider) GenerateText(ctx context.Context, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return k.do(ctx, func() (*TranscriptionResult, error) {
+		return GenerateText(ctx, k.provider, prompt, options)
+	})
+}
+
+// Embed embeds texts once a slot of the key is free
+func (k *KeyLimitedProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	_, err := k.do(ctx, func() (*TranscriptionResult, error) {
+		var err error
+		vectors, err = Embed(ctx, k.provider, texts)
+		return &TranscriptionResult{}, err
+	})
+	return vectors, err
+}
+
+// do runs fn holding a slot of the key
+func (k *KeyLimitedProvider) do(ctx context.Context, fn func() (*TranscriptionResult, error)) (*TranscriptionResult, error) {
+	release, err := k.slots.Acquire(ctx, k.apiKey)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return fn()
+}
+
+// ValidateConfig validates the wrapped provider
+func (k *KeyLimitedProvider) ValidateConfig() error {
+	return k.provider.ValidateConfig()
+}
+
+// SupportedFormats returns the wrapped provider's formats
+func (k *KeyLimitedProvider) SupportedFormats() []string {
+	return k.provider.SupportedFormats()
+}
+
+// Models returns the wrapped provider's models
+func (k *KeyLimitedProvider) Models() []string {
+	return Models(k.provider)
+}
+
+// Quota returns the wrapped provider's quota
+func (k *KeyLimitedProvider) Quota(ctx context.Context) (*Quota, error) {
+	return ProviderQuota(ctx, k.provider)
+}
+
+// Diarizes reports whether the wrapped provider labels speakers natively
+func (k *KeyLimitedProvider) Diarizes() bool {
+	return Diarizes(k.provider)
+}
+
+// IsLocal reports whether the wrapped provider is local
+func (k *KeyLimitedProvider) IsLocal() bool {
+	return IsLocal(k.provider)
+}
@@ -0,0 +1,178 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyProvider records the most requests it served at once
+type concurrencyProvider struct {
+	fakeProvider
+	running, peak atomic.Int32
+}
+
+func (p *concurrencyProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	n := p.running.Add(1)
+	defer p.running.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return &TranscriptionResult{Text: "ok"}, nil
+}
+
+func TestKeySlotsCapConcurrency(t *testing.T) {
+	slots := NewKeySlots(2, NewFileSlots(t.TempDir(), 0))
+	shared := &concurrencyProvider{}
+	other := &concurrencyProvider{}
+	// Two providers with the same key share its slots
+	first := LimitKeyConcurrency(shared, slots, "key-a")
+	second := LimitKeyConcurrency(shared, slots, "key-a")
+	separate := LimitKeyConcurrency(other, slots, "key-b")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		for _, p := range []LLMProvider{first, second, separate} {
+			wg.Add(1)
+			go func(p LLMProvider) {
+				defer wg.Done()
+				if _, err := p.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{}); err != nil {
+					t.Error(err)
+				}
+			}(p)
+		}
+	}
+	wg.Wait()
+
+	if peak := shared.peak.Load(); peak != 2 {
+		t.Errorf("key-a served %d requests at once, want 2", peak)
+	}
+	if peak := other.peak.Load(); peak != 2 {
+		t.Errorf("key-b served %d requests at once, want 2", peak)
+	}
+	if LimitKeyConcurrency(shared, nil, "key-a") != LLMProvider(shared) || LimitKeyConcurrency(shared, slots, "") != LLMProvider(shared) {
+		t.Error("providers without slots or a key were wrapped")
+	}
+}
+
+func TestFileSlots(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	slots := NewFileSlots(dir, time.Minute)
+	other := NewFileSlots(dir, time.Minute) // Another process sharing dir
+
+	release, err := slots.Acquire(ctx, "key", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := other.Acquire(short, "key", 1); err == nil {
+		t.Fatal("a second holder took the only slot")
+	}
+	release()
+	release() // Releasing twice is harmless
+	release, err = other.Acquire(ctx, "key", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// The lease of a crashed holder expires
+	lease := filepath.Join(dir, "crashed", "slot-0.lease")
+	if err := os.MkdirAll(filepath.Dir(lease), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lease, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(lease, old, old); err != nil {
+		t.Fatal(err)
+	}
+	short, cancel = context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	release, err = slots.Acquire(short, "crashed", 1)
+	if err != nil {
+		t.Fatalf("expired lease was not taken over: %v", err)
+	}
+	release()
+}
+
+func TestFileSlotsTakeOverOnce(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		dir := t.TempDir()
+		lease := filepath.Join(dir, "slot-0.lease")
+		if err := os.WriteFile(lease, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-2 * time.Minute)
+		if err := os.Chtimes(lease, old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		// Processes sharing dir all find the lease expired at once
+		var taken atomic.Int32
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				owner, err := NewFileSlots(dir, time.Minute).take(lease)
+				if err != nil {
+					t.Error(err)
+				}
+				if owner != "" {
+					taken.Add(1)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if n := taken.Load(); n != 1 {
+			t.Fatalf("round %d: expired lease taken over %d times, want once", round, n)
+		}
+		if _, err := os.Stat(lease); err != nil {
+			t.Fatalf("round %d: lease of the new holder is gone: %v", round, err)
+		}
+	}
+}
+
+func TestFileSlotsReleaseKeepsTakenOverLease(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	slots := NewFileSlots(dir, time.Minute)
+	other := NewFileSlots(dir, time.Minute) // Another process sharing dir
+
+	release, err := slots.Acquire(ctx, "key", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The holder stalls until its lease expires and is taken over
+	lease := filepath.Join(dir, "key", "slot-0.lease")
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(lease, old, old); err != nil {
+		t.Fatal(err)
+	}
+	owner, err := other.take(lease)
+	if err != nil || owner == "" {
+		t.Fatalf("take() = %q, %v; want the expired lease", owner, err)
+	}
+
+	release()
+	if got := leaseOwner(lease); got != owner {
+		t.Errorf("lease owner after the old holder released = %q, want %q", got, owner)
+	}
+}
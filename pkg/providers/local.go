@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// LocalProvider is implemented by providers that can keep audio on
+// infrastructure under the user's control
+type LocalProvider interface {
+	// IsLocal reports whether requests stay on the local machine or network
+	IsLocal() bool
+}
+
+// IsLocal reports whether p is a local provider. Providers that do not
+// implement LocalProvider are treated as cloud providers.
+func IsLocal(p LLMProvider) bool {
+	local, ok := p.(LocalProvider)
+	return ok && local.IsLocal()
+}
+
+// IsLocalURL reports whether rawURL points at a loopback or private
+// network address, e.g. a self-hosted inference server
+func IsLocalURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
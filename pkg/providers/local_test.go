@@ -0,0 +1,43 @@
+package providers
+
+import "testing"
+
+func TestIsLocalURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://127.0.0.1:8080", true},
+		{"http://localhost:8080", true},
+		{"http://[::1]:8080", true},
+		{"http://192.168.1.20:8080", true},
+		{"http://10.0.0.5", true},
+		{"https://api.groq.com/openai/v1", false},
+		{"http://8.8.8.8", false},
+		{"::not a url", false},
+	}
+	for _, tt := range tests {
+		if got := IsLocalURL(tt.url); got != tt.want {
+			t.Errorf("IsLocalURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIsLocalDecorators(t *testing.T) {
+	local := &localFake{fakeProvider: fakeProvider{name: "local"}}
+	cloud := &fakeProvider{name: "cloud"}
+
+	if !IsLocal(NewFallbackProvider(local, local)) {
+		t.Error("fallback chain of local providers should be local")
+	}
+	if IsLocal(NewFallbackProvider(local, cloud)) {
+		t.Error("fallback chain with a cloud provider should not be local")
+	}
+}
+
+// localFake is a fakeProvider that reports itself as local
+type localFake struct {
+	fakeProvider
+}
+
+func (l *localFake) IsLocal() bool { return true }
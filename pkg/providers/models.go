@@ -0,0 +1,17 @@
+package providers
+
+// ModelLister is implemented by providers that can report which models
+// they bill for each chunk, so cost can be estimated before uploading
+type ModelLister interface {
+	// Models returns the model of every request made per chunk
+	Models() []string
+}
+
+// Models returns the models p bills for each chunk, or nil if p does not
+// implement ModelLister
+func Models(p LLMProvider) []string {
+	if lister, ok := p.(ModelLister); ok {
+		return lister.Models()
+	}
+	return nil
+}
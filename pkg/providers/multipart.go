@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// CreateAudioPart adds a file part to a multipart form like
+// multipart.Writer.CreateFormFile, but with the audio's MIME type instead
+// of application/octet-stream
+func CreateAudioPart(w *multipart.Writer, field, filename, mimeType string) (io.Writer, error) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(field), quoteEscaper.Replace(filename)))
+	header.Set("Content-Type", mimeType)
+	return w.CreatePart(header)
+}
+
+// AudioForm is a multipart form of text fields followed by one audio file
+// part. The audio is streamed from its source each time the form is
+// read, so it is never copied into memory.
+type AudioForm struct {
+	prefix      []byte
+	suffix      []byte
+	audio       *AudioSource
+	contentType string
+}
+
+// NewAudioForm lays out a form with the fields, in name order, and the
+// audio as file part field
+func NewAudioForm(fields map[string]string, field, filename, mimeType string, audio *AudioSource) (*AudioForm, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writer.WriteField(name, fields[name]); err != nil {
+			return nil, fmt.Errorf("failed to write form field %s: %w", name, err)
+		}
+	}
+	if _, err := CreateAudioPart(writer, field, filename, mimeType); err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	prefix := bytes.Clone(buf.Bytes())
+
+	buf.Reset()
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+	return &AudioForm{
+		prefix:      prefix,
+		suffix:      bytes.Clone(buf.Bytes()),
+		audio:       audio,
+		contentType: writer.FormDataContentType(),
+	}, nil
+}
+
+// Reader returns the whole form from its start
+func (f *AudioForm) Reader() io.Reader {
+	return io.MultiReader(bytes.NewReader(f.prefix), f.audio.Reader(), bytes.NewReader(f.suffix))
+}
+
+// Size returns the length of the form in bytes
+func (f *AudioForm) Size() int64 {
+	return int64(len(f.prefix)) + f.audio.Size() + int64(len(f.suffix))
+}
+
+// ContentType returns the Content-Type header of the form, with its boundary
+func (f *AudioForm) ContentType() string {
+	return f.contentType
+}
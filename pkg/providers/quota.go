@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ErrQuotaUnknown is returned by providers that cannot report their quota,
+// e.g. because the API has no way to query it
+var ErrQuotaUnknown = errors.New("provider quota unknown")
+
+// Quota is what is left of a provider's daily request allowance
+type Quota struct {
+	Provider          string
+	RequestsLimit     int // Requests per day
+	RequestsRemaining int
+}
+
+// QuotaReporter is implemented by providers that can report their
+// remaining quota before a batch is started
+type QuotaReporter interface {
+	// Quota returns the remaining quota, or ErrQuotaUnknown
+	Quota(ctx context.Context) (*Quota, error)
+}
+
+// ProviderQuota returns the remaining quota of p, or ErrQuotaUnknown if p
+// does not implement QuotaReporter
+func ProviderQuota(ctx context.Context, p LLMProvider) (*Quota, error) {
+	if reporter, ok := p.(QuotaReporter); ok {
+		return reporter.Quota(ctx)
+	}
+	return nil, ErrQuotaUnknown
+}
+
+// ParseRateLimitHeaders reads the daily request quota from the
+// x-ratelimit-* headers of OpenAI-compatible APIs such as Groq, where the
+// request limit is per day. It reports false if the headers are missing.
+func ParseRateLimitHeaders(provider string, header http.Header) (*Quota, bool) {
+	limit, err1 := strconv.Atoi(header.Get("x-ratelimit-limit-requests"))
+	remaining, err2 := strconv.Atoi(header.Get("x-ratelimit-remaining-requests"))
+	if err1 != nil || err2 != nil {
+		return nil, false
+	}
+	return &Quota{Provider: provider, RequestsLimit: limit, RequestsRemaining: remaining}, true
+}
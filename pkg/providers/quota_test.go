@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// quotaProvider reports a fixed quota
+type quotaProvider struct {
+	fakeProvider
+	quota Quota
+}
+
+func (p *quotaProvider) Quota(ctx context.Context) (*Quota, error) {
+	quota := p.quota
+	return &quota, nil
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ratelimit-limit-requests", "14400")
+	header.Set("x-ratelimit-remaining-requests", "14370")
+	quota, ok := ParseRateLimitHeaders("groq", header)
+	if !ok || quota.RequestsLimit != 14400 || quota.RequestsRemaining != 14370 {
+		t.Errorf("ParseRateLimitHeaders() = %+v, %v", quota, ok)
+	}
+	if _, ok := ParseRateLimitHeaders("groq", http.Header{}); ok {
+		t.Error("ParseRateLimitHeaders() without headers reported a quota")
+	}
+}
+
+func TestProviderQuota(t *testing.T) {
+	if _, err := ProviderQuota(context.Background(), &fakeProvider{}); !errors.Is(err, ErrQuotaUnknown) {
+		t.Errorf("ProviderQuota() of a provider without a quota = %v, want ErrQuotaUnknown", err)
+	}
+
+	// Rotated keys add up
+	r, _ := NewRotatingProvider([]string{"k1", "k2"}, KeyRoundRobin, func(key string) LLMProvider {
+		return &quotaProvider{quota: Quota{Provider: "groq", RequestsLimit: 100, RequestsRemaining: 40}}
+	})
+	quota, err := ProviderQuota(context.Background(), r)
+	if err != nil || quota.RequestsLimit != 200 || quota.RequestsRemaining != 80 {
+		t.Errorf("rotating ProviderQuota() = %+v, %v, want 80 of 200", quota, err)
+	}
+
+	// Fallbacks only count the primary
+	f := NewFallbackProvider(&quotaProvider{quota: Quota{RequestsLimit: 100, RequestsRemaining: 10}}, &fakeProvider{})
+	if quota, err := ProviderQuota(context.Background(), f); err != nil || quota.RequestsRemaining != 10 {
+		t.Errorf("fallback ProviderQuota() = %+v, %v, want the primary's", quota, err)
+	}
+}
@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles provider requests using two token buckets: one for
+// requests per minute and one for estimated tokens per minute. A zero limit
+// disables the corresponding bucket. It is safe for concurrent use.
+type RateLimiter struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// NewRateLimiter creates a rate limiter; returns nil when both limits are zero
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		requests: newTokenBucket(requestsPerMinute),
+		tokens:   newTokenBucket(tokensPerMinute),
+	}
+}
+
+// Wait blocks until one request consuming the given number of tokens is allowed.
+// A nil RateLimiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.requests.wait(ctx, 1); err != nil {
+		return err
+	}
+	return l.tokens.wait(ctx, tokens)
+}
+
+// tokenBucket is a minimal token bucket refilled continuously at perMinute/60 per second
+type tokenBucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	available float64
+	rate      float64 // tokens per second
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		rate:      float64(perMinute) / 60,
+		last:      time.Now(),
+	}
+}
+
+// wait reserves n tokens, sleeping until they have accumulated
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.available += now.Sub(b.last).Seconds() * b.rate
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+	b.last = now
+
+	// A single request larger than the bucket could never be served; cap it
+	need := float64(n)
+	if need > b.capacity {
+		need = b.capacity
+	}
+
+	// Reserve immediately (possibly going negative) so concurrent callers queue fairly
+	b.available -= need
+	var delay time.Duration
+	if b.available < 0 {
+		delay = time.Duration(-b.available / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Give the reservation back
+		b.mu.Lock()
+		b.available += need
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// EstimateTokens roughly estimates the tokens a chunk request consumes:
+// about 32 tokens per second of audio plus ~4 characters per prompt token
+func EstimateTokens(audio time.Duration, prompt string) int {
+	return int(audio.Seconds()*32) + len(prompt)/4
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	var l *RateLimiter = NewRateLimiter(0, 0)
+	if l != nil {
+		t.Fatal("expected nil limiter when no limits are set")
+	}
+	if err := l.Wait(context.Background(), 1000); err != nil {
+		t.Errorf("nil limiter Wait() = %v", err)
+	}
+}
+
+func TestRateLimiterBurstThenThrottle(t *testing.T) {
+	// 600 requests/minute = 10/second, burst of 600
+	l := NewRateLimiter(600, 0)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 600; i++ {
+		if err := l.Wait(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("burst should not block, took %v", elapsed)
+	}
+
+	// The next request must wait for roughly one refill interval (100ms)
+	start = time.Now()
+	if err := l.Wait(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected throttling, waited only %v", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := NewRateLimiter(0, 60) // 1 token per second
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := l.Wait(ctx, 60); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := l.Wait(ctx, 60); err == nil {
+		t.Error("expected context error while waiting for tokens")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(10*time.Second, "abcdefgh"); got != 322 {
+		t.Errorf("EstimateTokens() = %d, want 322", got)
+	}
+}
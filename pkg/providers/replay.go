@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReplayableRequest lets a request's audio be sent more than once. Chunk
+// files are read again for each send; other audio is buffered once.
+type ReplayableRequest struct {
+	req   TranscriptionRequest
+	audio *AudioSource
+}
+
+// NewReplayableRequest prepares the request audio for replay
+func NewReplayableRequest(req *TranscriptionRequest) (*ReplayableRequest, error) {
+	audio, err := NewAudioSource(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayableRequest{req: *req, audio: audio}, nil
+}
+
+// Request returns a fresh copy of the request with an unread audio reader
+func (r *ReplayableRequest) Request() *TranscriptionRequest {
+	req := r.req
+	req.Audio = r.audio.Reader()
+	return &req
+}
+
+// Data reads the whole audio into memory
+func (r *ReplayableRequest) Data() ([]byte, error) {
+	data, err := io.ReadAll(r.audio.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,182 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when an API responds with a non-success HTTP status
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	message := fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Message)
+	if hint := e.Hint(); hint != "" {
+		message += " (" + hint + ")"
+	}
+	return message
+}
+
+// Retryable reports whether the status code indicates a transient failure
+func (e *StatusError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// NewHTTPError builds the error for a failed HTTP response from its body,
+// keeping only the message of JSON error bodies. Rate-limited responses
+// become a RateLimitError carrying the server's Retry-After hint.
+func NewHTTPError(resp *http.Response, body string) error {
+	err := &StatusError{StatusCode: resp.StatusCode, Message: apiErrorMessage(body)}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{
+			RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Err:        err,
+		}
+	}
+	return err
+}
+
+// ParseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date. It returns 0 when the header is missing or invalid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is a transient failure worth retrying:
+// rate limits, retryable HTTP statuses and network errors
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if IsRateLimited(err) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// skipRateLimitsKey is the context key marking rate limits as not to be
+// retried
+type skipRateLimitsKey struct{}
+
+// WithoutRateLimitRetries returns a context under which RetryPolicy.Do
+// returns rate limit errors at once instead of backing off, for callers
+// that have somewhere else to send the request, e.g. another API key
+func WithoutRateLimitRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipRateLimitsKey{}, true)
+}
+
+// RetryPolicy retries transient failures with exponential backoff and jitter
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry (default 1s)
+	MaxDelay   time.Duration // Upper bound for backoff delays (default 30s)
+}
+
+// NewRetryPolicy returns a policy with default delays
+func NewRetryPolicy(maxRetries int) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+	}
+}
+
+// Do calls fn until it succeeds, fails with a non-retryable error, the
+// retries are exhausted or ctx is done. Rate limits are not retried under
+// a context from WithoutRateLimitRetries.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	skipRateLimits, _ := ctx.Value(skipRateLimitsKey{}).(bool)
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if !IsRetryable(err) || (skipRateLimits && IsRateLimited(err)) {
+			return err
+		}
+		if attempt >= p.MaxRetries {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+		}
+
+		timer := time.NewTimer(p.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the wait before retry number attempt+1. A server-provided
+// Retry-After takes precedence; otherwise the backoff doubles per attempt
+// and the upper half is randomized to spread out concurrent clients.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var rlErr *RateLimitError
+	if errors.As(err, &rlErr) && rlErr.RetryAfter > 0 {
+		return rlErr.RetryAfter
+	}
+
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	d := base
+	for i := 0; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
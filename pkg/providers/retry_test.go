@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRetriesTransientErrors(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &StatusError{StatusCode: http.StatusServiceUnavailable}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryPolicyStopsOnFatalErrors(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		return fmt.Errorf("bad request: %w", &StatusError{StatusCode: http.StatusBadRequest})
+	})
+	if err == nil {
+		t.Fatal("Do() succeeded, want error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryPolicyGivesUp(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}
+
+	calls := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		return &RateLimitError{Err: errors.New("slow down")}
+	})
+	if !IsRateLimited(err) {
+		t.Errorf("err = %v, want wrapped RateLimitError", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestRetryPolicyHonoursRetryAfter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Hour}
+	err := &RateLimitError{RetryAfter: 2 * time.Second, Err: errors.New("429")}
+	if got := policy.delay(0, err); got != 2*time.Second {
+		t.Errorf("delay() = %v, want 2s", got)
+	}
+
+	// Backoff doubles but stays within [d/2, d] and under MaxDelay
+	policy = RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, maxWant := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		got := policy.delay(attempt, errors.New("transient"))
+		if got < maxWant/2 || got > maxWant {
+			t.Errorf("delay(%d) = %v, want within [%v, %v]", attempt, got, maxWant/2, maxWant)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyWithoutRateLimitRetries(t *testing.T) {
+	calls := 0
+	rlErr := &RateLimitError{RetryAfter: time.Hour, Err: errors.New("429")}
+	err := RetryPolicy{MaxRetries: 3}.Do(WithoutRateLimitRetries(context.Background()), func(context.Context) error {
+		calls++
+		return rlErr
+	})
+	if !errors.Is(err, rlErr) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want the rate limit after 1", err, calls)
+	}
+}
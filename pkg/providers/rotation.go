@@ -0,0 +1,277 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// KeyStrategy selects which API key a RotatingProvider uses next
+type KeyStrategy string
+
+const (
+	// KeyRoundRobin cycles through keys in order
+	KeyRoundRobin KeyStrategy = "round_robin"
+	// KeyLeastRecentlyUsed picks the key that has been idle the longest
+	KeyLeastRecentlyUsed KeyStrategy = "lru"
+)
+
+const (
+	defaultKeyBackoff = 30 * time.Second
+	maxKeyBackoff     = 10 * time.Minute
+)
+
+// ProviderFactory creates a provider bound to a single API key
+type ProviderFactory func(apiKey string) LLMProvider
+
+// RotatingProvider spreads requests over several API keys of the same
+// provider and backs off keys that hit their rate limit
+type RotatingProvider struct {
+	keys     []*providerKey
+	strategy KeyStrategy
+	next     int
+	mu       sync.Mutex
+}
+
+// providerKey tracks per-key state
+type providerKey struct {
+	index        int
+	provider     LLMProvider
+	lastUsed     time.Time
+	backoffUntil time.Time
+	strikes      int
+}
+
+// NewRotatingProvider creates a provider that rotates between API keys
+func NewRotatingProvider(apiKeys []string, strategy KeyStrategy, factory ProviderFactory) (*RotatingProvider, error) {
+	if len(apiKeys) == 0 {
+		return nil, fmt.Errorf("at least one API key is required")
+	}
+	switch strategy {
+	case "":
+		strategy = KeyRoundRobin
+	case KeyRoundRobin, KeyLeastRecentlyUsed:
+	default:
+		return nil, fmt.Errorf("unknown key strategy: %s", strategy)
+	}
+
+	r := &RotatingProvider{strategy: strategy}
+	for i, key := range apiKeys {
+		r.keys = append(r.keys, &providerKey{index: i, provider: factory(key)})
+	}
+	return r, nil
+}
+
+// Name returns the underlying provider name
+func (r *RotatingProvider) Name() string {
+	return r.keys[0].provider.Name()
+}
+
+// Transcribe transcribes audio, rotating keys on rate limit errors
+func (r *RotatingProvider) Transcribe(ctx context.Context, req *TranscriptionRequest) (*TranscriptionResult, error) {
+	replay, err := NewReplayableRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.do(ctx, func(ctx context.Context, p LLMProvider) (*TranscriptionResult, error) {
+		return p.Transcribe(ctx, replay.Request())
+	})
+}
+
+// TranscribeChunk transcribes a chunk, rotating keys on rate limit errors
+func (r *RotatingProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return r.do(ctx, func(ctx context.Context, p LLMProvider) (*TranscriptionResult, error) {
+		return p.TranscribeChunk(ctx, chunk, prompt, options)
+	})
+}
+
+// GenerateText answers a text-only prompt, rotating keys on rate limit errors
+func (r *RotatingProvider) GenerateText(ctx context.Context, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	return r.do(ctx, func(ctx context.Context, p LLMProvider) (*TranscriptionResult, error) {
+		return GenerateText(ctx, p, prompt, options)
+	})
+}
+
+// Embed embeds texts, rotating keys on rate limit errors
+func (r *RotatingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	_, err := r.do(ctx, func(ctx context.Context, p LLMProvider) (*TranscriptionResult, error) {
+		var err error
+		vectors, err = Embed(ctx, p, texts)
+		return &TranscriptionResult{}, err
+	})
+	return vectors, err
+}
+
+// do runs fn with an available key, moving to the next key when one is rate limited.
+// Every key is tried at most once per call; when all keys are backing off the
+// call waits for the earliest one to become available. Keys other than the
+// last one tried return rate limits at once instead of retrying them, so a
+// throttled key is left straight away.
+func (r *RotatingProvider) do(ctx context.Context, fn func(context.Context, LLMProvider) (*TranscriptionResult, error)) (*TranscriptionResult, error) {
+	log := logger.WithComponent("key-rotation")
+
+	var lastErr error
+	for attempt := 0; attempt < len(r.keys); attempt++ {
+		key, wait := r.acquire()
+		if wait > 0 {
+			log.Warn().Dur("wait", wait).Msg("All API keys are rate limited, waiting")
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		keyCtx := ctx
+		if attempt < len(r.keys)-1 {
+			keyCtx = WithoutRateLimitRetries(ctx)
+		}
+		result, err := fn(keyCtx, key.provider)
+		if err == nil {
+			r.release(key, nil)
+			return result, nil
+		}
+
+		r.release(key, err)
+		lastErr = err
+		if !IsRateLimited(err) {
+			return nil, err
+		}
+
+		log.Warn().
+			Int("key_index", key.index).
+			Err(err).
+			Msg("API key rate limited, rotating to next key")
+	}
+
+	return nil, fmt.Errorf("all %d API keys are rate limited: %w", len(r.keys), lastErr)
+}
+
+// acquire picks the next key. If every key is backing off it returns the one
+// that becomes available first and how long to wait for it.
+func (r *RotatingProvider) acquire() (*providerKey, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var chosen *providerKey
+
+	switch r.strategy {
+	case KeyLeastRecentlyUsed:
+		for _, k := range r.keys {
+			if k.backoffUntil.After(now) {
+				continue
+			}
+			if chosen == nil || k.lastUsed.Before(chosen.lastUsed) {
+				chosen = k
+			}
+		}
+	default:
+		for i := 0; i < len(r.keys); i++ {
+			k := r.keys[(r.next+i)%len(r.keys)]
+			if !k.backoffUntil.After(now) {
+				chosen = k
+				r.next = (k.index + 1) % len(r.keys)
+				break
+			}
+		}
+	}
+
+	var wait time.Duration
+	if chosen == nil {
+		for _, k := range r.keys {
+			if chosen == nil || k.backoffUntil.Before(chosen.backoffUntil) {
+				chosen = k
+			}
+		}
+		wait = chosen.backoffUntil.Sub(now)
+	}
+
+	chosen.lastUsed = now
+	return chosen, wait
+}
+
+// release records the outcome of a request made with key
+func (r *RotatingProvider) release(key *providerKey, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		key.strikes = 0
+		return
+	}
+
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		return
+	}
+
+	// Back off exponentially per key, honouring the server hint when present
+	key.strikes++
+	backoff := defaultKeyBackoff << (key.strikes - 1)
+	if backoff > maxKeyBackoff || backoff <= 0 {
+		backoff = maxKeyBackoff
+	}
+	if rlErr.RetryAfter > backoff {
+		backoff = rlErr.RetryAfter
+	}
+	key.backoffUntil = time.Now().Add(backoff)
+}
+
+// ValidateConfig validates the provider for every key
+func (r *RotatingProvider) ValidateConfig() error {
+	for _, k := range r.keys {
+		if err := k.provider.ValidateConfig(); err != nil {
+			return fmt.Errorf("key %d: %w", k.index, err)
+		}
+	}
+	return nil
+}
+
+// SupportedFormats returns the underlying provider's supported formats
+func (r *RotatingProvider) SupportedFormats() []string {
+	return r.keys[0].provider.SupportedFormats()
+}
+
+// Models returns the underlying provider's models
+func (r *RotatingProvider) Models() []string {
+	return Models(r.keys[0].provider)
+}
+
+// Quota returns the combined quota of all keys, or ErrQuotaUnknown if any
+// key's quota is unknown
+func (r *RotatingProvider) Quota(ctx context.Context) (*Quota, error) {
+	total := &Quota{}
+	for _, k := range r.keys {
+		quota, err := ProviderQuota(ctx, k.provider)
+		if err != nil {
+			return nil, err
+		}
+		total.Provider = quota.Provider
+		total.RequestsLimit += quota.RequestsLimit
+		total.RequestsRemaining += quota.RequestsRemaining
+	}
+	return total, nil
+}
+
+// Diarizes reports whether the underlying provider labels speakers
+// natively
+func (r *RotatingProvider) Diarizes() bool {
+	return Diarizes(r.keys[0].provider)
+}
+
+// IsLocal reports whether the underlying providers are local
+func (r *RotatingProvider) IsLocal() bool {
+	for _, k := range r.keys {
+		if !IsLocal(k.provider) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRotatingProviderRoundRobin(t *testing.T) {
+	built := map[string]*fakeProvider{}
+	r, err := NewRotatingProvider([]string{"k1", "k2"}, KeyRoundRobin, func(key string) LLMProvider {
+		built[key] = &fakeProvider{name: key}
+		return built[key]
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingProvider() failed: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := r.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{}); err != nil {
+			t.Fatalf("TranscribeChunk() failed: %v", err)
+		}
+	}
+
+	if built["k1"].calls != 2 || built["k2"].calls != 2 {
+		t.Errorf("calls = k1:%d k2:%d, want 2 each", built["k1"].calls, built["k2"].calls)
+	}
+}
+
+func TestRotatingProviderSkipsRateLimitedKey(t *testing.T) {
+	limited := &fakeProvider{name: "k1", err: &RateLimitError{Err: errors.New("429")}}
+	healthy := &fakeProvider{name: "k2"}
+	r, _ := NewRotatingProvider([]string{"k1", "k2"}, KeyLeastRecentlyUsed, func(key string) LLMProvider {
+		if key == "k1" {
+			return limited
+		}
+		return healthy
+	})
+
+	for i := 0; i < 3; i++ {
+		result, err := r.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+		if err != nil {
+			t.Fatalf("TranscribeChunk() failed: %v", err)
+		}
+		if result.Text != "from k2" {
+			t.Errorf("Text = %q, want from k2", result.Text)
+		}
+	}
+
+	// The limited key is backing off, so it must only have been tried once
+	if limited.calls != 1 {
+		t.Errorf("rate limited key called %d times, want 1", limited.calls)
+	}
+}
+
+// retryingProvider retries its error like the HTTP providers do
+type retryingProvider struct {
+	fakeProvider
+}
+
+func (p *retryingProvider) TranscribeChunk(ctx context.Context, chunk *AudioChunk, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	var result *TranscriptionResult
+	err := RetryPolicy{MaxRetries: 3, BaseDelay: time.Hour}.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = p.fakeProvider.TranscribeChunk(ctx, chunk, prompt, options)
+		return err
+	})
+	return result, err
+}
+
+func TestRotatingProviderLeavesThrottledKeyWithoutRetrying(t *testing.T) {
+	limited := &retryingProvider{fakeProvider{name: "k1", err: &RateLimitError{RetryAfter: time.Hour, Err: errors.New("429")}}}
+	healthy := &retryingProvider{fakeProvider{name: "k2"}}
+	r, _ := NewRotatingProvider([]string{"k1", "k2"}, KeyRoundRobin, func(key string) LLMProvider {
+		if key == "k1" {
+			return limited
+		}
+		return healthy
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := r.TranscribeChunk(ctx, &AudioChunk{}, "", TranscriptionOptions{})
+	if err != nil {
+		t.Fatalf("TranscribeChunk() failed: %v", err)
+	}
+	if result.Text != "from k2" {
+		t.Errorf("Text = %q, want from k2", result.Text)
+	}
+	if limited.calls != 1 {
+		t.Errorf("rate limited key called %d times, want 1", limited.calls)
+	}
+}
+
+func TestRotatingProviderNonRateLimitErrorIsReturned(t *testing.T) {
+	r, _ := NewRotatingProvider([]string{"k1", "k2"}, KeyRoundRobin, func(key string) LLMProvider {
+		return &fakeProvider{name: key, err: errors.New("bad request")}
+	})
+
+	_, err := r.TranscribeChunk(context.Background(), &AudioChunk{}, "", TranscriptionOptions{})
+	if err == nil || IsRateLimited(err) {
+		t.Errorf("expected plain error, got %v", err)
+	}
+}
+
+func TestNewRotatingProviderValidation(t *testing.T) {
+	factory := func(key string) LLMProvider { return &fakeProvider{name: key} }
+	if _, err := NewRotatingProvider(nil, KeyRoundRobin, factory); err == nil {
+		t.Error("expected error for no keys")
+	}
+	if _, err := NewRotatingProvider([]string{"k"}, "random", factory); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
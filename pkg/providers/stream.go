@@ -0,0 +1,89 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// AudioSource is request audio that can be read from the start once per
+// attempt, so retries resend it without holding the whole chunk in
+// memory. Chunk files and other readers with ReadAt are read in place;
+// anything else is buffered once.
+type AudioSource struct {
+	at   io.ReaderAt
+	size int64
+}
+
+// NewAudioSource prepares r for reading once per attempt
+func NewAudioSource(r io.Reader) (*AudioSource, error) {
+	if at, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		if size, err := at.Seek(0, io.SeekEnd); err == nil {
+			return &AudioSource{at: at, size: size}, nil
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio data: %w", err)
+	}
+	return &AudioSource{at: bytes.NewReader(data), size: int64(len(data))}, nil
+}
+
+// Size returns the length of the audio in bytes
+func (s *AudioSource) Size() int64 {
+	return s.size
+}
+
+// Reader returns the audio from its start. Readers are independent, so
+// one left behind by an abandoned attempt does not disturb the next.
+func (s *AudioSource) Reader() io.Reader {
+	return io.NewSectionReader(s.at, 0, s.size)
+}
+
+// Base64Size returns the length of the audio base64-encoded
+func (s *AudioSource) Base64Size() int64 {
+	return int64(base64.StdEncoding.EncodedLen(int(s.size)))
+}
+
+// Base64Reader returns the audio from its start, base64-encoded as it is read
+func (s *AudioSource) Base64Reader() io.Reader {
+	return &base64Reader{source: s.Reader()}
+}
+
+// base64Reader encodes its source a block at a time
+type base64Reader struct {
+	source  io.Reader
+	block   [3 * 1024]byte
+	encoded []byte
+	pending []byte
+	err     error
+}
+
+func (r *base64Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		// Whole blocks of three bytes encode without padding, so only the
+		// last block may end in "="
+		n, err := io.ReadFull(r.source, r.block[:])
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		r.err = err
+		size := base64.StdEncoding.EncodedLen(n)
+		if cap(r.encoded) < size {
+			r.encoded = make([]byte, size)
+		}
+		r.encoded = r.encoded[:size]
+		base64.StdEncoding.Encode(r.encoded, r.block[:n])
+		r.pending = r.encoded
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
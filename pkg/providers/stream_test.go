@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAudioSource(t *testing.T) {
+	data := bytes.Repeat([]byte("audio bytes "), 1000)
+	path := filepath.Join(t.TempDir(), "chunk.mp3")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	for name, r := range map[string]io.Reader{
+		"file":   file,
+		"stream": io.MultiReader(bytes.NewReader(data)), // Neither ReaderAt nor Seeker
+	} {
+		t.Run(name, func(t *testing.T) {
+			source, err := NewAudioSource(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source.Size() != int64(len(data)) {
+				t.Errorf("Size() = %d, want %d", source.Size(), len(data))
+			}
+			// Every attempt reads the whole audio again
+			for attempt := 0; attempt < 2; attempt++ {
+				got, err := io.ReadAll(source.Reader())
+				if err != nil || !bytes.Equal(got, data) {
+					t.Fatalf("attempt %d read %d bytes, %v", attempt, len(got), err)
+				}
+			}
+		})
+	}
+}
+
+func TestBase64Reader(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 3071, 3072, 3073, 10000} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		source, err := NewAudioSource(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(source.Base64Reader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := base64.StdEncoding.EncodeToString(data); string(got) != want {
+			t.Errorf("%d bytes encoded differently", size)
+		}
+		if source.Base64Size() != int64(len(got)) {
+			t.Errorf("Base64Size() = %d, want %d", source.Base64Size(), len(got))
+		}
+	}
+}
+
+func TestAudioForm(t *testing.T) {
+	data := []byte("RIFF audio")
+	source, err := NewAudioSource(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := NewAudioForm(map[string]string{"model": "whisper", "prompt": "Names: Ana"}, "file", "chunk_000.wav", "audio/wav", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(form.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(body)) != form.Size() {
+		t.Errorf("Size() = %d, body is %d bytes", form.Size(), len(body))
+	}
+
+	_, params, err := mime.ParseMediaType(form.ContentType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	parts := map[string]string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, _ := io.ReadAll(part)
+		parts[part.FormName()] = string(value)
+		if part.FormName() == "file" && (part.FileName() != "chunk_000.wav" || part.Header.Get("Content-Type") != "audio/wav") {
+			t.Errorf("file part header = %v", part.Header)
+		}
+	}
+	if parts["file"] != string(data) || parts["model"] != "whisper" || !strings.Contains(parts["prompt"], "Ana") {
+		t.Errorf("parts = %v", parts)
+	}
+}
@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTextUnsupported is returned for text-only prompts to providers that
+// only accept audio
+var ErrTextUnsupported = errors.New("provider does not support text prompts")
+
+// TextGenerator is implemented by providers that can answer a prompt
+// without audio, used by post-processing steps such as summarization
+type TextGenerator interface {
+	// GenerateText sends a text-only prompt and returns the reply
+	GenerateText(ctx context.Context, prompt string, options TranscriptionOptions) (*TranscriptionResult, error)
+}
+
+// GenerateText sends a text-only prompt to p, or returns
+// ErrTextUnsupported if p does not implement TextGenerator
+func GenerateText(ctx context.Context, p LLMProvider, prompt string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	generator, ok := p.(TextGenerator)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", p.Name(), ErrTextUnsupported)
+	}
+	return generator.GenerateText(ctx, prompt, options)
+}
@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is where the time of a provider's requests went, to tell a slow
+// network from a slow model
+type Timing struct {
+	Requests int           `json:"requests"` // HTTP requests made, retries included
+	Upload   time.Duration `json:"upload"`   // Connecting and sending the request body
+	Latency  time.Duration `json:"latency"`  // From the request sent to the first response byte
+	Parse    time.Duration `json:"parse"`    // Decoding the response into a result
+}
+
+// Add returns the sum of two timings
+func (t Timing) Add(other Timing) Timing {
+	return Timing{
+		Requests: t.Requests + other.Requests,
+		Upload:   t.Upload + other.Upload,
+		Latency:  t.Latency + other.Latency,
+		Parse:    t.Parse + other.Parse,
+	}
+}
+
+// timingKey is the context key of the timing recorder
+type timingKey struct{}
+
+// timingRecorder collects the timing of requests made with a context,
+// possibly concurrently, e.g. by an ensemble
+type timingRecorder struct {
+	mu     sync.Mutex
+	timing Timing
+}
+
+// add records a timing
+func (r *timingRecorder) add(timing Timing) {
+	r.mu.Lock()
+	r.timing = r.timing.Add(timing)
+	r.mu.Unlock()
+}
+
+// WithTiming returns a context whose provider requests are timed, and a
+// function returning the timing recorded so far
+func WithTiming(ctx context.Context) (context.Context, func() Timing) {
+	recorder := &timingRecorder{}
+	return context.WithValue(ctx, timingKey{}, recorder), func() Timing {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return recorder.timing
+	}
+}
+
+// TraceRequest returns req with its upload and latency recorded into the
+// timing of its context, if it has one (see WithTiming)
+func TraceRequest(req *http.Request) *http.Request {
+	recorder, ok := req.Context().Value(timingKey{}).(*timingRecorder)
+	if !ok {
+		return req
+	}
+
+	// The transport calls back from its reading and writing goroutines
+	var mu sync.Mutex
+	var started, wrote time.Time
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			mu.Lock()
+			started = time.Now()
+			mu.Unlock()
+			recorder.add(Timing{Requests: 1})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			upload := wrote.Sub(started)
+			mu.Unlock()
+			recorder.add(Timing{Upload: upload})
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			sent := wrote
+			mu.Unlock()
+			// A server may answer before the body is sent
+			if !sent.IsZero() {
+				recorder.add(Timing{Latency: time.Since(sent)})
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// TimeParse adds the time since start to the parse time of ctx's timing,
+// if it has one; deferred around decoding a response as
+// defer TimeParse(ctx, time.Now())
+func TimeParse(ctx context.Context, start time.Time) {
+	if recorder, ok := ctx.Value(timingKey{}).(*timingRecorder); ok {
+		recorder.add(Timing{Parse: time.Since(start)})
+	}
+}
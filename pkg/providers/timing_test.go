@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTraceRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	ctx, timing := WithTiming(context.Background())
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", server.URL, strings.NewReader("audio"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(TraceRequest(req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	func() {
+		defer TimeParse(ctx, time.Now().Add(-time.Millisecond))
+	}()
+
+	got := timing()
+	if got.Requests != 2 {
+		t.Errorf("Requests = %d, want 2", got.Requests)
+	}
+	if got.Latency < 40*time.Millisecond {
+		t.Errorf("Latency = %s, want the server's 2x20ms", got.Latency)
+	}
+	if got.Upload <= 0 || got.Parse < time.Millisecond {
+		t.Errorf("timing = %+v, want upload and parse recorded", got)
+	}
+
+	// Requests without a timing context are left alone
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	if TraceRequest(req) != req {
+		t.Error("TraceRequest() traced a request without timing")
+	}
+	TimeParse(context.Background(), time.Now())
+}
@@ -0,0 +1,64 @@
+package providers
+
+import "encoding/json"
+
+// Metadata keys under which providers report token usage
+const (
+	MetadataPromptTokens = "prompt_tokens"
+	MetadataOutputTokens = "output_tokens"
+	MetadataTotalTokens  = "total_tokens"
+)
+
+// Usage counts the tokens consumed by one or more requests
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens: u.PromptTokens + other.PromptTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		TotalTokens:  u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// IsZero reports whether no usage was recorded
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// SetMetadata stores the usage in a result's metadata
+func (u Usage) SetMetadata(metadata map[string]interface{}) {
+	metadata[MetadataPromptTokens] = u.PromptTokens
+	metadata[MetadataOutputTokens] = u.OutputTokens
+	metadata[MetadataTotalTokens] = u.TotalTokens
+}
+
+// UsageFromMetadata reads the usage a provider stored in result metadata.
+// Values decoded from JSON (float64, json.Number) are accepted too.
+func UsageFromMetadata(metadata map[string]interface{}) Usage {
+	return Usage{
+		PromptTokens: metadataInt(metadata, MetadataPromptTokens),
+		OutputTokens: metadataInt(metadata, MetadataOutputTokens),
+		TotalTokens:  metadataInt(metadata, MetadataTotalTokens),
+	}
+}
+
+// metadataInt reads an integer metadata value, returning 0 if absent
+func metadataInt(metadata map[string]interface{}, key string) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
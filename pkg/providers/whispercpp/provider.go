@@ -0,0 +1,350 @@
+package whispercpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+const defaultBaseURL = "http://127.0.0.1:8080"
+
+// mimeTypes holds MIME type overrides; the server detects the container
+// from the file itself
+var mimeTypes = mediatype.Overrides{}
+
+// Provider implements the LLM provider interface for a self-hosted
+// whisper.cpp server (examples/server). The server must be started with
+// --convert so it accepts the MP3 chunks produced by the chunker.
+type Provider struct {
+	baseURL    string
+	model      string
+	language   string
+	timeout    time.Duration
+	retries    int
+	httpClient *http.Client
+}
+
+// InferenceResponse represents the verbose_json response from whisper.cpp
+type InferenceResponse struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language"`
+	Duration float64   `json:"duration"`
+	Segments []Segment `json:"segments"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Segment represents a transcribed segment in the whisper.cpp response
+type Segment struct {
+	ID           int     `json:"id"`
+	Start        float64 `json:"start"`
+	End          float64 `json:"end"`
+	Text         string  `json:"text"`
+	NoSpeechProb float64 `json:"no_speech_prob"`
+
+	// Speaker of the segment when the server diarizes: a number or "?"
+	// for an unknown speaker. Servers that only mark it in the text, as
+	// "(speaker 1)", leave it empty.
+	Speaker json.RawMessage `json:"speaker,omitempty"`
+}
+
+// speakerPrefix matches the speaker mark diarizing servers put before the
+// text of a segment
+var speakerPrefix = regexp.MustCompile(`^\s*[(\[]speaker[ _]?([^)\]]*)[)\]]\s*`)
+
+// NewProvider creates a new whisper.cpp provider instance
+func NewProvider(options ...ProviderOption) *Provider {
+	p := &Provider{
+		baseURL: defaultBaseURL,
+		model:   "whisper.cpp",
+		timeout: 10 * time.Minute,
+		retries: 3,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Minute,
+		},
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p
+}
+
+// ProviderOption allows customizing the provider
+type ProviderOption func(*Provider)
+
+// WithBaseURL sets the server URL
+func WithBaseURL(baseURL string) ProviderOption {
+	return func(p *Provider) {
+		if baseURL != "" {
+			p.baseURL = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+}
+
+// WithTimeout sets the request timeout
+func WithTimeout(timeout time.Duration) ProviderOption {
+	return func(p *Provider) {
+		if timeout > 0 {
+			p.timeout = timeout
+			p.httpClient.Timeout = timeout
+		}
+	}
+}
+
+// WithRetries sets the number of retry attempts
+func WithRetries(retries int) ProviderOption {
+	return func(p *Provider) {
+		p.retries = retries
+	}
+}
+
+// WithModel sets the model label recorded in results. The server decides
+// which model is actually loaded.
+func WithModel(model string) ProviderOption {
+	return func(p *Provider) {
+		if model != "" {
+			p.model = model
+		}
+	}
+}
+
+// WithLanguage sets the language hint sent with each request
+func WithLanguage(language string) ProviderOption {
+	return func(p *Provider) {
+		if language != "auto" {
+			p.language = language
+		}
+	}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return "whispercpp"
+}
+
+// Models returns the model used for each request
+func (p *Provider) Models() []string {
+	return []string{p.model}
+}
+
+// Diarizes reports that the server labels speakers when asked to. It
+// tells stereo channels apart, so speakers are only labeled in recordings
+// with each speaker on their own channel, e.g. calls.
+func (p *Provider) Diarizes() bool {
+	return true
+}
+
+// IsLocal reports whether the server runs on a loopback or private address
+func (p *Provider) IsLocal() bool {
+	return providers.IsLocalURL(p.baseURL)
+}
+
+// Transcribe transcribes audio using the whisper.cpp server
+func (p *Provider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	// The audio is streamed from the chunk file rather than read into memory
+	audio, err := providers.NewAudioSource(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &providers.AudioChunk{
+		Format:   req.AudioFormat,
+		MimeType: req.MimeType,
+	}
+
+	return p.transcribe(ctx, chunk, audio, req.Prompt, req.Options)
+}
+
+// TranscribeChunk transcribes a specific audio chunk
+func (p *Provider) TranscribeChunk(ctx context.Context, chunk *providers.AudioChunk, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	audio, err := providers.NewAudioSource(bytes.NewReader(chunk.Data))
+	if err != nil {
+		return nil, err
+	}
+	return p.transcribe(ctx, chunk, audio, prompt, options)
+}
+
+// transcribe sends the chunk's audio from audio, retrying transient failures
+func (p *Provider) transcribe(ctx context.Context, chunk *providers.AudioChunk, audio *providers.AudioSource, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	if audio.Size() == 0 {
+		return nil, fmt.Errorf("empty audio data")
+	}
+
+	var resp *InferenceResponse
+	err := providers.NewRetryPolicy(p.retries).Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = p.makeRequest(ctx, chunk, audio, prompt, options)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make inference request: %w", err)
+	}
+
+	defer providers.TimeParse(ctx, time.Now())
+	return p.parseResponse(resp, chunk)
+}
+
+// makeRequest sends a multipart request to the server's /inference
+// endpoint, streaming the audio part
+func (p *Provider) makeRequest(ctx context.Context, chunk *providers.AudioChunk, audio *providers.AudioSource, prompt string, options providers.TranscriptionOptions) (*InferenceResponse, error) {
+	fields := map[string]string{
+		"response_format": "verbose_json",
+		"temperature":     fmt.Sprintf("%g", options.Temperature),
+	}
+	if prompt != "" {
+		fields["prompt"] = prompt
+	}
+	if p.language != "" {
+		fields["language"] = p.language
+	}
+	if options.Diarize {
+		fields["diarize"] = "true"
+	}
+	form, err := providers.NewAudioForm(fields, "file", chunkFilename(chunk), mimeTypes.MIME(chunk.Format, chunk.MimeType), audio)
+	if err != nil {
+		return nil, err
+	}
+
+	url := p.baseURL + "/inference"
+	logger.Debug().
+		Str("component", "whispercpp-provider").
+		Str("url", url).
+		Int64("request_size", form.Size()).
+		Msg("Sending request to whisper.cpp server")
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, form.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.ContentLength = form.Size()
+	httpReq.Header.Set("Content-Type", form.ContentType())
+
+	httpResp, err := p.httpClient.Do(providers.TraceRequest(httpReq))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+
+	respData, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, providers.NewHTTPError(httpResp, string(respData))
+	}
+
+	defer providers.TimeParse(ctx, time.Now())
+	var inferenceResp InferenceResponse
+	if err := json.Unmarshal(respData, &inferenceResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// whisper.cpp reports some failures with a 200 status and an error field
+	if inferenceResp.Error != "" {
+		return nil, fmt.Errorf("server error: %s", inferenceResp.Error)
+	}
+
+	return &inferenceResp, nil
+}
+
+// parseResponse converts the server response into a TranscriptionResult
+func (p *Provider) parseResponse(resp *InferenceResponse, chunk *providers.AudioChunk) (*providers.TranscriptionResult, error) {
+	result := &providers.TranscriptionResult{
+		ChunkID:  chunk.ChunkID,
+		Text:     strings.TrimSpace(resp.Text),
+		Language: resp.Language,
+		Duration: secondsToDuration(resp.Duration),
+		Metadata: map[string]interface{}{
+			"provider": "whispercpp",
+			"model":    p.model,
+		},
+	}
+
+	if result.Text == "" {
+		return nil, fmt.Errorf("empty transcription result")
+	}
+
+	for _, seg := range resp.Segments {
+		speaker, text := segmentSpeaker(seg)
+		if text == "" {
+			continue
+		}
+		result.Segments = append(result.Segments, providers.TranscriptionSegment{
+			Text:       text,
+			Start:      secondsToDuration(seg.Start),
+			End:        secondsToDuration(seg.End),
+			SpeakerID:  speaker,
+			Confidence: float32(1 - seg.NoSpeechProb),
+			Language:   resp.Language,
+		})
+	}
+
+	return result, nil
+}
+
+// ValidateConfig validates the provider configuration
+func (p *Provider) ValidateConfig() error {
+	if p.baseURL == "" {
+		return fmt.Errorf("server URL is required")
+	}
+	return nil
+}
+
+// SupportedFormats returns the list of supported audio formats
+func (p *Provider) SupportedFormats() []string {
+	return mediatype.MIMETypes("wav", "mp3", "flac", "ogg")
+}
+
+// segmentSpeaker returns the speaker a diarizing server gave a segment,
+// or "" if it gave none or could not tell, and the text without its
+// speaker mark
+func segmentSpeaker(seg Segment) (string, string) {
+	text := strings.TrimSpace(seg.Text)
+	speaker := ""
+	if match := speakerPrefix.FindStringSubmatch(text); match != nil {
+		speaker, text = strings.TrimSpace(match[1]), strings.TrimSpace(text[len(match[0]):])
+	}
+	if len(seg.Speaker) > 0 {
+		var label string
+		var number float64
+		switch {
+		case json.Unmarshal(seg.Speaker, &label) == nil:
+			speaker = strings.TrimSpace(label)
+		case json.Unmarshal(seg.Speaker, &number) == nil:
+			speaker = strconv.FormatFloat(number, 'f', -1, 64)
+		}
+	}
+	if speaker == "?" {
+		speaker = ""
+	}
+	return speaker, text
+}
+
+// chunkFilename returns a filename whose extension lets the server detect the container
+func chunkFilename(chunk *providers.AudioChunk) string {
+	ext := chunk.Format
+	if ext == "" {
+		ext = "mp3"
+	}
+	return fmt.Sprintf("chunk_%03d.%s", chunk.ChunkID, ext)
+}
+
+// secondsToDuration converts fractional seconds to a time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
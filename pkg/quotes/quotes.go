@@ -0,0 +1,153 @@
+// Package quotes finds exact, time-coded quotes in a transcript that match
+// a query, by keyword or by meaning using embeddings.
+package quotes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// DefaultMinScore is the lowest similarity a semantic match needs
+const DefaultMinScore = 0.5
+
+// Quote is a segment of the transcript, quoted word for word
+type Quote struct {
+	Text    string        `json:"text"`
+	Speaker string        `json:"speaker,omitempty"`
+	Start   time.Duration `json:"start"`
+	End     time.Duration `json:"end"`
+	Score   float64       `json:"score"` // 1 for keyword matches, cosine similarity for semantic ones
+}
+
+// Keyword returns the segments containing a word starting with every word
+// of query, ignoring case and punctuation, in transcript order. A limit of 0 returns all.
+func Keyword(result *transcriber.TranscribeResult, query string, limit int) ([]Quote, error) {
+	terms := words(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	var quotes []Quote
+	for _, segment := range result.Segments {
+		text := " " + strings.Join(words(segment.Text), " ")
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(text, " "+term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			quotes = append(quotes, newQuote(segment, 1))
+		}
+		if limit > 0 && len(quotes) == limit {
+			break
+		}
+	}
+	return quotes, nil
+}
+
+// Semantic returns the segments closest in meaning to query, compared by
+// the cosine similarity of their embeddings, best first. Segments scoring
+// below minScore are left out; a limit of 0 returns all.
+func Semantic(ctx context.Context, provider providers.LLMProvider, result *transcriber.TranscribeResult, query string, limit int, minScore float64) ([]Quote, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+
+	var segments []providers.TranscriptionSegment
+	texts := []string{query}
+	for _, segment := range result.Segments {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			segments = append(segments, segment)
+			texts = append(texts, text)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := providers.Embed(ctx, provider, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	var quotes []Quote
+	for i, segment := range segments {
+		if score := cosine(vectors[0], vectors[i+1]); score >= minScore {
+			quotes = append(quotes, newQuote(segment, score))
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Score > quotes[j].Score })
+	if limit > 0 && len(quotes) > limit {
+		quotes = quotes[:limit]
+	}
+	return quotes, nil
+}
+
+// Cite formats the quote for a report, e.g.
+// "We cut the budget." — Alice, meeting.mp3 [00:12:30]
+func (q Quote) Cite(source string) string {
+	var cite strings.Builder
+	cite.WriteString("“" + q.Text + "” — ")
+	if q.Speaker != "" {
+		cite.WriteString(q.Speaker + ", ")
+	}
+	if source != "" {
+		cite.WriteString(source + " ")
+	}
+	cite.WriteString("[" + formatTimestamp(q.Start) + "]")
+	return cite.String()
+}
+
+func newQuote(segment providers.TranscriptionSegment, score float64) Quote {
+	return Quote{
+		Text:    strings.TrimSpace(segment.Text),
+		Speaker: segment.SpeakerID,
+		Start:   segment.Start,
+		End:     segment.End,
+		Score:   score,
+	}
+}
+
+// words returns the lowercase words of s
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// cosine returns the cosine similarity of two vectors, 0 if either is empty
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// formatTimestamp formats a duration as HH:MM:SS
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
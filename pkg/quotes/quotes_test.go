@@ -0,0 +1,110 @@
+package quotes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// embedProvider embeds texts by looking them up
+type embedProvider struct {
+	providers.LLMProvider
+	vectors map[string][]float32
+}
+
+func (p *embedProvider) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.vectors[text]
+	}
+	return vectors, nil
+}
+
+func testResult() *transcriber.TranscribeResult {
+	return &transcriber.TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Welcome, everyone.", Start: 0, End: 2 * time.Second, SpeakerID: "Alice"},
+			{Text: " The Budget for Q3 is tight. ", Start: 750 * time.Second, End: 755 * time.Second, SpeakerID: "Bob"},
+			{Text: "We need to cut spending.", Start: 760 * time.Second, End: 763 * time.Second, SpeakerID: "Alice"},
+			{Text: "Budgets are due Friday.", Start: 800 * time.Second, End: 803 * time.Second},
+		},
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	quotes, err := Keyword(testResult(), "budget", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[0].Text != "The Budget for Q3 is tight." || quotes[1].Start != 800*time.Second {
+		t.Fatalf("Keyword() = %+v", quotes)
+	}
+
+	quotes, _ = Keyword(testResult(), "budget, Q3", 0)
+	if len(quotes) != 1 || quotes[0].Speaker != "Bob" {
+		t.Errorf("Keyword(budget Q3) = %+v, want Bob's segment", quotes)
+	}
+
+	quotes, _ = Keyword(testResult(), "get", 0)
+	if len(quotes) != 0 {
+		t.Errorf("Keyword(get) = %+v, want words to match from their start", quotes)
+	}
+
+	if _, err := Keyword(testResult(), " ,", 0); err == nil {
+		t.Error("Keyword() with an empty query should fail")
+	}
+}
+
+func TestSemantic(t *testing.T) {
+	provider := &embedProvider{vectors: map[string][]float32{
+		"money problems":              {1, 0},
+		"Welcome, everyone.":          {0, 1},
+		"The Budget for Q3 is tight.": {0.9, 0.1},
+		"We need to cut spending.":    {1, 0.05},
+		"Budgets are due Friday.":     {0.5, 0.8},
+	}}
+
+	quotes, err := Semantic(context.Background(), provider, testResult(), "money problems", 0, DefaultMinScore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 3 {
+		t.Fatalf("Semantic() = %+v, want 3 quotes", quotes)
+	}
+	if quotes[0].Text != "We need to cut spending." || quotes[1].Speaker != "Bob" {
+		t.Errorf("Semantic() = %+v, want best match first", quotes)
+	}
+
+	quotes, _ = Semantic(context.Background(), provider, testResult(), "money problems", 1, DefaultMinScore)
+	if len(quotes) != 1 {
+		t.Errorf("Semantic() with limit 1 = %+v", quotes)
+	}
+}
+
+func TestSemanticUnsupported(t *testing.T) {
+	provider := &audioOnlyProvider{}
+	if _, err := Semantic(context.Background(), provider, testResult(), "budget", 0, DefaultMinScore); err == nil || !strings.Contains(err.Error(), "embeddings") {
+		t.Errorf("Semantic() error = %v, want unsupported embeddings", err)
+	}
+}
+
+type audioOnlyProvider struct {
+	providers.LLMProvider
+}
+
+func (p *audioOnlyProvider) Name() string { return "audio-only" }
+
+func TestCite(t *testing.T) {
+	quote := Quote{Text: "We need to cut spending.", Speaker: "Alice", Start: 760 * time.Second}
+	if got, want := quote.Cite("meeting.mp3"), "“We need to cut spending.” — Alice, meeting.mp3 [00:12:40]"; got != want {
+		t.Errorf("Cite() = %q, want %q", got, want)
+	}
+	quote.Speaker = ""
+	if got, want := quote.Cite(""), "“We need to cut spending.” — [00:12:40]"; got != want {
+		t.Errorf("Cite() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Package rules routes files by their tags, taken from operator notes and
+// directory names: e.g. files tagged "legal" go to /secure/transcripts and
+// must only be sent to a local provider
+package rules
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// Rule applies its actions to files that have all of its tags
+type Rule struct {
+	Name string
+
+	// Tags a file must all have; each may be a glob such as "client-*".
+	// No tags matches every file.
+	Tags []string
+
+	// Directory the transcript is written to instead of the default
+	OutputDir string
+
+	// Transcriber for the file, e.g. with a local provider (nil keeps the
+	// file's)
+	Transcriber transcriber.Transcriber
+
+	// Refuse to transcribe the file unless its provider is local
+	LocalOnly bool
+
+	// Evaluate no further rules after this one matched
+	Stop bool
+}
+
+// Decision is the outcome of evaluating the rules for a file. Rules are
+// evaluated in order and later matching rules override the output
+// directory and transcriber of earlier ones; LocalOnly stays once set.
+type Decision struct {
+	Matched     []string // Names of the matching rules
+	OutputDir   string
+	Transcriber transcriber.Transcriber
+	LocalOnly   bool
+}
+
+// Evaluate returns the decision of the rules for a file with tags
+func Evaluate(rules []Rule, tags []string) Decision {
+	var decision Decision
+	for i, rule := range rules {
+		if !rule.matches(tags) {
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		decision.Matched = append(decision.Matched, name)
+		if rule.OutputDir != "" {
+			decision.OutputDir = rule.OutputDir
+		}
+		if rule.Transcriber != nil {
+			decision.Transcriber = rule.Transcriber
+		}
+		decision.LocalOnly = decision.LocalOnly || rule.LocalOnly
+		if rule.Stop {
+			break
+		}
+	}
+	return decision
+}
+
+// CheckLocal returns an error if the decision requires a local provider
+// and trans does not use one. Transcribers that do not expose their
+// provider are refused.
+func (d Decision) CheckLocal(trans transcriber.Transcriber) error {
+	if !d.LocalOnly {
+		return nil
+	}
+	if d.Transcriber != nil {
+		trans = d.Transcriber
+	}
+	withProvider, ok := trans.(interface{ Provider() providers.LLMProvider })
+	if !ok {
+		return fmt.Errorf("rule %s requires a local provider, which cannot be checked", strings.Join(d.Matched, ", "))
+	}
+	if provider := withProvider.Provider(); !providers.IsLocal(provider) {
+		return fmt.Errorf("rule %s requires a local provider but %s is not local", strings.Join(d.Matched, ", "), provider.Name())
+	}
+	return nil
+}
+
+// matches reports whether every tag of the rule is among tags
+func (r Rule) matches(tags []string) bool {
+	for _, want := range r.Tags {
+		want = strings.ToLower(strings.TrimSpace(want))
+		found := false
+		for _, tag := range tags {
+			if ok, _ := path.Match(want, tag); ok || want == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Tags returns the tags of a file: the lowercase names of the directories
+// between baseDir and the file, or of all its directories when baseDir is
+// empty or does not contain it, and its notes, split at commas
+func Tags(filePath, baseDir string, notes []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && tag != "." && tag != ".." && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	dir := filepath.Dir(filePath)
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+	}
+	for _, name := range strings.Split(filepath.ToSlash(dir), "/") {
+		add(name)
+	}
+	for _, note := range notes {
+		for _, tag := range strings.Split(note, ",") {
+			add(tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/providers/groq"
+	"github.com/eternnoir/gollmscribe/pkg/providers/whispercpp"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func TestTags(t *testing.T) {
+	path := filepath.Join("inbox", "Legal", "acme", "call.mp3")
+	got := Tags(path, "inbox", []string{"Board meeting, confidential", "acme"})
+	want := []string{"legal", "acme", "board meeting", "confidential"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+	if got := Tags("call.mp3", "", nil); len(got) != 0 {
+		t.Errorf("Tags() of a file without directories = %v", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	local := transcriber.NewTranscriber(whispercpp.NewProvider(whispercpp.WithBaseURL("http://127.0.0.1:8080")), config.DefaultConfig())
+	cloud := transcriber.NewTranscriber(groq.NewProvider("key"), config.DefaultConfig())
+	rules := []Rule{
+		{Name: "clients", Tags: []string{"client-*"}, OutputDir: "/transcripts/clients"},
+		{Name: "legal", Tags: []string{"legal"}, OutputDir: "/secure/transcripts", Transcriber: local, LocalOnly: true, Stop: true},
+		{Name: "all", OutputDir: "/transcripts"},
+	}
+
+	decision := Evaluate(rules, []string{"legal", "client-acme"})
+	if !reflect.DeepEqual(decision.Matched, []string{"clients", "legal"}) || decision.OutputDir != "/secure/transcripts" {
+		t.Errorf("Evaluate(legal) = %+v, want clients then legal, stopping there", decision)
+	}
+	if err := decision.CheckLocal(cloud); err != nil {
+		t.Errorf("CheckLocal() with the rule's local provider = %v", err)
+	}
+
+	decision = Evaluate(rules, []string{"client-acme"})
+	if decision.OutputDir != "/transcripts" || decision.LocalOnly {
+		t.Errorf("Evaluate(client) = %+v, want the last matching output directory", decision)
+	}
+
+	// Local-only without a local provider is refused
+	decision = Evaluate([]Rule{{Name: "legal", Tags: []string{"legal"}, LocalOnly: true}}, []string{"legal"})
+	if err := decision.CheckLocal(cloud); err == nil {
+		t.Error("CheckLocal() allowed a cloud provider")
+	}
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// Store keeps transcription results addressed by the content hash of their
+// source media, so a file is never transcribed twice
+type Store struct {
+	dir    string
+	cipher *encryption.Cipher
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithCipher encrypts stored results with c
+func WithCipher(c *encryption.Cipher) Option {
+	return func(s *Store) {
+		s.cipher = c
+	}
+}
+
+// New creates a store rooted at dir
+func New(dir string, opts ...Option) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("store directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	s := &Store{dir: dir}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Lookup returns the stored result for a media file, if any
+func (s *Store) Lookup(mediaPath string) (*transcriber.TranscribeResult, bool, error) {
+	hash, err := HashFile(mediaPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to hash media file: %w", err)
+	}
+	return s.LookupHash(hash)
+}
+
+// LookupHash returns the stored result for a content hash, if any
+func (s *Store) LookupHash(hash string) (*transcriber.TranscribeResult, bool, error) {
+	path := s.pathFor(hash)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+
+	result, err := transcriber.LoadEncryptedResult(path, s.cipher)
+	if err != nil {
+		return nil, false, err
+	}
+
+	logger.WithComponent("store").Debug().
+		Str("hash", hash).
+		Str("path", path).
+		Msg("Found stored transcript")
+	return result, true, nil
+}
+
+// Put stores the result for a media file
+func (s *Store) Put(mediaPath string, result *transcriber.TranscribeResult) error {
+	hash, err := HashFile(mediaPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash media file: %w", err)
+	}
+	return s.PutHash(hash, result)
+}
+
+// PutHash stores the result under a content hash
+func (s *Store) PutHash(hash string, result *transcriber.TranscribeResult) error {
+	data, err := result.ToJSON(true)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	data, err = s.cipher.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt result: %w", err)
+	}
+
+	// Write atomically so concurrent readers never see a partial file
+	path := s.pathFor(hash)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write stored result: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to store result: %w", err)
+	}
+	return nil
+}
+
+// pathFor returns the file path for a hash
+func (s *Store) pathFor(hash string) string {
+	return filepath.Join(s.dir, hash+".json")
+}
+
+// HashFile calculates the SHA256 content hash used to address media files.
+// It is the same hash chunk keys are derived from; see audio.HashFile.
+func HashFile(filePath string) (string, error) {
+	return audio.HashFile(filePath)
+}
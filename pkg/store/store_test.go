@@ -0,0 +1,104 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func TestStorePutLookup(t *testing.T) {
+	dir := t.TempDir()
+	media := filepath.Join(dir, "talk.mp3")
+	if err := os.WriteFile(media, []byte("fake audio content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, found, err := s.Lookup(media); err != nil || found {
+		t.Fatalf("Lookup() on empty store = found %v, err %v", found, err)
+	}
+
+	if err := s.Put(media, &transcriber.TranscribeResult{Text: "hello", Provider: "gemini"}); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+
+	// A copy with identical content must resolve to the same transcript
+	copyPath := filepath.Join(dir, "renamed.mp3")
+	if err := os.WriteFile(copyPath, []byte("fake audio content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, found, err := s.Lookup(copyPath)
+	if err != nil || !found {
+		t.Fatalf("Lookup() = found %v, err %v", found, err)
+	}
+	if result.Text != "hello" {
+		t.Errorf("Text = %q, want hello", result.Text)
+	}
+}
+
+func TestHashFileDiffersBySize(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	_ = os.WriteFile(a, []byte("abc"), 0o644)
+	_ = os.WriteFile(b, []byte("abcd"), 0o644)
+
+	hashA, err := HashFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := HashFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashA == hashB {
+		t.Error("different files should hash differently")
+	}
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := encryption.GenerateKey()
+	raw, _ := encryption.ParseKey(key)
+	cipher, err := encryption.New(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(dir, WithCipher(cipher))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PutHash("abc", &transcriber.TranscribeResult{Text: "board meeting"}); err != nil {
+		t.Fatalf("PutHash() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "abc.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encryption.IsEncrypted(data) || strings.Contains(string(data), "board meeting") {
+		t.Error("stored result is not encrypted")
+	}
+
+	result, found, err := s.LookupHash("abc")
+	if err != nil || !found || result.Text != "board meeting" {
+		t.Errorf("LookupHash() = %v, %v, %v", result, found, err)
+	}
+
+	// Without the key the transcript cannot be read
+	plain, _ := New(dir)
+	if _, _, err := plain.LookupHash("abc"); !errors.Is(err, encryption.ErrKeyRequired) {
+		t.Errorf("LookupHash() without key = %v, want ErrKeyRequired", err)
+	}
+}
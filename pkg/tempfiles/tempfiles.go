@@ -0,0 +1,410 @@
+// Package tempfiles keeps the temporary files of transcription jobs, such
+// as converted audio and chunks, in one directory per job, so they count
+// against a disk quota and are removed even after a crash
+package tempfiles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/humanize"
+)
+
+// JobsDir is the directory in the temp directory holding the job
+// directories
+const JobsDir = "gollmscribe-jobs"
+
+// ownerFile records the process working in a job directory
+const ownerFile = "owner.json"
+
+// legacyPatterns match the temp files written to the temp directory
+// itself before job directories, e.g. by a crashed older version
+var legacyPatterns = []string{"gollmscribe_chunks_*", "gollmscribe_voices_*"}
+
+// legacyMinAge keeps legacy files an older version may still be using
+const legacyMinAge = time.Hour
+
+// ownerlessMinAge keeps job directories without an owner file, which may
+// have just been created
+const ownerlessMinAge = time.Minute
+
+// QuotaError is returned when writing a temp file would take the temp
+// directory over its quota
+type QuotaError struct {
+	Used      int64 // Bytes in use and reserved by running jobs
+	Requested int64
+	Quota     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("temp files would use %s, over the %s quota (%s already in use)",
+		humanize.Size(e.Used+e.Requested), humanize.Size(e.Quota), humanize.Size(e.Used))
+}
+
+// Manager creates job directories in a temp directory and keeps their
+// total size under a quota
+type Manager struct {
+	dir   string
+	quota int64 // Bytes, 0 for no quota
+
+	mu       sync.Mutex
+	reserved int64
+}
+
+// New creates a manager keeping job directories in dir/JobsDir, with a
+// quota in bytes on their total size; 0 means no quota
+func New(dir string, quota int64) *Manager {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return &Manager{dir: dir, quota: quota}
+}
+
+// Dir returns the directory holding the job directories
+func (m *Manager) Dir() string {
+	return filepath.Join(m.dir, JobsDir)
+}
+
+// Quota returns the quota in bytes, 0 for none
+func (m *Manager) Quota() int64 {
+	return m.quota
+}
+
+// StartJob creates the directory of a job, recording this process as
+// working in it until the job is closed
+func (m *Manager) StartJob(id string) (*Job, error) {
+	if err := os.MkdirAll(m.Dir(), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(m.Dir(), id+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job temp directory: %w", err)
+	}
+
+	job := &Job{manager: m, id: id, dir: dir}
+	if err := job.writeOwner(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return job, nil
+}
+
+// Usage returns the bytes used by all job directories, including those of
+// other processes and crashed jobs
+func (m *Manager) Usage() (int64, error) {
+	size, err := dirSize(m.Dir())
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return size, err
+}
+
+// reserve adds n bytes to the reservations if they fit in the quota
+func (m *Manager) reserve(n int64) error {
+	if m.quota <= 0 || n <= 0 {
+		return nil
+	}
+	used, err := m.Usage()
+	if err != nil {
+		return fmt.Errorf("failed to measure temp files: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if used+m.reserved+n > m.quota {
+		return &QuotaError{Used: used + m.reserved, Requested: n, Quota: m.quota}
+	}
+	m.reserved += n
+	return nil
+}
+
+// release returns n reserved bytes
+func (m *Manager) release(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reserved -= n
+}
+
+// JobInfo describes a job directory
+type JobInfo struct {
+	ID      string
+	Path    string
+	PID     int
+	Host    string
+	Started time.Time
+	Size    int64
+
+	// The process working in it is running, or on another host and so
+	// presumed running
+	Alive bool
+
+	// Kept with PreserveAudio; only removed by sweeping kept jobs
+	Kept bool
+
+	// Left in the temp directory itself by an older version
+	Legacy bool
+}
+
+// List returns the job directories, oldest first, followed by leftovers
+// of older versions
+func (m *Manager) List() ([]JobInfo, error) {
+	entries, err := os.ReadDir(m.Dir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var jobs []JobInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(m.Dir(), entry.Name())
+		info := JobInfo{ID: entry.Name(), Path: path}
+		if i := strings.LastIndex(info.ID, "-"); i > 0 {
+			info.ID = info.ID[:i]
+		}
+		if owner, err := readOwner(path); err == nil {
+			info.PID, info.Host, info.Started, info.Kept = owner.PID, owner.Host, owner.Started, owner.Kept
+			info.Alive = owner.alive()
+		} else if stat, err := entry.Info(); err == nil {
+			// A job's process may not have recorded itself yet
+			info.Started = stat.ModTime()
+			info.Alive = time.Since(stat.ModTime()) < ownerlessMinAge
+		}
+		info.Size, _ = dirSize(path)
+		jobs = append(jobs, info)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Started.Before(jobs[j].Started) })
+
+	for _, pattern := range legacyPatterns {
+		paths, _ := filepath.Glob(filepath.Join(m.dir, pattern))
+		for _, path := range paths {
+			stat, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			info := JobInfo{ID: filepath.Base(path), Path: path, Started: stat.ModTime(), Legacy: true}
+			info.Alive = time.Since(stat.ModTime()) < legacyMinAge
+			info.Size, _ = dirSize(path)
+			jobs = append(jobs, info)
+		}
+	}
+	return jobs, nil
+}
+
+// SweepOptions selects what Sweep removes
+type SweepOptions struct {
+	Kept   bool // Also remove jobs kept with PreserveAudio
+	DryRun bool // Only report what would be removed
+}
+
+// Sweep removes the directories of jobs whose process is gone, e.g.
+// after a crash or kill, and leftovers of older versions, and returns
+// them. Directories of running jobs are never removed.
+func (m *Manager) Sweep(options SweepOptions) ([]JobInfo, error) {
+	jobs, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var swept []JobInfo
+	var errs []string
+	for _, job := range jobs {
+		if job.Alive || (job.Kept && !options.Kept) {
+			continue
+		}
+		if !options.DryRun {
+			if err := os.RemoveAll(job.Path); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+		swept = append(swept, job)
+	}
+	if len(errs) > 0 {
+		return swept, fmt.Errorf("failed to remove %d temp director(ies): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return swept, nil
+}
+
+// Job is the temp directory of one job. A nil Job has no directory and
+// reserves nothing.
+type Job struct {
+	manager *Manager
+	id      string
+	dir     string
+
+	mu   sync.Mutex
+	kept bool
+}
+
+// ID returns the job's ID
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Dir returns the job's directory
+func (j *Job) Dir() string {
+	return j.dir
+}
+
+// Path returns the path of a temp file named name in the job's directory
+func (j *Job) Path(name string) string {
+	return filepath.Join(j.dir, name)
+}
+
+// Reserve checks that n more bytes of temp files fit in the quota, before
+// writing them, and holds them until release is called, once the files
+// are written and count towards the usage themselves
+func (j *Job) Reserve(n int64) (release func(), err error) {
+	if j == nil || n <= 0 {
+		return func() {}, nil
+	}
+	if err := j.manager.reserve(n); err != nil {
+		return nil, fmt.Errorf("job %s: %w", j.id, err)
+	}
+	if j.manager.quota <= 0 {
+		return func() {}, nil
+	}
+	var once sync.Once
+	return func() { once.Do(func() { j.manager.release(n) }) }, nil
+}
+
+// Keep leaves the job's directory in place when it is closed, e.g. for
+// PreserveAudio, until it is swept with SweepOptions.Kept
+func (j *Job) Keep() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.kept {
+		j.kept = true
+		_ = j.writeOwner()
+	}
+}
+
+// Close removes the job's directory, unless it is kept
+func (j *Job) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.kept {
+		return nil
+	}
+	return os.RemoveAll(j.dir)
+}
+
+// owner is the process working in a job directory
+type owner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+	Kept    bool      `json:"kept,omitempty"`
+}
+
+// writeOwner records this process in the job's directory
+func (j *Job) writeOwner() error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(owner{PID: os.Getpid(), Host: host, Started: time.Now(), Kept: j.kept})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(j.dir, ownerFile), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write job temp directory owner: %w", err)
+	}
+	return nil
+}
+
+// readOwner reads the owner of a job directory
+func readOwner(dir string) (*owner, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ownerFile))
+	if err != nil {
+		return nil, err
+	}
+	var o owner
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// alive reports whether the owner is still running. Processes on other
+// hosts, sharing the temp directory, cannot be checked and are presumed
+// running.
+func (o *owner) alive() bool {
+	if host, _ := os.Hostname(); o.Host != host {
+		return true
+	}
+	if o.PID == os.Getpid() {
+		return true
+	}
+	return processAlive(o.PID)
+}
+
+// processAlive reports whether a process with the pid is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// FindProcess only succeeds for running processes on Windows; on Unix
+	// signal 0 checks without signalling
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// dirSize returns the bytes used by the files in path
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+type jobContextKey struct{}
+
+// WithJob returns a context carrying the job, whose directory temp files
+// made on its behalf go to
+func WithJob(ctx context.Context, job *Job) context.Context {
+	return context.WithValue(ctx, jobContextKey{}, job)
+}
+
+// JobFromContext returns the job carried by ctx, or nil
+func JobFromContext(ctx context.Context) *Job {
+	job, _ := ctx.Value(jobContextKey{}).(*Job)
+	return job
+}
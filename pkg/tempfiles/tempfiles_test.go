@@ -0,0 +1,133 @@
+package tempfiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJob(t *testing.T) {
+	m := New(t.TempDir(), 0)
+	job, err := m.StartJob("3f9a2c1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(job.Dir()) != m.Dir() {
+		t.Errorf("job directory %s is not in %s", job.Dir(), m.Dir())
+	}
+	if err := os.WriteFile(job.Path("chunk_000.mp3"), make([]byte, 100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := m.Usage(); err != nil || used < 100 {
+		t.Errorf("Usage() = %d, %v, want at least 100", used, err)
+	}
+	if got := JobFromContext(WithJob(context.Background(), job)); got != job {
+		t.Errorf("JobFromContext() = %v, want the job", got)
+	}
+	if got := JobFromContext(context.Background()); got != nil {
+		t.Errorf("JobFromContext() without a job = %v", got)
+	}
+
+	if err := job.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(job.Dir()); !os.IsNotExist(err) {
+		t.Errorf("job directory left after Close: %v", err)
+	}
+
+	// Kept jobs stay until swept with Kept
+	kept, err := m.StartJob("kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept.Keep()
+	_ = kept.Close()
+	if _, err := os.Stat(kept.Dir()); err != nil {
+		t.Errorf("kept job directory removed: %v", err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	dir := t.TempDir()
+	m := New(dir, 0)
+	running, err := m.StartJob("running")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = running.Close() }()
+
+	// A job whose process is gone, and one of an older version
+	dead := filepath.Join(m.Dir(), "dead-123")
+	if err := os.MkdirAll(dead, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(owner{PID: 1 << 30, Host: host, Started: time.Now()})
+	if err := os.WriteFile(filepath.Join(dead, ownerFile), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(dir, "gollmscribe_chunks_1700000000")
+	if err := os.MkdirAll(legacy, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * legacyMinAge)
+	_ = os.Chtimes(legacy, old, old)
+
+	swept, err := m.Sweep(SweepOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(swept) != 2 || swept[0].ID != "dead" || !swept[1].Legacy {
+		t.Fatalf("Sweep() = %+v, want the dead job and the legacy chunks", swept)
+	}
+	if _, err := os.Stat(dead); err != nil {
+		t.Errorf("dry run removed %s", dead)
+	}
+
+	if _, err := m.Sweep(SweepOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{dead, legacy} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s not swept", path)
+		}
+	}
+	if _, err := os.Stat(running.Dir()); err != nil {
+		t.Errorf("running job swept: %v", err)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	m := New(t.TempDir(), 1000)
+	job, err := m.StartJob("quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = job.Close() }()
+
+	release, err := job.Reserve(600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var quotaErr *QuotaError
+	if _, err := job.Reserve(600); !errors.As(err, &quotaErr) {
+		t.Fatalf("Reserve() over the quota = %v, want a QuotaError", err)
+	}
+	release()
+	release()
+	if release, err := job.Reserve(600); err != nil {
+		t.Errorf("Reserve() after release = %v", err)
+	} else {
+		release()
+	}
+
+	// A nil job reserves nothing
+	var none *Job
+	if _, err := none.Reserve(1 << 40); err != nil {
+		t.Errorf("nil job Reserve() = %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package transcriber
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Overlap alignment limits
+const (
+	alignWindow   = 600 // Tokens compared at each end of a chunk boundary
+	minAlignRunes = 12  // Shortest matched run, in letters, accepted as overlap
+)
+
+// token is a word, or a single CJK character, of a transcript
+type token struct {
+	norm       string // Lowercase letters and digits only
+	start, end int    // Byte offsets in the text
+}
+
+// tokenize splits text into words and CJK characters, which are written
+// without spaces and so are tokens on their own
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{norm: normalizeToken(text[start:end]), start: start, end: end})
+			start = -1
+		}
+	}
+
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flush(i)
+			size := utf8.RuneLen(r)
+			tokens = append(tokens, token{norm: string(r), start: i, end: i + size})
+		case unicode.IsSpace(r):
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+// normalizeToken lowercases a word and drops punctuation, so the same
+// word transcribed with different punctuation or case still matches
+func normalizeToken(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+// isCJK reports whether r belongs to a script written without spaces
+func isCJK(r rune) bool {
+	if r < 0x0e00 { // Below Thai, the first of them; most text is
+		return false
+	}
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
+
+// alignOverlap finds the text transcribed twice at a chunk boundary: the
+// longest run of tokens shared by the end of previous and the start of
+// current. It returns the byte offset in previous where the run ends and
+// the offset in current just after it, so previous[:prevEnd] +
+// current[curStart:] keeps the run once. Runs shorter than minAlignRunes
+// letters are not trusted and ok is false.
+func alignOverlap(previous, current string) (prevEnd, curStart int, ok bool) {
+	prevTokens := tokenize(previous)
+	if len(prevTokens) > alignWindow {
+		prevTokens = prevTokens[len(prevTokens)-alignWindow:]
+	}
+	curTokens := tokenize(current)
+	if len(curTokens) > alignWindow {
+		curTokens = curTokens[:alignWindow]
+	}
+
+	// Tokens are compared by ID, as the comparisons are quadratic
+	ids := make(map[string]int, len(curTokens))
+	curIDs := make([]int, len(curTokens))
+	curRunes := make([]int, len(curTokens))
+	for j, tok := range curTokens {
+		id, ok := ids[tok.norm]
+		if !ok {
+			id = len(ids) + 1
+			ids[tok.norm] = id
+		}
+		curIDs[j], curRunes[j] = id, utf8.RuneCountInString(tok.norm)
+	}
+
+	// Longest common substring over tokens, measured in letters; the
+	// first of equally long runs wins so the result is deterministic
+	bestRunes, bestPrev, bestCur := 0, -1, -1
+	runes := make([]int, len(curTokens)+1) // Letters in the run ending at [i-1][j-1]
+	prevRow := make([]int, len(curTokens)+1)
+	for i := range prevTokens {
+		id := ids[prevTokens[i].norm] // 0 for tokens current lacks
+		for j := range curTokens {
+			runes[j+1] = 0
+			if id != 0 && prevTokens[i].norm != "" && id == curIDs[j] {
+				runes[j+1] = prevRow[j] + curRunes[j]
+				if runes[j+1] > bestRunes {
+					bestRunes, bestPrev, bestCur = runes[j+1], i, j
+				}
+			}
+		}
+		prevRow, runes = runes, prevRow
+	}
+
+	if bestRunes < minAlignRunes {
+		return 0, 0, false
+	}
+	return prevTokens[bestPrev].end, curTokens[bestCur].end, true
+}
@@ -0,0 +1,145 @@
+package transcriber
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchProgress aggregates the progress of transcribing several files one
+// after another: files done, audio done and remaining, and an ETA for the
+// whole batch. Files are started in turn with Start and the chunk
+// progress of the current file is fed in through Callback.
+type BatchProgress struct {
+	mu      sync.Mutex
+	files   []batchFile
+	current int
+	started time.Time
+	now     func() time.Time
+}
+
+// batchFile is the progress of one file of a batch
+type batchFile struct {
+	path      string
+	duration  time.Duration // 0 if the file could not be probed
+	completed int           // Chunks
+	total     int
+	done      bool
+	failed    bool
+}
+
+// BatchSnapshot is the progress of a batch at one point in time
+type BatchSnapshot struct {
+	Files       int
+	FilesDone   int // Including failed files
+	FilesFailed int
+	Current     string // Path of the file being transcribed, "" between files
+
+	AudioTotal time.Duration
+	AudioDone  time.Duration
+	Elapsed    time.Duration
+	ETA        time.Duration // 0 until some audio is done
+}
+
+// NewBatchProgress probes the duration of each file for the progress of
+// transcribing them in order. Files that cannot be probed count as done
+// without audio once finished. It kills ffprobe when ctx is done.
+func (t *TranscriberImpl) NewBatchProgress(ctx context.Context, paths []string) *BatchProgress {
+	files := make([]batchFile, len(paths))
+	for i, path := range paths {
+		files[i].path = path
+		if info, err := t.processor.GetAudioInfoContext(ctx, path); err == nil {
+			files[i].duration = info.Duration
+		}
+	}
+	return newBatchProgress(files, time.Now)
+}
+
+// newBatchProgress starts the progress of files, timed by now
+func newBatchProgress(files []batchFile, now func() time.Time) *BatchProgress {
+	return &BatchProgress{files: files, current: -1, started: now(), now: now}
+}
+
+// Start makes file i of the batch the current one
+func (b *BatchProgress) Start(i int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if i >= 0 && i < len(b.files) {
+		b.current = i
+	}
+}
+
+// Callback returns a progress callback recording the chunk progress of the
+// current file, then calling next if it is not nil
+func (b *BatchProgress) Callback(next ProgressCallback) ProgressCallback {
+	return func(completed, total int, currentChunk string) {
+		b.mu.Lock()
+		if b.current >= 0 {
+			b.files[b.current].completed, b.files[b.current].total = completed, total
+		}
+		b.mu.Unlock()
+		if next != nil {
+			next(completed, total, currentChunk)
+		}
+	}
+}
+
+// Finish marks the current file done, or failed if err is not nil
+func (b *BatchProgress) Finish(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.current < 0 {
+		return
+	}
+	b.files[b.current].done = true
+	b.files[b.current].failed = err != nil
+	b.current = -1
+}
+
+// Snapshot returns the progress of the batch so far. The audio of the
+// current file counts as done in proportion to its chunks; the ETA
+// assumes the remaining audio goes as fast as the audio done so far.
+func (b *BatchProgress) Snapshot() BatchSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BatchSnapshot{Files: len(b.files), Elapsed: b.now().Sub(b.started)}
+	for i, file := range b.files {
+		snapshot.AudioTotal += file.duration
+		switch {
+		case file.done:
+			snapshot.FilesDone++
+			snapshot.AudioDone += file.duration
+			if file.failed {
+				snapshot.FilesFailed++
+			}
+		case i == b.current:
+			snapshot.Current = file.path
+			if file.total > 0 {
+				snapshot.AudioDone += file.duration * time.Duration(file.completed) / time.Duration(file.total)
+			}
+		}
+	}
+	if snapshot.AudioDone > 0 && snapshot.AudioTotal > snapshot.AudioDone {
+		remaining := float64(snapshot.AudioTotal-snapshot.AudioDone) / float64(snapshot.AudioDone)
+		snapshot.ETA = time.Duration(float64(snapshot.Elapsed) * remaining)
+	}
+	return snapshot
+}
+
+// Fraction returns how much of the batch's audio is done, from 0 to 1. It
+// counts files instead when no durations are known.
+func (s BatchSnapshot) Fraction() float64 {
+	if s.AudioTotal > 0 {
+		return float64(s.AudioDone) / float64(s.AudioTotal)
+	}
+	if s.Files > 0 {
+		return float64(s.FilesDone) / float64(s.Files)
+	}
+	return 0
+}
+
+// AudioRemaining returns the audio still to be transcribed
+func (s BatchSnapshot) AudioRemaining() time.Duration {
+	return s.AudioTotal - s.AudioDone
+}
@@ -0,0 +1,48 @@
+package transcriber
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchProgress(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	batch := newBatchProgress([]batchFile{
+		{path: "a.mp3", duration: 30 * time.Minute},
+		{path: "b.mp3", duration: 60 * time.Minute},
+		{path: "broken.mp3"},
+	}, func() time.Time { return now })
+
+	if s := batch.Snapshot(); s.Files != 3 || s.AudioTotal != 90*time.Minute || s.ETA != 0 {
+		t.Fatalf("initial snapshot = %+v", s)
+	}
+
+	// Half of a.mp3 in 5 minutes
+	batch.Start(0)
+	callbackCalls := 0
+	callback := batch.Callback(func(int, int, string) { callbackCalls++ })
+	callback(2, 4, "chunk_002")
+	now = now.Add(5 * time.Minute)
+	s := batch.Snapshot()
+	if s.Current != "a.mp3" || s.AudioDone != 15*time.Minute || callbackCalls != 1 {
+		t.Fatalf("snapshot during a.mp3 = %+v", s)
+	}
+	if want := 25 * time.Minute; s.ETA != want {
+		t.Errorf("ETA = %v, want %v for 75 minutes left at 3x realtime", s.ETA, want)
+	}
+
+	batch.Finish(nil)
+	batch.Start(2)
+	batch.Finish(errors.New("unreadable"))
+	s = batch.Snapshot()
+	if s.FilesDone != 2 || s.FilesFailed != 1 || s.Current != "" || s.AudioDone != 30*time.Minute {
+		t.Errorf("snapshot after two files = %+v", s)
+	}
+	if got := s.Fraction(); got < 0.33 || got > 0.34 {
+		t.Errorf("Fraction() = %v, want a third", got)
+	}
+	if s.AudioRemaining() != time.Hour {
+		t.Errorf("AudioRemaining() = %v, want 1h", s.AudioRemaining())
+	}
+}
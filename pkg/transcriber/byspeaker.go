@@ -0,0 +1,106 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// unlabeledSpeaker names the file of segments without a speaker
+const unlabeledSpeaker = "unlabeled"
+
+// SpeakerTranscript is everything one speaker said
+type SpeakerTranscript struct {
+	Speaker  string // Empty for segments without a speaker
+	Segments []providers.TranscriptionSegment
+}
+
+// SplitBySpeaker groups the segments by speaker, in order of first
+// appearance. It returns nil for results without segments.
+func (r *TranscribeResult) SplitBySpeaker() []SpeakerTranscript {
+	var transcripts []SpeakerTranscript
+	index := make(map[string]int)
+	for _, segment := range r.Segments {
+		if strings.TrimSpace(segment.Text) == "" {
+			continue
+		}
+		i, ok := index[segment.SpeakerID]
+		if !ok {
+			i = len(transcripts)
+			index[segment.SpeakerID] = i
+			transcripts = append(transcripts, SpeakerTranscript{Speaker: segment.SpeakerID})
+		}
+		transcripts[i].Segments = append(transcripts[i].Segments, segment)
+	}
+	return transcripts
+}
+
+// Format writes the speaker's segments as "[HH:MM:SS - HH:MM:SS] text"
+// lines under the speaker's name
+func (s SpeakerTranscript) Format() []byte {
+	var text strings.Builder
+	speaker := s.Speaker
+	if speaker == "" {
+		speaker = "Unlabeled"
+	}
+	text.WriteString(speaker + "\n\n")
+	for _, segment := range s.Segments {
+		fmt.Fprintf(&text, "[%s - %s] %s\n", formatClockTime(segment.Start), formatClockTime(segment.End), strings.TrimSpace(segment.Text))
+	}
+	return []byte(text.String())
+}
+
+// SpeakerPath returns the file for a speaker next to outputPath, e.g.
+// meeting.alice.txt for meeting.txt
+func SpeakerPath(outputPath, speaker string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, strings.TrimSpace(speaker))
+	slug = strings.Trim(slug, "-")
+	for strings.Contains(slug, "--") {
+		slug = strings.ReplaceAll(slug, "--", "-")
+	}
+	if slug == "" {
+		slug = unlabeledSpeaker
+	}
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "." + slug + ".txt"
+}
+
+// SaveSpeakerTranscripts writes one file per speaker next to outputPath,
+// encrypted with c, and returns the paths written. Results without
+// segments have no speakers to split.
+func SaveSpeakerTranscripts(result *TranscribeResult, outputPath string, c *encryption.Cipher) ([]string, error) {
+	transcripts := result.SplitBySpeaker()
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("result has no segments to split by speaker")
+	}
+
+	paths := make([]string, 0, len(transcripts))
+	written := make(map[string]bool)
+	for _, transcript := range transcripts {
+		path := SpeakerPath(outputPath, transcript.Speaker)
+		if written[path] {
+			return paths, fmt.Errorf("speaker %q would overwrite %s", transcript.Speaker, path)
+		}
+		if err := writeResultFile(transcript.Format(), path, "speaker", c); err != nil {
+			return paths, err
+		}
+		written[path] = true
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// formatClockTime formats a duration as HH:MM:SS
+func formatClockTime(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
@@ -0,0 +1,43 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestSaveSpeakerTranscripts(t *testing.T) {
+	result := &TranscribeResult{Segments: []providers.TranscriptionSegment{
+		{Text: "Please state your name.", SpeakerID: "Counsel", Start: 0, End: 2 * time.Second},
+		{Text: "Jane Doe.", SpeakerID: "Witness Jane", Start: 2 * time.Second, End: 3 * time.Second},
+		{Text: "Thank you.", SpeakerID: "Counsel", Start: 65 * time.Second, End: 66 * time.Second},
+		{Text: "(inaudible)", Start: 70 * time.Second, End: 71 * time.Second},
+	}}
+
+	output := filepath.Join(t.TempDir(), "deposition.txt")
+	paths, err := SaveSpeakerTranscripts(result, output, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"deposition.counsel.txt", "deposition.witness-jane.txt", "deposition.unlabeled.txt"}
+	if len(paths) != len(want) {
+		t.Fatalf("SaveSpeakerTranscripts() = %v, want %v", paths, want)
+	}
+	for i, path := range paths {
+		if filepath.Base(path) != want[i] {
+			t.Errorf("path %d = %s, want %s", i, filepath.Base(path), want[i])
+		}
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "Counsel\n\n[00:00:00 - 00:00:02] Please state your name.\n[00:01:05 - 00:01:06] Thank you.\n" {
+		t.Errorf("counsel transcript = %q", got)
+	}
+}
@@ -0,0 +1,265 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// DefaultHoldThreshold is the shortest silence counted as a hold
+const DefaultHoldThreshold = 15 * time.Second
+
+// Metadata keys set on results transcribed per channel
+const (
+	MetadataChannels    = "channels"     // []string, the speaker of each channel
+	MetadataCallMetrics = "call_metrics" // CallMetrics
+)
+
+// CallMetrics describes the talk and silence of a call transcribed per channel
+type CallMetrics struct {
+	TalkTime    map[string]time.Duration `json:"talk_time"` // Per speaker
+	Crosstalk   time.Duration            `json:"crosstalk"` // Speakers talking over each other
+	Silence     time.Duration            `json:"silence"`   // Nobody talking, including before and after the call
+	Holds       []Hold                   `json:"holds,omitempty"`
+	HoldTime    time.Duration            `json:"hold_time"`
+	LongestHold time.Duration            `json:"longest_hold"`
+}
+
+// Hold is a silence during a call of at least the hold threshold
+type Hold struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// span is a time range
+type span struct {
+	start, end time.Duration
+}
+
+// transcribeChannels splits a multichannel recording, transcribes every
+// channel as its own file and interleaves the segments by time, labeled
+// with Options.ChannelSpeakers
+func (t *TranscriberImpl) transcribeChannels(ctx context.Context, provider providers.LLMProvider, req *TranscribeRequest, callback ProgressCallback, onSegment SegmentCallback) (*TranscribeResult, []*checkpoint, error) {
+	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(req.FilePath))
+	startTime := time.Now()
+	speakers := req.Options.ChannelSpeakers
+
+	if err := t.processor.ValidateFileContext(ctx, req.FilePath); err != nil {
+		return nil, nil, fmt.Errorf("file validation failed: %w", err)
+	}
+	audioInfo, err := t.processor.GetAudioInfoContext(ctx, req.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get audio info: %w", err)
+	}
+	if audioInfo.Channels > 0 && audioInfo.Channels < len(speakers) {
+		return nil, nil, fmt.Errorf("%s has %d channels but %d channel speakers were given", filepath.Base(req.FilePath), audioInfo.Channels, len(speakers))
+	}
+
+	var results []*TranscribeResult
+	var checkpoints []*checkpoint
+	for i, speaker := range speakers {
+		channelPath := filepath.Join(t.scratchDir(ctx), fmt.Sprintf("channel_%d_%d.mp3", startTime.UnixNano(), i))
+		log.Info().Int("channel", i).Str("speaker", speaker).Msg("Extracting channel")
+		release, err := t.reserveTemp(ctx, audio.Encoding{Channels: 1}.MaxSize(audioInfo.Duration))
+		if err != nil {
+			return nil, nil, err
+		}
+		err = audio.ExtractChannel(ctx, req.FilePath, i, channelPath)
+		release()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to extract channel %d: %w", i, err)
+		}
+		if !req.Options.PreserveAudio {
+			defer func() { _ = os.Remove(channelPath) }()
+		}
+
+		// Each channel holds a single speaker; labels are set afterwards
+		channelReq := *req
+		channelReq.FilePath = channelPath
+		channelReq.OutputPath = ""
+		channelReq.Options.ChannelSpeakers = nil
+		channelReq.Options.SpeakerMap = nil
+		channelReq.Options.VoiceProfilesDir = ""
+		result, cp, err := t.transcribeSource(ctx, provider, &channelReq, callback, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("channel %d (%s): %w", i, speaker, err)
+		}
+		results = append(results, result)
+		checkpoints = append(checkpoints, cp)
+	}
+
+	finalResult := interleaveChannels(results, speakers)
+	for _, result := range results {
+		addStageTimings(finalResult, result.StageTimings())
+	}
+	finalResult.FilePath = req.FilePath
+	finalResult.Duration = audioInfo.Duration
+	finalResult.ProcessTime = time.Since(startTime)
+	finalResult.Provider = provider.Name()
+	RelabelSpeakers(finalResult, req.Options.SpeakerMap)
+
+	holdThreshold := req.Options.HoldThreshold
+	if holdThreshold <= 0 {
+		holdThreshold = DefaultHoldThreshold
+	}
+	finalResult.Metadata[MetadataCallMetrics] = ComputeCallMetrics(finalResult.Segments, finalResult.Duration, holdThreshold)
+
+	// A call is as trustworthy as its weakest channel
+	if quality := lowestQuality(results); quality != nil {
+		finalResult.Metadata[MetadataQuality] = quality
+	}
+
+	if onSegment != nil {
+		for _, segment := range finalResult.Segments {
+			onSegment(segment)
+		}
+	}
+	return finalResult, checkpoints, nil
+}
+
+// interleaveChannels combines the results of the channels of one
+// recording, labeling the segments of each with its speaker and ordering
+// them by start time
+func interleaveChannels(results []*TranscribeResult, speakers []string) *TranscribeResult {
+	combined := &TranscribeResult{
+		Metadata: map[string]interface{}{MetadataChannels: speakers},
+	}
+	var usage providers.Usage
+	var gaps []Gap
+	for i, result := range results {
+		segments := result.Segments
+		if len(segments) == 0 && strings.TrimSpace(result.Text) != "" {
+			segments = []providers.TranscriptionSegment{{Text: result.Text, Start: 0, End: result.Duration}}
+		}
+		for _, segment := range segments {
+			segment.SpeakerID = speakers[i]
+			combined.Segments = append(combined.Segments, segment)
+		}
+
+		combined.ChunkCount += result.ChunkCount
+		combined.Cost += result.Cost
+		if result.Usage != nil {
+			usage = usage.Add(*result.Usage)
+		}
+		if combined.Model == "" {
+			combined.Model = result.Model
+		}
+		if combined.Language == "" {
+			combined.Language = result.Language
+		}
+		if style, ok := result.Metadata[MetadataStyle]; ok {
+			combined.Metadata[MetadataStyle] = style
+		}
+		gaps = append(gaps, result.Gaps()...)
+	}
+
+	sort.SliceStable(combined.Segments, func(i, j int) bool {
+		return combined.Segments[i].Start < combined.Segments[j].Start
+	})
+	lines := make([]string, 0, len(combined.Segments))
+	for _, segment := range combined.Segments {
+		lines = append(lines, segment.SpeakerID+": "+strings.TrimSpace(segment.Text))
+	}
+	combined.Text = strings.Join(lines, "\n")
+
+	if !usage.IsZero() {
+		combined.Usage = &usage
+		usage.SetMetadata(combined.Metadata)
+	}
+	if len(gaps) > 0 {
+		combined.Metadata[MetadataPartial] = true
+		combined.Metadata[MetadataGaps] = gaps
+	}
+	return combined
+}
+
+// ComputeCallMetrics measures how long each speaker talked, how long they
+// talked over each other, and the silences of a call lasting duration.
+// Silences between speech of at least holdThreshold are holds.
+func ComputeCallMetrics(segments []providers.TranscriptionSegment, duration, holdThreshold time.Duration) CallMetrics {
+	metrics := CallMetrics{TalkTime: make(map[string]time.Duration)}
+
+	perSpeaker := make(map[string][]span)
+	var all []span
+	for _, segment := range segments {
+		if segment.End <= segment.Start {
+			continue
+		}
+		s := span{segment.Start, segment.End}
+		perSpeaker[segment.SpeakerID] = append(perSpeaker[segment.SpeakerID], s)
+		all = append(all, s)
+	}
+
+	// Crosstalk is where the talk of two or more speakers overlaps
+	type edge struct {
+		at    time.Duration
+		delta int
+	}
+	var edges []edge
+	for speaker, spans := range perSpeaker {
+		for _, s := range unionSpans(spans) {
+			metrics.TalkTime[speaker] += s.end - s.start
+			edges = append(edges, edge{s.start, 1}, edge{s.end, -1})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at != edges[j].at {
+			return edges[i].at < edges[j].at
+		}
+		return edges[i].delta < edges[j].delta
+	})
+	active := 0
+	for i, e := range edges {
+		if active >= 2 && i > 0 {
+			metrics.Crosstalk += e.at - edges[i-1].at
+		}
+		active += e.delta
+	}
+
+	talk := unionSpans(all)
+	var spoken time.Duration
+	for i, s := range talk {
+		spoken += s.end - s.start
+		if i == 0 {
+			continue
+		}
+		if gap := s.start - talk[i-1].end; gap >= holdThreshold {
+			metrics.Holds = append(metrics.Holds, Hold{Start: talk[i-1].end, End: s.start})
+			metrics.HoldTime += gap
+			if gap > metrics.LongestHold {
+				metrics.LongestHold = gap
+			}
+		}
+	}
+	if len(talk) > 0 && talk[len(talk)-1].end > duration {
+		duration = talk[len(talk)-1].end
+	}
+	metrics.Silence = duration - spoken
+	return metrics
+}
+
+// unionSpans merges overlapping spans, returning them sorted
+func unionSpans(spans []span) []span {
+	sorted := append([]span(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	var union []span
+	for _, s := range sorted {
+		if n := len(union); n > 0 && s.start <= union[n-1].end {
+			if s.end > union[n-1].end {
+				union[n-1].end = s.end
+			}
+			continue
+		}
+		union = append(union, s)
+	}
+	return union
+}
@@ -0,0 +1,68 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestInterleaveChannels(t *testing.T) {
+	agent := &TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Thanks for calling.", Start: 0, End: 2 * time.Second, SpeakerID: "Speaker 1"},
+			{Text: "Let me check.", Start: 6 * time.Second, End: 8 * time.Second},
+		},
+		ChunkCount: 1,
+		Usage:      &providers.Usage{PromptTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		Model:      "gemini-2.5-flash",
+	}
+	customer := &TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: " My order is late. ", Start: 3 * time.Second, End: 5 * time.Second},
+		},
+		ChunkCount: 1,
+		Usage:      &providers.Usage{PromptTokens: 20, OutputTokens: 5, TotalTokens: 25},
+	}
+
+	result := interleaveChannels([]*TranscribeResult{agent, customer}, []string{"Agent", "Customer"})
+
+	want := "Agent: Thanks for calling.\nCustomer: My order is late.\nAgent: Let me check."
+	if result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	if len(result.Segments) != 3 || result.Segments[1].SpeakerID != "Customer" || result.Segments[0].SpeakerID != "Agent" {
+		t.Errorf("Segments = %+v", result.Segments)
+	}
+	if result.ChunkCount != 2 || result.Usage == nil || result.Usage.TotalTokens != 40 || result.Model != "gemini-2.5-flash" {
+		t.Errorf("ChunkCount = %d, Usage = %+v, Model = %q", result.ChunkCount, result.Usage, result.Model)
+	}
+}
+
+func TestComputeCallMetrics(t *testing.T) {
+	segments := []providers.TranscriptionSegment{
+		{SpeakerID: "Agent", Start: 2 * time.Second, End: 10 * time.Second},
+		{SpeakerID: "Customer", Start: 8 * time.Second, End: 20 * time.Second},
+		{SpeakerID: "Agent", Start: 50 * time.Second, End: 60 * time.Second},
+		{SpeakerID: "Customer", Start: 65 * time.Second, End: 70 * time.Second},
+	}
+
+	metrics := ComputeCallMetrics(segments, 75*time.Second, 15*time.Second)
+
+	if metrics.TalkTime["Agent"] != 18*time.Second || metrics.TalkTime["Customer"] != 17*time.Second {
+		t.Errorf("TalkTime = %v", metrics.TalkTime)
+	}
+	if metrics.Crosstalk != 2*time.Second {
+		t.Errorf("Crosstalk = %v, want 2s", metrics.Crosstalk)
+	}
+	// Spoken: 2-20, 50-60, 65-70 = 33s of 75s
+	if metrics.Silence != 42*time.Second {
+		t.Errorf("Silence = %v, want 42s", metrics.Silence)
+	}
+	if len(metrics.Holds) != 1 || metrics.Holds[0] != (Hold{Start: 20 * time.Second, End: 50 * time.Second}) {
+		t.Errorf("Holds = %+v, want the 30s gap only", metrics.Holds)
+	}
+	if metrics.HoldTime != 30*time.Second || metrics.LongestHold != 30*time.Second {
+		t.Errorf("HoldTime = %v, LongestHold = %v", metrics.HoldTime, metrics.LongestHold)
+	}
+}
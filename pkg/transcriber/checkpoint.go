@@ -0,0 +1,175 @@
+package transcriber
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataResumed marks a chunk result restored from a checkpoint
+const MetadataResumed = "checkpoint_resumed"
+
+// checkpointVersion is the version of the checkpoint file layout
+const checkpointVersion = 2
+
+// checkpoint persists finished chunk results so a transcription that dies
+// part way can resume without re-sending completed chunks. A nil
+// checkpoint records nothing.
+type checkpoint struct {
+	path   string
+	cipher *encryption.Cipher
+	mu     sync.Mutex
+	data   checkpointData
+}
+
+// checkpointData is the on-disk checkpoint layout. Chunks are keyed by
+// their stable chunk key, so a file split differently on resume still
+// reuses every chunk covering the same span.
+type checkpointData struct {
+	Version int                                       `json:"version"`
+	Source  string                                    `json:"source"`
+	JobID   string                                    `json:"job_id,omitempty"`
+	Chunks  map[string]*providers.TranscriptionResult `json:"chunks"`
+}
+
+// checkpointKey identifies a transcription: the same source content
+// prompted the same way by the same provider
+func checkpointKey(sourceHash string, req *TranscribeRequest, provider providers.LLMProvider) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s",
+		sourceHash, provider.Name(), strings.Join(providers.Models(provider), ","), req.CustomPrompt)
+	if req.Options.LeadingContext {
+		// Chunk results differ from those transcribing the whole overlap
+		_, _ = hash.Write([]byte("\x00leading-context"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// openCheckpoint opens the checkpoint for a request in the temp directory.
+// Saved results are only loaded when the request asks to resume; otherwise
+// the transcription starts over and replaces the checkpoint.
+func (t *TranscriberImpl) openCheckpoint(req *TranscribeRequest, provider providers.LLMProvider, sourceHash string) *checkpoint {
+	log := logger.WithComponent("checkpoint").WithField("file", filepath.Base(req.FilePath)).WithField("job", req.JobID)
+
+	key := checkpointKey(sourceHash, req, provider)
+	cp := &checkpoint{
+		path:   filepath.Join(t.tempDir, "checkpoints", key+".json"),
+		cipher: t.cipher,
+		data: checkpointData{
+			Version: checkpointVersion,
+			Source:  req.FilePath,
+			JobID:   req.JobID,
+			Chunks:  make(map[string]*providers.TranscriptionResult),
+		},
+	}
+	if !req.Options.Resume {
+		return cp
+	}
+
+	if err := cp.load(); err != nil {
+		log.Warn().Err(err).Str("checkpoint", cp.path).Msg("Failed to load checkpoint, starting over")
+		cp.data.Chunks = make(map[string]*providers.TranscriptionResult)
+	} else if len(cp.data.Chunks) > 0 {
+		log.Info().Int("completed_chunks", len(cp.data.Chunks)).Msg("Resuming from checkpoint")
+	}
+	return cp
+}
+
+// load reads saved chunk results, if any
+func (c *checkpoint) load() error {
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	data, err = c.cipher.Decrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var saved checkpointData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if saved.Version != checkpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d", saved.Version)
+	}
+	if saved.Chunks != nil {
+		c.data.Chunks = saved.Chunks
+	}
+	return nil
+}
+
+// result returns the saved result for a chunk, marked as resumed
+func (c *checkpoint) result(chunk *audio.ChunkInfo) *providers.TranscriptionResult {
+	if c == nil || chunk.Key == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.data.Chunks[chunk.Key]
+	if result == nil {
+		return nil
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataResumed] = true
+	return result
+}
+
+// save records a finished chunk and rewrites the checkpoint file atomically
+func (c *checkpoint) save(chunk *audio.ChunkInfo, result *providers.TranscriptionResult) error {
+	if c == nil || chunk.Key == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data.Chunks[chunk.Key] = result
+	data, err := json.Marshal(c.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	data, err = c.cipher.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt checkpoint: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the checkpoint once the transcription has completed
+func (c *checkpoint) remove() {
+	if c == nil {
+		return
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		logger.WithComponent("checkpoint").Warn().Err(err).Str("checkpoint", c.path).Msg("Failed to remove checkpoint")
+	}
+}
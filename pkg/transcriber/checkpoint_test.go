@@ -0,0 +1,70 @@
+package transcriber
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+type namedProvider struct{ name string }
+
+func (p namedProvider) Name() string { return p.name }
+func (p namedProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	return nil, nil
+}
+func (p namedProvider) TranscribeChunk(ctx context.Context, chunk *providers.AudioChunk, prompt string, options providers.TranscriptionOptions) (*providers.TranscriptionResult, error) {
+	return nil, nil
+}
+func (p namedProvider) ValidateConfig() error      { return nil }
+func (p namedProvider) SupportedFormats() []string { return nil }
+
+func TestCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	tr := &TranscriberImpl{tempDir: dir}
+	provider := namedProvider{"gemini"}
+	req := &TranscribeRequest{FilePath: filepath.Join(dir, "talk.mp3"), CustomPrompt: "prompt"}
+	const source = "0123456789abcdef0123"
+
+	chunk := &audio.ChunkInfo{Index: 3, Key: audio.ChunkKey(source, 45*time.Minute, 60*time.Minute)}
+	cp := tr.openCheckpoint(req, provider, source)
+	if err := cp.save(chunk, &providers.TranscriptionResult{Text: "chunk four", ChunkID: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --resume the saved chunks are ignored
+	if r := tr.openCheckpoint(req, provider, source).result(chunk); r != nil {
+		t.Errorf("result() without resume = %+v, want nil", r)
+	}
+
+	req.Options.Resume = true
+	resumed := tr.openCheckpoint(req, provider, source).result(chunk)
+	if resumed == nil || resumed.Text != "chunk four" || resumed.Metadata[MetadataResumed] != true {
+		t.Fatalf("result() = %+v, want resumed chunk", resumed)
+	}
+
+	// The same span is found by key even when the file is split differently
+	resplit := &audio.ChunkInfo{Index: 1, Key: audio.ChunkKey(source, 45*time.Minute, 60*time.Minute)}
+	if r := tr.openCheckpoint(req, provider, source).result(resplit); r == nil {
+		t.Error("result() for a re-split chunk with the same span = nil, want resumed chunk")
+	}
+	shifted := &audio.ChunkInfo{Index: 3, Key: audio.ChunkKey(source, 44*time.Minute, 60*time.Minute)}
+	if r := tr.openCheckpoint(req, provider, source).result(shifted); r != nil {
+		t.Errorf("result() for a different span = %+v, want nil", r)
+	}
+
+	// A different prompt produces different chunks
+	other := *req
+	other.CustomPrompt = "another prompt"
+	if r := tr.openCheckpoint(&other, provider, source).result(chunk); r != nil {
+		t.Errorf("result() with another prompt = %+v, want nil", r)
+	}
+
+	cp.remove()
+	if r := tr.openCheckpoint(req, provider, source).result(chunk); r != nil {
+		t.Errorf("result() after remove = %+v, want nil", r)
+	}
+}
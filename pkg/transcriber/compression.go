@@ -0,0 +1,67 @@
+package transcriber
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CompressionSuffix is added to a format to write it gzip-compressed,
+// e.g. json.gz for meeting.json.gz. Word-level JSON of recordings hours
+// long reaches hundreds of MB and compresses about tenfold.
+const CompressionSuffix = ".gz"
+
+// ErrZstdUnsupported is returned for zstd-compressed results, which need
+// a decoder this build does not include
+var ErrZstdUnsupported = errors.New("zstd-compressed results are not supported, decompress with `zstd -d` or write json.gz instead")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// splitCompression splits a format such as json.gz into the format and
+// whether it is compressed
+func splitCompression(format string) (string, bool) {
+	if base, ok := strings.CutSuffix(format, CompressionSuffix); ok && base != "" {
+		return base, true
+	}
+	return format, false
+}
+
+// compress gzips content
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(content); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns data uncompressed if it is gzipped, and as is if it
+// is not compressed
+func decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+		defer func() { _ = zr.Close() }()
+		plain, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+		return plain, nil
+	case bytes.HasPrefix(data, zstdMagic):
+		return nil, ErrZstdUnsupported
+	}
+	return data, nil
+}
@@ -0,0 +1,66 @@
+package transcriber
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestCompressedJSON(t *testing.T) {
+	result := &TranscribeResult{
+		SchemaVersion: ResultSchemaVersion,
+		Text:          "Hello world.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello world.", Start: 0, End: 2 * time.Second},
+		},
+	}
+	dir := t.TempDir()
+	path := FormatPath(filepath.Join(dir, "talk.txt"), "json.gz")
+	if want := filepath.Join(dir, "talk.json.gz"); path != want {
+		t.Fatalf("FormatPath() = %q, want %q", path, want)
+	}
+
+	key := make([]byte, 32)
+	cipher, err := encryption.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*encryption.Cipher{nil, cipher} {
+		if err := SaveEncryptedResult(result, path, "json.gz", c); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadEncryptedResult(path, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Text != result.Text || len(loaded.Segments) != 1 {
+			t.Errorf("loaded %+v", loaded)
+		}
+	}
+	if _, ok := result.Metadata["saved_at"]; !ok {
+		t.Error("compressed JSON output was rendered without saved_at")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encryption.IsEncrypted(data) {
+		t.Error("output was compressed after encrypting")
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	plain := []byte(`{"text":"hi"}`)
+	if got, err := decompress(plain); err != nil || string(got) != string(plain) {
+		t.Errorf("decompress(plain) = %q, %v", got, err)
+	}
+	if _, err := decompress([]byte{0x28, 0xb5, 0x2f, 0xfd, 0}); !errors.Is(err, ErrZstdUnsupported) {
+		t.Errorf("decompress(zstd) error = %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// contextDuration returns the length of leading context in a chunk file,
+// which starts after any silence trimmed from the chunk
+func contextDuration(chunk *audio.ChunkInfo) time.Duration {
+	if chunk.Context <= chunk.TrimmedStart {
+		return 0
+	}
+	return chunk.Context - chunk.TrimmedStart
+}
+
+// contextPrompt tells the model not to transcribe the leading context of
+// a chunk, which starts offset into the audio sent (after any voice
+// reference clip)
+func contextPrompt(base string, chunk *audio.ChunkInfo, offset time.Duration) string {
+	context := contextDuration(chunk)
+	if context == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s\n\n"+
+		"The audio from %s to %s repeats the end of the previous part of the recording and is context only. "+
+		"Do not transcribe it; start the transcript at %s.",
+		strings.TrimSpace(base), formatTimestamp(offset), formatTimestamp(offset+context), formatTimestamp(offset+context))
+}
+
+// dropContext removes segments inside the leading context of a chunk from
+// a response with chunk-relative timestamps. A segment belongs to the
+// context when most of it is in there. The text is rebuilt from the
+// remaining segments when any were dropped; responses without segments
+// rely on the prompt alone.
+func dropContext(result *providers.TranscriptionResult, chunk *audio.ChunkInfo) {
+	context := contextDuration(chunk)
+	if context == 0 || len(result.Segments) == 0 {
+		return
+	}
+
+	segments := result.Segments[:0]
+	for _, segment := range result.Segments {
+		if segment.Start+(segment.End-segment.Start)/2 < context {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == len(result.Segments) {
+		return
+	}
+	result.Segments = segments
+	result.Text = segmentsText(segments)
+}
@@ -0,0 +1,80 @@
+package transcriber
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/cache"
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestContextPrompt(t *testing.T) {
+	chunk := &audio.ChunkInfo{Start: 29 * time.Minute, Context: time.Minute, TrimmedStart: 15 * time.Second}
+
+	prompt := contextPrompt("Transcribe.", chunk, 10*time.Second)
+	if !strings.HasPrefix(prompt, "Transcribe.\n\n") || !strings.Contains(prompt, "from 00:10 to 00:55") {
+		t.Errorf("prompt = %q", prompt)
+	}
+
+	if got := contextPrompt("Transcribe.", &audio.ChunkInfo{}, 0); got != "Transcribe." {
+		t.Errorf("prompt without context = %q", got)
+	}
+}
+
+func TestEstimateChunksFindsCachedContextChunks(t *testing.T) {
+	provider := &namedProvider{"gemini"}
+	tr := NewTranscriber(provider, &config.Config{})
+	responses, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.SetCache(responses)
+
+	// Chunks with leading context are cached under the prompt sent
+	chunk := &audio.ChunkInfo{Key: "chunk", Start: 29 * time.Minute, End: time.Hour, Duration: 31 * time.Minute, Context: time.Minute}
+	key, _ := tr.lookupChunkCache(provider, chunk, contextPrompt("Transcribe.", chunk, 0), false)
+	if err := responses.Put(key, &providers.TranscriptionResult{Text: "cached"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if estimate := tr.estimateChunks(provider, []*audio.ChunkInfo{chunk}, "Transcribe.", 0, false, nil); estimate.Tokens != 0 {
+		t.Errorf("estimated %d tokens for a cached chunk, want 0", estimate.Tokens)
+	}
+}
+
+func TestDropContext(t *testing.T) {
+	chunk := &audio.ChunkInfo{Start: 29 * time.Minute, Context: time.Minute}
+	result := &providers.TranscriptionResult{
+		Text: "old words. straddling words. new words.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "old words.", Start: 0, End: 50 * time.Second},
+			{Text: "straddling words.", Start: 58 * time.Second, End: 70 * time.Second},
+			{Text: "new words.", Start: 70 * time.Second, End: 80 * time.Second},
+		},
+	}
+
+	dropContext(result, chunk)
+
+	if len(result.Segments) != 2 || result.Segments[0].Text != "straddling words." {
+		t.Fatalf("segments = %+v", result.Segments)
+	}
+	if result.Text != "straddling words. new words." {
+		t.Errorf("Text = %q", result.Text)
+	}
+}
+
+func TestConcatMerger(t *testing.T) {
+	result, err := NewConcatMerger().MergeChunks([]*providers.TranscriptionResult{
+		{ChunkID: 1, Text: "second", Segments: []providers.TranscriptionSegment{seg("Speaker 1", 60, 90)}},
+		{ChunkID: 0, Text: "first ", Segments: []providers.TranscriptionSegment{seg("Speaker 1", 0, 60)}},
+	})
+	if err != nil {
+		t.Fatalf("MergeChunks() error = %v", err)
+	}
+	if result.Text != "first second" || len(result.Segments) != 2 || result.Duration != 90*time.Second {
+		t.Errorf("result = %q, %d segments, %v", result.Text, len(result.Segments), result.Duration)
+	}
+}
@@ -0,0 +1,58 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataDiarization records how speakers were labeled, set to
+// DiarizationNative when the provider labeled them itself
+const MetadataDiarization = "diarization"
+
+// DiarizationNative marks results labeled by the provider's own
+// diarization rather than by following the prompt
+const DiarizationNative = "native"
+
+// nativeDiarization reports whether a chunk's speakers are labeled by the
+// provider's own diarization: it was asked for, the provider supports it
+// and no voice profiles are used, which name speakers through the prompt
+func nativeDiarization(provider providers.LLMProvider, req *TranscribeRequest, voices *voiceProfiles) bool {
+	return req.Options.NativeDiarization && voices == nil && providers.Diarizes(provider)
+}
+
+// normalizeSpeakers turns the labels a provider's diarization gives
+// speakers, e.g. "0", "B" or "SPEAKER_01", into "Speaker N" in order of
+// first appearance, and writes the text as speaker lines like results
+// labeled through the prompt. Chunks are then reconciled like any other.
+func normalizeSpeakers(result *providers.TranscriptionResult) {
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataDiarization] = DiarizationNative
+
+	speakers := make(map[string]string)
+	for i := range result.Segments {
+		label := strings.TrimSpace(result.Segments[i].SpeakerID)
+		if label == "" {
+			continue
+		}
+		if _, ok := speakers[label]; !ok {
+			speakers[label] = fmt.Sprintf("Speaker %d", len(speakers)+1)
+		}
+		result.Segments[i].SpeakerID = speakers[label]
+	}
+	if len(speakers) == 0 {
+		return
+	}
+
+	lines := make([]string, len(result.Segments))
+	for i, segment := range result.Segments {
+		lines[i] = segment.Text
+		if segment.SpeakerID != "" {
+			lines[i] = segment.SpeakerID + ": " + segment.Text
+		}
+	}
+	result.Text = strings.Join(lines, "\n")
+}
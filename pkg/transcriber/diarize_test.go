@@ -0,0 +1,61 @@
+package transcriber
+
+import (
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// diarizingProvider labels speakers natively
+type diarizingProvider struct{ namedProvider }
+
+func (diarizingProvider) Diarizes() bool { return true }
+
+func TestNativeDiarization(t *testing.T) {
+	req := &TranscribeRequest{Options: TranscribeOptions{NativeDiarization: true}}
+	if !nativeDiarization(diarizingProvider{}, req, nil) {
+		t.Error("a diarizing provider is not used for native diarization")
+	}
+	if nativeDiarization(namedProvider{"gemini"}, req, nil) {
+		t.Error("a provider without diarization is used for native diarization")
+	}
+	if nativeDiarization(diarizingProvider{}, req, &voiceProfiles{}) {
+		t.Error("native diarization replaced voice profiles")
+	}
+	if nativeDiarization(diarizingProvider{}, &TranscribeRequest{}, nil) {
+		t.Error("native diarization used without being asked for")
+	}
+}
+
+func TestNormalizeSpeakers(t *testing.T) {
+	result := &providers.TranscriptionResult{
+		Text: "Hello. Hi. How are you? Fine.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello.", SpeakerID: "1"},
+			{Text: "Hi.", SpeakerID: "0"},
+			{Text: "How are you?", SpeakerID: "1"},
+			{Text: "Fine."},
+		},
+	}
+	normalizeSpeakers(result)
+
+	want := []string{"Speaker 1", "Speaker 2", "Speaker 1", ""}
+	for i, segment := range result.Segments {
+		if segment.SpeakerID != want[i] {
+			t.Errorf("segment %d speaker = %q, want %q", i, segment.SpeakerID, want[i])
+		}
+	}
+	if text := "Speaker 1: Hello.\nSpeaker 2: Hi.\nSpeaker 1: How are you?\nFine."; result.Text != text {
+		t.Errorf("Text = %q, want %q", result.Text, text)
+	}
+	if result.Metadata[MetadataDiarization] != DiarizationNative {
+		t.Errorf("metadata %s = %v, want %s", MetadataDiarization, result.Metadata[MetadataDiarization], DiarizationNative)
+	}
+
+	// Unlabeled results keep their text
+	plain := &providers.TranscriptionResult{Text: "Hello.", Segments: []providers.TranscriptionSegment{{Text: "Hello."}}}
+	normalizeSpeakers(plain)
+	if plain.Text != "Hello." {
+		t.Errorf("unlabeled Text = %q, want it unchanged", plain.Text)
+	}
+}
@@ -0,0 +1,206 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// Segment editing helpers. Each edit updates Segments and then rebuilds Text
+// from the segments, so both stay consistent for re-rendering.
+
+// ReplaceSegmentText replaces the text of a single segment
+func (r *TranscribeResult) ReplaceSegmentText(index int, text string) error {
+	if err := r.checkSegmentIndex(index); err != nil {
+		return err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("segment text cannot be empty")
+	}
+
+	r.Segments[index].Text = text
+	r.RebuildText()
+	return nil
+}
+
+// SplitSegment splits a segment in two at a byte offset into its text.
+// The split time is interpolated from the share of text before the offset.
+func (r *TranscribeResult) SplitSegment(index, offset int) error {
+	if err := r.checkSegmentIndex(index); err != nil {
+		return err
+	}
+
+	seg := r.Segments[index]
+	if offset <= 0 || offset >= len(seg.Text) || !utf8.RuneStart(seg.Text[offset]) {
+		return fmt.Errorf("invalid split offset %d for segment %d", offset, index)
+	}
+
+	firstText := strings.TrimSpace(seg.Text[:offset])
+	secondText := strings.TrimSpace(seg.Text[offset:])
+	if firstText == "" || secondText == "" {
+		return fmt.Errorf("split would produce an empty segment")
+	}
+
+	ratio := float64(utf8.RuneCountInString(seg.Text[:offset])) / float64(utf8.RuneCountInString(seg.Text))
+	splitAt := seg.Start + time.Duration(float64(seg.End-seg.Start)*ratio)
+
+	first := seg
+	first.Text = firstText
+	first.End = splitAt
+
+	second := seg
+	second.Text = secondText
+	second.Start = splitAt
+
+	segments := make([]providers.TranscriptionSegment, 0, len(r.Segments)+1)
+	segments = append(segments, r.Segments[:index]...)
+	segments = append(segments, first, second)
+	segments = append(segments, r.Segments[index+1:]...)
+	r.Segments = segments
+
+	r.RebuildText()
+	return nil
+}
+
+// MergeSegments merges the segments from first to last (inclusive) into one.
+// The speaker is kept only if all merged segments share it, and the merged
+// confidence is the lowest confidence among them.
+func (r *TranscribeResult) MergeSegments(first, last int) error {
+	if err := r.checkSegmentIndex(first); err != nil {
+		return err
+	}
+	if err := r.checkSegmentIndex(last); err != nil {
+		return err
+	}
+	if first >= last {
+		return fmt.Errorf("invalid merge range %d-%d", first, last)
+	}
+
+	merged := r.Segments[first]
+	texts := []string{merged.Text}
+	for _, seg := range r.Segments[first+1 : last+1] {
+		texts = append(texts, seg.Text)
+		if seg.End > merged.End {
+			merged.End = seg.End
+		}
+		if seg.SpeakerID != merged.SpeakerID {
+			merged.SpeakerID = ""
+		}
+		if seg.Confidence < merged.Confidence {
+			merged.Confidence = seg.Confidence
+		}
+	}
+	merged.Text = strings.Join(texts, " ")
+
+	segments := make([]providers.TranscriptionSegment, 0, len(r.Segments)-(last-first))
+	segments = append(segments, r.Segments[:first]...)
+	segments = append(segments, merged)
+	segments = append(segments, r.Segments[last+1:]...)
+	r.Segments = segments
+
+	r.RebuildText()
+	return nil
+}
+
+// ShiftTimestamps moves every segment and chapter by offset, clamping at zero
+func (r *TranscribeResult) ShiftTimestamps(offset time.Duration) {
+	for i := range r.Segments {
+		r.Segments[i].Start = clampDuration(r.Segments[i].Start + offset)
+		r.Segments[i].End = clampDuration(r.Segments[i].End + offset)
+	}
+	for i := range r.Chapters {
+		r.Chapters[i].Start = clampDuration(r.Chapters[i].Start + offset)
+		r.Chapters[i].End = clampDuration(r.Chapters[i].End + offset)
+	}
+}
+
+// RelabelSpeakers renames speakers, e.g. {"Speaker 1": "Alice"}, in the
+// segment SpeakerIDs and in "Label:" prefixes at the start of lines of
+// Text, so transcripts without segments are renamed too. Labels match
+// exactly or, failing that, ignoring case. It returns the number of
+// segments and lines renamed.
+func RelabelSpeakers(result *TranscribeResult, speakers map[string]string) int {
+	if result == nil || len(speakers) == 0 {
+		return 0
+	}
+	var renamed int
+	result.Text, renamed = relabel(result.Segments, result.Text, speakers)
+	return renamed
+}
+
+// relabel renames speakers in segments, in place, and in text
+func relabel(segments []providers.TranscriptionSegment, text string, speakers map[string]string) (string, int) {
+	renamed := 0
+	for i := range segments {
+		if name, ok := lookupSpeaker(speakers, segments[i].SpeakerID); ok {
+			segments[i].SpeakerID = name
+			renamed++
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		colon := strings.Index(line, ":")
+		if colon <= 0 || colon > maxSpeakerLabel {
+			continue
+		}
+		if name, ok := lookupSpeaker(speakers, line[:colon]); ok {
+			lines[i] = name + line[colon:]
+			renamed++
+		}
+	}
+	return strings.Join(lines, "\n"), renamed
+}
+
+// maxSpeakerLabel is the longest line prefix taken for a speaker label
+const maxSpeakerLabel = 60
+
+// lookupSpeaker returns the new name for a speaker label
+func lookupSpeaker(speakers map[string]string, label string) (string, bool) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return "", false
+	}
+	if name, ok := speakers[label]; ok {
+		return name, true
+	}
+	for from, name := range speakers {
+		if strings.EqualFold(from, label) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// RebuildText regenerates Text from the segment texts
+func (r *TranscribeResult) RebuildText() {
+	if len(r.Segments) == 0 {
+		return
+	}
+
+	texts := make([]string, len(r.Segments))
+	for i, seg := range r.Segments {
+		texts[i] = seg.Text
+	}
+	r.Text = strings.Join(texts, " ")
+}
+
+// checkSegmentIndex validates a segment index
+func (r *TranscribeResult) checkSegmentIndex(index int) error {
+	if index < 0 || index >= len(r.Segments) {
+		return fmt.Errorf("segment index %d out of range (have %d segments)", index, len(r.Segments))
+	}
+	return nil
+}
+
+func clampDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
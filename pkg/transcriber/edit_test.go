@@ -0,0 +1,130 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func newEditableResult() *TranscribeResult {
+	r := &TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello there.", Start: 0, End: 2 * time.Second, SpeakerID: "A", Confidence: 0.9},
+			{Text: "General Kenobi.", Start: 2 * time.Second, End: 4 * time.Second, SpeakerID: "B", Confidence: 0.8},
+			{Text: "You are a bold one.", Start: 4 * time.Second, End: 6 * time.Second, SpeakerID: "B", Confidence: 0.7},
+		},
+	}
+	r.RebuildText()
+	return r
+}
+
+func TestReplaceSegmentText(t *testing.T) {
+	r := newEditableResult()
+
+	if err := r.ReplaceSegmentText(1, "  General Grievous. "); err != nil {
+		t.Fatalf("ReplaceSegmentText() failed: %v", err)
+	}
+	if want := "Hello there. General Grievous. You are a bold one."; r.Text != want {
+		t.Errorf("Text = %q, want %q", r.Text, want)
+	}
+	if err := r.ReplaceSegmentText(5, "x"); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+	if err := r.ReplaceSegmentText(0, " "); err == nil {
+		t.Error("expected error for empty text")
+	}
+}
+
+func TestSplitSegment(t *testing.T) {
+	r := newEditableResult()
+
+	// Split "You are a bold one." after "You are"
+	if err := r.SplitSegment(2, 7); err != nil {
+		t.Fatalf("SplitSegment() failed: %v", err)
+	}
+	if len(r.Segments) != 4 {
+		t.Fatalf("segments = %d, want 4", len(r.Segments))
+	}
+	first, second := r.Segments[2], r.Segments[3]
+	if first.Text != "You are" || second.Text != "a bold one." {
+		t.Errorf("split texts = %q / %q", first.Text, second.Text)
+	}
+	if first.End != second.Start || first.End <= first.Start || second.End != 6*time.Second {
+		t.Errorf("split times = %v-%v / %v-%v", first.Start, first.End, second.Start, second.End)
+	}
+	if second.SpeakerID != "B" {
+		t.Errorf("speaker not preserved, got %q", second.SpeakerID)
+	}
+
+	if err := r.SplitSegment(0, 0); err == nil {
+		t.Error("expected error for zero offset")
+	}
+}
+
+func TestMergeSegments(t *testing.T) {
+	r := newEditableResult()
+
+	if err := r.MergeSegments(1, 2); err != nil {
+		t.Fatalf("MergeSegments() failed: %v", err)
+	}
+	if len(r.Segments) != 2 {
+		t.Fatalf("segments = %d, want 2", len(r.Segments))
+	}
+	merged := r.Segments[1]
+	if merged.Text != "General Kenobi. You are a bold one." {
+		t.Errorf("merged text = %q", merged.Text)
+	}
+	if merged.Start != 2*time.Second || merged.End != 6*time.Second {
+		t.Errorf("merged times = %v-%v", merged.Start, merged.End)
+	}
+	if merged.SpeakerID != "B" || merged.Confidence != 0.7 {
+		t.Errorf("merged speaker/confidence = %q/%v", merged.SpeakerID, merged.Confidence)
+	}
+
+	if err := r.MergeSegments(0, 1); err != nil {
+		t.Fatalf("MergeSegments() failed: %v", err)
+	}
+	if r.Segments[0].SpeakerID != "" {
+		t.Errorf("mixed speakers should be cleared, got %q", r.Segments[0].SpeakerID)
+	}
+	if err := r.MergeSegments(0, 0); err == nil {
+		t.Error("expected error for single-segment range")
+	}
+}
+
+func TestShiftTimestamps(t *testing.T) {
+	r := newEditableResult()
+
+	r.ShiftTimestamps(-3 * time.Second)
+	if r.Segments[0].Start != 0 || r.Segments[0].End != 0 {
+		t.Errorf("first segment should clamp to zero, got %v-%v", r.Segments[0].Start, r.Segments[0].End)
+	}
+	if r.Segments[2].Start != time.Second || r.Segments[2].End != 3*time.Second {
+		t.Errorf("last segment = %v-%v, want 1s-3s", r.Segments[2].Start, r.Segments[2].End)
+	}
+}
+
+func TestRelabelSpeakers(t *testing.T) {
+	r := newEditableResult()
+	r.Text = "A: Hello there.\nb: General Kenobi.\nC: Unmapped.\nNo label here"
+
+	renamed := RelabelSpeakers(r, map[string]string{"A": "Obi-Wan", "B": "Grievous"})
+	if renamed != 5 {
+		t.Errorf("renamed = %d, want 5", renamed)
+	}
+	for i, want := range []string{"Obi-Wan", "Grievous", "Grievous"} {
+		if r.Segments[i].SpeakerID != want {
+			t.Errorf("segment %d speaker = %q, want %q", i, r.Segments[i].SpeakerID, want)
+		}
+	}
+	if want := "Obi-Wan: Hello there.\nGrievous: General Kenobi.\nC: Unmapped.\nNo label here"; r.Text != want {
+		t.Errorf("Text = %q, want %q", r.Text, want)
+	}
+
+	// Swapping two speakers renames each once
+	RelabelSpeakers(r, map[string]string{"Obi-Wan": "Grievous", "Grievous": "Obi-Wan"})
+	if r.Segments[0].SpeakerID != "Grievous" || r.Segments[1].SpeakerID != "Obi-Wan" {
+		t.Errorf("swapped speakers = %q, %q", r.Segments[0].SpeakerID, r.Segments[1].SpeakerID)
+	}
+}
@@ -0,0 +1,212 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// EmbeddedMode selects what is done with subtitles a video already has
+type EmbeddedMode string
+
+// Embedded subtitle modes
+const (
+	// EmbeddedExtract writes the embedded subtitles next to the transcript,
+	// as a baseline to compare it with
+	EmbeddedExtract EmbeddedMode = "extract"
+
+	// EmbeddedPrefer uses the embedded subtitles as the transcript and
+	// skips transcribing videos that have them
+	EmbeddedPrefer EmbeddedMode = "prefer"
+)
+
+// ParseEmbeddedMode validates an embedded subtitle mode; an empty name
+// leaves embedded subtitles alone
+func ParseEmbeddedMode(name string) (EmbeddedMode, error) {
+	mode := EmbeddedMode(strings.ToLower(strings.TrimSpace(name)))
+	switch mode {
+	case "", EmbeddedExtract, EmbeddedPrefer:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown embedded subtitles mode %q (want extract or prefer)", name)
+}
+
+// EmbeddedPath returns where the embedded subtitles of a video are written
+// with EmbeddedExtract: meeting.embedded.srt next to meeting.txt
+func EmbeddedPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".embedded.srt"
+}
+
+// ProviderEmbedded is the provider of results taken from a video's own
+// subtitle track instead of being transcribed
+const ProviderEmbedded = "embedded-subtitles"
+
+// MetadataSubtitleStream records which subtitle track of the video a
+// result was taken from, as audio.SubtitleStream
+const MetadataSubtitleStream = "subtitle_stream"
+
+// ErrNoEmbeddedSubtitles is returned when a video has no text subtitle
+// track in the requested language
+var ErrNoEmbeddedSubtitles = errors.New("no embedded text subtitles")
+
+// terminologyLanguages maps the ISO 639-2/T codes some muxers write to the
+// bibliographic codes of subtitleLanguages
+var terminologyLanguages = map[string]string{
+	"deu": "ger", "fra": "fre", "nld": "dut", "zho": "chi",
+}
+
+// srtTiming matches the timing line of an SRT cue
+var srtTiming = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})[,.](\d{3})\s*-->\s*(\d+):(\d{2}):(\d{2})[,.](\d{3})`)
+
+// srtMarkup matches HTML-like tags and ASS override blocks in cue text
+var srtMarkup = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// FindSubtitleStream picks the text subtitle track in language, e.g. "en"
+// or "zh-TW"; "" or "auto" accepts any. A default track is preferred.
+func FindSubtitleStream(streams []audio.SubtitleStream, language string) (audio.SubtitleStream, bool) {
+	want := ""
+	if language != "" && language != "auto" {
+		want = normalizeTrackLanguage(subtitleLanguage(language))
+	}
+
+	var found []audio.SubtitleStream
+	for _, stream := range streams {
+		if !stream.Text() {
+			continue
+		}
+		if want != "" && normalizeTrackLanguage(stream.Language) != want {
+			continue
+		}
+		found = append(found, stream)
+	}
+	for _, stream := range found {
+		if stream.Default {
+			return stream, true
+		}
+	}
+	if len(found) == 0 {
+		return audio.SubtitleStream{}, false
+	}
+	return found[0], true
+}
+
+// EmbeddedSubtitles reads the subtitle track of a video in language ("" or
+// "auto" for any) into a result, without transcribing anything. It returns
+// ErrNoEmbeddedSubtitles when there is no such text track. It kills ffmpeg
+// when ctx is done.
+func EmbeddedSubtitles(ctx context.Context, videoPath, language string) (*TranscribeResult, error) {
+	streams, err := audio.SubtitleStreams(ctx, videoPath)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := FindSubtitleStream(streams, language)
+	if !ok {
+		return nil, ErrNoEmbeddedSubtitles
+	}
+
+	file, err := os.CreateTemp("", "gollmscribe_subtitles_*.srt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subtitle file: %w", err)
+	}
+	srtPath := file.Name()
+	_ = file.Close()
+	defer func() { _ = os.Remove(srtPath) }()
+
+	if err := audio.ExtractSubtitles(ctx, videoPath, stream.Index, srtPath); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(srtPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extracted subtitles: %w", err)
+	}
+	segments, err := ParseSRT(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subtitle track %d: %w", stream.Index, err)
+	}
+
+	result := &TranscribeResult{
+		SchemaVersion: ResultSchemaVersion,
+		FilePath:      videoPath,
+		Segments:      segments,
+		Language:      stream.Language,
+		Provider:      ProviderEmbedded,
+		Metadata:      map[string]interface{}{MetadataSubtitleStream: stream},
+	}
+	if language != "" && language != "auto" {
+		result.Language = language
+	}
+	if len(segments) > 0 {
+		result.Duration = segments[len(segments)-1].End
+	}
+	result.RebuildText()
+	return result, nil
+}
+
+// ParseSRT reads the cues of SRT subtitles as segments, dropping
+// formatting tags and joining the lines of each cue
+func ParseSRT(data []byte) ([]providers.TranscriptionSegment, error) {
+	content := strings.TrimPrefix(string(data), "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var segments []providers.TranscriptionSegment
+	for _, block := range strings.Split(content, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) > 0 && !strings.Contains(lines[0], "-->") {
+			lines = lines[1:] // Cue number
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		timing := srtTiming.FindStringSubmatch(strings.TrimSpace(lines[0]))
+		if timing == nil {
+			return nil, fmt.Errorf("invalid cue timing %q", lines[0])
+		}
+
+		var texts []string
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(srtMarkup.ReplaceAllString(line, "")); line != "" {
+				texts = append(texts, line)
+			}
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		segments = append(segments, providers.TranscriptionSegment{
+			Text:  strings.Join(texts, " "),
+			Start: srtDuration(timing[1:5]),
+			End:   srtDuration(timing[5:9]),
+		})
+	}
+	return segments, nil
+}
+
+// srtDuration converts hours, minutes, seconds and milliseconds to a
+// duration
+func srtDuration(parts []string) time.Duration {
+	units := []time.Duration{time.Hour, time.Minute, time.Second, time.Millisecond}
+	var d time.Duration
+	for i, part := range parts {
+		n, _ := strconv.Atoi(part)
+		d += time.Duration(n) * units[i]
+	}
+	return d
+}
+
+// normalizeTrackLanguage folds the codes a track's language may be tagged
+// with into one, e.g. "deu" into "ger"
+func normalizeTrackLanguage(code string) string {
+	code = strings.ToLower(code)
+	if bibliographic, ok := terminologyLanguages[code]; ok {
+		return bibliographic
+	}
+	return code
+}
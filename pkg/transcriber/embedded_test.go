@@ -0,0 +1,67 @@
+package transcriber
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+)
+
+func TestParseSRT(t *testing.T) {
+	srt := "\ufeff1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>Hello</i> there,\r\n{\\an8}everyone.\r\n\r\n" +
+		"2\r\n00:00:04,000 --> 00:00:05,250\r\n<font color=\"#fff\"></font>\r\n\r\n" +
+		"3\r\n01:02:03.004 --> 01:02:04.000\r\nLast line\r\n"
+
+	segments, err := ParseSRT([]byte(srt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("ParseSRT() = %d segments, want 2 without the empty cue", len(segments))
+	}
+	if got := segments[0]; got.Text != "Hello there, everyone." || got.Start != time.Second || got.End != 3500*time.Millisecond {
+		t.Errorf("first segment = %+v", got)
+	}
+	if want := time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond; segments[1].Start != want {
+		t.Errorf("last segment starts at %v, want %v", segments[1].Start, want)
+	}
+
+	if _, err := ParseSRT([]byte("1\nnot a timing\ntext\n")); err == nil {
+		t.Error("ParseSRT() of an invalid cue succeeded")
+	}
+}
+
+func TestFindSubtitleStream(t *testing.T) {
+	streams := []audio.SubtitleStream{
+		{Index: 0, Codec: "hdmv_pgs_subtitle", Language: "eng"},
+		{Index: 1, Codec: "subrip", Language: "eng"},
+		{Index: 2, Codec: "subrip", Language: "deu", Default: true},
+		{Index: 3, Codec: "mov_text", Language: "und"},
+	}
+	for language, want := range map[string]int{
+		"en":    1,
+		"de":    2,
+		"auto":  2,
+		"zh-TW": -1,
+	} {
+		stream, ok := FindSubtitleStream(streams, language)
+		if got := map[bool]int{true: stream.Index, false: -1}[ok]; got != want {
+			t.Errorf("FindSubtitleStream(%q) = track %d, want %d", language, got, want)
+		}
+	}
+}
+
+func TestParseEmbeddedMode(t *testing.T) {
+	for _, name := range []string{"", "extract", "Prefer"} {
+		if _, err := ParseEmbeddedMode(name); err != nil {
+			t.Errorf("ParseEmbeddedMode(%q) = %v", name, err)
+		}
+	}
+	if _, err := ParseEmbeddedMode("replace"); err == nil {
+		t.Error("ParseEmbeddedMode() accepted an unknown mode")
+	}
+	if got := EmbeddedPath(filepath.Join("out", "talk.txt")); got != filepath.Join("out", "talk.embedded.srt") {
+		t.Errorf("EmbeddedPath() = %s", got)
+	}
+}
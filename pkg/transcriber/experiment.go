@@ -0,0 +1,425 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/budget"
+	"github.com/eternnoir/gollmscribe/pkg/cache"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// ExperimentMode selects how an experiment compares its two prompts
+type ExperimentMode string
+
+const (
+	// ExperimentAlternate transcribes even chunks with prompt A and odd
+	// chunks with prompt B, so the file costs what a normal run does
+	ExperimentAlternate ExperimentMode = "alternate"
+
+	// ExperimentDuplicate transcribes a sample of the chunks with both
+	// prompts, comparing them on the same audio
+	ExperimentDuplicate ExperimentMode = "duplicate"
+)
+
+// Experiment variant names
+const (
+	VariantA = "A"
+	VariantB = "B"
+)
+
+// ParseExperimentMode validates an experiment mode; empty means alternate
+func ParseExperimentMode(s string) (ExperimentMode, error) {
+	switch mode := ExperimentMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ExperimentAlternate, nil
+	case ExperimentAlternate, ExperimentDuplicate:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown experiment mode %q (want alternate or duplicate)", s)
+}
+
+// ExperimentOptions configures a prompt experiment
+type ExperimentOptions struct {
+	PromptA string
+	PromptB string
+	Mode    ExperimentMode
+
+	// Chunks transcribed with both prompts in duplicate mode, spread
+	// evenly over the file (0 = all)
+	Sample int
+
+	// Trusted transcript with timestamped segments, e.g. a corrected
+	// result, to score each run by word error rate (optional)
+	Reference *TranscribeResult
+
+	// Every non-empty line of a response must match this for it to count
+	// as well-structured (optional, see ExperimentRun.Valid)
+	LinePattern *regexp.Regexp
+}
+
+// ExperimentRun is the transcription of one chunk with one prompt
+type ExperimentRun struct {
+	Chunk    int           `json:"chunk"`
+	Variant  string        `json:"variant"`
+	Start    time.Duration `json:"start"`
+	End      time.Duration `json:"end"`
+	Text     string        `json:"text,omitempty"`
+	Words    int           `json:"words"`
+	Segments int           `json:"segments"`
+
+	// The response is non-empty, its segments are in order and inside the
+	// chunk, and its lines match ExperimentOptions.LinePattern if given
+	Valid bool `json:"valid"`
+
+	// Word error rate against the reference, or -1 without one
+	WER float64 `json:"wer"`
+
+	Latency time.Duration    `json:"latency"`
+	Cached  bool             `json:"cached,omitempty"`
+	Usage   *providers.Usage `json:"usage,omitempty"`
+	Cost    float64          `json:"cost,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// VariantSummary aggregates the runs of one prompt
+type VariantSummary struct {
+	Variant     string        `json:"variant"`
+	Prompt      string        `json:"prompt"`
+	Runs        int           `json:"runs"`
+	Failures    int           `json:"failures"`
+	MeanWords   float64       `json:"mean_words"`
+	ValidShare  float64       `json:"valid_share"`
+	MeanWER     float64       `json:"mean_wer"` // -1 without a reference
+	MeanLatency time.Duration `json:"mean_latency"`
+	Cost        float64       `json:"cost"`
+}
+
+// ExperimentReport is the outcome of a prompt experiment
+type ExperimentReport struct {
+	FilePath string           `json:"file_path"`
+	Mode     ExperimentMode   `json:"mode"`
+	Runs     []ExperimentRun  `json:"runs"`
+	Variants []VariantSummary `json:"variants"`
+}
+
+// experimentTask is a chunk to transcribe with one of the prompts
+type experimentTask struct {
+	chunk   *audio.ChunkInfo
+	variant string
+	prompt  string
+}
+
+// Experiment transcribes the chunks of a file with two prompts and
+// measures each response, to compare prompts on real audio. Chunks are
+// sent one at a time so latencies are comparable; failed runs are
+// recorded in the report rather than failing the experiment. The prompts
+// replace req.CustomPrompt and no transcript is merged or saved.
+func (t *TranscriberImpl) Experiment(ctx context.Context, req *TranscribeRequest, opts ExperimentOptions) (*ExperimentReport, error) {
+	log := logger.WithComponent("experiment").WithField("file", req.FilePath)
+	if strings.TrimSpace(opts.PromptA) == "" || strings.TrimSpace(opts.PromptB) == "" {
+		return nil, fmt.Errorf("an experiment needs two prompts")
+	}
+	if opts.PromptA == opts.PromptB {
+		return nil, fmt.Errorf("the prompts of an experiment are identical")
+	}
+	if opts.Mode == "" {
+		opts.Mode = ExperimentAlternate
+	}
+	if opts.Reference != nil && len(opts.Reference.Segments) == 0 {
+		return nil, fmt.Errorf("the reference transcript has no timestamped segments")
+	}
+
+	if err := t.processor.ValidateFileContext(ctx, req.FilePath); err != nil {
+		return nil, fmt.Errorf("file validation failed: %w", err)
+	}
+	sourceHash, err := audio.HashFile(req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash input file: %w", err)
+	}
+	info, err := t.processor.GetAudioInfoContext(ctx, req.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio info: %w", err)
+	}
+	ctx, job, err := t.startJob(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = job.Close() }()
+	audioPath := req.FilePath
+	if info.IsVideo {
+		if audioPath, err = t.convertVideoToAudio(ctx, req.FilePath, info.Duration); err != nil {
+			return nil, fmt.Errorf("video conversion failed: %w", err)
+		}
+		defer func() { _ = os.Remove(audioPath) }()
+	}
+
+	chunks, err := t.createChunks(ctx, audioPath, req.Options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunks: %w", err)
+	}
+	defer func() { _ = t.chunker.CleanupChunks(chunks) }()
+	for _, chunk := range chunks {
+		chunk.Key = audio.ChunkKey(sourceHash, chunk.Start, chunk.End)
+	}
+
+	provider := t.Provider()
+	tasks := experimentTasks(chunks, opts)
+
+	// Both prompts count against the budget before anything is uploaded
+	var estimate budget.Estimate
+	for _, variant := range []string{VariantA, VariantB} {
+		var variantChunks []*audio.ChunkInfo
+		prompt := ""
+		for _, task := range tasks {
+			if task.variant == variant {
+				variantChunks = append(variantChunks, task.chunk)
+				prompt = task.prompt
+			}
+		}
+		e := t.estimateChunks(provider, variantChunks, prompt, 0, nativeDiarization(provider, req, nil), nil)
+		estimate.Chunks += e.Chunks
+		estimate.Tokens += e.Tokens
+		estimate.Cost += e.Cost
+	}
+	if err := t.budget.Reserve(ctx, estimate); err != nil {
+		return nil, fmt.Errorf("budget check failed: %w", err)
+	}
+
+	log.Info().Str("mode", string(opts.Mode)).Int("chunks", len(chunks)).Int("runs", len(tasks)).Msg("Starting prompt experiment")
+
+	report := &ExperimentReport{FilePath: req.FilePath, Mode: opts.Mode}
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		taskReq := *req
+		taskReq.CustomPrompt = task.prompt
+		started := time.Now()
+		result, err := t.transcribeChunk(ctx, provider, task.chunk, &taskReq, nil)
+		latency := time.Since(started)
+		run := t.measureRun(task, result, err, opts)
+		run.Latency = latency
+		report.Runs = append(report.Runs, run)
+
+		log.Info().
+			Int("chunk", run.Chunk).
+			Str("variant", run.Variant).
+			Int("words", run.Words).
+			Bool("valid", run.Valid).
+			Dur("latency", run.Latency).
+			Msg("Experiment run finished")
+	}
+
+	report.Variants = []VariantSummary{
+		summarizeVariant(VariantA, opts.PromptA, report.Runs),
+		summarizeVariant(VariantB, opts.PromptB, report.Runs),
+	}
+	return report, nil
+}
+
+// experimentTasks assigns the prompts to the chunks for the mode
+func experimentTasks(chunks []*audio.ChunkInfo, opts ExperimentOptions) []experimentTask {
+	// Silent chunks have nothing to compare
+	var audible []*audio.ChunkInfo
+	for _, chunk := range chunks {
+		if !chunk.Silent {
+			audible = append(audible, chunk)
+		}
+	}
+
+	var tasks []experimentTask
+	if opts.Mode == ExperimentDuplicate {
+		for _, i := range sampleIndices(len(audible), opts.Sample) {
+			tasks = append(tasks,
+				experimentTask{chunk: audible[i], variant: VariantA, prompt: opts.PromptA},
+				experimentTask{chunk: audible[i], variant: VariantB, prompt: opts.PromptB})
+		}
+		return tasks
+	}
+
+	for _, chunk := range audible {
+		task := experimentTask{chunk: chunk, variant: VariantA, prompt: opts.PromptA}
+		if chunk.Index%2 == 1 {
+			task.variant, task.prompt = VariantB, opts.PromptB
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// sampleIndices returns n indices of 0..total-1 spread evenly, or all of
+// them when n is 0 or not less than total
+func sampleIndices(total, n int) []int {
+	if n <= 0 || n >= total {
+		n = total
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i * total / n
+	}
+	return indices
+}
+
+// measureRun scores the response to one experiment task
+func (t *TranscriberImpl) measureRun(task experimentTask, result *providers.TranscriptionResult, err error, opts ExperimentOptions) ExperimentRun {
+	run := ExperimentRun{
+		Chunk:   task.chunk.Index,
+		Variant: task.variant,
+		Start:   task.chunk.ContentStart(),
+		End:     task.chunk.End,
+		WER:     -1,
+	}
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+
+	run.Text = result.Text
+	run.Words = len(wordTokens(result.Text))
+	run.Segments = len(result.Segments)
+	run.Valid = validStructure(result, run.Start, run.End, opts.LinePattern)
+	if opts.Reference != nil {
+		run.WER = WordErrorRate(referenceText(opts.Reference, run.Start, run.End), result.Text)
+	}
+
+	run.Cached, _ = result.Metadata[cache.MetadataHit].(bool)
+	if !run.Cached {
+		usage := providers.UsageFromMetadata(result.Metadata)
+		if !usage.IsZero() {
+			run.Usage = &usage
+		}
+		model, _ := result.Metadata["model"].(string)
+		run.Cost, _ = t.prices.Cost(model, usage, task.chunk.AudioDuration())
+	}
+	return run
+}
+
+// validStructure reports whether a response looks like a usable
+// transcript: it has text, its segments are in order within start to end,
+// and every non-empty line matches pattern if one is given
+func validStructure(result *providers.TranscriptionResult, start, end time.Duration, pattern *regexp.Regexp) bool {
+	if strings.TrimSpace(result.Text) == "" {
+		return false
+	}
+	previous := start
+	for _, segment := range result.Segments {
+		if segment.Start < previous || segment.End < segment.Start || segment.Start > end {
+			return false
+		}
+		previous = segment.Start
+	}
+	if pattern != nil {
+		for _, line := range strings.Split(result.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !pattern.MatchString(line) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// referenceText returns the text of the reference segments starting
+// between start and end
+func referenceText(reference *TranscribeResult, start, end time.Duration) string {
+	var texts []string
+	for _, segment := range reference.Segments {
+		if segment.Start >= start && segment.Start < end {
+			texts = append(texts, segment.Text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// summarizeVariant averages the runs of one variant. Failed runs count
+// towards Failures only.
+func summarizeVariant(variant, prompt string, runs []ExperimentRun) VariantSummary {
+	summary := VariantSummary{Variant: variant, Prompt: prompt, MeanWER: -1}
+	var words, valid, scored int
+	var wer float64
+	var latency time.Duration
+	for _, run := range runs {
+		if run.Variant != variant {
+			continue
+		}
+		summary.Runs++
+		if run.Error != "" {
+			summary.Failures++
+			continue
+		}
+		words += run.Words
+		latency += run.Latency
+		summary.Cost += run.Cost
+		if run.Valid {
+			valid++
+		}
+		if run.WER >= 0 {
+			wer += run.WER
+			scored++
+		}
+	}
+
+	if succeeded := summary.Runs - summary.Failures; succeeded > 0 {
+		summary.MeanWords = float64(words) / float64(succeeded)
+		summary.ValidShare = float64(valid) / float64(succeeded)
+		summary.MeanLatency = latency / time.Duration(succeeded)
+	}
+	if scored > 0 {
+		summary.MeanWER = wer / float64(scored)
+	}
+	return summary
+}
+
+// wordTokens returns the normalized words and CJK characters of text,
+// skipping tokens that are only punctuation
+func wordTokens(text string) []string {
+	var words []string
+	for _, tok := range tokenize(text) {
+		if tok.norm != "" {
+			words = append(words, tok.norm)
+		}
+	}
+	return words
+}
+
+// WordErrorRate returns the word error rate of hypothesis against
+// reference: the substitutions, deletions and insertions needed to turn
+// one into the other, divided by the words of the reference. Words are
+// compared ignoring case and punctuation, and CJK characters count as
+// words. It is 0 for two empty texts and 1 for text against an empty
+// reference.
+func WordErrorRate(reference, hypothesis string) float64 {
+	ref, hyp := wordTokens(reference), wordTokens(hypothesis)
+	if len(ref) == 0 {
+		if len(hyp) == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	// Edit distance over words, one row at a time
+	previous := make([]int, len(hyp)+1)
+	current := make([]int, len(hyp)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ref); i++ {
+		current[0] = i
+		for j := 1; j <= len(hyp); j++ {
+			substitution := previous[j-1]
+			if ref[i-1] != hyp[j-1] {
+				substitution++
+			}
+			current[j] = min(substitution, previous[j]+1, current[j-1]+1)
+		}
+		previous, current = current, previous
+	}
+	return float64(previous[len(hyp)]) / float64(len(ref))
+}
@@ -0,0 +1,123 @@
+package transcriber
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestWordErrorRate(t *testing.T) {
+	tests := []struct {
+		reference, hypothesis string
+		want                  float64
+	}{
+		{"the cat sat on the mat", "The cat sat on the mat.", 0},
+		{"the cat sat on the mat", "the cat sat on mat", 1.0 / 6},
+		{"the cat sat", "a cat sat down", 2.0 / 3},
+		{"我们下周开始", "我们下周再开始", 1.0 / 6},
+		{"", "", 0},
+		{"", "anything", 1},
+	}
+	for _, tt := range tests {
+		if got := WordErrorRate(tt.reference, tt.hypothesis); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("WordErrorRate(%q, %q) = %v, want %v", tt.reference, tt.hypothesis, got, tt.want)
+		}
+	}
+}
+
+func TestExperimentTasks(t *testing.T) {
+	chunks := make([]*audio.ChunkInfo, 5)
+	for i := range chunks {
+		chunks[i] = &audio.ChunkInfo{Index: i}
+	}
+	chunks[2].Silent = true
+
+	opts := ExperimentOptions{PromptA: "a", PromptB: "b", Mode: ExperimentAlternate}
+	var got []string
+	for _, task := range experimentTasks(chunks, opts) {
+		got = append(got, task.variant+task.prompt)
+	}
+	if want := "[Aa Bb Bb Aa]"; fmt.Sprint(got) != want {
+		t.Errorf("alternate tasks = %v, want %s", got, want)
+	}
+
+	opts.Mode, opts.Sample = ExperimentDuplicate, 2
+	got = nil
+	for _, task := range experimentTasks(chunks, opts) {
+		got = append(got, fmt.Sprintf("%d%s", task.chunk.Index, task.variant))
+	}
+	if want := "[0A 0B 3A 3B]"; fmt.Sprint(got) != want {
+		t.Errorf("duplicate tasks = %v, want %s", got, want)
+	}
+}
+
+func TestSampleIndices(t *testing.T) {
+	tests := []struct {
+		total, n int
+		want     []int
+	}{
+		{10, 3, []int{0, 3, 6}},
+		{4, 0, []int{0, 1, 2, 3}},
+		{2, 5, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		got := sampleIndices(tt.total, tt.n)
+		if len(got) != len(tt.want) {
+			t.Errorf("sampleIndices(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("sampleIndices(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestValidStructure(t *testing.T) {
+	pattern := regexp.MustCompile(`^\[\d{2}:\d{2}\] \w+: `)
+	start, end := time.Minute, 2*time.Minute
+	tests := []struct {
+		name   string
+		result *providers.TranscriptionResult
+		want   bool
+	}{
+		{"lines", &providers.TranscriptionResult{Text: "[00:01] Alice: hi\n\n[00:05] Bob: hello"}, true},
+		{"empty", &providers.TranscriptionResult{Text: "  "}, false},
+		{"prose", &providers.TranscriptionResult{Text: "Here is the transcript:\n[00:01] Alice: hi"}, false},
+		{"unordered", &providers.TranscriptionResult{Text: "[00:01] Alice: hi", Segments: []providers.TranscriptionSegment{
+			{Start: 80 * time.Second, End: 90 * time.Second}, {Start: 70 * time.Second, End: 75 * time.Second},
+		}}, false},
+		{"outside", &providers.TranscriptionResult{Text: "[00:01] Alice: hi", Segments: []providers.TranscriptionSegment{
+			{Start: 3 * time.Minute, End: 4 * time.Minute},
+		}}, false},
+	}
+	for _, tt := range tests {
+		if got := validStructure(tt.result, start, end, pattern); got != tt.want {
+			t.Errorf("%s: validStructure() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeVariant(t *testing.T) {
+	runs := []ExperimentRun{
+		{Variant: VariantA, Words: 100, Valid: true, WER: 0.1, Latency: time.Second, Cost: 0.01},
+		{Variant: VariantA, Words: 50, WER: 0.3, Latency: 3 * time.Second, Cost: 0.02},
+		{Variant: VariantA, Error: "timeout", WER: -1},
+		{Variant: VariantB, Words: 10, Valid: true, WER: -1},
+	}
+	a := summarizeVariant(VariantA, "prompt a", runs)
+	if a.Runs != 3 || a.Failures != 1 || a.MeanWords != 75 || a.ValidShare != 0.5 || math.Abs(a.MeanWER-0.2) > 1e-9 ||
+		a.MeanLatency != 2*time.Second || math.Abs(a.Cost-0.03) > 1e-9 {
+		t.Errorf("summary A = %+v", a)
+	}
+	if b := summarizeVariant(VariantB, "prompt b", runs); b.Runs != 1 || b.MeanWER != -1 || b.ValidShare != 1 {
+		t.Errorf("summary B = %+v", b)
+	}
+}
@@ -0,0 +1,229 @@
+package transcriber
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// speakerColors are the colors speakers are told apart by in HTML, in the
+// order they first speak
+var speakerColors = []string{"#1f77b4", "#d62728", "#2ca02c", "#9467bd", "#ff7f0e", "#17becf", "#8c564b", "#e377c2"}
+
+// htmlSegment is a segment as shown in the HTML transcript
+type htmlSegment struct {
+	Start       float64 // Seconds
+	Time        string
+	Speaker     string
+	Color       string
+	Text        string
+	Translation string
+}
+
+// htmlChapter is a chapter link in the HTML transcript
+type htmlChapter struct {
+	Start float64
+	Time  string
+	Title string
+}
+
+// htmlPage is the data of the HTML transcript template
+type htmlPage struct {
+	Title      string
+	Language   string
+	Duration   string
+	Notes      []string
+	AudioSrc   template.URL
+	Chapters   []htmlChapter
+	Segments   []htmlSegment
+	Paragraphs []string // Text of results without segments
+}
+
+// ToHTML renders the result as a self-contained interactive page: each
+// segment has a timestamp link, speakers are color coded and a search box
+// filters the segments. With audioSrc, the URL of the source media
+// relative to the page, an audio player is embedded and clicking a
+// timestamp plays from there.
+func (r *TranscribeResult) ToHTML(audioSrc string) ([]byte, error) {
+	page := htmlPage{
+		Title:    "Transcript",
+		Language: r.Language,
+		Notes:    r.Notes(),
+		AudioSrc: template.URL(audioSrc),
+	}
+	if r.FilePath != "" {
+		page.Title = filepath.Base(r.FilePath)
+	}
+	if r.Duration > 0 {
+		page.Duration = formatHTMLTime(r.Duration)
+	}
+	for _, chapter := range r.Chapters {
+		page.Chapters = append(page.Chapters, htmlChapter{
+			Start: chapter.Start.Seconds(),
+			Time:  formatHTMLTime(chapter.Start),
+			Title: chapter.Title,
+		})
+	}
+
+	colors := make(map[string]string)
+	for _, segment := range r.Segments {
+		color := ""
+		if segment.SpeakerID != "" {
+			if colors[segment.SpeakerID] == "" {
+				colors[segment.SpeakerID] = speakerColors[len(colors)%len(speakerColors)]
+			}
+			color = colors[segment.SpeakerID]
+		}
+		page.Segments = append(page.Segments, htmlSegment{
+			Start:       segment.Start.Seconds(),
+			Time:        formatHTMLTime(segment.Start),
+			Speaker:     segment.SpeakerID,
+			Color:       color,
+			Text:        strings.TrimSpace(segment.Text),
+			Translation: strings.TrimSpace(segment.Translation),
+		})
+	}
+	if len(r.Segments) == 0 {
+		for _, paragraph := range strings.Split(r.Text, "\n\n") {
+			if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+				page.Paragraphs = append(page.Paragraphs, paragraph)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return nil, fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// HTMLAudioSource returns the URL of the result's source media relative
+// to an HTML page at outputPath, or "" if there is no source file
+func HTMLAudioSource(result *TranscribeResult, outputPath string) string {
+	if result.FilePath == "" {
+		return ""
+	}
+	media, err := filepath.Abs(result.FilePath)
+	if err != nil {
+		return ""
+	}
+	dir, err := filepath.Abs(filepath.Dir(outputPath))
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(dir, media)
+	if err != nil {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(media)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+// formatHTMLTime formats a timestamp as H:MM:SS, or M:SS under an hour
+func formatHTMLTime(d time.Duration) string {
+	total := int(d.Seconds())
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+var htmlTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html{{with .Language}} lang="{{.}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 52rem; margin: 0 auto; padding: 1rem; line-height: 1.5; color: #222; }
+header { position: sticky; top: 0; background: #fff; padding: .5rem 0; border-bottom: 1px solid #ddd; }
+h1 { font-size: 1.3rem; margin: 0 0 .25rem; }
+.meta { color: #666; font-size: .9rem; }
+audio { width: 100%; margin-top: .5rem; }
+#search { width: 100%; box-sizing: border-box; padding: .4rem; margin-top: .5rem; font-size: 1rem; }
+nav ol { padding-left: 1.2rem; }
+.segment { display: flex; gap: .75rem; padding: .25rem 0; }
+.segment.hidden { display: none; }
+.segment.playing { background: #fff6d5; }
+.time { color: #06c; text-decoration: none; font-variant-numeric: tabular-nums; min-width: 4rem; }
+.speaker { font-weight: 600; }
+.translation { color: #555; font-style: italic; }
+mark { background: #ffe066; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<div class="meta">{{with .Duration}}Duration {{.}}{{end}}{{with .Language}} · {{.}}{{end}}{{range .Notes}} · {{.}}{{end}}</div>
+{{if .AudioSrc}}<audio id="player" controls preload="metadata" src="{{.AudioSrc}}"></audio>{{end}}
+<input id="search" type="search" placeholder="Search the transcript" autocomplete="off">
+</header>
+{{if .Chapters}}<nav><ol>{{range .Chapters}}
+<li><a class="time" href="#t={{.Start}}" data-start="{{.Start}}">{{.Time}}</a> {{.Title}}</li>{{end}}
+</ol></nav>{{end}}
+<main>{{range .Segments}}
+<div class="segment" data-start="{{.Start}}"><a class="time" href="#t={{.Start}}" data-start="{{.Start}}">{{.Time}}</a><div>{{if .Speaker}}<span class="speaker" style="color: {{.Color}}">{{.Speaker}}:</span> {{end}}<span class="text">{{.Text}}</span>{{if .Translation}}<div class="translation">{{.Translation}}</div>{{end}}</div></div>{{end}}{{range .Paragraphs}}
+<p class="segment"><span class="text">{{.}}</span></p>{{end}}
+</main>
+<script>
+(function () {
+  var player = document.getElementById("player");
+  var segments = Array.prototype.slice.call(document.querySelectorAll(".segment"));
+  function seek(seconds) {
+    if (!player) return;
+    player.currentTime = seconds;
+    player.play();
+  }
+  document.addEventListener("click", function (e) {
+    var link = e.target.closest("a.time");
+    if (!link || !player) return;
+    e.preventDefault();
+    seek(parseFloat(link.dataset.start));
+    history.replaceState(null, "", link.getAttribute("href"));
+  });
+  if (player) {
+    var match = location.hash.match(/^#t=([\d.]+)$/);
+    if (match) player.currentTime = parseFloat(match[1]);
+    player.addEventListener("timeupdate", function () {
+      var current = null;
+      segments.forEach(function (s) {
+        if (s.dataset.start && parseFloat(s.dataset.start) <= player.currentTime) current = s;
+        s.classList.remove("playing");
+      });
+      if (current) current.classList.add("playing");
+    });
+  }
+  var search = document.getElementById("search");
+  segments.forEach(function (s) {
+    var text = s.querySelector(".text");
+    if (text) text.dataset.plain = text.textContent;
+  });
+  search.addEventListener("input", function () {
+    var query = search.value.trim().toLowerCase();
+    segments.forEach(function (s) {
+      var text = s.querySelector(".text");
+      if (!text) return;
+      var plain = text.dataset.plain;
+      var at = plain.toLowerCase().indexOf(query);
+      var speaker = s.querySelector(".speaker");
+      var speakerMatch = speaker && speaker.textContent.toLowerCase().indexOf(query) >= 0;
+      s.classList.toggle("hidden", query !== "" && at < 0 && !speakerMatch);
+      text.textContent = plain;
+      if (query !== "" && at >= 0) {
+        var mark = document.createElement("mark");
+        mark.textContent = plain.substr(at, query.length);
+        text.textContent = plain.slice(0, at);
+        text.appendChild(mark);
+        text.appendChild(document.createTextNode(plain.slice(at + query.length)));
+      }
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
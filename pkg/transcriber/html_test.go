@@ -0,0 +1,81 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestToHTML(t *testing.T) {
+	result := &TranscribeResult{
+		FilePath: "talks/intro.mp3",
+		Text:     "Hello <world>.",
+		Duration: 90 * time.Second,
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello <world>.", Start: 0, End: 2 * time.Second, SpeakerID: "Alice"},
+			{Text: "Hi.", Start: 65 * time.Second, End: 66 * time.Second, SpeakerID: "Bob"},
+			{Text: "Bye.", Start: 70 * time.Second, End: 71 * time.Second, SpeakerID: "Alice"},
+		},
+	}
+
+	page, err := result.ToHTML("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(page)
+	for _, want := range []string{
+		"<title>intro.mp3</title>",
+		"Hello &lt;world&gt;.",
+		`href="#t=65"`,
+		">1:05</a>",
+		`id="search"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+	if strings.Contains(html, "<audio") {
+		t.Error("HTML without an audio source has a player")
+	}
+	// Each speaker keeps their color
+	if strings.Count(html, speakerColors[0]) != 2 || strings.Count(html, speakerColors[1]) != 1 {
+		t.Errorf("speaker colors not assigned per speaker:\n%s", html)
+	}
+
+	page, err = result.ToHTML("../talks/intro%20one.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `<audio id="player" controls preload="metadata" src="../talks/intro%20one.mp3">`) {
+		t.Errorf("HTML lacks the audio player:\n%s", page)
+	}
+}
+
+func TestHTMLAudioSource(t *testing.T) {
+	dir := t.TempDir()
+	result := &TranscribeResult{FilePath: filepath.Join(dir, "media", "my talk.mp3")}
+	if got, want := HTMLAudioSource(result, filepath.Join(dir, "out", "talk.html")), "../media/my%20talk.mp3"; got != want {
+		t.Errorf("HTMLAudioSource() = %q, want %q", got, want)
+	}
+	if got := HTMLAudioSource(&TranscribeResult{}, "talk.html"); got != "" {
+		t.Errorf("HTMLAudioSource() without a source = %q", got)
+	}
+
+	if err := SaveResult(result, filepath.Join(dir, "out", "talk.html"), "html-audio"); err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "out", "talk.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `src="../media/my%20talk.mp3"`) {
+		t.Errorf("saved page lacks the audio source:\n%s", page)
+	}
+	if _, ok := result.Metadata["saved_at"]; ok {
+		t.Error("HTML output was stamped like JSON")
+	}
+}
@@ -2,8 +2,10 @@ package transcriber
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"github.com/eternnoir/gollmscribe/pkg/events"
 	"github.com/eternnoir/gollmscribe/pkg/providers"
 )
 
@@ -13,6 +15,23 @@ type TranscribeRequest struct {
 	OutputPath   string
 	CustomPrompt string
 	Options      TranscribeOptions
+
+	// Audio is read instead of FilePath when set, e.g. an upload, and is
+	// spooled to the temp directory for the run. Format is its container
+	// as a file extension ("mp3", "wav"). FilePath may still name the
+	// audio for events and the result. EstimateCostContext and PlanChunksContext only
+	// read files.
+	Audio  io.Reader
+	Format string
+
+	// JobID is the short ID of the run, used in logs, events, the
+	// checkpoint and the result. One is generated when empty; pass the ID
+	// of an interrupted run when resuming it to keep referring to it.
+	JobID string
+
+	// Notes are free-form operator annotations, e.g. "board meeting" or
+	// "confidential", kept in the result's metadata (see MetadataNotes)
+	Notes []string
 }
 
 // TranscribeOptions provides configuration for the transcription process
@@ -22,24 +41,172 @@ type TranscribeOptions struct {
 	Workers        int // Default: 3
 	Temperature    float32
 	PreserveAudio  bool // Keep temporary audio files
+	Resume         bool // Skip chunks finished by an earlier, interrupted run
+	ChunkRetries   int  // Times to retry a failed chunk before giving up on it
+	AllowPartial   bool // Return a result with gaps instead of failing when chunks fail
+	TrimSilence    bool // Cut long leading/trailing silence from chunks before upload
+	LeadingContext bool // Send the overlap as context-only audio instead of transcribing it twice
+
+	// Formats rendered next to the request's OutputPath, e.g. "srt" writes
+	// meeting.srt beside meeting.txt, up to RenderWorkers at a time
+	// (default: DefaultRenderWorkers)
+	OutputFormats []string
+	RenderWorkers int
+
+	// Keep earlier transcripts of the file: when OutputPath or one of its
+	// formats exists, write the next version instead, e.g. meeting_v2.txt
+	// and meeting_v2.json (see VersionPath)
+	KeepVersions bool
+
+	// Remove silences longer than Audio.SkipSilenceSeconds (default 10)
+	// from the whole recording before chunking, so sparse recordings cost
+	// less. Times still refer to the original audio.
+	SkipSilence bool
+
+	// Scale the chunk workers between MinWorkers (default 1) and
+	// MaxWorkers (default twice Workers) by the provider's latency and
+	// errors, starting at Workers
+	AdaptiveWorkers bool
+	MinWorkers      int
+	MaxWorkers      int
+
+	// How chunk transcripts are combined (default: MergeTextAlign, or
+	// MergeNaive with LeadingContext)
+	MergeStrategy MergeStrategy
+
+	// Bundled prompt template for how the transcript is written, appended
+	// to the custom prompt if there is one (default: the provider's prompt)
+	Style Style
+
+	// Directory of speaker recordings named after the speaker, see
+	// audio.LoadVoiceProfiles. Speakers matching a profile get its name
+	// as SpeakerID.
+	VoiceProfilesDir string
+
+	// Speaker renames applied to the merged result and streamed segments,
+	// e.g. {"Speaker 1": "Alice"}; see RelabelSpeakers
+	SpeakerMap map[string]string
+
+	// Prefer the speaker labels of providers that diarize natively (see
+	// providers.Diarizer) over labels asked for in the prompt. They are
+	// normalized to "Speaker N" and recorded under MetadataDiarization.
+	// Other providers, and runs with voice profiles, keep the prompt.
+	NativeDiarization bool
+
+	// Ask the provider to tag the language of every line, for recordings
+	// that switch languages. Tags become segment languages, and the share
+	// of each language is recorded under MetadataLanguages.
+	SegmentLanguages bool
+
+	// Transcribe each channel of a multichannel recording on its own and
+	// label its segments with the speaker at the channel's index, e.g.
+	// {"Agent", "Customer"} for telephony audio with the agent on the left.
+	// The result carries CallMetrics under MetadataCallMetrics.
+	ChannelSpeakers []string
+
+	// Shortest silence between speakers counted as a hold in CallMetrics
+	// (default: DefaultHoldThreshold)
+	HoldThreshold time.Duration
+
+	// Record where the time of every chunk sent went (encoding, upload,
+	// provider latency and parsing) under MetadataChunkTimings
+	Timing bool
+
+	// Language the audio is spoken in, e.g. "ja" or "zh-TW"; chunks
+	// transcribed in another one are handled by LanguageCheck (default:
+	// off). Languages are guessed from the script and, for Latin script,
+	// common words, so only clear mismatches are caught.
+	ExpectedLanguage string
+	LanguageCheck    LanguageCheck
 }
 
+// MergeStrategy selects how the transcripts of overlapping chunks are
+// combined into one result
+type MergeStrategy string
+
+// Merge strategies
+const (
+	// MergeNaive concatenates chunks, keeping any text repeated in the overlap
+	MergeNaive MergeStrategy = "naive"
+
+	// MergeTimestamp cuts at the middle of the overlap by segment
+	// timestamps and rebuilds the text from the kept segments
+	MergeTimestamp MergeStrategy = "timestamp"
+
+	// MergeTextAlign cuts the text at the longest run of words shared by
+	// adjacent chunks and segments by timestamp
+	MergeTextAlign MergeStrategy = "text-align"
+
+	// MergeLLM sends the overlap audio with the text around each chunk
+	// boundary to the provider and lets it write the joined text. It costs
+	// one extra request per boundary; failures fall back to MergeTextAlign.
+	MergeLLM MergeStrategy = "llm-assisted"
+)
+
+// MetadataSilent marks the result of a chunk that was all silence and
+// was not sent to the provider
+const MetadataSilent = "silent"
+
+// Metadata keys set on partial results
+const (
+	MetadataPartial = "partial" // true when chunks are missing
+	MetadataGaps    = "gaps"    // []Gap describing the missing chunks
+)
+
+// MetadataNotes holds the request's operator notes on the merged result
+const MetadataNotes = "notes"
+
+// Metadata keys identifying chunks by their stable key (see audio.ChunkKey)
+const (
+	MetadataChunkKey  = "chunk_key"  // Set on each chunk result
+	MetadataChunkKeys = "chunk_keys" // []string of all chunks, set on the merged result
+)
+
+// Gap is a chunk missing from a partial result
+type Gap struct {
+	Chunk int           `json:"chunk"`
+	Key   string        `json:"key,omitempty"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Error string        `json:"error"`
+}
+
+// Chapter is a section of a transcript about one topic
+type Chapter struct {
+	Title string        `json:"title"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// ResultSchemaVersion is the version of the JSON layout written by TranscribeResult.ToJSON
+const ResultSchemaVersion = 1
+
 // TranscribeResult represents the complete transcription result
 type TranscribeResult struct {
-	FilePath    string                           `json:"file_path"`
-	Text        string                           `json:"text"`
-	Segments    []providers.TranscriptionSegment `json:"segments,omitempty"`
-	Language    string                           `json:"language,omitempty"`
-	Duration    time.Duration                    `json:"duration,omitempty"`
-	ChunkCount  int                              `json:"chunk_count,omitempty"`
-	ProcessTime time.Duration                    `json:"process_time,omitempty"`
-	Provider    string                           `json:"provider"`
-	Metadata    map[string]interface{}           `json:"metadata,omitempty"`
+	SchemaVersion int                              `json:"schema_version"`
+	FilePath      string                           `json:"file_path"`
+	JobID         string                           `json:"job_id,omitempty"`
+	Text          string                           `json:"text"`
+	Segments      []providers.TranscriptionSegment `json:"segments,omitempty"`
+	Chapters      []Chapter                        `json:"chapters,omitempty"`
+	Language      string                           `json:"language,omitempty"`
+	Duration      time.Duration                    `json:"duration,omitempty"`
+	ChunkCount    int                              `json:"chunk_count,omitempty"`
+	ProcessTime   time.Duration                    `json:"process_time,omitempty"`
+	Provider      string                           `json:"provider"`
+	Model         string                           `json:"model,omitempty"`
+	Usage         *providers.Usage                 `json:"usage,omitempty"`
+	Cost          float64                          `json:"cost_usd,omitempty"` // Estimated from the pricing table
+	Metadata      map[string]interface{}           `json:"metadata,omitempty"`
 }
 
 // ProgressCallback is called during transcription to report progress
 type ProgressCallback func(completed, total int, currentChunk string)
 
+// SegmentCallback receives transcribed segments in order, with timestamps
+// relative to the start of the file. It is never called concurrently.
+type SegmentCallback func(segment providers.TranscriptionSegment)
+
 // Transcriber defines the interface for the main transcription orchestrator
 type Transcriber interface {
 	// Transcribe processes a single audio/video file
@@ -48,14 +215,22 @@ type Transcriber interface {
 	// TranscribeWithProgress processes a file with progress reporting
 	TranscribeWithProgress(ctx context.Context, req *TranscribeRequest, callback ProgressCallback) (*TranscribeResult, error)
 
+	// TranscribeStream processes a file, emitting segments as chunks finish
+	// instead of waiting for the whole file to be merged
+	TranscribeStream(ctx context.Context, req *TranscribeRequest, onSegment SegmentCallback) (*TranscribeResult, error)
+
 	// TranscribeBatch processes multiple files
 	TranscribeBatch(ctx context.Context, requests []*TranscribeRequest) ([]*TranscribeResult, error)
 
 	// SupportedFormats returns supported file formats
 	SupportedFormats() []string
 
-	// SetProvider changes the LLM provider
+	// SetProvider changes the LLM provider for files started afterwards;
+	// it is safe to call while files are being transcribed
 	SetProvider(provider providers.LLMProvider)
+
+	// Events returns the bus on which lifecycle events are published
+	Events() *events.Bus
 }
 
 // ChunkMerger handles merging overlapping transcript chunks
@@ -63,6 +238,6 @@ type ChunkMerger interface {
 	// MergeChunks combines multiple transcription results with overlap handling
 	MergeChunks(chunks []*providers.TranscriptionResult) (*TranscribeResult, error)
 
-	// DetectOverlap identifies overlapping content between chunks
+	// DetectOverlap returns the time range transcribed by both chunks
 	DetectOverlap(chunk1, chunk2 *providers.TranscriptionResult) (time.Duration, time.Duration, error)
 }
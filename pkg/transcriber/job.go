@@ -0,0 +1,122 @@
+package transcriber
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Job is a transcription that left a checkpoint behind, i.e. one that was
+// interrupted or finished with gaps and can be resumed
+type Job struct {
+	ID      string    `json:"id"`
+	Source  string    `json:"source"`
+	Chunks  int       `json:"completed_chunks"`
+	Updated time.Time `json:"updated"`
+}
+
+// NewJobID returns a short random ID for a transcription run
+func NewJobID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", uint32(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b)
+}
+
+// withJobID returns the request with a job ID, generating one if it has none
+func withJobID(req *TranscribeRequest) *TranscribeRequest {
+	if req.JobID != "" {
+		return req
+	}
+	identified := *req
+	identified.JobID = NewJobID()
+	return &identified
+}
+
+// Jobs lists the resumable jobs in the temp directory, most recent first.
+// Checkpoints written before job IDs existed, or that cannot be read with
+// the transcriber's cipher, are left out.
+func (t *TranscriberImpl) Jobs() ([]Job, error) {
+	paths, err := filepath.Glob(filepath.Join(t.tempDir, "checkpoints", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if data, err = t.cipher.Decrypt(data); err != nil {
+			continue
+		}
+		var saved checkpointData
+		if err := json.Unmarshal(data, &saved); err != nil || saved.JobID == "" {
+			continue
+		}
+		jobs = append(jobs, Job{
+			ID:      saved.JobID,
+			Source:  saved.Source,
+			Chunks:  len(saved.Chunks),
+			Updated: info.ModTime(),
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Updated.After(jobs[j].Updated) })
+	return jobs, nil
+}
+
+// FindJob returns the resumable job with the given ID
+func (t *TranscriberImpl) FindJob(id string) (*Job, error) {
+	jobs, err := t.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no resumable job %s", id)
+}
+
+// LatestJob returns the most recent resumable job for a source file, if any
+func (t *TranscriberImpl) LatestJob(source string) *Job {
+	jobs, err := t.Jobs()
+	if err != nil {
+		return nil
+	}
+	for i := range jobs {
+		if jobs[i].Source == source {
+			return &jobs[i]
+		}
+	}
+	return nil
+}
+
+// ExpandOutputTemplate builds an output path from a filename template with
+// the placeholders {name} (the input's name without extension), {job} and
+// {date} (YYYY-MM-DD). A relative result is placed next to the input.
+func ExpandOutputTemplate(template, inputPath, jobID string, now time.Time) string {
+	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	path := strings.NewReplacer(
+		"{name}", name,
+		"{job}", jobID,
+		"{date}", now.Format("2006-01-02"),
+	).Replace(template)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(inputPath), path)
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+package transcriber
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestJobs(t *testing.T) {
+	dir := t.TempDir()
+	tr := &TranscriberImpl{tempDir: dir}
+	provider := namedProvider{"gemini"}
+	const source = "0123456789abcdef0123"
+
+	if id := NewJobID(); len(id) != 8 || id == NewJobID() {
+		t.Errorf("NewJobID() = %q, want 8 random characters", id)
+	}
+	if jobs, err := tr.Jobs(); err != nil || len(jobs) != 0 {
+		t.Fatalf("Jobs() without checkpoints = %v, %v", jobs, err)
+	}
+
+	req := &TranscribeRequest{FilePath: filepath.Join(dir, "talk.mp3"), JobID: "3f9a2c1d"}
+	cp := tr.openCheckpoint(req, provider, source)
+	chunk := &audio.ChunkInfo{Key: audio.ChunkKey(source, 0, 15*time.Minute)}
+	if err := cp.save(chunk, &providers.TranscriptionResult{Text: "chunk one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := tr.FindJob("3f9a2c1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Source != req.FilePath || job.Chunks != 1 {
+		t.Errorf("FindJob() = %+v, want one chunk of %s", job, req.FilePath)
+	}
+	if latest := tr.LatestJob(req.FilePath); latest == nil || latest.ID != "3f9a2c1d" {
+		t.Errorf("LatestJob() = %+v, want job 3f9a2c1d", latest)
+	}
+	if _, err := tr.FindJob("00000000"); err == nil {
+		t.Error("FindJob() for an unknown ID succeeded")
+	}
+
+	// A finished run leaves no job behind
+	cp.remove()
+	if jobs, _ := tr.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() after remove = %v, want none", jobs)
+	}
+}
+
+func TestExpandOutputTemplate(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template, input, want string
+	}{
+		{"{name}.{job}.txt", "rec/interview.mp3", "rec/interview.3f9a2c1d.txt"},
+		{"{date}-{name}.json", "talk.wav", "2026-03-14-talk.json"},
+		{"/out/{job}.srt", "rec/talk.mp4", "/out/3f9a2c1d.srt"},
+	}
+	for _, tt := range tests {
+		if got := ExpandOutputTemplate(tt.template, tt.input, "3f9a2c1d", now); got != tt.want {
+			t.Errorf("ExpandOutputTemplate(%q, %q) = %q, want %q", tt.template, tt.input, got, tt.want)
+		}
+	}
+}
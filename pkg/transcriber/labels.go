@@ -0,0 +1,117 @@
+package transcriber
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// ToAudacityLabels converts the segments to an Audacity label track, one
+// "start<TAB>end<TAB>label" line per segment, which File > Import >
+// Labels opens over the recording
+func (r *TranscribeResult) ToAudacityLabels() ([]byte, error) {
+	if len(r.Segments) == 0 {
+		return nil, fmt.Errorf("result has no segments")
+	}
+
+	var labels strings.Builder
+	for _, segment := range r.Segments {
+		text := singleLine(segment.Text)
+		if segment.SpeakerID != "" {
+			text = fmt.Sprintf("%s: %s", segment.SpeakerID, text)
+		}
+		fmt.Fprintf(&labels, "%s\t%s\t%s\n", labelSeconds(segment.Start), labelSeconds(segment.End), text)
+	}
+	return []byte(labels.String()), nil
+}
+
+// ToTextGrid converts the segments to a Praat TextGrid with one interval
+// tier per speaker, in the order they first speak, or a single
+// "transcript" tier without speakers. Praat needs the intervals of a tier
+// to cover the recording, so the gaps between segments are empty
+// intervals, and segments overlapping the previous one of their tier start
+// where it ends.
+func (r *TranscribeResult) ToTextGrid() ([]byte, error) {
+	if len(r.Segments) == 0 {
+		return nil, fmt.Errorf("result has no segments")
+	}
+
+	end := r.Duration
+	var names []string
+	tiers := make(map[string][]providers.TranscriptionSegment)
+	for _, segment := range r.Segments {
+		name := segment.SpeakerID
+		if name == "" {
+			name = "transcript"
+		}
+		if _, ok := tiers[name]; !ok {
+			names = append(names, name)
+		}
+		tiers[name] = append(tiers[name], segment)
+		if segment.End > end {
+			end = segment.End
+		}
+	}
+
+	var grid strings.Builder
+	grid.WriteString("File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n")
+	fmt.Fprintf(&grid, "xmin = 0\nxmax = %s\ntiers? <exists>\nsize = %d\nitem []:\n", labelSeconds(end), len(names))
+	for i, name := range names {
+		intervals := tierIntervals(tiers[name], end)
+		fmt.Fprintf(&grid, "    item [%d]:\n", i+1)
+		fmt.Fprintf(&grid, "        class = \"IntervalTier\"\n        name = %s\n", textGridString(name))
+		fmt.Fprintf(&grid, "        xmin = 0\n        xmax = %s\n", labelSeconds(end))
+		fmt.Fprintf(&grid, "        intervals: size = %d\n", len(intervals))
+		for j, interval := range intervals {
+			fmt.Fprintf(&grid, "        intervals [%d]:\n", j+1)
+			fmt.Fprintf(&grid, "            xmin = %s\n            xmax = %s\n", labelSeconds(interval.Start), labelSeconds(interval.End))
+			fmt.Fprintf(&grid, "            text = %s\n", textGridString(singleLine(interval.Text)))
+		}
+	}
+	return []byte(grid.String()), nil
+}
+
+// tierIntervals returns the intervals of a tier from 0 to end: its
+// segments in order, with empty intervals between them
+func tierIntervals(segments []providers.TranscriptionSegment, end time.Duration) []providers.TranscriptionSegment {
+	var intervals []providers.TranscriptionSegment
+	var at time.Duration
+	for _, segment := range segments {
+		start := segment.Start
+		if start < at {
+			start = at
+		}
+		if segment.End <= start {
+			continue
+		}
+		if start > at {
+			intervals = append(intervals, providers.TranscriptionSegment{Start: at, End: start})
+		}
+		intervals = append(intervals, providers.TranscriptionSegment{Start: start, End: segment.End, Text: segment.Text})
+		at = segment.End
+	}
+	if at < end || len(intervals) == 0 {
+		intervals = append(intervals, providers.TranscriptionSegment{Start: at, End: end})
+	}
+	return intervals
+}
+
+// labelSeconds formats a time as seconds, e.g. 12.5, as Audacity and
+// Praat read them
+func labelSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// textGridString quotes a TextGrid string, in which quotes are doubled
+func textGridString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// singleLine joins the lines and tabs of a label, which would break the
+// line-based formats
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,64 @@
+package transcriber
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestToAudacityLabels(t *testing.T) {
+	result := &TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello\tthere,\nworld.", Start: 0, End: 1500 * time.Millisecond, SpeakerID: "Alice"},
+			{Text: "Hi.", Start: 2 * time.Second, End: 3 * time.Second},
+		},
+	}
+	labels, err := result.ToAudacityLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0\t1.5\tAlice: Hello there, world.\n2\t3\tHi.\n"
+	if string(labels) != want {
+		t.Errorf("ToAudacityLabels() = %q, want %q", labels, want)
+	}
+
+	if _, err := (&TranscribeResult{Text: "no segments"}).ToAudacityLabels(); err == nil {
+		t.Error("ToAudacityLabels() without segments succeeded")
+	}
+}
+
+func TestToTextGrid(t *testing.T) {
+	result := &TranscribeResult{
+		Duration: 10 * time.Second,
+		Segments: []providers.TranscriptionSegment{
+			{Text: `She said "yes".`, Start: time.Second, End: 3 * time.Second, SpeakerID: "A"},
+			{Text: "Right.", Start: 2 * time.Second, End: 4 * time.Second, SpeakerID: "B"},
+			{Text: "Overlap.", Start: 2500 * time.Millisecond, End: 5 * time.Second, SpeakerID: "A"},
+		},
+	}
+	grid, err := result.ToTextGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(grid)
+	for _, want := range []string{
+		"xmax = 10\ntiers? <exists>\nsize = 2\n",
+		`name = "A"`,
+		`name = "B"`,
+		`text = "She said ""yes""."`,
+		// A's second segment starts where its first ends
+		"xmin = 3\n            xmax = 5\n            text = \"Overlap.\"",
+		// Gaps are empty intervals up to the end of the recording
+		"xmin = 0\n            xmax = 1\n            text = \"\"",
+		"xmin = 5\n            xmax = 10\n            text = \"\"",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("TextGrid lacks %q:\n%s", want, text)
+		}
+	}
+	if got := strings.Count(text, "intervals: size = 4"); got != 1 {
+		t.Errorf("tier A has not 4 intervals:\n%s", text)
+	}
+}
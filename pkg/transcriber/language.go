@@ -0,0 +1,273 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// DefaultDetectSample is how much of the start of a recording is sent to
+// detect its language
+const DefaultDetectSample = time.Minute
+
+// MetadataLanguages is the result metadata key holding the share of the
+// transcript in each language, see LanguageShares
+const MetadataLanguages = "languages"
+
+// metadataLanguageWords holds the words per language tagged in a chunk
+// result; the merged result gets MetadataLanguages instead
+const metadataLanguageWords = "language_words"
+
+// languageInstructions asks the provider to tag the language of every line
+const languageInstructions = "The recording may switch between languages. Write everything in the language it is spoken in, " +
+	"without translating, and start a new line whenever the language changes. Put the ISO 639-1 code of the language " +
+	"in square brackets in front of the text of every line, e.g. \"[zh] 我们下周开始\" and \"[en] Sounds good\"."
+
+// languageTagPattern matches a tag such as "[en]" or "[zh-TW]"
+var languageTagPattern = regexp.MustCompile(`\[([A-Za-z]{2}(?:-[A-Za-z]{2,4})?)\]\s*`)
+
+// languagePrompt adds the language tagging instructions to prompt, or to
+// defaultPrompt when there is no custom prompt
+func languagePrompt(prompt, defaultPrompt string) string {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = defaultPrompt
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return languageInstructions
+	}
+	return prompt + "\n\n" + languageInstructions
+}
+
+// splitLanguageTag removes the first language tag from a line and returns
+// its language, e.g. "en" or "zh-TW", or "" if the line has none
+func splitLanguageTag(line string) (string, string) {
+	loc := languageTagPattern.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return "", line
+	}
+	language, region, found := strings.Cut(line[loc[2]:loc[3]], "-")
+	language = strings.ToLower(language)
+	if found {
+		language += "-" + strings.ToUpper(region)
+	}
+	return language, line[:loc[0]] + line[loc[1]:]
+}
+
+// tagLanguages moves the language tags the provider wrote into the
+// languages of the segments, strips them from the text, and counts the
+// words in each language
+func tagLanguages(result *providers.TranscriptionResult) {
+	words := make(map[string]int)
+
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		if language, text := splitLanguageTag(segment.Text); language != "" {
+			segment.Language = language
+			segment.Text = strings.TrimSpace(text)
+		}
+		if segment.Language != "" {
+			words[segment.Language] += len(tokenize(segment.Text))
+		}
+	}
+
+	lines := strings.Split(result.Text, "\n")
+	for i, line := range lines {
+		language, text := splitLanguageTag(line)
+		if language == "" {
+			continue
+		}
+		lines[i] = text
+		if len(result.Segments) == 0 {
+			words[language] += len(tokenize(text))
+		}
+	}
+	result.Text = strings.Join(lines, "\n")
+
+	if len(words) > 0 {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata[metadataLanguageWords] = words
+	}
+}
+
+// setLanguages records the share of each language in the merged result,
+// counted from the tagged chunks or else from the segment languages, and
+// sets the result language to the most used one if the provider gave none
+func setLanguages(result *TranscribeResult, chunks []*providers.TranscriptionResult) {
+	words := make(map[string]int)
+	for _, chunk := range chunks {
+		if chunk == nil {
+			continue
+		}
+		for language, n := range languageWords(chunk.Metadata[metadataLanguageWords]) {
+			words[language] += n
+		}
+	}
+	delete(result.Metadata, metadataLanguageWords)
+
+	if len(words) == 0 {
+		for _, segment := range result.Segments {
+			if segment.Language != "" {
+				words[segment.Language] += len(tokenize(segment.Text))
+			}
+		}
+	}
+	shares := LanguageShares(words)
+	if len(shares) == 0 {
+		return
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataLanguages] = shares
+	if result.Language == "" {
+		result.Language = DominantLanguage(shares)
+	}
+}
+
+// languageWords reads word counts from chunk metadata. Counts loaded from
+// a checkpoint are decoded from JSON.
+func languageWords(value interface{}) map[string]int {
+	switch v := value.(type) {
+	case map[string]int:
+		return v
+	case map[string]interface{}:
+		words := make(map[string]int, len(v))
+		for language, n := range v {
+			switch n := n.(type) {
+			case float64:
+				words[language] = int(n)
+			case json.Number:
+				count, _ := n.Int64()
+				words[language] = int(count)
+			}
+		}
+		return words
+	}
+	return nil
+}
+
+// LanguageShares converts word counts per language into the fraction of
+// all words, rounded to three decimals. Languages without words are left out.
+func LanguageShares(words map[string]int) map[string]float64 {
+	total := 0
+	for _, n := range words {
+		total += n
+	}
+	if total == 0 {
+		return nil
+	}
+
+	shares := make(map[string]float64, len(words))
+	for language, n := range words {
+		if n > 0 {
+			shares[language] = math.Round(float64(n)/float64(total)*1000) / 1000
+		}
+	}
+	return shares
+}
+
+// DominantLanguage returns the language with the largest share; ties go
+// to the alphabetically first
+func DominantLanguage(shares map[string]float64) string {
+	languages := make([]string, 0, len(shares))
+	for language := range shares {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	dominant := ""
+	for _, language := range languages {
+		if dominant == "" || shares[language] > shares[dominant] {
+			dominant = language
+		}
+	}
+	return dominant
+}
+
+// DetectLanguage transcribes the start of a file, up to sample long (0
+// uses DefaultDetectSample), and returns the language most of it is
+// spoken in, e.g. "zh" or "pt-BR"
+func (t *TranscriberImpl) DetectLanguage(ctx context.Context, filePath string, sample time.Duration) (string, error) {
+	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(filePath))
+
+	info, err := t.processor.GetAudioInfoContext(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get audio info: %w", err)
+	}
+	if sample <= 0 {
+		sample = DefaultDetectSample
+	}
+	if info.Duration > 0 && info.Duration < sample {
+		sample = info.Duration
+	}
+
+	// Samples are MP3 unless ffmpeg is missing and the source format is kept
+	ext := ".mp3"
+	if !audio.FFmpegAvailable() {
+		ext = strings.ToLower(filepath.Ext(filePath))
+	}
+	path := filepath.Join(t.scratchDir(ctx), fmt.Sprintf("detect_%d%s", time.Now().UnixNano(), ext))
+	if err := t.chunker.CreateChunkContext(ctx, filePath, 0, sample, path); err != nil {
+		return "", fmt.Errorf("failed to cut language sample: %w", err)
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	reader, err := t.reader.OpenAudio(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open language sample: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	prompt := languagePrompt("", t.config.Transcribe.DefaultPrompt)
+	if err := t.limiter.Wait(ctx, providers.EstimateTokens(sample, prompt)); err != nil {
+		return "", fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+
+	format := audio.DetectFormat(path)
+	result, err := t.Provider().Transcribe(ctx, &providers.TranscriptionRequest{
+		Audio:       reader,
+		AudioFormat: string(format),
+		MimeType:    audio.GetMimeType(format),
+		Filename:    filepath.Base(path),
+		Prompt:      prompt,
+		Options: providers.TranscriptionOptions{
+			MaxTokens:      t.config.Provider.MaxTokens,
+			TimeoutSeconds: int(t.config.Provider.Timeout.Seconds()),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("language detection failed: %w", err)
+	}
+
+	language := detectedLanguage(result)
+	if language == "" {
+		return "", fmt.Errorf("could not detect the language of %s", filepath.Base(filePath))
+	}
+	log.Info().Str("language", language).Dur("sample", sample).Msg("Detected language")
+	return language, nil
+}
+
+// detectedLanguage returns the main language of a sample transcribed with
+// language tags, or the language code reported by the provider
+func detectedLanguage(result *providers.TranscriptionResult) string {
+	tagLanguages(result)
+	if language := DominantLanguage(LanguageShares(languageWords(result.Metadata[metadataLanguageWords]))); language != "" {
+		return language
+	}
+	language, _ := splitLanguageTag("[" + strings.TrimSpace(result.Language) + "]")
+	return language
+}
@@ -0,0 +1,110 @@
+package transcriber
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestTagLanguagesText(t *testing.T) {
+	result := &providers.TranscriptionResult{
+		Text: "[zh] 我们下周开始\n[en] Sounds good to me\n[sic] no tag here",
+	}
+	tagLanguages(result)
+
+	if want := "我们下周开始\nSounds good to me\n[sic] no tag here"; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	words := result.Metadata[metadataLanguageWords].(map[string]int)
+	if words["zh"] != 6 || words["en"] != 4 {
+		t.Errorf("words = %v, want 6 zh and 4 en", words)
+	}
+}
+
+func TestTagLanguagesSegments(t *testing.T) {
+	result := &providers.TranscriptionResult{
+		Text: "Alice: [EN] Hello there\nBob: [zh-TW] 你好",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "[EN] Hello there", SpeakerID: "Alice"},
+			{Text: "[zh-tw] 你好", SpeakerID: "Bob"},
+			{Text: "Untagged", Language: "english"},
+		},
+	}
+	tagLanguages(result)
+
+	if result.Text != "Alice: Hello there\nBob: 你好" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	got := []string{result.Segments[0].Language, result.Segments[1].Language, result.Segments[2].Language}
+	if strings.Join(got, ",") != "en,zh-TW,english" || result.Segments[1].Text != "你好" {
+		t.Errorf("Segments = %+v", result.Segments)
+	}
+}
+
+func TestSetLanguages(t *testing.T) {
+	chunks := []*providers.TranscriptionResult{
+		{Metadata: map[string]interface{}{metadataLanguageWords: map[string]int{"zh": 6, "en": 2}}},
+		nil,
+		// Resumed from a checkpoint, decoded from JSON
+		{Metadata: map[string]interface{}{metadataLanguageWords: map[string]interface{}{"en": float64(2)}}},
+	}
+	result := &TranscribeResult{Metadata: map[string]interface{}{metadataLanguageWords: map[string]int{"zh": 6}}}
+	setLanguages(result, chunks)
+
+	shares := result.Metadata[MetadataLanguages].(map[string]float64)
+	if shares["zh"] != 0.6 || shares["en"] != 0.4 {
+		t.Errorf("shares = %v, want zh 0.6 and en 0.4", shares)
+	}
+	if _, ok := result.Metadata[metadataLanguageWords]; ok {
+		t.Error("per-chunk word counts should be removed from the merged result")
+	}
+	if result.Language != "zh" {
+		t.Errorf("Language = %q, want zh", result.Language)
+	}
+}
+
+func TestSetLanguagesFromSegments(t *testing.T) {
+	result := &TranscribeResult{
+		Language: "en",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "one two three", Language: "en"},
+			{Text: "uno", Language: "es"},
+			{Text: "unknown"},
+		},
+	}
+	setLanguages(result, nil)
+
+	shares := result.Metadata[MetadataLanguages].(map[string]float64)
+	if shares["en"] != 0.75 || shares["es"] != 0.25 || len(shares) != 2 {
+		t.Errorf("shares = %v", shares)
+	}
+	if result.Language != "en" {
+		t.Errorf("Language = %q, want the provider's language kept", result.Language)
+	}
+}
+
+func TestSetLanguagesWithoutLanguages(t *testing.T) {
+	result := &TranscribeResult{Segments: []providers.TranscriptionSegment{{Text: "hello"}}}
+	setLanguages(result, nil)
+	if result.Metadata != nil {
+		t.Errorf("Metadata = %v, want none without languages", result.Metadata)
+	}
+}
+
+func TestDetectedLanguage(t *testing.T) {
+	tests := []struct {
+		result *providers.TranscriptionResult
+		want   string
+	}{
+		{&providers.TranscriptionResult{Text: "[en] OK\n[zh] 我们下周开始上线"}, "zh"},
+		{&providers.TranscriptionResult{Text: "hola", Language: "ES"}, "es"},
+		{&providers.TranscriptionResult{Text: "hello", Language: "english"}, ""},
+		{&providers.TranscriptionResult{Text: "hello"}, ""},
+	}
+	for _, tt := range tests {
+		if got := detectedLanguage(tt.result); got != tt.want {
+			t.Errorf("detectedLanguage(%q, %q) = %q, want %q", tt.result.Text, tt.result.Language, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// LanguageCheck selects what happens to chunks transcribed in another
+// language than TranscribeOptions.ExpectedLanguage, e.g. when the model
+// answers in the language of the prompt instead of the audio
+type LanguageCheck string
+
+// Language checks
+const (
+	// LanguageCheckFlag records the chunks under MetadataLanguageMismatches
+	LanguageCheckFlag LanguageCheck = "flag"
+
+	// LanguageCheckRerun sends the chunks once more with a prompt insisting
+	// on the expected language, and flags those still in another one
+	LanguageCheckRerun LanguageCheck = "rerun"
+)
+
+// Metadata keys of the language check
+const (
+	MetadataLanguageMismatch   = "language_mismatch"   // Detected language, set on each chunk result in another language
+	MetadataLanguageMismatches = "language_mismatches" // []LanguageMismatch, set on the merged result
+)
+
+// minGuessLetters is the fewest letters a language is guessed from
+const minGuessLetters = 20
+
+// LanguageMismatch is a chunk left in another language than expected
+type LanguageMismatch struct {
+	Chunk    int           `json:"chunk"`
+	Key      string        `json:"key,omitempty"`
+	Start    time.Duration `json:"start"`
+	End      time.Duration `json:"end"`
+	Expected string        `json:"expected"`
+	Detected string        `json:"detected"`
+	Rerun    bool          `json:"rerun,omitempty"` // The corrective re-run did not help
+}
+
+// ParseLanguageCheck validates a language check name; an empty name
+// turns the check off
+func ParseLanguageCheck(name string) (LanguageCheck, error) {
+	switch check := LanguageCheck(strings.ToLower(strings.TrimSpace(name))); check {
+	case "", LanguageCheckFlag, LanguageCheckRerun:
+		return check, nil
+	}
+	return "", fmt.Errorf("unknown language check %q (want flag or rerun)", name)
+}
+
+// scriptLanguages are the languages guessed from the script most letters
+// are written in; Latin text is told apart by latinStopwords
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Latin, "latin"},
+}
+
+// languageScripts maps languages sharing a script to the language
+// GuessLanguage reports for it; other languages are written in Latin
+var languageScripts = map[string]string{
+	"ja": "ja", "ko": "ko", "zh": "zh", "yue": "zh",
+	"ru": "ru", "uk": "ru", "be": "ru", "bg": "ru", "mk": "ru", "kk": "ru", "mn": "ru",
+	"ar": "ar", "fa": "ar", "ur": "ar", "ps": "ar",
+	"he": "he", "yi": "he",
+	"el": "el",
+	"th": "th",
+	"hi": "hi", "mr": "hi", "ne": "hi",
+}
+
+// latinStopwords are frequent words of languages written in Latin script
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "that", "this", "it", "you", "was", "with", "for", "have", "not", "what"},
+	"es": {"el", "la", "los", "las", "que", "y", "es", "en", "un", "una", "por", "con", "para", "no", "lo", "pero", "está"},
+	"fr": {"le", "les", "et", "est", "que", "des", "un", "une", "pour", "pas", "je", "vous", "nous", "il", "ce", "dans"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "wir", "ein", "eine", "zu", "mit", "auf", "den", "es"},
+	"it": {"il", "che", "di", "e", "è", "un", "una", "per", "non", "sono", "con", "del", "della", "gli", "questo", "ma"},
+	"pt": {"o", "os", "que", "de", "e", "é", "um", "uma", "para", "não", "com", "do", "da", "em", "você", "está"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "ik", "je", "we", "op", "met", "voor", "zijn", "er"},
+}
+
+// stopwordLanguages indexes latinStopwords by word
+var stopwordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range latinStopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// GuessLanguage guesses the language of a transcript from its script and,
+// for Latin script, its most frequent words. It returns a language code
+// such as "ja", "zh", "ru" or "en", standing for every language of its
+// script when only the script is known, or "" when the text is too short
+// or too mixed to tell.
+func GuessLanguage(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scriptLanguages {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+	if letters < minGuessLetters {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters
+	if counts["ja"] > 0 && counts["ja"]*10 >= counts["ja"]+counts["zh"] {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	script, most := "", 0
+	for language, n := range counts {
+		if n > most || n == most && language < script {
+			script, most = language, n
+		}
+	}
+	if most*2 <= letters {
+		return ""
+	}
+	if script != "latin" {
+		return script
+	}
+	return guessLatinLanguage(text)
+}
+
+// guessLatinLanguage picks the language whose stopwords are clearly the
+// most frequent in text, or "" if none is. Words shared by several
+// languages count less for each.
+func guessLatinLanguage(text string) string {
+	hits := make(map[string]float64)
+	for _, tok := range tokenize(text) {
+		languages := stopwordLanguages[tok.norm]
+		for _, language := range languages {
+			hits[language] += 1 / float64(len(languages))
+		}
+	}
+	languages := make([]string, 0, len(hits))
+	for language := range hits {
+		languages = append(languages, language)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if hits[languages[i]] != hits[languages[j]] {
+			return hits[languages[i]] > hits[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+	if len(languages) == 0 || hits[languages[0]] < 3 {
+		return ""
+	}
+	if len(languages) > 1 && hits[languages[0]] < 1.5*hits[languages[1]] {
+		return ""
+	}
+	return languages[0]
+}
+
+// baseLanguage returns the language of a code without its region, e.g.
+// "zh" for "zh-TW"
+func baseLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+// languageMismatch returns the language text was guessed to be in when it
+// is surely not expected. Languages GuessLanguage cannot tell apart, such
+// as Russian and Ukrainian, or Latin languages without stopwords, match.
+func languageMismatch(expected, text string) (string, bool) {
+	detected := GuessLanguage(text)
+	if detected == "" {
+		return "", false
+	}
+	expected = baseLanguage(expected)
+	expectedScript, ok := languageScripts[expected]
+	if !ok {
+		expectedScript = "latin"
+	}
+	if detectedScript, ok := languageScripts[detected]; ok {
+		return detected, detectedScript != expectedScript
+	}
+	// Detected in Latin script
+	if expectedScript != "latin" {
+		return detected, true
+	}
+	_, known := latinStopwords[expected]
+	return detected, known && detected != expected
+}
+
+// resultText returns the spoken text of a chunk result, without the
+// speaker labels of its plain text
+func resultText(result *providers.TranscriptionResult) string {
+	if len(result.Segments) == 0 {
+		return result.Text
+	}
+	texts := make([]string, len(result.Segments))
+	for i, segment := range result.Segments {
+		texts[i] = segment.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// correctivePrompt insists on transcribing in language, for chunks the
+// provider answered in another one
+func correctivePrompt(prompt, language string) string {
+	instructions := fmt.Sprintf("The audio is spoken in %s. Transcribe it in %s exactly as spoken. "+
+		"Do not translate it, not even into the language of these instructions.", language, language)
+	if strings.TrimSpace(prompt) == "" {
+		return instructions
+	}
+	return prompt + "\n\n" + instructions
+}
+
+// checkChunkLanguage applies the request's language check to a chunk
+// result: a result in another language than expected is re-run with a
+// corrective prompt if asked, and marked with the language it is in if
+// it still is
+func (t *TranscriberImpl) checkChunkLanguage(ctx context.Context, provider providers.LLMProvider, chunk *audio.ChunkInfo, req *TranscribeRequest, voices *voiceProfiles, result *providers.TranscriptionResult) *providers.TranscriptionResult {
+	check, expected := req.Options.LanguageCheck, req.Options.ExpectedLanguage
+	if check == "" || expected == "" || strings.EqualFold(expected, "auto") {
+		return result
+	}
+	detected, mismatch := languageMismatch(expected, resultText(result))
+	if !mismatch {
+		return result
+	}
+	log := logger.WithComponent("chunk").WithFields(map[string]interface{}{
+		"chunk_key": chunk.Key,
+		"expected":  expected,
+		"detected":  detected,
+	})
+
+	rerun := false
+	if check == LanguageCheckRerun && ctx.Err() == nil {
+		log.Warn().Msg("Chunk transcribed in another language, re-running with a corrective prompt")
+		prompt := req.CustomPrompt
+		if strings.TrimSpace(prompt) == "" {
+			prompt = t.config.Transcribe.DefaultPrompt
+		}
+		corrected := *req
+		corrected.CustomPrompt = correctivePrompt(prompt, expected)
+		retried, err := t.transcribeChunk(ctx, provider, chunk, &corrected, voices)
+		switch {
+		case err != nil:
+			log.Warn().Err(err).Msg("Corrective re-run failed, keeping the first transcript")
+		default:
+			result = retried
+			if detected, mismatch = languageMismatch(expected, resultText(result)); !mismatch {
+				log.Info().Msg("Corrective re-run fixed the chunk's language")
+				return result
+			}
+		}
+		rerun = true
+	}
+
+	log.Warn().Bool("rerun", rerun).Msg("Chunk transcribed in another language than expected")
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataLanguageMismatch] = LanguageMismatch{
+		Key:      chunk.Key,
+		Start:    chunk.Start,
+		End:      chunk.End,
+		Expected: expected,
+		Detected: detected,
+		Rerun:    rerun,
+	}
+	return result
+}
+
+// setLanguageMismatches lists the chunks left in another language in the
+// merged result, with times on the source recording
+func setLanguageMismatches(result *TranscribeResult, results []*providers.TranscriptionResult, timeMap *audio.TimeMap) {
+	var mismatches []LanguageMismatch
+	for i, chunkResult := range results {
+		if chunkResult == nil {
+			continue
+		}
+		if mismatch, ok := chunkResult.Metadata[MetadataLanguageMismatch].(LanguageMismatch); ok {
+			mismatch.Chunk = i
+			if timeMap != nil {
+				mismatch.Start, mismatch.End = timeMap.ToSource(mismatch.Start), timeMap.EndToSource(mismatch.End)
+			}
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	if result.Metadata == nil {
+		if len(mismatches) == 0 {
+			return
+		}
+		result.Metadata = make(map[string]interface{})
+	}
+	// The merger copies per-chunk metadata; replace it with all chunks
+	delete(result.Metadata, MetadataLanguageMismatch)
+	if len(mismatches) > 0 {
+		result.Metadata[MetadataLanguageMismatches] = mismatches
+	}
+}
+
+// LanguageMismatches returns the chunks a result has in another language
+// than expected
+func (r *TranscribeResult) LanguageMismatches() []LanguageMismatch {
+	if r == nil || r.Metadata == nil {
+		return nil
+	}
+	mismatches, _ := r.Metadata[MetadataLanguageMismatches].([]LanguageMismatch)
+	return mismatches
+}
@@ -0,0 +1,110 @@
+package transcriber
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+const (
+	englishText  = "So this is the plan that we have for the launch, and it is not what you expected."
+	japaneseText = "今日はみなさんにお集まりいただきありがとうございます。来週の予定について話しましょう。"
+	chineseText  = "今天感謝大家來參加這個會議，我們來討論一下下週的計畫和每個人的工作分配。"
+)
+
+func TestGuessLanguage(t *testing.T) {
+	tests := map[string]string{
+		englishText:  "en",
+		japaneseText: "ja",
+		chineseText:  "zh",
+		"Привет всем, сегодня мы обсудим план на следующую неделю.":                     "ru",
+		"Hoy vamos a hablar de los planes para la semana que viene, y de lo que falta.": "es",
+		"Okay.": "",
+		"Kubernetes Terraform Ansible Prometheus Grafana": "",
+	}
+	for text, want := range tests {
+		if got := GuessLanguage(text); got != want {
+			t.Errorf("GuessLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestLanguageMismatch(t *testing.T) {
+	tests := []struct {
+		expected, text string
+		mismatch       bool
+	}{
+		{"ja", englishText, true},
+		{"zh-TW", chineseText, false},
+		{"ja", japaneseText, false},
+		{"en", chineseText, true},
+		{"uk", "Привет всем, сегодня мы обсудим план на следующую неделю.", false},
+		{"sv", englishText, false}, // No stopwords to tell Swedish from English
+		{"es", englishText, true},
+	}
+	for _, tt := range tests {
+		if _, mismatch := languageMismatch(tt.expected, tt.text); mismatch != tt.mismatch {
+			t.Errorf("languageMismatch(%q, %.20q) = %v, want %v", tt.expected, tt.text, mismatch, tt.mismatch)
+		}
+	}
+}
+
+// promptLanguageProvider answers in English unless the prompt insists on
+// the language of the audio
+type promptLanguageProvider struct {
+	namedProvider
+	prompts []string
+}
+
+func (p *promptLanguageProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	if strings.Contains(req.Prompt, "Do not translate") {
+		return &providers.TranscriptionResult{Text: japaneseText}, nil
+	}
+	return &providers.TranscriptionResult{Text: englishText}, nil
+}
+
+func TestLanguageCheck(t *testing.T) {
+	provider := &promptLanguageProvider{namedProvider: namedProvider{"gemini"}}
+	tr := NewTranscriber(provider, &config.Config{})
+	chunks := testChunks(t, 1)
+	req := &TranscribeRequest{
+		FilePath:     "talk.mp3",
+		CustomPrompt: "Transcribe this meeting.",
+		Options:      TranscribeOptions{ExpectedLanguage: "ja", LanguageCheck: LanguageCheckFlag},
+	}
+
+	results, _, err := tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := &TranscribeResult{}
+	setLanguageMismatches(merged, results, nil)
+	if mismatches := merged.LanguageMismatches(); len(mismatches) != 1 || mismatches[0].Detected != "en" || mismatches[0].Rerun {
+		t.Fatalf("flagged mismatches = %+v", mismatches)
+	}
+	if len(provider.prompts) != 1 {
+		t.Errorf("flagging sent %d requests, want 1", len(provider.prompts))
+	}
+
+	provider.prompts = nil
+	req.Options.LanguageCheck = LanguageCheckRerun
+	results, _, err = tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Text != japaneseText || len(provider.prompts) != 2 {
+		t.Fatalf("re-run = %q after %d requests", results[0].Text, len(provider.prompts))
+	}
+	if !strings.HasPrefix(provider.prompts[1], "Transcribe this meeting.") || !strings.Contains(provider.prompts[1], "spoken in ja") {
+		t.Errorf("corrective prompt = %q", provider.prompts[1])
+	}
+	merged = &TranscribeResult{}
+	setLanguageMismatches(merged, results, nil)
+	if merged.Metadata != nil {
+		t.Errorf("fixed chunk was flagged: %v", merged.Metadata)
+	}
+}
@@ -0,0 +1,176 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/events"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// TranscribeLive transcribes a recording that arrives one file at a time,
+// such as the segments of audio.Capture, as one continuous recording.
+// Every file is transcribed as soon as it arrives and its segments are
+// passed to onSegment with timestamps from the start of the first file;
+// files are removed afterwards unless PreserveAudio is set. Files that
+// fail become gaps of a partial result. Once files is closed the
+// transcripts are joined, post-processed and saved to req.OutputPath if
+// set. req.FilePath names the source, e.g. a device or stream URL.
+func (t *TranscriberImpl) TranscribeLive(ctx context.Context, req *TranscribeRequest, files <-chan string, onSegment SegmentCallback) (*TranscribeResult, error) {
+	req = withJobID(req)
+	log := logger.WithComponent("transcriber").WithField("source", req.FilePath).WithField("job", req.JobID)
+	startTime := time.Now()
+	provider := t.Provider()
+
+	t.events.Publish(events.Event{
+		Type:     events.TranscriptionStarted,
+		FilePath: req.FilePath,
+		JobID:    req.JobID,
+		ChunkID:  -1,
+	})
+	fail := func(err error) (*TranscribeResult, error) {
+		t.events.Publish(events.Event{
+			Type:     events.TranscriptionFailed,
+			FilePath: req.FilePath,
+			JobID:    req.JobID,
+			ChunkID:  -1,
+			Error:    err,
+		})
+		return nil, err
+	}
+
+	final := &TranscribeResult{
+		FilePath: req.FilePath,
+		JobID:    req.JobID,
+		Provider: provider.Name(),
+		Metadata: make(map[string]interface{}),
+	}
+	var usage providers.Usage
+	var gaps []Gap
+	var texts []string
+	var offset time.Duration
+	received := 0
+
+	for {
+		var path string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return fail(ctx.Err())
+		case path, ok = <-files:
+		}
+		if !ok {
+			break
+		}
+		index := received
+		received++
+
+		duration := time.Duration(0)
+		if info, err := t.processor.GetAudioInfoContext(ctx, path); err == nil {
+			duration = info.Duration
+		}
+
+		// Each file is transcribed on its own, then moved to its offset
+		fileReq := *req
+		fileReq.FilePath = path
+		fileReq.OutputPath = ""
+		fileOffset := offset
+		var shifted SegmentCallback
+		if onSegment != nil {
+			shifted = func(segment providers.TranscriptionSegment) {
+				segment.Start += fileOffset
+				segment.End += fileOffset
+				onSegment(segment)
+			}
+		}
+		result, cp, err := t.transcribeSource(ctx, provider, &fileReq, nil, shifted)
+		if !req.Options.PreserveAudio {
+			_ = os.Remove(path)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return fail(ctx.Err())
+			}
+			log.Warn().Err(err).Int("segment", index).Msg("Failed to transcribe live segment, continuing")
+			gaps = append(gaps, Gap{Chunk: index, Start: offset, End: offset + duration, Error: err.Error()})
+			offset += duration
+			continue
+		}
+		cp.remove()
+
+		if duration == 0 {
+			duration = result.Duration
+		}
+		for _, segment := range result.Segments {
+			segment.Start += offset
+			segment.End += offset
+			final.Segments = append(final.Segments, segment)
+		}
+		if text := strings.TrimSpace(result.Text); text != "" {
+			texts = append(texts, text)
+		}
+		final.ChunkCount += result.ChunkCount
+		final.Cost += result.Cost
+		if result.Usage != nil {
+			usage = usage.Add(*result.Usage)
+		}
+		if final.Model == "" {
+			final.Model = result.Model
+		}
+		if final.Language == "" {
+			final.Language = result.Language
+		}
+		gaps = append(gaps, shiftGaps(result.Gaps(), offset)...)
+		addStageTimings(final, result.StageTimings())
+		offset += duration
+	}
+
+	if received == 0 {
+		return fail(fmt.Errorf("no audio was received from %s", req.FilePath))
+	}
+	final.Text = strings.Join(texts, "\n")
+	final.Duration = offset
+	final.ProcessTime = time.Since(startTime)
+	if !usage.IsZero() {
+		final.Usage = &usage
+		usage.SetMetadata(final.Metadata)
+	}
+	if len(gaps) > 0 {
+		final.Metadata[MetadataPartial] = true
+		final.Metadata[MetadataGaps] = gaps
+	}
+
+	log.Info().
+		Int("files", received).
+		Dur("duration", final.Duration).
+		Int("segments", len(final.Segments)).
+		Float64("cost_usd", final.Cost).
+		Msg("Live transcription finished")
+
+	if err := t.finishResult(ctx, provider, req, final); err != nil {
+		return fail(err)
+	}
+
+	t.events.Publish(events.Event{
+		Type:      events.TranscriptionCompleted,
+		FilePath:  req.FilePath,
+		JobID:     req.JobID,
+		ChunkID:   -1,
+		Completed: final.ChunkCount,
+		Total:     final.ChunkCount,
+	})
+	return final, nil
+}
+
+// shiftGaps moves the gaps of a file to its offset in a longer recording
+func shiftGaps(gaps []Gap, offset time.Duration) []Gap {
+	for i := range gaps {
+		gaps[i].Start += offset
+		gaps[i].End += offset
+	}
+	return gaps
+}
@@ -0,0 +1,193 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// reconcileMargin widens the text sent around a chunk boundary beyond the
+// share of words expected in the overlap, as speech is not evenly spread
+const reconcileMargin = 1.5
+
+// minReconcileTokens is the least text sent from each side of a boundary
+const minReconcileTokens = 20
+
+// overlapReconciler joins chunk transcripts for the llm-assisted merge
+// strategy: the audio both chunks cover is cut from the source and sent to
+// the provider with the end of the merged text and the start of the next
+// chunk, and the reply replaces both.
+type overlapReconciler struct {
+	t         *TranscriberImpl
+	ctx       context.Context
+	provider  providers.LLMProvider
+	req       *TranscribeRequest
+	audioPath string
+	chunks    []*audio.ChunkInfo
+
+	// Requests made, accounted like chunk requests
+	results []*providers.TranscriptionResult
+	audio   time.Duration
+}
+
+// newLLMMerger returns a merger that reconciles each chunk boundary with
+// the provider, and the reconciler holding the requests it made
+func (t *TranscriberImpl) newLLMMerger(ctx context.Context, provider providers.LLMProvider, req *TranscribeRequest, audioPath string, chunks []*audio.ChunkInfo) (*ChunkMergerImpl, *overlapReconciler) {
+	r := &overlapReconciler{
+		t:         t,
+		ctx:       ctx,
+		provider:  provider,
+		req:       req,
+		audioPath: audioPath,
+		chunks:    chunks,
+	}
+	return &ChunkMergerImpl{strategy: MergeLLM, reconcile: r.reconcile}, r
+}
+
+// reconcile asks the provider to write the text spoken around the
+// boundary between previous and current once, and splices it into merged
+func (r *overlapReconciler) reconcile(previous, current *providers.TranscriptionResult, merged string) (string, error) {
+	if previous.ChunkID < 0 || current.ChunkID >= len(r.chunks) || previous.ChunkID >= current.ChunkID {
+		return "", fmt.Errorf("unknown chunks %d and %d", previous.ChunkID, current.ChunkID)
+	}
+	prevChunk, curChunk := r.chunks[previous.ChunkID], r.chunks[current.ChunkID]
+	start, end := curChunk.Start, prevChunk.End
+	if end <= start {
+		return "", fmt.Errorf("chunks %d and %d do not overlap", previous.ChunkID, current.ChunkID)
+	}
+
+	// The text around the boundary: the overlap's share of each chunk
+	tail := tailStart(merged, reconcileTokens(previous.Text, end-start, prevChunk.Duration))
+	head := headEnd(current.Text, reconcileTokens(current.Text, end-start, curChunk.Duration))
+
+	path := filepath.Join(r.t.scratchDir(r.ctx), fmt.Sprintf("overlap_%d_%d%s", previous.ChunkID, current.ChunkID, filepath.Ext(curChunk.Name())))
+	if err := r.t.chunker.CreateChunkContext(r.ctx, r.audioPath, start, end-start, path); err != nil {
+		return "", fmt.Errorf("failed to cut overlap audio: %w", err)
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	reader, err := r.t.reader.OpenAudio(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open overlap audio: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	prompt := reconcilePrompt(r.req.CustomPrompt, merged[tail:], current.Text[:head])
+	if err := r.t.limiter.Wait(r.ctx, providers.EstimateTokens(end-start, prompt)); err != nil {
+		return "", fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+
+	format := audio.DetectFormat(path)
+	result, err := r.provider.Transcribe(r.ctx, &providers.TranscriptionRequest{
+		Audio:       reader,
+		AudioFormat: string(format),
+		MimeType:    audio.GetMimeType(format),
+		Filename:    filepath.Base(path),
+		Prompt:      prompt,
+		Options: providers.TranscriptionOptions{
+			Temperature:    r.req.Options.Temperature,
+			MaxTokens:      r.t.config.Provider.MaxTokens,
+			TimeoutSeconds: int(r.t.config.Provider.Timeout.Seconds()),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("overlap reconciliation failed: %w", err)
+	}
+	r.results = append(r.results, result)
+	r.audio += end - start
+
+	joined := strings.TrimSpace(result.Text)
+	if joined == "" {
+		return "", fmt.Errorf("provider returned no text for the overlap")
+	}
+	return joinWords(joinWords(merged[:tail], joined), current.Text[head:]), nil
+}
+
+// account adds the reconciliation requests to the usage and cost of the
+// merged result. A nil reconciler does nothing.
+func (r *overlapReconciler) account(result *TranscribeResult) {
+	if r == nil || len(r.results) == 0 {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["merge_requests"] = len(r.results)
+	r.t.addRequestUsage(result, r.results, r.audio)
+}
+
+// reconcileTokens returns how many tokens of a chunk's text to send for an
+// overlap, from the overlap's share of the chunk duration
+func reconcileTokens(text string, overlap, duration time.Duration) int {
+	if duration <= 0 {
+		return minReconcileTokens
+	}
+	n := int(float64(len(tokenize(text))) * reconcileMargin * float64(overlap) / float64(duration))
+	if n < minReconcileTokens {
+		return minReconcileTokens
+	}
+	return n
+}
+
+// tailStart returns the byte offset where the last n tokens of text start
+func tailStart(text string, n int) int {
+	tokens := tokenize(text)
+	if n >= len(tokens) {
+		return 0
+	}
+	return tokens[len(tokens)-n].start
+}
+
+// headEnd returns the byte offset where the first n tokens of text end
+func headEnd(text string, n int) int {
+	tokens := tokenize(text)
+	if n <= 0 {
+		return 0
+	}
+	if n >= len(tokens) {
+		return len(text)
+	}
+	return tokens[n-1].end
+}
+
+// joinWords joins two pieces of text with a space, or without one where
+// either side is in a script written without spaces
+func joinWords(a, b string) string {
+	a, b = strings.TrimRightFunc(a, unicode.IsSpace), strings.TrimLeftFunc(b, unicode.IsSpace)
+	if a == "" || b == "" {
+		return a + b
+	}
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if isCJK(last) || isCJK(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// reconcilePrompt asks for the transcript of the overlap audio, written to
+// continue the end of one chunk and lead into the start of the next
+func reconcilePrompt(original, before, after string) string {
+	var b strings.Builder
+	b.WriteString("The attached audio was transcribed twice, at the end of one part of a recording and at the start of the next. ")
+	b.WriteString("Below are the end of the first transcript and the start of the second. ")
+	b.WriteString("Listen to the audio and write the text from the beginning of the first excerpt to the end of the second excerpt, ")
+	b.WriteString("with everything spoken in the audio appearing exactly once. ")
+	b.WriteString("Keep the wording and formatting of the excerpts outside the audio unchanged. Output only that text.\n")
+	if original != "" {
+		b.WriteString("\nOriginal transcription instructions:\n")
+		b.WriteString(original)
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nEnd of the first transcript:\n%s\n", strings.TrimSpace(before))
+	fmt.Fprintf(&b, "\nStart of the second transcript:\n%s\n", strings.TrimSpace(after))
+	return b.String()
+}
@@ -7,42 +7,55 @@ import (
 	"strings"
 	"time"
 
+	"github.com/eternnoir/gollmscribe/pkg/logger"
 	"github.com/eternnoir/gollmscribe/pkg/providers"
 )
 
-// ChunkMergerImpl implements the ChunkMerger interface
+// ChunkMergerImpl implements the ChunkMerger interface for the
+// text-align, timestamp and llm-assisted strategies
 type ChunkMergerImpl struct {
-	overlapThreshold time.Duration
+	strategy MergeStrategy
+
+	// reconcile, when set, joins the merged text with the next chunk;
+	// when it fails the texts are aligned instead
+	reconcile func(previous, current *providers.TranscriptionResult, merged string) (string, error)
 }
 
-// NewChunkMerger creates a new chunk merger
+// NewChunkMerger creates a new chunk merger using text alignment
 func NewChunkMerger() *ChunkMergerImpl {
-	return &ChunkMergerImpl{
-		overlapThreshold: 30 * time.Second, // Minimum overlap to consider for merging
-	}
+	return &ChunkMergerImpl{strategy: MergeTextAlign}
 }
 
-// MergeChunks combines multiple transcription results with overlap handling
-func (m *ChunkMergerImpl) MergeChunks(chunks []*providers.TranscriptionResult) (*TranscribeResult, error) {
-	if len(chunks) == 0 {
-		return nil, fmt.Errorf("no chunks to merge")
+// ParseMergeStrategy validates a merge strategy name; empty selects the default
+func ParseMergeStrategy(name string) (MergeStrategy, error) {
+	switch strategy := MergeStrategy(strings.ToLower(strings.TrimSpace(name))); strategy {
+	case "", MergeNaive, MergeTimestamp, MergeTextAlign, MergeLLM:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown merge strategy %q (want naive, timestamp, text-align or llm-assisted)", name)
 	}
+}
 
-	// Sort chunks by chunk ID to ensure proper order
-	sort.Slice(chunks, func(i, j int) bool {
-		return chunks[i].ChunkID < chunks[j].ChunkID
-	})
-
-	// Filter out nil chunks
-	validChunks := make([]*providers.TranscriptionResult, 0, len(chunks))
-	for _, chunk := range chunks {
-		if chunk != nil && chunk.Text != "" {
-			validChunks = append(validChunks, chunk)
-		}
+// NewMerger returns the merger for a strategy. MergeLLM needs a provider
+// and is created by the transcriber; here it is an error.
+func NewMerger(strategy MergeStrategy) (ChunkMerger, error) {
+	switch strategy {
+	case "", MergeTextAlign:
+		return NewChunkMerger(), nil
+	case MergeTimestamp:
+		return &ChunkMergerImpl{strategy: MergeTimestamp}, nil
+	case MergeNaive:
+		return NewConcatMerger(), nil
+	default:
+		return nil, fmt.Errorf("unsupported merge strategy %q", strategy)
 	}
+}
 
-	if len(validChunks) == 0 {
-		return nil, fmt.Errorf("no valid chunks to merge")
+// MergeChunks combines multiple transcription results with overlap handling
+func (m *ChunkMergerImpl) MergeChunks(chunks []*providers.TranscriptionResult) (*TranscribeResult, error) {
+	validChunks, err := sortChunks(chunks)
+	if err != nil {
+		return nil, err
 	}
 
 	// If only one chunk, return it directly
@@ -50,101 +63,85 @@ func (m *ChunkMergerImpl) MergeChunks(chunks []*providers.TranscriptionResult) (
 		return m.convertToTranscribeResult(validChunks[0]), nil
 	}
 
+	// Give each speaker the same label in every chunk
+	m.reconcileSpeakers(validChunks)
+
 	// Merge chunks with overlap handling
 	merged := m.mergeWithOverlap(validChunks)
 
 	return merged, nil
 }
 
-// DetectOverlap identifies overlapping content between chunks
-func (m *ChunkMergerImpl) DetectOverlap(chunk1, chunk2 *providers.TranscriptionResult) (overlap1, overlap2 time.Duration, err error) {
+// DetectOverlap returns the time range transcribed by both chunks: from the
+// start of chunk2's first segment to the end of chunk1's last segment. It
+// returns zeros when either chunk has no segments or they do not overlap.
+func (m *ChunkMergerImpl) DetectOverlap(chunk1, chunk2 *providers.TranscriptionResult) (overlapStart, overlapEnd time.Duration, err error) {
 	if len(chunk1.Segments) == 0 || len(chunk2.Segments) == 0 {
 		return 0, 0, nil
 	}
 
-	// Get the last segments from chunk1 and first segments from chunk2
-	lastSegments1 := chunk1.Segments
-	firstSegments2 := chunk2.Segments
-
-	// Find potential overlap by comparing text content
-	overlapStart := time.Duration(0)
-	overlapEnd := time.Duration(0)
-
-	// Look for text similarity in the last part of chunk1 and first part of chunk2
-	chunk1LastPart := m.getLastTextPart(chunk1.Text, 100)   // Last 100 characters
-	chunk2FirstPart := m.getFirstTextPart(chunk2.Text, 100) // First 100 characters
-
-	similarity := m.calculateTextSimilarity(chunk1LastPart, chunk2FirstPart)
-
-	if similarity > 0.3 { // 30% similarity threshold
-		// Find the actual time boundaries
-		if len(lastSegments1) > 0 && len(firstSegments2) > 0 {
-			// Look for segment overlap
-			for i := len(lastSegments1) - 1; i >= 0; i-- {
-				for j := 0; j < len(firstSegments2); j++ {
-					seg1 := lastSegments1[i]
-					seg2 := firstSegments2[j]
-
-					if m.segmentsOverlap(seg1, seg2) {
-						overlapStart = seg1.Start
-						overlapEnd = seg2.End
-						break
-					}
-				}
-				if overlapEnd > 0 {
-					break
-				}
-			}
-		}
+	overlapStart = chunk2.Segments[0].Start
+	overlapEnd = chunk1.Segments[len(chunk1.Segments)-1].End
+	if overlapEnd <= overlapStart {
+		return 0, 0, nil
 	}
-
 	return overlapStart, overlapEnd, nil
 }
 
-// mergeWithOverlap merges chunks while handling overlapping content
+// mergeWithOverlap merges chunks, keeping content transcribed in the
+// overlap between two chunks only once. Segments are cut at the middle of
+// the overlapping time range, where both chunks had the most context.
+// Text is cut at the longest run of words shared by the end of the merged
+// text and the start of the next chunk; without such a run it is appended.
+// The timestamp strategy instead rebuilds the text from the kept segments
+// when every chunk has them, and the llm-assisted strategy reconciles the
+// text first.
+//
+// Merging streams through the chunks in one pass, so recordings of
+// hundreds of chunks merge in linear time: only the end of the merged text
+// a boundary can change (see mergeWindowStart) and the segments of the
+// previous chunk are revisited, and the rest is written once to
+// preallocated buffers.
 func (m *ChunkMergerImpl) mergeWithOverlap(chunks []*providers.TranscriptionResult) *TranscribeResult {
-	var allSegments []providers.TranscriptionSegment
-	var fullText strings.Builder
-	var totalDuration time.Duration
+	textSize, segmentCount := 0, 0
+	for _, chunk := range chunks {
+		textSize += len(chunk.Text) + 1
+		segmentCount += len(chunk.Segments)
+	}
 
-	// Process first chunk completely
 	firstChunk := chunks[0]
+	allSegments := make([]providers.TranscriptionSegment, 0, segmentCount)
 	allSegments = append(allSegments, firstChunk.Segments...)
-	fullText.WriteString(firstChunk.Text)
-
+	var committed strings.Builder
+	committed.Grow(textSize)
+	window := firstChunk.Text // End of the merged text, after committed
+	var totalDuration time.Duration
 	if len(firstChunk.Segments) > 0 {
 		totalDuration = firstChunk.Segments[len(firstChunk.Segments)-1].End
 	}
 
-	// Process subsequent chunks with overlap detection
+	aligned := 0
+	previousFrom := 0 // Index of the previous chunk's first kept segment
 	for i := 1; i < len(chunks); i++ {
 		currentChunk := chunks[i]
-		previousChunk := chunks[i-1]
 
-		// Detect overlap
-		overlapStart, overlapEnd, err := m.DetectOverlap(previousChunk, currentChunk)
-		switch {
-		case err != nil:
-			// If overlap detection fails, just append
-			allSegments = append(allSegments, currentChunk.Segments...)
-			fullText.WriteString(" ")
-			fullText.WriteString(currentChunk.Text)
-		case overlapEnd > overlapStart && overlapEnd-overlapStart > m.overlapThreshold:
-			// Handle overlap by removing duplicated content
-			mergedSegments := m.mergeOverlappingSegments(allSegments, currentChunk.Segments, overlapStart, overlapEnd)
-			allSegments = mergedSegments
-
-			// For text, remove overlap and merge
-			cleanText := m.removeOverlapFromText(currentChunk.Text, overlapStart, overlapEnd)
-			if cleanText != "" {
-				fullText.WriteString(" ")
-				fullText.WriteString(cleanText)
-			}
-		default:
-			// No significant overlap, just append
+		overlapStart, overlapEnd, _ := m.DetectOverlap(chunks[i-1], currentChunk)
+		if overlapEnd > overlapStart {
+			cut := overlapStart + (overlapEnd-overlapStart)/2
+			allSegments = allSegments[:previousFrom+len(segmentsBefore(allSegments[previousFrom:], cut))]
+			previousFrom = len(allSegments)
+			allSegments = append(allSegments, segmentsFrom(currentChunk.Segments, cut)...)
+		} else {
+			previousFrom = len(allSegments)
 			allSegments = append(allSegments, currentChunk.Segments...)
-			fullText.WriteString(" ")
-			fullText.WriteString(currentChunk.Text)
+		}
+
+		start := mergeWindowStart(window, chunks[i-1].Text)
+		committed.WriteString(window[:start])
+		var joined bool
+		window, joined = m.joinText(chunks[i-1], currentChunk, window[start:])
+		if joined {
+			aligned++
 		}
 
 		// Update total duration
@@ -156,109 +153,179 @@ func (m *ChunkMergerImpl) mergeWithOverlap(chunks []*providers.TranscriptionResu
 		}
 	}
 
-	// Create final result
-	result := &TranscribeResult{
-		Text:     strings.TrimSpace(fullText.String()),
-		Segments: allSegments,
-		Duration: totalDuration,
-		Metadata: make(map[string]interface{}),
+	committed.WriteString(window)
+	fullText := committed.String()
+	if m.strategy == MergeTimestamp && allHaveSegments(chunks) {
+		fullText = segmentsText(allSegments)
 	}
 
-	// Merge metadata from all chunks
-	for _, chunk := range chunks {
-		if chunk.Language != "" {
-			result.Language = chunk.Language
-		}
-		// Merge metadata
-		for k, v := range chunk.Metadata {
-			result.Metadata[k] = v
-		}
-	}
-
-	result.Metadata["merged_chunks"] = len(chunks)
-	result.Metadata["merge_method"] = "overlap_detection"
-
+	result := newMergedResult(chunks, fullText, allSegments, totalDuration, string(m.strategy))
+	result.Metadata["aligned_boundaries"] = aligned
 	return result
 }
 
-// mergeOverlappingSegments merges segments while handling overlap
-func (m *ChunkMergerImpl) mergeOverlappingSegments(existingSegments, newSegments []providers.TranscriptionSegment, overlapStart, overlapEnd time.Duration) []providers.TranscriptionSegment {
-	var result []providers.TranscriptionSegment
+// mergeWindowStart returns the byte offset in the end of the merged text
+// from which joining the next chunk may change it: the alignOverlap window,
+// or the share of the previous chunk the llm-assisted strategy sends, with
+// one token more so the text before stays apart from the joined text.
+// Text before it is final.
+func mergeWindowStart(merged, previous string) int {
+	n := alignWindow
+	if sent := int(float64(len(tokenize(previous)))*reconcileMargin) + 1; sent > n {
+		n = sent
+	}
+	return tailStart(merged, n+1)
+}
 
-	// Add all existing segments that end before the overlap
-	for _, seg := range existingSegments {
-		if seg.End <= overlapStart {
-			result = append(result, seg)
+// joinText appends the next chunk to the merged text, reconciling or
+// aligning the overlap between them. It reports whether duplicated text
+// was found and removed.
+func (m *ChunkMergerImpl) joinText(previous, current *providers.TranscriptionResult, merged string) (string, bool) {
+	if m.reconcile != nil {
+		text, err := m.reconcile(previous, current, merged)
+		if err == nil {
+			return text, true
 		}
+		logger.WithComponent("merger").Warn().Err(err).
+			Int("chunk_id", current.ChunkID).
+			Msg("Overlap reconciliation failed, aligning text instead")
+	}
+	if prevEnd, curStart, ok := alignOverlap(merged, current.Text); ok {
+		return merged[:prevEnd] + current.Text[curStart:], true
 	}
+	return merged + " " + current.Text, false
+}
 
-	// Add new segments that start after the overlap
-	for _, seg := range newSegments {
-		if seg.Start >= overlapEnd {
-			result = append(result, seg)
+// allHaveSegments reports whether every chunk has timestamped segments
+func allHaveSegments(chunks []*providers.TranscriptionResult) bool {
+	for _, chunk := range chunks {
+		if len(chunk.Segments) == 0 {
+			return false
 		}
 	}
+	return true
+}
 
-	// Sort by start time
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Start < result[j].Start
-	})
-
-	return result
+// segmentsText joins the text of segments
+func segmentsText(segments []providers.TranscriptionSegment) string {
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, " ")
 }
 
-// Helper functions
+// sortChunks orders chunks by chunk ID and drops those without text
+func sortChunks(chunks []*providers.TranscriptionResult) ([]*providers.TranscriptionResult, error) {
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no chunks to merge")
+	}
 
-func (m *ChunkMergerImpl) getLastTextPart(text string, length int) string {
-	if len(text) <= length {
-		return text
+	// Sort chunks by chunk ID to ensure proper order
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].ChunkID < chunks[j].ChunkID
+	})
+
+	// Filter out nil chunks
+	validChunks := make([]*providers.TranscriptionResult, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk != nil && chunk.Text != "" {
+			validChunks = append(validChunks, chunk)
+		}
 	}
-	return text[len(text)-length:]
-}
 
-func (m *ChunkMergerImpl) getFirstTextPart(text string, length int) string {
-	if len(text) <= length {
-		return text
+	if len(validChunks) == 0 {
+		return nil, fmt.Errorf("no valid chunks to merge")
 	}
-	return text[:length]
+	return validChunks, nil
 }
 
-func (m *ChunkMergerImpl) calculateTextSimilarity(text1, text2 string) float64 {
-	if text1 == "" || text2 == "" {
-		return 0
+// newMergedResult builds the merged result, carrying over the language
+// and metadata of the chunks
+func newMergedResult(chunks []*providers.TranscriptionResult, text string, segments []providers.TranscriptionSegment, duration time.Duration, method string) *TranscribeResult {
+	result := &TranscribeResult{
+		Text:     strings.TrimSpace(text),
+		Segments: segments,
+		Duration: duration,
+		Metadata: make(map[string]interface{}),
 	}
 
-	// Simple similarity based on common words
-	words1 := strings.Fields(strings.ToLower(text1))
-	words2 := strings.Fields(strings.ToLower(text2))
-
-	if len(words1) == 0 || len(words2) == 0 {
-		return 0
+	// Merge metadata from all chunks
+	for _, chunk := range chunks {
+		if chunk.Language != "" {
+			result.Language = chunk.Language
+		}
+		for k, v := range chunk.Metadata {
+			result.Metadata[k] = v
+		}
 	}
 
-	wordSet1 := make(map[string]bool)
-	for _, word := range words1 {
-		wordSet1[word] = true
+	result.Metadata["merged_chunks"] = len(chunks)
+	result.Metadata["merge_method"] = method
+	return result
+}
+
+// ConcatMerger joins chunks end to end without looking for duplicated
+// content. It is used when chunks do not transcribe the same audio, such
+// as chunks whose overlap is context only (see audio.ChunkInfo.Context).
+type ConcatMerger struct{}
+
+// NewConcatMerger creates a merger that concatenates chunks
+func NewConcatMerger() *ConcatMerger {
+	return &ConcatMerger{}
+}
+
+// MergeChunks joins the text and segments of chunks in order
+func (m *ConcatMerger) MergeChunks(chunks []*providers.TranscriptionResult) (*TranscribeResult, error) {
+	validChunks, err := sortChunks(chunks)
+	if err != nil {
+		return nil, err
 	}
 
-	commonWords := 0
-	for _, word := range words2 {
-		if wordSet1[word] {
-			commonWords++
+	texts := make([]string, 0, len(validChunks))
+	segmentCount := 0
+	for _, chunk := range validChunks {
+		segmentCount += len(chunk.Segments)
+	}
+	segments := make([]providers.TranscriptionSegment, 0, segmentCount)
+	var duration time.Duration
+	for _, chunk := range validChunks {
+		texts = append(texts, strings.TrimSpace(chunk.Text))
+		segments = append(segments, chunk.Segments...)
+		if len(chunk.Segments) > 0 {
+			duration = maxDuration(duration, chunk.Segments[len(chunk.Segments)-1].End)
 		}
 	}
+	return newMergedResult(validChunks, strings.Join(texts, " "), segments, duration, string(MergeNaive)), nil
+}
 
-	return float64(commonWords) / float64(len(words2))
+// DetectOverlap reports no overlap; chunks are assumed not to repeat audio
+func (m *ConcatMerger) DetectOverlap(_, _ *providers.TranscriptionResult) (overlapStart, overlapEnd time.Duration, err error) {
+	return 0, 0, nil
 }
 
-func (m *ChunkMergerImpl) segmentsOverlap(seg1, seg2 providers.TranscriptionSegment) bool {
-	return seg1.End > seg2.Start && seg2.End > seg1.Start
+// segmentsBefore returns the segments starting before cut
+func segmentsBefore(segments []providers.TranscriptionSegment, cut time.Duration) []providers.TranscriptionSegment {
+	kept := segments[:0]
+	for _, seg := range segments {
+		if seg.Start < cut {
+			kept = append(kept, seg)
+		}
+	}
+	return kept
 }
 
-func (m *ChunkMergerImpl) removeOverlapFromText(text string, overlapStart, overlapEnd time.Duration) string {
-	// This is a simplified implementation
-	// In practice, you might want to use the segment timing to precisely remove overlap
-	return text
+// segmentsFrom returns the segments starting at or after cut
+func segmentsFrom(segments []providers.TranscriptionSegment, cut time.Duration) []providers.TranscriptionSegment {
+	var kept []providers.TranscriptionSegment
+	for _, seg := range segments {
+		if seg.Start >= cut {
+			kept = append(kept, seg)
+		}
+	}
+	return kept
 }
 
 func (m *ChunkMergerImpl) convertToTranscribeResult(chunk *providers.TranscriptionResult) *TranscribeResult {
@@ -275,6 +342,9 @@ func (m *ChunkMergerImpl) convertToTranscribeResult(chunk *providers.Transcripti
 
 // ToJSON converts the result to JSON format
 func (r *TranscribeResult) ToJSON(pretty bool) ([]byte, error) {
+	if r.SchemaVersion == 0 {
+		r.SchemaVersion = ResultSchemaVersion
+	}
 	if pretty {
 		return json.MarshalIndent(r, "", "  ")
 	}
@@ -283,8 +353,18 @@ func (r *TranscribeResult) ToJSON(pretty bool) ([]byte, error) {
 
 // ToSRT converts the result to SRT subtitle format
 func (r *TranscribeResult) ToSRT() ([]byte, error) {
+	return r.toSRT(false), nil
+}
+
+// ToBilingualSRT converts the result to SRT subtitles with the translation
+// of each cue on a second line, below the original
+func (r *TranscribeResult) ToBilingualSRT() ([]byte, error) {
+	return r.toSRT(true), nil
+}
+
+func (r *TranscribeResult) toSRT(bilingual bool) []byte {
 	if len(r.Segments) == 0 {
-		return []byte(r.Text), nil
+		return []byte(r.Text)
 	}
 
 	var srt strings.Builder
@@ -299,10 +379,92 @@ func (r *TranscribeResult) ToSRT() ([]byte, error) {
 			text = fmt.Sprintf("%s: %s", segment.SpeakerID, text)
 		}
 		srt.WriteString(text)
+		writeTranslation(&srt, segment, bilingual)
 		srt.WriteString("\n\n")
 	}
 
-	return []byte(srt.String()), nil
+	return []byte(srt.String())
+}
+
+// ToVTT converts the result to WebVTT subtitle format
+func (r *TranscribeResult) ToVTT() ([]byte, error) {
+	return r.toVTT(false), nil
+}
+
+// ToBilingualVTT converts the result to WebVTT subtitles with the
+// translation of each cue on a second line, below the original
+func (r *TranscribeResult) ToBilingualVTT() ([]byte, error) {
+	return r.toVTT(true), nil
+}
+
+func (r *TranscribeResult) toVTT(bilingual bool) []byte {
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+
+	if len(r.Segments) == 0 {
+		vtt.WriteString(r.Text)
+		vtt.WriteString("\n")
+		return []byte(vtt.String())
+	}
+
+	for _, segment := range r.Segments {
+		vtt.WriteString(fmt.Sprintf("%s --> %s\n",
+			formatVTTTime(segment.Start),
+			formatVTTTime(segment.End)))
+
+		text := segment.Text
+		if segment.SpeakerID != "" {
+			text = fmt.Sprintf("<v %s>%s", segment.SpeakerID, text)
+		}
+		vtt.WriteString(text)
+		writeTranslation(&vtt, segment, bilingual)
+		vtt.WriteString("\n\n")
+	}
+
+	return []byte(vtt.String())
+}
+
+// writeTranslation adds the translation of a cue on its own line. Cues
+// without one keep only the original, and blank lines would end the cue.
+func writeTranslation(b *strings.Builder, segment providers.TranscriptionSegment, bilingual bool) {
+	translation := strings.Join(strings.Fields(segment.Translation), " ")
+	if !bilingual || translation == "" {
+		return
+	}
+	b.WriteString("\n")
+	b.WriteString(translation)
+}
+
+// ToChapters formats the chapters as YouTube-style chapter lines, e.g.
+// "02:15 Budget review", with hours only when the recording needs them
+func (r *TranscribeResult) ToChapters() ([]byte, error) {
+	if len(r.Chapters) == 0 {
+		return nil, fmt.Errorf("result has no chapters")
+	}
+
+	hours := r.Chapters[len(r.Chapters)-1].Start >= time.Hour
+	var text strings.Builder
+	for _, chapter := range r.Chapters {
+		text.WriteString(formatChapterTime(chapter.Start, hours))
+		text.WriteString(" ")
+		text.WriteString(chapter.Title)
+		text.WriteString("\n")
+	}
+	return []byte(text.String()), nil
+}
+
+// formatChapterTime formats a chapter start as MM:SS or H:MM:SS
+func formatChapterTime(d time.Duration, hours bool) string {
+	seconds := int(d.Seconds())
+	if hours {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// formatVTTTime formats duration for WebVTT format
+func formatVTTTime(d time.Duration) string {
+	return strings.Replace(formatSRTTime(d), ",", ".", 1)
 }
 
 // formatSRTTime formats duration for SRT format
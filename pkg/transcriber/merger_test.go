@@ -0,0 +1,300 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestAlignOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     string
+		ok       bool
+	}{
+		{
+			name:     "words",
+			previous: "We started the meeting. Next we will review the budget for the coming quar",
+			current:  "Next, we will review the budget for the coming quarter and then talk about hiring.",
+			want:     "We started the meeting. Next we will review the budget for the coming quarter and then talk about hiring.",
+			ok:       true,
+		},
+		{
+			name:     "cjk",
+			previous: "今天我們討論預算。接下來我們要檢討下一季的預算規",
+			current:  "接下來我們要檢討下一季的預算規劃，然後談招募。",
+			want:     "今天我們討論預算。接下來我們要檢討下一季的預算規劃，然後談招募。",
+			ok:       true,
+		},
+		{
+			name:     "short match ignored",
+			previous: "thank you all",
+			current:  "thank you for coming",
+			ok:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevEnd, curStart, ok := alignOverlap(tt.previous, tt.current)
+			if ok != tt.ok {
+				t.Fatalf("alignOverlap() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok {
+				if got := tt.previous[:prevEnd] + tt.current[curStart:]; got != tt.want {
+					t.Errorf("merged = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeChunksRemovesOverlap(t *testing.T) {
+	chunks := []*providers.TranscriptionResult{
+		{ChunkID: 0, Text: "One two three four. Five six seven eight nine ten.", Segments: []providers.TranscriptionSegment{
+			{Text: "One two three four.", Start: 0, End: 50 * time.Second},
+			{Text: "Five six seven eight", Start: 50 * time.Second, End: 56 * time.Second},
+			{Text: "nine ten.", Start: 56 * time.Second, End: 60 * time.Second},
+		}},
+		{ChunkID: 1, Text: "Five six seven eight nine ten. Eleven twelve.", Segments: []providers.TranscriptionSegment{
+			{Text: "Five six seven eight", Start: 50 * time.Second, End: 56 * time.Second},
+			{Text: "nine ten.", Start: 56 * time.Second, End: 60 * time.Second},
+			{Text: "Eleven twelve.", Start: 60 * time.Second, End: 70 * time.Second},
+		}},
+	}
+
+	result, err := NewChunkMerger().MergeChunks(chunks)
+	if err != nil {
+		t.Fatalf("MergeChunks() error = %v", err)
+	}
+
+	if want := "One two three four. Five six seven eight nine ten. Eleven twelve."; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	var texts []string
+	for _, segment := range result.Segments {
+		texts = append(texts, segment.Text)
+	}
+	if len(texts) != 4 || texts[1] != "Five six seven eight" || texts[2] != "nine ten." || texts[3] != "Eleven twelve." {
+		t.Errorf("segments = %q", texts)
+	}
+	if result.Duration != 70*time.Second {
+		t.Errorf("Duration = %v, want 70s", result.Duration)
+	}
+}
+
+// longRecordingChunks returns n chunks of 300 words each, sharing 30
+// words with the next, with a segment of 10 words every 5 seconds, and the
+// text of the recording
+func longRecordingChunks(n int) ([]*providers.TranscriptionResult, string) {
+	const words, overlap, perSegment = 300, 30, 10
+	word := func(k int) string { return fmt.Sprintf("word%d", k) }
+
+	chunks := make([]*providers.TranscriptionResult, n)
+	for i := range chunks {
+		first := i * (words - overlap)
+		chunk := &providers.TranscriptionResult{ChunkID: i}
+		texts := make([]string, 0, words)
+		for k := first; k < first+words; k += perSegment {
+			segment := make([]string, 0, perSegment)
+			for j := k; j < k+perSegment; j++ {
+				segment = append(segment, word(j))
+			}
+			texts = append(texts, segment...)
+			chunk.Segments = append(chunk.Segments, providers.TranscriptionSegment{
+				Text:  strings.Join(segment, " "),
+				Start: time.Duration(k) * 500 * time.Millisecond,
+				End:   time.Duration(k+perSegment) * 500 * time.Millisecond,
+			})
+		}
+		chunk.Text = strings.Join(texts, " ")
+		chunks[i] = chunk
+	}
+
+	all := make([]string, 0, (n-1)*(words-overlap)+words)
+	for k := 0; k < (n-1)*(words-overlap)+words; k++ {
+		all = append(all, word(k))
+	}
+	return chunks, strings.Join(all, " ")
+}
+
+func TestMergeManyChunks(t *testing.T) {
+	for _, strategy := range []MergeStrategy{MergeTextAlign, MergeTimestamp} {
+		chunks, want := longRecordingChunks(200)
+		merger, err := NewMerger(strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := merger.MergeChunks(chunks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Text != want {
+			t.Errorf("%s: merged text of %d bytes differs from the recording's %d", strategy, len(result.Text), len(want))
+		}
+		if got := segmentsText(result.Segments); got != want {
+			t.Errorf("%s: merged segments repeat or miss words", strategy)
+		}
+		if result.Metadata["aligned_boundaries"] != 199 {
+			t.Errorf("%s: aligned_boundaries = %v, want 199", strategy, result.Metadata["aligned_boundaries"])
+		}
+	}
+}
+
+func BenchmarkMergeChunks(b *testing.B) {
+	for _, n := range []int{500, 2000} {
+		chunks, _ := longRecordingChunks(n)
+		b.Run(fmt.Sprintf("%d chunks", n), func(b *testing.B) {
+			merger := NewChunkMerger()
+			for i := 0; i < b.N; i++ {
+				if _, err := merger.MergeChunks(chunks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestTimestampMerger(t *testing.T) {
+	merger, err := NewMerger(MergeTimestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := merger.MergeChunks([]*providers.TranscriptionResult{
+		{ChunkID: 0, Text: "a b", Segments: []providers.TranscriptionSegment{
+			{Text: "a", Start: 0, End: 50 * time.Second}, {Text: "b", Start: 50 * time.Second, End: 60 * time.Second},
+		}},
+		{ChunkID: 1, Text: "b c", Segments: []providers.TranscriptionSegment{
+			{Text: "b", Start: 50 * time.Second, End: 60 * time.Second}, {Text: "c", Start: 60 * time.Second, End: 70 * time.Second},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "a b c" || result.Metadata["merge_method"] != "timestamp" {
+		t.Errorf("result = %q, %v", result.Text, result.Metadata["merge_method"])
+	}
+
+	if _, err := ParseMergeStrategy("fancy"); err == nil {
+		t.Error("ParseMergeStrategy(fancy) succeeded")
+	}
+	if _, err := NewMerger(MergeLLM); err == nil {
+		t.Error("NewMerger(llm-assisted) succeeded without a provider")
+	}
+}
+
+// reconcilingProvider answers overlap reconciliation prompts
+type reconcilingProvider struct {
+	namedProvider
+	prompts []string
+	fail    bool
+}
+
+func (p *reconcilingProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	p.prompts = append(p.prompts, req.Prompt)
+	if p.fail {
+		return nil, errors.New("unavailable")
+	}
+	return &providers.TranscriptionResult{
+		Text:     "seven eight nine ten eleven",
+		Metadata: map[string]interface{}{providers.MetadataTotalTokens: 40},
+	}, nil
+}
+
+// fileChunker writes placeholder chunk files
+type fileChunker struct{ audio.Chunker }
+
+func (fileChunker) CreateChunkContext(_ context.Context, _ string, _, _ time.Duration, outputPath string) error {
+	return os.WriteFile(outputPath, []byte("audio"), 0o600)
+}
+
+func TestLLMMerger(t *testing.T) {
+	provider := &reconcilingProvider{namedProvider: namedProvider{"gemini"}}
+	tr := NewTranscriber(provider, &config.Config{})
+	tr.tempDir = t.TempDir()
+	tr.chunker = fileChunker{}
+	chunks := []*audio.ChunkInfo{
+		{Index: 0, Start: 0, End: 60 * time.Second, Duration: 60 * time.Second, TempFilePath: "a.mp3"},
+		{Index: 1, Start: 50 * time.Second, End: 110 * time.Second, Duration: 60 * time.Second, TempFilePath: "b.mp3"},
+	}
+	req := &TranscribeRequest{FilePath: "talk.mp3", Options: TranscribeOptions{MergeStrategy: MergeLLM}}
+
+	merger, reconciler, err := tr.chunkMerger(context.Background(), provider, req, "talk.mp3", chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := func() []*providers.TranscriptionResult {
+		return []*providers.TranscriptionResult{
+			{ChunkID: 0, Text: "one two three four five six seven eight nine"},
+			{ChunkID: 1, Text: "eigth nine tenn eleven twelve thirteen"},
+		}
+	}
+
+	result, err := merger.MergeChunks(results())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both chunks are shorter than the minimum window, so all text is reconciled
+	if result.Text != "seven eight nine ten eleven" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "eigth nine tenn") {
+		t.Errorf("prompts = %q", provider.prompts)
+	}
+	reconciler.account(result)
+	if result.Usage == nil || result.Usage.TotalTokens != 40 || result.Metadata["merge_requests"] != 1 {
+		t.Errorf("usage = %+v, metadata = %v", result.Usage, result.Metadata)
+	}
+
+	// Failed reconciliation falls back to alignment, here finding nothing
+	provider.fail = true
+	result, err = merger.MergeChunks(results())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "one two three four five six seven eight nine eigth nine tenn eleven twelve thirteen" {
+		t.Errorf("fallback Text = %q", result.Text)
+	}
+}
+
+func TestBilingualSubtitles(t *testing.T) {
+	result := &TranscribeResult{
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Good morning.", Start: 0, End: 2 * time.Second, SpeakerID: "Alice", Translation: "Buenos días."},
+			{Text: "Let's begin.", Start: 2 * time.Second, End: 4 * time.Second},
+		},
+	}
+
+	srt, err := result.ToBilingualSRT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,000\nAlice: Good morning.\nBuenos días.\n\n" +
+		"2\n00:00:02,000 --> 00:00:04,000\nLet's begin.\n\n"
+	if string(srt) != want {
+		t.Errorf("ToBilingualSRT() = %q, want %q", srt, want)
+	}
+
+	vtt, err := result.ToBilingualVTT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(vtt), "<v Alice>Good morning.\nBuenos días.\n\n") {
+		t.Errorf("ToBilingualVTT() = %q", vtt)
+	}
+
+	plain, _ := result.ToSRT()
+	if strings.Contains(string(plain), "Buenos días.") {
+		t.Errorf("ToSRT() includes the translation: %q", plain)
+	}
+}
@@ -0,0 +1,62 @@
+package transcriber
+
+import "strings"
+
+// AddNotes appends operator notes to the result's metadata, skipping
+// blank ones and ones it already has
+func AddNotes(result *TranscribeResult, notes ...string) {
+	existing := result.Notes()
+	for _, note := range notes {
+		note = strings.TrimSpace(note)
+		if note == "" || containsNote(existing, note) {
+			continue
+		}
+		existing = append(existing, note)
+	}
+	if len(existing) == 0 {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataNotes] = existing
+}
+
+// Notes returns the operator notes of the result, also when it was read
+// back from JSON
+func (r *TranscribeResult) Notes() []string {
+	switch notes := r.Metadata[MetadataNotes].(type) {
+	case []string:
+		return append([]string(nil), notes...)
+	case []interface{}:
+		var strs []string
+		for _, note := range notes {
+			if s, ok := note.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// MatchNotes reports whether any note contains query, ignoring case
+func MatchNotes(notes []string, query string) bool {
+	query = strings.ToLower(query)
+	for _, note := range notes {
+		if strings.Contains(strings.ToLower(note), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNote reports whether notes has note
+func containsNote(notes []string, note string) bool {
+	for _, n := range notes {
+		if n == note {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	result := &TranscribeResult{}
+	AddNotes(result, "board meeting", " ", "confidential")
+	AddNotes(result, "confidential")
+	want := []string{"board meeting", "confidential"}
+	if got := result.Notes(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Notes() = %v, want %v", got, want)
+	}
+
+	// Notes survive a JSON round trip
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded TranscribeResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Notes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded Notes() = %v, want %v", got, want)
+	}
+
+	if !MatchNotes(want, "BOARD") || MatchNotes(want, "budget") {
+		t.Error("MatchNotes() does not match case-insensitively")
+	}
+	empty := &TranscribeResult{}
+	AddNotes(empty)
+	if empty.Metadata != nil {
+		t.Errorf("AddNotes() without notes set metadata %v", empty.Metadata)
+	}
+}
@@ -0,0 +1,222 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// flakyProvider fails each chunk file a set number of times
+type flakyProvider struct {
+	namedProvider
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func (p *flakyProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures[req.Filename] > 0 {
+		p.failures[req.Filename]--
+		return nil, errors.New("unparseable response")
+	}
+	return &providers.TranscriptionResult{Text: req.Filename}, nil
+}
+
+func testChunks(t *testing.T, n int) []*audio.ChunkInfo {
+	t.Helper()
+	dir := t.TempDir()
+	chunks := make([]*audio.ChunkInfo, n)
+	for i := range chunks {
+		path := filepath.Join(dir, string(rune('a'+i))+".mp3")
+		if err := os.WriteFile(path, []byte("audio"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		chunks[i] = &audio.ChunkInfo{
+			Index:        i,
+			Start:        time.Duration(i) * time.Minute,
+			End:          time.Duration(i+1) * time.Minute,
+			Duration:     time.Minute,
+			TempFilePath: path,
+		}
+	}
+	return chunks
+}
+
+func TestChunkRetries(t *testing.T) {
+	defer func(d time.Duration) { chunkRetryDelay = d }(chunkRetryDelay)
+	chunkRetryDelay = time.Millisecond
+
+	provider := &flakyProvider{namedProvider: namedProvider{"flaky"}, failures: map[string]int{"b.mp3": 2}}
+	tr := NewTranscriber(provider, &config.Config{})
+	chunks := testChunks(t, 3)
+
+	req := &TranscribeRequest{FilePath: "talk.mp3", Options: TranscribeOptions{ChunkRetries: 1}}
+	if _, _, err := tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil); err == nil {
+		t.Fatal("transcribeChunks() with too few retries succeeded")
+	}
+
+	provider.failures["b.mp3"] = 2
+	req.Options.ChunkRetries = 2
+	results, gaps, err := tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil)
+	if err != nil || len(gaps) != 0 {
+		t.Fatalf("transcribeChunks() = %v, %v", gaps, err)
+	}
+	if results[1] == nil || results[1].Text != "b.mp3" {
+		t.Errorf("results[1] = %+v, want retried chunk", results[1])
+	}
+}
+
+func TestAllowPartial(t *testing.T) {
+	provider := &flakyProvider{namedProvider: namedProvider{"flaky"}, failures: map[string]int{"b.mp3": 1}}
+	tr := NewTranscriber(provider, &config.Config{})
+	chunks := testChunks(t, 3)
+
+	req := &TranscribeRequest{FilePath: "talk.mp3", Options: TranscribeOptions{AllowPartial: true}}
+	results, gaps, err := tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 1 || gaps[0].Chunk != 1 || gaps[0].Start != time.Minute || gaps[0].End != 2*time.Minute {
+		t.Fatalf("gaps = %+v, want chunk 1 from 1m to 2m", gaps)
+	}
+	if results[1] != nil || len(completedChunks(results)) != 2 {
+		t.Errorf("results = %+v, want chunks 0 and 2", results)
+	}
+
+	result := &TranscribeResult{Metadata: map[string]interface{}{MetadataGaps: gaps}}
+	data, err := result.ToJSON(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ParseResult(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Gaps(); len(got) != 1 || got[0] != gaps[0] {
+		t.Errorf("Gaps() after JSON = %+v, want %+v", got, gaps)
+	}
+
+	// A file with every chunk failed is still an error
+	provider.failures = map[string]int{"a.mp3": 1, "b.mp3": 1, "c.mp3": 1}
+	if _, _, err := tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, nil); err == nil {
+		t.Error("transcribeChunks() with no chunks left succeeded")
+	}
+}
+
+func TestTrimmedAndSilentChunks(t *testing.T) {
+	provider := &flakyProvider{namedProvider: namedProvider{"flaky"}}
+	tr := NewTranscriber(provider, &config.Config{})
+
+	silent := &audio.ChunkInfo{Index: 0, Start: 0, End: time.Minute, Duration: time.Minute, Silent: true}
+	result, err := tr.transcribeChunk(context.Background(), provider, silent, &TranscribeRequest{}, nil)
+	if err != nil || result.Text != "" || result.Metadata[MetadataSilent] != true {
+		t.Fatalf("transcribeChunk(silent) = %+v, %v", result, err)
+	}
+
+	// Timestamps from the trimmed chunk file map back to the source
+	trimmed := &audio.ChunkInfo{Start: 10 * time.Minute, TrimmedStart: 5 * time.Second}
+	chunkResult := &providers.TranscriptionResult{Segments: []providers.TranscriptionSegment{{Start: time.Second, End: 3 * time.Second}}}
+	tr.adjustTimestamps(chunkResult, trimmed)
+	if seg := chunkResult.Segments[0]; seg.Start != 10*time.Minute+6*time.Second || seg.End != 10*time.Minute+8*time.Second {
+		t.Errorf("adjusted segment = %v-%v, want 10m6s-10m8s", seg.Start, seg.End)
+	}
+}
+
+// seenProvider reports each chunk file it is sent
+type seenProvider struct {
+	namedProvider
+	seen chan string
+}
+
+func (p *seenProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	p.seen <- req.Filename
+	return &providers.TranscriptionResult{Text: req.Filename}, nil
+}
+
+func TestTranscribeChunksAsCreated(t *testing.T) {
+	provider := &seenProvider{namedProvider: namedProvider{"gemini"}, seen: make(chan string, 3)}
+	tr := NewTranscriber(provider, &config.Config{})
+	chunks := testChunks(t, 3)
+	req := &TranscribeRequest{FilePath: "talk.mp3", Options: TranscribeOptions{Workers: 2}}
+
+	ready := make(chan *audio.ChunkInfo, len(chunks))
+	done := make(chan error, 1)
+	var results []*providers.TranscriptionResult
+	go func() {
+		var err error
+		results, _, err = tr.transcribeChunks(context.Background(), provider, chunks, req, nil, nil, nil, nil, ready)
+		done <- err
+	}()
+
+	// The first chunk is transcribed before the others are ready
+	ready <- chunks[0]
+	select {
+	case name := <-provider.seen:
+		if name != "a.mp3" {
+			t.Fatalf("first transcribed %s, want a.mp3", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first chunk was not transcribed before the rest were ready")
+	}
+	ready <- chunks[1]
+	close(ready)
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if results[0] == nil || results[1] == nil || results[2] != nil {
+		t.Errorf("results = %+v, want chunks 0 and 1 only", results)
+	}
+	for _, chunk := range chunks[:2] {
+		if _, err := os.Stat(chunk.TempFilePath); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed after transcription", chunk.TempFilePath)
+		}
+	}
+	if _, err := os.Stat(chunks[2].TempFilePath); err != nil {
+		t.Errorf("chunk that never arrived was touched: %v", err)
+	}
+}
+
+// audioProvider records the audio it is sent
+type audioProvider struct {
+	namedProvider
+	req  *providers.TranscriptionRequest
+	data []byte
+}
+
+func (p *audioProvider) Transcribe(ctx context.Context, req *providers.TranscriptionRequest) (*providers.TranscriptionResult, error) {
+	data, err := io.ReadAll(req.Audio)
+	p.req, p.data = req, data
+	return &providers.TranscriptionResult{Text: "hello"}, err
+}
+
+func TestTranscribeInMemoryChunk(t *testing.T) {
+	provider := &audioProvider{namedProvider: namedProvider{"gemini"}}
+	tr := NewTranscriber(provider, &config.Config{})
+	chunk := &audio.ChunkInfo{Index: 2, End: time.Second, Duration: time.Second, Data: []byte("RIFF audio"), Format: audio.FormatWAV}
+	req := &TranscribeRequest{FilePath: "talk.wav"}
+
+	if _, err := tr.transcribeChunk(context.Background(), provider, chunk, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if string(provider.data) != "RIFF audio" {
+		t.Errorf("sent %q, want the chunk's audio", provider.data)
+	}
+	if provider.req.Filename != "chunk_002.wav" || provider.req.AudioFormat != "wav" {
+		t.Errorf("sent %s as %s, want chunk_002.wav as wav", provider.req.Filename, provider.req.AudioFormat)
+	}
+	// Providers resend it on retry without copying it
+	if _, ok := provider.req.Audio.(io.ReaderAt); !ok {
+		t.Errorf("sent audio as %T, want an io.ReaderAt", provider.req.Audio)
+	}
+}
@@ -0,0 +1,217 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// Built-in pipeline stages, in the order they run
+const (
+	StageProbe       = "probe"       // Validate and hash the file, read its audio info
+	StageConvert     = "convert"     // Extract the audio of video files, skip silence
+	StageChunk       = "chunk"       // Split the audio into chunks
+	StageTranscribe  = "transcribe"  // Transcribe the chunks with the provider
+	StageMerge       = "merge"       // Combine the chunk transcripts into one result
+	StagePostprocess = "postprocess" // Run the post-processing steps
+	StageRender      = "render"      // Write the result to the request's OutputPath
+)
+
+// MetadataStageTimings records how long each pipeline stage took, as
+// []StageTiming in the order the stages ran
+const MetadataStageTimings = "stage_timings"
+
+// Stage is one step of the transcription pipeline. Stages run in order on
+// a shared PipelineState; an error stops the pipeline.
+//
+// Stages before StagePostprocess run once for each audio source, e.g. each
+// channel of a call recording, and see the chunks; later stages run once
+// on the combined result.
+type Stage interface {
+	// Name identifies the stage in logs, timings and InsertStage
+	Name() string
+
+	// Run advances the state
+	Run(ctx context.Context, state *PipelineState) error
+}
+
+// StageTiming is the time one stage took
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+}
+
+// PipelineState is what the stages know about the file being transcribed.
+// A stage reads what earlier stages set and sets its own fields, e.g. a
+// voice activity stage after StageConvert may replace AudioPath, and a
+// redaction stage after StageMerge may edit Result.
+type PipelineState struct {
+	Request  *TranscribeRequest
+	Provider providers.LLMProvider
+
+	AudioInfo *audio.AudioInfo
+	AudioPath string         // Audio the chunks are cut from
+	TimeMap   *audio.TimeMap // Set when silence was removed from AudioPath
+
+	Chunks       []*audio.ChunkInfo               // Files are written during StageTranscribe
+	ChunkResults []*providers.TranscriptionResult // Nil for failed chunks
+	Gaps         []Gap
+
+	Result *TranscribeResult
+
+	started    time.Time
+	sourceHash string
+	callback   ProgressCallback
+	onSegment  SegmentCallback
+	voices     *voiceProfiles
+	checkpoint *checkpoint
+	merger     ChunkMerger
+	reconciler *overlapReconciler
+	unwritten  bool // Chunks are planned but their files not yet created
+	timings    []StageTiming
+	cleanups   []func()
+}
+
+// OnCleanup registers fn to run once the pipeline has finished, latest
+// first, e.g. to remove a temporary file a stage created
+func (s *PipelineState) OnCleanup(fn func()) {
+	s.cleanups = append(s.cleanups, fn)
+}
+
+// cleanup runs the registered cleanup functions
+func (s *PipelineState) cleanup() {
+	for i := len(s.cleanups) - 1; i >= 0; i-- {
+		s.cleanups[i]()
+	}
+	s.cleanups = nil
+}
+
+// stageFunc is a Stage backed by a function
+type stageFunc struct {
+	name string
+	run  func(ctx context.Context, state *PipelineState) error
+}
+
+// NewStage returns a Stage named name that runs fn
+func NewStage(name string, fn func(ctx context.Context, state *PipelineState) error) Stage {
+	return &stageFunc{name: name, run: fn}
+}
+
+func (s *stageFunc) Name() string { return s.name }
+
+func (s *stageFunc) Run(ctx context.Context, state *PipelineState) error {
+	return s.run(ctx, state)
+}
+
+// defaultStages returns the built-in pipeline
+func (t *TranscriberImpl) defaultStages() []Stage {
+	return []Stage{
+		NewStage(StageProbe, t.probeStage),
+		NewStage(StageConvert, t.convertStage),
+		NewStage(StageChunk, t.chunkStage),
+		NewStage(StageTranscribe, t.transcribeStage),
+		NewStage(StageMerge, t.mergeStage),
+		NewStage(StagePostprocess, t.postprocessStage),
+		NewStage(StageRender, t.renderStage),
+	}
+}
+
+// Stages returns the names of the pipeline stages in the order they run
+func (t *TranscriberImpl) Stages() []string {
+	names := make([]string, len(t.stages))
+	for i, stage := range t.stages {
+		names[i] = stage.Name()
+	}
+	return names
+}
+
+// InsertStage adds a custom stage to the pipeline right after the stage
+// named after. It must be called before transcribing.
+func (t *TranscriberImpl) InsertStage(after string, stage Stage) error {
+	for i, existing := range t.stages {
+		if existing.Name() != after {
+			continue
+		}
+		stages := make([]Stage, 0, len(t.stages)+1)
+		stages = append(stages, t.stages[:i+1]...)
+		stages = append(stages, stage)
+		t.stages = append(stages, t.stages[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("unknown pipeline stage %q", after)
+}
+
+// sourceStages returns the stages that run for each audio source, and
+// resultStages those that run on the combined result
+func (t *TranscriberImpl) sourceStages() []Stage {
+	for i, stage := range t.stages {
+		if stage.Name() == StagePostprocess {
+			return t.stages[:i]
+		}
+	}
+	return t.stages
+}
+
+func (t *TranscriberImpl) resultStages() []Stage {
+	return t.stages[len(t.sourceStages()):]
+}
+
+// runStages runs stages in order, recording how long each took
+func runStages(ctx context.Context, state *PipelineState, stages []Stage) error {
+	log := logger.WithComponent("pipeline").WithField("file", filepath.Base(state.Request.FilePath)).WithField("job", state.Request.JobID)
+	for _, stage := range stages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Now()
+		err := stage.Run(ctx, state)
+		elapsed := time.Since(start)
+		state.timings = append(state.timings, StageTiming{Stage: stage.Name(), Duration: elapsed})
+		if err != nil {
+			log.Debug().Str("stage", stage.Name()).Dur("elapsed", elapsed).Err(err).Msg("Pipeline stage failed")
+			return err
+		}
+		log.Debug().Str("stage", stage.Name()).Dur("elapsed", elapsed).Msg("Pipeline stage completed")
+	}
+	return nil
+}
+
+// StageTimings returns the stage timings recorded on a result
+func (r *TranscribeResult) StageTimings() []StageTiming {
+	if r == nil || r.Metadata == nil {
+		return nil
+	}
+	timings, _ := r.Metadata[MetadataStageTimings].([]StageTiming)
+	return timings
+}
+
+// addStageTimings adds timings to those recorded on result. Stages that
+// ran for several sources, e.g. the channels of a call, are summed.
+func addStageTimings(result *TranscribeResult, timings []StageTiming) {
+	if result == nil || len(timings) == 0 {
+		return
+	}
+	combined := append([]StageTiming(nil), result.StageTimings()...)
+	for _, timing := range timings {
+		found := false
+		for i := range combined {
+			if combined[i].Stage == timing.Stage {
+				combined[i].Duration += timing.Duration
+				found = true
+				break
+			}
+		}
+		if !found {
+			combined = append(combined, timing)
+		}
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataStageTimings] = combined
+}
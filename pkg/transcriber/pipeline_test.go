@@ -0,0 +1,108 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+func TestInsertStage(t *testing.T) {
+	tr := NewTranscriber(nil, &config.Config{})
+	noop := func(name string) Stage {
+		return NewStage(name, func(ctx context.Context, state *PipelineState) error { return nil })
+	}
+
+	if err := tr.InsertStage(StageConvert, noop("vad")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.InsertStage(StageMerge, noop("align")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.InsertStage("denoise", noop("x")); err == nil {
+		t.Error("InsertStage() after an unknown stage succeeded")
+	}
+
+	want := "probe convert vad chunk transcribe merge align postprocess render"
+	if got := strings.Join(tr.Stages(), " "); got != want {
+		t.Errorf("Stages() = %s, want %s", got, want)
+	}
+
+	// Stages inserted before postprocess run for every source
+	if n := len(tr.sourceStages()); n != 7 {
+		t.Errorf("%d source stages, want 7", n)
+	}
+	if stages := tr.resultStages(); len(stages) != 2 || stages[0].Name() != StagePostprocess {
+		t.Errorf("result stages start with %s, want %s", stages[0].Name(), StagePostprocess)
+	}
+}
+
+func TestFinishResultStages(t *testing.T) {
+	tr := NewTranscriber(nil, &config.Config{})
+	redact := NewStage("redact", func(ctx context.Context, state *PipelineState) error {
+		state.Result.Text = strings.ReplaceAll(state.Result.Text, "555-0100", "[redacted]")
+		return nil
+	})
+	if err := tr.InsertStage(StagePostprocess, redact); err != nil {
+		t.Fatal(err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "call.txt")
+	req := &TranscribeRequest{FilePath: "call.mp3", OutputPath: outputPath}
+	result := &TranscribeResult{Text: "Call me at 555-0100."}
+	if err := tr.finishResult(context.Background(), nil, req, result); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[redacted]") {
+		t.Errorf("rendered output %q was not redacted before rendering", data)
+	}
+
+	var names []string
+	for _, timing := range result.StageTimings() {
+		names = append(names, timing.Stage)
+	}
+	if got := strings.Join(names, " "); got != "postprocess redact render" {
+		t.Errorf("stage timings = %s, want postprocess redact render", got)
+	}
+}
+
+func TestRunStagesStopsOnError(t *testing.T) {
+	ran := false
+	stages := []Stage{
+		NewStage("fail", func(ctx context.Context, state *PipelineState) error { return errors.New("no speech") }),
+		NewStage("after", func(ctx context.Context, state *PipelineState) error { ran = true; return nil }),
+	}
+	state := &PipelineState{Request: &TranscribeRequest{FilePath: "talk.mp3"}}
+	if err := runStages(context.Background(), state, stages); err == nil || ran {
+		t.Errorf("runStages() = %v, later stage ran: %v", err, ran)
+	}
+	if len(state.timings) != 1 || state.timings[0].Stage != "fail" {
+		t.Errorf("timings = %+v, want the failed stage only", state.timings)
+	}
+}
+
+func TestAddStageTimings(t *testing.T) {
+	result := &TranscribeResult{}
+	addStageTimings(result, []StageTiming{{StageChunk, 2}, {StageTranscribe, 10}})
+	addStageTimings(result, []StageTiming{{StageChunk, 3}, {StageTranscribe, 20}, {StageRender, 1}})
+
+	want := []StageTiming{{StageChunk, 5}, {StageTranscribe, 30}, {StageRender, 1}}
+	got := result.StageTimings()
+	if len(got) != len(want) {
+		t.Fatalf("StageTimings() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("timing %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package transcriber
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// PostProcessor is a step run on the merged transcript before it is saved,
+// such as summarization
+type PostProcessor interface {
+	// Name identifies the step in logs
+	Name() string
+
+	// Process updates result and returns the provider responses it used,
+	// which are added to the result's usage and cost
+	Process(ctx context.Context, provider providers.LLMProvider, result *TranscribeResult) ([]*providers.TranscriptionResult, error)
+}
+
+// AddPostProcessor adds a step run on every merged transcript, in the
+// order added
+func (t *TranscriberImpl) AddPostProcessor(p PostProcessor) {
+	t.postProcessors = append(t.postProcessors, p)
+}
+
+// postProcess runs the post-processing steps. A failed step is logged and
+// skipped, keeping the transcript.
+func (t *TranscriberImpl) postProcess(ctx context.Context, provider providers.LLMProvider, result *TranscribeResult) {
+	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(result.FilePath))
+
+	for _, p := range t.postProcessors {
+		responses, err := p.Process(ctx, provider, result)
+		t.addRequestUsage(result, responses, 0)
+		if err != nil {
+			log.Warn().Err(err).Str("step", p.Name()).Msg("Post-processing step failed")
+			continue
+		}
+		log.Debug().Str("step", p.Name()).Int("requests", len(responses)).Msg("Post-processing step completed")
+	}
+}
+
+// addRequestUsage adds the usage and cost of requests made beyond the
+// chunk transcriptions, such as reconciliation or post-processing, to result
+func (t *TranscriberImpl) addRequestUsage(result *TranscribeResult, responses []*providers.TranscriptionResult, audio time.Duration) {
+	var usage providers.Usage
+	var model string
+	for _, res := range responses {
+		if res == nil {
+			continue
+		}
+		usage = usage.Add(providers.UsageFromMetadata(res.Metadata))
+		if model == "" {
+			model, _ = res.Metadata["model"].(string)
+		}
+	}
+	if usage.IsZero() {
+		return
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	total := usage
+	if result.Usage != nil {
+		total = result.Usage.Add(usage)
+	}
+	result.Usage = &total
+	total.SetMetadata(result.Metadata)
+
+	if cost, ok := t.prices.Cost(model, usage, audio); ok {
+		result.Cost += cost
+	}
+}
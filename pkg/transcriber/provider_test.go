@@ -0,0 +1,46 @@
+package transcriber
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/events"
+)
+
+func TestSwapProvider(t *testing.T) {
+	tr := NewTranscriber(namedProvider{"gemini"}, &config.Config{})
+	sub := tr.Events().Subscribe(4, events.ProviderSwapped)
+	defer sub.Close()
+
+	old := tr.SwapProvider(namedProvider{"groq"})
+	if old.Name() != "gemini" {
+		t.Errorf("SwapProvider() returned %q, want gemini", old.Name())
+	}
+	if got := tr.Provider().Name(); got != "groq" {
+		t.Errorf("Provider() = %q, want groq", got)
+	}
+	if event := <-sub.C(); event.Message != "groq" {
+		t.Errorf("ProviderSwapped message = %q, want groq", event.Message)
+	}
+}
+
+func TestSwapProviderConcurrent(t *testing.T) {
+	tr := NewTranscriber(namedProvider{"gemini"}, &config.Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tr.SetProvider(namedProvider{"groq"})
+		}()
+		go func() {
+			defer wg.Done()
+			if tr.Provider() == nil {
+				t.Error("Provider() = nil during swap")
+			}
+		}()
+	}
+	wg.Wait()
+}
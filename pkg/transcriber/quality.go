@@ -0,0 +1,288 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataQuality is the metadata key of the Quality of a merged result
+const MetadataQuality = "quality"
+
+// SuspectQuality is the score below which a transcript is worth reading
+// before it is trusted
+const SuspectQuality = 60
+
+// Quality thresholds
+const (
+	lowConfidence  = 0.5 // Segments less confident than this are uncertain
+	fullCoverage   = 0.6 // Share of the audio speech usually covers
+	minCoverage    = 0.4 // Coverage below this is reported
+	maxUncertain   = 0.2 // Share of uncertain segments above this is reported
+	minStructured  = 0.9 // Share of structured chunks below this is reported
+	cjkDensityRate = 2   // CJK characters are spoken about twice as fast as words
+)
+
+// Words per minute of speech outside which a transcript is suspiciously
+// sparse, e.g. missed speech or a chunk answered with a summary, or dense,
+// e.g. repetition loops or hallucinated text
+const (
+	minWordsPerMinute = 40
+	maxWordsPerMinute = 260
+)
+
+// Quality is a heuristic score of how trustworthy a transcript looks, for
+// spotting suspect transcripts in large batches without reading them.
+// Measurements a run gives nothing to measure by, e.g. confidence from a
+// provider that reports none, are nil and left out of the score.
+type Quality struct {
+	Score int `json:"score"` // 0 (suspect) to 100
+
+	// Share of transcribed chunks returned with timestamped segments, when
+	// any were; providers answering in plain text throughout have none
+	StructuredRate *float64 `json:"structured_rate,omitempty"`
+
+	// Share of the audio covered by segment timestamps
+	Coverage *float64 `json:"timestamp_coverage,omitempty"`
+
+	// Words, or CJK characters, per minute of audio that is not silence
+	Density *float64 `json:"words_per_minute,omitempty"`
+
+	// Share of segments with a confidence below 0.5, among those with one
+	LowConfidence *float64 `json:"low_confidence_ratio,omitempty"`
+
+	// What lowered the score, e.g. "timestamps cover 35% of the audio"
+	Issues []string `json:"issues,omitempty"`
+}
+
+// Suspect reports whether the score is below SuspectQuality
+func (q *Quality) Suspect() bool {
+	return q.Score < SuspectQuality
+}
+
+// String returns the score and its issues, e.g.
+// "48/100 (timestamps cover 35% of the audio)"
+func (q *Quality) String() string {
+	text := fmt.Sprintf("%d/100", q.Score)
+	if len(q.Issues) > 0 {
+		text += " (" + strings.Join(q.Issues, "; ") + ")"
+	}
+	return text
+}
+
+// AssessQuality scores a merged result from its chunk results; chunks
+// plans the audio each covers, so silent chunks do not count as missed
+// speech. It returns nil when there is nothing to measure.
+func AssessQuality(result *TranscribeResult, chunks []*audio.ChunkInfo, results []*providers.TranscriptionResult) *Quality {
+	quality := &Quality{}
+	var scores []float64
+
+	// Chunks that should have come back as segments but did not
+	transcribed, structured := 0, 0
+	for _, chunkResult := range results {
+		if chunkResult == nil {
+			continue
+		}
+		if silent, _ := chunkResult.Metadata[MetadataSilent].(bool); silent {
+			continue
+		}
+		transcribed++
+		if len(chunkResult.Segments) > 0 {
+			structured++
+		}
+	}
+	if structured > 0 {
+		rate := float64(structured) / float64(transcribed)
+		quality.StructuredRate = &rate
+		scores = append(scores, rate)
+		if rate < minStructured {
+			quality.Issues = append(quality.Issues,
+				fmt.Sprintf("%d of %d chunks without timestamps", transcribed-structured, transcribed))
+		}
+	}
+
+	if result.Duration > 0 && len(result.Segments) > 0 {
+		coverage := segmentCoverage(result.Segments, result.Duration)
+		quality.Coverage = &coverage
+		scores = append(scores, math.Min(1, coverage/fullCoverage))
+		if coverage < minCoverage {
+			quality.Issues = append(quality.Issues,
+				fmt.Sprintf("timestamps cover %.0f%% of the audio", coverage*100))
+		}
+	}
+
+	if speech := speechDuration(result.Duration, chunks); speech > 0 {
+		tokens, cjk := countTokens(qualityText(result))
+		density := float64(tokens) / speech.Minutes()
+		quality.Density = &density
+		low, high := float64(minWordsPerMinute), float64(maxWordsPerMinute)
+		unit := "words"
+		if cjk*2 > tokens {
+			low, high, unit = low*cjkDensityRate, high*cjkDensityRate, "characters"
+		}
+		switch {
+		case density < low:
+			scores = append(scores, density/low)
+			quality.Issues = append(quality.Issues, fmt.Sprintf("only %.0f %s per minute", density, unit))
+		case density > high:
+			scores = append(scores, high/density)
+			quality.Issues = append(quality.Issues, fmt.Sprintf("%.0f %s per minute", density, unit))
+		default:
+			scores = append(scores, 1)
+		}
+	}
+
+	rated, uncertain := 0, 0
+	for _, segment := range result.Segments {
+		if segment.Confidence <= 0 {
+			continue
+		}
+		rated++
+		if segment.Confidence < lowConfidence {
+			uncertain++
+		}
+	}
+	if rated > 0 {
+		ratio := float64(uncertain) / float64(rated)
+		quality.LowConfidence = &ratio
+		scores = append(scores, 1-ratio)
+		if ratio > maxUncertain {
+			quality.Issues = append(quality.Issues,
+				fmt.Sprintf("%.0f%% of segments low-confidence", ratio*100))
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, score := range scores {
+		total += score
+	}
+	quality.Score = int(math.Round(100 * total / float64(len(scores))))
+	return quality
+}
+
+// segmentCoverage returns the share of duration covered by at least one
+// segment
+func segmentCoverage(segments []providers.TranscriptionSegment, duration time.Duration) float64 {
+	spans := make([]audio.Span, 0, len(segments))
+	for _, segment := range segments {
+		start, end := maxDuration(segment.Start, 0), minDuration(segment.End, duration)
+		if end > start {
+			spans = append(spans, audio.Span{Start: start, End: end})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+	var covered, reached time.Duration
+	for _, span := range spans {
+		if span.Start < reached {
+			span.Start = reached
+		}
+		if span.End > span.Start {
+			covered += span.End - span.Start
+			reached = span.End
+		}
+	}
+	return float64(covered) / float64(duration)
+}
+
+// speechDuration returns the duration of a recording less its chunks that
+// are all silence
+func speechDuration(duration time.Duration, chunks []*audio.ChunkInfo) time.Duration {
+	for i, chunk := range chunks {
+		if !chunk.Silent {
+			continue
+		}
+		silence := chunk.Duration
+		// The overlap with the next chunk is transcribed there
+		if i+1 < len(chunks) && chunks[i+1].Start < chunk.End {
+			silence -= chunk.End - chunks[i+1].Start
+		}
+		duration -= silence
+	}
+	return duration
+}
+
+// qualityText returns the spoken text of a result, without the speaker
+// labels of its plain text
+func qualityText(result *TranscribeResult) string {
+	if len(result.Segments) == 0 {
+		return result.Text
+	}
+	texts := make([]string, len(result.Segments))
+	for i, segment := range result.Segments {
+		texts[i] = segment.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// countTokens counts the words and CJK characters of text, and how many
+// of them are CJK characters
+func countTokens(text string) (tokens, cjk int) {
+	for _, tok := range tokenize(text) {
+		if tok.norm == "" {
+			continue
+		}
+		tokens++
+		if r := []rune(tok.norm); len(r) == 1 && isCJK(r[0]) {
+			cjk++
+		}
+	}
+	return tokens, cjk
+}
+
+// setQuality records the quality of a merged result
+func setQuality(result *TranscribeResult, chunks []*audio.ChunkInfo, results []*providers.TranscriptionResult) {
+	quality := AssessQuality(result, chunks, results)
+	if quality == nil {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataQuality] = quality
+}
+
+// lowestQuality returns the lowest quality of results, or nil if none has
+// one
+func lowestQuality(results []*TranscribeResult) *Quality {
+	var lowest *Quality
+	for _, result := range results {
+		if quality := result.Quality(); quality != nil && (lowest == nil || quality.Score < lowest.Score) {
+			lowest = quality
+		}
+	}
+	return lowest
+}
+
+// Quality returns the quality recorded on a result, also for results
+// loaded from JSON, or nil if it has none
+func (r *TranscribeResult) Quality() *Quality {
+	if r == nil || r.Metadata == nil {
+		return nil
+	}
+	switch quality := r.Metadata[MetadataQuality].(type) {
+	case nil:
+		return nil
+	case *Quality:
+		return quality
+	default:
+		data, err := json.Marshal(quality)
+		if err != nil {
+			return nil
+		}
+		var parsed Quality
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil
+		}
+		return &parsed
+	}
+}
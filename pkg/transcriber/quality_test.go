@@ -0,0 +1,98 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// spokenSegments returns a segment of words every ten seconds of duration
+func spokenSegments(duration time.Duration, words int, confidence float32) []providers.TranscriptionSegment {
+	var segments []providers.TranscriptionSegment
+	text := strings.TrimSpace(strings.Repeat("word ", words))
+	for start := time.Duration(0); start < duration; start += 10 * time.Second {
+		segments = append(segments, providers.TranscriptionSegment{
+			Text: text, Start: start, End: start + 9*time.Second, Confidence: confidence,
+		})
+	}
+	return segments
+}
+
+func TestAssessQuality(t *testing.T) {
+	chunks := []*audio.ChunkInfo{
+		{Start: 0, End: time.Minute, Duration: time.Minute},
+		{Start: time.Minute, End: 2 * time.Minute, Duration: time.Minute},
+	}
+	segments := spokenSegments(2*time.Minute, 25, 0.9) // 150 words per minute
+	good := &TranscribeResult{Duration: 2 * time.Minute, Segments: segments}
+	results := []*providers.TranscriptionResult{{Segments: segments[:6]}, {Segments: segments[6:]}}
+
+	quality := AssessQuality(good, chunks, results)
+	if quality == nil || quality.Score != 100 || len(quality.Issues) > 0 {
+		t.Fatalf("AssessQuality() = %+v, want 100 without issues", quality)
+	}
+	if *quality.StructuredRate != 1 || *quality.LowConfidence != 0 || *quality.Density != 150 {
+		t.Errorf("measurements = %v, %v, %v", *quality.StructuredRate, *quality.LowConfidence, *quality.Density)
+	}
+
+	// One chunk came back as plain text with a few words, the rest unsure
+	sparse := spokenSegments(30*time.Second, 2, 0.3)
+	bad := &TranscribeResult{Duration: 2 * time.Minute, Segments: sparse}
+	results = []*providers.TranscriptionResult{{Segments: sparse}, {Text: "word"}}
+	quality = AssessQuality(bad, chunks, results)
+	if quality == nil || !quality.Suspect() {
+		t.Fatalf("AssessQuality() = %+v, want a suspect score", quality)
+	}
+	for _, issue := range []string{"1 of 2 chunks without timestamps", "timestamps cover", "per minute", "low-confidence"} {
+		if !strings.Contains(quality.String(), issue) {
+			t.Errorf("quality %q does not report %q", quality, issue)
+		}
+	}
+}
+
+func TestAssessQualityPlainText(t *testing.T) {
+	// Providers answering in plain text are scored by density alone
+	text := strings.Repeat("word ", 300)
+	result := &TranscribeResult{Duration: 2 * time.Minute, Text: text}
+	chunks := []*audio.ChunkInfo{
+		{Start: 0, End: time.Minute, Duration: time.Minute},
+		{Start: time.Minute, End: 2 * time.Minute, Duration: time.Minute, Silent: true},
+	}
+	results := []*providers.TranscriptionResult{{Text: text}, {Metadata: map[string]interface{}{MetadataSilent: true}}}
+
+	quality := AssessQuality(result, chunks, results)
+	if quality == nil || quality.StructuredRate != nil || quality.Coverage != nil || quality.LowConfidence != nil {
+		t.Fatalf("AssessQuality() = %+v, want density only", quality)
+	}
+	// The silent minute is not missed speech
+	if *quality.Density != 300 || quality.Score != 87 {
+		t.Errorf("density %v, score %d; want 300 words per minute scoring 87", *quality.Density, quality.Score)
+	}
+
+	if AssessQuality(&TranscribeResult{}, nil, nil) != nil {
+		t.Error("empty result was scored")
+	}
+}
+
+func TestQualityFromJSON(t *testing.T) {
+	rate := 0.5
+	result := &TranscribeResult{SchemaVersion: ResultSchemaVersion, Metadata: map[string]interface{}{
+		MetadataQuality: &Quality{Score: 42, StructuredRate: &rate, Issues: []string{"1 of 2 chunks without timestamps"}},
+	}}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ParseResult(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quality := loaded.Quality()
+	if quality == nil || quality.Score != 42 || *quality.StructuredRate != 0.5 || len(quality.Issues) != 1 {
+		t.Errorf("Quality() = %+v, want the saved quality", quality)
+	}
+}
@@ -0,0 +1,57 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReaderRequest returns a request transcribing the audio read from r.
+// format is the container of the audio as a file extension, e.g. "mp3".
+func ReaderRequest(r io.Reader, format string) *TranscribeRequest {
+	return &TranscribeRequest{Audio: r, Format: format}
+}
+
+// BytesRequest returns a request transcribing in-memory audio in format
+func BytesRequest(data []byte, format string) *TranscribeRequest {
+	return ReaderRequest(bytes.NewReader(data), format)
+}
+
+// spoolAudio copies the audio of a reader request to a file in the temp
+// directory, since probing and chunking need a seekable file. It returns
+// a copy of req reading from that file, which the caller removes.
+func (t *TranscriberImpl) spoolAudio(ctx context.Context, req *TranscribeRequest) (*TranscribeRequest, error) {
+	format := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.Format), "."))
+	if format == "" {
+		return nil, fmt.Errorf("audio from a reader needs a format, e.g. \"mp3\"")
+	}
+	if !t.processor.IsSupported("input." + format) {
+		return nil, fmt.Errorf("unsupported audio format: %s", format)
+	}
+
+	tempDir := t.scratchDir(ctx)
+	if err := os.MkdirAll(tempDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	file, err := os.CreateTemp(tempDir, "input_*."+format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := io.Copy(file, req.Audio); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	spooled := *req
+	spooled.FilePath = file.Name()
+	spooled.Audio = nil
+	return &spooled, nil
+}
@@ -0,0 +1,40 @@
+package transcriber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+func TestSpoolAudio(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Audio.TempDir = t.TempDir()
+	tr := NewTranscriber(namedProvider{"gemini"}, cfg)
+
+	req := BytesRequest([]byte("audio"), ".MP3")
+	req.FilePath = "upload.mp3"
+	spooled, err := tr.spoolAudio(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(spooled.FilePath)
+
+	if filepath.Dir(spooled.FilePath) != cfg.Audio.TempDir || filepath.Ext(spooled.FilePath) != ".mp3" {
+		t.Errorf("FilePath = %q, want an .mp3 in the temp directory", spooled.FilePath)
+	}
+	if data, err := os.ReadFile(spooled.FilePath); err != nil || string(data) != "audio" {
+		t.Errorf("spooled file = %q, %v", data, err)
+	}
+	if spooled.Audio != nil || req.FilePath != "upload.mp3" {
+		t.Errorf("spoolAudio() changed the request: %+v", req)
+	}
+
+	for _, format := range []string{"", "txt"} {
+		if _, err := tr.spoolAudio(context.Background(), BytesRequest([]byte("audio"), format)); err == nil {
+			t.Errorf("spoolAudio() with format %q succeeded", format)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// DefaultRenderWorkers is how many outputs are rendered at once when
+// TranscribeOptions.RenderWorkers is not set
+const DefaultRenderWorkers = 4
+
+// MetadataRenderTimings records how long each output took to render and
+// write, as []RenderTiming
+const MetadataRenderTimings = "render_timings"
+
+// RenderTarget is one output of a result
+type RenderTarget struct {
+	Format string // json, text, srt, vtt, bilingual-srt, bilingual-vtt, chapters, html, html-audio, audacity, textgrid or template:<file>, with .gz to compress
+	Path   string
+}
+
+// RenderTiming is how rendering one target went
+type RenderTiming struct {
+	Format   string        `json:"format"`
+	Path     string        `json:"path"`
+	Duration time.Duration `json:"duration"`
+	Size     int           `json:"size,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// formatExtensions are the file extensions of the output formats
+var formatExtensions = map[string]string{
+	"json":     ".json",
+	"text":     ".txt",
+	"srt":      ".srt",
+	"vtt":      ".vtt",
+	"chapters": ".chapters.txt",
+	"html":     ".html",
+	"audacity": ".labels.txt",
+	"textgrid": ".TextGrid",
+
+	"bilingual-srt": ".bilingual.srt",
+	"bilingual-vtt": ".bilingual.vtt",
+	"html-audio":    ".html",
+}
+
+// FormatPath returns the path of format rendered next to outputPath,
+// e.g. meeting.srt for meeting.txt, or meeting.json.gz for json.gz
+func FormatPath(outputPath, format string) string {
+	if path, ok := templateFormatPath(format); ok {
+		return templateOutputPath(outputPath, path)
+	}
+	if base, compressed := splitCompression(format); compressed {
+		return FormatPath(outputPath, base) + CompressionSuffix
+	}
+	ext, ok := formatExtensions[format]
+	if !ok {
+		ext = "." + format
+	}
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ext
+}
+
+// RenderTargets returns the targets of a request: OutputPath as text,
+// then each of formats next to it, skipping duplicates
+func RenderTargets(outputPath string, formats []string) []RenderTarget {
+	if outputPath == "" {
+		return nil
+	}
+	targets := []RenderTarget{{Format: "text", Path: outputPath}}
+	seen := map[string]bool{outputPath: true}
+	for _, format := range formats {
+		format = strings.TrimSpace(format)
+		if _, ok := templateFormatPath(format); !ok {
+			format = strings.ToLower(format)
+		}
+		if format == "" {
+			continue
+		}
+		path := FormatPath(outputPath, format)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		targets = append(targets, RenderTarget{Format: format, Path: path})
+	}
+	return targets
+}
+
+// SaveOutputs writes result to outputPath and the options' OutputFormats
+// next to it, as a run does: with KeepVersions to the next version of
+// outputPath, recorded in the result's metadata. It returns the path the
+// text was written to and the timing of each output.
+func SaveOutputs(ctx context.Context, result *TranscribeResult, outputPath string, options TranscribeOptions, c *encryption.Cipher) (string, []RenderTiming, error) {
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+
+	// Earlier transcripts of the file, e.g. with another prompt or model,
+	// are kept next to the new one
+	if options.KeepVersions {
+		var version int
+		outputPath, version = NextVersion(outputPath, options.OutputFormats)
+		result.Metadata[MetadataOutputPath] = outputPath
+		result.Metadata[MetadataVersion] = version
+	}
+	timings, err := RenderResult(ctx, result, RenderTargets(outputPath, options.OutputFormats), options.RenderWorkers, c)
+	return outputPath, timings, err
+}
+
+// RenderResult formats and writes result to every target, up to workers
+// at a time, encrypted with c. Long transcripts take a while to format,
+// so the formats are rendered concurrently. It returns the timing of each
+// target, in order, and the errors of those that failed.
+func RenderResult(ctx context.Context, result *TranscribeResult, targets []RenderTarget, workers int, c *encryption.Cipher) ([]RenderTiming, error) {
+	if workers <= 0 {
+		workers = DefaultRenderWorkers
+	}
+	for _, target := range targets {
+		if isJSONFormat(target.Format) {
+			// Formatting must not modify the result while others read it
+			stampSaved(result)
+			break
+		}
+	}
+
+	timings := make([]RenderTiming, len(targets))
+	errs := make([]error, len(targets))
+	semaphore := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target RenderTarget) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				timings[i] = RenderTiming{Format: target.Format, Path: target.Path, Error: ctx.Err().Error()}
+				return
+			}
+			defer func() { <-semaphore }()
+			timings[i], errs[i] = renderTarget(result, target, c)
+		}(i, target)
+	}
+	wg.Wait()
+	return timings, errors.Join(errs...)
+}
+
+// renderTarget formats and writes one target
+func renderTarget(result *TranscribeResult, target RenderTarget, c *encryption.Cipher) (RenderTiming, error) {
+	timing := RenderTiming{Format: target.Format, Path: target.Path}
+	start := time.Now()
+	content, err := formatResult(result, target.Format, target.Path)
+	if err == nil {
+		timing.Size = len(content)
+		err = writeResultFile(content, target.Path, target.Format, c)
+	}
+	timing.Duration = time.Since(start)
+	if err != nil {
+		err = fmt.Errorf("failed to render %s: %w", target.Format, err)
+		timing.Error = err.Error()
+		return timing, err
+	}
+	logger.WithComponent("file-writer").Debug().
+		Str("format", target.Format).
+		Dur("elapsed", timing.Duration).
+		Int("size_bytes", timing.Size).
+		Msg("Rendered output")
+	return timing, nil
+}
+
+// RenderTimings returns the render timings recorded on a result
+func (r *TranscribeResult) RenderTimings() []RenderTiming {
+	if r == nil || r.Metadata == nil {
+		return nil
+	}
+	timings, _ := r.Metadata[MetadataRenderTimings].([]RenderTiming)
+	return timings
+}
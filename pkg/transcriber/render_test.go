@@ -0,0 +1,145 @@
+package transcriber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestRenderTargets(t *testing.T) {
+	targets := RenderTargets("out/meeting.txt", []string{"srt", " JSON", "text", "srt", "chapters"})
+	want := []RenderTarget{
+		{"text", "out/meeting.txt"},
+		{"srt", "out/meeting.srt"},
+		{"json", "out/meeting.json"},
+		{"chapters", "out/meeting.chapters.txt"},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("RenderTargets() = %v, want %v", targets, want)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("target %d = %v, want %v", i, targets[i], want[i])
+		}
+	}
+	if targets := RenderTargets("", []string{"srt"}); targets != nil {
+		t.Errorf("RenderTargets() without an output = %v", targets)
+	}
+}
+
+func TestRenderResult(t *testing.T) {
+	result := &TranscribeResult{
+		Text: "Hello world.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello world.", Start: 0, End: 2 * time.Second},
+		},
+	}
+	dir := t.TempDir()
+	targets := RenderTargets(filepath.Join(dir, "talk.txt"), []string{"srt", "vtt", "json"})
+
+	timings, err := RenderResult(context.Background(), result, targets, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) != len(targets) {
+		t.Fatalf("%d timings for %d targets", len(timings), len(targets))
+	}
+	for i, timing := range timings {
+		if timing.Format != targets[i].Format || timing.Size == 0 || timing.Error != "" {
+			t.Errorf("timing %d = %+v", i, timing)
+		}
+	}
+
+	srt, err := os.ReadFile(filepath.Join(dir, "talk.srt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(srt), "00:00:00,000 --> 00:00:02,000") {
+		t.Errorf("talk.srt = %q", srt)
+	}
+	if _, ok := result.Metadata["saved_at"]; !ok {
+		t.Error("JSON output was rendered without saved_at")
+	}
+}
+
+func TestSaveOutputsKeepsVersions(t *testing.T) {
+	result := &TranscribeResult{
+		Text:     "Hello world.",
+		Segments: []providers.TranscriptionSegment{{Text: "Hello world.", Start: 0, End: 2 * time.Second}},
+	}
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "talk.txt")
+	options := TranscribeOptions{OutputFormats: []string{"srt"}, KeepVersions: true}
+
+	for version, want := range []string{"talk.txt", "talk_v2.txt"} {
+		path, timings, err := SaveOutputs(context.Background(), result, outputPath, options, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != filepath.Join(dir, want) || len(timings) != 2 {
+			t.Errorf("save %d wrote %s with %d outputs, want %s with 2", version+1, path, len(timings), want)
+		}
+		if result.Metadata[MetadataVersion] != version+1 {
+			t.Errorf("save %d recorded version %v", version+1, result.Metadata[MetadataVersion])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "talk_v2.srt")); err != nil {
+		t.Errorf("second version's srt missing: %v", err)
+	}
+}
+
+func TestRenderResultFailure(t *testing.T) {
+	dir := t.TempDir()
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	targets := []RenderTarget{
+		{"text", filepath.Join(dir, "talk.txt")},
+		{"srt", filepath.Join(blocked, "talk.srt")}, // Its directory is a file
+	}
+
+	timings, err := RenderResult(context.Background(), &TranscribeResult{Text: "Hi."}, targets, 0, nil)
+	if err == nil {
+		t.Fatal("RenderResult() succeeded writing below a file")
+	}
+	if timings[0].Error != "" || timings[1].Error == "" {
+		t.Errorf("timings = %+v, want only srt to fail", timings)
+	}
+	if _, err := os.Stat(targets[0].Path); err != nil {
+		t.Errorf("text output not written after srt failed: %v", err)
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	result := &TranscribeResult{
+		Text: "Hello world.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello world.", Start: 0, End: 2 * time.Second},
+		},
+	}
+
+	var text strings.Builder
+	if err := WriteResult(&text, result, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != "Hello world.\n" {
+		t.Errorf("text = %q", text.String())
+	}
+	if _, ok := result.Metadata["saved_at"]; ok {
+		t.Error("text output stamped the result")
+	}
+
+	var json strings.Builder
+	if err := WriteResult(&json, result, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(json.String(), `"saved_at"`) || !strings.Contains(json.String(), "Hello world.") {
+		t.Errorf("json = %s", json.String())
+	}
+}
@@ -0,0 +1,76 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+)
+
+// LoadResult reads a JSON result previously written with ToJSON
+func LoadResult(path string) (*TranscribeResult, error) {
+	return LoadEncryptedResult(path, nil)
+}
+
+// LoadEncryptedResult reads a JSON result that may have been encrypted
+// with c and gzipped, e.g. meeting.json.gz. Plaintext files are read as
+// is.
+func LoadEncryptedResult(path string, c *encryption.Cipher) (*TranscribeResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result file: %w", err)
+	}
+
+	data, err = c.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if data, err = decompress(data); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	result, err := ParseResult(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// ParseResult parses a JSON result and validates its schema version
+func ParseResult(data []byte) (*TranscribeResult, error) {
+	var result TranscribeResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse result JSON: %w", err)
+	}
+
+	switch {
+	case result.SchemaVersion == 0:
+		return nil, fmt.Errorf("missing schema_version, not a gollmscribe JSON result")
+	case result.SchemaVersion > ResultSchemaVersion:
+		return nil, fmt.Errorf("unsupported schema_version %d (newest supported is %d)", result.SchemaVersion, ResultSchemaVersion)
+	}
+
+	return &result, nil
+}
+
+// Gaps returns the chunks missing from a partial result, also for results
+// loaded from JSON
+func (r *TranscribeResult) Gaps() []Gap {
+	switch gaps := r.Metadata[MetadataGaps].(type) {
+	case nil:
+		return nil
+	case []Gap:
+		return gaps
+	default:
+		data, err := json.Marshal(gaps)
+		if err != nil {
+			return nil
+		}
+		var parsed []Gap
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil
+		}
+		return parsed
+	}
+}
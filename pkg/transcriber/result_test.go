@@ -0,0 +1,74 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestLoadResultRoundTrip(t *testing.T) {
+	original := &TranscribeResult{
+		FilePath: "meeting.mp3",
+		Text:     "Hello world. Goodbye.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hello world.", Start: 0, End: 2 * time.Second, SpeakerID: "A"},
+			{Text: "Goodbye.", Start: 2 * time.Second, End: 3500 * time.Millisecond},
+		},
+		Duration:   3500 * time.Millisecond,
+		ChunkCount: 1,
+		Provider:   "gemini",
+	}
+
+	data, err := original.ToJSON(true)
+	if err != nil {
+		t.Fatalf("ToJSON() failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write result: %v", err)
+	}
+
+	loaded, err := LoadResult(path)
+	if err != nil {
+		t.Fatalf("LoadResult() failed: %v", err)
+	}
+
+	if loaded.SchemaVersion != ResultSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", loaded.SchemaVersion, ResultSchemaVersion)
+	}
+	if loaded.Text != original.Text {
+		t.Errorf("Text = %q, want %q", loaded.Text, original.Text)
+	}
+	if len(loaded.Segments) != 2 || loaded.Segments[1].End != 3500*time.Millisecond {
+		t.Errorf("Segments not preserved: %+v", loaded.Segments)
+	}
+	if loaded.Duration != original.Duration {
+		t.Errorf("Duration = %v, want %v", loaded.Duration, original.Duration)
+	}
+}
+
+func TestParseResultSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "current version", data: `{"schema_version": 1, "text": "hi"}`},
+		{name: "missing version", data: `{"text": "hi"}`, wantErr: true},
+		{name: "future version", data: `{"schema_version": 99, "text": "hi"}`, wantErr: true},
+		{name: "invalid json", data: `{`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseResult([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataSilenceSkipped records how much silence SkipSilence removed
+// before chunking, as a time.Duration
+const MetadataSilenceSkipped = "silence_skipped"
+
+// skipSilence writes a copy of audioPath without its long silences and
+// returns its path with the mapping back to the source. Removing silence
+// only saves cost, so when it fails the source is used as it is and the
+// returned map is nil.
+func (t *TranscriberImpl) skipSilence(ctx context.Context, audioPath string, duration time.Duration) (string, *audio.TimeMap) {
+	log := logger.WithComponent("transcriber").WithField("file", audioPath)
+
+	release, err := t.reserveTemp(ctx, audio.Encoding{}.MaxSize(duration))
+	if err != nil {
+		log.Warn().Err(err).Msg("Not skipping silence, transcribing the full recording")
+		return audioPath, nil
+	}
+	defer release()
+	outputPath := filepath.Join(t.scratchDir(ctx), fmt.Sprintf("speech_%d.mp3", time.Now().UnixNano()))
+	minSilence := time.Duration(t.config.Audio.SkipSilenceSeconds) * time.Second
+	timeMap, err := audio.SkipSilence(ctx, audioPath, outputPath, duration, t.config.Audio.SilenceThreshold, minSilence)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to skip silence, transcribing the full recording")
+		_ = os.Remove(outputPath)
+		return audioPath, nil
+	}
+	if timeMap == nil {
+		log.Info().Msg("No long silences to skip")
+		return audioPath, nil
+	}
+
+	log.Info().
+		Dur("removed", timeMap.Removed()).
+		Int("kept_spans", len(timeMap.Kept)).
+		Msg("Skipped silence before chunking")
+	return outputPath, timeMap
+}
+
+// mapToSource moves the times of a result transcribed from audio with
+// silences removed back onto the source recording
+func mapToSource(result *TranscribeResult, gaps []Gap, timeMap *audio.TimeMap) {
+	if timeMap == nil {
+		return
+	}
+	for i := range result.Segments {
+		mapSegment(&result.Segments[i], timeMap)
+	}
+	for i := range gaps {
+		gaps[i].Start = timeMap.ToSource(gaps[i].Start)
+		gaps[i].End = timeMap.EndToSource(gaps[i].End)
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataSilenceSkipped] = timeMap.Removed()
+}
+
+// mapSegment moves one segment back onto the source recording
+func mapSegment(segment *providers.TranscriptionSegment, timeMap *audio.TimeMap) {
+	segment.Start = timeMap.ToSource(segment.Start)
+	segment.End = max(timeMap.EndToSource(segment.End), segment.Start)
+}
@@ -0,0 +1,36 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestMapToSource(t *testing.T) {
+	timeMap := &audio.TimeMap{
+		Kept:     []audio.Span{{Start: 0, End: 10 * time.Second}, {Start: 40 * time.Second, End: time.Minute}},
+		Duration: time.Minute,
+	}
+	result := &TranscribeResult{Segments: []providers.TranscriptionSegment{
+		{Start: 2 * time.Second, End: 10 * time.Second, Text: "Before the pause."},
+		{Start: 10 * time.Second, End: 15 * time.Second, Text: "After it."},
+	}}
+	gaps := []Gap{{Start: 12 * time.Second, End: 30 * time.Second}}
+
+	mapToSource(result, gaps, timeMap)
+
+	want := [][2]time.Duration{{2 * time.Second, 10 * time.Second}, {40 * time.Second, 45 * time.Second}}
+	for i, segment := range result.Segments {
+		if segment.Start != want[i][0] || segment.End != want[i][1] {
+			t.Errorf("segment %d = %v-%v, want %v-%v", i, segment.Start, segment.End, want[i][0], want[i][1])
+		}
+	}
+	if gaps[0].Start != 42*time.Second || gaps[0].End != time.Minute {
+		t.Errorf("gap = %v-%v, want 42s-1m0s", gaps[0].Start, gaps[0].End)
+	}
+	if skipped := result.Metadata[MetadataSilenceSkipped]; skipped != 30*time.Second {
+		t.Errorf("%s = %v, want 30s", MetadataSilenceSkipped, skipped)
+	}
+}
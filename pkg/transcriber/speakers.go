@@ -0,0 +1,167 @@
+package transcriber
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// genericSpeakerPattern matches the numbered labels providers give to
+// speakers they cannot name
+var genericSpeakerPattern = regexp.MustCompile(`^(?i:speaker)\s*(\d+)$`)
+
+// reconcileSpeakers makes speaker labels consistent across chunks, which
+// providers label independently. Labels of each chunk are matched to the
+// labels already used by the previous chunk through segments that cover
+// the same audio in the overlap between them, weighted by how long they
+// overlap. A label without a match keeps its name unless that name is
+// taken, in which case it gets the next free "Speaker N". Chunks that
+// share no overlapping segments with their predecessor are left as is.
+func (m *ChunkMergerImpl) reconcileSpeakers(chunks []*providers.TranscriptionResult) {
+	for i := 1; i < len(chunks); i++ {
+		speakers := matchSpeakers(chunks[i-1].Segments, chunks[i].Segments, nextSpeakerNumber(chunks[:i+1]))
+		if len(speakers) == 0 {
+			continue
+		}
+		chunks[i].Text, _ = relabel(chunks[i].Segments, chunks[i].Text, speakers)
+	}
+}
+
+// matchSpeakers maps the labels of current to labels of previous by their
+// overlapping segments, returning nil when no segments overlap
+func matchSpeakers(previous, current []providers.TranscriptionSegment, next int) map[string]string {
+	type pair struct{ from, to string }
+	votes := make(map[pair]time.Duration)
+	for _, cur := range current {
+		if cur.SpeakerID == "" {
+			continue
+		}
+		for _, prev := range previous {
+			if prev.SpeakerID == "" {
+				continue
+			}
+			if overlap := minDuration(cur.End, prev.End) - maxDuration(cur.Start, prev.Start); overlap > 0 {
+				votes[pair{cur.SpeakerID, prev.SpeakerID}] += overlap
+			}
+		}
+	}
+	if len(votes) == 0 {
+		return nil
+	}
+
+	// Assign the strongest matches first, one to one
+	pairs := make([]pair, 0, len(votes))
+	for p := range votes {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if votes[pairs[i]] != votes[pairs[j]] {
+			return votes[pairs[i]] > votes[pairs[j]]
+		}
+		return pairs[i].from+"\x00"+pairs[i].to < pairs[j].from+"\x00"+pairs[j].to
+	})
+
+	speakers := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, p := range pairs {
+		if _, ok := speakers[p.from]; ok || taken[p.to] {
+			continue
+		}
+		speakers[p.from] = p.to
+		taken[p.to] = true
+	}
+
+	// Everyone else keeps their label if it is free, in order of appearance
+	for _, segment := range current {
+		label := segment.SpeakerID
+		if _, ok := speakers[label]; ok || label == "" {
+			continue
+		}
+		if taken[label] {
+			speakers[label] = fmt.Sprintf("Speaker %d", next)
+			next++
+		} else {
+			speakers[label] = label
+		}
+		taken[speakers[label]] = true
+	}
+	return speakers
+}
+
+// nextSpeakerNumber returns the number after the highest "Speaker N"
+// label used in chunks, so new labels collide with none of them
+func nextSpeakerNumber(chunks []*providers.TranscriptionResult) int {
+	next := 1
+	for _, chunk := range chunks {
+		for _, segment := range chunk.Segments {
+			if match := genericSpeakerPattern.FindStringSubmatch(segment.SpeakerID); match != nil {
+				if n, err := strconv.Atoi(match[1]); err == nil && n >= next {
+					next = n + 1
+				}
+			}
+		}
+	}
+	return next
+}
+
+// SpeakerSample lists representative segments of one speaker
+type SpeakerSample struct {
+	Speaker  string
+	Segments []providers.TranscriptionSegment // The longest segments, in time order
+	Count    int                              // Segments spoken in total
+	Duration time.Duration                    // Time spoken in total
+}
+
+// SpeakerSamples returns the speakers of a result in order of first
+// appearance, each with up to perSpeaker of their longest segments, so a
+// person can tell who is behind a label
+func SpeakerSamples(result *TranscribeResult, perSpeaker int) []SpeakerSample {
+	var samples []SpeakerSample
+	index := make(map[string]int)
+	for _, segment := range result.Segments {
+		if segment.SpeakerID == "" || strings.TrimSpace(segment.Text) == "" {
+			continue
+		}
+		i, ok := index[segment.SpeakerID]
+		if !ok {
+			i = len(samples)
+			index[segment.SpeakerID] = i
+			samples = append(samples, SpeakerSample{Speaker: segment.SpeakerID})
+		}
+		samples[i].Segments = append(samples[i].Segments, segment)
+		samples[i].Count++
+		samples[i].Duration += segment.End - segment.Start
+	}
+
+	for i := range samples {
+		segments := samples[i].Segments
+		sort.SliceStable(segments, func(a, b int) bool {
+			return segments[a].End-segments[a].Start > segments[b].End-segments[b].Start
+		})
+		if perSpeaker > 0 && len(segments) > perSpeaker {
+			segments = segments[:perSpeaker]
+		}
+		sort.SliceStable(segments, func(a, b int) bool { return segments[a].Start < segments[b].Start })
+		samples[i].Segments = segments
+	}
+	return samples
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func seg(speaker string, start, end int) providers.TranscriptionSegment {
+	return providers.TranscriptionSegment{
+		Text:      speaker,
+		SpeakerID: speaker,
+		Start:     time.Duration(start) * time.Second,
+		End:       time.Duration(end) * time.Second,
+	}
+}
+
+func TestReconcileSpeakers(t *testing.T) {
+	chunks := []*providers.TranscriptionResult{
+		{ChunkID: 0, Text: "first", Segments: []providers.TranscriptionSegment{
+			seg("Speaker 1", 0, 40), seg("Speaker 2", 40, 55), seg("Speaker 1", 55, 65),
+		}},
+		// The provider swapped the labels and a third voice joins
+		{ChunkID: 1, Text: "Speaker 2: hi\nSpeaker 1: hello", Segments: []providers.TranscriptionSegment{
+			seg("Speaker 1", 50, 56), seg("Speaker 2", 56, 65), seg("Speaker 1", 65, 90), seg("Speaker 3", 90, 100), seg("Speaker 2", 100, 120),
+		}},
+		// No segments in the overlap: labels are kept
+		{ChunkID: 2, Text: "third", Segments: []providers.TranscriptionSegment{
+			seg("Speaker 1", 125, 150),
+		}},
+	}
+
+	NewChunkMerger().reconcileSpeakers(chunks)
+
+	want := []string{"Speaker 2", "Speaker 1", "Speaker 2", "Speaker 3", "Speaker 1"}
+	for i, segment := range chunks[1].Segments {
+		if segment.SpeakerID != want[i] {
+			t.Errorf("chunk 1 segment %d speaker = %q, want %q", i, segment.SpeakerID, want[i])
+		}
+	}
+	if chunks[1].Text != "Speaker 1: hi\nSpeaker 2: hello" {
+		t.Errorf("chunk 1 text = %q", chunks[1].Text)
+	}
+	if chunks[2].Segments[0].SpeakerID != "Speaker 1" {
+		t.Errorf("chunk 2 speaker = %q, want it unchanged", chunks[2].Segments[0].SpeakerID)
+	}
+}
+
+func TestReconcileSpeakersKeepsNames(t *testing.T) {
+	chunks := []*providers.TranscriptionResult{
+		{Segments: []providers.TranscriptionSegment{seg("Alice", 0, 60)}},
+		{Segments: []providers.TranscriptionSegment{seg("Alice", 50, 60), seg("Bob", 60, 90)}},
+	}
+	NewChunkMerger().reconcileSpeakers(chunks)
+	if chunks[1].Segments[0].SpeakerID != "Alice" || chunks[1].Segments[1].SpeakerID != "Bob" {
+		t.Errorf("speakers = %q, %q", chunks[1].Segments[0].SpeakerID, chunks[1].Segments[1].SpeakerID)
+	}
+}
+
+func TestReconcileSpeakersFreshLabel(t *testing.T) {
+	chunks := []*providers.TranscriptionResult{
+		{Segments: []providers.TranscriptionSegment{seg("Speaker 1", 0, 60)}},
+		// Speaker 2 is the earlier Speaker 1, so this Speaker 1 is someone new
+		{Segments: []providers.TranscriptionSegment{seg("Speaker 2", 50, 60), seg("Speaker 1", 60, 90)}},
+	}
+	NewChunkMerger().reconcileSpeakers(chunks)
+	if chunks[1].Segments[0].SpeakerID != "Speaker 1" || chunks[1].Segments[1].SpeakerID != "Speaker 3" {
+		t.Errorf("speakers = %q, %q", chunks[1].Segments[0].SpeakerID, chunks[1].Segments[1].SpeakerID)
+	}
+}
+
+func TestSpeakerSamples(t *testing.T) {
+	result := &TranscribeResult{Segments: []providers.TranscriptionSegment{
+		seg("Speaker 2", 0, 3),
+		seg("Speaker 1", 3, 20),
+		seg("Speaker 2", 20, 30),
+		seg("Speaker 2", 30, 32),
+		seg("", 32, 40),
+		seg("Speaker 2", 40, 60),
+	}}
+
+	samples := SpeakerSamples(result, 2)
+	if len(samples) != 2 || samples[0].Speaker != "Speaker 2" || samples[1].Speaker != "Speaker 1" {
+		t.Fatalf("SpeakerSamples() = %+v, want Speaker 2 then Speaker 1", samples)
+	}
+	first := samples[0]
+	if first.Count != 4 || first.Duration != 35*time.Second {
+		t.Errorf("Speaker 2 count = %d, duration = %v, want 4 and 35s", first.Count, first.Duration)
+	}
+	if len(first.Segments) != 2 || first.Segments[0].Start != 20*time.Second || first.Segments[1].Start != 40*time.Second {
+		t.Errorf("Speaker 2 samples = %+v, want the two longest in time order", first.Segments)
+	}
+}
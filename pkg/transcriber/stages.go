@@ -0,0 +1,375 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/events"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// stageLogger returns the logger of the built-in stages for a state
+func stageLogger(state *PipelineState) *logger.Logger {
+	return logger.WithComponent("transcriber").WithField("file", filepath.Base(state.Request.FilePath)).WithField("job", state.Request.JobID)
+}
+
+// probeStage validates the input file, hashes it and reads its audio info
+func (t *TranscriberImpl) probeStage(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	req := state.Request
+
+	log.Debug().Msg("Validating input file")
+	if err := t.processor.ValidateFileContext(ctx, req.FilePath); err != nil {
+		log.Error().Err(err).Msg("File validation failed")
+		return fmt.Errorf("file validation failed: %w", err)
+	}
+
+	// Chunk keys are derived from the source hash so they stay stable
+	// across runs, however the file is split
+	sourceHash, err := audio.HashFile(req.FilePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to hash input file")
+		return fmt.Errorf("failed to hash input file: %w", err)
+	}
+	state.sourceHash = sourceHash
+
+	log.Debug().Msg("Getting audio information")
+	audioInfo, err := t.processor.GetAudioInfoContext(ctx, req.FilePath)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get audio info")
+		return fmt.Errorf("failed to get audio info: %w", err)
+	}
+	state.AudioInfo = audioInfo
+	state.AudioPath = req.FilePath
+
+	log.Info().
+		Dur("duration", audioInfo.Duration).
+		Bool("is_video", audioInfo.IsVideo).
+		Str("format", string(audioInfo.Format)).
+		Msg("Audio information retrieved")
+	return nil
+}
+
+// convertStage extracts the audio of video files and removes long
+// silences when the request asks for it
+func (t *TranscriberImpl) convertStage(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	req := state.Request
+
+	if state.AudioInfo.IsVideo {
+		log.Info().Msg("Converting video to audio")
+		audioPath, err := t.convertVideoToAudio(ctx, req.FilePath, state.AudioInfo.Duration)
+		if err != nil {
+			log.Error().Err(err).Msg("Video conversion failed")
+			return fmt.Errorf("video conversion failed: %w", err)
+		}
+		log.Info().Str("audio_path", audioPath).Msg("Video converted to audio")
+		state.AudioPath = audioPath
+		state.OnCleanup(func() {
+			if !req.Options.PreserveAudio {
+				log.Debug().Str("audio_path", audioPath).Msg("Cleaning up converted audio file")
+				_ = os.Remove(audioPath)
+			}
+		})
+	}
+
+	// Cut long silences so they are not paid for. Chunks are keyed apart
+	// from chunks of the full recording, and times are mapped back to it
+	// once the chunks are merged.
+	if req.Options.SkipSilence {
+		speechPath, timeMap := t.skipSilence(ctx, state.AudioPath, state.AudioInfo.Duration)
+		if timeMap != nil {
+			state.AudioPath, state.TimeMap = speechPath, timeMap
+			state.sourceHash = timeMap.Key(state.sourceHash)
+			state.OnCleanup(func() {
+				if !req.Options.PreserveAudio {
+					_ = os.Remove(speechPath)
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// chunkStage splits the audio into keyed chunks. Only their boundaries
+// are planned here; the files are written during StageTranscribe, so the
+// first chunks are transcribed while later ones are still being cut.
+func (t *TranscriberImpl) chunkStage(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	req := state.Request
+
+	log.Info().
+		Int("chunk_minutes", req.Options.ChunkMinutes).
+		Int("overlap_seconds", req.Options.OverlapSeconds).
+		Msg("Planning audio chunks")
+	// Filtered chunks are different audio, keyed apart from unfiltered ones
+	filters := t.audioFilters()
+	if err := filters.Validate(); err != nil {
+		return fmt.Errorf("invalid audio filters: %w", err)
+	}
+	state.sourceHash = filters.Key(state.sourceHash)
+
+	// Converted or silence-skipped audio differs from the probed file
+	info := state.AudioInfo
+	if state.AudioPath != req.FilePath {
+		var err error
+		if info, err = t.processor.GetAudioInfoContext(ctx, state.AudioPath); err != nil {
+			log.Error().Err(err).Msg("Failed to get audio info")
+			return fmt.Errorf("failed to create chunks: failed to get audio info: %w", err)
+		}
+	}
+
+	chunks := t.chunker.PlanChunks(*info, t.processorOptions(ctx, req.Options))
+	for _, chunk := range chunks {
+		chunk.Key = audio.ChunkKey(state.sourceHash, chunk.Start, chunk.End)
+	}
+	state.Chunks, state.unwritten = chunks, true
+	state.OnCleanup(func() {
+		if !req.Options.PreserveAudio {
+			log.Debug().Int("chunk_count", len(chunks)).Msg("Cleaning up chunk files")
+			_ = t.chunker.CleanupChunks(chunks)
+		}
+	})
+
+	log.Info().Int("chunk_count", len(chunks)).Msg("Audio chunks planned")
+	t.events.Publish(events.Event{
+		Type:     events.ChunksCreated,
+		FilePath: req.FilePath,
+		JobID:    req.JobID,
+		ChunkID:  -1,
+		Total:    len(chunks),
+	})
+	return nil
+}
+
+// transcribeStage prepares the prompt, checks the budget and transcribes
+// the chunks in parallel, writing the chunk files as they are needed
+func (t *TranscriberImpl) transcribeStage(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	if err := t.preparePrompt(ctx, state); err != nil {
+		return err
+	}
+	req, provider, chunks := state.Request, state.Provider, state.Chunks
+
+	// Pick the merger before paying for chunks, so a bad strategy fails early
+	merger, reconciler, err := t.chunkMerger(ctx, provider, req, state.AudioPath, chunks)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid merge strategy")
+		return err
+	}
+	state.merger, state.reconciler = merger, reconciler
+
+	// Finished chunks are checkpointed so an interrupted run can resume
+	cp := t.openCheckpoint(req, provider, state.sourceHash)
+	state.checkpoint = cp
+
+	// Enforce the spending budget before anything is uploaded
+	estimate := t.estimateChunks(provider, chunks, req.CustomPrompt, state.voices.duration(), nativeDiarization(provider, req, state.voices), cp)
+	if err := t.budget.Reserve(ctx, estimate); err != nil {
+		log.Error().
+			Err(err).
+			Int("estimated_tokens", estimate.Tokens).
+			Float64("estimated_cost", estimate.Cost).
+			Msg("Budget check failed")
+		return fmt.Errorf("budget check failed: %w", err)
+	}
+
+	log.Info().
+		Int("workers", req.Options.Workers).
+		Int("chunks", len(chunks)).
+		Msg("Starting parallel chunk transcription")
+	onSegment := state.onSegment
+	if onSegment != nil && len(req.Options.SpeakerMap) > 0 {
+		emit := onSegment
+		onSegment = func(segment providers.TranscriptionSegment) {
+			if name, ok := lookupSpeaker(req.Options.SpeakerMap, segment.SpeakerID); ok {
+				segment.SpeakerID = name
+			}
+			emit(segment)
+		}
+	}
+	if onSegment != nil && state.TimeMap != nil {
+		emit, timeMap := onSegment, state.TimeMap
+		onSegment = func(segment providers.TranscriptionSegment) {
+			mapSegment(&segment, timeMap)
+			emit(segment)
+		}
+	}
+	stream := newSegmentStreamer(chunks, onSegment)
+
+	// Chunk files are cut while earlier chunks are transcribed; in-memory
+	// chunks only as workers free up, so they do not pile up in memory
+	var ready chan *audio.ChunkInfo
+	created := make(chan error, 1)
+	if state.unwritten {
+		state.unwritten = false
+		options := t.processorOptions(ctx, req.Options)
+		buffer := len(chunks)
+		var size int64
+		if options.InMemory {
+			buffer = 0
+		} else {
+			for _, chunk := range chunks {
+				size += t.encoding().MaxSize(chunk.Duration)
+			}
+		}
+		release, err := t.reserveTemp(ctx, size)
+		if err != nil {
+			return err
+		}
+		ready = make(chan *audio.ChunkInfo, buffer)
+		go func() {
+			defer release()
+			created <- t.chunker.CreateChunks(ctx, state.AudioPath, chunks, options, ready)
+		}()
+	} else {
+		created <- nil
+	}
+
+	results, gaps, err := t.transcribeChunks(ctx, provider, chunks, req, state.voices, cp, state.callback, stream, ready)
+	if createErr := <-created; createErr != nil {
+		log.Error().Err(createErr).Msg("Failed to create chunks")
+		return fmt.Errorf("failed to create chunks: %w", createErr)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Chunk transcription failed")
+		return fmt.Errorf("chunk transcription failed: %w", err)
+	}
+	state.ChunkResults, state.Gaps = results, gaps
+	return nil
+}
+
+// preparePrompt adds the style, language tagging and voice profiles to
+// the request's prompt
+func (t *TranscriberImpl) preparePrompt(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	req := state.Request
+
+	// The style's instructions become part of the prompt
+	if req.Options.Style != "" {
+		styled := *req
+		styled.CustomPrompt = stylePrompt(req.CustomPrompt, req.Options.Style)
+		req = &styled
+	}
+
+	// Lines are tagged with their language for code-switching audio
+	if req.Options.SegmentLanguages {
+		tagged := *req
+		tagged.CustomPrompt = languagePrompt(req.CustomPrompt, t.config.Transcribe.DefaultPrompt)
+		req = &tagged
+	}
+
+	// Speakers are matched against voice profiles prepended to every chunk
+	voices, err := t.openVoiceProfiles(ctx, req.Options.VoiceProfilesDir)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to prepare voice profiles")
+		return fmt.Errorf("failed to prepare voice profiles: %w", err)
+	}
+	state.OnCleanup(voices.cleanup)
+	if req.Options.NativeDiarization && !nativeDiarization(state.Provider, req, voices) {
+		log.Warn().
+			Bool("voice_profiles", voices != nil).
+			Msg("Native diarization is unavailable, labeling speakers through the prompt")
+	}
+	if voices != nil {
+		prompt := req.CustomPrompt
+		if prompt == "" {
+			prompt = t.config.Transcribe.DefaultPrompt
+		}
+		withVoices := *req
+		withVoices.CustomPrompt = voices.prompt(prompt)
+		req = &withVoices
+	}
+
+	state.Request, state.voices = req, voices
+	return nil
+}
+
+// mergeStage combines the chunk transcripts into the result
+func (t *TranscriberImpl) mergeStage(ctx context.Context, state *PipelineState) error {
+	log := stageLogger(state)
+	req, chunks, results := state.Request, state.Chunks, state.ChunkResults
+
+	log.Info().Msg("Merging transcription results")
+	merger := state.merger
+	if merger == nil {
+		merger = t.merger
+	}
+	finalResult, err := merger.MergeChunks(completedChunks(results))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to merge chunks")
+		return fmt.Errorf("failed to merge chunks: %w", err)
+	}
+
+	// Rename speakers once, after the chunks are combined
+	RelabelSpeakers(finalResult, req.Options.SpeakerMap)
+	mapToSource(finalResult, state.Gaps, state.TimeMap)
+
+	// Fill in additional metadata
+	finalResult.FilePath = req.FilePath
+	finalResult.Duration = state.AudioInfo.Duration
+	finalResult.ChunkCount = len(chunks)
+	finalResult.ProcessTime = time.Since(state.started)
+	finalResult.Provider = state.Provider.Name()
+	t.accountUsage(finalResult, chunks, results)
+	setChunkTimings(finalResult, results)
+	setLanguageMismatches(finalResult, results, state.TimeMap)
+	state.reconciler.account(finalResult)
+	setChunkKeys(finalResult, chunks)
+	if len(state.Gaps) > 0 {
+		if finalResult.Metadata == nil {
+			finalResult.Metadata = make(map[string]interface{})
+		}
+		finalResult.Metadata[MetadataPartial] = true
+		finalResult.Metadata[MetadataGaps] = state.Gaps
+	}
+	setLanguages(finalResult, results)
+	setQuality(finalResult, chunks, results)
+	if ranges := state.voices.ranges(); len(ranges) > 0 {
+		if finalResult.Metadata == nil {
+			finalResult.Metadata = make(map[string]interface{})
+		}
+		finalResult.Metadata[MetadataVoiceProfiles] = ranges
+	}
+	if req.Options.Style != "" {
+		if finalResult.Metadata == nil {
+			finalResult.Metadata = make(map[string]interface{})
+		}
+		finalResult.Metadata[MetadataStyle] = string(req.Options.Style)
+	}
+
+	state.Result = finalResult
+	return nil
+}
+
+// postprocessStage runs the post-processing steps on the result
+func (t *TranscriberImpl) postprocessStage(ctx context.Context, state *PipelineState) error {
+	t.postProcess(ctx, state.Provider, state.Result)
+	return nil
+}
+
+// renderStage writes the result to the request's OutputPath, if any,
+// and the extra OutputFormats next to it
+func (t *TranscriberImpl) renderStage(ctx context.Context, state *PipelineState) error {
+	req := state.Request
+	outputPath := req.OutputPath
+	if outputPath == "" {
+		return nil
+	}
+	log := stageLogger(state)
+
+	log.Info().Str("output_path", outputPath).Msg("Saving transcription result")
+	outputPath, timings, err := SaveOutputs(ctx, state.Result, outputPath, req.Options, t.cipher)
+	state.Result.Metadata[MetadataRenderTimings] = timings
+	if err != nil {
+		log.Error().Err(err).Str("output_path", outputPath).Msg("Failed to save result")
+		return fmt.Errorf("failed to save result: %w", err)
+	}
+	log.Info().Str("output_path", outputPath).Int("outputs", len(timings)).Msg("Transcription result saved")
+	return nil
+}
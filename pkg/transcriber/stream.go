@@ -0,0 +1,83 @@
+package transcriber
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// segmentStreamer hands chunk segments to a SegmentCallback as chunks
+// finish. Chunks complete out of order, so later chunks are held until
+// the ones before them are done; segments starting inside audio that was
+// already emitted (the chunk overlap) are dropped. A nil streamer does
+// nothing.
+type segmentStreamer struct {
+	mu        sync.Mutex
+	onSegment SegmentCallback
+	chunks    []*audio.ChunkInfo
+	done      map[int]*providers.TranscriptionResult
+	next      int
+	emitted   time.Duration // End of the last emitted segment
+}
+
+// newSegmentStreamer returns a streamer for chunks, or nil if onSegment is nil
+func newSegmentStreamer(chunks []*audio.ChunkInfo, onSegment SegmentCallback) *segmentStreamer {
+	if onSegment == nil {
+		return nil
+	}
+	return &segmentStreamer{
+		onSegment: onSegment,
+		chunks:    chunks,
+		done:      make(map[int]*providers.TranscriptionResult),
+	}
+}
+
+// chunkDone records a finished chunk and emits every chunk that is now
+// next in order. A nil result marks a failed chunk, which is skipped.
+func (s *segmentStreamer) chunkDone(index int, result *providers.TranscriptionResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result == nil {
+		result = &providers.TranscriptionResult{}
+	}
+	s.done[index] = result
+
+	for s.next < len(s.chunks) {
+		ready, ok := s.done[s.next]
+		if !ok {
+			return
+		}
+		delete(s.done, s.next)
+		s.emit(s.chunks[s.next], ready)
+		s.next++
+	}
+}
+
+// emit passes a chunk's new segments to the callback. Chunks without
+// segments are emitted as one segment spanning the chunk.
+func (s *segmentStreamer) emit(chunk *audio.ChunkInfo, result *providers.TranscriptionResult) {
+	segments := result.Segments
+	if len(segments) == 0 && result.Text != "" {
+		segments = []providers.TranscriptionSegment{{
+			Text:  result.Text,
+			Start: chunk.ContentStart(),
+			End:   chunk.End - chunk.TrimmedEnd,
+		}}
+	}
+
+	for _, segment := range segments {
+		if segment.Start < s.emitted {
+			continue
+		}
+		s.onSegment(segment)
+		if segment.End > s.emitted {
+			s.emitted = segment.End
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestSegmentStreamerOrdersAndTrimsOverlap(t *testing.T) {
+	chunks := []*audio.ChunkInfo{
+		{Index: 0, Start: 0, End: 65 * time.Second},
+		{Index: 1, Start: 60 * time.Second, End: 125 * time.Second},
+		{Index: 2, Start: 120 * time.Second, End: 185 * time.Second},
+	}
+
+	var got []string
+	s := newSegmentStreamer(chunks, func(segment providers.TranscriptionSegment) {
+		got = append(got, segment.Text)
+	})
+
+	// Chunk 1 finishes first and is held until chunk 0 is done
+	s.chunkDone(1, &providers.TranscriptionResult{Segments: []providers.TranscriptionSegment{
+		{Text: "overlap", Start: 60 * time.Second, End: 64 * time.Second},
+		{Text: "second", Start: 65 * time.Second, End: 120 * time.Second},
+	}})
+	if len(got) != 0 {
+		t.Fatalf("emitted %v before chunk 0 finished", got)
+	}
+
+	s.chunkDone(0, &providers.TranscriptionResult{Segments: []providers.TranscriptionSegment{
+		{Text: "first", Start: 0, End: 65 * time.Second},
+	}})
+	s.chunkDone(2, &providers.TranscriptionResult{Text: "third"})
+
+	want := []string{"first", "second", "third"}
+	if len(got) != len(want) {
+		t.Fatalf("emitted %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("emitted %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSegmentStreamerSkipsFailedChunks(t *testing.T) {
+	chunks := []*audio.ChunkInfo{{Index: 0}, {Index: 1, Start: time.Minute, End: 2 * time.Minute}}
+
+	var got []string
+	s := newSegmentStreamer(chunks, func(segment providers.TranscriptionSegment) {
+		got = append(got, segment.Text)
+	})
+	s.chunkDone(1, &providers.TranscriptionResult{Text: "after gap"})
+	s.chunkDone(0, nil)
+
+	if len(got) != 1 || got[0] != "after gap" {
+		t.Errorf("emitted %v, want [after gap]", got)
+	}
+
+	// A nil streamer ignores chunks
+	var none *segmentStreamer
+	none.chunkDone(0, &providers.TranscriptionResult{Text: "ignored"})
+}
@@ -0,0 +1,61 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style selects a bundled prompt template for how the transcript is written
+type Style string
+
+// Transcript styles
+const (
+	// StyleVerbatim keeps every word as spoken, including fillers and
+	// false starts
+	StyleVerbatim Style = "verbatim"
+
+	// StyleClean removes fillers and false starts and lightly fixes
+	// grammar, for readable prose
+	StyleClean Style = "clean"
+
+	// StyleNotes condenses the recording into bullet-point notes
+	StyleNotes Style = "notes"
+)
+
+// MetadataStyle is the result metadata key recording the style used
+const MetadataStyle = "style"
+
+// styleBasePrompt starts the prompt of a style when there is no custom prompt
+const styleBasePrompt = "Transcribe the following audio. Add punctuation and capitalization, and mark speaker changes."
+
+// styleInstructions are appended to the prompt for each style
+var styleInstructions = map[Style]string{
+	StyleVerbatim: "Write a true verbatim transcript: include every word exactly as spoken, with filler words (um, uh), " +
+		"false starts, repetitions and stutters. Do not correct grammar or word choice.",
+	StyleClean: "Write a clean-read transcript: leave out filler words, false starts and repetitions, lightly fix grammar " +
+		"and split the text into readable paragraphs. Keep the speaker's meaning and wording otherwise; do not summarize.",
+	StyleNotes: "Instead of a transcript, write condensed notes: short bullet points grouped by topic, covering every point made. " +
+		"Keep names, numbers, decisions and action items exactly as stated.",
+}
+
+// ParseStyle validates a style name; an empty name means no style
+func ParseStyle(name string) (Style, error) {
+	style := Style(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := styleInstructions[style]; ok || style == "" {
+		return style, nil
+	}
+	return "", fmt.Errorf("unknown style %q (want verbatim, clean or notes)", name)
+}
+
+// stylePrompt returns the prompt for a style: the custom prompt, or a
+// generic transcription prompt, followed by the style's instructions
+func stylePrompt(prompt string, style Style) string {
+	instructions, ok := styleInstructions[style]
+	if !ok {
+		return prompt
+	}
+	if strings.TrimSpace(prompt) == "" {
+		prompt = styleBasePrompt
+	}
+	return prompt + "\n\n" + instructions
+}
@@ -0,0 +1,34 @@
+package transcriber
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	for name, want := range map[string]Style{"": "", "Clean": StyleClean, " notes ": StyleNotes, "verbatim": StyleVerbatim} {
+		got, err := ParseStyle(name)
+		if err != nil || got != want {
+			t.Errorf("ParseStyle(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseStyle("summary"); err == nil {
+		t.Error("ParseStyle(summary) succeeded, want an error")
+	}
+}
+
+func TestStylePrompt(t *testing.T) {
+	if got := stylePrompt("Custom.", ""); got != "Custom." {
+		t.Errorf("stylePrompt without style = %q, want the prompt unchanged", got)
+	}
+
+	got := stylePrompt("", StyleClean)
+	if !strings.HasPrefix(got, styleBasePrompt) || !strings.HasSuffix(got, styleInstructions[StyleClean]) {
+		t.Errorf("stylePrompt(\"\", clean) = %q, want the base prompt and clean instructions", got)
+	}
+
+	got = stylePrompt("Transcribe this sales call.", StyleNotes)
+	if !strings.HasPrefix(got, "Transcribe this sales call.\n\n") || strings.Contains(got, styleBasePrompt) {
+		t.Errorf("stylePrompt(custom, notes) = %q, want the custom prompt followed by the notes instructions", got)
+	}
+}
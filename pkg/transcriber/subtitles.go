@@ -0,0 +1,72 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+)
+
+// subtitleLanguages maps ISO 639-1 language codes to the ISO 639-2 codes
+// video containers tag subtitle tracks with
+var subtitleLanguages = map[string]string{
+	"ar": "ara", "de": "ger", "en": "eng", "es": "spa", "fr": "fre",
+	"he": "heb", "hi": "hin", "id": "ind", "it": "ita", "ja": "jpn",
+	"ko": "kor", "nl": "dut", "pl": "pol", "pt": "por", "ru": "rus",
+	"sv": "swe", "th": "tha", "tr": "tur", "uk": "ukr", "vi": "vie",
+	"zh": "chi",
+}
+
+// CanMuxSubtitles reports whether a file is a video whose container can
+// carry subtitle tracks
+func CanMuxSubtitles(videoPath string) bool {
+	format, ok := mediatype.FromPath(videoPath)
+	return ok && format.Video && format.Subtitles != ""
+}
+
+// SubtitledPath returns where the subtitled copy of a video is written:
+// meeting.subtitled.mp4 next to the transcript meeting.txt
+func SubtitledPath(outputPath, videoPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".subtitled" + strings.ToLower(filepath.Ext(videoPath))
+}
+
+// MuxSubtitles writes a copy of the video at videoPath to outputPath with
+// the result's SRT subtitles as a soft subtitle track, tagged with the
+// result's language, killing ffmpeg when ctx is done
+func MuxSubtitles(ctx context.Context, result *TranscribeResult, videoPath, outputPath string) error {
+	if len(result.Segments) == 0 {
+		return fmt.Errorf("result has no timestamped segments to make subtitles from")
+	}
+
+	// ffmpeg reads the subtitles from a plaintext file, even when the
+	// transcripts are encrypted at rest
+	file, err := os.CreateTemp("", "gollmscribe_subtitles_*.srt")
+	if err != nil {
+		return fmt.Errorf("failed to create subtitle file: %w", err)
+	}
+	srtPath := file.Name()
+	_ = file.Close()
+	defer func() { _ = os.Remove(srtPath) }()
+	if err := SaveResult(result, srtPath, "srt"); err != nil {
+		return err
+	}
+
+	return audio.MuxSubtitles(ctx, videoPath, srtPath, outputPath, subtitleLanguage(result.Language))
+}
+
+// subtitleLanguage returns the ISO 639-2 code of a language such as
+// "en" or "zh-TW", or "" if it is unknown
+func subtitleLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i > 0 {
+		language = language[:i]
+	}
+	if len(language) == 3 {
+		return language
+	}
+	return subtitleLanguages[language]
+}
@@ -0,0 +1,50 @@
+package transcriber
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubtitledPath(t *testing.T) {
+	got := SubtitledPath(filepath.Join("out", "meeting.txt"), filepath.Join("in", "meeting.MP4"))
+	if want := filepath.Join("out", "meeting.subtitled.mp4"); got != want {
+		t.Errorf("SubtitledPath() = %s, want %s", got, want)
+	}
+}
+
+func TestCanMuxSubtitles(t *testing.T) {
+	for path, want := range map[string]bool{
+		"talk.mp4":  true,
+		"talk.mkv":  true,
+		"talk.webm": true,
+		"talk.avi":  false,
+		"talk.mp3":  false,
+		"talk":      false,
+	} {
+		if got := CanMuxSubtitles(path); got != want {
+			t.Errorf("CanMuxSubtitles(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestSubtitleLanguage(t *testing.T) {
+	for language, want := range map[string]string{
+		"en":    "eng",
+		"zh-TW": "chi",
+		"ja_JP": "jpn",
+		"deu":   "deu",
+		"auto":  "",
+		"":      "",
+	} {
+		if got := subtitleLanguage(language); got != want {
+			t.Errorf("subtitleLanguage(%q) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestMuxSubtitlesWithoutSegments(t *testing.T) {
+	if err := MuxSubtitles(context.Background(), &TranscribeResult{Text: "hello"}, "talk.mp4", "talk.subtitled.mp4"); err == nil {
+		t.Error("MuxSubtitles() without segments succeeded")
+	}
+}
@@ -0,0 +1,117 @@
+package transcriber
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFormatPrefix marks a format rendered with a Go text/template
+// file, e.g. "template:anki.csv.tmpl" (see TemplateFormat)
+const TemplateFormatPrefix = "template:"
+
+// templateExtensions are stripped from a template's name to name its
+// output, e.g. anki.csv.tmpl writes meeting.anki.csv
+var templateExtensions = []string{".tmpl", ".tpl", ".gotmpl"}
+
+// templateFuncs are the functions available to output templates besides
+// the text/template builtins
+var templateFuncs = template.FuncMap{
+	"srtTime": formatSRTTime,
+	"vttTime": formatVTTTime,
+	"clock":   formatHTMLTime,
+	"seconds": func(d time.Duration) float64 { return d.Seconds() },
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"join":    func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"xml": func(s string) (string, error) {
+		var buf bytes.Buffer
+		err := xml.EscapeText(&buf, []byte(s))
+		return buf.String(), err
+	},
+	"csv": func(s string) (string, error) {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write([]string{s}); err != nil {
+			return "", err
+		}
+		w.Flush()
+		return strings.TrimSuffix(buf.String(), "\n"), w.Error()
+	},
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// TemplateFormat returns the format rendering the template file at path
+func TemplateFormat(path string) string {
+	return TemplateFormatPrefix + path
+}
+
+// templateFormatPath returns the template file of a template format
+func templateFormatPath(format string) (string, bool) {
+	return strings.CutPrefix(format, TemplateFormatPrefix)
+}
+
+// templateOutputPath returns the path a template's output is written to
+// next to outputPath: the template's name without its template extension,
+// e.g. meeting.xml for xml.tmpl or meeting.anki.csv for anki.csv.tmpl
+func templateOutputPath(outputPath, templatePath string) string {
+	name := filepath.Base(templatePath)
+	for _, ext := range templateExtensions {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok && trimmed != "" {
+			name = trimmed
+			break
+		}
+	}
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "." + name
+}
+
+// ParseTemplateFile parses an output template, so a broken template is
+// reported before any file is transcribed
+func ParseTemplateFile(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output template: %w", err)
+	}
+	return tmpl.Option("missingkey=error"), nil
+}
+
+// CheckFormats reports formats that cannot be rendered, such as templates
+// that fail to parse
+func CheckFormats(formats []string) error {
+	for _, format := range formats {
+		if path, ok := templateFormatPath(strings.TrimSpace(format)); ok {
+			if _, err := ParseTemplateFile(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderTemplate renders the result with the template file at path. The
+// template is executed with the TranscribeResult, so it reaches .Text,
+// .Segments, .Chapters, .Language, .Duration and the rest, and can use the
+// functions srtTime, vttTime, clock, seconds, upper, lower, trim, join,
+// replace, xml, csv and json, e.g. {{range .Segments}}{{xml .Text}}{{end}}.
+func (r *TranscribeResult) RenderTemplate(path string) ([]byte, error) {
+	tmpl, err := ParseTemplateFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("failed to render output template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
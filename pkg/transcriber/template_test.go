@@ -0,0 +1,63 @@
+package transcriber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "Deck.Anki.csv.tmpl")
+	tmpl := `{{range .Segments}}{{srtTime .Start}},{{csv .Text}},{{xml .SpeakerID}}
+{{end}}`
+	if err := os.WriteFile(tmplPath, []byte(tmpl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := &TranscribeResult{
+		Text: "Hi, there.",
+		Segments: []providers.TranscriptionSegment{
+			{Text: "Hi, there.", Start: 1500 * time.Millisecond, End: 3 * time.Second, SpeakerID: "Tom & Jerry"},
+		},
+	}
+
+	// The template's case is kept when formats are normalized
+	targets := RenderTargets(filepath.Join(dir, "talk.txt"), []string{TemplateFormat(tmplPath)})
+	if len(targets) != 2 || targets[1].Path != filepath.Join(dir, "talk.Deck.Anki.csv") {
+		t.Fatalf("RenderTargets() = %v", targets)
+	}
+	if _, err := RenderResult(context.Background(), result, targets, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(targets[1].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "00:00:01,500,\"Hi, there.\",Tom &amp; Jerry\n"; string(data) != want {
+		t.Errorf("rendered %q, want %q", data, want)
+	}
+	if _, ok := result.Metadata["saved_at"]; ok {
+		t.Error("template output was stamped like JSON")
+	}
+}
+
+func TestCheckFormats(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(broken, []byte("{{range .Segments}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckFormats([]string{"srt", TemplateFormat(broken)}); err == nil {
+		t.Error("CheckFormats() accepted a broken template")
+	}
+	if err := CheckFormats([]string{TemplateFormat(filepath.Join(dir, "missing.tmpl"))}); err == nil {
+		t.Error("CheckFormats() accepted a missing template")
+	}
+	if err := CheckFormats([]string{"srt", "json.gz"}); err != nil {
+		t.Errorf("CheckFormats() = %v", err)
+	}
+}
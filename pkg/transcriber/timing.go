@@ -0,0 +1,62 @@
+package transcriber
+
+import (
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// Metadata keys of the timing breakdowns recorded with TranscribeOptions.Timing
+const (
+	MetadataChunkTiming  = "chunk_timing"  // ChunkTiming, set on each chunk result sent
+	MetadataChunkTimings = "chunk_timings" // []ChunkTiming in chunk order, set on the merged result
+)
+
+// ChunkTiming is where the time of transcribing one chunk went: ffmpeg
+// cutting and encoding its audio, the network carrying the request, the
+// provider's model answering, and decoding the answer
+type ChunkTiming struct {
+	Chunk  int           `json:"chunk"`
+	Encode time.Duration `json:"encode"` // Cutting the chunk and prepending voice profiles
+	providers.Timing
+}
+
+// Total returns the time accounted for
+func (c ChunkTiming) Total() time.Duration {
+	return c.Encode + c.Upload + c.Latency + c.Parse
+}
+
+// setChunkTimings collects the timings of the chunks sent to the provider
+// into the merged result. Silent, cached and checkpointed chunks have none.
+func setChunkTimings(result *TranscribeResult, results []*providers.TranscriptionResult) {
+	var timings []ChunkTiming
+	for i, chunkResult := range results {
+		if chunkResult == nil {
+			continue
+		}
+		if timing, ok := chunkResult.Metadata[MetadataChunkTiming].(ChunkTiming); ok {
+			timing.Chunk = i
+			timings = append(timings, timing)
+		}
+	}
+	if result.Metadata == nil {
+		if len(timings) == 0 {
+			return
+		}
+		result.Metadata = make(map[string]interface{})
+	}
+	// The merger copies per-chunk metadata; replace it with all chunks
+	delete(result.Metadata, MetadataChunkTiming)
+	if len(timings) > 0 {
+		result.Metadata[MetadataChunkTimings] = timings
+	}
+}
+
+// ChunkTimings returns the chunk timings recorded on a result
+func (r *TranscribeResult) ChunkTimings() []ChunkTiming {
+	if r == nil || r.Metadata == nil {
+		return nil
+	}
+	timings, _ := r.Metadata[MetadataChunkTimings].([]ChunkTiming)
+	return timings
+}
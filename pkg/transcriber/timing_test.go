@@ -0,0 +1,34 @@
+package transcriber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+func TestSetChunkTimings(t *testing.T) {
+	sent := ChunkTiming{Encode: time.Second, Timing: providers.Timing{Requests: 1, Latency: 5 * time.Second}}
+	results := []*providers.TranscriptionResult{
+		{Metadata: map[string]interface{}{MetadataSilent: true}},
+		nil,
+		{Metadata: map[string]interface{}{MetadataChunkTiming: sent}},
+	}
+	// The merger copied the last chunk's timing
+	result := &TranscribeResult{Metadata: map[string]interface{}{MetadataChunkTiming: sent}}
+
+	setChunkTimings(result, results)
+	timings := result.ChunkTimings()
+	if len(timings) != 1 || timings[0].Chunk != 2 || timings[0].Total() != 6*time.Second {
+		t.Fatalf("ChunkTimings() = %+v", timings)
+	}
+	if _, ok := result.Metadata[MetadataChunkTiming]; ok {
+		t.Error("per-chunk timing was left on the merged result")
+	}
+
+	untimed := &TranscribeResult{}
+	setChunkTimings(untimed, results[:2])
+	if untimed.Metadata != nil {
+		t.Errorf("Metadata = %v, want none without timings", untimed.Metadata)
+	}
+}
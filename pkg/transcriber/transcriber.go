@@ -1,28 +1,57 @@
 package transcriber
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/budget"
+	"github.com/eternnoir/gollmscribe/pkg/cache"
 	"github.com/eternnoir/gollmscribe/pkg/config"
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/events"
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/mediatype"
+	"github.com/eternnoir/gollmscribe/pkg/pricing"
 	"github.com/eternnoir/gollmscribe/pkg/providers"
+	"github.com/eternnoir/gollmscribe/pkg/tempfiles"
 )
 
+// chunkRetryDelay is the wait before the first chunk retry; later retries
+// wait proportionally longer
+var chunkRetryDelay = 2 * time.Second
+
 // TranscriberImpl implements the Transcriber interface
 type TranscriberImpl struct {
-	provider  providers.LLMProvider
+	provider  atomic.Pointer[providers.LLMProvider]
 	processor audio.Processor
 	chunker   audio.Chunker
 	reader    audio.Reader
 	merger    ChunkMerger
 	tempDir   string
 	config    *config.Config
+	events    *events.Bus
+	limiter   *providers.RateLimiter
+	budget    *budget.Guard
+	prices    pricing.Table
+	cipher    *encryption.Cipher
+	cache     *cache.Cache
+	temp      *tempfiles.Manager
+
+	// Usable voice profiles by audio.VoiceReferenceKey of all profiles
+	voiceChecks sync.Map
+
+	postProcessors []PostProcessor
+	stages         []Stage
 }
 
 // NewTranscriber creates a new transcriber instance
@@ -32,15 +61,25 @@ func NewTranscriber(provider providers.LLMProvider, cfg *config.Config) *Transcr
 		tempDir = os.TempDir()
 	}
 
-	return &TranscriberImpl{
-		provider:  provider,
+	t := &TranscriberImpl{
 		processor: audio.NewProcessor(tempDir),
 		chunker:   audio.NewChunker(tempDir),
 		reader:    audio.NewReader(tempDir),
 		merger:    NewChunkMerger(),
 		tempDir:   tempDir,
 		config:    cfg,
+		events:    events.NewBus(),
+		limiter: providers.NewRateLimiter(
+			cfg.Provider.RateLimit.RequestsPerMinute,
+			cfg.Provider.RateLimit.TokensPerMinute,
+		),
+		budget: budget.New(cfg.Budget),
+		prices: pricing.DefaultTable().Merge(cfg.Pricing),
+		temp:   tempfiles.New(tempDir, int64(cfg.Audio.MaxTempMB)<<20),
 	}
+	t.stages = t.defaultStages()
+	t.provider.Store(&provider)
+	return t
 }
 
 // NewTranscriberWithOptions creates a new transcriber with default options
@@ -57,116 +96,156 @@ func (t *TranscriberImpl) Transcribe(ctx context.Context, req *TranscribeRequest
 
 // TranscribeWithProgress processes a file with progress reporting
 func (t *TranscriberImpl) TranscribeWithProgress(ctx context.Context, req *TranscribeRequest, callback ProgressCallback) (*TranscribeResult, error) {
-	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(req.FilePath))
-	startTime := time.Now()
+	return t.transcribeWithEvents(ctx, req, callback, nil)
+}
 
-	log.Info().
-		Str("output_path", req.OutputPath).
-		Interface("options", req.Options).
-		Msg("Starting transcription with progress")
+// TranscribeStream processes a file, passing segments to onSegment as soon
+// as the chunks before them are done
+func (t *TranscriberImpl) TranscribeStream(ctx context.Context, req *TranscribeRequest, onSegment SegmentCallback) (*TranscribeResult, error) {
+	return t.transcribeWithEvents(ctx, req, nil, onSegment)
+}
+
+// transcribeWithEvents runs transcribe, publishing its lifecycle events
+func (t *TranscriberImpl) transcribeWithEvents(ctx context.Context, req *TranscribeRequest, callback ProgressCallback, onSegment SegmentCallback) (*TranscribeResult, error) {
+	req = withJobID(req)
+	t.events.Publish(events.Event{
+		Type:     events.TranscriptionStarted,
+		FilePath: req.FilePath,
+		JobID:    req.JobID,
+		ChunkID:  -1,
+	})
 
-	// Validate input file
-	log.Debug().Msg("Validating input file")
-	if err := t.processor.ValidateFile(req.FilePath); err != nil {
-		log.Error().Err(err).Msg("File validation failed")
-		return nil, fmt.Errorf("file validation failed: %w", err)
+	result, err := t.transcribe(ctx, req, callback, onSegment)
+	if err != nil {
+		t.events.Publish(events.Event{
+			Type:     events.TranscriptionFailed,
+			FilePath: req.FilePath,
+			JobID:    req.JobID,
+			ChunkID:  -1,
+			Error:    err,
+		})
+		return nil, err
 	}
 
-	// Get audio info
-	log.Debug().Msg("Getting audio information")
-	audioInfo, err := t.processor.GetAudioInfo(req.FilePath)
+	t.events.Publish(events.Event{
+		Type:      events.TranscriptionCompleted,
+		FilePath:  req.FilePath,
+		JobID:     req.JobID,
+		ChunkID:   -1,
+		Completed: result.ChunkCount,
+		Total:     result.ChunkCount,
+	})
+	return result, nil
+}
+
+// transcribe runs the full transcription pipeline for a single file
+func (t *TranscriberImpl) transcribe(ctx context.Context, req *TranscribeRequest, callback ProgressCallback, onSegment SegmentCallback) (*TranscribeResult, error) {
+	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(req.FilePath)).WithField("job", req.JobID)
+
+	// Every chunk of a file goes to the same provider, even if it is
+	// swapped while the file is in flight
+	provider := t.Provider()
+
+	// Temp files of the run go to its own directory, removed afterwards
+	// or swept on a later start if the process dies first
+	ctx, job, err := t.startJob(ctx, req)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get audio info")
-		return nil, fmt.Errorf("failed to get audio info: %w", err)
+		return nil, err
 	}
+	defer func() { _ = job.Close() }()
 
-	log.Info().
-		Dur("duration", audioInfo.Duration).
-		Bool("is_video", audioInfo.IsVideo).
-		Str("format", string(audioInfo.Format)).
-		Msg("Audio information retrieved")
-
-	// Convert video to audio if needed
-	audioPath := req.FilePath
-	if audioInfo.IsVideo {
-		log.Info().Msg("Converting video to audio")
-		audioPath, err = t.convertVideoToAudio(req.FilePath)
+	source := req
+	if req.Audio != nil {
+		spooled, err := t.spoolAudio(ctx, req)
 		if err != nil {
-			log.Error().Err(err).Msg("Video conversion failed")
-			return nil, fmt.Errorf("video conversion failed: %w", err)
+			log.Error().Err(err).Msg("Failed to spool audio")
+			return nil, err
 		}
-		log.Info().Str("audio_path", audioPath).Msg("Video converted to audio")
-		defer func() {
-			if !req.Options.PreserveAudio {
-				log.Debug().Str("audio_path", audioPath).Msg("Cleaning up converted audio file")
-				_ = os.Remove(audioPath)
-			}
-		}()
+		defer func() { _ = os.Remove(spooled.FilePath) }()
+		source = spooled
 	}
 
-	// Create audio chunks
-	log.Info().
-		Int("chunk_minutes", req.Options.ChunkMinutes).
-		Int("overlap_seconds", req.Options.OverlapSeconds).
-		Msg("Creating audio chunks")
-	chunks, err := t.createChunks(audioPath, req.Options)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create chunks")
-		return nil, fmt.Errorf("failed to create chunks: %w", err)
-	}
-	log.Info().Int("chunk_count", len(chunks)).Msg("Audio chunks created")
-	defer func() {
-		if !req.Options.PreserveAudio {
-			log.Debug().Int("chunk_count", len(chunks)).Msg("Cleaning up chunk files")
-			_ = t.chunker.CleanupChunks(chunks)
-		}
-	}()
-
-	// Transcribe chunks in parallel
-	log.Info().
-		Int("workers", req.Options.Workers).
-		Int("chunks", len(chunks)).
-		Msg("Starting parallel chunk transcription")
-	results, err := t.transcribeChunks(ctx, chunks, req, callback)
-	if err != nil {
-		log.Error().Err(err).Msg("Chunk transcription failed")
-		return nil, fmt.Errorf("chunk transcription failed: %w", err)
+	var finalResult *TranscribeResult
+	var checkpoints []*checkpoint
+	if len(req.Options.ChannelSpeakers) > 0 {
+		finalResult, checkpoints, err = t.transcribeChannels(ctx, provider, source, callback, onSegment)
+	} else {
+		var cp *checkpoint
+		finalResult, cp, err = t.transcribeSource(ctx, provider, source, callback, onSegment)
+		checkpoints = []*checkpoint{cp}
 	}
-
-	// Merge results
-	log.Info().Msg("Merging transcription results")
-	finalResult, err := t.merger.MergeChunks(results)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to merge chunks")
-		return nil, fmt.Errorf("failed to merge chunks: %w", err)
+		return nil, err
 	}
-
-	// Fill in additional metadata
 	finalResult.FilePath = req.FilePath
-	finalResult.Duration = audioInfo.Duration
-	finalResult.ChunkCount = len(chunks)
-	finalResult.ProcessTime = time.Since(startTime)
-	finalResult.Provider = t.provider.Name()
+	finalResult.JobID = req.JobID
+	AddNotes(finalResult, req.Notes...)
+	if err := t.finishResult(ctx, provider, req, finalResult); err != nil {
+		return nil, err
+	}
 
 	log.Info().
 		Int("final_text_length", len(finalResult.Text)).
 		Int("segments", len(finalResult.Segments)).
 		Dur("processing_time", finalResult.ProcessTime).
+		Float64("cost_usd", finalResult.Cost).
 		Msg("Transcription results merged")
 
-	// Save output if specified
-	if req.OutputPath != "" {
-		log.Info().Str("output_path", req.OutputPath).Msg("Saving transcription result")
-		if err := t.saveResult(finalResult, req.OutputPath, "text"); err != nil {
-			log.Error().Err(err).Str("output_path", req.OutputPath).Msg("Failed to save result")
-			return nil, fmt.Errorf("failed to save result: %w", err)
+	// Keep the checkpoint of a partial result so --resume can fill the gaps
+	if partial, _ := finalResult.Metadata[MetadataPartial].(bool); !partial {
+		for _, cp := range checkpoints {
+			cp.remove()
 		}
-		log.Info().Str("output_path", req.OutputPath).Msg("Transcription result saved")
 	}
 
 	return finalResult, nil
 }
 
+// transcribeSource runs the source stages of the pipeline, from probing
+// to merging, on one audio or video file and returns the result with the
+// checkpoint of its chunks
+func (t *TranscriberImpl) transcribeSource(ctx context.Context, provider providers.LLMProvider, req *TranscribeRequest, callback ProgressCallback, onSegment SegmentCallback) (*TranscribeResult, *checkpoint, error) {
+	log := logger.WithComponent("transcriber").WithField("file", filepath.Base(req.FilePath)).WithField("job", req.JobID)
+	log.Info().
+		Str("output_path", req.OutputPath).
+		Interface("options", req.Options).
+		Msg("Starting transcription with progress")
+
+	state := &PipelineState{
+		Request:   req,
+		Provider:  provider,
+		started:   time.Now(),
+		callback:  callback,
+		onSegment: onSegment,
+	}
+	defer state.cleanup()
+
+	if err := runStages(ctx, state, t.sourceStages()); err != nil {
+		return nil, nil, err
+	}
+	if state.Result == nil {
+		return nil, nil, fmt.Errorf("pipeline finished without a result")
+	}
+	addStageTimings(state.Result, state.timings)
+	return state.Result, state.checkpoint, nil
+}
+
+// finishResult runs the result stages of the pipeline, post-processing
+// and rendering, on a finished transcript
+func (t *TranscriberImpl) finishResult(ctx context.Context, provider providers.LLMProvider, req *TranscribeRequest, result *TranscribeResult) error {
+	state := &PipelineState{
+		Request:  req,
+		Provider: provider,
+		Result:   result,
+		started:  time.Now(),
+	}
+	defer state.cleanup()
+
+	err := runStages(ctx, state, t.resultStages())
+	addStageTimings(result, state.timings)
+	return err
+}
+
 // TranscribeBatch processes multiple files
 func (t *TranscriberImpl) TranscribeBatch(ctx context.Context, requests []*TranscribeRequest) ([]*TranscribeResult, error) {
 	results := make([]*TranscribeResult, len(requests))
@@ -204,91 +283,457 @@ func (t *TranscriberImpl) TranscribeBatch(ctx context.Context, requests []*Trans
 
 // SupportedFormats returns supported file formats
 func (t *TranscriberImpl) SupportedFormats() []string {
-	return []string{
-		"audio/wav", "audio/mp3", "audio/mpeg", "audio/m4a", "audio/flac",
-		"video/mp4", "video/avi", "video/mov", "video/mkv",
-	}
+	return mediatype.MIMETypes("wav", "mp3", "m4a", "flac", "ogg", "opus", "webm", "aac", "wma", "amr", "3gp", "mp4", "avi", "mov", "mkv")
 }
 
-// SetProvider changes the LLM provider
+// SetProvider changes the LLM provider. It is safe to call while files are
+// being transcribed: files already started finish on the old provider.
 func (t *TranscriberImpl) SetProvider(provider providers.LLMProvider) {
-	t.provider = provider
+	t.SwapProvider(provider)
+}
+
+// SwapProvider atomically replaces the LLM provider for files started from
+// now on and returns the previous one, e.g. to close it once in-flight
+// files are done. It lets long-running watch sessions switch providers
+// between files without a restart.
+func (t *TranscriberImpl) SwapProvider(provider providers.LLMProvider) providers.LLMProvider {
+	old := *t.provider.Swap(&provider)
+	logger.WithComponent("transcriber").Info().
+		Str("old_provider", old.Name()).
+		Str("new_provider", provider.Name()).
+		Msg("Provider swapped")
+	t.events.Publish(events.Event{
+		Type:    events.ProviderSwapped,
+		ChunkID: -1,
+		Message: provider.Name(),
+	})
+	return old
+}
+
+// Provider returns the provider used for newly started files
+func (t *TranscriberImpl) Provider() providers.LLMProvider {
+	return *t.provider.Load()
+}
+
+// Events returns the bus on which lifecycle events are published
+func (t *TranscriberImpl) Events() *events.Bus {
+	return t.events
+}
+
+// SetBudget replaces the spending guard, letting several transcribers
+// (e.g. watch routes) share one per-run budget
+func (t *TranscriberImpl) SetBudget(guard *budget.Guard) {
+	t.budget = guard
+}
+
+// SetCipher enables encryption of saved results; nil writes plaintext
+func (t *TranscriberImpl) SetCipher(c *encryption.Cipher) {
+	t.cipher = c
+}
+
+// SetTempFiles replaces the temp file manager, letting several
+// transcribers (e.g. watch routes) share one quota
+func (t *TranscriberImpl) SetTempFiles(m *tempfiles.Manager) {
+	t.temp = m
+}
+
+// startJob creates the temp directory of a run and returns a context
+// carrying it. Temp files are kept with PreserveAudio.
+func (t *TranscriberImpl) startJob(ctx context.Context, req *TranscribeRequest) (context.Context, *tempfiles.Job, error) {
+	job, err := t.temp.StartJob(req.JobID)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if req.Options.PreserveAudio {
+		job.Keep()
+		logger.WithComponent("transcriber").Info().Str("temp_dir", job.Dir()).Msg("Keeping temporary audio files")
+	}
+	return tempfiles.WithJob(ctx, job), job, nil
+}
+
+// scratchDir returns where temp files go: the directory of the job in
+// ctx, or the temp directory outside of a job
+func (t *TranscriberImpl) scratchDir(ctx context.Context) string {
+	if job := tempfiles.JobFromContext(ctx); job != nil {
+		return job.Dir()
+	}
+	return t.tempDir
+}
+
+// reserveTemp reserves n bytes of the temp quota for the job in ctx
+// before writing them, see tempfiles.Job.Reserve
+func (t *TranscriberImpl) reserveTemp(ctx context.Context, n int64) (func(), error) {
+	return tempfiles.JobFromContext(ctx).Reserve(n)
+}
+
+// SetCache enables reuse of provider responses for identical chunks; nil
+// disables caching
+func (t *TranscriberImpl) SetCache(c *cache.Cache) {
+	t.cache = c
+}
+
+// EstimateCost predicts the cost of transcribing a file from its duration
+// and the provider's model prices, without uploading anything. It reports
+// false if the provider's models are not in the pricing table. It kills
+// ffprobe when ctx is done.
+func (t *TranscriberImpl) EstimateCost(ctx context.Context, req *TranscribeRequest) (float64, bool, error) {
+	estimate, ok, err := t.EstimateUsage(ctx, req)
+	return estimate.Cost, ok, err
+}
+
+// EstimateUsage predicts the chunks, tokens and cost of transcribing a file
+// from its duration, like EstimateCost, e.g. to check a batch against the
+// quota left. It reports false if the provider's models are not in the
+// pricing table. It kills ffprobe when ctx is done.
+func (t *TranscriberImpl) EstimateUsage(ctx context.Context, req *TranscribeRequest) (budget.Estimate, bool, error) {
+	info, err := t.processor.GetAudioInfoContext(ctx, req.FilePath)
+	if err != nil {
+		return budget.Estimate{}, false, fmt.Errorf("failed to get audio info: %w", err)
+	}
+
+	chunkDuration := time.Duration(req.Options.ChunkMinutes) * time.Minute
+	if chunkDuration <= 0 {
+		chunkDuration = 30 * time.Minute
+	}
+	overlap := time.Duration(req.Options.OverlapSeconds) * time.Second
+	if overlap <= 0 {
+		overlap = 60 * time.Second
+	}
+
+	// Every chunk after the first also re-sends the overlap
+	chunks := int((info.Duration + chunkDuration - 1) / chunkDuration)
+	if chunks < 1 {
+		chunks = 1
+	}
+	billed := info.Duration + time.Duration(chunks-1)*overlap
+
+	prompt := stylePrompt(req.CustomPrompt, req.Options.Style)
+	cost, ok := t.prices.EstimateCost(providers.Models(t.Provider()), billed/time.Duration(chunks), prompt)
+	estimate := budget.Estimate{
+		Chunks: chunks,
+		Tokens: chunks * providers.EstimateTokens(billed/time.Duration(chunks), prompt),
+		Cost:   cost * float64(chunks),
+	}
+	if !ok {
+		estimate.Cost = t.budget.EstimateCost(estimate.Tokens)
+	}
+
+	// Every channel is transcribed as a file of its own
+	if channels := len(req.Options.ChannelSpeakers); channels > 0 {
+		estimate.Chunks *= channels
+		estimate.Tokens *= channels
+		estimate.Cost *= float64(channels)
+	}
+	return estimate, ok, nil
+}
+
+// estimateChunks predicts the usage of transcribing chunks, skipping chunks
+// that are checkpointed or have a cached response. Each chunk is looked up
+// with the prompt transcribeChunk sends, prompt plus any leading context
+// starting offset into the audio. Cost comes from the pricing table, or the
+// budget's flat token price for unknown models.
+func (t *TranscriberImpl) estimateChunks(provider providers.LLMProvider, chunks []*audio.ChunkInfo, prompt string, offset time.Duration, diarize bool, cp *checkpoint) budget.Estimate {
+	estimate := budget.Estimate{Chunks: len(chunks)}
+	models := providers.Models(provider)
+	priced := true
+	for _, chunk := range chunks {
+		// Silent, checkpointed and cached chunks are not sent
+		if chunk.Silent || cp.result(chunk) != nil {
+			continue
+		}
+		chunkPrompt := contextPrompt(prompt, chunk, offset)
+		if _, cached := t.lookupChunkCache(provider, chunk, chunkPrompt, diarize); cached != nil {
+			continue
+		}
+		estimate.Tokens += providers.EstimateTokens(chunk.AudioDuration(), chunkPrompt)
+		cost, ok := t.prices.EstimateCost(models, chunk.AudioDuration(), chunkPrompt)
+		priced = priced && ok
+		estimate.Cost += cost
+	}
+	if !priced {
+		estimate.Cost = t.budget.EstimateCost(estimate.Tokens)
+	}
+	return estimate
+}
+
+// accountUsage totals the token usage of all chunks into the result and
+// estimates its cost with each chunk's model price. The cost stays 0 if
+// any chunk used a model missing from the price table.
+func (t *TranscriberImpl) accountUsage(result *TranscribeResult, chunks []*audio.ChunkInfo, results []*providers.TranscriptionResult) {
+	var usage providers.Usage
+	var cost float64
+	priced := true
+
+	for i, chunkResult := range results {
+		if chunkResult == nil {
+			continue
+		}
+		model, _ := chunkResult.Metadata["model"].(string)
+		if result.Model == "" {
+			result.Model = model
+		}
+
+		// Cached and checkpointed results were paid for by an earlier run
+		hit, _ := chunkResult.Metadata[cache.MetadataHit].(bool)
+		resumed, _ := chunkResult.Metadata[MetadataResumed].(bool)
+		if hit || resumed {
+			continue
+		}
+
+		chunkUsage := providers.UsageFromMetadata(chunkResult.Metadata)
+		usage = usage.Add(chunkUsage)
+		chunkCost, ok := t.prices.Cost(model, chunkUsage, chunks[i].AudioDuration())
+		if !ok {
+			priced = false
+		}
+		cost += chunkCost
+	}
+
+	if !usage.IsZero() {
+		result.Usage = &usage
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		// The merger copies per-chunk metadata; replace it with the totals
+		usage.SetMetadata(result.Metadata)
+	}
+	if priced {
+		result.Cost = cost
+	} else {
+		logger.WithComponent("transcriber").Debug().
+			Str("model", result.Model).
+			Msg("No price for model, skipping cost estimate")
+	}
 }
 
 // convertVideoToAudio converts video file to audio
-func (t *TranscriberImpl) convertVideoToAudio(videoPath string) (string, error) {
-	audioPath := filepath.Join(t.tempDir, fmt.Sprintf("audio_%d.mp3", time.Now().Unix()))
+func (t *TranscriberImpl) convertVideoToAudio(ctx context.Context, videoPath string, duration time.Duration) (string, error) {
+	encoding := t.encoding()
+	audioPath := filepath.Join(t.scratchDir(ctx), fmt.Sprintf("audio_%d%s", time.Now().Unix(), encoding.Ext()))
 
-	if err := t.processor.ConvertToAudio(videoPath, audioPath, audio.FormatMP3); err != nil {
+	release, err := t.reserveTemp(ctx, encoding.MaxSize(duration))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if err := t.processor.ConvertWithEncoding(ctx, videoPath, audioPath, encoding); err != nil {
 		return "", err
 	}
 
 	return audioPath, nil
 }
 
+// chunkMerger returns the merger for the request's merge strategy, and
+// for MergeLLM the reconciler recording the requests it makes
+func (t *TranscriberImpl) chunkMerger(ctx context.Context, provider providers.LLMProvider, req *TranscribeRequest, audioPath string, chunks []*audio.ChunkInfo) (ChunkMerger, *overlapReconciler, error) {
+	switch strategy := req.Options.MergeStrategy; {
+	case strategy == "" && req.Options.LeadingContext:
+		// Chunks no longer transcribe the same audio twice
+		return NewConcatMerger(), nil, nil
+	case strategy == "":
+		return t.merger, nil, nil
+	case strategy == MergeLLM:
+		merger, reconciler := t.newLLMMerger(ctx, provider, req, audioPath, chunks)
+		return merger, reconciler, nil
+	default:
+		merger, err := NewMerger(strategy)
+		return merger, nil, err
+	}
+}
+
 // createChunks creates audio chunks based on options
-func (t *TranscriberImpl) createChunks(audioPath string, options TranscribeOptions) ([]*audio.ChunkInfo, error) {
-	processorOptions := audio.ProcessorOptions{
+func (t *TranscriberImpl) createChunks(ctx context.Context, audioPath string, options TranscribeOptions) ([]*audio.ChunkInfo, error) {
+	return t.chunker.ChunkAudioContext(ctx, audioPath, t.processorOptions(ctx, options))
+}
+
+// processorOptions converts transcription options to chunking options
+func (t *TranscriberImpl) processorOptions(ctx context.Context, options TranscribeOptions) audio.ProcessorOptions {
+	return audio.ProcessorOptions{
 		ChunkDuration:   time.Duration(options.ChunkMinutes) * time.Minute,
 		OverlapDuration: time.Duration(options.OverlapSeconds) * time.Second,
-		OutputFormat:    audio.FormatMP3,
-		TempDir:         t.tempDir,
+		OutputFormat:    audio.AudioFormat(t.config.Audio.OutputFormat),
+		SampleRate:      t.config.Audio.SampleRate,
+		Channels:        t.config.Audio.Channels,
+		Quality:         t.config.Audio.Quality,
+		TempDir:         t.scratchDir(ctx),
 		KeepTemp:        options.PreserveAudio,
+
+		TrimSilence:      options.TrimSilence,
+		SilenceThreshold: t.config.Audio.SilenceThreshold,
+		MinSilence:       time.Duration(t.config.Audio.MinSilenceSeconds) * time.Second,
+
+		LeadingContext: options.LeadingContext,
+		Filters:        t.audioFilters(),
+
+		// Preserved audio has to be written somewhere
+		InMemory: t.config.Audio.InMemoryChunks && !options.PreserveAudio,
+	}
+}
+
+// encoding returns the configured encoding of converted audio and chunks
+func (t *TranscriberImpl) encoding() audio.Encoding {
+	return audio.Encoding{
+		Format:     audio.AudioFormat(t.config.Audio.OutputFormat),
+		SampleRate: t.config.Audio.SampleRate,
+		Channels:   t.config.Audio.Channels,
+		Quality:    t.config.Audio.Quality,
+	}
+}
+
+// audioFilters returns the configured preprocessing filters
+func (t *TranscriberImpl) audioFilters() audio.Filters {
+	filters := t.config.Audio.Filters
+	return audio.Filters{
+		Highpass: filters.HighpassHz,
+		Lowpass:  filters.LowpassHz,
+		Denoise:  filters.DenoiseDB,
+		Loudnorm: filters.Loudnorm,
 	}
+}
 
-	// Set defaults if not specified
-	if processorOptions.ChunkDuration == 0 {
-		processorOptions.ChunkDuration = 30 * time.Minute
+// PlanChunks returns the chunks a request would be split into, with the
+// keys a run would give them, and the audio info they were planned from. No
+// chunk files are created and the provider is not used. It kills ffmpeg
+// when ctx is done.
+func (t *TranscriberImpl) PlanChunks(ctx context.Context, req *TranscribeRequest) ([]*audio.ChunkInfo, *audio.AudioInfo, error) {
+	info, err := t.processor.GetAudioInfoContext(ctx, req.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get audio info: %w", err)
 	}
-	if processorOptions.OverlapDuration == 0 {
-		processorOptions.OverlapDuration = 60 * time.Second
+	sourceHash, err := audio.HashFile(req.FilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash input file: %w", err)
 	}
 
-	return t.chunker.ChunkAudio(audioPath, processorOptions)
+	options := t.processorOptions(ctx, req.Options)
+	sourceHash = options.Filters.Key(sourceHash)
+	chunks := t.chunker.PlanChunks(*info, options)
+	for _, chunk := range chunks {
+		chunk.Key = audio.ChunkKey(sourceHash, chunk.Start, chunk.End)
+	}
+	return chunks, info, nil
 }
 
-// transcribeChunks transcribes all chunks in parallel
-func (t *TranscriberImpl) transcribeChunks(ctx context.Context, chunks []*audio.ChunkInfo, req *TranscribeRequest, callback ProgressCallback) ([]*providers.TranscriptionResult, error) {
+// transcribeChunks transcribes all chunks in parallel, reusing and
+// recording results in the checkpoint and streaming their segments. With
+// Options.AllowPartial, failed chunks are returned as gaps and their
+// results are nil.
+//
+// A nil ready means every chunk file exists. Otherwise the chunks are
+// still being created: ready yields them in order as their files are
+// written, each is transcribed as soon as it arrives and a worker is
+// free, and its file or in-memory audio is released once done unless
+// Options.PreserveAudio.
+// Chunks that never arrive before ready is closed are left without a
+// result.
+func (t *TranscriberImpl) transcribeChunks(ctx context.Context, provider providers.LLMProvider, chunks []*audio.ChunkInfo, req *TranscribeRequest, voices *voiceProfiles, cp *checkpoint, callback ProgressCallback, stream *segmentStreamer, ready <-chan *audio.ChunkInfo) ([]*providers.TranscriptionResult, []Gap, error) {
 	log := logger.WithComponent("chunk-processor").WithField("file", filepath.Base(req.FilePath))
 
 	results := make([]*providers.TranscriptionResult, len(chunks))
+	failed := make(map[int]error)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var firstErr error
 
-	// Use semaphore to limit concurrent requests
-	workers := req.Options.Workers
-	if workers <= 0 {
-		workers = 3
-	}
-	log.Debug().Int("workers", workers).Int("total_chunks", len(chunks)).Msg("Initializing chunk transcription workers")
-	semaphore := make(chan struct{}, workers)
+	// Limit concurrent requests, adapting to the provider's health if asked
+	pool := newWorkerPool(req.Options, filepath.Base(req.FilePath))
+	log.Debug().
+		Int("workers", pool.limit).
+		Bool("adaptive", pool.adaptive).
+		Int("total_chunks", len(chunks)).
+		Msg("Initializing chunk transcription workers")
 
 	completed := 0
 
-	for i, chunk := range chunks {
+	removeDone := ready != nil && !req.Options.PreserveAudio
+	if ready == nil {
+		all := make(chan *audio.ChunkInfo, len(chunks))
+		for _, chunk := range chunks {
+			all <- chunk
+		}
+		close(all)
+		ready = all
+	}
+
+	for i := 0; i < len(chunks); i++ {
+		pool.acquire()
+		chunk, ok := <-ready
+		if !ok {
+			pool.release()
+			break
+		}
+
 		wg.Add(1)
 		go func(index int, chunkInfo *audio.ChunkInfo) {
 			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
 
-			chunkLog := log.WithField("chunk_index", index)
+			chunkLog := log.WithFields(map[string]interface{}{
+				"chunk_index": index,
+				"chunk_key":   chunkInfo.Key,
+			})
 			chunkLog.Debug().
 				Dur("start", chunkInfo.Start).
 				Dur("end", chunkInfo.End).
 				Str("temp_file", chunkInfo.TempFilePath).
 				Msg("Starting chunk transcription")
+			t.events.Publish(events.Event{
+				Type:     events.ChunkStarted,
+				FilePath: req.FilePath,
+				JobID:    req.JobID,
+				ChunkID:  index,
+				ChunkKey: chunkInfo.Key,
+				Total:    len(chunks),
+			})
 
-			// Transcribe chunk
-			result, err := t.transcribeChunk(ctx, chunkInfo, req)
+			// Transcribe chunk unless an earlier run finished it
+			result := cp.result(chunkInfo)
+			var err error
+			if result != nil {
+				chunkLog.Info().Msg("Reusing chunk result from checkpoint")
+				pool.release()
+			} else {
+				started := time.Now()
+				result, err = t.transcribeChunkWithRetry(ctx, provider, chunkInfo, req, voices)
+				switch {
+				case ctx.Err() != nil, err == nil && (chunkInfo.Silent || result.Metadata[cache.MetadataHit] == true):
+					// Cancellation, skipped silence and cache hits say
+					// nothing about the provider
+					pool.release()
+				default:
+					pool.done(time.Since(started), err)
+				}
+				if err == nil {
+					result.ChunkID = index
+					setChunkKey(result, chunkInfo)
+					if saveErr := cp.save(chunkInfo, result); saveErr != nil {
+						chunkLog.Warn().Err(saveErr).Msg("Failed to checkpoint chunk result")
+					}
+				}
+			}
+			if removeDone {
+				if chunkInfo.TempFilePath != "" {
+					_ = os.Remove(chunkInfo.TempFilePath)
+				}
+				chunkInfo.Data = nil
+			}
 
 			mu.Lock()
+			event := events.Event{
+				Type:     events.ChunkCompleted,
+				FilePath: req.FilePath,
+				JobID:    req.JobID,
+				ChunkID:  index,
+				ChunkKey: chunkInfo.Key,
+				Total:    len(chunks),
+			}
 			if err != nil {
 				chunkLog.Error().Err(err).Msg("Chunk transcription failed")
 				if firstErr == nil {
 					firstErr = err
 				}
+				failed[index] = err
+				event.Type = events.ChunkFailed
+				event.Error = err
 			} else if result != nil {
 				result.ChunkID = index
 				results[index] = result
@@ -298,116 +743,452 @@ func (t *TranscriberImpl) transcribeChunks(ctx context.Context, chunks []*audio.
 					Msg("Chunk transcription completed")
 			}
 			completed++
+			event.Completed = completed
+			t.events.Publish(event)
 			if callback != nil {
 				callback(completed, len(chunks), fmt.Sprintf("Chunk %d", index+1))
 			}
 			mu.Unlock()
+
+			if err != nil {
+				result = nil
+			}
+			stream.chunkDone(index, result)
 		}(i, chunk)
 	}
 
 	wg.Wait()
 
+	// With AllowPartial the failed chunks become gaps, unless nothing is left
+	if firstErr != nil && req.Options.AllowPartial && len(failed) < len(chunks) && ctx.Err() == nil {
+		gaps := make([]Gap, 0, len(failed))
+		for index, err := range failed {
+			gaps = append(gaps, Gap{
+				Chunk: index,
+				Key:   chunks[index].Key,
+				Start: chunks[index].Start,
+				End:   chunks[index].End,
+				Error: err.Error(),
+			})
+		}
+		sort.Slice(gaps, func(i, j int) bool { return gaps[i].Chunk < gaps[j].Chunk })
+		log.Warn().
+			Int("failed", len(gaps)).
+			Int("total", len(chunks)).
+			Msg("Some chunks failed, continuing with a partial transcript")
+		return results, gaps, nil
+	}
+
 	if firstErr != nil {
 		log.Error().Err(firstErr).Int("completed", completed).Int("total", len(chunks)).Msg("Chunk transcription failed")
-		return nil, firstErr
+		return nil, nil, firstErr
 	}
 
-	log.Info().Int("completed", completed).Int("total", len(chunks)).Msg("All chunks transcribed successfully")
-	return results, nil
+	workers, peak := pool.stats()
+	log.Info().
+		Int("completed", completed).
+		Int("total", len(chunks)).
+		Int("workers", workers).
+		Int("peak_workers", peak).
+		Msg("All chunks transcribed successfully")
+	return results, nil, nil
 }
 
-// transcribeChunk transcribes a single chunk
-func (t *TranscriberImpl) transcribeChunk(ctx context.Context, chunk *audio.ChunkInfo, req *TranscribeRequest) (*providers.TranscriptionResult, error) {
+// transcribeChunkWithRetry transcribes a chunk, retrying the whole chunk up
+// to Options.ChunkRetries times. Providers already retry transient HTTP
+// errors; this also covers failures such as unparseable responses.
+func (t *TranscriberImpl) transcribeChunkWithRetry(ctx context.Context, provider providers.LLMProvider, chunk *audio.ChunkInfo, req *TranscribeRequest, voices *voiceProfiles) (*providers.TranscriptionResult, error) {
 	log := logger.WithComponent("chunk").WithField("temp_file", filepath.Base(chunk.TempFilePath))
 
-	// Read chunk data
-	log.Debug().Msg("Opening chunk file")
-	chunkReader, err := t.reader.OpenAudio(chunk.TempFilePath)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to open chunk file")
-		return nil, fmt.Errorf("failed to open chunk: %w", err)
+	for attempt := 0; ; attempt++ {
+		result, err := t.transcribeChunk(ctx, provider, chunk, req, voices)
+		if err == nil {
+			return t.checkChunkLanguage(ctx, provider, chunk, req, voices, result), nil
+		}
+		if attempt >= req.Options.ChunkRetries || ctx.Err() != nil {
+			return result, err
+		}
+
+		delay := time.Duration(attempt+1) * chunkRetryDelay
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt+1).
+			Int("max_retries", req.Options.ChunkRetries).
+			Dur("delay", delay).
+			Msg("Chunk failed, retrying")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
 	}
-	defer func() {
-		_ = chunkReader.Close()
-	}()
+}
+
+// setChunkKey records the chunk's stable key in its result metadata
+func setChunkKey(result *providers.TranscriptionResult, chunk *audio.ChunkInfo) {
+	if chunk.Key == "" {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[MetadataChunkKey] = chunk.Key
+}
+
+// setChunkKeys lists the keys of all chunks, in order, in the merged
+// result. The merger copies per-chunk metadata, so the single chunk key
+// it picked up is dropped.
+func setChunkKeys(result *TranscribeResult, chunks []*audio.ChunkInfo) {
+	keys := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Key != "" {
+			keys = append(keys, chunk.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	delete(result.Metadata, MetadataChunkKey)
+	result.Metadata[MetadataChunkKeys] = keys
+}
+
+// completedChunks drops the results of failed chunks
+func completedChunks(results []*providers.TranscriptionResult) []*providers.TranscriptionResult {
+	completed := make([]*providers.TranscriptionResult, 0, len(results))
+	for _, result := range results {
+		if result != nil {
+			completed = append(completed, result)
+		}
+	}
+	return completed
+}
+
+// transcribeChunk transcribes a single chunk
+func (t *TranscriberImpl) transcribeChunk(ctx context.Context, provider providers.LLMProvider, chunk *audio.ChunkInfo, req *TranscribeRequest, voices *voiceProfiles) (*providers.TranscriptionResult, error) {
+	log := logger.WithComponent("chunk").WithFields(map[string]interface{}{
+		"chunk_key": chunk.Key,
+		"temp_file": filepath.Base(chunk.TempFilePath),
+	})
+
+	// Nothing to transcribe in a chunk that is all silence
+	if chunk.Silent {
+		log.Debug().Msg("Skipping silent chunk")
+		return &providers.TranscriptionResult{
+			Metadata: map[string]interface{}{MetadataSilent: true},
+		}, nil
+	}
+
+	// Leading context follows the voice reference clip, if any
+	prompt := contextPrompt(req.CustomPrompt, chunk, voices.duration())
+
+	// Reuse the response from an earlier run for identical audio and prompt
+	diarize := nativeDiarization(provider, req, voices)
+	cacheKey, result := t.lookupChunkCache(provider, chunk, prompt, diarize)
+	if result != nil {
+		log.Info().Str("cache_key", cacheKey).Msg("Reusing cached chunk response")
+		if diarize {
+			normalizeSpeakers(result)
+		}
+		voices.label(result, chunk)
+		if req.Options.SegmentLanguages {
+			tagLanguages(result)
+		}
+		dropContext(result, chunk)
+		t.adjustTimestamps(result, chunk)
+		return result, nil
+	}
+
+	// Send the voice reference clip ahead of the chunk
+	var chunkAudio io.Reader
+	name := chunk.Name()
+	encodeStarted := time.Now()
+	if chunk.Data != nil {
+		// In-memory chunks never touch the disk
+		data := chunk.Data
+		if voices != nil {
+			var err error
+			if data, err = voices.chunkData(ctx, chunk); err != nil {
+				log.Error().Err(err).Msg("Failed to prepend voice profiles")
+				return nil, err
+			}
+			name = strings.TrimSuffix(name, filepath.Ext(name)) + "_voices.mp3"
+		}
+		chunkAudio = bytes.NewReader(data)
+	} else {
+		chunkPath := chunk.TempFilePath
+		if voices != nil {
+			var err error
+			chunkPath, err = voices.chunkFile(ctx, chunk)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to prepend voice profiles")
+				return nil, err
+			}
+			defer func() { _ = os.Remove(chunkPath) }()
+		}
+
+		// Read chunk data
+		log.Debug().Msg("Opening chunk file")
+		chunkReader, err := t.reader.OpenAudio(chunkPath)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to open chunk file")
+			return nil, fmt.Errorf("failed to open chunk: %w", err)
+		}
+		defer func() {
+			_ = chunkReader.Close()
+		}()
+		chunkAudio, name = chunkReader, filepath.Base(chunkPath)
+	}
+
+	encodeTime := chunk.EncodeTime + time.Since(encodeStarted)
+
+	// Chunks are in audio.output_format unless ffmpeg was missing and the
+	// source format was kept
+	format := audio.DetectFormat(name)
 
 	// Create transcription request
 	transcReq := &providers.TranscriptionRequest{
-		Audio:       chunkReader,
-		AudioFormat: "mp3",
-		MimeType:    "audio/mpeg",
-		Filename:    filepath.Base(chunk.TempFilePath),
-		Prompt:      req.CustomPrompt,
+		Audio:       chunkAudio,
+		AudioFormat: string(format),
+		MimeType:    audio.GetMimeType(format),
+		Filename:    name,
+		Prompt:      prompt,
 		Options: providers.TranscriptionOptions{
 			Temperature:    req.Options.Temperature,
 			MaxTokens:      t.config.Provider.MaxTokens,
 			TimeoutSeconds: int(t.config.Provider.Timeout.Seconds()),
+			Diarize:        diarize,
 		},
 	}
 
+	// Throttle before sending so parallel workers stay under provider limits
+	if err := t.limiter.Wait(ctx, providers.EstimateTokens(chunk.AudioDuration()+voices.duration(), prompt)); err != nil {
+		return nil, fmt.Errorf("rate limiter wait failed: %w", err)
+	}
+
 	log.Debug().
 		Float32("temperature", req.Options.Temperature).
 		Msg("Sending chunk to provider for transcription")
 
 	// Transcribe using provider
-	result, err := t.provider.Transcribe(ctx, transcReq)
+	providerCtx, timing := ctx, (func() providers.Timing)(nil)
+	if req.Options.Timing {
+		providerCtx, timing = providers.WithTiming(ctx)
+	}
+	result, err := provider.Transcribe(providerCtx, transcReq)
 	if err != nil {
 		log.Error().Err(err).Msg("Provider transcription failed")
 		return nil, fmt.Errorf("provider transcription failed: %w", err)
 	}
 
+	// Cache before timestamps are shifted to the chunk offset
+	if cacheKey != "" {
+		if err := t.cache.Put(cacheKey, result); err != nil {
+			log.Warn().Err(err).Msg("Failed to cache chunk response")
+		}
+	}
+	if timing != nil {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata[MetadataChunkTiming] = ChunkTiming{Encode: encodeTime, Timing: timing()}
+	}
+
 	log.Debug().
 		Int("text_length", len(result.Text)).
 		Int("segments", len(result.Segments)).
 		Msg("Received transcription result from provider")
 
-	// Adjust timestamps based on chunk start time
-	if len(result.Segments) > 0 {
-		log.Debug().
-			Dur("chunk_start", chunk.Start).
-			Int("segments_count", len(result.Segments)).
-			Msg("Adjusting timestamps for chunk offset")
-		for i := range result.Segments {
-			result.Segments[i].Start += chunk.Start
-			result.Segments[i].End += chunk.Start
-		}
+	if diarize {
+		normalizeSpeakers(result)
 	}
-
+	voices.label(result, chunk)
+	if req.Options.SegmentLanguages {
+		tagLanguages(result)
+	}
+	dropContext(result, chunk)
+	t.adjustTimestamps(result, chunk)
 	return result, nil
 }
 
-// saveResult saves the transcription result to file
-func (t *TranscriberImpl) saveResult(result *TranscribeResult, outputPath, format string) error {
+// adjustTimestamps shifts segment timestamps from the chunk file to the
+// source: by the chunk start plus any leading silence trimmed from it
+func (t *TranscriberImpl) adjustTimestamps(result *providers.TranscriptionResult, chunk *audio.ChunkInfo) {
+	if len(result.Segments) == 0 {
+		return
+	}
+
+	offset := chunk.Start + chunk.TrimmedStart
+	logger.WithComponent("chunk").Debug().
+		Dur("chunk_start", chunk.Start).
+		Dur("trimmed_start", chunk.TrimmedStart).
+		Int("segments_count", len(result.Segments)).
+		Msg("Adjusting timestamps for chunk offset")
+	for i := range result.Segments {
+		result.Segments[i].Start += offset
+		result.Segments[i].End += offset
+	}
+}
+
+// lookupChunkCache returns the cache key for a chunk and the cached
+// response, if any. The key is empty when caching is disabled.
+// Cached responses are marked so they are not billed again.
+func (t *TranscriberImpl) lookupChunkCache(provider providers.LLMProvider, chunk *audio.ChunkInfo, prompt string, diarize bool) (string, *providers.TranscriptionResult) {
+	if t.cache == nil || chunk.Key == "" {
+		return "", nil
+	}
+
+	// Responses are cached before timestamps are adjusted, so trimmed and
+	// untrimmed audio of the same span must not share an entry
+	chunkKey := chunk.Key
+	if chunk.TrimmedStart > 0 || chunk.TrimmedEnd > 0 {
+		chunkKey = fmt.Sprintf("%s+trim-%d-%d", chunk.Key, chunk.TrimmedStart.Milliseconds(), chunk.TrimmedEnd.Milliseconds())
+	}
+	// Natively diarized responses carry speaker labels others lack
+	if diarize {
+		chunkKey += "+diarize"
+	}
+	key := cache.Key(chunkKey, provider.Name(), providers.Models(provider), prompt)
+	result, found, err := t.cache.Get(key)
+	if err != nil {
+		logger.WithComponent("chunk").WithField("chunk_key", chunk.Key).Warn().Err(err).Msg("Chunk cache lookup failed")
+		return key, nil
+	}
+	if !found {
+		return key, nil
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata[cache.MetadataHit] = true
+	return key, result
+}
+
+// SaveResult formats the transcription result and writes it to file
+func SaveResult(result *TranscribeResult, outputPath, format string) error {
+	return SaveEncryptedResult(result, outputPath, format, nil)
+}
+
+// SaveEncryptedResult formats the transcription result and writes it to
+// file, encrypted with c. A nil cipher writes plaintext.
+func SaveEncryptedResult(result *TranscribeResult, outputPath, format string, c *encryption.Cipher) error {
 	log := logger.WithComponent("file-writer").WithField("output_path", outputPath)
 
 	log.Debug().Str("format", format).Msg("Formatting transcription result")
+	if isJSONFormat(format) {
+		stampSaved(result)
+	}
+	content, err := formatResult(result, format, outputPath)
+	if err != nil {
+		log.Error().Err(err).Str("format", format).Msg("Failed to format result")
+		return fmt.Errorf("failed to format result: %w", err)
+	}
 
-	var content []byte
-	var err error
+	log.Debug().Int("content_size", len(content)).Msg("Content formatted successfully")
+	return writeResultFile(content, outputPath, format, c)
+}
 
+// WriteResult formats the transcription result and writes it to w, e.g.
+// stdout when piping a transcript into another program. The output ends
+// with a newline, so several results written one after another stay apart.
+func WriteResult(w io.Writer, result *TranscribeResult, format string) error {
+	if isJSONFormat(format) {
+		stampSaved(result)
+	}
+	content, err := formatResult(result, format, "")
+	if err != nil {
+		return fmt.Errorf("failed to format result: %w", err)
+	}
+	if _, compressed := splitCompression(format); !compressed && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// isJSONFormat reports whether format is written as JSON, including
+// unknown formats
+func isJSONFormat(format string) bool {
+	if _, ok := templateFormatPath(format); ok {
+		return false
+	}
+	format, _ = splitCompression(format)
 	switch format {
-	case "json":
-		if result.Metadata == nil {
-			result.Metadata = make(map[string]interface{})
+	case "text", "srt", "vtt", "bilingual-srt", "bilingual-vtt", "chapters", "html", "html-audio", "audacity", "textgrid":
+		return false
+	}
+	return true
+}
+
+// stampSaved records when a JSON result was saved
+func stampSaved(result *TranscribeResult) {
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["saved_at"] = time.Now().Format(time.RFC3339)
+}
+
+// formatResult renders result in format, without modifying it, so
+// several formats can be rendered at once. outputPath is where it is
+// written, which html-audio links the source media relative to. Formats
+// ending in CompressionSuffix are rendered and then gzipped, and template
+// formats with their template file.
+func formatResult(result *TranscribeResult, format, outputPath string) ([]byte, error) {
+	if path, ok := templateFormatPath(format); ok {
+		return result.RenderTemplate(path)
+	}
+	if base, compressed := splitCompression(format); compressed {
+		content, err := formatResult(result, base, outputPath)
+		if err != nil {
+			return nil, err
 		}
-		result.Metadata["saved_at"] = time.Now().Format(time.RFC3339)
-		content, err = result.ToJSON(true)
+		return compress(content)
+	}
+	switch format {
+	case "json":
+		return result.ToJSON(true)
 	case "text":
-		content = []byte(result.Text)
+		return []byte(result.Text), nil
 	case "srt":
-		content, err = result.ToSRT()
+		return result.ToSRT()
+	case "vtt":
+		return result.ToVTT()
+	case "bilingual-srt":
+		return result.ToBilingualSRT()
+	case "bilingual-vtt":
+		return result.ToBilingualVTT()
+	case "chapters":
+		return result.ToChapters()
+	case "html":
+		return result.ToHTML("")
+	case "html-audio":
+		return result.ToHTML(HTMLAudioSource(result, outputPath))
+	case "audacity":
+		return result.ToAudacityLabels()
+	case "textgrid":
+		return result.ToTextGrid()
 	default:
-		log.Warn().Str("format", format).Msg("Unknown format, defaulting to JSON")
-		content, err = result.ToJSON(true)
+		logger.WithComponent("file-writer").Warn().Str("format", format).Msg("Unknown format, defaulting to JSON")
+		return result.ToJSON(true)
 	}
+}
+
+// writeResultFile encrypts formatted content with c and writes it to
+// outputPath, creating its directory
+func writeResultFile(content []byte, outputPath, format string, c *encryption.Cipher) error {
+	log := logger.WithComponent("file-writer").WithField("output_path", outputPath)
 
+	content, err := c.Encrypt(content)
 	if err != nil {
-		log.Error().Err(err).Str("format", format).Msg("Failed to format result")
-		return fmt.Errorf("failed to format result: %w", err)
+		log.Error().Err(err).Msg("Failed to encrypt result")
+		return fmt.Errorf("failed to encrypt result: %w", err)
 	}
 
-	log.Debug().Int("content_size", len(content)).Msg("Content formatted successfully")
-
 	// Ensure output directory exists
 	outputDir := filepath.Dir(outputPath)
 	log.Debug().Str("output_dir", outputDir).Msg("Creating output directory")
@@ -426,6 +1207,7 @@ func (t *TranscriberImpl) saveResult(result *TranscribeResult, outputPath, forma
 	log.Info().
 		Str("output_path", outputPath).
 		Str("format", format).
+		Bool("encrypted", c != nil).
 		Int("size_bytes", len(content)).
 		Msg("Transcription result saved successfully")
 
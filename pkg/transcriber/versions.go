@@ -0,0 +1,107 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata set when TranscribeOptions.KeepVersions is on
+const (
+	MetadataOutputPath = "output_path" // Where the text was saved, e.g. meeting_v2.txt
+	MetadataVersion    = "version"     // int, 1 for the first transcript of a file
+)
+
+// versionSuffix matches the version in the name of a versioned output
+var versionSuffix = regexp.MustCompile(`_v([0-9]+)$`)
+
+// TranscriptVersion is one saved version of a transcript
+type TranscriptVersion struct {
+	Version  int
+	Path     string
+	Modified time.Time
+}
+
+// VersionPath returns where version of outputPath is saved: meeting.txt
+// itself for version 1, meeting_v2.txt for version 2. Other formats
+// follow, e.g. meeting_v2.json.
+func VersionPath(outputPath string, version int) string {
+	if version <= 1 {
+		return outputPath
+	}
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s_v%d%s", strings.TrimSuffix(outputPath, ext), version, ext)
+}
+
+// NextVersion returns the first version of outputPath with none of its
+// outputs in formats written yet, so saving it overwrites nothing
+func NextVersion(outputPath string, formats []string) (string, int) {
+	for version := 1; ; version++ {
+		path := VersionPath(outputPath, version)
+		taken := false
+		for _, target := range RenderTargets(path, formats) {
+			if _, err := os.Stat(target.Path); err == nil {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return path, version
+		}
+	}
+}
+
+// VersionBase returns the path of version 1 of a versioned output path,
+// and its version
+func VersionBase(path string) (string, int) {
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(path, ext)
+	m := versionSuffix.FindStringSubmatchIndex(name)
+	if m == nil {
+		return path, 1
+	}
+	version, err := strconv.Atoi(name[m[2]:m[3]])
+	if err != nil || version < 2 {
+		return path, 1
+	}
+	return name[:m[0]] + ext, version
+}
+
+// Versions returns the saved versions of the transcript at outputPath,
+// or any of its versions, oldest first
+func Versions(outputPath string) ([]TranscriptVersion, error) {
+	base, _ := VersionBase(filepath.Clean(outputPath))
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []TranscriptVersion
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(base), entry.Name())
+		if candidate, version := VersionBase(path); candidate == base && !entry.IsDir() {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			versions = append(versions, TranscriptVersion{Version: version, Path: path, Modified: info.ModTime()})
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
+}
+
+// SavedPath returns which version of the request's OutputPath the
+// result's text was saved to when versions are kept, or "" otherwise
+func (r *TranscribeResult) SavedPath() string {
+	if r == nil || r.Metadata == nil {
+		return ""
+	}
+	path, _ := r.Metadata[MetadataOutputPath].(string)
+	return path
+}
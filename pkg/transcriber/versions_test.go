@@ -0,0 +1,70 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		version int
+		want    string
+	}{
+		{"out/meeting.txt", 1, "out/meeting.txt"},
+		{"out/meeting.txt", 2, "out/meeting_v2.txt"},
+		{"out/meeting.md", 12, "out/meeting_v12.md"},
+		{"meeting", 3, "meeting_v3"},
+	}
+	for _, tt := range tests {
+		got := VersionPath(tt.path, tt.version)
+		if got != tt.want {
+			t.Errorf("VersionPath(%q, %d) = %q, want %q", tt.path, tt.version, got, tt.want)
+		}
+		if base, version := VersionBase(got); base != tt.path || version != max(tt.version, 1) {
+			t.Errorf("VersionBase(%q) = %q, %d", got, base, version)
+		}
+	}
+	if base, version := VersionBase("out/take_v1.txt"); base != "out/take_v1.txt" || version != 1 {
+		t.Errorf("VersionBase(take_v1.txt) = %q, %d, want the path itself", base, version)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "meeting.txt")
+
+	if path, version := NextVersion(outputPath, nil); path != outputPath || version != 1 {
+		t.Errorf("NextVersion() of a new transcript = %q, %d", path, version)
+	}
+	writeFile := func(name string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("meeting.txt")
+	writeFile("meeting_v2.json")
+	writeFile("meeting_v3.txt")
+	writeFile("meeting.srt")
+	writeFile("meeting_notes.txt")
+
+	// A version is taken when any of its formats is written
+	if path, version := NextVersion(outputPath, nil); version != 2 || path != filepath.Join(dir, "meeting_v2.txt") {
+		t.Errorf("NextVersion() = %q, %d, want meeting_v2.txt", path, version)
+	}
+	if _, version := NextVersion(outputPath, []string{"json"}); version != 4 {
+		t.Errorf("NextVersion() with json = %d, want 4", version)
+	}
+
+	versions, err := Versions(filepath.Join(dir, "meeting_v3.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 3 {
+		t.Fatalf("Versions() = %+v, want versions 1 and 3", versions)
+	}
+	if versions[1].Path != filepath.Join(dir, "meeting_v3.txt") {
+		t.Errorf("version 3 path = %q", versions[1].Path)
+	}
+}
@@ -0,0 +1,286 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// MetadataVoiceProfiles lists the speakers of the reference clip, as
+// []VoiceProfileRange
+const MetadataVoiceProfiles = "voice_profiles"
+
+// VoiceProfileRange is where a voice profile is in the reference clip
+// prepended to every chunk
+type VoiceProfileRange struct {
+	Name  string        `json:"name"`
+	Role  string        `json:"role,omitempty"`
+	File  string        `json:"file"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// voiceProfiles identifies speakers of one file by their voice profiles.
+// The profiles are prepended to every chunk as a reference clip, the
+// prompt names the speakers in it, and responses are shifted back past
+// the clip with the speaker names moved into SpeakerID. A nil
+// voiceProfiles does nothing.
+type voiceProfiles struct {
+	ref *audio.VoiceReference
+}
+
+// openVoiceProfiles loads the usable profiles in dir and their reference
+// clip, or returns nil if dir is empty. Clips are cached in the temp
+// directory by the content of the profiles, so watch mode and repeated
+// runs only build them again when a profile changes.
+func (t *TranscriberImpl) openVoiceProfiles(ctx context.Context, dir string) (*voiceProfiles, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	profiles, err := audio.LoadVoiceProfiles(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if profiles, err = t.usableVoiceProfiles(ctx, dir, profiles); err != nil {
+		return nil, err
+	}
+	ref, cached, err := audio.CachedVoiceReference(ctx, profiles, filepath.Join(t.tempDir, "voice_references"))
+	if err != nil {
+		return nil, err
+	}
+	logger.WithComponent("voice-profiles").Debug().
+		Str("dir", dir).
+		Bool("cached", cached).
+		Int("profiles", len(profiles)).
+		Msg("Loaded voice reference")
+	return &voiceProfiles{ref: ref}, nil
+}
+
+// usableVoiceProfiles leaves out profiles that would mislead speaker
+// matching, logging each quality issue with how to fix it. The outcome is
+// remembered by the content of the profiles, so watch mode only measures
+// them again when one changes.
+func (t *TranscriberImpl) usableVoiceProfiles(ctx context.Context, dir string, profiles []*audio.VoiceProfile) ([]*audio.VoiceProfile, error) {
+	key, err := audio.VoiceReferenceKey(profiles)
+	if err != nil {
+		return nil, err
+	}
+	if usable, ok := t.voiceChecks.Load(key); ok {
+		return usable.([]*audio.VoiceProfile), nil
+	}
+
+	usable, flagged, err := audio.ScreenVoiceProfiles(ctx, profiles)
+	if err != nil {
+		return nil, err
+	}
+	log := logger.WithComponent("voice-profiles").WithField("dir", dir)
+	for _, profile := range profiles {
+		quality := flagged[profile.Path]
+		if quality == nil {
+			continue
+		}
+		msg := "Voice profile may be unreliable"
+		if !quality.Usable() {
+			msg = "Skipping voice profile"
+		}
+		for _, issue := range quality.Issues {
+			log.Warn().
+				Str("profile", filepath.Base(profile.Path)).
+				Str("speaker", profile.Name).
+				Str("problem", issue.Problem).
+				Str("advice", issue.Advice).
+				Msg(msg)
+		}
+	}
+	if len(usable) == 0 {
+		return nil, fmt.Errorf("no usable voice profiles in %s, see gollmscribe voiceprofile validate", dir)
+	}
+
+	t.voiceChecks.Store(key, usable)
+	return usable, nil
+}
+
+// cleanup removes the reference clip
+func (v *voiceProfiles) cleanup() {
+	if v == nil {
+		return
+	}
+	_ = v.ref.Cleanup()
+}
+
+// duration returns the length of audio prepended to each chunk
+func (v *voiceProfiles) duration() time.Duration {
+	if v == nil {
+		return 0
+	}
+	return v.ref.Duration
+}
+
+// prompt adds the speaker instructions to a transcription prompt
+func (v *voiceProfiles) prompt(base string) string {
+	if v == nil {
+		return base
+	}
+
+	speakers := make([]string, len(v.ref.Profiles))
+	for i, r := range v.ranges() {
+		span := formatTimestamp(r.Start) + "-" + formatTimestamp(r.End)
+		if r.Role != "" {
+			span = r.Role + ", " + span
+		}
+		speakers[i] = fmt.Sprintf("%s (%s)", r.Name, span)
+	}
+
+	return fmt.Sprintf("%s\n\n"+
+		"The first %s of the audio are reference recordings of known speakers, in this order: %s. "+
+		"Do not transcribe the reference recordings. "+
+		"Transcribe only the audio after them, one line per speaker turn, formatted as \"[MM:SS] Name: text\", "+
+		"where MM:SS is the time in the audio including the reference recordings. "+
+		"Use the name of the reference speaker whose voice matches; label any other voice \"Speaker 1\", \"Speaker 2\" and so on.",
+		strings.TrimSpace(base), formatTimestamp(v.ref.Duration), strings.Join(speakers, ", "))
+}
+
+// ranges returns the speakers of the reference clip in order
+func (v *voiceProfiles) ranges() []VoiceProfileRange {
+	if v == nil {
+		return nil
+	}
+	ranges := make([]VoiceProfileRange, len(v.ref.Profiles))
+	for i, profile := range v.ref.Profiles {
+		ranges[i] = VoiceProfileRange{
+			Name:  profile.Name,
+			Role:  profile.Role,
+			File:  filepath.Base(profile.Path),
+			Start: v.ref.Offsets[i],
+			End:   v.ref.Offsets[i] + profile.Duration,
+		}
+	}
+	return ranges
+}
+
+// chunkFile writes the reference clip followed by the chunk next to the
+// chunk file and returns its path
+func (v *voiceProfiles) chunkFile(ctx context.Context, chunk *audio.ChunkInfo) (string, error) {
+	path := strings.TrimSuffix(chunk.TempFilePath, filepath.Ext(chunk.TempFilePath)) + "_voices.mp3"
+	if err := v.ref.Prepend(ctx, chunk.TempFilePath, path); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// chunkData returns the reference clip followed by an in-memory chunk
+func (v *voiceProfiles) chunkData(ctx context.Context, chunk *audio.ChunkInfo) ([]byte, error) {
+	return v.ref.PrependData(ctx, chunk.Data, chunk.Format)
+}
+
+// speakerLinePattern matches the "[MM:SS] Name: text" lines asked for in
+// the prompt; hours and fractional seconds are tolerated
+var speakerLinePattern = regexp.MustCompile(`^\[(?:(\d+):)?(\d+):(\d{2})(?:\.\d+)?\]\s*([^:\]]{1,60}):\s*(.*)$`)
+
+// label moves a response for a chunk with the reference clip back onto
+// the chunk. Segments are shifted past the clip and those inside it are
+// dropped; responses without segments are split into one segment per
+// speaker line when every line follows the prompted format.
+func (v *voiceProfiles) label(result *providers.TranscriptionResult, chunk *audio.ChunkInfo) {
+	if v == nil {
+		return
+	}
+
+	if len(result.Segments) == 0 {
+		result.Segments = v.parseSpeakerLines(result.Text, chunk.AudioDuration())
+		if len(result.Segments) > 0 {
+			lines := make([]string, len(result.Segments))
+			for i, segment := range result.Segments {
+				lines[i] = segment.SpeakerID + ": " + segment.Text
+			}
+			result.Text = strings.Join(lines, "\n")
+		}
+		return
+	}
+
+	segments := result.Segments[:0]
+	for _, segment := range result.Segments {
+		segment.Start -= v.ref.Duration
+		segment.End -= v.ref.Duration
+		if segment.End <= 0 {
+			continue
+		}
+		if segment.Start < 0 {
+			segment.Start = 0
+		}
+		segment.SpeakerID = v.speaker(segment.SpeakerID)
+		segments = append(segments, segment)
+	}
+	result.Segments = segments
+}
+
+// parseSpeakerLines turns "[MM:SS] Name: text" lines into segments
+// relative to the chunk, each ending where the next starts. It returns
+// nil unless every non-empty line matches.
+func (v *voiceProfiles) parseSpeakerLines(text string, chunkDuration time.Duration) []providers.TranscriptionSegment {
+	var segments []providers.TranscriptionSegment
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		m := speakerLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		seconds, _ := strconv.Atoi(m[3])
+		start := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+			time.Duration(seconds)*time.Second - v.ref.Duration
+		if start < 0 {
+			continue // Transcribed the reference clip despite the prompt
+		}
+		segments = append(segments, providers.TranscriptionSegment{
+			Text:      strings.TrimSpace(m[5]),
+			Start:     start,
+			SpeakerID: v.speaker(m[4]),
+		})
+	}
+
+	for i := range segments {
+		if i+1 < len(segments) {
+			segments[i].End = segments[i+1].Start
+		} else {
+			segments[i].End = chunkDuration
+		}
+		if segments[i].End < segments[i].Start {
+			segments[i].End = segments[i].Start
+		}
+	}
+	return segments
+}
+
+// speaker returns the profile name matching a speaker label, ignoring
+// case, or the label itself
+func (v *voiceProfiles) speaker(label string) string {
+	label = strings.TrimSpace(label)
+	for _, profile := range v.ref.Profiles {
+		if strings.EqualFold(profile.Name, label) {
+			return profile.Name
+		}
+	}
+	return label
+}
+
+// formatTimestamp formats a duration as MM:SS
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
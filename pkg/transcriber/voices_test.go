@@ -0,0 +1,113 @@
+package transcriber
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/audio"
+	"github.com/eternnoir/gollmscribe/pkg/providers"
+)
+
+// testVoices returns profiles for Alice and Bob with a 12s reference clip
+func testVoices() *voiceProfiles {
+	return &voiceProfiles{ref: &audio.VoiceReference{
+		Profiles: []*audio.VoiceProfile{
+			{Name: "Alice", Duration: 5 * time.Second},
+			{Name: "Bob", Duration: 5 * time.Second},
+		},
+		Offsets:  []time.Duration{0, 6 * time.Second},
+		Duration: 12 * time.Second,
+	}}
+}
+
+func TestVoicePrompt(t *testing.T) {
+	prompt := testVoices().prompt("Transcribe this.")
+	for _, want := range []string{"Transcribe this.", "first 00:12", "Alice (00:00-00:05), Bob (00:06-00:11)", "[MM:SS] Name: text"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	var none *voiceProfiles
+	if got := none.prompt("Transcribe this."); got != "Transcribe this." {
+		t.Errorf("nil prompt() = %q", got)
+	}
+}
+
+func TestVoiceLabelSpeakerLines(t *testing.T) {
+	chunk := &audio.ChunkInfo{Start: time.Minute, End: 2 * time.Minute, Duration: time.Minute}
+	result := &providers.TranscriptionResult{Text: "[00:03] Alice: reference\n" +
+		"[00:12] alice: Hello Bob.\n\n" +
+		"[00:20] Bob: Hi.\n" +
+		"[00:31] Speaker 1: Sorry I'm late."}
+
+	testVoices().label(result, chunk)
+
+	want := []providers.TranscriptionSegment{
+		{Text: "Hello Bob.", Start: 0, End: 8 * time.Second, SpeakerID: "Alice"},
+		{Text: "Hi.", Start: 8 * time.Second, End: 19 * time.Second, SpeakerID: "Bob"},
+		{Text: "Sorry I'm late.", Start: 19 * time.Second, End: time.Minute, SpeakerID: "Speaker 1"},
+	}
+	if len(result.Segments) != len(want) {
+		t.Fatalf("segments = %+v, want %+v", result.Segments, want)
+	}
+	for i := range want {
+		if result.Segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, result.Segments[i], want[i])
+		}
+	}
+	if result.Text != "Alice: Hello Bob.\nBob: Hi.\nSpeaker 1: Sorry I'm late." {
+		t.Errorf("text = %q", result.Text)
+	}
+}
+
+func TestVoiceLabelKeepsUnformattedText(t *testing.T) {
+	result := &providers.TranscriptionResult{Text: "[00:12] Alice: Hello.\nno timestamp here"}
+	testVoices().label(result, &audio.ChunkInfo{Duration: time.Minute})
+	if len(result.Segments) != 0 || result.Text != "[00:12] Alice: Hello.\nno timestamp here" {
+		t.Errorf("label() changed unformatted response: %+v", result)
+	}
+}
+
+func TestVoiceLabelShiftsSegments(t *testing.T) {
+	result := &providers.TranscriptionResult{Segments: []providers.TranscriptionSegment{
+		{Text: "reference", Start: 0, End: 5 * time.Second, SpeakerID: "ALICE"},
+		{Text: "straddles", Start: 11 * time.Second, End: 14 * time.Second, SpeakerID: "bob"},
+		{Text: "speech", Start: 14 * time.Second, End: 20 * time.Second},
+	}}
+	testVoices().label(result, &audio.ChunkInfo{Duration: time.Minute})
+
+	want := []providers.TranscriptionSegment{
+		{Text: "straddles", Start: 0, End: 2 * time.Second, SpeakerID: "Bob"},
+		{Text: "speech", Start: 2 * time.Second, End: 8 * time.Second},
+	}
+	if len(result.Segments) != len(want) {
+		t.Fatalf("segments = %+v, want %+v", result.Segments, want)
+	}
+	for i := range want {
+		if result.Segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, result.Segments[i], want[i])
+		}
+	}
+}
+
+func TestVoiceRanges(t *testing.T) {
+	voices := testVoices()
+	voices.ref.Profiles[1].Role = "customer"
+	voices.ref.Profiles[1].Path = "/profiles/bob.wav"
+
+	if prompt := voices.prompt("Transcribe this."); !strings.Contains(prompt, "Bob (customer, 00:06-00:11)") {
+		t.Errorf("prompt missing Bob's role:\n%s", prompt)
+	}
+	ranges := voices.ranges()
+	want := VoiceProfileRange{Name: "Bob", Role: "customer", File: "bob.wav", Start: 6 * time.Second, End: 11 * time.Second}
+	if len(ranges) != 2 || ranges[1] != want {
+		t.Errorf("ranges() = %+v", ranges)
+	}
+
+	var none *voiceProfiles
+	if none.ranges() != nil {
+		t.Error("nil ranges() is not empty")
+	}
+}
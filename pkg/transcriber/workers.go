@@ -0,0 +1,150 @@
+package transcriber
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// Adaptive worker tuning
+const (
+	workerSlowFactor     = 2.0 // A request this many times slower than usual signals overload
+	workerLatencySmooth  = 0.2 // Weight of the newest latency in the running average
+	defaultMaxWorkersMul = 2   // MaxWorkers defaults to this many times Workers
+)
+
+// workerPool limits concurrent chunk requests. A fixed pool keeps Workers
+// requests in flight. An adaptive pool starts at Workers and scales
+// AIMD-style within [MinWorkers, MaxWorkers]: one more worker after a full
+// round of healthy requests, half as many after a failure or a request
+// much slower than the running average latency.
+type workerPool struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	active   int
+	limit    int
+	min, max int
+	adaptive bool
+
+	average  time.Duration // Running average latency of successful requests
+	healthy  int           // Healthy requests since the limit last changed
+	cooldown int           // Requests to ignore after a decrease, still in flight when it happened
+	peak     int
+	file     string
+}
+
+// newWorkerPool creates the pool for a request's chunks
+func newWorkerPool(options TranscribeOptions, file string) *workerPool {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = 3
+	}
+	p := &workerPool{limit: workers, min: workers, max: workers, file: file}
+	if options.AdaptiveWorkers {
+		p.adaptive = true
+		p.min, p.max = options.MinWorkers, options.MaxWorkers
+		if p.min <= 0 {
+			p.min = 1
+		}
+		if p.max <= 0 {
+			p.max = workers * defaultMaxWorkersMul
+		}
+		p.max = max(p.max, p.min)
+		p.limit = min(max(workers, p.min), p.max)
+	}
+	p.peak = p.limit
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// acquire waits for a free worker
+func (p *workerPool) acquire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.active >= p.limit {
+		p.cond.Wait()
+	}
+	p.active++
+}
+
+// release frees a worker without judging the provider's health, e.g.
+// for a chunk restored from a checkpoint or the cache
+func (p *workerPool) release() {
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// done frees a worker after a provider request and adjusts an adaptive
+// pool to how it went
+func (p *workerPool) done(latency time.Duration, err error) {
+	p.mu.Lock()
+	p.active--
+	if p.adaptive {
+		p.observe(latency, err)
+	}
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// observe applies one request's outcome to the limit; p.mu is held
+func (p *workerPool) observe(latency time.Duration, err error) {
+	slow := err == nil && p.average > 0 && float64(latency) > workerSlowFactor*float64(p.average)
+	if err == nil {
+		if p.average == 0 {
+			p.average = latency
+		} else {
+			p.average = time.Duration(workerLatencySmooth*float64(latency) + (1-workerLatencySmooth)*float64(p.average))
+		}
+	}
+
+	// Requests already in flight when the limit dropped report the same
+	// congestion and must not halve it again
+	if p.cooldown > 0 {
+		p.cooldown--
+		return
+	}
+
+	if err != nil || slow {
+		if p.limit > p.min {
+			from := p.limit
+			p.limit = max(p.min, p.limit/2)
+			p.log(from, err, slow)
+		}
+		p.healthy = 0
+		p.cooldown = p.active
+		return
+	}
+
+	p.healthy++
+	if p.healthy >= p.limit && p.limit < p.max {
+		from := p.limit
+		p.limit++
+		p.peak = max(p.peak, p.limit)
+		p.healthy = 0
+		p.log(from, nil, false)
+	}
+}
+
+// log records a change of the limit
+func (p *workerPool) log(from int, err error, slow bool) {
+	event := logger.WithComponent("workers").WithField("file", p.file).Info()
+	reason := "healthy requests"
+	switch {
+	case err != nil:
+		reason = "request failed"
+		event = event.Err(err)
+	case slow:
+		reason = "slow request"
+	}
+	event.Int("from", from).Int("to", p.limit).Dur("avg_latency", p.average).Str("reason", reason).Msg("Scaling chunk workers")
+}
+
+// stats returns the current and peak number of workers
+func (p *workerPool) stats() (limit, peak int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.limit, p.peak
+}
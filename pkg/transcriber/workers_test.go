@@ -0,0 +1,71 @@
+package transcriber
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolFixed(t *testing.T) {
+	p := newWorkerPool(TranscribeOptions{Workers: 4}, "talk.mp3")
+	p.acquire()
+	p.done(time.Second, errors.New("rate limited"))
+	if limit, peak := p.stats(); limit != 4 || peak != 4 || p.adaptive {
+		t.Errorf("fixed pool = %d workers (peak %d), want 4", limit, peak)
+	}
+}
+
+func TestWorkerPoolAdaptive(t *testing.T) {
+	p := newWorkerPool(TranscribeOptions{Workers: 2, AdaptiveWorkers: true, MaxWorkers: 4}, "talk.mp3")
+	if p.min != 1 || p.max != 4 || p.limit != 2 {
+		t.Fatalf("pool bounds = [%d, %d] starting at %d, want [1, 4] at 2", p.min, p.max, p.limit)
+	}
+	request := func(latency time.Duration, err error) {
+		p.acquire()
+		p.done(latency, err)
+	}
+
+	// A round of healthy requests adds a worker, up to the maximum
+	for i := 0; i < 2+3+4; i++ {
+		request(10*time.Second, nil)
+	}
+	if limit, peak := p.stats(); limit != 4 || peak != 4 {
+		t.Fatalf("after healthy requests: %d workers (peak %d), want 4", limit, peak)
+	}
+	for i := 0; i < 8; i++ {
+		request(10*time.Second, nil)
+	}
+	if limit, _ := p.stats(); limit != 4 {
+		t.Errorf("limit grew past the maximum to %d", limit)
+	}
+
+	// A failure halves the workers, as does a request far slower than usual
+	request(time.Second, errors.New("429 Too Many Requests"))
+	if limit, _ := p.stats(); limit != 2 {
+		t.Errorf("after a failure: %d workers, want 2", limit)
+	}
+	request(time.Minute, nil)
+	if limit, _ := p.stats(); limit != 1 {
+		t.Errorf("after a slow request: %d workers, want 1", limit)
+	}
+	request(time.Second, errors.New("overloaded"))
+	if limit, _ := p.stats(); limit != 1 {
+		t.Errorf("limit fell below the minimum to %d", limit)
+	}
+}
+
+func TestWorkerPoolCooldown(t *testing.T) {
+	p := newWorkerPool(TranscribeOptions{Workers: 8, AdaptiveWorkers: true}, "talk.mp3")
+	for i := 0; i < 4; i++ {
+		p.acquire()
+	}
+
+	// Requests in flight during a failure report the same congestion
+	p.done(time.Second, errors.New("rate limited"))
+	for i := 0; i < 3; i++ {
+		p.done(time.Second, errors.New("rate limited"))
+	}
+	if limit, _ := p.stats(); limit != 4 {
+		t.Errorf("after a burst of failures: %d workers, want 4", limit)
+	}
+}
@@ -0,0 +1,293 @@
+package watcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/config"
+)
+
+// Export formats of history files
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+// exportTimeout bounds pushing a report to an HTTP endpoint
+const exportTimeout = 30 * time.Second
+
+// HistoryExport mirrors the history database and its usage stats to a
+// file or an HTTP endpoint, for teams building reports without access to
+// the database itself
+type HistoryExport struct {
+	// File the report is written to, replaced atomically (optional)
+	Path string
+
+	// ExportJSON or ExportCSV; empty picks it from Path's extension. CSV
+	// writes a row per file, and the daily stats to StatsPath(Path).
+	Format string
+
+	// Endpoint the JSON report is POSTed to (optional)
+	URL string
+
+	// Headers sent with the report, e.g. Authorization
+	Headers map[string]string
+
+	// How often watch mode exports the history (default: hourly)
+	Interval time.Duration
+}
+
+// MarshalJSON encodes the export with its header values redacted, as they
+// hold credentials, so logging the watch configuration does not leak them
+func (e HistoryExport) MarshalJSON() ([]byte, error) {
+	type export HistoryExport
+	redacted := export(e)
+	redacted.Headers = config.RedactHeaders(e.Headers)
+	return json.Marshal(redacted)
+}
+
+// Enabled reports whether the history is exported anywhere
+func (e HistoryExport) Enabled() bool {
+	return e.Path != "" || e.URL != ""
+}
+
+// format returns the format the report file is written in
+func (e HistoryExport) format() string {
+	if e.Format != "" {
+		return strings.ToLower(e.Format)
+	}
+	if strings.EqualFold(filepath.Ext(e.Path), ".csv") {
+		return ExportCSV
+	}
+	return ExportJSON
+}
+
+// Validate checks that the export can be made
+func (e HistoryExport) Validate() error {
+	switch e.format() {
+	case ExportJSON, ExportCSV:
+	default:
+		return fmt.Errorf("unknown history export format: %s (use json or csv)", e.Format)
+	}
+	if e.URL != "" && !strings.HasPrefix(e.URL, "http://") && !strings.HasPrefix(e.URL, "https://") {
+		return fmt.Errorf("history export URL must be http or https: %s", e.URL)
+	}
+	return nil
+}
+
+// StatsPath returns where a CSV export writes its daily stats, e.g.
+// history.stats.csv for history.csv
+func StatsPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".stats.csv"
+}
+
+// UsageStats totals the files processed and failed
+type UsageStats struct {
+	Processed      int           `json:"processed"`
+	Failed         int           `json:"failed"` // Failed attempts
+	Bytes          int64         `json:"bytes"`  // Size of the processed media
+	ProcessingTime time.Duration `json:"processing_time"`
+	Suspect        int           `json:"suspect"` // Processed files scored below transcriber.SuspectQuality
+}
+
+// add counts a processed file
+func (s *UsageStats) add(info *ProcessedInfo) {
+	s.Processed++
+	s.Bytes += info.FileSize
+	s.ProcessingTime += info.Duration
+	if info.Quality != nil && info.Quality.Suspect() {
+		s.Suspect++
+	}
+}
+
+// DailyStats are the usage stats of one day
+type DailyStats struct {
+	Date string `json:"date"` // YYYY-MM-DD, UTC
+	UsageStats
+}
+
+// HistoryReport is a snapshot of the history database
+type HistoryReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Totals      UsageStats       `json:"totals"`
+	Days        []DailyStats     `json:"days"` // Oldest first
+	Processed   []*ProcessedInfo `json:"processed"`
+	Failed      []*FailedInfo    `json:"failed"`
+}
+
+// BuildHistoryReport snapshots the processed and failed files in history
+// with their usage stats, newest first
+func BuildHistoryReport(history ProcessingHistory, now time.Time) (*HistoryReport, error) {
+	processed, err := history.ListProcessed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processed files: %w", err)
+	}
+	failed, err := history.ListFailed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed files: %w", err)
+	}
+	sort.SliceStable(processed, func(i, j int) bool { return processed[i].ProcessedAt.After(processed[j].ProcessedAt) })
+	sort.SliceStable(failed, func(i, j int) bool { return failed[i].FailedAt.After(failed[j].FailedAt) })
+
+	report := &HistoryReport{GeneratedAt: now, Processed: processed, Failed: failed}
+	days := make(map[string]*DailyStats)
+	day := func(t time.Time) *DailyStats {
+		date := t.UTC().Format("2006-01-02")
+		if days[date] == nil {
+			days[date] = &DailyStats{Date: date}
+		}
+		return days[date]
+	}
+	for _, info := range processed {
+		report.Totals.add(info)
+		day(info.ProcessedAt).add(info)
+	}
+	for _, info := range failed {
+		attempts := info.History
+		if len(attempts) == 0 {
+			attempts = []FailedAttempt{{At: info.FailedAt, Error: info.Error}}
+		}
+		for _, attempt := range attempts {
+			report.Totals.Failed++
+			day(attempt.At).Failed++
+		}
+	}
+	for _, stats := range days {
+		report.Days = append(report.Days, *stats)
+	}
+	sort.Slice(report.Days, func(i, j int) bool { return report.Days[i].Date < report.Days[j].Date })
+	return report, nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *HistoryReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes a row per processed and failed file
+func (r *HistoryReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"status", "hash", "filepath", "at", "output_path", "moved_path",
+		"file_size", "duration_seconds", "attempts", "error", "notes", "quality"})
+	for _, info := range r.Processed {
+		quality := ""
+		if info.Quality != nil {
+			quality = strconv.Itoa(info.Quality.Score)
+		}
+		_ = writer.Write([]string{"processed", info.FileHash, info.FilePath, info.ProcessedAt.Format(time.RFC3339),
+			info.OutputPath, info.MovedPath, strconv.FormatInt(info.FileSize, 10),
+			strconv.FormatFloat(info.Duration.Seconds(), 'f', 1, 64), "", "", strings.Join(info.Notes, "; "), quality})
+	}
+	for _, info := range r.Failed {
+		_ = writer.Write([]string{"failed", info.FileHash, info.FilePath, info.FailedAt.Format(time.RFC3339),
+			"", "", "", "", strconv.Itoa(info.Attempts()), info.Error, "", ""})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteStatsCSV writes a row of usage stats per day
+func (r *HistoryReport) WriteStatsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"date", "processed", "failed", "bytes", "processing_seconds", "suspect"})
+	for _, day := range r.Days {
+		_ = writer.Write([]string{day.Date, strconv.Itoa(day.Processed), strconv.Itoa(day.Failed),
+			strconv.FormatInt(day.Bytes, 10), strconv.FormatFloat(day.ProcessingTime.Seconds(), 'f', 1, 64),
+			strconv.Itoa(day.Suspect)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportHistory snapshots history and writes it to the export's file and
+// endpoint. A failing destination does not keep the other from being
+// written; the errors of both are returned.
+func ExportHistory(ctx context.Context, history ProcessingHistory, export HistoryExport, now time.Time) (*HistoryReport, error) {
+	if err := export.Validate(); err != nil {
+		return nil, err
+	}
+	report, err := BuildHistoryReport(history, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if export.Path != "" {
+		if export.format() == ExportCSV {
+			errs = append(errs, writeReportFile(export.Path, report.WriteCSV),
+				writeReportFile(StatsPath(export.Path), report.WriteStatsCSV))
+		} else {
+			errs = append(errs, writeReportFile(export.Path, report.WriteJSON))
+		}
+	}
+	if export.URL != "" {
+		errs = append(errs, postReport(ctx, export, report))
+	}
+	return report, errors.Join(errs...)
+}
+
+// writeReportFile writes a report file atomically, so readers never see
+// half of it
+func writeReportFile(path string, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return fmt.Errorf("failed to encode history export: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create history export directory: %w", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write history export: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write history export: %w", err)
+	}
+	return nil
+}
+
+// postReport POSTs the report as JSON to the export's endpoint
+func postReport(ctx context.Context, export HistoryExport, report *HistoryReport) error {
+	var body bytes.Buffer
+	if err := report.WriteJSON(&body); err != nil {
+		return fmt.Errorf("failed to encode history export: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, export.URL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create history export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range export.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push history export: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("history export endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
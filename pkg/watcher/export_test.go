@@ -0,0 +1,154 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+func exportTestHistory(t *testing.T) ProcessingHistory {
+	t.Helper()
+	history, err := NewProcessingHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = history.Close() })
+
+	day := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	_ = history.RecordProcessed("a", &ProcessedInfo{FileHash: "a", FilePath: "in/a.mp3", OutputPath: "out/a.txt",
+		ProcessedAt: day, Duration: time.Minute, FileSize: 1000, Notes: []string{"board meeting"}})
+	_ = history.RecordProcessed("b", &ProcessedInfo{FileHash: "b", FilePath: "in/b.mp3", OutputPath: "out/b.txt",
+		ProcessedAt: day.Add(-24 * time.Hour), Duration: 2 * time.Minute, FileSize: 500,
+		Quality: &transcriber.Quality{Score: 41}})
+	_ = history.RecordFailed("c", &FailedInfo{FileHash: "c", FilePath: "in/c.mp3", FailedAt: day, Error: "timeout"})
+	_ = history.RecordFailed("c", &FailedInfo{FileHash: "c", FilePath: "in/c.mp3", FailedAt: day.Add(time.Hour), Error: "timeout"})
+	return history
+}
+
+func TestBuildHistoryReport(t *testing.T) {
+	report, err := BuildHistoryReport(exportTestHistory(t), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Processed) != 2 || report.Processed[0].FileHash != "a" {
+		t.Fatalf("processed = %+v, want newest first", report.Processed)
+	}
+	want := UsageStats{Processed: 2, Failed: 2, Bytes: 1500, ProcessingTime: 3 * time.Minute, Suspect: 1}
+	if report.Totals != want {
+		t.Errorf("totals = %+v, want %+v", report.Totals, want)
+	}
+	if len(report.Days) != 2 || report.Days[0].Date != "2025-06-29" || report.Days[1].Date != "2025-06-30" {
+		t.Fatalf("days = %+v", report.Days)
+	}
+	if day := report.Days[1]; day.Processed != 1 || day.Failed != 2 || day.Bytes != 1000 {
+		t.Errorf("2025-06-30 = %+v", day)
+	}
+}
+
+func TestExportHistoryCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "history.csv")
+	if _, err := ExportHistory(context.Background(), exportTestHistory(t), HistoryExport{Path: path}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "status,hash,filepath") {
+		t.Fatalf("history.csv = %s", data)
+	}
+	if !strings.Contains(lines[1], "processed,a,in/a.mp3") || !strings.HasSuffix(lines[1], "board meeting,") {
+		t.Errorf("row = %s", lines[1])
+	}
+	if !strings.Contains(lines[2], "processed,b,in/b.mp3") || !strings.HasSuffix(lines[2], ",41") {
+		t.Errorf("row = %s", lines[2])
+	}
+	if !strings.Contains(lines[3], "failed,c,in/c.mp3") || !strings.Contains(lines[3], ",2,timeout,") {
+		t.Errorf("row = %s", lines[3])
+	}
+
+	stats, err := os.ReadFile(StatsPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "date,processed,failed,bytes,processing_seconds,suspect\n2025-06-29,1,0,500,120.0,1\n2025-06-30,1,2,1000,60.0,0\n"; string(stats) != want {
+		t.Errorf("history.stats.csv = %q, want %q", stats, want)
+	}
+}
+
+func TestExportHistoryURL(t *testing.T) {
+	var got HistoryReport
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("body is not a report: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	export := HistoryExport{URL: server.URL, Headers: map[string]string{"authorization": "Bearer secret"}}
+	if _, err := ExportHistory(context.Background(), exportTestHistory(t), export, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if len(got.Processed) != 2 || len(got.Failed) != 1 || got.Totals.Failed != 2 {
+		t.Errorf("pushed report = %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	path := filepath.Join(t.TempDir(), "history.json")
+	_, err := ExportHistory(context.Background(), exportTestHistory(t), HistoryExport{URL: failing.URL, Path: path}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("err = %v, want the endpoint's status", err)
+	}
+	if _, statErr := os.Stat(path); statErr != nil {
+		t.Errorf("file not written when the endpoint failed: %v", statErr)
+	}
+}
+
+func TestHistoryExportJSONRedactsHeaders(t *testing.T) {
+	export := HistoryExport{URL: "https://reports.example.com", Headers: map[string]string{"Authorization": "Bearer secret"}}
+	data, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || !strings.Contains(string(data), "Authorization") {
+		t.Errorf("export JSON = %s, want the header without its value", data)
+	}
+	if export.Headers["Authorization"] != "Bearer secret" {
+		t.Error("redacting changed the headers sent")
+	}
+}
+
+func TestHistoryExportValidate(t *testing.T) {
+	for _, export := range []HistoryExport{
+		{Path: "history.xml", Format: "xml"},
+		{URL: "ftp://example.com"},
+	} {
+		if err := export.Validate(); err == nil {
+			t.Errorf("%+v validated", export)
+		}
+	}
+	if err := (HistoryExport{Path: "history.CSV"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,9 +3,10 @@ package watcher
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	bolt "go.etcd.io/bbolt"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
 )
 
 const (
@@ -15,15 +16,26 @@ const (
 
 // processingHistory implements ProcessingHistory interface using BoltDB
 type processingHistory struct {
-	db *bolt.DB
+	db     *bolt.DB
+	path   string
+	cipher *encryption.Cipher
 }
 
 // NewProcessingHistory creates a new processing history with BoltDB
 func NewProcessingHistory(dbPath string) (ProcessingHistory, error) {
-	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{
-		Timeout: 1 * time.Second,
-	})
+	return NewEncryptedProcessingHistory(dbPath, nil)
+}
+
+// NewEncryptedProcessingHistory creates a processing history whose records
+// are encrypted with c. Plaintext records from earlier runs stay readable.
+// If another process has the database open, the error is a
+// *HistoryLockedError naming it.
+func NewEncryptedProcessingHistory(dbPath string, c *encryption.Cipher) (ProcessingHistory, error) {
+	db, err := openHistoryDB(dbPath)
 	if err != nil {
+		if IsHistoryLocked(err) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to open history database: %w", err)
 	}
 
@@ -39,10 +51,11 @@ func NewProcessingHistory(dbPath string) (ProcessingHistory, error) {
 	})
 	if err != nil {
 		_ = db.Close()
+		removeHistoryHolder(dbPath)
 		return nil, err
 	}
 
-	return &processingHistory{db: db}, nil
+	return &processingHistory{db: db, path: dbPath, cipher: c}, nil
 }
 
 // IsProcessed checks if a file hash has been processed
@@ -68,7 +81,7 @@ func (ph *processingHistory) RecordProcessed(fileHash string, info *ProcessedInf
 			return fmt.Errorf("processed bucket not found")
 		}
 
-		data, err := json.Marshal(info)
+		data, err := ph.encode(info)
 		if err != nil {
 			return fmt.Errorf("failed to marshal processed info: %w", err)
 		}
@@ -96,15 +109,18 @@ func (ph *processingHistory) RecordFailed(fileHash string, info *FailedInfo) err
 		}
 
 		// Check if already failed, increment retry count
+		var history []FailedAttempt
 		existingData := bucket.Get([]byte(fileHash))
 		if existingData != nil {
 			var existing FailedInfo
-			if err := json.Unmarshal(existingData, &existing); err == nil {
+			if err := ph.decode(existingData, &existing); err == nil {
 				info.RetryCount = existing.RetryCount + 1
+				history = existing.History
 			}
 		}
+		info.History = append(history, FailedAttempt{At: info.FailedAt, Error: info.Error})
 
-		data, err := json.Marshal(info)
+		data, err := ph.encode(info)
 		if err != nil {
 			return fmt.Errorf("failed to marshal failed info: %w", err)
 		}
@@ -132,7 +148,7 @@ func (ph *processingHistory) GetProcessedInfo(fileHash string) (*ProcessedInfo,
 		}
 
 		var processedInfo ProcessedInfo
-		if err := json.Unmarshal(data, &processedInfo); err != nil {
+		if err := ph.decode(data, &processedInfo); err != nil {
 			return fmt.Errorf("failed to unmarshal processed info: %w", err)
 		}
 
@@ -157,7 +173,7 @@ func (ph *processingHistory) GetFailedInfo(fileHash string) (*FailedInfo, error)
 		}
 
 		var failedInfo FailedInfo
-		if err := json.Unmarshal(data, &failedInfo); err != nil {
+		if err := ph.decode(data, &failedInfo); err != nil {
 			return fmt.Errorf("failed to unmarshal failed info: %w", err)
 		}
 
@@ -167,7 +183,71 @@ func (ph *processingHistory) GetFailedInfo(fileHash string) (*FailedInfo, error)
 	return info, err
 }
 
+// ListProcessed returns all processed file records
+func (ph *processingHistory) ListProcessed() ([]*ProcessedInfo, error) {
+	var infos []*ProcessedInfo
+	err := ph.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketProcessed))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, data []byte) error {
+			var info ProcessedInfo
+			if err := ph.decode(data, &info); err != nil {
+				return fmt.Errorf("failed to unmarshal processed info %s: %w", key, err)
+			}
+			infos = append(infos, &info)
+			return nil
+		})
+	})
+	return infos, err
+}
+
+// ListFailed returns all failed file records
+func (ph *processingHistory) ListFailed() ([]*FailedInfo, error) {
+	var infos []*FailedInfo
+	err := ph.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(bucketFailed))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, data []byte) error {
+			var info FailedInfo
+			if err := ph.decode(data, &info); err != nil {
+				return fmt.Errorf("failed to unmarshal failed info %s: %w", key, err)
+			}
+			infos = append(infos, &info)
+			return nil
+		})
+	})
+	return infos, err
+}
+
+// encode marshals a record and encrypts it if a cipher is set
+func (ph *processingHistory) encode(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return ph.cipher.Encrypt(data)
+}
+
+// decode decrypts a record if needed and unmarshals it
+func (ph *processingHistory) decode(data []byte, v interface{}) error {
+	data, err := ph.cipher.Decrypt(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
 // Close closes the underlying database
 func (ph *processingHistory) Close() error {
-	return ph.db.Close()
+	err := ph.db.Close()
+	if !ph.db.IsReadOnly() {
+		removeHistoryHolder(ph.path)
+	}
+	return err
 }
@@ -0,0 +1,181 @@
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/store"
+)
+
+// Outcomes of importing a media file
+const (
+	ImportRecorded     = "recorded"      // Recorded as processed
+	ImportExisting     = "existing"      // Already in the history
+	ImportNoTranscript = "no-transcript" // Left for watch mode to transcribe
+	ImportFailed       = "failed"
+)
+
+// importTranscriptExts are the transcript files recognized, preferred in
+// this order when a media file has several
+var importTranscriptExts = []string{".txt", ".md", ".srt", ".vtt", ".json"}
+
+// ImportOptions selects the media and the transcripts other tools wrote
+// for it
+type ImportOptions struct {
+	MediaDir      string   // Where the media is, e.g. the watched directory
+	TranscriptDir string   // Where the transcripts are
+	Patterns      []string // Media file patterns, as in watch mode
+
+	// Include subdirectories; a transcript must then be at the same
+	// relative path in TranscriptDir as its media in MediaDir
+	Recursive bool
+
+	// Only report what would be recorded
+	DryRun bool
+}
+
+// ImportEntry is the outcome for one media file
+type ImportEntry struct {
+	MediaPath  string
+	Transcript string
+	FileHash   string
+	Status     string
+	Error      string
+}
+
+// ImportTranscripts records media that other tools already transcribed as
+// processed, so watch mode does not transcribe it again. Media matches a
+// transcript of the same name: meeting.mp3 matches meeting.txt or
+// meeting.mp3.txt (or .md, .srt, .vtt, .json). It is recorded as
+// processed when the transcript was last written, with the transcript as
+// its output, so the retention policy ages it from then. Media already in
+// the history is left alone.
+func ImportTranscripts(history ProcessingHistory, options ImportOptions) ([]ImportEntry, error) {
+	transcripts, err := indexTranscripts(options.TranscriptDir, options.Recursive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcripts: %w", err)
+	}
+
+	log := logger.WithComponent("history-import")
+	var entries []ImportEntry
+	err = walkDir(options.MediaDir, options.Recursive, func(path, rel string) error {
+		if !matchesAny(options.Patterns, filepath.Base(path)) {
+			return nil
+		}
+		entry := ImportEntry{MediaPath: path, Status: ImportNoTranscript}
+		defer func() { entries = append(entries, entry) }()
+
+		transcript, ok := transcripts[rel]
+		if !ok {
+			if transcript, ok = transcripts[strings.TrimSuffix(rel, filepath.Ext(rel))]; !ok {
+				return nil
+			}
+		}
+		entry.Transcript = transcript
+
+		hash, err := store.HashFile(path)
+		if err != nil {
+			entry.Status, entry.Error = ImportFailed, err.Error()
+			return nil
+		}
+		entry.FileHash = hash
+		if processed, err := history.IsProcessed(entry.FileHash); err != nil {
+			entry.Status, entry.Error = ImportFailed, err.Error()
+			return nil
+		} else if processed {
+			entry.Status = ImportExisting
+			return nil
+		}
+
+		mediaInfo, err := os.Stat(path)
+		if err != nil {
+			entry.Status, entry.Error = ImportFailed, err.Error()
+			return nil
+		}
+		transcriptInfo, err := os.Stat(transcript)
+		if err != nil {
+			entry.Status, entry.Error = ImportFailed, err.Error()
+			return nil
+		}
+
+		entry.Status = ImportRecorded
+		if options.DryRun {
+			return nil
+		}
+		info := &ProcessedInfo{
+			FileHash:    entry.FileHash,
+			FilePath:    path,
+			ProcessedAt: transcriptInfo.ModTime(),
+			OutputPath:  transcript,
+			FileSize:    mediaInfo.Size(),
+		}
+		if err := history.RecordProcessed(entry.FileHash, info); err != nil {
+			entry.Status, entry.Error = ImportFailed, err.Error()
+			log.Warn().Err(err).Str("file", path).Msg("Failed to record imported transcript")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	return entries, nil
+}
+
+// indexTranscripts returns the transcripts in dir by their path relative
+// to dir without the extension
+func indexTranscripts(dir string, recursive bool) (map[string]string, error) {
+	transcripts := make(map[string]string)
+	rank := make(map[string]int)
+	err := walkDir(dir, recursive, func(path, rel string) error {
+		ext := strings.ToLower(filepath.Ext(rel))
+		for i, known := range importTranscriptExts {
+			if ext != known {
+				continue
+			}
+			key := strings.TrimSuffix(rel, filepath.Ext(rel))
+			if current, ok := rank[key]; !ok || i < current {
+				transcripts[key], rank[key] = path, i
+			}
+		}
+		return nil
+	})
+	return transcripts, err
+}
+
+// walkDir calls fn with each regular file in dir and its slash-separated
+// path relative to dir, descending into subdirectories if recursive
+func walkDir(dir string, recursive bool, fn func(path, rel string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(rel))
+	})
+}
+
+// matchesAny reports whether name matches one of the file patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
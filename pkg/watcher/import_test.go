@@ -0,0 +1,111 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/store"
+)
+
+func TestImportTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	history, err := NewProcessingHistory(filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = history.Close() }()
+
+	inbox := filepath.Join(dir, "inbox")
+	transcripts := filepath.Join(dir, "transcripts")
+	for _, d := range []string{inbox, filepath.Join(inbox, "old"), transcripts, filepath.Join(transcripts, "old")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{"a.mp3", "b.wav", "c.mp3", "d.mp3", "notes.pdf", "old/e.mp3"} {
+		if err := os.WriteFile(filepath.Join(inbox, path), []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	written := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, path := range []string{"a.srt", "a.txt", "b.wav.txt", "c.json", "old/e.txt"} {
+		path = filepath.Join(transcripts, path)
+		writeFile(t, path)
+		if err := os.Chtimes(path, written, written); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cHash, err := store.HashFile(filepath.Join(inbox, "c.mp3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = history.RecordProcessed(cHash, &ProcessedInfo{FileHash: cHash, FilePath: filepath.Join(inbox, "c.mp3")})
+
+	options := ImportOptions{
+		MediaDir:      inbox,
+		TranscriptDir: transcripts,
+		Patterns:      []string{"*.mp3", "*.wav"},
+		DryRun:        true,
+	}
+	status := func(entries []ImportEntry) map[string]string {
+		got := make(map[string]string)
+		for _, entry := range entries {
+			rel, _ := filepath.Rel(inbox, entry.MediaPath)
+			got[filepath.ToSlash(rel)] = entry.Status
+		}
+		return got
+	}
+
+	// A dry run reports without recording; subdirectories need Recursive
+	entries, err := ImportTranscripts(history, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.mp3": ImportRecorded, "b.wav": ImportRecorded, "c.mp3": ImportExisting, "d.mp3": ImportNoTranscript}
+	if got := status(entries); len(got) != len(want) {
+		t.Fatalf("dry run = %v, want %v", got, want)
+	}
+	for media, s := range status(entries) {
+		if s != want[media] {
+			t.Errorf("dry run %s = %s, want %s", media, s, want[media])
+		}
+	}
+	if processed, _ := history.ListProcessed(); len(processed) != 1 {
+		t.Errorf("dry run recorded %d files", len(processed)-1)
+	}
+
+	options.DryRun, options.Recursive = false, true
+	entries, err = ImportTranscripts(history, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want["old/e.mp3"] = ImportRecorded
+	for media, s := range status(entries) {
+		if s != want[media] {
+			t.Errorf("import %s = %s, want %s", media, s, want[media])
+		}
+	}
+
+	// The plain transcript wins over subtitles, and dates the record
+	for _, entry := range entries {
+		if entry.Status != ImportRecorded {
+			continue
+		}
+		if processed, err := history.IsProcessed(entry.FileHash); err != nil || !processed {
+			t.Errorf("%s not recorded as processed", entry.MediaPath)
+		}
+	}
+	processed, err := history.ListProcessed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range processed {
+		if info.FilePath == filepath.Join(inbox, "a.mp3") {
+			if info.OutputPath != filepath.Join(transcripts, "a.txt") || !info.ProcessedAt.Equal(written) {
+				t.Errorf("a.mp3 recorded with %s at %v, want a.txt at %v", info.OutputPath, info.ProcessedAt, written)
+			}
+		}
+	}
+}
@@ -2,9 +2,13 @@ package watcher
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+	"github.com/eternnoir/gollmscribe/pkg/events"
+	"github.com/eternnoir/gollmscribe/pkg/rules"
 	"github.com/eternnoir/gollmscribe/pkg/transcriber"
 )
 
@@ -24,6 +28,45 @@ type FileWatcher interface {
 
 	// WaitForInitialProcessing returns a WaitGroup that completes when initial file processing is done
 	WaitForInitialProcessing() *sync.WaitGroup
+
+	// Events returns the bus on which file lifecycle events are published.
+	// The bus is closed when the watcher stops.
+	Events() *events.Bus
+
+	// Queued returns the files waiting for a worker, longest waiting first
+	Queued() []QueuedFile
+
+	// InProgress returns the files being processed, longest running first
+	InProgress() []ActiveFile
+
+	// Recent returns the latest completed and failed files, newest first:
+	// those finished since the watcher started, then older ones from the
+	// history. A limit of 0 returns all of them.
+	Recent(limit int) ([]RecentFile, error)
+}
+
+// QueuedFile is a file waiting for a worker
+type QueuedFile struct {
+	FilePath string    `json:"filepath"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// ActiveFile is a file being processed
+type ActiveFile struct {
+	FilePath  string        `json:"filepath"`
+	StartedAt time.Time     `json:"started_at"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+// RecentFile is a file that finished processing
+type RecentFile struct {
+	FilePath   string        `json:"filepath"`
+	Status     string        `json:"status"` // "completed" or "failed"
+	At         time.Time     `json:"at"`
+	OutputPath string        `json:"output_path,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"` // Processing time, if known
 }
 
 // ProcessingTracker manages the state of files being processed
@@ -42,6 +85,9 @@ type ProcessingTracker interface {
 
 	// GetLocked returns all currently locked files
 	GetLocked() []string
+
+	// GetLockedSince returns when each currently locked file was locked
+	GetLockedSince() map[string]time.Time
 }
 
 // ProcessingHistory manages the persistent history of processed files
@@ -61,6 +107,12 @@ type ProcessingHistory interface {
 	// GetFailedInfo retrieves information about a failed file
 	GetFailedInfo(fileHash string) (*FailedInfo, error)
 
+	// ListProcessed returns all processed file records
+	ListProcessed() ([]*ProcessedInfo, error)
+
+	// ListFailed returns all failed file records
+	ListFailed() ([]*FailedInfo, error)
+
 	// Close closes the underlying database
 	Close() error
 }
@@ -79,11 +131,12 @@ type ProgressCallback func(event *ProgressEvent)
 
 // ProgressEvent represents a progress update
 type ProgressEvent struct {
-	Type      string // "found", "processing", "completed", "failed", "skipped"
-	FilePath  string
-	Message   string
-	Error     error
-	Timestamp time.Time
+	Type       string // "found", "processing", "completed", "failed", "quarantined", "skipped"
+	FilePath   string
+	OutputPath string // Set for "completed", and the new path for "quarantined"
+	Message    string
+	Error      error
+	Timestamp  time.Time
 }
 
 // ProcessedInfo contains information about a successfully processed file
@@ -94,6 +147,27 @@ type ProcessedInfo struct {
 	OutputPath  string        `json:"output_path"`
 	Duration    time.Duration `json:"duration"`
 	FileSize    int64         `json:"file_size"`
+
+	// Where the source file was moved to with MoveToDir
+	MovedPath string `json:"moved_path,omitempty"`
+
+	// Operator notes of the transcript, e.g. "board meeting"
+	Notes []string `json:"notes,omitempty"`
+
+	// Heuristic quality of the transcript, if it could be scored
+	Quality *transcriber.Quality `json:"quality,omitempty"`
+
+	// When the retention policy removed the source media or transcript
+	MediaRemovedAt      *time.Time `json:"media_removed_at,omitempty"`
+	TranscriptRemovedAt *time.Time `json:"transcript_removed_at,omitempty"`
+}
+
+// MediaPath returns the current location of the processed source file
+func (p *ProcessedInfo) MediaPath() string {
+	if p.MovedPath != "" {
+		return p.MovedPath
+	}
+	return p.FilePath
 }
 
 // FailedInfo contains information about a failed processing attempt
@@ -103,6 +177,23 @@ type FailedInfo struct {
 	FailedAt   time.Time `json:"failed_at"`
 	Error      string    `json:"error"`
 	RetryCount int       `json:"retry_count"`
+
+	// Every failed attempt, oldest first
+	History []FailedAttempt `json:"history,omitempty"`
+}
+
+// FailedAttempt is one failed attempt at processing a file
+type FailedAttempt struct {
+	At    time.Time `json:"at"`
+	Error string    `json:"error"`
+}
+
+// Attempts returns how many times processing the file failed
+func (f *FailedInfo) Attempts() int {
+	if len(f.History) > 0 {
+		return len(f.History)
+	}
+	return f.RetryCount + 1
 }
 
 // WatchStats contains statistics about the watcher
@@ -141,23 +232,119 @@ type WatchConfig struct {
 	// Directory to output transcriptions to
 	OutputDir string
 
-	// Shared prompt for all transcriptions
+	// Operator notes stored with every transcript and history record
+	Notes []string
+
+	// Shared prompt for all transcriptions. A file with a sidecar prompt
+	// file (see PromptSidecarSuffix) uses that instead.
 	SharedPrompt string
 
 	// Path to the BoltDB history database
 	HistoryDB string
 
+	// Encrypts history records (optional)
+	HistoryCipher *encryption.Cipher
+
+	// What to do when another process has HistoryDB open: HistoryLockedFail
+	// (the default), HistoryLockedReadOnly or HistoryLockedInstance
+	HistoryLocked string
+
 	// Whether to process existing files on startup
 	ProcessExisting bool
 
+	// Records the existing files in a session next to HistoryDB (see
+	// SessionPath) until they are all processed, so a run killed halfway,
+	// e.g. with --once, is continued by the next one. ResetSession starts
+	// over.
+	ResumeSession bool
+	ResetSession  bool
+
 	// Whether to retry failed files
 	RetryFailed bool
 
+	// Files that failed MaxAttempts times are moved to QuarantineDir with
+	// their failure history in a .error.json file, instead of being
+	// retried on every scan (optional)
+	QuarantineDir string
+	MaxAttempts   int
+
 	// Maximum number of concurrent processing workers
 	MaxWorkers int
 
 	// Transcription options for all files
 	TranscribeOptions transcriber.TranscribeOptions
+
+	// Per-directory overrides; the most specific matching route wins
+	Routes []Route
+
+	// Rules evaluated per file on its tags, from its directories and
+	// Notes; they override the route's transcriber and the output directory
+	Rules []rules.Rule
+
+	// Removal of processed media and transcripts after a retention period
+	Retention RetentionPolicy
+
+	// Periodic mirror of the history and its usage stats to a file or an
+	// HTTP endpoint (optional)
+	Export HistoryExport
+
+	// Prompt and output name picked from each file's language (optional)
+	Languages *LanguageRouting
+}
+
+// PromptSidecarSuffix names a file's sidecar prompt file: the prompt in
+// meeting1.mp3.prompt.txt is used for meeting1.mp3 instead of the shared
+// or route prompt
+const PromptSidecarSuffix = ".prompt.txt"
+
+// Route sends files below a directory to a dedicated transcriber, e.g. to
+// keep sensitive recordings on a local model while meetings use a cloud one
+type Route struct {
+	// Directory relative to WatchDir (or absolute) whose files use this route
+	Dir string
+
+	// Transcriber for matching files (nil uses the default transcriber)
+	Transcriber transcriber.Transcriber
+
+	// Prompt overriding SharedPrompt for matching files (optional)
+	Prompt string
+}
+
+// LanguageRouting detects the language of every file from the start of
+// the recording and picks its prompt and output name, for inboxes with
+// recordings in several languages
+type LanguageRouting struct {
+	// Audio sent to detect the language (0 uses transcriber.DefaultDetectSample)
+	Sample time.Duration
+
+	// Prompt per language code such as "zh" or "zh-TW"; files in other
+	// languages keep the prompt of their route. A sidecar prompt wins.
+	Prompts map[string]string
+
+	// Add the language to output names: meeting_zh.txt
+	Suffix bool
+}
+
+// Prompt returns the prompt for a language, trying "zh-TW" before "zh"
+// and ignoring case, or "" if there is none
+func (l *LanguageRouting) Prompt(language string) string {
+	base, _, _ := strings.Cut(language, "-")
+	prompt := ""
+	for code, p := range l.Prompts {
+		if strings.EqualFold(code, language) {
+			return p
+		}
+		if strings.EqualFold(code, base) {
+			prompt = p
+		}
+	}
+	return prompt
+}
+
+// languageDetector is implemented by transcribers that can detect the
+// language of a file
+type languageDetector interface {
+	DetectLanguage(ctx context.Context, filePath string, sample time.Duration) (string, error)
 }
 
 // DefaultWatchConfig returns default configuration
@@ -171,6 +358,7 @@ func DefaultWatchConfig() *WatchConfig {
 		HistoryDB:         ".gollmscribe-watch.db",
 		ProcessExisting:   true,
 		RetryFailed:       false,
+		MaxAttempts:       3,
 		MaxWorkers:        3,
 		TranscribeOptions: transcriber.TranscribeOptions{
 			ChunkMinutes:   15,
@@ -0,0 +1,201 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// What to do when the history database is locked by another process
+const (
+	HistoryLockedFail     = "fail"     // Stop with a HistoryLockedError
+	HistoryLockedReadOnly = "readonly" // Read a snapshot and keep new records in memory
+	HistoryLockedInstance = "instance" // Use the first free per-instance database next to it
+)
+
+// historyLockTimeout is how long opening the history waits for another
+// process to close it
+const historyLockTimeout = 1 * time.Second
+
+// maxHistoryInstances bounds the per-instance databases tried
+const maxHistoryInstances = 16
+
+// HistoryHolder describes the process that has a history database open,
+// as recorded in its owner file next to the database
+type HistoryHolder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Since   time.Time `json:"since"`
+}
+
+// String describes the holder, e.g. "gollmscribe watch (pid 4242 on nas,
+// since 2025-03-01 09:14)"
+func (h *HistoryHolder) String() string {
+	return fmt.Sprintf("%s (pid %d on %s, since %s)", h.Command, h.PID, h.Host, h.Since.Format("2006-01-02 15:04"))
+}
+
+// HistoryLockedError is returned when the history database is locked by
+// another process, e.g. a second watch on the same directory
+type HistoryLockedError struct {
+	Path   string
+	Holder *HistoryHolder // nil if the holder did not record itself
+}
+
+func (e *HistoryLockedError) Error() string {
+	holder := "another process"
+	if e.Holder != nil {
+		holder = e.Holder.String()
+	}
+	return fmt.Sprintf("history database %s is in use by %s", e.Path, holder)
+}
+
+func (e *HistoryLockedError) Unwrap() error {
+	return bolt.ErrTimeout
+}
+
+// IsHistoryLocked reports whether err is caused by the history database
+// being locked by another process
+func IsHistoryLocked(err error) bool {
+	var locked *HistoryLockedError
+	return errors.As(err, &locked)
+}
+
+// ValidHistoryLocked reports whether mode is a known locked-history mode;
+// "" means HistoryLockedFail
+func ValidHistoryLocked(mode string) bool {
+	switch mode {
+	case "", HistoryLockedFail, HistoryLockedReadOnly, HistoryLockedInstance:
+		return true
+	}
+	return false
+}
+
+// ownerPath returns where the process holding dbPath records itself
+func ownerPath(dbPath string) string {
+	return dbPath + ".owner"
+}
+
+// openHistoryDB opens the bolt database at dbPath for writing and records
+// this process as its holder
+func openHistoryDB(dbPath string) (*bolt.DB, error) {
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{
+		Timeout: historyLockTimeout,
+	})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, &HistoryLockedError{Path: dbPath, Holder: readHistoryHolder(dbPath)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	writeHistoryHolder(dbPath)
+	return db, nil
+}
+
+// currentHolder describes this process
+func currentHolder() *HistoryHolder {
+	host, _ := os.Hostname()
+	command := "gollmscribe"
+	if len(os.Args) > 0 {
+		command = strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	}
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command += " " + os.Args[1]
+	}
+	return &HistoryHolder{PID: os.Getpid(), Host: host, Command: command, Since: time.Now()}
+}
+
+// writeHistoryHolder records this process as the holder of dbPath. It is
+// only informational, so failing to write it is ignored.
+func writeHistoryHolder(dbPath string) {
+	data, err := json.Marshal(currentHolder())
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(ownerPath(dbPath), data, 0o600)
+}
+
+// readHistoryHolder returns the recorded holder of dbPath, or nil
+func readHistoryHolder(dbPath string) *HistoryHolder {
+	data, err := os.ReadFile(ownerPath(dbPath))
+	if err != nil {
+		return nil
+	}
+	var holder HistoryHolder
+	if err := json.Unmarshal(data, &holder); err != nil || holder.PID == 0 {
+		return nil
+	}
+	return &holder
+}
+
+// removeHistoryHolder removes the owner file of dbPath if this process
+// wrote it
+func removeHistoryHolder(dbPath string) {
+	holder := readHistoryHolder(dbPath)
+	if holder == nil {
+		return
+	}
+	if current := currentHolder(); holder.PID == current.PID && holder.Host == current.Host {
+		_ = os.Remove(ownerPath(dbPath))
+	}
+}
+
+// InstanceHistoryPath returns the n-th per-instance database next to
+// dbPath: .gollmscribe-watch.2.db for n = 2
+func InstanceHistoryPath(dbPath string, n int) string {
+	ext := filepath.Ext(dbPath)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(dbPath, ext), n, ext)
+}
+
+// openWatchHistory opens the history database of a watch, handling a
+// database locked by another process as config.HistoryLocked says. With
+// HistoryLockedInstance, config.HistoryDB is set to the database used.
+func openWatchHistory(config *WatchConfig) (ProcessingHistory, error) {
+	history, err := NewEncryptedProcessingHistory(config.HistoryDB, config.HistoryCipher)
+	var locked *HistoryLockedError
+	if !errors.As(err, &locked) {
+		return history, err
+	}
+
+	log := logger.WithComponent("watcher")
+	switch config.HistoryLocked {
+	case HistoryLockedReadOnly:
+		log.Warn().Str("history_db", config.HistoryDB).Str("holder", holderName(locked.Holder)).
+			Msg("History database is in use; reading a snapshot and keeping this run's records in memory only")
+		return NewReadOnlyProcessingHistory(config.HistoryDB, config.HistoryCipher)
+
+	case HistoryLockedInstance:
+		for n := 2; n <= maxHistoryInstances; n++ {
+			path := InstanceHistoryPath(config.HistoryDB, n)
+			history, err := NewEncryptedProcessingHistory(path, config.HistoryCipher)
+			if IsHistoryLocked(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			log.Warn().Str("history_db", config.HistoryDB).Str("holder", holderName(locked.Holder)).
+				Str("instance_db", path).Msg("History database is in use; using a separate one for this instance")
+			config.HistoryDB = path
+			return history, nil
+		}
+		return nil, fmt.Errorf("%w, as are its per-instance databases", locked)
+	}
+	return nil, err
+}
+
+// holderName describes a holder for logs
+func holderName(holder *HistoryHolder) string {
+	if holder == nil {
+		return "unknown"
+	}
+	return holder.String()
+}
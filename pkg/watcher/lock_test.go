@@ -0,0 +1,77 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryLocked(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "history.db")
+	holder, err := NewProcessingHistory(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = holder.RecordProcessed("a", &ProcessedInfo{FileHash: "a", FilePath: "a.mp3"})
+	_ = holder.RecordFailed("b", &FailedInfo{FileHash: "b", FilePath: "b.mp3", FailedAt: time.Now(), Error: "timeout"})
+
+	// A second open names the holder
+	_, err = NewProcessingHistory(dbPath)
+	if !IsHistoryLocked(err) {
+		t.Fatalf("second open = %v, want a HistoryLockedError", err)
+	}
+	if !strings.Contains(err.Error(), "pid") {
+		t.Errorf("error %q does not name the holder", err)
+	}
+
+	// A per-instance database next to it
+	config := DefaultWatchConfig()
+	config.HistoryDB, config.HistoryLocked = dbPath, HistoryLockedInstance
+	instance, err := openWatchHistory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(dbPath), "history.2.db"); config.HistoryDB != want {
+		t.Errorf("instance database = %s, want %s", config.HistoryDB, want)
+	}
+	_ = instance.Close()
+
+	// A read-only snapshot that keeps new records in memory
+	config.HistoryDB, config.HistoryLocked = dbPath, HistoryLockedReadOnly
+	readOnly, err := openWatchHistory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed, _ := readOnly.IsProcessed("a"); !processed {
+		t.Error("snapshot is missing a processed file")
+	}
+	if err := readOnly.RecordFailed("b", &FailedInfo{FileHash: "b", FailedAt: time.Now(), Error: "again"}); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := readOnly.GetFailedInfo("b"); info == nil || info.RetryCount != 1 || len(info.History) != 2 {
+		t.Errorf("failed info = %+v, want the second attempt", info)
+	}
+	_ = readOnly.RecordProcessed("b", &ProcessedInfo{FileHash: "b", FilePath: "b.mp3"})
+	if failed, _ := readOnly.ListFailed(); len(failed) != 0 {
+		t.Errorf("ListFailed() = %d records after b was processed", len(failed))
+	}
+	if processed, _ := readOnly.ListProcessed(); len(processed) != 2 {
+		t.Errorf("ListProcessed() = %d records, want 2", len(processed))
+	}
+	if err := readOnly.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if processed, _ := holder.IsProcessed("b"); processed {
+		t.Error("read-only history wrote to the database")
+	}
+
+	// The owner file goes with the holder
+	if err := holder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ownerPath(dbPath)); !os.IsNotExist(err) {
+		t.Errorf("owner file left after close: %v", err)
+	}
+}
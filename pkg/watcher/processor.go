@@ -2,7 +2,6 @@ package watcher
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +11,8 @@ import (
 	"time"
 
 	"github.com/eternnoir/gollmscribe/pkg/logger"
+	"github.com/eternnoir/gollmscribe/pkg/rules"
+	"github.com/eternnoir/gollmscribe/pkg/store"
 	"github.com/eternnoir/gollmscribe/pkg/transcriber"
 )
 
@@ -115,32 +116,81 @@ func (fp *fileProcessor) ProcessFile(ctx context.Context, filePath string) error
 	// Determine output path
 	outputPath := fp.getOutputPath(filePath)
 
+	// Apply the rules matching the file's tags
+	decision := rules.Evaluate(fp.config.Rules, rules.Tags(filePath, fp.config.WatchDir, fp.config.Notes))
+	if len(decision.Matched) > 0 {
+		log.Info().Strs("rules", decision.Matched).Str("output_dir", decision.OutputDir).Msg("Rules matched")
+	}
+
 	// Create output directory if needed
 	outputDir := fp.config.OutputDir
 	if outputDir == "" {
 		outputDir = filepath.Dir(filePath)
 	}
+	if decision.OutputDir != "" {
+		outputDir = decision.OutputDir
+		outputPath = filepath.Join(outputDir, filepath.Base(outputPath))
+	}
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	// Create context with timeout
+	startTime := time.Now()
+	transcribeCtx, cancel := context.WithTimeout(ctx, fp.config.ProcessingTimeout)
+	defer cancel()
+
 	// Create transcription request
+	trans, prompt := fp.route(filePath)
+	if decision.Transcriber != nil {
+		trans = decision.Transcriber
+	}
+	if err := decision.CheckLocal(trans); err != nil {
+		if histErr := fp.history.RecordFailed(hash, &FailedInfo{FileHash: hash, FilePath: filePath, FailedAt: time.Now(), Error: err.Error()}); histErr != nil {
+			log.Warn().Err(histErr).Msg("Failed to record failure in history")
+		}
+		fp.reportProgress(&ProgressEvent{
+			Type:      "failed",
+			FilePath:  filePath,
+			Message:   "Refused by rules",
+			Error:     err,
+			Timestamp: time.Now(),
+		})
+		return err
+	}
+	sidecar, err := readSidecarPrompt(filePath)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read sidecar prompt, using the shared prompt")
+	}
+	language := fp.detectLanguage(transcribeCtx, trans, filePath)
+	if language != "" {
+		if languagePrompt := fp.config.Languages.Prompt(language); languagePrompt != "" {
+			prompt = languagePrompt
+		}
+		if fp.config.Languages.Suffix {
+			outputPath = languageOutputPath(outputPath, language)
+		}
+	}
+	if sidecar != "" {
+		log.Info().Str("sidecar", filepath.Base(filePath)+PromptSidecarSuffix).Msg("Using sidecar prompt")
+		prompt = sidecar
+	}
 	req := &transcriber.TranscribeRequest{
 		FilePath:     filePath,
 		OutputPath:   outputPath,
-		CustomPrompt: fp.config.SharedPrompt,
+		CustomPrompt: prompt,
 		Options:      fp.config.TranscribeOptions,
+		Notes:        fp.config.Notes,
+	}
+	// The language check holds the file to its detected language
+	if language != "" {
+		req.Options.ExpectedLanguage = language
 	}
 
 	// Start transcription
-	startTime := time.Now()
 	log.Info().Msg("Starting transcription")
 
-	// Create context with timeout
-	transcribeCtx, cancel := context.WithTimeout(ctx, fp.config.ProcessingTimeout)
-	defer cancel()
-
-	result, err := fp.transcriber.Transcribe(transcribeCtx, req)
+	result, err := trans.Transcribe(transcribeCtx, req)
 	if err != nil {
 		// Record failure
 		failedInfo := FailedInfo{
@@ -161,8 +211,28 @@ func (fp *fileProcessor) ProcessFile(ctx context.Context, filePath string) error
 			Timestamp: time.Now(),
 		})
 
+		// Stop retrying a file that keeps failing; shutting down is not
+		// the file's fault
+		if ctx.Err() == nil {
+			fp.quarantineIfExhausted(filePath, hash)
+		}
+
 		return fmt.Errorf("transcription failed: %w", err)
 	}
+	if saved := result.SavedPath(); saved != "" {
+		outputPath = saved
+	}
+
+	// Move file if configured
+	var movedPath string
+	if fp.config.MoveToDir != "" {
+		movedPath, err = moveFileTo(filePath, fp.config.MoveToDir)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to move processed file")
+		} else if err := moveSidecarPrompt(filePath, movedPath); err != nil {
+			log.Warn().Err(err).Msg("Failed to move sidecar prompt")
+		}
+	}
 
 	// Record success
 	processedInfo := ProcessedInfo{
@@ -172,23 +242,20 @@ func (fp *fileProcessor) ProcessFile(ctx context.Context, filePath string) error
 		OutputPath:  outputPath,
 		Duration:    time.Since(startTime),
 		FileSize:    fileInfo.Size(),
+		MovedPath:   movedPath,
+		Notes:       result.Notes(),
+		Quality:     result.Quality(),
 	}
 	if err := fp.history.RecordProcessed(hash, &processedInfo); err != nil {
 		log.Warn().Err(err).Msg("Failed to record success in history")
 	}
 
-	// Move file if configured
-	if fp.config.MoveToDir != "" {
-		if err := fp.moveFile(filePath); err != nil {
-			log.Warn().Err(err).Msg("Failed to move processed file")
-		}
-	}
-
 	fp.reportProgress(&ProgressEvent{
-		Type:      "completed",
-		FilePath:  filePath,
-		Message:   fmt.Sprintf("Transcription completed in %v", result.ProcessTime),
-		Timestamp: time.Now(),
+		Type:       "completed",
+		FilePath:   filePath,
+		OutputPath: outputPath,
+		Message:    completionMessage(result),
+		Timestamp:  time.Now(),
 	})
 
 	log.Info().
@@ -219,6 +286,11 @@ func (fp *fileProcessor) CanProcess(filePath string) bool {
 		return false
 	}
 
+	// Quarantined files wait for a person to look at them
+	if fp.inQuarantine(filePath) {
+		return false
+	}
+
 	// Check if file is stable
 	if !fp.isFileStable(filePath) {
 		return false
@@ -232,6 +304,109 @@ func (fp *fileProcessor) CanProcess(filePath string) bool {
 	return true
 }
 
+// completionMessage summarizes a finished transcription
+func completionMessage(result *transcriber.TranscribeResult) string {
+	message := fmt.Sprintf("Transcription completed in %v", result.ProcessTime)
+	if result.Cost > 0 {
+		message += fmt.Sprintf(" (est. $%.4f)", result.Cost)
+	}
+	if gaps := result.Gaps(); len(gaps) > 0 {
+		message += fmt.Sprintf(", partial: %d of %d chunks missing", len(gaps), result.ChunkCount)
+	}
+	return message
+}
+
+// route returns the transcriber and prompt for a file, using the route
+// with the most specific directory that contains it
+func (fp *fileProcessor) route(filePath string) (transcriber.Transcriber, string) {
+	trans, prompt := fp.transcriber, fp.config.SharedPrompt
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return trans, prompt
+	}
+
+	bestLen := -1
+	for _, route := range fp.config.Routes {
+		dir := route.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(fp.config.WatchDir, dir)
+		}
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(dir, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(dir) <= bestLen {
+			continue
+		}
+
+		bestLen = len(dir)
+		trans, prompt = fp.transcriber, fp.config.SharedPrompt
+		if route.Transcriber != nil {
+			trans = route.Transcriber
+		}
+		if route.Prompt != "" {
+			prompt = route.Prompt
+		}
+	}
+
+	return trans, prompt
+}
+
+// detectLanguage returns the language of a file when language routing is
+// on, or "" if it is off or the language could not be detected
+func (fp *fileProcessor) detectLanguage(ctx context.Context, trans transcriber.Transcriber, filePath string) string {
+	if fp.config.Languages == nil {
+		return ""
+	}
+	log := logger.WithComponent("processor").WithField("file", filePath)
+
+	detector, ok := trans.(languageDetector)
+	if !ok {
+		log.Warn().Msg("Transcriber cannot detect languages, using the route prompt")
+		return ""
+	}
+	language, err := detector.DetectLanguage(ctx, filePath, fp.config.Languages.Sample)
+	if err != nil {
+		log.Warn().Err(err).Msg("Language detection failed, using the route prompt")
+		return ""
+	}
+	return language
+}
+
+// languageOutputPath adds a language to an output name: meeting_zh.txt
+func languageOutputPath(outputPath, language string) string {
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + "_" + language + ext
+}
+
+// readSidecarPrompt returns the prompt in the file's sidecar prompt file,
+// or "" if it has none
+func readSidecarPrompt(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath + PromptSidecarSuffix)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// moveSidecarPrompt moves a file's sidecar prompt, if any, next to the
+// file's new path
+func moveSidecarPrompt(filePath, movedPath string) error {
+	sidecar := filePath + PromptSidecarSuffix
+	if _, err := os.Stat(sidecar); os.IsNotExist(err) {
+		return nil
+	}
+	return moveFile(sidecar, movedPath+PromptSidecarSuffix)
+}
+
 // isFileStable checks if a file has been stable for the configured duration
 func (fp *fileProcessor) isFileStable(filePath string) bool {
 	info1, err := os.Stat(filePath)
@@ -250,28 +425,9 @@ func (fp *fileProcessor) isFileStable(filePath string) bool {
 		info1.ModTime().Equal(info2.ModTime())
 }
 
-// getFileHash calculates SHA256 hash of the file (first 1MB for performance)
+// getFileHash calculates the content hash shared with the transcript store
 func (fp *fileProcessor) getFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer func() { _ = file.Close() }()
-
-	hash := sha256.New()
-	// Read first 1MB for hash calculation (performance optimization for large files)
-	if _, err := io.CopyN(hash, file, 1024*1024); err != nil && err != io.EOF {
-		return "", err
-	}
-
-	// Also include file size in hash for better uniqueness
-	info, err := file.Stat()
-	if err != nil {
-		return "", err
-	}
-	_, _ = fmt.Fprintf(hash, ":%d", info.Size())
-
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	return store.HashFile(filePath)
 }
 
 // getOutputPath determines the output path for the transcription
@@ -287,13 +443,13 @@ func (fp *fileProcessor) getOutputPath(inputPath string) string {
 	return filepath.Join(filepath.Dir(inputPath), outputName)
 }
 
-// moveFile moves the processed file to the configured directory
-func (fp *fileProcessor) moveFile(filePath string) error {
-	if err := os.MkdirAll(fp.config.MoveToDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create move-to directory: %w", err)
+// moveFileTo moves a file into dir and returns its new path
+func moveFileTo(filePath, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create move-to directory: %w", err)
 	}
 
-	destPath := filepath.Join(fp.config.MoveToDir, filepath.Base(filePath))
+	destPath := filepath.Join(dir, filepath.Base(filePath))
 
 	// Check if destination already exists
 	if _, err := os.Stat(destPath); err == nil {
@@ -301,9 +457,17 @@ func (fp *fileProcessor) moveFile(filePath string) error {
 		ext := filepath.Ext(filePath)
 		name := strings.TrimSuffix(filepath.Base(filePath), ext)
 		timestamp := time.Now().Format("20060102_150405")
-		destPath = filepath.Join(fp.config.MoveToDir, fmt.Sprintf("%s_%s%s", name, timestamp, ext))
+		destPath = filepath.Join(dir, fmt.Sprintf("%s_%s%s", name, timestamp, ext))
+	}
+
+	if err := moveFile(filePath, destPath); err != nil {
+		return "", err
 	}
+	return destPath, nil
+}
 
+// moveFile moves a file to destPath, copying it across filesystems
+func moveFile(filePath, destPath string) error {
 	// Try rename first (faster for same filesystem)
 	err := os.Rename(filePath, destPath)
 	if err == nil {
@@ -314,7 +478,7 @@ func (fp *fileProcessor) moveFile(filePath string) error {
 	if linkErr, ok := err.(*os.LinkError); ok {
 		if errno, ok := linkErr.Err.(syscall.Errno); ok && errno == syscall.EXDEV {
 			// Cross-device link error, fallback to copy-then-delete
-			return fp.copyThenDelete(filePath, destPath)
+			return copyThenDelete(filePath, destPath)
 		}
 	}
 
@@ -323,7 +487,7 @@ func (fp *fileProcessor) moveFile(filePath string) error {
 }
 
 // copyThenDelete copies a file then deletes the original (for cross-filesystem moves)
-func (fp *fileProcessor) copyThenDelete(srcPath, destPath string) error {
+func copyThenDelete(srcPath, destPath string) error {
 	// Open source file
 	src, err := os.Open(srcPath)
 	if err != nil {
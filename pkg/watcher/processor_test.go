@@ -0,0 +1,123 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// namedTranscriber identifies which transcriber a route selected
+type namedTranscriber struct {
+	transcriber.Transcriber
+	name string
+}
+
+func TestFileProcessorRoute(t *testing.T) {
+	root := t.TempDir()
+	defaultTr := &namedTranscriber{name: "default"}
+	meetings := &namedTranscriber{name: "meetings"}
+	sensitive := &namedTranscriber{name: "sensitive"}
+
+	cfg := DefaultWatchConfig()
+	cfg.WatchDir = root
+	cfg.SharedPrompt = "shared"
+	cfg.Routes = []Route{
+		{Dir: "meetings", Transcriber: meetings},
+		{Dir: "meetings/board", Prompt: "board prompt"},
+		{Dir: filepath.Join(root, "sensitive"), Transcriber: sensitive, Prompt: "private"},
+	}
+	fp := &fileProcessor{config: cfg, transcriber: defaultTr}
+
+	tests := []struct {
+		file       string
+		wantName   string
+		wantPrompt string
+	}{
+		{"inbox.mp3", "default", "shared"},
+		{"meetings/standup.mp3", "meetings", "shared"},
+		{"meetings/board/q3.mp3", "default", "board prompt"},
+		{"sensitive/hr/call.mp3", "sensitive", "private"},
+		{"meetings-archive/old.mp3", "default", "shared"},
+	}
+	for _, tt := range tests {
+		trans, prompt := fp.route(filepath.Join(root, tt.file))
+		if got := trans.(*namedTranscriber).name; got != tt.wantName {
+			t.Errorf("route(%s) transcriber = %s, want %s", tt.file, got, tt.wantName)
+		}
+		if prompt != tt.wantPrompt {
+			t.Errorf("route(%s) prompt = %q, want %q", tt.file, prompt, tt.wantPrompt)
+		}
+	}
+}
+
+func TestSidecarPrompt(t *testing.T) {
+	dir := t.TempDir()
+	media := filepath.Join(dir, "meeting1.mp3")
+	if err := os.WriteFile(media, []byte("audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if prompt, err := readSidecarPrompt(media); err != nil || prompt != "" {
+		t.Fatalf("readSidecarPrompt() without sidecar = %q, %v", prompt, err)
+	}
+
+	if err := os.WriteFile(media+PromptSidecarSuffix, []byte("  Sales call with Acme.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if prompt, err := readSidecarPrompt(media); err != nil || prompt != "Sales call with Acme." {
+		t.Fatalf("readSidecarPrompt() = %q, %v", prompt, err)
+	}
+
+	moved, err := moveFileTo(media, filepath.Join(dir, "done"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := moveSidecarPrompt(media, moved); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(moved + PromptSidecarSuffix); err != nil {
+		t.Errorf("sidecar not moved: %v", err)
+	}
+}
+
+// detectingTranscriber reports a fixed language
+type detectingTranscriber struct {
+	transcriber.Transcriber
+	language string
+}
+
+func (d *detectingTranscriber) DetectLanguage(ctx context.Context, filePath string, sample time.Duration) (string, error) {
+	return d.language, nil
+}
+
+func TestLanguageRouting(t *testing.T) {
+	cfg := DefaultWatchConfig()
+	fp := &fileProcessor{config: cfg}
+	zh := &detectingTranscriber{language: "zh-TW"}
+
+	if got := fp.detectLanguage(context.Background(), zh, "a.mp3"); got != "" {
+		t.Errorf("detectLanguage() without routing = %q", got)
+	}
+
+	cfg.Languages = &LanguageRouting{Prompts: map[string]string{"zh": "Chinese prompt", "pt-br": "Brazilian prompt"}}
+	if got := fp.detectLanguage(context.Background(), zh, "a.mp3"); got != "zh-TW" {
+		t.Errorf("detectLanguage() = %q, want zh-TW", got)
+	}
+	if got := fp.detectLanguage(context.Background(), &namedTranscriber{name: "plain"}, "a.mp3"); got != "" {
+		t.Errorf("detectLanguage() without a detector = %q", got)
+	}
+
+	for language, want := range map[string]string{"zh-TW": "Chinese prompt", "pt-BR": "Brazilian prompt", "pt": "", "en": ""} {
+		if got := cfg.Languages.Prompt(language); got != want {
+			t.Errorf("Prompt(%s) = %q, want %q", language, got, want)
+		}
+	}
+
+	if got := languageOutputPath(filepath.Join("out", "meeting.txt"), "zh"); got != filepath.Join("out", "meeting_zh.txt") {
+		t.Errorf("languageOutputPath() = %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// ErrorFileSuffix names the failure history written next to a quarantined
+// file: meeting.mp3.error.json
+const ErrorFileSuffix = ".error.json"
+
+// quarantineRecord is the content of a quarantined file's .error.json
+type quarantineRecord struct {
+	File          string          `json:"file"`
+	Hash          string          `json:"hash"`
+	QuarantinedAt time.Time       `json:"quarantined_at"`
+	Attempts      int             `json:"attempts"`
+	Failures      []FailedAttempt `json:"failures"`
+}
+
+// shouldQuarantine reports whether a file has failed too often to retry
+func (fp *fileProcessor) shouldQuarantine(info *FailedInfo) bool {
+	return fp.config.QuarantineDir != "" && fp.config.MaxAttempts > 0 &&
+		info != nil && info.Attempts() >= fp.config.MaxAttempts
+}
+
+// quarantineIfExhausted quarantines a file that has used up its attempts
+func (fp *fileProcessor) quarantineIfExhausted(filePath, hash string) {
+	log := logger.WithComponent("processor").WithField("file", filePath)
+
+	info, err := fp.history.GetFailedInfo(hash)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read failure history")
+		return
+	}
+	if !fp.shouldQuarantine(info) {
+		return
+	}
+
+	movedPath, err := fp.quarantine(filePath, info)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to quarantine file")
+		if movedPath == "" {
+			return
+		}
+	}
+	log.Warn().Int("attempts", info.Attempts()).Str("quarantine_path", movedPath).Msg("Quarantined file after repeated failures")
+	fp.reportProgress(&ProgressEvent{
+		Type:       "quarantined",
+		FilePath:   filePath,
+		OutputPath: movedPath,
+		Message:    fmt.Sprintf("Moved to %s after %d failed attempts", movedPath, info.Attempts()),
+		Timestamp:  time.Now(),
+	})
+}
+
+// quarantine moves a failing file, with its sidecar prompt, to the
+// quarantine directory and writes its failure history next to it. It
+// returns the file's new path.
+func (fp *fileProcessor) quarantine(filePath string, info *FailedInfo) (string, error) {
+	movedPath, err := moveFileTo(filePath, fp.config.QuarantineDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to quarantine file: %w", err)
+	}
+	if err := moveSidecarPrompt(filePath, movedPath); err != nil {
+		return movedPath, fmt.Errorf("failed to quarantine sidecar prompt: %w", err)
+	}
+
+	record := quarantineRecord{
+		File:          filePath,
+		Hash:          info.FileHash,
+		QuarantinedAt: time.Now(),
+		Attempts:      info.Attempts(),
+		Failures:      info.History,
+	}
+	if len(record.Failures) == 0 {
+		// Recorded before the history was kept
+		record.Failures = []FailedAttempt{{At: info.FailedAt, Error: info.Error}}
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return movedPath, fmt.Errorf("failed to encode failure history: %w", err)
+	}
+	if err := os.WriteFile(movedPath+ErrorFileSuffix, append(data, '\n'), 0o644); err != nil {
+		return movedPath, fmt.Errorf("failed to write failure history: %w", err)
+	}
+	return movedPath, nil
+}
+
+// inQuarantine reports whether a path is inside the quarantine directory,
+// which may be below the watched directory
+func (fp *fileProcessor) inQuarantine(filePath string) bool {
+	if fp.config.QuarantineDir == "" {
+		return false
+	}
+	dir, err := filepath.Abs(fp.config.QuarantineDir)
+	if err != nil {
+		return false
+	}
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
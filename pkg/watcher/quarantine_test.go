@@ -0,0 +1,74 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	root := t.TempDir()
+	history, err := NewProcessingHistory(filepath.Join(root, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	cfg := DefaultWatchConfig()
+	cfg.QuarantineDir = filepath.Join(root, "quarantine")
+	cfg.MaxAttempts = 2
+	fp := &fileProcessor{config: cfg, history: history}
+	var events []string
+	fp.progress = func(event *ProgressEvent) { events = append(events, event.Type) }
+
+	filePath := filepath.Join(root, "broken.mp3")
+	for _, path := range []string{filePath, filePath + PromptSidecarSuffix} {
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fail := func(message string) {
+		info := &FailedInfo{FileHash: "hash", FilePath: filePath, FailedAt: time.Now(), Error: message}
+		if err := history.RecordFailed("hash", info); err != nil {
+			t.Fatal(err)
+		}
+		fp.quarantineIfExhausted(filePath, "hash")
+	}
+
+	fail("unsupported codec")
+	if _, err := os.Stat(filePath); err != nil || len(events) != 0 {
+		t.Fatalf("file quarantined after one attempt: %v, events %v", err, events)
+	}
+
+	fail("unsupported codec again")
+	movedPath := filepath.Join(cfg.QuarantineDir, "broken.mp3")
+	if _, err := os.Stat(filePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file still in the inbox after %d attempts", cfg.MaxAttempts)
+	}
+	if _, err := os.Stat(movedPath + PromptSidecarSuffix); err != nil {
+		t.Errorf("sidecar prompt not quarantined: %v", err)
+	}
+	if len(events) != 1 || events[0] != "quarantined" {
+		t.Errorf("events = %v, want one quarantined event", events)
+	}
+
+	data, err := os.ReadFile(movedPath + ErrorFileSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record quarantineRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.File != filePath || record.Attempts != 2 || len(record.Failures) != 2 || record.Failures[1].Error != "unsupported codec again" {
+		t.Errorf("error record = %+v", record)
+	}
+
+	if !fp.inQuarantine(movedPath) || fp.inQuarantine(filePath) {
+		t.Error("inQuarantine() does not match the quarantine directory")
+	}
+}
@@ -0,0 +1,176 @@
+package watcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRecent is how many finished files the watcher remembers
+const maxRecent = 100
+
+// queueState tracks the files waiting for a worker and the latest ones
+// to finish, which the worker queue channel cannot show
+type queueState struct {
+	mu     sync.Mutex
+	queued map[string]*queuedEntry
+	recent []RecentFile // Oldest first
+}
+
+// queuedEntry is a queued file; scans may queue a file again before a
+// worker takes it
+type queuedEntry struct {
+	at    time.Time // When first queued
+	count int
+}
+
+// add records that a file is being queued
+func (q *queueState) add(filePath string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queued == nil {
+		q.queued = make(map[string]*queuedEntry)
+	}
+	entry, ok := q.queued[filePath]
+	if !ok {
+		entry = &queuedEntry{at: time.Now()}
+		q.queued[filePath] = entry
+	}
+	entry.count++
+}
+
+// remove records that a worker took the file, or that queueing it failed
+func (q *queueState) remove(filePath string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry, ok := q.queued[filePath]; ok {
+		if entry.count--; entry.count <= 0 {
+			delete(q.queued, filePath)
+		}
+	}
+}
+
+// finished remembers a completed or failed file, forgetting the oldest
+// beyond maxRecent
+func (q *queueState) finished(file RecentFile) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.recent = append(q.recent, file)
+	if len(q.recent) > maxRecent {
+		q.recent = q.recent[len(q.recent)-maxRecent:]
+	}
+}
+
+// Queued returns the files waiting for a worker, longest waiting first
+func (fw *fileWatcher) Queued() []QueuedFile {
+	fw.queue.mu.Lock()
+	files := make([]QueuedFile, 0, len(fw.queue.queued))
+	for filePath, entry := range fw.queue.queued {
+		files = append(files, QueuedFile{FilePath: filePath, QueuedAt: entry.at})
+	}
+	fw.queue.mu.Unlock()
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].QueuedAt.Equal(files[j].QueuedAt) {
+			return files[i].QueuedAt.Before(files[j].QueuedAt)
+		}
+		return files[i].FilePath < files[j].FilePath
+	})
+	return files
+}
+
+// InProgress returns the files being processed, longest running first
+func (fw *fileWatcher) InProgress() []ActiveFile {
+	now := time.Now()
+	locked := fw.tracker.GetLockedSince()
+	files := make([]ActiveFile, 0, len(locked))
+	for filePath, startedAt := range locked {
+		files = append(files, ActiveFile{FilePath: filePath, StartedAt: startedAt, Elapsed: now.Sub(startedAt)})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].StartedAt.Equal(files[j].StartedAt) {
+			return files[i].StartedAt.Before(files[j].StartedAt)
+		}
+		return files[i].FilePath < files[j].FilePath
+	})
+	return files
+}
+
+// Recent returns the latest completed and failed files, newest first.
+// Files finished since the watcher started come with their progress
+// message; the history adds older ones, and ones finished by earlier
+// runs, unless the same outcome of the file is already listed.
+func (fw *fileWatcher) Recent(limit int) ([]RecentFile, error) {
+	type outcome struct{ filePath, status string }
+	seen := make(map[outcome]bool)
+
+	fw.queue.mu.Lock()
+	files := make([]RecentFile, 0, len(fw.queue.recent))
+	for i := len(fw.queue.recent) - 1; i >= 0; i-- {
+		file := fw.queue.recent[i]
+		if !seen[outcome{file.FilePath, file.Status}] {
+			seen[outcome{file.FilePath, file.Status}] = true
+			files = append(files, file)
+		}
+	}
+	fw.queue.mu.Unlock()
+
+	processed, err := fw.history.ListProcessed()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range processed {
+		if !seen[outcome{info.FilePath, "completed"}] {
+			files = append(files, RecentFile{
+				FilePath:   info.FilePath,
+				Status:     "completed",
+				At:         info.ProcessedAt,
+				OutputPath: info.OutputPath,
+				Duration:   info.Duration,
+			})
+		}
+	}
+	failed, err := fw.history.ListFailed()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range failed {
+		if !seen[outcome{info.FilePath, "failed"}] {
+			files = append(files, RecentFile{
+				FilePath: info.FilePath,
+				Status:   "failed",
+				At:       info.FailedAt,
+				Error:    info.Error,
+			})
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].At.After(files[j].At) })
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+// recordFinished remembers a completed or failed progress event, with
+// how long the file was locked for processing
+func (fw *fileWatcher) recordFinished(event *ProgressEvent) {
+	file := RecentFile{
+		FilePath:   event.FilePath,
+		Status:     event.Type,
+		At:         event.Timestamp,
+		OutputPath: event.OutputPath,
+		Message:    event.Message,
+	}
+	if event.Error != nil {
+		file.Error = event.Error.Error()
+	}
+	if startedAt, ok := fw.tracker.GetLockedSince()[event.FilePath]; ok {
+		file.Duration = event.Timestamp.Sub(startedAt)
+	}
+	fw.queue.finished(file)
+}
@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/events"
+)
+
+func TestWatcherQueueInspection(t *testing.T) {
+	history, err := NewProcessingHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = history.Close() }()
+	fw := &fileWatcher{tracker: NewProcessingTracker(), history: history, stats: &WatchStats{}, events: events.NewBus()}
+
+	// A file queued twice by scans stays queued until both copies are taken
+	fw.queue.add("a.mp3")
+	fw.queue.add("b.mp3")
+	fw.queue.add("a.mp3")
+	fw.queue.remove("a.mp3")
+	if queued := fw.Queued(); len(queued) != 2 || queued[0].FilePath != "a.mp3" {
+		t.Fatalf("Queued() = %+v, want a.mp3 then b.mp3", queued)
+	}
+	fw.queue.remove("a.mp3")
+	if queued := fw.Queued(); len(queued) != 1 || queued[0].FilePath != "b.mp3" {
+		t.Fatalf("Queued() = %+v, want b.mp3", queued)
+	}
+
+	fw.tracker.TryLock("b.mp3")
+	if active := fw.InProgress(); len(active) != 1 || active[0].FilePath != "b.mp3" || active[0].Elapsed < 0 {
+		t.Fatalf("InProgress() = %+v, want b.mp3", active)
+	}
+
+	// Earlier runs are only known from the history
+	past := time.Now().Add(-time.Hour)
+	if err := history.RecordProcessed("h-old", &ProcessedInfo{FilePath: "old.mp3", ProcessedAt: past, OutputPath: "old.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.RecordFailed("h-bad", &FailedInfo{FilePath: "bad.mp3", FailedAt: past.Add(time.Minute), Error: "timeout"}); err != nil {
+		t.Fatal(err)
+	}
+	// This run's outcome is also in the history, but listed once
+	if err := history.RecordProcessed("h-b", &ProcessedInfo{FilePath: "b.mp3", ProcessedAt: time.Now(), OutputPath: "b.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	fw.handleProgressEvent(&ProgressEvent{Type: "completed", FilePath: "b.mp3", OutputPath: "b.txt", Message: "done", Timestamp: time.Now()})
+	fw.handleProgressEvent(&ProgressEvent{Type: "skipped", FilePath: "c.mp3", Timestamp: time.Now()})
+
+	recent, err := fw.Recent(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"b.mp3 completed", "bad.mp3 failed", "old.mp3 completed"}
+	if len(recent) != len(want) {
+		t.Fatalf("Recent() = %+v, want %v", recent, want)
+	}
+	for i, file := range recent {
+		if got := file.FilePath + " " + file.Status; got != want[i] {
+			t.Errorf("Recent()[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+	if recent[0].Message != "done" || recent[0].Duration <= 0 {
+		t.Errorf("this run's file = %+v, want its message and processing time", recent[0])
+	}
+	if recent[1].Error != "timeout" {
+		t.Errorf("failed file error = %q, want timeout", recent[1].Error)
+	}
+	if limited, _ := fw.Recent(1); len(limited) != 1 {
+		t.Errorf("Recent(1) returned %d files", len(limited))
+	}
+
+	fw.handleProgressEvent(&ProgressEvent{Type: "failed", FilePath: "d.mp3", Error: errors.New("quota"), Timestamp: time.Now()})
+	if recent, _ := fw.Recent(1); recent[0].FilePath != "d.mp3" || recent[0].Error != "quota" {
+		t.Errorf("Recent(1) = %+v, want the failed d.mp3", recent)
+	}
+}
@@ -0,0 +1,219 @@
+package watcher
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/eternnoir/gollmscribe/pkg/encryption"
+)
+
+// readOnlyHistory reads a snapshot of a history database, which another
+// process may have open, and keeps the records made meanwhile in memory
+// only. They are lost when it is closed.
+type readOnlyHistory struct {
+	base     *processingHistory // nil if the database does not exist yet
+	snapshot string
+
+	mu        sync.Mutex
+	processed map[string]*ProcessedInfo
+	failed    map[string]*FailedInfo
+}
+
+// NewReadOnlyProcessingHistory opens a read-only view of the history
+// database at dbPath that works while another process has it open, e.g.
+// to look files up while watch mode runs. It reads a copy of the database
+// as it is now; a missing database reads as empty. Records are accepted
+// but only kept in memory until Close.
+func NewReadOnlyProcessingHistory(dbPath string, c *encryption.Cipher) (ProcessingHistory, error) {
+	h := &readOnlyHistory{
+		processed: make(map[string]*ProcessedInfo),
+		failed:    make(map[string]*FailedInfo),
+	}
+
+	snapshot, err := copyToTemp(dbPath)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy history database: %w", err)
+	}
+	db, err := bolt.Open(snapshot, 0o600, &bolt.Options{
+		Timeout:  historyLockTimeout,
+		ReadOnly: true,
+	})
+	if err != nil {
+		_ = os.Remove(snapshot)
+		return nil, fmt.Errorf("failed to read history database snapshot: %w", err)
+	}
+	h.base = &processingHistory{db: db, path: snapshot, cipher: c}
+	h.snapshot = snapshot
+	return h, nil
+}
+
+// copyToTemp copies a file to a new temporary file and returns its path
+func copyToTemp(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.CreateTemp("", "gollmscribe-history-*.db")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// IsProcessed checks if a file hash has been processed
+func (h *readOnlyHistory) IsProcessed(fileHash string) (bool, error) {
+	h.mu.Lock()
+	_, ok := h.processed[fileHash]
+	h.mu.Unlock()
+	if ok || h.base == nil {
+		return ok, nil
+	}
+	return h.base.IsProcessed(fileHash)
+}
+
+// RecordProcessed records a successfully processed file in memory
+func (h *readOnlyHistory) RecordProcessed(fileHash string, info *ProcessedInfo) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	copied := *info
+	h.processed[fileHash] = &copied
+	delete(h.failed, fileHash)
+	return nil
+}
+
+// RecordFailed records a failed processing attempt in memory
+func (h *readOnlyHistory) RecordFailed(fileHash string, info *FailedInfo) error {
+	existing, err := h.GetFailedInfo(fileHash)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var history []FailedAttempt
+	if existing != nil {
+		info.RetryCount = existing.RetryCount + 1
+		history = existing.History
+	}
+	info.History = append(history, FailedAttempt{At: info.FailedAt, Error: info.Error})
+	copied := *info
+	h.failed[fileHash] = &copied
+	return nil
+}
+
+// GetProcessedInfo retrieves information about a processed file
+func (h *readOnlyHistory) GetProcessedInfo(fileHash string) (*ProcessedInfo, error) {
+	h.mu.Lock()
+	info, ok := h.processed[fileHash]
+	h.mu.Unlock()
+	if ok {
+		copied := *info
+		return &copied, nil
+	}
+	if h.base == nil {
+		return nil, nil
+	}
+	return h.base.GetProcessedInfo(fileHash)
+}
+
+// GetFailedInfo retrieves information about a failed file
+func (h *readOnlyHistory) GetFailedInfo(fileHash string) (*FailedInfo, error) {
+	h.mu.Lock()
+	info, ok := h.failed[fileHash]
+	_, processed := h.processed[fileHash]
+	h.mu.Unlock()
+	if ok {
+		copied := *info
+		return &copied, nil
+	}
+	if processed || h.base == nil {
+		return nil, nil
+	}
+	return h.base.GetFailedInfo(fileHash)
+}
+
+// ListProcessed returns all processed file records
+func (h *readOnlyHistory) ListProcessed() ([]*ProcessedInfo, error) {
+	var list []*ProcessedInfo
+	if h.base != nil {
+		var err error
+		if list, err = h.base.ListProcessed(); err != nil {
+			return nil, err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	merged := make([]*ProcessedInfo, 0, len(list)+len(h.processed))
+	for _, info := range list {
+		if _, ok := h.processed[info.FileHash]; !ok {
+			merged = append(merged, info)
+		}
+	}
+	for _, info := range h.processed {
+		copied := *info
+		merged = append(merged, &copied)
+	}
+	return merged, nil
+}
+
+// ListFailed returns all failed file records
+func (h *readOnlyHistory) ListFailed() ([]*FailedInfo, error) {
+	var list []*FailedInfo
+	if h.base != nil {
+		var err error
+		if list, err = h.base.ListFailed(); err != nil {
+			return nil, err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	merged := make([]*FailedInfo, 0, len(list)+len(h.failed))
+	for _, info := range list {
+		_, failed := h.failed[info.FileHash]
+		_, processed := h.processed[info.FileHash]
+		if !failed && !processed {
+			merged = append(merged, info)
+		}
+	}
+	for _, info := range h.failed {
+		copied := *info
+		merged = append(merged, &copied)
+	}
+	return merged, nil
+}
+
+// Close discards the records kept in memory and the snapshot
+func (h *readOnlyHistory) Close() error {
+	if h.base == nil {
+		return nil
+	}
+	err := h.base.Close()
+	if removeErr := os.Remove(h.snapshot); err == nil {
+		err = removeErr
+	}
+	return err
+}
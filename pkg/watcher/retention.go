@@ -0,0 +1,235 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// Retention actions
+const (
+	RetentionDelete  = "delete"
+	RetentionArchive = "archive"
+)
+
+// Kinds of files handled by the retention policy
+const (
+	RetentionMedia      = "media"
+	RetentionTranscript = "transcript"
+)
+
+// RetentionPolicy removes processed source media and transcripts once they
+// are older than their retention period. A zero period keeps files forever.
+type RetentionPolicy struct {
+	// How long to keep processed source media
+	MediaAfter time.Duration
+
+	// How long to keep transcripts
+	TranscriptsAfter time.Duration
+
+	// RetentionDelete (default) or RetentionArchive
+	Action string
+
+	// Directory expired files are moved to with RetentionArchive
+	ArchiveDir string
+
+	// Only report what would be removed
+	DryRun bool
+
+	// JSON lines file recording every action (optional)
+	AuditLog string
+
+	// How often watch mode applies the policy (default: hourly)
+	Interval time.Duration
+}
+
+// Enabled reports whether any retention period is set
+func (p RetentionPolicy) Enabled() bool {
+	return p.MediaAfter > 0 || p.TranscriptsAfter > 0
+}
+
+// Validate checks that the policy can be applied
+func (p RetentionPolicy) Validate() error {
+	switch p.Action {
+	case "", RetentionDelete:
+	case RetentionArchive:
+		if p.ArchiveDir == "" {
+			return fmt.Errorf("retention action archive requires an archive directory")
+		}
+	default:
+		return fmt.Errorf("unknown retention action: %s", p.Action)
+	}
+	return nil
+}
+
+// RetentionEntry describes one file the policy removed, or would remove in
+// a dry run. Entries are also the audit log records.
+type RetentionEntry struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	Action      string    `json:"action"`
+	Path        string    `json:"path"`
+	ArchivedTo  string    `json:"archived_to,omitempty"`
+	FileHash    string    `json:"hash"`
+	ProcessedAt time.Time `json:"processed_at"`
+	DryRun      bool      `json:"dry_run,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// auditLogMu serializes appends to audit logs within the process
+var auditLogMu sync.Mutex
+
+// ApplyRetention removes the media and transcripts in history that have
+// expired at now, records the removals in history and appends them to the
+// audit log. In a dry run nothing is changed or logged to the audit file,
+// and the returned entries report what would be removed.
+func ApplyRetention(history ProcessingHistory, policy RetentionPolicy, now time.Time) ([]RetentionEntry, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	infos, err := history.ListProcessed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processed files: %w", err)
+	}
+
+	log := logger.WithComponent("retention")
+	var entries []RetentionEntry
+	for _, info := range infos {
+		age := now.Sub(info.ProcessedAt)
+		dirty := false
+
+		if policy.MediaAfter > 0 && info.MediaRemovedAt == nil && age >= policy.MediaAfter {
+			entry, removed := expireFile(policy, info, RetentionMedia, info.MediaPath(), now)
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+			if removed {
+				info.MediaRemovedAt = &now
+				dirty = true
+			}
+		}
+
+		if policy.TranscriptsAfter > 0 && info.TranscriptRemovedAt == nil && age >= policy.TranscriptsAfter {
+			entry, removed := expireFile(policy, info, RetentionTranscript, info.OutputPath, now)
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+			if removed {
+				info.TranscriptRemovedAt = &now
+				dirty = true
+			}
+		}
+
+		if dirty {
+			if err := history.RecordProcessed(info.FileHash, info); err != nil {
+				log.Warn().Err(err).Str("hash", info.FileHash).Msg("Failed to record retention in history")
+			}
+		}
+	}
+
+	if policy.DryRun {
+		return entries, nil
+	}
+	if err := writeAuditLog(policy.AuditLog, entries); err != nil {
+		log.Warn().Err(err).Str("audit_log", policy.AuditLog).Msg("Failed to write retention audit log")
+	}
+	return entries, nil
+}
+
+// expireFile deletes or archives one expired file. It returns the entry to
+// report (nil if there was nothing to do) and whether the file is gone.
+func expireFile(policy RetentionPolicy, info *ProcessedInfo, kind, path string, now time.Time) (*RetentionEntry, bool) {
+	log := logger.WithComponent("retention").WithFields(map[string]interface{}{
+		"kind": kind,
+		"path": path,
+	})
+
+	if path == "" {
+		return nil, !policy.DryRun
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		log.Debug().Msg("Expired file no longer exists")
+		return nil, !policy.DryRun
+	}
+
+	entry := &RetentionEntry{
+		Time:        now,
+		Kind:        kind,
+		Action:      policy.Action,
+		Path:        path,
+		FileHash:    info.FileHash,
+		ProcessedAt: info.ProcessedAt,
+		DryRun:      policy.DryRun,
+	}
+	if entry.Action == "" {
+		entry.Action = RetentionDelete
+	}
+	if policy.DryRun {
+		log.Info().Str("action", entry.Action).Msg("Would remove expired file (dry run)")
+		return entry, false
+	}
+
+	var err error
+	if entry.Action == RetentionArchive {
+		entry.ArchivedTo, err = moveFileTo(path, policy.ArchiveDir)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		log.Warn().Err(err).Str("action", entry.Action).Msg("Failed to remove expired file")
+		return entry, false
+	}
+
+	// The media's sidecar prompt follows it
+	if kind == RetentionMedia {
+		if entry.Action == RetentionArchive {
+			err = moveSidecarPrompt(path, entry.ArchivedTo)
+		} else if err = os.Remove(path + PromptSidecarSuffix); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to remove sidecar prompt")
+		}
+	}
+
+	log.Info().
+		Str("action", entry.Action).
+		Str("archived_to", entry.ArchivedTo).
+		Time("processed_at", info.ProcessedAt).
+		Msg("Removed expired file")
+	return entry, true
+}
+
+// writeAuditLog appends entries to the audit log as JSON lines
+func writeAuditLog(path string, entries []RetentionEntry) error {
+	if path == "" || len(entries) == 0 {
+		return nil
+	}
+
+	auditLogMu.Lock()
+	defer auditLogMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
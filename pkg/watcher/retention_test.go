@@ -0,0 +1,110 @@
+package watcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	dir := t.TempDir()
+	history, err := NewProcessingHistory(filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = history.Close() }()
+
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	oldMedia := filepath.Join(dir, "old.mp3")
+	oldText := filepath.Join(dir, "old.txt")
+	newMedia := filepath.Join(dir, "new.mp3")
+	for _, path := range []string{oldMedia, oldText, newMedia} {
+		writeFile(t, path)
+	}
+	_ = history.RecordProcessed("old", &ProcessedInfo{FileHash: "old", FilePath: oldMedia, OutputPath: oldText, ProcessedAt: now.Add(-10 * 24 * time.Hour)})
+	_ = history.RecordProcessed("new", &ProcessedInfo{FileHash: "new", FilePath: newMedia, ProcessedAt: now.Add(-time.Hour)})
+
+	policy := RetentionPolicy{
+		MediaAfter:       7 * 24 * time.Hour,
+		TranscriptsAfter: 30 * 24 * time.Hour,
+		DryRun:           true,
+		AuditLog:         filepath.Join(dir, "audit.log"),
+	}
+
+	// A dry run reports the expired media without touching anything
+	entries, err := ApplyRetention(history, policy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Path != oldMedia || !entries[0].DryRun {
+		t.Fatalf("dry run entries = %+v, want old media only", entries)
+	}
+	if _, err := os.Stat(oldMedia); err != nil {
+		t.Errorf("dry run removed %s", oldMedia)
+	}
+	if _, err := os.Stat(policy.AuditLog); !os.IsNotExist(err) {
+		t.Error("dry run wrote the audit log")
+	}
+
+	// Archiving moves the media and records it once
+	policy.DryRun = false
+	policy.Action = RetentionArchive
+	policy.ArchiveDir = filepath.Join(dir, "archive")
+	entries, err = ApplyRetention(history, policy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ArchivedTo != filepath.Join(policy.ArchiveDir, "old.mp3") {
+		t.Fatalf("entries = %+v, want old media archived", entries)
+	}
+	if _, err := os.Stat(oldMedia); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after archiving", oldMedia)
+	}
+	if _, err := os.Stat(oldText); err != nil {
+		t.Errorf("transcript removed before its retention period: %v", err)
+	}
+
+	info, _ := history.GetProcessedInfo("old")
+	if info.MediaRemovedAt == nil || info.TranscriptRemovedAt != nil {
+		t.Errorf("history = %+v, want media removal recorded", info)
+	}
+
+	if entries, _ := ApplyRetention(history, policy, now); len(entries) != 0 {
+		t.Errorf("second run entries = %+v, want none", entries)
+	}
+
+	f, err := os.Open(policy.AuditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	var lines int
+	for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+		var entry RetentionEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Kind != RetentionMedia {
+			t.Errorf("audit entry = %s, %v", scanner.Text(), err)
+		}
+	}
+	if lines != 1 {
+		t.Errorf("audit log has %d entries, want 1", lines)
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	if err := (RetentionPolicy{Action: RetentionArchive}).Validate(); err == nil {
+		t.Error("archive without a directory validated")
+	}
+	if err := (RetentionPolicy{Action: "shred"}).Validate(); err == nil {
+		t.Error("unknown action validated")
+	}
+}
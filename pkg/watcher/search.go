@@ -0,0 +1,33 @@
+package watcher
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eternnoir/gollmscribe/pkg/transcriber"
+)
+
+// SearchProcessed returns the processed files whose notes or path contain
+// query, ignoring case, newest first. An empty query matches every file
+// with notes.
+func SearchProcessed(history ProcessingHistory, query string) ([]*ProcessedInfo, error) {
+	processed, err := history.ListProcessed()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*ProcessedInfo
+	for _, info := range processed {
+		switch {
+		case query == "":
+			if len(info.Notes) > 0 {
+				matches = append(matches, info)
+			}
+		case transcriber.MatchNotes(info.Notes, query),
+			strings.Contains(strings.ToLower(info.FilePath), strings.ToLower(query)):
+			matches = append(matches, info)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].ProcessedAt.After(matches[j].ProcessedAt) })
+	return matches, nil
+}
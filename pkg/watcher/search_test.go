@@ -0,0 +1,34 @@
+package watcher
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearchProcessed(t *testing.T) {
+	history, err := NewProcessingHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = history.Close() }()
+
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	_ = history.RecordProcessed("a", &ProcessedInfo{FileHash: "a", FilePath: "inbox/q1.mp3", ProcessedAt: day, Notes: []string{"Board meeting"}})
+	_ = history.RecordProcessed("b", &ProcessedInfo{FileHash: "b", FilePath: "inbox/q2.mp3", ProcessedAt: day.Add(time.Hour), Notes: []string{"board meeting", "confidential"}})
+	_ = history.RecordProcessed("c", &ProcessedInfo{FileHash: "c", FilePath: "inbox/standup.mp3", ProcessedAt: day})
+
+	matches, err := SearchProcessed(history, "board")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].FileHash != "b" {
+		t.Errorf("SearchProcessed(board) = %d files, want q2 then q1", len(matches))
+	}
+	if matches, _ := SearchProcessed(history, "standup"); len(matches) != 1 {
+		t.Errorf("SearchProcessed(standup) = %d files, want the path match", len(matches))
+	}
+	if matches, _ := SearchProcessed(history, ""); len(matches) != 2 {
+		t.Errorf("SearchProcessed(\"\") = %d files, want the 2 with notes", len(matches))
+	}
+}
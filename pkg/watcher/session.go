@@ -0,0 +1,195 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eternnoir/gollmscribe/pkg/logger"
+)
+
+// States of a file in a session
+const (
+	sessionPending  = "pending"
+	sessionInFlight = "in_flight"
+	sessionDone     = "done"
+)
+
+// SessionPath returns where the session of a watch using the history
+// database at dbPath is recorded
+func SessionPath(dbPath string) string {
+	return dbPath + ".session.json"
+}
+
+// watchSession records the backlog of a --once run, so a run killed
+// halfway is continued by the next one: files it finished are skipped,
+// files it was processing go first and the rest keep their order
+type watchSession struct {
+	path string
+	mu   sync.Mutex
+
+	WatchDir string            `json:"watch_dir"`
+	Started  time.Time         `json:"started"`
+	Order    []string          `json:"order"` // Backlog in queue order
+	Files    map[string]string `json:"files"` // State of each file
+}
+
+// openSession reads the session at path, or starts a new one if there is
+// none, it belongs to another directory or reset is set
+func openSession(path, watchDir string, reset bool) (*watchSession, error) {
+	log := logger.WithComponent("watcher")
+	fresh := &watchSession{path: path, WatchDir: watchDir, Started: time.Now(), Files: make(map[string]string)}
+	if reset {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to reset watch session: %w", err)
+		}
+		return fresh, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch session: %w", err)
+	}
+	session := &watchSession{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to parse watch session %s (use --reset-session to start over): %w", path, err)
+	}
+	if session.WatchDir != watchDir {
+		log.Warn().Str("session_dir", session.WatchDir).Msg("Watch session belongs to another directory, starting a new one")
+		return fresh, nil
+	}
+	if session.Files == nil {
+		session.Files = make(map[string]string)
+	}
+	session.path = path
+
+	counts := make(map[string]int)
+	for _, state := range session.Files {
+		counts[state]++
+	}
+	log.Info().
+		Time("started", session.Started).
+		Int("done", counts[sessionDone]).
+		Int("in_flight", counts[sessionInFlight]).
+		Int("pending", counts[sessionPending]).
+		Msg("Continuing interrupted watch session")
+	return session, nil
+}
+
+// plan orders the files found by a scan and records them as the backlog:
+// files in flight when the last run stopped first, then its pending files
+// in their order, then new files by path. Files the session finished are
+// left out.
+func (s *watchSession) plan(found []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	present := make(map[string]bool, len(found))
+	for _, path := range found {
+		present[path] = true
+	}
+
+	var inFlight, pending []string
+	for _, path := range s.Order {
+		if !present[path] {
+			continue
+		}
+		switch s.Files[path] {
+		case sessionInFlight:
+			inFlight = append(inFlight, path)
+		case sessionPending:
+			pending = append(pending, path)
+		}
+	}
+	var fresh []string
+	for _, path := range found {
+		if _, known := s.Files[path]; !known {
+			fresh = append(fresh, path)
+		}
+	}
+	sort.Strings(fresh)
+
+	order := append(append(inFlight, pending...), fresh...)
+	s.Order = order
+	files := make(map[string]string, len(order))
+	for _, path := range order {
+		files[path] = sessionPending
+	}
+	for path, state := range s.Files {
+		if state == sessionDone && present[path] {
+			files[path] = sessionDone
+		}
+	}
+	// Files in flight stay so until they finish, should this run stop too
+	for _, path := range inFlight {
+		files[path] = sessionInFlight
+	}
+	s.Files = files
+	return order, s.save()
+}
+
+// start records that a file is being processed
+func (s *watchSession) start(path string) {
+	s.set(path, sessionInFlight)
+}
+
+// finish records that a file was processed, whatever the outcome
+func (s *watchSession) finish(path string) {
+	s.set(path, sessionDone)
+}
+
+// set records the state of a file of the backlog
+func (s *watchSession) set(path, state string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Files[path]; !ok {
+		return
+	}
+	s.Files[path] = state
+	if err := s.save(); err != nil {
+		logger.WithComponent("watcher").Warn().Err(err).Msg("Failed to save watch session")
+	}
+}
+
+// complete removes the session once its backlog is processed
+func (s *watchSession) complete() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		logger.WithComponent("watcher").Warn().Err(err).Msg("Failed to remove watch session")
+	}
+}
+
+// save writes the session atomically
+func (s *watchSession) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watch session: %w", err)
+	}
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write watch session: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write watch session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionResume(t *testing.T) {
+	path := SessionPath(filepath.Join(t.TempDir(), "history.db"))
+	session, err := openSession(path, "/inbox", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := session.plan([]string{"c.mp3", "a.mp3", "b.mp3", "d.mp3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("plan() = %v, want %v", order, want)
+	}
+
+	// Killed while c was being processed
+	session.start("a.mp3")
+	session.finish("a.mp3")
+	session.start("c.mp3")
+
+	// The next run skips a, starts with c, keeps the order of the rest
+	// and adds new files last
+	session, err = openSession(path, "/inbox", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err = session.plan([]string{"a.mp3", "b.mp3", "c.mp3", "d.mp3", "0-new.mp3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c.mp3", "b.mp3", "d.mp3", "0-new.mp3"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("resumed plan() = %v, want %v", order, want)
+	}
+
+	// Another directory or a reset starts over
+	for _, reset := range []bool{false, true} {
+		dir := "/other"
+		if reset {
+			dir = "/inbox"
+		}
+		fresh, err := openSession(path, dir, reset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fresh.Files) != 0 {
+			t.Errorf("openSession(%s, reset %v) continued the session", dir, reset)
+		}
+	}
+
+	session.complete()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("session left after complete: %v", err)
+	}
+}
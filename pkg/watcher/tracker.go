@@ -78,3 +78,16 @@ func (pt *processingTracker) GetLocked() []string {
 
 	return files
 }
+
+// GetLockedSince returns when each currently locked file was locked
+func (pt *processingTracker) GetLockedSince() map[string]time.Time {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	since := make(map[string]time.Time, len(pt.processing))
+	for filepath, startTime := range pt.processing {
+		since[filepath] = startTime
+	}
+
+	return since
+}
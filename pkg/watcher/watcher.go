@@ -11,6 +11,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 
+	"github.com/eternnoir/gollmscribe/pkg/events"
 	"github.com/eternnoir/gollmscribe/pkg/logger"
 	"github.com/eternnoir/gollmscribe/pkg/transcriber"
 )
@@ -24,8 +25,10 @@ type fileWatcher struct {
 	processor   FileProcessor
 	watcher     *fsnotify.Watcher
 	progress    ProgressCallback
+	events      *events.Bus
 	stats       *WatchStats
 	statsLock   sync.RWMutex
+	queue       queueState
 
 	// Event deduplication
 	recentEvents    map[string]time.Time
@@ -35,6 +38,10 @@ type fileWatcher struct {
 	initialProcessing    sync.WaitGroup
 	initialProcessingMap map[string]bool
 	initialProcessingMux sync.Mutex
+	initialQueued        bool // All existing files are queued
+
+	// Backlog of existing files, with ResumeSession
+	session *watchSession
 
 	// Control channels
 	stopCh      chan struct{}
@@ -49,12 +56,42 @@ func NewFileWatcher(config *WatchConfig, trans transcriber.Transcriber) (FileWat
 		return nil, fmt.Errorf("watch directory is required")
 	}
 
+	if len(config.Routes) > 0 && !config.Recursive {
+		logger.WithComponent("watcher").Warn().
+			Int("routes", len(config.Routes)).
+			Msg("Watch routes for subdirectories only take effect with recursive watching")
+	}
+
+	if err := config.Retention.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Export.Validate(); err != nil {
+		return nil, err
+	}
+	if !ValidHistoryLocked(config.HistoryLocked) {
+		return nil, fmt.Errorf("unknown history locked mode %q (use %s, %s or %s)",
+			config.HistoryLocked, HistoryLockedFail, HistoryLockedReadOnly, HistoryLockedInstance)
+	}
+
 	// Create processing history
-	history, err := NewProcessingHistory(config.HistoryDB)
+	history, err := openWatchHistory(config)
 	if err != nil {
+		if IsHistoryLocked(err) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create processing history: %w", err)
 	}
 
+	var session *watchSession
+	if config.ResumeSession {
+		watchDir, _ := filepath.Abs(config.WatchDir)
+		session, err = openSession(SessionPath(config.HistoryDB), watchDir, config.ResetSession)
+		if err != nil {
+			_ = history.Close()
+			return nil, err
+		}
+	}
+
 	// Create fsnotify watcher
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -70,7 +107,9 @@ func NewFileWatcher(config *WatchConfig, trans transcriber.Transcriber) (FileWat
 		transcriber:          trans,
 		tracker:              tracker,
 		history:              history,
+		session:              session,
 		watcher:              watcher,
+		events:               events.NewBus(),
 		recentEvents:         make(map[string]time.Time),
 		initialProcessingMap: make(map[string]bool),
 		stopCh:               make(chan struct{}),
@@ -111,6 +150,18 @@ func (fw *fileWatcher) Start(ctx context.Context) error {
 	fw.wg.Add(1)
 	go fw.cleanupRoutine()
 
+	// Start retention routine
+	if fw.config.Retention.Enabled() {
+		fw.wg.Add(1)
+		go fw.retentionRoutine()
+	}
+
+	// Start history export routine
+	if fw.config.Export.Enabled() {
+		fw.wg.Add(1)
+		go fw.exportRoutine(ctx)
+	}
+
 	// Clean up stale processing markers first
 	log.Info().Msg("Cleaning up stale processing markers")
 	if err := fw.cleanupStaleMarkers(); err != nil {
@@ -162,6 +213,9 @@ func (fw *fileWatcher) Stop() error {
 		log.Warn().Err(err).Msg("Error closing history database")
 	}
 
+	// Close event subscriptions
+	fw.events.Close()
+
 	log.Info().Msg("File watcher stopped")
 	return nil
 }
@@ -187,6 +241,11 @@ func (fw *fileWatcher) WaitForInitialProcessing() *sync.WaitGroup {
 	return &fw.initialProcessing
 }
 
+// Events returns the bus on which file lifecycle events are published
+func (fw *fileWatcher) Events() *events.Bus {
+	return fw.events
+}
+
 // addWatchDir adds a directory to watch
 func (fw *fileWatcher) addWatchDir(dir string) error {
 	// Add the directory
@@ -215,10 +274,12 @@ func (fw *fileWatcher) addWatchDir(dir string) error {
 	return nil
 }
 
-// processExistingFiles processes files that already exist in the watch directory
+// processExistingFiles processes files that already exist in the watch
+// directory, in the order of the session if there is one
 func (fw *fileWatcher) processExistingFiles() error {
 	log := logger.WithComponent("watcher")
 
+	var found []string
 	err := filepath.Walk(fw.config.WatchDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -235,30 +296,57 @@ func (fw *fileWatcher) processExistingFiles() error {
 
 		// Check if file can be processed
 		if fw.processor.CanProcess(path) {
-			log.Debug().Str("file", path).Msg("Queueing existing file")
+			found = append(found, path)
+		}
 
-			// Add to initial processing tracking
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if fw.session != nil {
+		if found, err = fw.session.plan(found); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range found {
+		log.Debug().Str("file", path).Msg("Queueing existing file")
+
+		// Add to initial processing tracking
+		fw.initialProcessingMux.Lock()
+		fw.initialProcessingMap[path] = true
+		fw.initialProcessing.Add(1)
+		fw.initialProcessingMux.Unlock()
+
+		fw.queue.add(path)
+		select {
+		case fw.workerQueue <- path:
+		case <-fw.stopCh:
+			// Clean up if we're stopping
+			fw.queue.remove(path)
 			fw.initialProcessingMux.Lock()
-			fw.initialProcessingMap[path] = true
-			fw.initialProcessing.Add(1)
+			delete(fw.initialProcessingMap, path)
+			fw.initialProcessing.Done()
 			fw.initialProcessingMux.Unlock()
-
-			select {
-			case fw.workerQueue <- path:
-			case <-fw.stopCh:
-				// Clean up if we're stopping
-				fw.initialProcessingMux.Lock()
-				delete(fw.initialProcessingMap, path)
-				fw.initialProcessing.Done()
-				fw.initialProcessingMux.Unlock()
-				return fmt.Errorf("watcher stopped")
-			}
+			return fmt.Errorf("watcher stopped")
 		}
+	}
 
-		return nil
-	})
+	fw.initialProcessingMux.Lock()
+	fw.initialQueued = true
+	fw.completeInitialProcessing()
+	fw.initialProcessingMux.Unlock()
+	return nil
+}
 
-	return err
+// completeInitialProcessing ends the session once every existing file is
+// processed. The caller holds initialProcessingMux.
+func (fw *fileWatcher) completeInitialProcessing() {
+	if fw.initialQueued && len(fw.initialProcessingMap) == 0 {
+		fw.session.complete()
+	}
 }
 
 // watchLoop is the main watch loop
@@ -338,6 +426,7 @@ func (fw *fileWatcher) periodicScan() {
 
 // queueFile queues a file for processing
 func (fw *fileWatcher) queueFile(filepath string) {
+	fw.queue.add(filepath)
 	select {
 	case fw.workerQueue <- filepath:
 		fw.reportProgress(&ProgressEvent{
@@ -348,6 +437,7 @@ func (fw *fileWatcher) queueFile(filepath string) {
 		})
 	default:
 		// Queue is full, skip this file for now
+		fw.queue.remove(filepath)
 		logger.WithComponent("watcher").
 			Warn().
 			Str("file", filepath).
@@ -361,6 +451,7 @@ func (fw *fileWatcher) processWorker(ctx context.Context) {
 	log := logger.WithComponent("worker")
 
 	for filepath := range fw.workerQueue {
+		fw.queue.remove(filepath)
 		select {
 		case <-ctx.Done():
 			return
@@ -368,17 +459,30 @@ func (fw *fileWatcher) processWorker(ctx context.Context) {
 			return
 		default:
 			log.Debug().Str("file", filepath).Msg("Processing file")
+			fw.initialProcessingMux.Lock()
+			initial := fw.initialProcessingMap[filepath]
+			fw.initialProcessingMux.Unlock()
+			if initial {
+				fw.session.start(filepath)
+			}
 
 			// Process the file
 			if err := fw.processor.ProcessFile(ctx, filepath); err != nil {
 				log.Error().Err(err).Str("file", filepath).Msg("Failed to process file")
 			}
 
+			// A file interrupted by shutdown stays in flight in the session
+			if ctx.Err() != nil {
+				return
+			}
+
 			// Mark this file as done from initial processing (if it was part of it)
 			fw.initialProcessingMux.Lock()
 			if fw.initialProcessingMap[filepath] {
+				fw.session.finish(filepath)
 				delete(fw.initialProcessingMap, filepath)
 				fw.initialProcessing.Done()
+				fw.completeInitialProcessing()
 			}
 			fw.initialProcessingMux.Unlock()
 		}
@@ -411,6 +515,77 @@ func (fw *fileWatcher) cleanupRoutine() {
 	}
 }
 
+// retentionRoutine applies the retention policy on start and then periodically
+func (fw *fileWatcher) retentionRoutine() {
+	defer fw.wg.Done()
+
+	interval := fw.config.Retention.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		entries, err := ApplyRetention(fw.history, fw.config.Retention, time.Now())
+		if err != nil {
+			logger.WithComponent("watcher").Warn().Err(err).Msg("Failed to apply retention policy")
+		} else if len(entries) > 0 {
+			logger.WithComponent("watcher").Info().
+				Int("files", len(entries)).
+				Bool("dry_run", fw.config.Retention.DryRun).
+				Msg("Applied retention policy")
+		}
+
+		select {
+		case <-fw.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exportRoutine exports the history on start and then periodically
+func (fw *fileWatcher) exportRoutine(ctx context.Context) {
+	defer fw.wg.Done()
+
+	// Stopping the watcher aborts an export in flight, e.g. a slow POST
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-fw.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	interval := fw.config.Export.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := ExportHistory(ctx, fw.history, fw.config.Export, time.Now())
+		if err != nil {
+			logger.WithComponent("watcher").Warn().Err(err).Msg("Failed to export history")
+		} else {
+			logger.WithComponent("watcher").Debug().
+				Int("processed", len(report.Processed)).
+				Int("failed", len(report.Failed)).
+				Msg("Exported history")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // handleProgressEvent handles progress events from the processor
 func (fw *fileWatcher) handleProgressEvent(event *ProgressEvent) {
 	// Update stats
@@ -425,6 +600,10 @@ func (fw *fileWatcher) handleProgressEvent(event *ProgressEvent) {
 	}
 	fw.statsLock.Unlock()
 
+	if event.Type == "completed" || event.Type == "failed" {
+		fw.recordFinished(event)
+	}
+
 	// Forward to external callback
 	fw.reportProgress(event)
 }
@@ -512,9 +691,27 @@ func (fw *fileWatcher) cleanupRecentEvents() {
 	}
 }
 
-// reportProgress reports progress if callback is set
+// reportProgress reports progress if callback is set and publishes the event on the bus
 func (fw *fileWatcher) reportProgress(event *ProgressEvent) {
+	fw.events.Publish(events.Event{
+		Type:      fileEventTypes[event.Type],
+		FilePath:  event.FilePath,
+		ChunkID:   -1,
+		Message:   event.Message,
+		Error:     event.Error,
+		Timestamp: event.Timestamp,
+	})
+
 	if fw.progress != nil {
 		fw.progress(event)
 	}
 }
+
+// fileEventTypes maps progress event types to bus event types
+var fileEventTypes = map[string]events.Type{
+	"found":      events.FileFound,
+	"processing": events.FileProcessing,
+	"completed":  events.FileCompleted,
+	"failed":     events.FileFailed,
+	"skipped":    events.FileSkipped,
+}